@@ -64,15 +64,14 @@ func New(opts PKCS11Opts, keyStore bccsp.KeyStore) (bccsp.BCCSP, error) {
 	lib := opts.Library
 	pin := opts.Pin
 	label := opts.Label
-	ctx, slot, session, err := loadLib(lib, pin, label)
+	ref, err := acquireContext(lib, pin, label)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed initializing PKCS11 library %s %s",
 			lib, label)
 	}
 
-	sessions := make(chan pkcs11.SessionHandle, sessionCacheSize)
-	csp := &impl{swCSP, conf, keyStore, ctx, sessions, slot, lib, opts.Sensitive, opts.SoftVerify}
-	csp.returnSession(*session)
+	shared := ref.shared
+	csp := &impl{swCSP, conf, keyStore, shared.ctx, shared.sessions, shared.slot, lib, opts.Sensitive, opts.SoftVerify, ref}
 	return csp, nil
 }
 
@@ -89,6 +88,8 @@ type impl struct {
 	lib          string
 	noPrivImport bool
 	softVerify   bool
+
+	ref *contextRef
 }
 
 // KeyGen generates a key using opts.
@@ -0,0 +1,113 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+/*
+Notice: This file has been modified for Hyperledger Fabric SDK Go usage.
+Please review third_party pinning scripts and patches for more details.
+*/
+
+package pkcs11
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+// sharedContext is the context of a PKCS11 library, logged in to a token, along with its pool of sessions.
+// The context is shared by all the BCCSP instances of the token in the process (e.g. those of several SDK
+// instances), so that the sessions opened on the token are bounded by a single pool rather than by a pool per
+// instance. The pool is a buffered channel, which is safe for concurrent use.
+type sharedContext struct {
+	key      string
+	pinHash  []byte
+	ctx      *pkcs11.Ctx
+	slot     uint
+	sessions chan pkcs11.SessionHandle
+	refs     int
+}
+
+// contextRef is the reference of a BCCSP instance to the shared context of its token
+type contextRef struct {
+	shared *sharedContext
+	once   sync.Once
+}
+
+var contexts = struct {
+	sync.Mutex
+	byKey map[string]*sharedContext
+}{byKey: make(map[string]*sharedContext)}
+
+// acquireContext returns a new reference to the context of the token with the given label, loading the library and
+// logging in to the token if there's no context for the token yet. The context is logged in with the PIN of the
+// first reference, so an error is returned if the PIN differs from it rather than sharing the logged-in session.
+func acquireContext(lib, pin, label string) (*contextRef, error) {
+	contexts.Lock()
+	defer contexts.Unlock()
+
+	key := lib + "|" + label
+	pinHash := sha256.Sum256([]byte(pin))
+	if shared, ok := contexts.byKey[key]; ok {
+		if subtle.ConstantTimeCompare(shared.pinHash, pinHash[:]) != 1 {
+			return nil, errors.Errorf("PIN doesn't match the PIN of the pkcs11 context of token %s", label)
+		}
+		shared.refs++
+		logger.Debugf("Sharing pkcs11 context of token %s on slot %d, references: %d\n", label, shared.slot, shared.refs)
+		return &contextRef{shared: shared}, nil
+	}
+
+	ctx, slot, session, err := loadLib(lib, pin, label)
+	if err != nil {
+		return nil, err
+	}
+	shared := &sharedContext{
+		key:      key,
+		pinHash:  pinHash[:],
+		ctx:      ctx,
+		slot:     slot,
+		sessions: make(chan pkcs11.SessionHandle, sessionCacheSize),
+		refs:     1,
+	}
+	shared.sessions <- *session
+	contexts.byKey[key] = shared
+	return &contextRef{shared: shared}, nil
+}
+
+// release releases the reference. The sessions of the pool are closed and the library is finalized when the last
+// reference to the context is released.
+func (r *contextRef) release() {
+	r.once.Do(func() {
+		contexts.Lock()
+		defer contexts.Unlock()
+
+		shared := r.shared
+		shared.refs--
+		if shared.refs > 0 {
+			return
+		}
+		delete(contexts.byKey, shared.key)
+
+		for {
+			select {
+			case session := <-shared.sessions:
+				shared.ctx.CloseSession(session)
+			default:
+				logger.Debugf("Closing pkcs11 context on slot %d\n", shared.slot)
+				shared.ctx.Finalize()
+				shared.ctx.Destroy()
+				return
+			}
+		}
+	})
+}
+
+// Close releases the reference of the BCCSP to the shared context of its token; the context is closed along with
+// the last BCCSP of the token. The BCCSP must not be used after it's closed.
+func (csp *impl) Close() {
+	csp.ref.release()
+}
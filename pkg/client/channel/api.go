@@ -8,11 +8,15 @@ package channel
 
 import (
 	reqContext "context"
+	"crypto/sha256"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
@@ -20,11 +24,40 @@ import (
 
 // opts allows the user to specify more advanced options
 type requestOptions struct {
-	Targets       []fab.Peer // targets
-	TargetFilter  fab.TargetFilter
-	Retry         retry.Opts
-	Timeouts      map[fab.TimeoutType]time.Duration //timeout options for channel client operations
-	ParentContext reqContext.Context                //parent grpc context for channel client operations (query, execute, invokehandler)
+	Targets                 []fab.Peer // targets
+	TargetFilter            fab.TargetFilter
+	TargetSorter            fab.TargetSorter //orders the targets by preference
+	ChaincodeInterests      []fab.CCInterest //chaincodes invoked by the transaction in addition to the chaincode of the request
+	OrgAffinity             string           //MSP whose peers are the only ones to endorse the request and deliver its commit event
+	Retry                   retry.Opts
+	RetryableCodes          map[status.Group][]status.Code    //retryable codes overriding those of the retry options
+	AddedRetryableCodes     map[status.Group][]status.Code    //codes retried in addition to the retryable codes
+	ExcludedRetryableCodes  map[status.Group][]status.Code    //codes not retried even if they're retryable codes
+	MaxElapsedTime          time.Duration                     //bound on the time spent on the request and its retries
+	BeforeRetry             retry.BeforeRetryHandler          //invoked with the error of the failed attempt before each retry
+	AfterAttempt            func(attempt int, err error)      //invoked with the outcome of each attempt
+	CorrelationMetadata     map[string]string                 //correlation metadata passed to the post-commit hook
+	CorrelationID           string                            //ID carried by the request context to correlate the logs of the request
+	BlockCommitWait         bool                              //wait for the committing block event instead of the TxStatus event
+	NoCommitWait            bool                              //return once the orderer accepted the transaction
+	EventServiceUnavailable invoke.CommitWaitMode             //what Execute does if the event service isn't connected
+	CCEventCapture          string                            //name of the chaincode event to capture from the committing block
+	MaxBlockHeightSelection bool                              //prefer the peers with the highest block height for queries
+	BypassCache             bool                              //query the peers even if the query cache holds a response
+	BypassGreylist          bool                              //select greylisted peers for the request
+	Orderer                 string                            //URL of the orderer to send the transaction to first
+	OrdererFilter           func(fab.Orderer) bool            //selects the orderers the transaction may be sent to
+	ShuffleOrderers         bool                              //attempt the orderers in random order rather than in the order of the channel config
+	OrdererComparator       func(o1, o2 fab.Orderer) bool     //returns true if o1 is attempted before o2
+	EndorsementThreshold    int                               //minimum number of matching endorsements, 0 requires all targets to endorse
+	EndorsementComparator   invoke.EndorsementComparator      //compares the endorsements, byte-exact if nil
+	EndorsementValidator    invoke.EndorsementValidator       //selects the endorsements submitted, all matching if nil
+	QueryQuorum             int                               //number of peers which must return identical query payloads
+	TLSPins                 map[string][]byte                 //SHA-256 fingerprints of the TLS certificates pinned per target address
+	ProgressNotifier        chan<- invoke.TxProgress          //receives the progress of the transaction through its lifecycle
+	IdempotencyKey          string                            //client-side key guarding Execute against duplicate submissions
+	Timeouts                map[fab.TimeoutType]time.Duration //timeout options for channel client operations
+	ParentContext           reqContext.Context                //parent grpc context for channel client operations (query, execute, invokehandler)
 }
 
 // RequestOption func for each Opts argument
@@ -44,8 +77,17 @@ type Response struct {
 	Responses        []*fab.TransactionProposalResponse
 	TransactionID    fab.TransactionID
 	TxValidationCode pb.TxValidationCode
+	BlockNumber      uint64       // block which committed the transaction, 0 if the commit wasn't observed (e.g. timeout)
+	CommitObserved   bool         // whether the commit of the transaction was observed, i.e. BlockNumber is set
+	NoCommitWait     bool         // whether Execute returned without waiting for the commit (see WithNoCommitWait)
+	CCEvent          *fab.CCEvent // chaincode event captured from the committing block (see WithCCEventCapture)
 	ChaincodeStatus  int32
 	Payload          []byte
+	FailedEndorsers  map[string]error // peers (by URL) that failed to endorse when an endorsement threshold is used
+	SelectedTargets  []string         // peers (by URL) selected for the request after filtering, set even if the request fails
+	Orderer          string           // orderer (by URL) which accepted the transaction
+	FailedOrderers   map[string]error // orderers (by URL) which were attempted but failed to accept the transaction
+	OrgAffinity      string           // MSP the transaction was pinned to (see WithOrgAffinity)
 }
 
 //WithTargets encapsulates ProposalProcessors to Option
@@ -83,6 +125,57 @@ func WithTargetURLs(urls ...string) RequestOption {
 	}
 }
 
+// WithTargetsFromOrgConfig targets the peers of the given organizations, as configured in the organizations
+// section of the endpoint config, even if the channel section of the config omits them (e.g. while an organization
+// is being added to the channel and the config files lag behind the channel membership). A warning is logged for
+// each target which isn't configured for the channel. The peers still have to accept the proposal.
+func WithTargetsFromOrgConfig(orgNames ...string) RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		endpointConfig := ctx.EndpointConfig()
+
+		var channelID string
+		channelPeers := make(map[string]bool)
+		if chCtx, ok := ctx.(context.Channel); ok {
+			channelID = chCtx.ChannelID()
+			peers, err := endpointConfig.ChannelPeers(channelID)
+			if err != nil {
+				return errors.WithMessage(err, "unable to load channel peers config")
+			}
+			for _, p := range peers {
+				channelPeers[p.URL] = true
+			}
+		}
+
+		var targets []fab.Peer
+		for _, org := range orgNames {
+			mspID, err := endpointConfig.MSPID(org)
+			if err != nil {
+				return errors.WithMessage(err, "unable to get MSP ID of organization "+org)
+			}
+			peersConfig, err := endpointConfig.PeersConfig(org)
+			if err != nil {
+				return errors.WithMessage(err, "unable to load peers config of organization "+org)
+			}
+			if len(peersConfig) == 0 {
+				return errors.Errorf("no peers configured for organization [%s]", org)
+			}
+
+			for _, peerConfig := range peersConfig {
+				if channelID != "" && !channelPeers[peerConfig.URL] {
+					logger.Warnf("Peer [%s] of organization [%s] is not configured for channel [%s], targeting it from the organization config", peerConfig.URL, org, channelID)
+				}
+				peer, err := ctx.InfraProvider().CreatePeerFromConfig(&fab.NetworkPeer{PeerConfig: peerConfig, MSPID: mspID})
+				if err != nil {
+					return errors.WithMessage(err, "creating peer from config failed")
+				}
+				targets = append(targets, peer)
+			}
+		}
+
+		return WithTargets(targets...)(ctx, opts)
+	}
+}
+
 // WithTargetFilter specifies a per-request target peer-filter
 func WithTargetFilter(filter fab.TargetFilter) RequestOption {
 	return func(ctx context.Client, o *requestOptions) error {
@@ -91,7 +184,51 @@ func WithTargetFilter(filter fab.TargetFilter) RequestOption {
 	}
 }
 
+// WithTargetSorter orders the targets of the request by preference, after they're filtered (see selection.NewBlockHeightSorter).
+// The sorter is passed to the selection service, or applied to the targets given with WithTargets. With
+// WithMaxBlockHeightSelection the query is sent to the peer preferred by the sorter, falling back to the next
+// peers if the query fails, and the ledger height of peers which don't report it is queried.
+func WithTargetSorter(sorter fab.TargetSorter) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.TargetSorter = sorter
+		return nil
+	}
+}
+
+// WithChaincodeInterest declares the chaincodes invoked by the transaction in addition to the chaincode of the
+// request, e.g. through chaincode-to-chaincode calls, along with the private data collections they access. The
+// endorsers are then selected so that they satisfy the endorsement policies of all of the chaincodes.
+// The interests are ignored if the targets of the request are given.
+func WithChaincodeInterest(interests ...fab.CCInterest) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		for _, interest := range interests {
+			if interest.ID == "" {
+				return errors.New("chaincode ID is required in chaincode interest")
+			}
+		}
+		o.ChaincodeInterests = append(o.ChaincodeInterests, interests...)
+		return nil
+	}
+}
+
+// WithOrgAffinity pins the request to the peers of the given MSP, e.g. for data residency: the endorsers are
+// only selected among the peers of the MSP, the targets of the request must belong to it and the commit event of
+// the transaction is received from one of its peers (the orderer is exempt). A transaction fails before it's
+// endorsed if the endorsement policy of the chaincode can't be satisfied by the MSP alone, naming the missing MSPs.
+// The MSP is recorded in the response.
+func WithOrgAffinity(mspID string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if mspID == "" {
+			return errors.New("MSP ID is required for org affinity")
+		}
+		o.OrgAffinity = mspID
+		return nil
+	}
+}
+
 // WithRetry option to configure retries
+// Use retry.WithExponentialBackoff for exponential backoff with jitter, which spreads out the retries of many clients,
+// e.g. WithRetry(retry.WithExponentialBackoff(retry.DefaultChClientOpts, ...)).
 func WithRetry(retryOpt retry.Opts) RequestOption {
 	return func(ctx context.Client, o *requestOptions) error {
 		o.Retry = retryOpt
@@ -99,6 +236,264 @@ func WithRetry(retryOpt retry.Opts) RequestOption {
 	}
 }
 
+// WithRetryableCodes overrides the status codes, mapped by group, that warrant a retry
+// for this request. The retry attempts and backoff are still configured with WithRetry.
+func WithRetryableCodes(retryableCodes map[status.Group][]status.Code) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.RetryableCodes = retryableCodes
+		return nil
+	}
+}
+
+// WithAdditionalRetryableCodes retries the request on the given status codes of the group in addition to the
+// retryable codes of the retry options (or those of WithRetryableCodes), e.g. on an application error code
+// returned by the chaincode (status.EndorserServerStatus group). The retry options themselves are left unchanged.
+func WithAdditionalRetryableCodes(group status.Group, codes ...status.Code) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if o.AddedRetryableCodes == nil {
+			o.AddedRetryableCodes = make(map[status.Group][]status.Code)
+		}
+		o.AddedRetryableCodes[group] = append(o.AddedRetryableCodes[group], codes...)
+		return nil
+	}
+}
+
+// WithoutRetryableCodes doesn't retry the request on the given status codes of the group even if they're
+// retryable codes of the retry options, e.g. status.EndorsementMismatch of the status.EndorserClientStatus group.
+// Exclusions take precedence over WithAdditionalRetryableCodes.
+func WithoutRetryableCodes(group status.Group, codes ...status.Code) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if o.ExcludedRetryableCodes == nil {
+			o.ExcludedRetryableCodes = make(map[status.Group][]status.Code)
+		}
+		o.ExcludedRetryableCodes[group] = append(o.ExcludedRetryableCodes[group], codes...)
+		return nil
+	}
+}
+
+// WithMaxElapsedTime stops retrying the request once the time spent on it, including the backoff before the
+// next retry, would exceed maxElapsedTime; the error of the last attempt is returned. It overrides the
+// MaxElapsedTime of the retry options (see WithRetry), whose attempts still apply.
+func WithMaxElapsedTime(maxElapsedTime time.Duration) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if maxElapsedTime <= 0 {
+			return errors.Errorf("invalid max elapsed time [%s]", maxElapsedTime)
+		}
+		o.MaxElapsedTime = maxElapsedTime
+		return nil
+	}
+}
+
+// WithBeforeRetry specifies a function which is invoked with the error of the failed attempt before each retry
+// of the request, e.g. to log or count retries. It's invoked after the peers which failed are greylisted.
+func WithBeforeRetry(beforeRetry retry.BeforeRetryHandler) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.BeforeRetry = beforeRetry
+		return nil
+	}
+}
+
+// WithAfterAttempt specifies a function which is invoked after each attempt of the request with the attempt
+// number (starting at 1) and the error of the attempt, which is nil if the attempt succeeded.
+func WithAfterAttempt(afterAttempt func(attempt int, err error)) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.AfterAttempt = afterAttempt
+		return nil
+	}
+}
+
+// WithEndorsementThreshold sends the proposal to all targets but completes the endorsement
+// as soon as min matching successful responses are received. Failures from the remaining
+// targets are tolerated and reported in Response.FailedEndorsers.
+func WithEndorsementThreshold(min int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if min < 1 {
+			return errors.Errorf("invalid endorsement threshold [%d]", min)
+		}
+		o.EndorsementThreshold = min
+		return nil
+	}
+}
+
+// WithEndorsementComparator specifies how the endorsements of the targets are compared when they're validated
+// (and when an endorsement threshold is used), e.g. invoke.PayloadOnly to ignore chaincode events and read sets.
+// The endorsements are compared byte for byte (invoke.ByteExact) by default.
+func WithEndorsementComparator(comparator invoke.EndorsementComparator) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if comparator == nil {
+			return errors.New("endorsement comparator is nil")
+		}
+		o.EndorsementComparator = comparator
+		return nil
+	}
+}
+
+// WithEndorsementValidator specifies how the endorsements collected from the targets are validated before the
+// transaction is submitted, e.g. invoke.MajorityMatch to submit the write set endorsed by most organizations even if
+// another endorser disagrees. The targets which fail to endorse are then tolerated and reported in
+// Response.FailedEndorsers, leaving it to the validator to decide whether the endorsements received suffice.
+// By default all endorsements have to be successful and to match (see WithEndorsementComparator).
+func WithEndorsementValidator(validator invoke.EndorsementValidator) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if validator == nil {
+			return errors.New("endorsement validator is nil")
+		}
+		o.EndorsementValidator = validator
+		return nil
+	}
+}
+
+// WithQueryQuorum queries at least n peers and only succeeds once n of them returned identical response payloads.
+// The targets (selected unless given) are expanded with the peers of the channel if there are fewer than n, and
+// further peers are queried as long as failed or divergent responses keep the quorum out of reach. Otherwise a
+// status.QuorumNotReached error is returned whose details hold the SHA-256 hashes of the payloads per peer; the
+// errors of the peers which failed are reported in Response.FailedEndorsers.
+// The option applies to queries only.
+func WithQueryQuorum(n int) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if n < 1 {
+			return errors.Errorf("invalid query quorum [%d]", n)
+		}
+		o.QueryQuorum = n
+		return nil
+	}
+}
+
+// WithCorrelationMetadata attaches application metadata to the request which is passed
+// to the post-commit hook once the transaction's commit is observed. The metadata is merged
+// with that of previous options, e.g. default request options.
+func WithCorrelationMetadata(metadata map[string]string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if o.CorrelationMetadata == nil {
+			o.CorrelationMetadata = make(map[string]string)
+		}
+		for key, value := range metadata {
+			o.CorrelationMetadata[key] = value
+		}
+		return nil
+	}
+}
+
+// WithMaxBlockHeightSelection queries the peer with the highest ledger height, falling back to peers
+// at lower heights if the query fails. Block heights are cached briefly, so this doesn't add a round
+// trip to every query. Only applies to Query.
+func WithMaxBlockHeightSelection() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.MaxBlockHeightSelection = true
+		return nil
+	}
+}
+
+// WithCCEventCapture captures the chaincode event with the given name emitted by the executed transaction
+// into the CCEvent field of the Response. Execute then waits for the committing (filtered) block, as with
+// WithBlockCommitWait. If the transaction didn't emit the event, CCEvent is left nil.
+func WithCCEventCapture(eventName string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if eventName == "" {
+			return errors.New("chaincode event name is required")
+		}
+		o.CCEventCapture = eventName
+		return nil
+	}
+}
+
+// WithoutCache queries the peers even if the response is held by the client's query cache.
+// The response of the peers still replaces the cached response. Queries with WithQueryQuorum or
+// WithMaxBlockHeightSelection always bypass the cache.
+func WithoutCache() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.BypassCache = true
+		return nil
+	}
+}
+
+// WithoutGreylist doesn't exclude the peers greylisted after recent failures from the request, e.g. to probe
+// a peer known to have recovered before its greylisting expires. Failures of the request are still greylisted.
+func WithoutGreylist() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.BypassGreylist = true
+		return nil
+	}
+}
+
+// WithOrderer sends the transaction of Execute to the orderer with the given URL, which must be an orderer of the
+// channel. If the orderer is unreachable, the transaction is sent to the other orderers (see WithOrdererFilter and
+// WithOrdererComparator).
+// The orderer which accepted the transaction is set in the response.
+func WithOrderer(url string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if url == "" {
+			return errors.New("orderer URL is required")
+		}
+		o.Orderer = url
+		return nil
+	}
+}
+
+// WithOrdererFilter only sends the transaction of Execute to the orderers of the channel accepted by the filter.
+// The orderer which accepted the transaction is set in the response.
+func WithOrdererFilter(filter func(fab.Orderer) bool) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.OrdererFilter = filter
+		return nil
+	}
+}
+
+// WithOrdererShuffle attempts the orderers in random order when sending the transaction of Execute, rather than in
+// the order in which they're configured for the channel, spreading the load over the orderers.
+func WithOrdererShuffle() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ShuffleOrderers = true
+		return nil
+	}
+}
+
+// WithOrdererComparator sets the order in which the orderers are attempted when sending the transaction of Execute:
+// less returns true if orderer o1 is attempted before orderer o2. The orderer given with WithOrderer is attempted first.
+func WithOrdererComparator(less func(o1, o2 fab.Orderer) bool) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if less == nil {
+			return errors.New("orderer comparator is required")
+		}
+		o.OrdererComparator = less
+		return nil
+	}
+}
+
+// WithBlockCommitWait makes Execute wait for the (filtered) block containing the transaction rather
+// than for its TxStatus event, for peers on which TxStatus events lag behind block delivery.
+func WithBlockCommitWait() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.BlockCommitWait = true
+		return nil
+	}
+}
+
+// WithNoCommitWait makes Execute return as soon as the orderer accepted the transaction, without registering for
+// or waiting for its commit, e.g. for high-volume idempotent writes which are reconciled later through events.
+// The response then has NoCommitWait set and no validation code nor block number. The transaction is still
+// notified to the post-commit hook, if any, once its commit is observed.
+// It can't be combined with WithBlockCommitWait or WithCCEventCapture.
+func WithNoCommitWait() RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.NoCommitWait = true
+		return nil
+	}
+}
+
+// WithEventServiceUnavailableMode determines what Execute does when the event service which would deliver the
+// commit event of the transaction isn't connected once the transaction is endorsed, e.g. while it reconnects to
+// the peer: invoke.WaitForCommit (the default) broadcasts the transaction and waits for its commit until the timeout,
+// invoke.FailFast fails with the EventServiceUnavailable status without broadcasting it, and
+// invoke.ProceedWithoutCommitWait broadcasts it and returns its ID with NoCommitWait set in the response.
+// The commit of a transaction which was broadcast is still notified to the post-commit hook, if any.
+func WithEventServiceUnavailableMode(mode invoke.CommitWaitMode) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.EventServiceUnavailable = mode
+		return nil
+	}
+}
+
 //WithTimeout encapsulates key value pairs of timeout type, timeout duration to Options
 func WithTimeout(timeoutType fab.TimeoutType, timeout time.Duration) RequestOption {
 	return func(ctx context.Client, o *requestOptions) error {
@@ -110,6 +505,69 @@ func WithTimeout(timeoutType fab.TimeoutType, timeout time.Duration) RequestOpti
 	}
 }
 
+// WithCorrelationID attaches the given correlation ID, e.g. the ID of a trace spanning several components, to the
+// request context of the request, from which it can be retrieved with RequestCorrelationID of pkg/context, e.g. by gRPC
+// interceptors. The ID is logged along with the proposals sent to the endorsers.
+// An ID attached to the parent context of the request (see WithParentContext) is used if this option isn't set.
+func WithCorrelationID(correlationID string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if correlationID == "" {
+			return errors.New("correlation ID is empty")
+		}
+		o.CorrelationID = correlationID
+		return nil
+	}
+}
+
+// WithTargetTLSPin pins the TLS certificate of the given target (URL or host:port) for the request:
+// the proposal is sent to the target over a new connection which is established only if the SHA-256
+// fingerprint of the leaf certificate presented by the target matches the given fingerprint.
+// Otherwise the request fails with a ConnectionFailed status. The certificate is still verified against
+// the configured TLS CA certs as well.
+func WithTargetTLSPin(target string, fingerprint []byte) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if len(fingerprint) != sha256.Size {
+			return errors.Errorf("invalid TLS pin for target [%s]: expecting a SHA-256 fingerprint", target)
+		}
+		if o.TLSPins == nil {
+			o.TLSPins = make(map[string][]byte)
+		}
+		o.TLSPins[endpoint.ToAddress(target)] = fingerprint
+		return nil
+	}
+}
+
+// WithProgressNotifier reports the progress of the request through the transaction lifecycle on the given channel:
+// the selection of the targets, the endorsement, the broadcast to the orderer and the commit (the latter two for
+// Execute only). The stages are reported again if the request is retried. Notifications are sent without blocking,
+// i.e. they are dropped if the channel isn't ready to receive them, so a buffered channel should be used. The channel
+// is owned by the caller and is never closed by the SDK.
+func WithProgressNotifier(notifier chan<- invoke.TxProgress) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		o.ProgressNotifier = notifier
+		return nil
+	}
+}
+
+// WithIdempotencyKey guards Execute against submitting the same transaction twice, e.g. because of a retry in
+// the application. The client records the transactions submitted with an idempotency key (see
+// WithIdempotencyLimits and WithIdempotencyStore) and another Execute with the same key returns the recorded
+// response if the transaction was committed, or fails with an AlreadySubmitted status if its outcome isn't known
+// yet. The ID of the transaction is recorded before it's sent to the orderer, so the commit of a transaction
+// which isn't pending in the client, e.g. which was submitted before a restart, is looked up in the ledger
+// (qscc GetBlockByTxID). A transaction which failed before it was sent to the orderer, or which was invalidated,
+// may be submitted again with the same key.
+// The key is opaque to the SDK and is never sent to the network.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(ctx context.Client, o *requestOptions) error {
+		if key == "" {
+			return errors.New("idempotency key is required")
+		}
+		o.IdempotencyKey = key
+		return nil
+	}
+}
+
 //WithParentContext encapsulates grpc context parent to Options
 func WithParentContext(parentContext reqContext.Context) RequestOption {
 	return func(ctx context.Client, o *requestOptions) error {
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	"crypto/sha256"
+	"strings"
 	"testing"
 
 	"time"
@@ -72,6 +74,51 @@ func TestWithTargetURLsValid(t *testing.T) {
 	assert.Equal(t, npConfig1.MSPID, opts.Targets[0].MSPID(), "", "Wrong MSP")
 }
 
+func TestWithTargetsFromOrgConfig(t *testing.T) {
+	ctx := setupMockTestContext("test", "Org1MSP")
+	ctx.SetEndpointConfig(&orgPeersConfig{
+		MockConfig: &fcmocks.MockConfig{},
+		peers: map[string][]fab.PeerConfig{
+			"org1": {{URL: "peer0.org1.example.com:7051"}},
+			"org2": {{URL: "peer0.org2.example.com:7051"}, {URL: "peer1.org2.example.com:7051"}},
+		},
+		channelPeers: []fab.ChannelPeer{{NetworkPeer: fab.NetworkPeer{PeerConfig: fab.PeerConfig{URL: "peer0.org1.example.com:7051"}}}},
+	})
+	chCtx := fcmocks.NewMockChannelContext(ctx, "mychannel")
+
+	// The peers of org2 aren't configured for the channel yet
+	opts := requestOptions{}
+	err := WithTargetsFromOrgConfig("org1", "org2")(chCtx, &opts)
+	assert.Nil(t, err)
+	if assert.Len(t, opts.Targets, 3) {
+		assert.Equal(t, "peer0.org1.example.com:7051", opts.Targets[0].URL())
+		assert.Equal(t, "Org1MSP", opts.Targets[0].MSPID())
+		assert.Equal(t, "peer1.org2.example.com:7051", opts.Targets[2].URL())
+		assert.Equal(t, "Org2MSP", opts.Targets[2].MSPID())
+	}
+
+	err = WithTargetsFromOrgConfig("org3")(chCtx, &requestOptions{})
+	assert.NotNil(t, err, "Expected error for organization without peers")
+}
+
+type orgPeersConfig struct {
+	*fcmocks.MockConfig
+	peers        map[string][]fab.PeerConfig
+	channelPeers []fab.ChannelPeer
+}
+
+func (c *orgPeersConfig) MSPID(org string) (string, error) {
+	return strings.Title(org) + "MSP", nil
+}
+
+func (c *orgPeersConfig) PeersConfig(org string) ([]fab.PeerConfig, error) {
+	return c.peers[org], nil
+}
+
+func (c *orgPeersConfig) ChannelPeers(name string) ([]fab.ChannelPeer, error) {
+	return c.channelPeers, nil
+}
+
 func setupMockTestContext(username string, mspID string) *fcmocks.MockContext {
 	user := mspmocks.NewMockSigningIdentity(username, mspID)
 	ctx := fcmocks.NewMockContext(user)
@@ -99,3 +146,56 @@ func TestTimeoutOptions(t *testing.T) {
 	assert.True(t, opts.Timeouts[fab.Query] == 45*time.Second, "timeout value by type didn't match with one supplied")
 
 }
+
+func TestTargetTLSPinOptions(t *testing.T) {
+	opts := requestOptions{}
+
+	err := WithTargetTLSPin("grpcs://peer0.org1.example.com:7051", []byte("not a fingerprint"))(nil, &opts)
+	assert.NotNil(t, err, "Expected error for invalid fingerprint")
+
+	fingerprint := sha256.Sum256([]byte("certificate"))
+	err = WithTargetTLSPin("grpcs://peer0.org1.example.com:7051", fingerprint[:])(nil, &opts)
+	assert.Nil(t, err)
+	err = WithTargetTLSPin("peer1.org1.example.com:7051", fingerprint[:])(nil, &opts)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string][]byte{
+		"peer0.org1.example.com:7051": fingerprint[:],
+		"peer1.org1.example.com:7051": fingerprint[:],
+	}, opts.TLSPins)
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	opts := requestOptions{}
+
+	err := WithCorrelationID("")(nil, &opts)
+	assert.NotNil(t, err, "Expected error for empty correlation ID")
+
+	err = WithCorrelationID("trace-1")(nil, &opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "trace-1", opts.CorrelationID)
+}
+
+func TestWithOrgAffinity(t *testing.T) {
+	ctx := setupMockTestContext("test", "Org1MSP")
+
+	opts := requestOptions{}
+	err := WithOrgAffinity("Org1MSP")(ctx, &opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "Org1MSP", opts.OrgAffinity)
+
+	err = WithOrgAffinity("")(ctx, &opts)
+	assert.NotNil(t, err, "Expected error for org affinity without MSP ID")
+}
+
+func TestWithChaincodeInterest(t *testing.T) {
+	ctx := setupMockTestContext("test", "Org1MSP")
+
+	opts := requestOptions{}
+	err := WithChaincodeInterest(fab.CCInterest{ID: "cc1"}, fab.CCInterest{ID: "cc2", Collections: []string{"coll1"}})(ctx, &opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []fab.CCInterest{{ID: "cc1"}, {ID: "cc2", Collections: []string{"coll1"}}}, opts.ChaincodeInterests)
+
+	err = WithChaincodeInterest(fab.CCInterest{Collections: []string{"coll1"}})(ctx, &opts)
+	assert.NotNil(t, err, "Expected error for chaincode interest without chaincode ID")
+}
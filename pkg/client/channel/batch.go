@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+// queryBatchConcurrency is the maximum number of queries of a batch which are in flight at once
+const queryBatchConcurrency = 10
+
+// BatchError contains the errors of the requests of a batch which failed, in the order of the requests.
+// The errors of the requests which succeeded are nil.
+type BatchError struct {
+	Errors []error
+}
+
+// Error returns the errors of the failed requests along with their index in the batch
+func (e *BatchError) Error() string {
+	var msgs []string
+	for i, err := range e.Errors {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("request %d: %s", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d batched queries failed: %s", len(msgs), len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// QueryBatch queries the chaincode with each of the requests, up to 10 of them concurrently, and returns their
+// responses in the order of the requests. The options apply to each of the requests. Unless targets are given, the
+// endorsers of the chaincodes of the batch are selected once; each request is retried as configured, and the peers
+// greylisted by a failed request are no longer targeted by the requests which follow.
+// If some of the requests fail, a *BatchError is returned along with the responses of the others. Cancelling
+// the parent context of the request (WithParentContext) aborts all the requests of the batch.
+func (cc *Client) QueryBatch(requests []Request, options ...RequestOption) ([]Response, error) {
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "option failed")
+	}
+	cc.addDefaultTimeout(fab.Query, &txnOpts)
+
+	handler := cc.queryHandler(txnOpts)
+	if len(txnOpts.Targets) == 0 {
+		targets, err := cc.selectBatchTargets(requests, txnOpts)
+		if err != nil {
+			return nil, err
+		}
+		txnOpts.Targets = targets
+		handler = &batchTargetsHandler{next: handler}
+	}
+
+	parentContext := txnOpts.ParentContext
+	if parentContext == nil {
+		parentContext = reqContext.Background()
+	}
+	ctx, cancel := reqContext.WithCancel(parentContext)
+	defer cancel()
+	txnOpts.ParentContext = ctx
+
+	responses := make([]Response, len(requests))
+	errs := make([]error, len(requests))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < queryBatchConcurrency && w < len(requests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = errors.Wrap(err, "batch aborted")
+					continue
+				}
+				responses[i], errs[i] = cc.query(handler, requests[i], txnOpts)
+			}
+		}()
+	}
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return responses, &BatchError{Errors: errs}
+		}
+	}
+	return responses, nil
+}
+
+//selectBatchTargets selects the endorsers of the chaincodes of the requests
+func (cc *Client) selectBatchTargets(requests []Request, txnOpts requestOptions) ([]fab.Peer, error) {
+	var ccIDs []string
+	for _, request := range requests {
+		ccIDs = append(ccIDs, request.ChaincodeID)
+	}
+	ccIDs = invoke.ChaincodeIDs(ccIDs, txnOpts.ChaincodeInterests)
+
+	selectionOpts := []options.Opt{selectopts.WithPeerFilter(cc.peerFilter(txnOpts))}
+	if txnOpts.TargetSorter != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithPeerSorter(txnOpts.TargetSorter.Sort))
+	}
+	if len(txnOpts.ChaincodeInterests) > 0 {
+		selectionOpts = append(selectionOpts, selectopts.WithChaincodeInterests(txnOpts.ChaincodeInterests))
+	}
+	targets, err := cc.context.SelectionService().GetEndorsersForChaincode(ccIDs, selectionOpts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get endorsing peers")
+	}
+	return targets, nil
+}
+
+//batchTargetsHandler drops the targets selected for the batch which are refused by the selection filter, e.g.
+//because a previous request of the batch greylisted them, and selects new targets if none is left
+type batchTargetsHandler struct {
+	next invoke.Handler
+}
+
+//Handle filters the targets and invokes the next handler
+func (h *batchTargetsHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	var targets []fab.Peer
+	for _, peer := range requestContext.Opts.Targets {
+		if requestContext.SelectionFilter == nil || requestContext.SelectionFilter(peer) {
+			targets = append(targets, peer)
+		}
+	}
+	requestContext.Opts.Targets = targets
+
+	if len(targets) == 0 {
+		invoke.NewProposalProcessorHandler(h.next).Handle(requestContext, clientContext)
+		return
+	}
+	h.next.Handle(requestContext, clientContext)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBatch(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	var requests []Request
+	for i := 0; i < 25; i++ {
+		requests = append(requests, Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte(fmt.Sprintf("key%d", i))}})
+	}
+
+	responses, err := chClient.QueryBatch(requests)
+	assert.Nil(t, err, "Failed to query batch")
+	assert.Len(t, responses, len(requests))
+	for _, response := range responses {
+		assert.Equal(t, []string{testPeer1.URL()}, response.SelectedTargets)
+	}
+	assert.Equal(t, len(requests), testPeer1.ProcessProposalCalls)
+
+	// The errors are returned in the order of the requests
+	requests[3].Fcn = ""
+	responses, err = chClient.QueryBatch(requests)
+	batchErr, ok := err.(*BatchError)
+	assert.True(t, ok, "Expected batch error")
+	assert.Len(t, batchErr.Errors, len(requests))
+	for i, err := range batchErr.Errors {
+		if i == 3 {
+			assert.NotNil(t, err, "Expected error for request without function")
+			continue
+		}
+		assert.Nil(t, err, "Expected request %d to succeed", i)
+		assert.Equal(t, []string{testPeer1.URL()}, responses[i].SelectedTargets)
+	}
+}
+
+// TestQueryBatchTimeout runs batches whose requests share the timeout options, which are completed with the
+// default execute timeout by each request (run with -race)
+func TestQueryBatchTimeout(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	var requests []Request
+	for i := 0; i < 25; i++ {
+		requests = append(requests, Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte(fmt.Sprintf("key%d", i))}})
+	}
+
+	for i := 0; i < 20; i++ {
+		responses, err := chClient.QueryBatch(requests, WithTimeout(fab.Query, 5*time.Second))
+		assert.Nil(t, err, "Failed to query batch")
+		assert.Len(t, responses, len(requests))
+	}
+}
+
+func TestQueryBatchGreylist(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
+
+	chClient := setupChannelClientWithSelection(t, testPeer1)
+
+	retryOpts := retry.Opts{
+		Attempts:       2,
+		BackoffFactor:  1,
+		InitialBackoff: time.Millisecond * 1,
+		MaxBackoff:     time.Second * 1,
+		RetryableCodes: retry.ChannelClientRetryableCodes,
+	}
+	requests := []Request{
+		{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}},
+		{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("c")}},
+	}
+
+	_, err := chClient.QueryBatch(requests, WithRetry(retryOpts))
+	batchErr, ok := err.(*BatchError)
+	assert.True(t, ok, "Expected batch error")
+	for _, err := range batchErr.Errors {
+		s, ok := status.FromError(err)
+		assert.True(t, ok, "expected status error")
+		assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected No Peers Found status on greylist")
+	}
+	assert.True(t, testPeer1.ProcessProposalCalls <= len(requests), "expected the greylisted peer not to be retried")
+}
+
+func TestQueryBatchCancel(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	cancel()
+
+	requests := []Request{
+		{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}},
+		{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+	}
+	_, err := chClient.QueryBatch(requests, WithParentContext(ctx))
+	batchErr, ok := err.(*BatchError)
+	assert.True(t, ok, "Expected batch error")
+	for _, err := range batchErr.Errors {
+		assert.NotNil(t, err, "Expected cancelled batch to fail")
+	}
+}
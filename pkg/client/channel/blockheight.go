@@ -0,0 +1,232 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	"github.com/pkg/errors"
+)
+
+// blockHeightExpiry is how long the block height of a peer is cached
+const blockHeightExpiry = 2 * time.Second
+
+type blockHeight struct {
+	height uint64
+	expiry time.Time
+}
+
+// blockHeightCache caches the ledger heights of the channel's peers, keyed by peer URL
+type blockHeightCache struct {
+	channelID string
+	lock      sync.RWMutex
+	heights   map[string]blockHeight
+}
+
+func newBlockHeightCache(channelID string) *blockHeightCache {
+	return &blockHeightCache{
+		channelID: channelID,
+		heights:   make(map[string]blockHeight),
+	}
+}
+
+// get returns the block height of each of the given peers, querying the peers whose
+// height is not cached. Peers that fail to report their height are given a height of 0.
+func (c *blockHeightCache) get(reqCtx reqContext.Context, peers []fab.Peer) map[string]uint64 {
+	heights := make(map[string]uint64)
+	var expired []fab.Peer
+
+	now := time.Now()
+	c.lock.RLock()
+	for _, peer := range peers {
+		h, ok := c.heights[peer.URL()]
+		if ok && now.Before(h.expiry) {
+			heights[peer.URL()] = h.height
+		} else {
+			expired = append(expired, peer)
+		}
+	}
+	c.lock.RUnlock()
+
+	for url, height := range c.refresh(reqCtx, expired) {
+		heights[url] = height
+	}
+	return heights
+}
+
+// refresh queries the block height of each of the given peers, bypassing the cache.
+// Peers that fail to report their height are omitted.
+func (c *blockHeightCache) refresh(reqCtx reqContext.Context, peers []fab.Peer) map[string]uint64 {
+	heights := make(map[string]uint64)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer fab.Peer) {
+			defer wg.Done()
+			height, err := c.query(reqCtx, peer)
+			if err != nil {
+				logger.Debugf("Failed to query block height of peer [%s]: %s", peer.URL(), err)
+				return
+			}
+			mutex.Lock()
+			heights[peer.URL()] = height
+			mutex.Unlock()
+			c.set(peer.URL(), height)
+		}(peer)
+	}
+	wg.Wait()
+
+	return heights
+}
+
+func (c *blockHeightCache) set(url string, height uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.heights[url] = blockHeight{height: height, expiry: time.Now().Add(blockHeightExpiry)}
+}
+
+func (c *blockHeightCache) query(reqCtx reqContext.Context, peer fab.Peer) (uint64, error) {
+	l, err := channel.NewLedger(c.channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	responses, err := l.QueryInfo(reqCtx, []fab.ProposalProcessor{peer}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(responses) == 0 {
+		return 0, errors.New("no chain info returned")
+	}
+	return responses[0].BCI.Height, nil
+}
+
+//blockHeightSelectionHandler selects the peers with the highest ledger height for the query, or the peers
+//preferred by the sorter if one is given, falling back to the next peers if the query fails on the preferred ones
+type blockHeightSelectionHandler struct {
+	heights *blockHeightCache
+	sorter  fab.TargetSorter
+	next    invoke.Handler
+}
+
+//Handle invokes the next handler on the peers in order of preference until one succeeds
+func (h *blockHeightSelectionHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	candidates, err := selectionCandidates(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	queried := withoutPeerState(candidates)
+	urls := make([]string, len(queried))
+	for i, peer := range queried {
+		urls[i] = peer.URL()
+	}
+	requestContext.SetStage(invoke.StageSelection, urls...)
+
+	heights := h.heights.get(requestContext.Ctx, queried)
+	for _, peer := range candidates {
+		if state, ok := peer.(fab.PeerState); ok {
+			heights[peer.URL()] = state.BlockHeight()
+		}
+	}
+
+	if h.sorter != nil {
+		candidates = h.sort(candidates, heights)
+	} else {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return heights[candidates[i].URL()] > heights[candidates[j].URL()]
+		})
+	}
+
+	for _, peer := range candidates {
+		requestContext.Opts.Targets = []fab.Peer{peer}
+		requestContext.Response = invoke.Response{SelectedTargets: []string{peer.URL()}}
+		requestContext.NotifyProgress(invoke.ProgressSelected, peer.URL())
+		requestContext.Error = nil
+
+		h.next.Handle(requestContext, clientContext)
+		if requestContext.Error == nil {
+			return
+		}
+		logger.Debugf("Query on peer [%s] at block height %d failed: %s", peer.URL(), heights[peer.URL()], requestContext.Error)
+	}
+}
+
+//selectionCandidates returns the request's targets, or the discovered peers accepted by the selection filter
+func selectionCandidates(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) ([]fab.Peer, error) {
+	if len(requestContext.Opts.Targets) > 0 {
+		return append([]fab.Peer(nil), requestContext.Opts.Targets...), nil
+	}
+
+	peers, err := clientContext.Discovery.GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get peers")
+	}
+
+	var candidates []fab.Peer
+	for _, peer := range peers {
+		if requestContext.SelectionFilter == nil || requestContext.SelectionFilter(peer) {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "no peers available for block height selection", nil)
+	}
+	return candidates, nil
+}
+
+//sort orders the candidates with the sorter. Peers which don't report their block height are
+//given the queried height, so that sorters relying on fab.PeerState can order them.
+func (h *blockHeightSelectionHandler) sort(candidates []fab.Peer, heights map[string]uint64) []fab.Peer {
+	peers := make([]fab.Peer, len(candidates))
+	for i, peer := range candidates {
+		if _, ok := peer.(fab.PeerState); ok {
+			peers[i] = peer
+		} else {
+			peers[i] = &peerWithHeight{Peer: peer, height: heights[peer.URL()]}
+		}
+	}
+
+	sorted := h.sorter.Sort(peers)
+	for i, peer := range sorted {
+		if p, ok := peer.(*peerWithHeight); ok {
+			sorted[i] = p.Peer
+		}
+	}
+	return sorted
+}
+
+//withoutPeerState returns the peers whose block height has to be queried
+func withoutPeerState(peers []fab.Peer) []fab.Peer {
+	var result []fab.Peer
+	for _, peer := range peers {
+		if _, ok := peer.(fab.PeerState); !ok {
+			result = append(result, peer)
+		}
+	}
+	return result
+}
+
+//peerWithHeight decorates a peer with its queried block height
+type peerWithHeight struct {
+	fab.Peer
+	height uint64
+}
+
+func (p *peerWithHeight) BlockHeight() uint64 {
+	return p.height
+}
@@ -9,33 +9,102 @@ package channel
 
 import (
 	reqContext "context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/greylist"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
 	"github.com/pkg/errors"
 )
 
+var logger = logging.NewLogger("fabsdk/client")
+
 // Client enables access to a channel on a Fabric network.
 //
 // A channel client instance provides a handler to interact with peers on specified channel.
 // An application that requires interaction with multiple channels should create a separate
 // instance of the channel client for each channel. Channel client supports non-admin functions only.
+//
+// A channel client is safe for concurrent use by multiple goroutines, as are request options
+// which are shared between requests.
 type Client struct {
-	context      context.Channel
-	membership   fab.ChannelMembership
-	eventService fab.EventService
-	greylist     *greylist.Filter
+	context       context.Channel
+	membership    fab.ChannelMembership
+	eventService  fab.EventService
+	eventOpts     []options.Opt
+	replayEvents  replayEventClient
+	registrations eventRegistrations
+	greylist      *greylist.Filter
+	greylistPred  func(error) bool
+	blockHeights  *blockHeightCache
+
+	commitHook      PostCommitHook
+	commitRetention time.Duration
+	commitNotifier  *commitNotifier
+	pending         *pendingTxRegistry
+	idempotency     IdempotencyStore
+	queryCache      *queryCache
+	metricsObserver invoke.MetricsObserver
+	metricsSink     Metrics
+	defaultOpts     []RequestOption
+
+	queryChain       invoke.Handler //selects the targets unless given, endorses and validates a query
+	endorsementChain invoke.Handler //endorses and validates a query on the targets already selected
+	executeChain     invoke.Handler //selects the targets unless given, endorses and commits a transaction
+	commitChain      invoke.Handler //endorses and commits a transaction on the targets already selected
+}
+
+//replayEventClient registers for events which are replayed from a given block
+type replayEventClient interface {
+	RegisterChaincodeEventFrom(ccID, eventFilter string, fromBlock uint64) (fab.Registration, <-chan *fab.CCEvent, error)
+	Unregister(reg fab.Registration)
+}
+
+//replayRegistration is the registration of events replayed by the replay event client
+type replayRegistration struct {
+	fab.Registration
 }
 
 // ClientOption describes a functional parameter for the New constructor
 type ClientOption func(*Client) error
 
+// WithGreylistExpiry sets the time for which a peer which couldn't be connected to is excluded from the selection
+// of the endorsers, overriding the DiscoveryGreylistExpiry timeout of the config for this client
+func WithGreylistExpiry(expiry time.Duration) ClientOption {
+	return func(client *Client) error {
+		if expiry <= 0 {
+			return errors.Errorf("invalid greylist expiry [%s]", expiry)
+		}
+		client.greylist = greylist.New(expiry)
+		return nil
+	}
+}
+
+// WithGreylistPredicate only greylists the peer which caused the error of a failed attempt if the predicate
+// returns true for the error, e.g. to keep peers in the selection when the connection to them failed because of
+// a client-side timeout or cancellation. The predicate can't greylist peers for errors which don't identify
+// a peer whose connection failed.
+func WithGreylistPredicate(predicate func(err error) bool) ClientOption {
+	return func(client *Client) error {
+		if predicate == nil {
+			return errors.New("greylist predicate is required")
+		}
+		client.greylistPred = predicate
+		return nil
+	}
+}
+
 // New returns a Client instance.
 func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client, error) {
 
@@ -50,21 +119,23 @@ func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client
 		return nil, errors.New("channel service not initialized")
 	}
 
-	eventService, err := channelContext.ChannelService().EventService()
+	membership, err := channelContext.ChannelService().Membership()
 	if err != nil {
-		return nil, errors.WithMessage(err, "event service creation failed")
+		return nil, errors.WithMessage(err, "membership creation failed")
 	}
 
-	membership, err := channelContext.ChannelService().Membership()
+	replayEvents, err := event.New(func() (context.Channel, error) { return channelContext, nil })
 	if err != nil {
-		return nil, errors.WithMessage(err, "membership creation failed")
+		return nil, errors.WithMessage(err, "event client creation failed")
 	}
 
 	channelClient := Client{
 		membership:   membership,
-		eventService: eventService,
+		replayEvents: replayEvents,
 		greylist:     greylistProvider,
+		blockHeights: newBlockHeightCache(channelContext.ChannelID()),
 		context:      channelContext,
+		defaultOpts:  contextRequestOptions(channelContext),
 	}
 
 	for _, param := range opts {
@@ -74,27 +145,181 @@ func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client
 		}
 	}
 
+	eventService, err := channelContext.ChannelService().EventService(channelClient.eventOpts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "event service creation failed")
+	}
+	channelClient.eventService = eventService
+
+	if channelClient.pending == nil {
+		channelClient.pending = newPendingTxRegistry(defaultMaxPendingTransactions, defaultPendingTransactionTTL)
+	}
+
+	if channelClient.idempotency == nil {
+		channelClient.idempotency = NewMemoryIdempotencyStore(defaultMaxIdempotencyKeys, defaultIdempotencyWindow)
+	}
+
+	if channelClient.metricsSink != nil {
+		channelClient.metricsObserver = newSinkObserver(channelClient.metricsSink, channelClient.metricsObserver)
+	}
+
+	if channelClient.commitHook != nil {
+		retention := channelClient.commitRetention
+		if retention == 0 {
+			retention = defaultCommitRetention
+		}
+		channelClient.commitNotifier = newCommitNotifier(channelClient.commitHook, retention, channelClient.pending)
+	}
+
+	if err := channelClient.buildChains(); err != nil {
+		return nil, errors.WithMessage(err, "building handler chains failed")
+	}
+
+	if qc := channelClient.queryCache; qc != nil && qc.invalidation&(InvalidateOnChaincodeEvent|InvalidateOnBlock) != 0 {
+		reg, eventch, err := eventService.RegisterFilteredBlockEvent()
+		if err != nil {
+			return nil, errors.WithMessage(err, "registering for filtered block events for query cache invalidation failed")
+		}
+		channelClient.registrations.add(reg, func() { eventService.Unregister(reg) })
+		go qc.listen(eventch)
+	}
+
 	return &channelClient, nil
 }
 
 // Query chaincode using request and optional options provided
 func (cc *Client) Query(request Request, options ...RequestOption) (Response, error) {
-	optsWithTimeout, err := cc.addDefaultTimeout(cc.context, fab.Query, options...)
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, options...)
 	if err != nil {
 		return Response{}, errors.WithMessage(err, "option failed")
 	}
+	cc.addDefaultTimeout(fab.Query, &txnOpts)
+
+	return cc.query(cc.queryHandler(txnOpts), request, txnOpts)
+}
+
+//query invokes the query handler, through the query cache if enabled. A query with a consistency option, i.e.
+//a quorum or the selection of the peers by block height, isn't served from the cache since the cached response
+//may come from a single peer.
+func (cc *Client) query(handler invoke.Handler, request Request, txnOpts requestOptions) (Response, error) {
+	if cc.queryCache == nil {
+		return cc.invokeHandler(handler, request, txnOpts)
+	}
+
+	bypass := txnOpts.BypassCache || txnOpts.QueryQuorum > 0 || txnOpts.MaxBlockHeightSelection
+	return cc.queryCache.query(cc.context.ChannelID(), request, bypass, func() (Response, error) {
+		return cc.invokeHandler(handler, request, txnOpts)
+	})
+}
 
-	return cc.InvokeHandler(invoke.NewQueryHandler(), request, optsWithTimeout...)
+// QueryCacheStats returns the hit, miss and stale-serve counts of the query cache
+func (cc *Client) QueryCacheStats() QueryCacheStats {
+	if cc.queryCache == nil {
+		return QueryCacheStats{}
+	}
+	return cc.queryCache.stats()
+}
+
+//queryHandler returns the query handler, querying a quorum of peers or selecting targets by block height if
+//requested. The target sorter is otherwise applied by the selection service, or to the given targets.
+func (cc *Client) queryHandler(txnOpts requestOptions) invoke.Handler {
+	if txnOpts.QueryQuorum > 0 {
+		return invoke.NewQuorumQueryHandler(invoke.NewSignatureValidationHandler())
+	}
+	if !txnOpts.MaxBlockHeightSelection {
+		return cc.queryChain
+	}
+
+	return &blockHeightSelectionHandler{
+		heights: cc.blockHeights,
+		sorter:  txnOpts.TargetSorter,
+		next:    cc.endorsementChain,
+	}
 }
 
 // Execute prepares and executes transaction using request and optional options provided
+// The number of the block which committed the transaction is returned in Response.BlockNumber, also when the
+// transaction was invalidated. Response.CommitObserved tells whether the commit was observed, since the block
+// number is 0 otherwise (e.g. if the request timed out waiting for the commit).
 func (cc *Client) Execute(request Request, options ...RequestOption) (Response, error) {
-	optsWithTimeout, err := cc.addDefaultTimeout(cc.context, fab.Execute, options...)
+	txnOpts, err := cc.prepareOptsFromOptions(cc.context, options...)
 	if err != nil {
 		return Response{}, errors.WithMessage(err, "option failed")
 	}
 
-	return cc.InvokeHandler(invoke.NewExecuteHandler(), request, optsWithTimeout...)
+	return cc.execute(cc.executeChain, request, txnOpts)
+}
+
+//execute invokes the handler of a transaction, at most once per idempotency key
+func (cc *Client) execute(handler invoke.Handler, request Request, txnOpts requestOptions) (Response, error) {
+	if txnOpts.IdempotencyKey == "" {
+		return cc.submit(handler, request, txnOpts)
+	}
+
+	return cc.executeOnce(txnOpts.IdempotencyKey, func() (Response, error) {
+		return cc.submit(handler, request, txnOpts)
+	})
+}
+
+//submit invokes the handler of a transaction, invalidating the cached queries of the chaincode if requested
+func (cc *Client) submit(handler invoke.Handler, request Request, txnOpts requestOptions) (Response, error) {
+	cc.addDefaultTimeout(fab.Execute, &txnOpts)
+
+	if cc.queryCache != nil && cc.queryCache.invalidation&InvalidateOnExecute != 0 {
+		// The transaction may be committed even if Execute fails (e.g. times out)
+		defer cc.queryCache.invalidate(request.ChaincodeID)
+	}
+
+	return cc.invokeHandler(handler, request, txnOpts)
+}
+
+//buildChains builds the handler chains of queries and transactions. Transactions are tracked as pending
+//until their commit is observed.
+func (cc *Client) buildChains() error {
+	track := func(next invoke.Handler) invoke.Handler {
+		return &txTrackingHandler{client: cc, next: next}
+	}
+
+	chains := []struct {
+		chain   *invoke.Chain
+		handler *invoke.Handler
+	}{
+		{invoke.NewQueryChain(), &cc.queryChain},
+		{invoke.NewChain().Endorse().ValidateEndorsements().CheckSignature(), &cc.endorsementChain},
+		{invoke.NewChain().Select().CheckOrgAffinity().Endorse().ValidateEndorsements().CheckSignature().Step(trackStep, track).Commit(), &cc.executeChain},
+		{invoke.NewChain().CheckOrgAffinity().Endorse().ValidateEndorsements().CheckSignature().Step(trackStep, track).Commit(), &cc.commitChain},
+	}
+	for _, c := range chains {
+		handler, err := c.chain.Build()
+		if err != nil {
+			return err
+		}
+		*c.handler = handler
+	}
+	return nil
+}
+
+// PendingTransactions returns the transactions submitted by Execute whose commit has not been observed yet
+func (cc *Client) PendingTransactions() []PendingTransaction {
+	return cc.pending.list()
+}
+
+// WaitForPending blocks until the commit of all pending transactions has been observed (or they expired),
+// or the given context is done. Useful before a graceful shutdown.
+func (cc *Client) WaitForPending(ctx reqContext.Context) error {
+	return cc.pending.wait(ctx)
+}
+
+// GreylistedPeers returns the peers which are excluded from the selection of the endorsers because a connection
+// to them failed, along with the time at which they expire from the greylist
+func (cc *Client) GreylistedPeers() []greylist.Entry {
+	return cc.greylist.Entries()
+}
+
+// ClearGreylist removes the given peer URLs from the greylist, e.g. once the peers are known to be back up,
+// or all peers if no URL is given
+func (cc *Client) ClearGreylist(urls ...string) {
+	cc.greylist.Remove(urls...)
 }
 
 //InvokeHandler invokes handler using request and options provided
@@ -105,6 +330,12 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 		return Response{}, err
 	}
 
+	return cc.invokeHandler(handler, request, txnOpts)
+}
+
+//invokeHandler invokes handler using request and the options already read from RequestOptions
+func (cc *Client) invokeHandler(handler invoke.Handler, request Request, txnOpts requestOptions) (Response, error) {
+	started := time.Now()
 	reqCtx, cancel := cc.createReqContext(&txnOpts)
 	defer cancel()
 
@@ -114,58 +345,146 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 		return Response{}, err
 	}
 
+	// The targets, sorted if requested, are restored before each retry
+	targets := requestContext.Opts.Targets
+
 	invoker := retry.NewInvoker(
 		requestContext.RetryHandler,
 		retry.WithBeforeRetry(
 			func(err error) {
-				cc.greylist.Greylist(err)
+				cc.greylistPeer(err)
 
 				// Reset context parameters
-				requestContext.Opts.Targets = txnOpts.Targets
+				requestContext.Opts.Targets = targets
 				requestContext.Error = nil
 				requestContext.Response = invoke.Response{}
+
+				if txnOpts.BeforeRetry != nil {
+					txnOpts.BeforeRetry(err)
+				}
 			},
 		),
 	)
 
+	attempts := 0
 	complete := make(chan bool)
 	go func() {
 		_, _ = invoker.Invoke(
 			func() (interface{}, error) {
+				attempts++
 				handler.Handle(requestContext, clientContext)
+				if txnOpts.AfterAttempt != nil {
+					txnOpts.AfterAttempt(attempts, requestContext.Error)
+				}
 				return nil, requestContext.Error
 			})
 		complete <- true
 	}()
 	select {
 	case <-complete:
-		return Response(requestContext.Response), requestContext.Error
+		err := invalidTxError(requestContext.Error, attempts)
+		requestContext.ObserveCompletion(started, err)
+		return Response(requestContext.Response), err
 	case <-reqCtx.Done():
-		return Response{}, status.New(status.ClientStatus, status.Timeout.ToInt32(),
-			"request timed out or been cancelled", nil)
+		err := timeoutError(requestContext.Stage())
+		requestContext.ObserveRequest(started, err)
+		return Response{}, err
+	}
+}
+
+//greylistPeer greylists the peer which caused the error, if any, unless the greylist predicate rejects the error
+func (cc *Client) greylistPeer(err error) {
+	if cc.greylistPred != nil && !cc.greylistPred(err) {
+		return
+	}
+	cc.greylist.Greylist(err)
+}
+
+//peerFilter returns the filter of the peers which may be selected for the request, i.e. the peers accepted by
+//the target filter of the request which aren't greylisted and belong to the MSP the request is pinned to, if any
+func (cc *Client) peerFilter(o requestOptions) func(peer fab.Peer) bool {
+	return func(peer fab.Peer) bool {
+		if !o.BypassGreylist && !cc.greylist.Accept(peer) {
+			return false
+		}
+		if o.OrgAffinity != "" && peer.MSPID() != o.OrgAffinity {
+			return false
+		}
+		if o.TargetFilter != nil && !o.TargetFilter.Accept(peer) {
+			return false
+		}
+		return true
 	}
 }
 
+//invalidTxError adds the number of attempts to the error of a transaction which was invalidated on each of the attempts,
+//e.g. because of conflicting transactions
+func invalidTxError(err error, attempts int) error {
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.TxValidationStatus || attempts <= 1 {
+		return err
+	}
+	return status.New(s.Group, s.Code, fmt.Sprintf("%s after %d attempts", s.Message, attempts), s.Details)
+}
+
+//timeoutError returns the status error of a request which timed out or was cancelled at the given stage
+func timeoutError(stage invoke.StageInfo) error {
+	msg := "request timed out or been cancelled"
+	if stage.Stage == "" {
+		return status.New(status.ClientStatus, status.Timeout.ToInt32(), msg, nil)
+	}
+
+	msg = fmt.Sprintf("%s during %s", msg, stage.Stage)
+	if len(stage.Targets) > 0 {
+		msg = fmt.Sprintf("%s with targets [%s]", msg, strings.Join(stage.Targets, ", "))
+	}
+	return status.New(status.ClientStatus, status.Timeout.ToInt32(), msg, []interface{}{stage})
+}
+
 //createReqContext creates req context for invoke handler
 func (cc *Client) createReqContext(txnOpts *requestOptions) (reqContext.Context, reqContext.CancelFunc) {
-
-	if txnOpts.Timeouts == nil {
-		txnOpts.Timeouts = make(map[fab.TimeoutType]time.Duration)
+	//Copy the timeouts since the options may be shared by concurrent requests, e.g. those of a batch
+	timeouts := make(map[fab.TimeoutType]time.Duration, len(txnOpts.Timeouts)+1)
+	for timeoutType, timeout := range txnOpts.Timeouts {
+		timeouts[timeoutType] = timeout
 	}
+	txnOpts.Timeouts = timeouts
 
 	//setting default timeouts when not provided
 	if txnOpts.Timeouts[fab.Execute] == 0 {
 		txnOpts.Timeouts[fab.Execute] = cc.context.EndpointConfig().TimeoutOrDefault(fab.Execute)
 	}
 
-	reqCtx, cancel := contextImpl.NewRequest(cc.context, contextImpl.WithTimeout(txnOpts.Timeouts[fab.Execute]),
+	reqCtx, cancel := contextImpl.NewRequest(cc.context, contextImpl.WithTimeout(executeTimeout(*txnOpts)),
 		contextImpl.WithParent(txnOpts.ParentContext))
 	//Add timeout overrides here as a value so that it can be used by immediate child contexts (in handlers/transactors)
 	reqCtx = reqContext.WithValue(reqCtx, contextImpl.ReqContextTimeoutOverrides, txnOpts.Timeouts)
+	if len(txnOpts.TLSPins) > 0 {
+		reqCtx = reqContext.WithValue(reqCtx, contextImpl.ReqContextTLSPins, txnOpts.TLSPins)
+	}
+	if txnOpts.CorrelationID != "" {
+		reqCtx = contextImpl.WithCorrelationID(reqCtx, txnOpts.CorrelationID)
+	}
 
 	return reqCtx, cancel
 }
 
+//executeTimeout returns the execute timeout of the request, bounded by the deadline of the parent context so that
+//a request whose parent context expires first is cancelled at the parent's deadline
+func executeTimeout(o requestOptions) time.Duration {
+	timeout := o.Timeouts[fab.Execute]
+	if o.ParentContext == nil {
+		return timeout
+	}
+	if deadline, ok := o.ParentContext.Deadline(); ok {
+		// An expired parent context cancels the request anyway
+		if remaining := time.Until(deadline); remaining > 0 && remaining < timeout {
+			return remaining
+		}
+	}
+	return timeout
+}
+
 //prepareHandlerContexts prepares context objects for handlers
 func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Request, o requestOptions) (*invoke.RequestContext, *invoke.ClientContext, error) {
 
@@ -182,14 +501,15 @@ func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Requ
 		return nil, nil, errors.WithMessage(err, "failed to create transactor")
 	}
 
-	peerFilter := func(peer fab.Peer) bool {
-		if !cc.greylist.Accept(peer) {
-			return false
+	eventService := cc.eventService
+	if o.OrgAffinity != "" {
+		if err := checkTargetsAffinity(o); err != nil {
+			return nil, nil, err
 		}
-		if o.TargetFilter != nil && !o.TargetFilter.Accept(peer) {
-			return false
+		eventService, err = cc.orgEventService(o.OrgAffinity)
+		if err != nil {
+			return nil, nil, err
 		}
-		return true
 	}
 
 	clientContext := &invoke.ClientContext{
@@ -197,24 +517,44 @@ func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Requ
 		Discovery:    cc.context.DiscoveryService(),
 		Membership:   cc.membership,
 		Transactor:   transactor,
-		EventService: cc.eventService,
+		EventService: eventService,
+	}
+
+	retryOpts := o.Retry
+	retryOpts.RetryableCodes = retryableCodes(o)
+	if o.MaxElapsedTime > 0 {
+		retryOpts.MaxElapsedTime = o.MaxElapsedTime
 	}
 
 	requestContext := &invoke.RequestContext{
 		Request:         invoke.Request(request),
 		Opts:            invoke.Opts(o),
 		Response:        invoke.Response{},
-		RetryHandler:    retry.New(o.Retry),
+		RetryHandler:    retry.New(retryOpts),
 		Ctx:             reqCtx,
-		SelectionFilter: peerFilter,
+		SelectionFilter: cc.peerFilter(o),
+		ChannelID:       cc.context.ChannelID(),
+		MetricsObserver: cc.metricsObserver,
+	}
+	if o.TargetSorter != nil && len(o.Targets) > 0 {
+		// Sort a copy since the targets may be shared by concurrent requests
+		targets := make([]fab.Peer, len(o.Targets))
+		copy(targets, o.Targets)
+		requestContext.Opts.Targets = o.TargetSorter.Sort(targets)
 	}
 
 	return requestContext, clientContext, nil
 }
 
-//prepareOptsFromOptions Reads apitxn.Opts from Option array
+//prepareOptsFromOptions Reads apitxn.Opts from Option array, after the default options of the client
 func (cc *Client) prepareOptsFromOptions(ctx context.Client, options ...RequestOption) (requestOptions, error) {
 	txnOpts := requestOptions{}
+	for _, option := range cc.defaultOpts {
+		err := option(ctx, &txnOpts)
+		if err != nil {
+			return txnOpts, errors.WithMessage(err, "Failed to read default opts")
+		}
+	}
 	for _, option := range options {
 		err := option(ctx, &txnOpts)
 		if err != nil {
@@ -225,31 +565,226 @@ func (cc *Client) prepareOptsFromOptions(ctx context.Client, options ...RequestO
 }
 
 //addDefaultTimeout adds given default timeout if it is missing in options
-func (cc *Client) addDefaultTimeout(ctx context.Client, timeOutType fab.TimeoutType, options ...RequestOption) ([]RequestOption, error) {
-	txnOpts := requestOptions{}
-	for _, option := range options {
-		err := option(ctx, &txnOpts)
-		if err != nil {
-			return nil, errors.WithMessage(err, "option failed")
-		}
+func (cc *Client) addDefaultTimeout(timeOutType fab.TimeoutType, txnOpts *requestOptions) {
+	if txnOpts.Timeouts[timeOutType] != 0 {
+		return
 	}
 
-	if txnOpts.Timeouts[timeOutType] == 0 {
-		//InvokeHandler relies on Execute timeout
-		return append(options, WithTimeout(fab.Execute, cc.context.EndpointConfig().TimeoutOrDefault(timeOutType))), nil
+	if txnOpts.Timeouts == nil {
+		txnOpts.Timeouts = make(map[fab.TimeoutType]time.Duration)
 	}
-	return options, nil
+	//InvokeHandler relies on Execute timeout
+	txnOpts.Timeouts[fab.Execute] = cc.context.EndpointConfig().TimeoutOrDefault(timeOutType)
 }
 
 // RegisterChaincodeEvent registers chain code event
+// The SourceURL of the received events is the URL of the peer which delivered the event, which may be used
+// to de-duplicate events or to detect a peer which lags behind in event delivery.
 // @param {chan bool} channel which receives event details when the event is complete
 // @returns {object} object handle that should be used to unregister
 func (cc *Client) RegisterChaincodeEvent(chainCodeID string, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
 	// Register callback for CE
-	return cc.eventService.RegisterChaincodeEvent(chainCodeID, eventFilter)
+	reg, eventch, err := cc.eventService.RegisterChaincodeEvent(chainCodeID, eventFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+	cc.registrations.add(reg, func() { cc.eventService.Unregister(reg) })
+	return reg, eventch, nil
+}
+
+// RegisterChaincodeEventFromBlock registers for chaincode events, starting with the events committed in the given
+// block, so that a consumer which restarts receives the events which were committed while it was down. The events of
+// the blocks which were already committed are received in order before the live events. The events are received
+// from a dedicated connection to the Deliver service, which is closed by UnregisterChaincodeEvent.
+// Note that, as for RegisterChaincodeEvent, the events are received from filtered blocks and have no payload.
+func (cc *Client) RegisterChaincodeEventFromBlock(chainCodeID string, eventFilter string, fromBlock uint64) (fab.Registration, <-chan *fab.CCEvent, error) {
+	reg, eventch, err := cc.replayEvents.RegisterChaincodeEventFrom(chainCodeID, eventFilter, fromBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	replayReg := &replayRegistration{Registration: reg}
+	cc.registrations.add(replayReg, func() { cc.replayEvents.Unregister(reg) })
+	return replayReg, eventch, nil
 }
 
 // UnregisterChaincodeEvent removes chain code event registration
 func (cc *Client) UnregisterChaincodeEvent(registration fab.Registration) {
-	cc.eventService.Unregister(registration)
+	cc.registrations.remove(registration, func(registration fab.Registration) {
+		if r, ok := registration.(*replayRegistration); ok {
+			cc.replayEvents.Unregister(r.Registration)
+			return
+		}
+		cc.eventService.Unregister(registration)
+	})
+}
+
+// RegisterFilteredBlockEvent registers for filtered block events. Events are received until the registration
+// is removed with UnregisterFilteredBlockEvent, which closes the channel.
+// The channel is buffered by the event service (100 events by default). If the buffer is full, the event service
+// waits for the consumer up to the event consumer timeout (500ms by default) and then drops the event, so the
+// events should be consumed promptly.
+func (cc *Client) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	reg, eventch, err := cc.eventService.RegisterFilteredBlockEvent()
+	if err != nil {
+		return nil, nil, err
+	}
+	cc.registrations.add(reg, func() { cc.eventService.Unregister(reg) })
+	return reg, eventch, nil
+}
+
+// UnregisterFilteredBlockEvent removes filtered block event registration
+func (cc *Client) UnregisterFilteredBlockEvent(registration fab.Registration) {
+	cc.registrations.remove(registration, cc.eventService.Unregister)
+}
+
+// RegisterTxStatusEvent registers for the status event of the given transaction, e.g. of a transaction which was
+// submitted by another process. The event is received once the transaction is committed, with the validation code
+// of the transaction, after which the registration should be removed with UnregisterTxStatusEvent.
+func (cc *Client) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	if txID == "" {
+		return nil, nil, errors.New("transaction ID is required to register for transaction status events")
+	}
+	reg, eventch, err := cc.eventService.RegisterTxStatusEvent(txID)
+	if err != nil {
+		return nil, nil, err
+	}
+	cc.registrations.add(reg, func() { cc.eventService.Unregister(reg) })
+	return reg, eventch, nil
+}
+
+// UnregisterTxStatusEvent removes transaction status event registration
+func (cc *Client) UnregisterTxStatusEvent(registration fab.Registration) {
+	cc.registrations.remove(registration, cc.eventService.Unregister)
+}
+
+// RegisterBlockEvent registers for block events, optionally filtered by the given block filter. The identity of the
+// client must be authorized to receive full blocks on the channel. Events are received until the registration is
+// removed with UnregisterBlockEvent, which closes the channel. The channel is buffered in the same way as for
+// RegisterFilteredBlockEvent.
+// The client requests an event service which permits block events from the channel service. An error is returned
+// if the channel service only provides an event service for filtered blocks.
+func (cc *Client) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	eventService, err := cc.blockEventService()
+	if err != nil {
+		return nil, nil, err
+	}
+	reg, eventch, err := eventService.RegisterBlockEvent(filter...)
+	if err != nil {
+		if errors.Cause(err) == client.ErrBlockEventsNotPermitted {
+			return nil, nil, errors.Wrap(err, "event service initialized for filtered blocks; recreate channel context with WithBlockEvents")
+		}
+		return nil, nil, err
+	}
+	cc.registrations.add(reg, func() { eventService.Unregister(reg) })
+	return reg, eventch, nil
+}
+
+// UnregisterBlockEvent removes block event registration
+func (cc *Client) UnregisterBlockEvent(registration fab.Registration) {
+	cc.registrations.remove(registration, func(registration fab.Registration) {
+		eventService, err := cc.blockEventService()
+		if err != nil {
+			logger.Warnf("Unable to unregister block event: %s", err)
+			return
+		}
+		eventService.Unregister(registration)
+	})
+}
+
+// UnregisterAll removes all of the event registrations made through the client which weren't removed yet,
+// e.g. on shutdown, closing their event channels. The registration of the query cache for its invalidation
+// upon committed blocks is removed as well, after which cached responses only expire or are invalidated by Execute.
+func (cc *Client) UnregisterAll() {
+	cc.registrations.removeAll()
+}
+
+//closable is implemented by channel contexts which release shared services when closed
+type closable interface {
+	Close()
+}
+
+// Close removes the event registrations made through the client, including the block event registration of the
+// post-commit hook, and releases the discovery and selection services of the channel, which are shared by the
+// clients of the channel and closed once no client uses them. The client may no longer be used once closed.
+func (cc *Client) Close() {
+	cc.UnregisterAll()
+	if cc.commitNotifier != nil {
+		cc.commitNotifier.close()
+	}
+	if c, ok := cc.context.(closable); ok {
+		c.Close()
+	}
+}
+
+// blockEventService returns the event service of the channel which permits block events. The event service is
+// cached by the channel provider so that registrations and unregistrations are made on the same service.
+func (cc *Client) blockEventService() (fab.EventService, error) {
+	opts := append([]options.Opt{client.WithBlockEvents()}, cc.eventOpts...)
+	eventService, err := cc.context.ChannelService().EventService(opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "block event service creation failed")
+	}
+	return eventService, nil
+}
+
+// orgEventService returns the event service of the channel which receives the events from the peers of the given
+// MSP. The event service is cached by the channel provider per MSP.
+func (cc *Client) orgEventService(mspID string) (fab.EventService, error) {
+	opts := append([]options.Opt{dispatcher.WithPeerMSPID(mspID)}, cc.eventOpts...)
+	eventService, err := cc.context.ChannelService().EventService(opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "event service creation for org affinity failed")
+	}
+	return eventService, nil
+}
+
+//checkTargetsAffinity checks that the targets of a request pinned to an MSP belong to it
+func checkTargetsAffinity(o requestOptions) error {
+	for _, target := range o.Targets {
+		if target.MSPID() != o.OrgAffinity {
+			return errors.Errorf("target [%s] of MSP [%s] doesn't belong to MSP [%s] the request is pinned to", target.URL(), target.MSPID(), o.OrgAffinity)
+		}
+	}
+	return nil
+}
+
+//retryableCodes returns the retryable codes of the request, i.e. those of the retry options (or those overriding
+//them) merged with the codes added and excluded for the request
+func retryableCodes(o requestOptions) map[status.Group][]status.Code {
+	codes := o.Retry.RetryableCodes
+	if o.RetryableCodes != nil {
+		codes = o.RetryableCodes
+	}
+	if len(o.AddedRetryableCodes) == 0 && len(o.ExcludedRetryableCodes) == 0 {
+		return codes
+	}
+	if len(codes) == 0 {
+		codes = retry.DefaultRetryableCodes
+	}
+
+	excluded := func(group status.Group, code status.Code) bool {
+		for _, c := range o.ExcludedRetryableCodes[group] {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The retryable codes may be shared by concurrent requests, so they're copied. The groups are kept even if
+	// all of their codes are excluded since the retry handler falls back to the default codes if there are none.
+	merged := make(map[status.Group][]status.Code)
+	for _, m := range []map[status.Group][]status.Code{codes, o.AddedRetryableCodes} {
+		for group, groupCodes := range m {
+			if _, ok := merged[group]; !ok {
+				merged[group] = []status.Code{}
+			}
+			for _, code := range groupCodes {
+				if !excluded(group, code) {
+					merged[group] = append(merged[group], code)
+				}
+			}
+		}
+	}
+	return merged
 }
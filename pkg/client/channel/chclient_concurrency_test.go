@@ -0,0 +1,117 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	concurrentClients  = 20
+	concurrentRequests = 10
+)
+
+// TestConcurrentRequests shares one client and one set of request options between goroutines.
+// It's meant to be run with the race detector.
+func TestConcurrentRequests(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("value")
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case txStatusReg := <-mockEventService.TxStatusRegCh:
+				go func() {
+					txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+				}()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	chClient.eventService = mockEventService
+
+	retryOpts := retry.DefaultChClientOpts
+	retryOpts.InitialBackoff = 10 * time.Millisecond
+	opts := []RequestOption{
+		WithTimeout(fab.Query, 5*time.Second),
+		WithRetry(retryOpts),
+		WithTargetFilter(&mspFilter{mspID: "Org1MSP"}),
+		WithBeforeRetry(func(err error) {}),
+	}
+	// Explicit targets sorted in place by the sorter
+	targetOpts := []RequestOption{
+		WithTargets(testPeer2, testPeer1),
+		WithTargetSorter(&urlSorter{}),
+	}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentClients*concurrentRequests*4)
+	for i := 0; i < concurrentClients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < concurrentRequests; j++ {
+				if _, err := chClient.Query(request, opts...); err != nil {
+					errs <- err
+				}
+				if _, err := chClient.Query(request, targetOpts...); err != nil {
+					errs <- err
+				}
+				if _, err := chClient.Execute(request, opts...); err != nil {
+					errs <- err
+				}
+
+				reg, _, err := chClient.RegisterChaincodeEvent("testCC", ".*")
+				if err != nil {
+					errs <- err
+					continue
+				}
+				chClient.UnregisterChaincodeEvent(reg)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, chClient.PendingTransactions(), "Expected no pending transactions")
+}
+
+type mspFilter struct {
+	mspID string
+}
+
+func (f *mspFilter) Accept(peer fab.Peer) bool {
+	return peer.MSPID() == f.mspID
+}
+
+type urlSorter struct{}
+
+func (s *urlSorter) Sort(peers []fab.Peer) []fab.Peer {
+	sort.Slice(peers, func(i, j int) bool { return peers[i].URL() < peers[j].URL() })
+	return peers
+}
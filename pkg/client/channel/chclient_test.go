@@ -7,21 +7,28 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	reqContext "context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/staticselection"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
@@ -114,6 +121,470 @@ func TestQuery(t *testing.T) {
 
 }
 
+func TestRegisterBlockEvents(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	reg, eventch, err := chClient.RegisterFilteredBlockEvent()
+	assert.Nil(t, err, "Failed to register for filtered block events")
+	assert.NotNil(t, eventch, "Expected filtered block event channel")
+	assert.Equal(t, reg, <-mockEventService.FilteredBlockRegCh, "Expected registration with the event service of the client")
+	chClient.UnregisterFilteredBlockEvent(reg)
+
+	reg, blockch, err := chClient.RegisterBlockEvent()
+	assert.Nil(t, err, "Failed to register for block events")
+	assert.NotNil(t, reg, "Expected block event registration")
+	assert.NotNil(t, blockch, "Expected block event channel")
+	chClient.UnregisterBlockEvent(reg)
+}
+
+func TestRegisterBlockEventsNotPermitted(t *testing.T) {
+	ctx := setupTestContext().(*fcmocks.MockContext)
+	testChannelSvc, err := setupTestChannelService(ctx, nil)
+	assert.Nil(t, err, "Got error %s", err)
+	channelSvc := &filteredChannelService{ChannelService: testChannelSvc}
+	ctx.MockProviderContext.ChannelProvider().(*fcmocks.MockChannelProvider).SetCustomChannelService(channelSvc)
+	chClient, err := New(createChannelContext(createClientContext(ctx), channelID))
+	assert.Nil(t, err, "Got error %s", err)
+
+	_, _, err = chClient.RegisterBlockEvent()
+	assert.NotNil(t, err, "Expected error registering for block events with a filtered event service")
+	assert.Equal(t, client.ErrBlockEventsNotPermitted, errors.Cause(err))
+	assert.Contains(t, err.Error(), "event service initialized for filtered blocks")
+}
+
+//filteredChannelService provides event services which only permit filtered block events
+type filteredChannelService struct {
+	fab.ChannelService
+}
+
+func (cs *filteredChannelService) EventService(opts ...options.Opt) (fab.EventService, error) {
+	return &filteredEventService{MockEventService: fcmocks.NewMockEventService()}, nil
+}
+
+type filteredEventService struct {
+	*fcmocks.MockEventService
+}
+
+func (s *filteredEventService) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	return nil, nil, client.ErrBlockEventsNotPermitted
+}
+
+func TestCreateReqContextParentDeadline(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	// The deadline of the parent context is earlier than the execute timeout
+	parent, cancelParent := reqContext.WithTimeout(reqContext.Background(), time.Second)
+	defer cancelParent()
+	opts := requestOptions{ParentContext: parent, Timeouts: map[fab.TimeoutType]time.Duration{fab.Execute: 30 * time.Second}}
+	reqCtx, cancel := chClient.createReqContext(&opts)
+	defer cancel()
+	deadline, ok := reqCtx.Deadline()
+	assert.True(t, ok, "Expected request deadline")
+	parentDeadline, _ := parent.Deadline()
+	assert.False(t, deadline.After(parentDeadline), "Expected the parent deadline to bound the request deadline")
+
+	// The execute timeout is earlier than the deadline of the parent context
+	parent, cancelParent = reqContext.WithTimeout(reqContext.Background(), 30*time.Second)
+	defer cancelParent()
+	opts = requestOptions{ParentContext: parent, Timeouts: map[fab.TimeoutType]time.Duration{fab.Execute: time.Second}}
+	reqCtx, cancel = chClient.createReqContext(&opts)
+	defer cancel()
+	deadline, ok = reqCtx.Deadline()
+	assert.True(t, ok, "Expected request deadline")
+	assert.True(t, time.Until(deadline) <= time.Second, "Expected the execute timeout to bound the request deadline")
+
+	// The request is cancelled with its parent
+	parent, cancelParent = reqContext.WithCancel(reqContext.Background())
+	opts = requestOptions{ParentContext: parent}
+	reqCtx, cancel = chClient.createReqContext(&opts)
+	defer cancel()
+	cancelParent()
+	select {
+	case <-reqCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected request context to be cancelled with its parent")
+	}
+}
+
+func TestCreateReqContextCorrelationID(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	reqCtx, cancel := chClient.createReqContext(&requestOptions{})
+	defer cancel()
+	_, ok := contextImpl.RequestCorrelationID(reqCtx)
+	assert.False(t, ok, "Expected no correlation ID")
+
+	reqCtx, cancel = chClient.createReqContext(&requestOptions{CorrelationID: "trace-1"})
+	defer cancel()
+	correlationID, ok := contextImpl.RequestCorrelationID(reqCtx)
+	assert.True(t, ok, "Expected correlation ID")
+	assert.Equal(t, "trace-1", correlationID)
+
+	// The ID of the parent context is used unless the request has its own
+	parent := contextImpl.WithCorrelationID(reqContext.Background(), "trace-2")
+	reqCtx, cancel = chClient.createReqContext(&requestOptions{ParentContext: parent})
+	defer cancel()
+	correlationID, _ = contextImpl.RequestCorrelationID(reqCtx)
+	assert.Equal(t, "trace-2", correlationID)
+
+	reqCtx, cancel = chClient.createReqContext(&requestOptions{ParentContext: parent, CorrelationID: "trace-1"})
+	defer cancel()
+	correlationID, _ = contextImpl.RequestCorrelationID(reqCtx)
+	assert.Equal(t, "trace-1", correlationID)
+}
+
+func TestRegisterTxStatusEvent(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	eventService := &unregisterRecordingEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient.eventService = eventService
+
+	_, _, err := chClient.RegisterTxStatusEvent("")
+	assert.NotNil(t, err, "Expected error for empty transaction ID")
+
+	reg, eventch, err := chClient.RegisterTxStatusEvent("txid")
+	assert.Nil(t, err, "Failed to register for transaction status events")
+	assert.NotNil(t, eventch, "Expected transaction status event channel")
+	txStatusReg := <-eventService.TxStatusRegCh
+	assert.Equal(t, reg, txStatusReg, "Expected registration with the event service of the client")
+	assert.Equal(t, "txid", txStatusReg.TxID)
+
+	chClient.UnregisterTxStatusEvent(reg)
+	assert.Equal(t, []fab.Registration{reg}, eventService.unregistered)
+
+	// Registrations are removed when the client is closed
+	reg2, _, err := chClient.RegisterTxStatusEvent("txid2")
+	assert.Nil(t, err, "Failed to register for transaction status events")
+	<-eventService.TxStatusRegCh
+	chClient.Close()
+	assert.Equal(t, []fab.Registration{reg, reg2}, eventService.unregistered, "Expected registration to be removed on close")
+}
+
+func TestRegisterChaincodeEventFromBlock(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	replayEvents := &mockReplayEventClient{}
+	chClient.replayEvents = replayEvents
+
+	reg, eventch, err := chClient.RegisterChaincodeEventFromBlock("testCC", "event.*", 5)
+	assert.Nil(t, err, "Failed to register for chaincode events from block")
+	assert.NotNil(t, eventch, "Expected chaincode event channel")
+	assert.EqualValues(t, 5, replayEvents.fromBlock)
+
+	chClient.UnregisterChaincodeEvent(reg)
+	assert.Equal(t, replayEvents.reg, replayEvents.unregistered, "Expected replay registration to be removed from the replay event client")
+
+	replayEvents.err = errors.New("replay failed")
+	_, _, err = chClient.RegisterChaincodeEventFromBlock("testCC", "event.*", 5)
+	assert.NotNil(t, err, "Expected error registering for chaincode events from block")
+}
+
+func TestUnregisterAll(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	eventService := &unregisterRecordingEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient.eventService = eventService
+	replayEvents := &mockReplayEventClient{}
+	chClient.replayEvents = replayEvents
+
+	ccReg1, _, err := chClient.RegisterChaincodeEvent("testCC", "event1")
+	assert.Nil(t, err, "Failed to register for chaincode events")
+	ccReg2, _, err := chClient.RegisterChaincodeEvent("testCC", "event2")
+	assert.Nil(t, err, "Failed to register for chaincode events")
+	blockReg, _, err := chClient.RegisterFilteredBlockEvent()
+	assert.Nil(t, err, "Failed to register for filtered block events")
+	_, _, err = chClient.RegisterChaincodeEventFromBlock("testCC", "event.*", 5)
+	assert.Nil(t, err, "Failed to register for chaincode events from block")
+
+	chClient.UnregisterChaincodeEvent(ccReg1)
+	assert.Equal(t, []fab.Registration{ccReg1}, eventService.unregistered)
+
+	chClient.UnregisterAll()
+	assert.ElementsMatch(t, []fab.Registration{ccReg1, ccReg2, blockReg}, eventService.unregistered)
+	assert.Equal(t, replayEvents.reg, replayEvents.unregistered, "Expected replay registration to be removed")
+
+	// The registrations were removed once only
+	chClient.UnregisterAll()
+	assert.Len(t, eventService.unregistered, 3)
+}
+
+func TestClose(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	eventService := &unregisterRecordingEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient.eventService = eventService
+	channelContext := &closeRecordingChannel{Channel: chClient.context}
+	chClient.context = channelContext
+
+	ccReg, _, err := chClient.RegisterChaincodeEvent("testCC", "event1")
+	assert.Nil(t, err, "Failed to register for chaincode events")
+
+	chClient.Close()
+	assert.Equal(t, []fab.Registration{ccReg}, eventService.unregistered)
+	assert.True(t, channelContext.closed, "Expected channel context to be closed")
+}
+
+type closeRecordingChannel struct {
+	context.Channel
+	closed bool
+}
+
+func (c *closeRecordingChannel) Close() {
+	c.closed = true
+}
+
+type unregisterRecordingEventService struct {
+	*fcmocks.MockEventService
+	unregistered []fab.Registration
+}
+
+func (s *unregisterRecordingEventService) Unregister(reg fab.Registration) {
+	s.unregistered = append(s.unregistered, reg)
+}
+
+type mockReplayEventClient struct {
+	fromBlock    uint64
+	reg          fab.Registration
+	unregistered fab.Registration
+	err          error
+}
+
+func (c *mockReplayEventClient) RegisterChaincodeEventFrom(ccID, eventFilter string, fromBlock uint64) (fab.Registration, <-chan *fab.CCEvent, error) {
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+	c.fromBlock = fromBlock
+	reg, eventch, err := fcmocks.NewMockEventService().RegisterChaincodeEvent(ccID, eventFilter)
+	c.reg = reg
+	return reg, eventch, err
+}
+
+func (c *mockReplayEventClient) Unregister(reg fab.Registration) {
+	c.unregistered = reg
+}
+
+func TestExecuteBlockNumber(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	go commitTx(mockEventService, 7)
+	resp, err := chClient.Execute(request, WithTargets(testPeer))
+	assert.Nil(t, err, "Failed to execute transaction")
+	assert.EqualValues(t, 7, resp.BlockNumber)
+	assert.True(t, resp.CommitObserved, "Expected commit to be observed")
+
+	// The block number is set for invalidated transactions
+	go func() {
+		txStatusReg := <-mockEventService.TxStatusRegCh
+		txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_BAD_PAYLOAD, BlockNumber: 8}
+	}()
+	resp, err = chClient.Execute(request, WithTargets(testPeer))
+	assert.NotNil(t, err, "Expected error for invalid transaction")
+	assert.EqualValues(t, 8, resp.BlockNumber)
+	assert.True(t, resp.CommitObserved, "Expected commit of invalid transaction to be observed")
+
+	// The block number is 0 if the commit wasn't observed
+	resp, err = chClient.Execute(request, WithTargets(testPeer), WithTimeout(fab.Execute, 50*time.Millisecond))
+	assert.NotNil(t, err, "Expected timeout")
+	assert.EqualValues(t, 0, resp.BlockNumber)
+	assert.False(t, resp.CommitObserved, "Expected commit not to be observed")
+}
+
+func TestExecuteNoCommitWait(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	resp, err := chClient.Execute(request, WithTargets(testPeer), WithNoCommitWait())
+	assert.Nil(t, err, "Failed to execute transaction without commit wait")
+	assert.True(t, resp.NoCommitWait, "Expected commit wait to be skipped")
+	assert.False(t, resp.CommitObserved, "Expected commit not to be observed")
+	assert.NotEmpty(t, resp.TransactionID)
+	assert.Equal(t, 0, len(mockEventService.TxStatusRegCh), "Expected no registration for the commit")
+	assert.Empty(t, chClient.PendingTransactions(), "Expected transaction not to be tracked without post-commit hook")
+
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithNoCommitWait(), WithBlockCommitWait())
+	assert.NotNil(t, err, "Expected error for block commit wait without commit wait")
+}
+
+// connectedEventService is a mock event service which reports whether it's connected
+type connectedEventService struct {
+	*fcmocks.MockEventService
+	connected bool
+}
+
+func (s *connectedEventService) Connected() bool {
+	return s.connected
+}
+
+func TestExecuteEventServiceUnavailable(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	eventService := &connectedEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient.eventService = eventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	// The transaction isn't broadcast
+	_, err := chClient.Execute(request, WithTargets(testPeer), WithEventServiceUnavailableMode(invoke.FailFast), WithIdempotencyKey("transfer-1"))
+	s, ok := status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.Equal(t, status.ClientStatus, s.Group)
+		assert.EqualValues(t, status.EventServiceUnavailable.ToInt32(), s.Code)
+	}
+	assert.Empty(t, eventService.TxStatusRegCh, "Expected no registration for the commit")
+	assert.Empty(t, chClient.PendingTransactions(), "Expected transaction not to be pending")
+
+	// The capture of a chaincode event requires the commit event
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithEventServiceUnavailableMode(invoke.ProceedWithoutCommitWait), WithCCEventCapture("event"))
+	s, ok = status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.EqualValues(t, status.EventServiceUnavailable.ToInt32(), s.Code)
+	}
+
+	// The default is to wait for the commit regardless
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithTimeout(fab.Execute, 50*time.Millisecond))
+	s, ok = status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.EqualValues(t, status.Timeout.ToInt32(), s.Code, "Expected execute to time out waiting for the commit")
+	}
+	<-eventService.TxStatusRegCh
+
+	// The mode doesn't apply while the event service is connected
+	eventService.connected = true
+	go commitTx(eventService.MockEventService, 3)
+	resp, err := chClient.Execute(request, WithTargets(testPeer), WithEventServiceUnavailableMode(invoke.FailFast), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Failed to execute transaction with connected event service")
+	assert.True(t, resp.CommitObserved, "Expected commit to be observed")
+}
+
+func TestExecuteEventServiceUnavailableProceed(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	eventService := &connectedEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient.eventService = eventService
+	notifications := make(chan CommitNotification, 1)
+	chClient.commitNotifier = newCommitNotifier(func(n CommitNotification) { notifications <- n }, time.Minute, chClient.pending)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	resp, err := chClient.Execute(request, WithTargets(testPeer), WithEventServiceUnavailableMode(invoke.ProceedWithoutCommitWait), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Failed to execute transaction without commit wait")
+	assert.True(t, resp.NoCommitWait, "Expected commit wait to be skipped")
+	assert.False(t, resp.CommitObserved, "Expected commit not to be observed")
+	assert.NotEmpty(t, resp.TransactionID)
+	assert.Empty(t, eventService.TxStatusRegCh, "Expected no registration for the commit")
+
+	// The transaction is pending until the post-commit hook observes its commit
+	pending := chClient.PendingTransactions()
+	if assert.Len(t, pending, 1, "Expected transaction to be pending") {
+		assert.Equal(t, resp.TransactionID, pending[0].TxID)
+	}
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	s, ok := status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.EqualValues(t, status.AlreadySubmitted.ToInt32(), s.Code, "Expected pending transaction not to be submitted again")
+	}
+
+	var blockReg *dispatcher.FilteredBlockReg
+	select {
+	case blockReg = <-eventService.FilteredBlockRegCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for post-commit hook to register for block events")
+	}
+	blockReg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+		Number:               9,
+		FilteredTransactions: []*pb.FilteredTransaction{{Txid: string(resp.TransactionID), TxValidationCode: pb.TxValidationCode_VALID}},
+	}}
+
+	select {
+	case n := <-notifications:
+		assert.Equal(t, resp.TransactionID, n.TxID)
+		assert.EqualValues(t, 9, n.BlockNumber)
+		assert.Empty(t, chClient.PendingTransactions(), "Expected committed transaction not to be pending")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for post-commit notification")
+	}
+}
+
+func TestExecuteProgressNotifier(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	progressch := make(chan invoke.TxProgress, 10)
+	go commitTx(mockEventService, 7)
+	resp, err := chClient.Execute(request, WithTargets(testPeer), WithProgressNotifier(progressch))
+	assert.Nil(t, err, "Failed to execute transaction")
+
+	var stages []invoke.ProgressStage
+	for len(progressch) > 0 {
+		progress := <-progressch
+		stages = append(stages, progress.Stage)
+		assert.False(t, progress.Time.IsZero(), "Expected time of progress")
+		switch progress.Stage {
+		case invoke.ProgressSelected, invoke.ProgressEndorsed:
+			assert.Equal(t, []string{"http://peer1.com"}, progress.Targets)
+		case invoke.ProgressBroadcast:
+			assert.Equal(t, resp.TransactionID, progress.TxID)
+		case invoke.ProgressCommitted:
+			assert.Equal(t, resp.TransactionID, progress.TxID)
+			assert.EqualValues(t, 7, progress.BlockNumber)
+		}
+	}
+	assert.Equal(t, []invoke.ProgressStage{invoke.ProgressSelected, invoke.ProgressEndorsed, invoke.ProgressBroadcast, invoke.ProgressCommitted}, stages)
+
+	// The notifier is owned by the caller and isn't closed
+	select {
+	case _, ok := <-progressch:
+		assert.True(t, ok, "Expected progress notifier not to be closed")
+	default:
+	}
+
+	// A notifier which isn't ready to receive doesn't stall the transaction
+	go commitTx(mockEventService, 8)
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithProgressNotifier(make(chan invoke.TxProgress)))
+	assert.Nil(t, err, "Failed to execute transaction with unread progress notifier")
+}
+
+func TestQuerySelectedTargets(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("test1")
+	testPeer1.SetMSPID("Org1MSP")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("test2")
+	testPeer2.SetMSPID("Org2MSP")
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+
+	// The selected targets and endorsers are reported even though the request fails
+	response, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	assert.NotNil(t, err, "Expected endorsement mismatch")
+	assert.ElementsMatch(t, []string{"http://peer1.com", "http://peer2.com"}, response.SelectedTargets)
+
+	endorsers := make(map[string]string)
+	for _, r := range response.Responses {
+		endorsers[r.Endorser] = r.MSPID
+	}
+	assert.Equal(t, map[string]string{"http://peer1.com": "Org1MSP", "http://peer2.com": "Org2MSP"}, endorsers)
+
+	testPeer2.Payload = []byte("test1")
+	response, err = chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}, WithTargets(testPeer2))
+	assert.Nil(t, err, "Failed to invoke test cc")
+	assert.Equal(t, []string{"http://peer2.com"}, response.SelectedTargets)
+}
+
 func TestQuerySelectionError(t *testing.T) {
 	chClient := setupChannelClientWithError(nil, errors.New("Test Error"), nil, t)
 
@@ -175,6 +646,29 @@ func TestQueryWithOptTarget(t *testing.T) {
 	}
 }
 
+func TestRequestOptionsEvaluatedOnce(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	var count int
+	countingOpt := func(ctx context.Client, opts *requestOptions) error {
+		count++
+		return nil
+	}
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}, countingOpt)
+	if err != nil {
+		t.Fatalf("Failed to invoke test cc: %s", err)
+	}
+	assert.Equal(t, 1, count, "expected request option to be evaluated once for Query")
+
+	count = 0
+	_, err = chClient.Execute(Request{}, countingOpt)
+	if err == nil {
+		t.Fatalf("Should have failed for empty invoke request")
+	}
+	assert.Equal(t, 1, count, "expected request option to be evaluated once for Execute")
+}
+
 func TestExecuteTx(t *testing.T) {
 	chClient := setupChannelClient(nil, t)
 
@@ -262,6 +756,154 @@ func (h *customEndorsementHandler) Handle(requestContext *invoke.RequestContext,
 	}
 }
 
+func TestQueryWithMaxBlockHeightSelection(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 5)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 10)
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	resp, err := chClient.Query(request, WithTargets(testPeer1, testPeer2), WithMaxBlockHeightSelection())
+	assert.Nil(t, err, "Failed to invoke test cc")
+	assert.Equal(t, testPeer2.Payload, resp.Payload, "Expected query on the peer with the highest block height")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "Expected only the block height to be queried on the lower peer")
+
+	// Block heights are cached, so the failing peer is still preferred and the query falls back to the lower peer
+	testPeer2.RWLock.Lock()
+	testPeer2.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	testPeer2.RWLock.Unlock()
+
+	resp, err = chClient.Query(request, WithTargets(testPeer1, testPeer2), WithMaxBlockHeightSelection())
+	assert.Nil(t, err, "Expected query to fall back to the lower peer")
+	assert.Equal(t, testPeer1.Payload, resp.Payload, "Expected query on the lower peer")
+	assert.Equal(t, 3, testPeer2.ProcessProposalCalls, "Expected block height to be cached")
+}
+
+func TestQueryWithTargetSorter(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+
+	// The sorter is passed to the selection service, without querying the ledger heights
+	sorter := &preferredPeerSorter{url: testPeer2.URL()}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	resp, err := chClient.Query(request, WithTargetSorter(sorter))
+	assert.Nil(t, err, "Failed to invoke test cc")
+	assert.Equal(t, []byte("value"), resp.Payload)
+	if assert.Len(t, sorter.sorted, 2) {
+		assert.Equal(t, testPeer2.URL(), sorter.sorted[0].URL(), "Expected the selected peers to be sorted")
+	}
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "Expected only the query to be sent to the peer")
+	assert.Equal(t, 1, testPeer2.ProcessProposalCalls, "Expected only the query to be sent to the peer")
+}
+
+func TestQueryWithMaxBlockHeightAndTargetSorter(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 10)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 5)
+
+	discoveryService, err := setupTestDiscovery(nil, []fab.Peer{testPeer2, testPeer1})
+	if err != nil {
+		t.Fatalf("Failed to setup discovery service: %s", err)
+	}
+	selectionService, err := setupTestSelection(nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to setup selection service: %s", err)
+	}
+	chClient, err := New(createChannelContext(setupCustomTestContext(t, selectionService, discoveryService, nil), channelID))
+	if err != nil {
+		t.Fatalf("Failed to create new channel client: %s", err)
+	}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// The ledger heights of the peers are queried since they don't report them
+	resp, err := chClient.Query(request, WithMaxBlockHeightSelection(), WithTargetSorter(selection.NewBlockHeightSorter()))
+	assert.Nil(t, err, "Failed to invoke test cc")
+	assert.Equal(t, testPeer1.Payload, resp.Payload, "Expected query on the peer with the highest block height")
+	assert.Equal(t, 1, testPeer2.ProcessProposalCalls, "Expected only the block height to be queried on the lower peer")
+}
+
+func TestQueryWithCustomTargetSorter(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 10)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 5)
+	chClient := setupChannelClient(nil, t)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	// The query is sent to the preferred peer only, although its ledger height is lower
+	resp, err := chClient.Query(request, WithTargets(testPeer1, testPeer2), WithMaxBlockHeightSelection(),
+		WithTargetSorter(&preferredPeerSorter{url: testPeer2.URL()}))
+	assert.Nil(t, err, "Failed to invoke test cc")
+	assert.Equal(t, testPeer2.Payload, resp.Payload, "Expected query on the peer preferred by the sorter")
+}
+
+func TestQueryRetryWithTargetSorter(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	chClient := setupChannelClient(nil, t)
+
+	// The targets are sorted on each attempt
+	handler := &targetsRecordingHandler{err: status.New(status.EndorserServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "test", nil)}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.InvokeHandler(handler, request, WithTargets(testPeer1, testPeer2),
+		WithTargetSorter(&preferredPeerSorter{url: testPeer2.URL()}),
+		WithRetry(retry.Opts{Attempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+			RetryableCodes: retry.ChannelClientRetryableCodes}))
+	assert.Nil(t, err, "Expected the retry to succeed")
+	if assert.Len(t, handler.targets, 2, "Expected the request to be retried") {
+		for _, targets := range handler.targets {
+			if assert.Len(t, targets, 2) {
+				assert.Equal(t, testPeer2.URL(), targets[0].URL(), "Expected the targets to be sorted")
+			}
+		}
+	}
+}
+
+// targetsRecordingHandler records the targets of each attempt, failing the first one with err
+type targetsRecordingHandler struct {
+	err     error
+	targets [][]fab.Peer
+}
+
+func (h *targetsRecordingHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	h.targets = append(h.targets, requestContext.Opts.Targets)
+	if len(h.targets) == 1 {
+		requestContext.Error = h.err
+	}
+}
+
+type preferredPeerSorter struct {
+	url    string
+	sorted []fab.Peer
+}
+
+func (s *preferredPeerSorter) Sort(peers []fab.Peer) []fab.Peer {
+	var sorted []fab.Peer
+	for _, peer := range peers {
+		if peer.URL() == s.url {
+			sorted = append([]fab.Peer{peer}, sorted...)
+		} else {
+			sorted = append(sorted, peer)
+		}
+	}
+	s.sorted = sorted
+	return sorted
+}
+
+func blockchainInfoPayload(t *testing.T, height uint64) []byte {
+	payload, err := proto.Marshal(&common.BlockchainInfo{Height: height})
+	if err != nil {
+		t.Fatalf("Failed to marshal blockchain info: %s", err)
+	}
+	return payload
+}
+
 func TestQueryWithCustomEndorser(t *testing.T) {
 	chClient := setupChannelClient(nil, t)
 
@@ -372,6 +1014,125 @@ func TestTransactionValidationError(t *testing.T) {
 	statusError, ok := status.FromError(err)
 	assert.True(t, ok, "Expected status error got %+v", err)
 	assert.EqualValues(t, validationCode, status.ToTransactionValidationCode(statusError.Code))
+	assert.Empty(t, chClient.PendingTransactions(), "Expected committed transaction not to be pending")
+}
+
+func TestExecuteTxRetryOnConflict(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+
+	go func() {
+		for {
+			select {
+			case txStatusReg := <-mockEventService.TxStatusRegCh:
+				txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_MVCC_READ_CONFLICT}
+			case <-time.After(time.Second * 5):
+				return
+			}
+		}
+	}()
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.eventService = mockEventService
+
+	retryOpts := retry.DefaultChClientOpts
+	retryOpts.Attempts = 2
+	retryOpts.InitialBackoff = 10 * time.Millisecond
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke",
+		Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}, WithRetry(retryOpts))
+	statusError, ok := status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.Equal(t, status.TxValidationStatus, statusError.Group)
+		assert.EqualValues(t, pb.TxValidationCode_MVCC_READ_CONFLICT, status.ToTransactionValidationCode(statusError.Code))
+		assert.Contains(t, statusError.Message, "after 3 attempts")
+	}
+	assert.Equal(t, 3, testPeer1.ProcessProposalCalls, "Expected conflicting transaction to be endorsed again")
+}
+
+func TestPostCommitHookAfterTimeout(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+
+	notifications := make(chan CommitNotification, 1)
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.eventService = mockEventService
+	chClient.commitNotifier = newCommitNotifier(func(n CommitNotification) { notifications <- n }, time.Minute, chClient.pending)
+
+	metadata := map[string]string{"correlationID": "123"}
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithCorrelationMetadata(metadata), WithTimeout(fab.Execute, 100*time.Millisecond))
+	assert.NotNil(t, err, "Expected execute to time out")
+	statusError, ok := status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.EqualValues(t, status.Timeout.ToInt32(), statusError.Code)
+		assert.Contains(t, statusError.Message, "during commit")
+		assert.Equal(t, []interface{}{invoke.StageInfo{Stage: invoke.StageCommit}}, statusError.Details)
+	}
+
+	var txStatusReg *dispatcher.TxStatusReg
+	select {
+	case txStatusReg = <-mockEventService.TxStatusRegCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for execute Tx to register event callback")
+	}
+
+	pending := chClient.PendingTransactions()
+	if assert.Len(t, pending, 1, "Expected timed out transaction to be pending") {
+		assert.EqualValues(t, txStatusReg.TxID, pending[0].TxID)
+		assert.Equal(t, []string{"http://peer1.com"}, pending[0].Targets)
+	}
+
+	var blockReg *dispatcher.FilteredBlockReg
+	select {
+	case blockReg = <-mockEventService.FilteredBlockRegCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for post-commit hook to register for block events")
+	}
+
+	blockReg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+		Number: 7,
+		FilteredTransactions: []*pb.FilteredTransaction{
+			{Txid: "othertx", TxValidationCode: pb.TxValidationCode_VALID},
+			{Txid: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID},
+		},
+	}}
+
+	select {
+	case n := <-notifications:
+		assert.Empty(t, chClient.PendingTransactions(), "Expected committed transaction to be removed from pending transactions")
+		assert.EqualValues(t, txStatusReg.TxID, n.TxID)
+		assert.Equal(t, pb.TxValidationCode_VALID, n.TxValidationCode)
+		assert.EqualValues(t, 7, n.BlockNumber)
+		assert.Equal(t, metadata, n.Metadata)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for post-commit notification")
+	}
+}
+
+func TestCommitNotifierRetention(t *testing.T) {
+	notifier := newCommitNotifier(func(CommitNotification) {}, time.Millisecond, nil)
+	err := notifier.track(fcmocks.NewMockEventService(), "txid", nil)
+	assert.Nil(t, err, "Failed to track transaction")
+
+	time.Sleep(10 * time.Millisecond)
+	notifications := notifier.committed(&pb.FilteredBlock{FilteredTransactions: []*pb.FilteredTransaction{{Txid: "txid"}}})
+	assert.Empty(t, notifications, "Expected no notification for expired transaction")
+	assert.Nil(t, notifier.reg, "Expected block event registration to be released")
+}
+
+func TestCommitNotifierClose(t *testing.T) {
+	eventService := &unregisterRecordingEventService{MockEventService: fcmocks.NewMockEventService()}
+	chClient := setupChannelClient(nil, t)
+	chClient.commitNotifier = newCommitNotifier(func(CommitNotification) {}, time.Minute, chClient.pending)
+
+	err := chClient.commitNotifier.track(eventService, "txid", nil)
+	assert.Nil(t, err, "Failed to track transaction")
+
+	// The registration is released on close although the transaction wasn't committed
+	chClient.Close()
+	assert.Len(t, eventService.unregistered, 1, "Expected block event registration to be released")
+	assert.Nil(t, chClient.commitNotifier.reg)
+	assert.Empty(t, chClient.commitNotifier.pending)
 }
 
 func TestExecuteTxWithRetries(t *testing.T) {
@@ -404,6 +1165,137 @@ func TestExecuteTxWithRetries(t *testing.T) {
 	assert.Equal(t, testResp, resp.Payload, "expected correct response")
 }
 
+func TestQueryWithMaxElapsedTime(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 100
+	retryOpts.BackoffFactor = 1
+	retryOpts.InitialBackoff = 20 * time.Millisecond
+	retryOpts.RetryableCodes = retry.ChannelClientRetryableCodes
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(retryOpts), WithMaxElapsedTime(100*time.Millisecond))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.ConnectionFailed.ToInt32(), s.Code, "expected error of the last attempt")
+	assert.True(t, testPeer1.ProcessProposalCalls > 1 && testPeer1.ProcessProposalCalls <= 5, "Expected retries to stop at the max elapsed time")
+}
+
+func TestExecuteTxWithRetryCallbacks(t *testing.T) {
+	testStatus := status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = testStatus
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 2
+	retryOpts.InitialBackoff = 10 * time.Millisecond
+	retryOpts.RetryableCodes = retry.ChannelClientRetryableCodes
+
+	var retryErrs []error
+	var attempts []int
+	var attemptErrs []error
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(retryOpts),
+		WithBeforeRetry(func(err error) {
+			retryErrs = append(retryErrs, err)
+		}),
+		WithAfterAttempt(func(attempt int, err error) {
+			attempts = append(attempts, attempt)
+			attemptErrs = append(attemptErrs, err)
+		}))
+	assert.Error(t, err, "expected error after retries are exhausted")
+	assert.Equal(t, 3, testPeer1.ProcessProposalCalls, "Expected peer to be called three times")
+	assert.Len(t, retryErrs, 2, "Expected before retry callback before each retry")
+	assert.Equal(t, []int{1, 2, 3}, attempts, "Expected after attempt callback after each attempt")
+	for _, attemptErr := range attemptErrs {
+		assert.Error(t, attemptErr, "Expected attempt error")
+	}
+}
+
+func TestExecuteTxWithRetryableCodes(t *testing.T) {
+	testStatus := status.New(status.EndorserServerStatus, 409, "conflict", nil)
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 2
+	retryOpts.InitialBackoff = 10 * time.Millisecond
+
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = testStatus
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	_, err := chClient.Query(request, WithRetry(retryOpts))
+	assert.NotNil(t, err, "expected error")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "Expected default codes not to retry custom status")
+
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Error = testStatus
+	chClient = setupChannelClient([]fab.Peer{testPeer2}, t)
+
+	_, err = chClient.Query(request, WithRetry(retryOpts),
+		WithRetryableCodes(map[status.Group][]status.Code{status.EndorserServerStatus: {409}}))
+	assert.NotNil(t, err, "expected error")
+	assert.Equal(t, 3, testPeer2.ProcessProposalCalls, "Expected custom status to be retried")
+}
+
+func TestExecuteTxWithAddedAndExcludedRetryableCodes(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	retryOpts := retry.DefaultOpts
+	retryOpts.Attempts = 2
+	retryOpts.InitialBackoff = 10 * time.Millisecond
+
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserServerStatus, 409, "conflict", nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+
+	_, err := chClient.Query(request, WithRetry(retryOpts), WithAdditionalRetryableCodes(status.EndorserServerStatus, 409))
+	assert.NotNil(t, err, "expected error")
+	assert.Equal(t, 3, testPeer1.ProcessProposalCalls, "Expected added status to be retried")
+	assert.NotContains(t, retryOpts.RetryableCodes[status.EndorserServerStatus], status.Code(409), "Expected retry options not to be modified")
+
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Error = status.New(status.EndorserServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "unavailable", nil)
+	chClient = setupChannelClient([]fab.Peer{testPeer2}, t)
+
+	_, err = chClient.Query(request, WithRetry(retryOpts),
+		WithoutRetryableCodes(status.EndorserServerStatus, status.Code(common.Status_SERVICE_UNAVAILABLE)))
+	assert.NotNil(t, err, "expected error")
+	assert.Equal(t, 1, testPeer2.ProcessProposalCalls, "Expected excluded status not to be retried")
+
+	// The default codes still apply without the options
+	_, err = chClient.Query(request, WithRetry(retryOpts))
+	assert.NotNil(t, err, "expected error")
+	assert.Equal(t, 4, testPeer2.ProcessProposalCalls, "Expected default status to be retried")
+}
+
+func TestQueryWithQuorum(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	response, err := chClient.Query(request, WithQueryQuorum(2))
+	assert.Nil(t, err, "Failed to query with quorum")
+	assert.Equal(t, []byte("value"), response.Payload)
+	assert.Len(t, response.Responses, 2)
+
+	testPeer2.Payload = []byte("other value")
+	_, err = chClient.Query(request, WithQueryQuorum(2))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.QuorumNotReached.ToInt32(), s.Code, "Expected quorum not to be reached")
+
+	_, err = chClient.Query(request, WithQueryQuorum(0))
+	assert.NotNil(t, err, "Expected error for invalid quorum")
+}
+
 func TestMultiErrorPropogation(t *testing.T) {
 	testErr := fmt.Errorf("Test Error")
 
@@ -434,24 +1326,7 @@ func TestDiscoveryGreylist(t *testing.T) {
 	testPeer1.Error = status.New(status.EndorserClientStatus,
 		status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
 
-	selectionProvider, err := staticselection.New(fcmocks.NewMockEndpointConfig())
-	assert.Nil(t, err, "Got error %s", err)
-
-	selectionService, err := selectionProvider.CreateSelectionService("mychannel")
-	assert.Nil(t, err, "Got error %s", err)
-
-	discoveryService, err := setupTestDiscovery(nil, []fab.Peer{testPeer1})
-	assert.Nil(t, err, "Got error %s", err)
-
-	fabCtx := setupCustomTestContext(t, selectionService, discoveryService, nil)
-	ctx := createChannelContext(fabCtx, channelID)
-
-	channelCtx, err := ctx()
-	assert.Nil(t, err, "Got error %s", err)
-	selectionService.(serviceInit).Initialize(channelCtx)
-
-	chClient, err := New(ctx)
-	assert.Nil(t, err, "Got error %s", err)
+	chClient := setupChannelClientWithSelection(t, testPeer1)
 
 	attempts := 3
 	retryOpts := retry.Opts{
@@ -461,7 +1336,7 @@ func TestDiscoveryGreylist(t *testing.T) {
 		MaxBackoff:     time.Second * 1,
 		RetryableCodes: retry.ChannelClientRetryableCodes,
 	}
-	_, err = chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
 		WithRetry(retryOpts))
 	assert.NotNil(t, err, "expected error")
 	s, ok := status.FromError(err)
@@ -483,6 +1358,197 @@ func TestDiscoveryGreylist(t *testing.T) {
 
 }
 
+func TestClearGreylist(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
+
+	chClient := setupChannelClientWithSelection(t, testPeer1)
+	assert.Empty(t, chClient.GreylistedPeers())
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	// The peer is greylisted before the request is retried
+	_, err := chClient.Query(request, WithRetry(retry.Opts{Attempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		RetryableCodes: retry.ChannelClientRetryableCodes}))
+	assert.NotNil(t, err, "expected error")
+
+	peers := chClient.GreylistedPeers()
+	if assert.Len(t, peers, 1) {
+		assert.Equal(t, "http://peer1.com", peers[0].URL)
+		assert.True(t, peers[0].Expiry.After(time.Now()), "expected greylist entry to expire in the future")
+	}
+
+	// The peer is back up: un-greylist it without waiting for the expiry
+	testPeer1.Error = nil
+	testPeer1.Payload = []byte("value")
+	chClient.ClearGreylist(testPeer1.URL())
+	assert.Empty(t, chClient.GreylistedPeers())
+
+	response, err := chClient.Query(request)
+	assert.Nil(t, err, "expected peer to be selected after clearing the greylist")
+	assert.Equal(t, []byte("value"), response.Payload)
+}
+
+func TestGreylistExpiry(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
+
+	chClient := setupChannelClientWithSelection(t, testPeer1)
+	assert.NotNil(t, WithGreylistExpiry(0)(chClient), "expected error for invalid greylist expiry")
+
+	expiry := 100 * time.Millisecond
+	assert.Nil(t, WithGreylistExpiry(expiry)(chClient))
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request, WithRetry(retry.Opts{Attempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		RetryableCodes: retry.ChannelClientRetryableCodes}))
+	assert.NotNil(t, err, "expected error")
+
+	peers := chClient.GreylistedPeers()
+	if assert.Len(t, peers, 1) {
+		assert.True(t, peers[0].Expiry.Before(time.Now().Add(expiry)), "expected the configured greylist expiry")
+	}
+
+	// The peer is selected again once the (short) greylist expiry elapsed
+	time.Sleep(expiry)
+	testPeer1.Error = nil
+	_, err = chClient.Query(request)
+	assert.Nil(t, err, "expected peer not to be greylisted after the expiry")
+}
+
+func TestGreylistPredicate(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "context deadline exceeded", []interface{}{testPeer1.URL()})
+
+	chClient := setupChannelClientWithSelection(t, testPeer1)
+	assert.NotNil(t, WithGreylistPredicate(nil)(chClient), "expected error for nil predicate")
+
+	// Connection failures caused by client-side timeouts don't greylist the peer
+	clientTimeout := func(err error) bool { return strings.Contains(err.Error(), "context deadline exceeded") }
+	assert.Nil(t, WithGreylistPredicate(func(err error) bool { return !clientTimeout(err) })(chClient))
+
+	attempts := 2
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request, WithRetry(retry.Opts{Attempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		RetryableCodes: retry.ChannelClientRetryableCodes}))
+	assert.NotNil(t, err, "expected error")
+	assert.Empty(t, chClient.GreylistedPeers(), "expected peer not to be greylisted")
+	assert.Equal(t, attempts+1, testPeer1.ProcessProposalCalls, "expected peer to be selected on each attempt")
+
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "connection refused", []interface{}{testPeer1.URL()})
+	_, err = chClient.Query(request, WithRetry(retry.Opts{Attempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		RetryableCodes: retry.ChannelClientRetryableCodes}))
+	assert.NotNil(t, err, "expected error")
+	assert.Len(t, chClient.GreylistedPeers(), 1, "expected peer to be greylisted")
+}
+
+func TestWithoutGreylist(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus,
+		status.ConnectionFailed.ToInt32(), "test", []interface{}{testPeer1.URL()})
+
+	chClient := setupChannelClientWithSelection(t, testPeer1)
+
+	retryOpts := retry.Opts{
+		Attempts:       2,
+		BackoffFactor:  1,
+		InitialBackoff: time.Millisecond * 1,
+		MaxBackoff:     time.Second * 1,
+		RetryableCodes: retry.ChannelClientRetryableCodes,
+	}
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	_, err := chClient.Query(request, WithRetry(retryOpts))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected No Peers Found status on greylist")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "expected peer 1 to be greylisted")
+
+	_, err = chClient.Query(request, WithRetry(retryOpts), WithoutGreylist())
+	s, ok = status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.ConnectionFailed.ToInt32(), s.Code, "expected greylisted peer to be selected")
+	assert.Equal(t, retryOpts.Attempts+2, testPeer1.ProcessProposalCalls, "expected the greylisted peer to be retried")
+
+	// The failures of the request are still greylisted
+	testPeer1.Error = nil
+	_, err = chClient.Query(request)
+	s, ok = status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected peer 1 to remain greylisted")
+
+	_, err = chClient.Query(request, WithoutGreylist())
+	assert.Nil(t, err, "expected query on recovered peer to succeed")
+}
+
+func TestQueryWithOrgAffinity(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.SetMSPID("Org1MSP")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.SetMSPID("Org2MSP")
+
+	chClient := setupChannelClientWithSelection(t, testPeer1, testPeer2)
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// Only the peers of the MSP are selected
+	resp, err := chClient.Query(request, WithOrgAffinity("Org1MSP"))
+	assert.Nil(t, err, "Failed to query with org affinity")
+	assert.Equal(t, []string{"http://peer1.com"}, resp.SelectedTargets)
+	assert.Equal(t, 0, testPeer2.ProcessProposalCalls, "expected the peer of the other MSP not to be queried")
+
+	// The targets must belong to the MSP
+	_, err = chClient.Query(request, WithTargets(testPeer1, testPeer2), WithOrgAffinity("Org1MSP"))
+	assert.NotNil(t, err, "Expected error for target of another MSP")
+	assert.Contains(t, err.Error(), "http://peer2.com")
+
+	// The commit events are received from the peers of the MSP
+	ctx := setupTestContext().(*fcmocks.MockContext)
+	testChannelSvc, err := setupTestChannelService(ctx, nil)
+	assert.Nil(t, err, "Got error %s", err)
+	channelSvc := &eventOptsChannelService{ChannelService: testChannelSvc}
+	ctx.MockProviderContext.ChannelProvider().(*fcmocks.MockChannelProvider).SetCustomChannelService(channelSvc)
+	chClient, err = New(createChannelContext(createClientContext(ctx), channelID))
+	assert.Nil(t, err, "Got error %s", err)
+	_, err = chClient.orgEventService("Org1MSP")
+	assert.Nil(t, err, "Got error %s", err)
+	params := &peerMSPIDParams{}
+	options.Apply(params, channelSvc.opts[len(channelSvc.opts)-1])
+	assert.Equal(t, "Org1MSP", params.mspID, "expected event service restricted to the peers of the MSP")
+}
+
+type peerMSPIDParams struct {
+	mspID string
+}
+
+func (p *peerMSPIDParams) SetPeerMSPID(mspID string) {
+	p.mspID = mspID
+}
+
+func setupChannelClientWithSelection(t *testing.T, peers ...fab.Peer) *Client {
+	selectionProvider, err := staticselection.New(fcmocks.NewMockEndpointConfig())
+	assert.Nil(t, err, "Got error %s", err)
+
+	selectionService, err := selectionProvider.CreateSelectionService("mychannel")
+	assert.Nil(t, err, "Got error %s", err)
+
+	discoveryService, err := setupTestDiscovery(nil, peers)
+	assert.Nil(t, err, "Got error %s", err)
+
+	fabCtx := setupCustomTestContext(t, selectionService, discoveryService, nil)
+	ctx := createChannelContext(fabCtx, channelID)
+
+	channelCtx, err := ctx()
+	assert.Nil(t, err, "Got error %s", err)
+	selectionService.(serviceInit).Initialize(channelCtx)
+
+	chClient, err := New(ctx)
+	assert.Nil(t, err, "Got error %s", err)
+	return chClient
+}
+
 func setupTestChannelService(ctx context.Client, orderers []fab.Orderer) (fab.ChannelService, error) {
 	chProvider, err := fcmocks.NewMockChannelProvider(ctx)
 	if err != nil {
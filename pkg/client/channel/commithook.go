@@ -0,0 +1,162 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+const defaultCommitRetention = 10 * time.Minute
+
+// CommitNotification contains the outcome of a transaction that was submitted through the channel client
+type CommitNotification struct {
+	TxID             fab.TransactionID
+	TxValidationCode pb.TxValidationCode
+	BlockNumber      uint64
+	Metadata         map[string]string // correlation metadata of the original request
+}
+
+// PostCommitHook is invoked whenever the commit of a transaction submitted by Execute is observed
+type PostCommitHook func(CommitNotification)
+
+// WithPostCommitHook sets a hook which is notified of the commit of every transaction submitted
+// by Execute, even if the commit is only observed after the Execute call returned (e.g. timed out).
+func WithPostCommitHook(hook PostCommitHook) ClientOption {
+	return func(client *Client) error {
+		if hook == nil {
+			return errors.New("post-commit hook is required")
+		}
+		client.commitHook = hook
+		return nil
+	}
+}
+
+// WithPostCommitRetention sets how long submitted transactions are tracked for the post-commit hook.
+// Commits observed after the retention window has elapsed are not notified.
+func WithPostCommitRetention(retention time.Duration) ClientOption {
+	return func(client *Client) error {
+		if retention <= 0 {
+			return errors.Errorf("invalid post-commit retention [%s]", retention)
+		}
+		client.commitRetention = retention
+		return nil
+	}
+}
+
+type pendingCommit struct {
+	metadata map[string]string
+	expiry   time.Time
+}
+
+// commitNotifier keeps a registry of submitted transactions and notifies the hook when their
+// commit is observed in a filtered block event
+type commitNotifier struct {
+	hook         PostCommitHook
+	retention    time.Duration
+	lock         sync.Mutex
+	pending      map[string]*pendingCommit
+	eventService fab.EventService
+	reg          fab.Registration
+	txRegistry   *pendingTxRegistry
+}
+
+func newCommitNotifier(hook PostCommitHook, retention time.Duration, txRegistry *pendingTxRegistry) *commitNotifier {
+	return &commitNotifier{
+		hook:       hook,
+		retention:  retention,
+		pending:    make(map[string]*pendingCommit),
+		txRegistry: txRegistry,
+	}
+}
+
+// track adds the transaction to the registry, registering for block events if not already registered
+func (n *commitNotifier) track(eventService fab.EventService, txID fab.TransactionID, metadata map[string]string) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.reg == nil {
+		reg, eventch, err := eventService.RegisterFilteredBlockEvent()
+		if err != nil {
+			return errors.WithMessage(err, "registering for filtered block events failed")
+		}
+		n.reg = reg
+		n.eventService = eventService
+		go n.listen(eventch)
+	}
+
+	n.pending[string(txID)] = &pendingCommit{metadata: metadata, expiry: time.Now().Add(n.retention)}
+	return nil
+}
+
+func (n *commitNotifier) listen(eventch <-chan *fab.FilteredBlockEvent) {
+	for event := range eventch {
+		for _, notification := range n.committed(event.FilteredBlock) {
+			n.hook(notification)
+		}
+	}
+}
+
+// committed removes the tracked transactions contained in the block from the registry and returns their notifications.
+// Once there is nothing left to track, the block event registration is released.
+func (n *commitNotifier) committed(block *pb.FilteredBlock) []CommitNotification {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	now := time.Now()
+	for txID, pending := range n.pending {
+		if now.After(pending.expiry) {
+			delete(n.pending, txID)
+		}
+	}
+
+	var notifications []CommitNotification
+	if block != nil {
+		for _, tx := range block.FilteredTransactions {
+			pending, ok := n.pending[tx.Txid]
+			if !ok {
+				continue
+			}
+			delete(n.pending, tx.Txid)
+			if n.txRegistry != nil {
+				n.txRegistry.remove(fab.TransactionID(tx.Txid))
+			}
+			notifications = append(notifications, CommitNotification{
+				TxID:             fab.TransactionID(tx.Txid),
+				TxValidationCode: tx.TxValidationCode,
+				BlockNumber:      block.Number,
+				Metadata:         pending.metadata,
+			})
+		}
+	}
+
+	if len(n.pending) == 0 && n.reg != nil {
+		// Unregister closes the event channel, which ends the listener
+		go n.eventService.Unregister(n.reg)
+		n.reg = nil
+	}
+
+	return notifications
+}
+
+// close stops tracking the transactions and releases the block event registration
+func (n *commitNotifier) close() {
+	n.lock.Lock()
+	reg := n.reg
+	n.reg = nil
+	n.pending = make(map[string]*pendingCommit)
+	n.lock.Unlock()
+
+	// The registration is released without holding the lock, which the listener may be waiting for
+	if reg != nil {
+		n.eventService.Unregister(reg)
+	}
+}
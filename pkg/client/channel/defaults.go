@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"github.com/pkg/errors"
+)
+
+// DefaultRequestOptionsProvider is implemented by channel contexts which provide default request options
+// for the clients created from them, e.g. the channel contexts of an SDK created with fabsdk.WithChannelRequestOptions
+type DefaultRequestOptionsProvider interface {
+	DefaultRequestOptions() []RequestOption
+}
+
+// WithDefaultRequestOptions sets request options which are applied to every request of the client before the
+// options of the request, e.g. an organization-wide retry policy or timeouts. Since the options are applied in
+// order, an option of the request overrides a default option which sets the same value, except for the options
+// which add entries to a map: the timeouts of WithTimeout, the pins of WithTargetTLSPin and the metadata of
+// WithCorrelationMetadata are merged by key, an entry of the request overriding a default entry with the same key,
+// and the codes of WithAdditionalRetryableCodes and WithoutRetryableCodes are added to the default codes.
+// The default options of the channel context (see DefaultRequestOptionsProvider) are applied before those of the
+// client.
+func WithDefaultRequestOptions(opts ...RequestOption) ClientOption {
+	return func(client *Client) error {
+		for _, opt := range opts {
+			if opt == nil {
+				return errors.New("default request option is required")
+			}
+		}
+		client.defaultOpts = append(client.defaultOpts, opts...)
+		return nil
+	}
+}
+
+//contextRequestOptions returns the default request options of the channel context, if any
+func contextRequestOptions(channelContext interface{}) []RequestOption {
+	if provider, ok := channelContext.(DefaultRequestOptionsProvider); ok {
+		return provider.DefaultRequestOptions()
+	}
+	return nil
+}
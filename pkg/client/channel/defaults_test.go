@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestDefaultRequestOptions(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+
+	err := WithDefaultRequestOptions(WithQueryQuorum(1), nil)(chClient)
+	assert.NotNil(t, err, "Expected error for nil default request option")
+
+	defaultMetadata := map[string]string{"app": "payments", "env": "test"}
+	err = WithDefaultRequestOptions(
+		WithQueryQuorum(2),
+		WithTimeout(fab.Query, time.Second),
+		WithTimeout(fab.Execute, 2*time.Second),
+		WithCorrelationMetadata(defaultMetadata),
+		WithAdditionalRetryableCodes(status.EndorserClientStatus, status.ConnectionFailed),
+	)(chClient)
+	assert.Nil(t, err)
+
+	// Defaults only
+	opts, err := chClient.prepareOptsFromOptions(chClient.context)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, opts.QueryQuorum)
+	assert.Equal(t, map[fab.TimeoutType]time.Duration{fab.Query: time.Second, fab.Execute: 2 * time.Second}, opts.Timeouts)
+	assert.Equal(t, defaultMetadata, opts.CorrelationMetadata)
+
+	// Scalars of the request override the defaults, map entries are merged by key
+	opts, err = chClient.prepareOptsFromOptions(chClient.context,
+		WithQueryQuorum(3),
+		WithTimeout(fab.Query, 3*time.Second),
+		WithCorrelationMetadata(map[string]string{"env": "prod", "order": "42"}),
+		WithAdditionalRetryableCodes(status.EndorserClientStatus, status.EndorsementMismatch),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, opts.QueryQuorum)
+	assert.Equal(t, map[fab.TimeoutType]time.Duration{fab.Query: 3 * time.Second, fab.Execute: 2 * time.Second}, opts.Timeouts)
+	assert.Equal(t, map[string]string{"app": "payments", "env": "prod", "order": "42"}, opts.CorrelationMetadata)
+	assert.Equal(t, []status.Code{status.ConnectionFailed, status.EndorsementMismatch}, opts.AddedRetryableCodes[status.EndorserClientStatus])
+
+	// The defaults aren't modified by the requests
+	assert.Equal(t, map[string]string{"app": "payments", "env": "test"}, defaultMetadata)
+	opts, err = chClient.prepareOptsFromOptions(chClient.context)
+	assert.Nil(t, err)
+	assert.Equal(t, map[fab.TimeoutType]time.Duration{fab.Query: time.Second, fab.Execute: 2 * time.Second}, opts.Timeouts)
+	assert.Equal(t, []status.Code{status.ConnectionFailed}, opts.AddedRetryableCodes[status.EndorserClientStatus])
+
+	// A failing default fails the request
+	chClient.defaultOpts = append(chClient.defaultOpts, WithQueryQuorum(0))
+	_, err = chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	assert.NotNil(t, err, "Expected error for invalid default request option")
+}
+
+func TestContextDefaultRequestOptions(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	discoveryService, err := setupTestDiscovery(nil, nil)
+	assert.Nil(t, err)
+	selectionService, err := setupTestSelection(nil, []fab.Peer{testPeer})
+	assert.Nil(t, err)
+	fabCtx := setupCustomTestContext(t, selectionService, discoveryService, nil)
+
+	channelProvider := func() (context.Channel, error) {
+		ch, err := contextImpl.NewChannel(fabCtx, channelID)
+		if err != nil {
+			return nil, err
+		}
+		return &defaultsChannelContext{Channel: ch, opts: []RequestOption{WithQueryQuorum(2), WithTimeout(fab.Query, time.Second)}}, nil
+	}
+
+	// The defaults of the client are applied after those of the context
+	chClient, err := New(channelProvider, WithDefaultRequestOptions(WithQueryQuorum(3)))
+	assert.Nil(t, err)
+
+	opts, err := chClient.prepareOptsFromOptions(chClient.context)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, opts.QueryQuorum)
+	assert.Equal(t, time.Second, opts.Timeouts[fab.Query])
+
+	opts, err = chClient.prepareOptsFromOptions(chClient.context, WithQueryQuorum(1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, opts.QueryQuorum)
+}
+
+//defaultsChannelContext is a channel context which provides default request options
+type defaultsChannelContext struct {
+	*contextImpl.Channel
+	opts []RequestOption
+}
+
+func (c *defaultsChannelContext) DefaultRequestOptions() []RequestOption {
+	return c.opts
+}
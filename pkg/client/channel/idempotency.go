@@ -0,0 +1,312 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxIdempotencyKeys = 10000
+	defaultIdempotencyWindow  = 10 * time.Minute
+)
+
+// IdempotencyRecord is the record of the transaction submitted with an idempotency key (see WithIdempotencyKey).
+// The record is created without a transaction ID when the submission begins, and the ID is recorded before the
+// transaction is sent to the orderer, so that a client which restarts after a crash may find out from the ledger
+// whether the transaction was committed. Once the commit is observed, its outcome is recorded.
+type IdempotencyRecord struct {
+	TxID             fab.TransactionID
+	Committed        bool
+	TxValidationCode pb.TxValidationCode
+	BlockNumber      uint64
+	ChaincodeStatus  int32
+	Payload          []byte
+}
+
+// IdempotencyStore stores the records of the transactions submitted with an idempotency key. The default store
+// is in memory (see WithIdempotencyLimits), so it only guards against duplicate submissions by the same client.
+// A store which is shared by the clients of several processes, e.g. backed by Redis, also guards against duplicate
+// submissions across processes and restarts. The store is responsible for the expiry of the records at the end of
+// the idempotency window of the application.
+type IdempotencyStore interface {
+	// Create stores the record of the key unless the key already has a record, in which case the existing record
+	// is returned and the store is left unchanged. The check and the creation must be atomic (e.g. SET NX).
+	Create(key string, record IdempotencyRecord) (*IdempotencyRecord, error)
+	// Update replaces the record of the key, without extending its expiry
+	Update(key string, record IdempotencyRecord) error
+	// Delete removes the record of the key
+	Delete(key string) error
+}
+
+// WithIdempotencyLimits bounds the in-memory registry of the transactions submitted with an idempotency key (see
+// WithIdempotencyKey) to maxEntries keys, each of which guards against duplicate submissions for window.
+func WithIdempotencyLimits(maxEntries int, window time.Duration) ClientOption {
+	return func(client *Client) error {
+		if maxEntries < 1 || window <= 0 {
+			return errors.Errorf("invalid idempotency limits [%d, %s]", maxEntries, window)
+		}
+		client.idempotency = NewMemoryIdempotencyStore(maxEntries, window)
+		return nil
+	}
+}
+
+// WithIdempotencyStore stores the records of the transactions submitted with an idempotency key in the given
+// store rather than in memory, e.g. to guard against duplicate submissions across restarts
+func WithIdempotencyStore(store IdempotencyStore) ClientOption {
+	return func(client *Client) error {
+		if store == nil {
+			return errors.New("idempotency store is required")
+		}
+		client.idempotency = store
+		return nil
+	}
+}
+
+//executeOnce invokes the execute function unless a transaction was submitted with the same key, in which case the
+//response of the committed transaction is returned or an AlreadySubmitted status if its outcome isn't known yet
+func (cc *Client) executeOnce(key string, execute func() (Response, error)) (Response, error) {
+	existing, err := cc.idempotency.Create(key, IdempotencyRecord{})
+	if err != nil {
+		return Response{}, errors.WithMessage(err, "recording idempotency key failed")
+	}
+
+	if existing != nil {
+		response, resubmit, err := cc.resolveSubmission(key, existing)
+		if !resubmit {
+			return response, err
+		}
+
+		// The transaction was invalidated: it may be submitted again
+		existing, err = cc.idempotency.Create(key, IdempotencyRecord{})
+		if err != nil {
+			return Response{}, errors.WithMessage(err, "recording idempotency key failed")
+		}
+		if existing != nil {
+			return Response{}, alreadySubmitted(existing.TxID)
+		}
+	}
+
+	response, err := execute()
+	cc.completeSubmission(key, response, err)
+	return response, err
+}
+
+//resolveSubmission returns the response of the transaction of the existing record if it was committed, an
+//AlreadySubmitted status if its outcome isn't known yet, or true if the transaction was invalidated, in which
+//case the record is removed. The commit of a transaction which isn't pending in this client, e.g. which was
+//submitted before a restart, is looked up in the ledger.
+func (cc *Client) resolveSubmission(key string, existing *IdempotencyRecord) (Response, bool, error) {
+	if existing.Committed {
+		logger.Debugf("Transaction [%s] with idempotency key was already committed", existing.TxID)
+		return recordResponse(existing), false, nil
+	}
+
+	if existing.TxID == "" || cc.pending.contains(existing.TxID) {
+		return Response{}, false, alreadySubmitted(existing.TxID)
+	}
+
+	committed, err := cc.queryCommittedTx(existing.TxID)
+	if err != nil {
+		logger.Debugf("Commit of transaction [%s] with idempotency key wasn't found: %s", existing.TxID, err)
+		return Response{}, false, alreadySubmitted(existing.TxID)
+	}
+
+	if committed.TxValidationCode != pb.TxValidationCode_VALID {
+		logger.Debugf("Transaction [%s] with idempotency key was invalidated [%s]", existing.TxID, committed.TxValidationCode)
+		if err := cc.idempotency.Delete(key); err != nil {
+			return Response{}, false, errors.WithMessage(err, "removing idempotency key failed")
+		}
+		return Response{}, true, nil
+	}
+
+	logger.Debugf("Transaction [%s] with idempotency key was committed in block [%d]", existing.TxID, committed.BlockNumber)
+	if err := cc.idempotency.Update(key, *committed); err != nil {
+		logger.Warnf("Recording commit of transaction [%s] with idempotency key failed: %s", existing.TxID, err)
+	}
+	return recordResponse(committed), false, nil
+}
+
+//completeSubmission records the outcome of the submission. The record is removed if the transaction certainly
+//wasn't committed, so that it may be submitted again with the same key.
+func (cc *Client) completeSubmission(key string, response Response, err error) {
+	if err == nil && response.NoCommitWait {
+		// The record of the transaction ID is kept until the commit of the transaction is resolved
+		logger.Debugf("Commit of transaction [%s] with idempotency key wasn't awaited", response.TransactionID)
+		return
+	}
+	if err == nil {
+		record := IdempotencyRecord{
+			TxID:             response.TransactionID,
+			Committed:        true,
+			TxValidationCode: response.TxValidationCode,
+			BlockNumber:      response.BlockNumber,
+			ChaincodeStatus:  response.ChaincodeStatus,
+			Payload:          response.Payload,
+		}
+		if err := cc.idempotency.Update(key, record); err != nil {
+			logger.Warnf("Recording commit of transaction [%s] with idempotency key failed: %s", response.TransactionID, err)
+		}
+		return
+	}
+
+	if outcomeUnknown(response, err, cc.pending) {
+		logger.Debugf("Outcome of transaction [%s] with idempotency key is unknown: %s", response.TransactionID, err)
+		return
+	}
+	if err := cc.idempotency.Delete(key); err != nil {
+		logger.Warnf("Removing idempotency key of transaction [%s] failed: %s", response.TransactionID, err)
+	}
+}
+
+//recordTxID records the ID of the transaction of the key before the transaction is sent to the orderer
+func (cc *Client) recordTxID(key string, txID fab.TransactionID) error {
+	return cc.idempotency.Update(key, IdempotencyRecord{TxID: txID})
+}
+
+//recordResponse returns the response of the committed transaction of the record
+func recordResponse(record *IdempotencyRecord) Response {
+	return Response{
+		TransactionID:    record.TxID,
+		TxValidationCode: record.TxValidationCode,
+		BlockNumber:      record.BlockNumber,
+		CommitObserved:   true,
+		ChaincodeStatus:  record.ChaincodeStatus,
+		Payload:          record.Payload,
+	}
+}
+
+func alreadySubmitted(txID fab.TransactionID) error {
+	return status.New(status.ClientStatus, status.AlreadySubmitted.ToInt32(),
+		"a transaction with the same idempotency key was already submitted", []interface{}{txID})
+}
+
+// outcomeUnknown returns true if the failed transaction may still be committed, i.e. it's pending or the
+// request timed out after the transaction was sent to the orderer
+func outcomeUnknown(response Response, err error, pending *pendingTxRegistry) bool {
+	if response.TransactionID != "" && pending.contains(response.TransactionID) {
+		return true
+	}
+
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.ClientStatus || s.Code != status.Timeout.ToInt32() {
+		return false
+	}
+	for _, detail := range s.Details {
+		if stage, ok := detail.(invoke.StageInfo); ok {
+			return !beforeBroadcast(stage.Stage)
+		}
+	}
+	return false
+}
+
+// MemoryIdempotencyStore is a size and TTL bounded in-memory store of the transactions submitted with an
+// idempotency key. The records are kept in the order of their creation, so that the oldest are expired or
+// evicted first.
+type MemoryIdempotencyStore struct {
+	maxEntries int
+	window     time.Duration
+	lock       sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryRecord struct {
+	key     string
+	created time.Time
+	record  IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore returns an in-memory store of at most maxEntries keys, whose records expire after
+// window. The oldest record is evicted when the store is full.
+func NewMemoryIdempotencyStore(maxEntries int, window time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		maxEntries: maxEntries,
+		window:     window,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Create stores the record of the key unless the key already has a record, which is returned
+func (s *MemoryIdempotencyStore) Create(key string, record IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.purgeExpired()
+	if e, ok := s.entries[key]; ok {
+		existing := e.Value.(*memoryRecord).record
+		return &existing, nil
+	}
+
+	s.add(key, record)
+	return nil, nil
+}
+
+// Update replaces the record of the key, which is created if it expired
+func (s *MemoryIdempotencyStore) Update(key string, record IdempotencyRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*memoryRecord).record = record
+		return nil
+	}
+
+	s.add(key, record)
+	return nil
+}
+
+// Delete removes the record of the key
+func (s *MemoryIdempotencyStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.remove(key)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) add(key string, record IdempotencyRecord) {
+	if len(s.entries) >= s.maxEntries {
+		s.evictOldest()
+	}
+	s.entries[key] = s.order.PushBack(&memoryRecord{key: key, created: time.Now(), record: record})
+}
+
+func (s *MemoryIdempotencyStore) remove(key string) {
+	if e, ok := s.entries[key]; ok {
+		s.order.Remove(e)
+		delete(s.entries, key)
+	}
+}
+
+func (s *MemoryIdempotencyStore) purgeExpired() {
+	now := time.Now()
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		entry := e.Value.(*memoryRecord)
+		if now.Sub(entry.created) <= s.window {
+			return
+		}
+		s.remove(entry.key)
+	}
+}
+
+func (s *MemoryIdempotencyStore) evictOldest() {
+	if e := s.order.Front(); e != nil {
+		oldest := e.Value.(*memoryRecord)
+		logger.Warnf("Transaction [%s] with idempotency key evicted before the end of the idempotency window", oldest.record.TxID)
+		s.remove(oldest.key)
+	}
+}
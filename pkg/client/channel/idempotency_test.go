@@ -0,0 +1,181 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteIdempotencyKey(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	type result struct {
+		response Response
+		err      error
+	}
+	resultch := make(chan result, 1)
+	go func() {
+		response, err := chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+		resultch <- result{response, err}
+	}()
+
+	// The first transaction is waiting for its commit: duplicates are rejected
+	txStatusReg := <-mockEventService.TxStatusRegCh
+	for i := 0; i < 3; i++ {
+		_, err := chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+		s, ok := status.FromError(err)
+		assert.True(t, ok, "Expected status error")
+		assert.EqualValues(t, status.AlreadySubmitted.ToInt32(), s.Code, "Expected AlreadySubmitted status")
+	}
+
+	txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID}
+	first := <-resultch
+	assert.Nil(t, first.err, "Failed to execute transaction")
+
+	// Once committed, the response of the transaction is returned without submitting it again
+	calls := testPeer.ProcessProposalCalls
+	response, err := chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Expected response of the committed transaction")
+	assert.Equal(t, first.response.TransactionID, response.TransactionID)
+	assert.Equal(t, calls, testPeer.ProcessProposalCalls, "Expected transaction not to be endorsed again")
+
+	// Other keys aren't affected
+	go commitTx(mockEventService, 1)
+	response, err = chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-2"))
+	assert.Nil(t, err, "Failed to execute transaction")
+	assert.NotEqual(t, first.response.TransactionID, response.TransactionID)
+
+	_, err = chClient.Execute(request, WithIdempotencyKey(""))
+	assert.NotNil(t, err, "Expected error for empty idempotency key")
+}
+
+func TestExecuteIdempotencyKeyResubmit(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Error = status.New(status.EndorserServerStatus, int32(500), "endorsement failed", nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	// The transaction wasn't sent to the orderer: it may be submitted again
+	_, err := chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	assert.NotNil(t, err, "Expected endorsement error")
+
+	testPeer.Error = nil
+	go commitTx(mockEventService, 1)
+	_, err = chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Expected transaction to be submitted again after failed endorsement")
+}
+
+func TestExecuteIdempotencyKeyAfterRestart(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	store := NewMemoryIdempotencyStore(10, time.Minute)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	// The ID of the transaction is recorded before it's sent to the orderer
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	assert.NotNil(t, WithIdempotencyStore(nil)(chClient), "Expected error for nil idempotency store")
+	assert.Nil(t, WithIdempotencyStore(store)(chClient))
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	errch := make(chan error, 1)
+	go func() {
+		_, err := chClient.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"),
+			WithTimeout(fab.Execute, 100*time.Millisecond))
+		errch <- err
+	}()
+	txStatusReg := <-mockEventService.TxStatusRegCh
+	record, err := store.Create("transfer-1", IdempotencyRecord{})
+	assert.Nil(t, err)
+	assert.Equal(t, fab.TransactionID(txStatusReg.TxID), record.TxID, "Expected transaction ID to be recorded")
+
+	assert.NotNil(t, <-errch, "Expected timeout waiting for commit")
+
+	// A client sharing the store after a restart looks up the commit of the transaction in the ledger
+	restarted := setupChannelClient([]fab.Peer{testPeer}, t)
+	assert.Nil(t, WithIdempotencyStore(store)(restarted))
+	restarted.eventService = fcmocks.NewMockEventService()
+
+	testPeer.Payload = []byte("invalid")
+	_, err = restarted.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.AlreadySubmitted.ToInt32(), s.Code, "Expected AlreadySubmitted status while the commit isn't found")
+
+	block := servicemocks.NewBlock(channelID, servicemocks.NewTransaction(txStatusReg.TxID, pb.TxValidationCode_VALID, common.HeaderType_ENDORSER_TRANSACTION))
+	block.Header.Number = 7
+	testPeer.Payload = marshal(t, block)
+	calls := testPeer.ProcessProposalCalls
+	response, err := restarted.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Expected response of the committed transaction")
+	assert.Equal(t, fab.TransactionID(txStatusReg.TxID), response.TransactionID)
+	assert.EqualValues(t, 7, response.BlockNumber)
+	assert.True(t, response.CommitObserved)
+	assert.Equal(t, calls+1, testPeer.ProcessProposalCalls, "Expected the ledger to be queried rather than the transaction endorsed again")
+
+	// The commit is recorded
+	response, err = restarted.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-1"))
+	assert.Nil(t, err, "Expected response of the committed transaction")
+	assert.EqualValues(t, 7, response.BlockNumber)
+	assert.Equal(t, calls+1, testPeer.ProcessProposalCalls, "Expected recorded commit to be returned")
+
+	// An invalidated transaction is submitted again
+	assert.Nil(t, store.Update("transfer-2", IdempotencyRecord{TxID: "txid2"}))
+	block = servicemocks.NewBlock(channelID, servicemocks.NewTransaction("txid2", pb.TxValidationCode_MVCC_READ_CONFLICT, common.HeaderType_ENDORSER_TRANSACTION))
+	testPeer.Payload = marshal(t, block)
+	restartedEvents := fcmocks.NewMockEventService()
+	restarted.eventService = restartedEvents
+	go commitTx(restartedEvents, 8)
+	response, err = restarted.Execute(request, WithTargets(testPeer), WithIdempotencyKey("transfer-2"))
+	assert.Nil(t, err, "Expected invalidated transaction to be submitted again")
+	assert.NotEqual(t, fab.TransactionID("txid2"), response.TransactionID)
+	assert.EqualValues(t, 8, response.BlockNumber)
+}
+
+func TestMemoryIdempotencyStoreLimits(t *testing.T) {
+	store := NewMemoryIdempotencyStore(2, 50*time.Millisecond)
+
+	for _, key := range []string{"a", "b", "c"} {
+		existing, err := store.Create(key, IdempotencyRecord{TxID: "txid"})
+		assert.Nil(t, err)
+		assert.Nil(t, existing)
+	}
+	assert.Len(t, store.entries, 2, "Expected oldest key to be evicted")
+	assert.Nil(t, store.entries["a"])
+
+	existing, err := store.Create("c", IdempotencyRecord{})
+	assert.Nil(t, err)
+	assert.Equal(t, fab.TransactionID("txid"), existing.TxID, "Expected existing record to be returned")
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = store.Create("d", IdempotencyRecord{})
+	assert.Nil(t, err)
+	assert.Len(t, store.entries, 1, "Expected expired keys to be purged")
+
+	assert.Nil(t, store.Delete("d"))
+	assert.Len(t, store.entries, 0)
+
+	err = WithIdempotencyLimits(0, time.Minute)(&Client{})
+	assert.NotNil(t, err, "Expected error for invalid limits")
+}
@@ -0,0 +1,141 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/pkg/errors"
+)
+
+//NewOrgAffinityHandler returns a handler that checks that a request pinned to an organization may be endorsed by
+//the peers of the organization alone
+func NewOrgAffinityHandler(next ...Handler) *OrgAffinityHandler {
+	return &OrgAffinityHandler{next: getNext(next)}
+}
+
+//OrgAffinityHandler checks, for a request pinned to the peers of an organization (see Opts.OrgAffinity), that the
+//targets belong to the organization and that the endorsement policy of the chaincode may be satisfied by the
+//organization alone, so that the request fails before it's endorsed rather than being invalidated. The policy is
+//queried from lscc on the targets. The constraint is recorded in the response. Requests which aren't pinned to an
+//organization are passed on as they are.
+type OrgAffinityHandler struct {
+	next Handler
+}
+
+//Handle checks the targets and the endorsement policy against the organization of the request
+func (h *OrgAffinityHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	if mspID := requestContext.Opts.OrgAffinity; mspID != "" {
+		if err := checkOrgAffinity(requestContext, clientContext, mspID); err != nil {
+			requestContext.Error = err
+			return
+		}
+		requestContext.Response.OrgAffinity = mspID
+	}
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+//checkOrgAffinity checks that the targets belong to the given MSP and that the endorsement policy may be satisfied by it
+func checkOrgAffinity(requestContext *RequestContext, clientContext *ClientContext, mspID string) error {
+	for _, target := range requestContext.Opts.Targets {
+		if target.MSPID() != mspID {
+			return errors.Errorf("target [%s] of MSP [%s] violates the affinity of the request to MSP [%s]", target.URL(), target.MSPID(), mspID)
+		}
+	}
+
+	policy, err := queryEndorsementPolicy(requestContext, clientContext)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get endorsement policy")
+	}
+
+	if !satisfiableByMSP(policy.Rule, policy.Identities, mspID) {
+		missing := policyMSPIDs(policy.Identities, mspID)
+		return status.New(status.EndorserClientStatus, status.EndorsementPolicyNotSatisfied.ToInt32(),
+			fmt.Sprintf("endorsement policy of chaincode [%s] can't be satisfied by MSP [%s] alone: endorsements of MSPs %v are required",
+				requestContext.Request.ChaincodeID, mspID, missing), []interface{}{missing})
+	}
+	return nil
+}
+
+//satisfiableByMSP returns whether the signature policy may be satisfied by the endorsements of the given MSP,
+//assuming that the MSP has enough peers to satisfy each of its principals
+func satisfiableByMSP(policy *common.SignaturePolicy, principals []*mb.MSPPrincipal, mspID string) bool {
+	switch t := policy.GetType().(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(principals) {
+			return false
+		}
+		principalMSP, ok := principalMSPID(principals[t.SignedBy])
+		return ok && principalMSP == mspID
+
+	case *common.SignaturePolicy_NOutOf_:
+		satisfiable := int32(0)
+		for _, rule := range t.NOutOf.Rules {
+			if satisfiableByMSP(rule, principals, mspID) {
+				satisfiable++
+			}
+		}
+		return satisfiable >= t.NOutOf.N
+
+	default:
+		return false
+	}
+}
+
+//policyMSPIDs returns the sorted MSP IDs of the principals of the policy, except for the given MSP
+func policyMSPIDs(principals []*mb.MSPPrincipal, except string) []string {
+	var mspIDs []string
+	seen := make(map[string]bool)
+	for _, principal := range principals {
+		mspID, ok := principalMSPID(principal)
+		if !ok || mspID == except || seen[mspID] {
+			continue
+		}
+		seen[mspID] = true
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	return mspIDs
+}
+
+//principalMSPID returns the MSP ID of the principal
+func principalMSPID(principal *mb.MSPPrincipal) (string, bool) {
+	switch principal.PrincipalClassification {
+	case mb.MSPPrincipal_ROLE:
+		role := &mb.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return "", false
+		}
+		return role.MspIdentifier, true
+
+	case mb.MSPPrincipal_ORGANIZATION_UNIT:
+		unit := &mb.OrganizationUnit{}
+		if err := proto.Unmarshal(principal.Principal, unit); err != nil {
+			return "", false
+		}
+		return unit.MspIdentifier, true
+
+	case mb.MSPPrincipal_IDENTITY:
+		identity := &mb.SerializedIdentity{}
+		if err := proto.Unmarshal(principal.Principal, identity); err != nil {
+			return "", false
+		}
+		return identity.Mspid, true
+
+	default:
+		return "", false
+	}
+}
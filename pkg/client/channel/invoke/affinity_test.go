@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgAffinityHandler(t *testing.T) {
+	// Endorsements are required from Org1MSP, Org2MSP and Org3MSP
+	lsccPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	lsccPeer.SetMSPID("Org1MSP")
+	lsccPeer.Payload = newChaincodeData(t, newAndPolicy(t, "Org1MSP", "Org3MSP", "Org2MSP"))
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("b")}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer}, OrgAffinity: "Org1MSP"}, t)
+	NewOrgAffinityHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.EndorsementPolicyNotSatisfied.ToInt32(), s.Code, "expected endorsement policy not satisfied")
+	assert.Equal(t, []interface{}{[]string{"Org2MSP", "Org3MSP"}}, s.Details, "expected missing MSPs")
+	assert.Contains(t, s.Message, "[Org2MSP Org3MSP]")
+	assert.Empty(t, requestContext.Response.OrgAffinity)
+
+	// 1 out of Org1MSP and Org2MSP may be satisfied by Org1MSP alone
+	policy := newAndPolicy(t, "Org1MSP", "Org2MSP")
+	policy.Rule.GetNOutOf().N = 1
+	lsccPeer.Payload = newChaincodeData(t, policy)
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer}, OrgAffinity: "Org1MSP"}, t)
+	NewOrgAffinityHandler().Handle(requestContext, clientContext)
+	assert.NoError(t, requestContext.Error)
+	assert.Equal(t, "Org1MSP", requestContext.Response.OrgAffinity, "expected org affinity to be recorded")
+
+	// The targets must belong to the MSP
+	otherPeer := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	otherPeer.SetMSPID("Org2MSP")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer, otherPeer}, OrgAffinity: "Org1MSP"}, t)
+	NewOrgAffinityHandler().Handle(requestContext, clientContext)
+	assert.Error(t, requestContext.Error)
+
+	// Requests which aren't pinned to an MSP aren't checked
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{otherPeer}}, t)
+	NewOrgAffinityHandler().Handle(requestContext, clientContext)
+	assert.NoError(t, requestContext.Error)
+	assert.Empty(t, requestContext.Response.OrgAffinity)
+}
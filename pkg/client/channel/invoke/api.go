@@ -9,10 +9,12 @@ package invoke
 
 import (
 	reqContext "context"
+	"sync/atomic"
 	"time"
 
 	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
@@ -20,11 +22,40 @@ import (
 
 // Opts allows the user to specify more advanced options
 type Opts struct {
-	Targets       []fab.Peer // targets
-	TargetFilter  fab.TargetFilter
-	Retry         retry.Opts
-	Timeouts      map[fab.TimeoutType]time.Duration
-	ParentContext reqContext.Context //parent grpc context
+	Targets                 []fab.Peer // targets
+	TargetFilter            fab.TargetFilter
+	TargetSorter            fab.TargetSorter
+	ChaincodeInterests      []fab.CCInterest
+	OrgAffinity             string
+	Retry                   retry.Opts
+	RetryableCodes          map[status.Group][]status.Code
+	AddedRetryableCodes     map[status.Group][]status.Code
+	ExcludedRetryableCodes  map[status.Group][]status.Code
+	MaxElapsedTime          time.Duration
+	BeforeRetry             retry.BeforeRetryHandler
+	AfterAttempt            func(attempt int, err error)
+	CorrelationMetadata     map[string]string
+	CorrelationID           string
+	BlockCommitWait         bool
+	NoCommitWait            bool
+	EventServiceUnavailable CommitWaitMode
+	CCEventCapture          string
+	MaxBlockHeightSelection bool
+	BypassCache             bool
+	BypassGreylist          bool
+	Orderer                 string
+	OrdererFilter           func(fab.Orderer) bool
+	ShuffleOrderers         bool
+	OrdererComparator       func(o1, o2 fab.Orderer) bool
+	EndorsementThreshold    int
+	EndorsementComparator   EndorsementComparator
+	EndorsementValidator    EndorsementValidator
+	QueryQuorum             int
+	TLSPins                 map[string][]byte
+	ProgressNotifier        chan<- TxProgress
+	IdempotencyKey          string
+	Timeouts                map[fab.TimeoutType]time.Duration
+	ParentContext           reqContext.Context //parent grpc context
 }
 
 // Request contains the parameters to execute transaction
@@ -35,22 +66,31 @@ type Request struct {
 	TransientMap map[string][]byte
 }
 
-//Response contains response parameters for query and execute transaction
+// Response contains response parameters for query and execute transaction
 type Response struct {
 	Proposal         *fab.TransactionProposal
 	Responses        []*fab.TransactionProposalResponse
 	TransactionID    fab.TransactionID
 	TxValidationCode pb.TxValidationCode
+	BlockNumber      uint64
+	CommitObserved   bool
+	NoCommitWait     bool
+	CCEvent          *fab.CCEvent
 	ChaincodeStatus  int32
 	Payload          []byte
+	FailedEndorsers  map[string]error
+	SelectedTargets  []string
+	Orderer          string
+	FailedOrderers   map[string]error
+	OrgAffinity      string
 }
 
-//Handler for chaining transaction executions
+// Handler for chaining transaction executions
 type Handler interface {
 	Handle(context *RequestContext, clientContext *ClientContext)
 }
 
-//ClientContext contains context parameters for handler execution
+// ClientContext contains context parameters for handler execution
 type ClientContext struct {
 	CryptoSuite  core.CryptoSuite
 	Discovery    fab.DiscoveryService
@@ -60,7 +100,94 @@ type ClientContext struct {
 	EventService fab.EventService
 }
 
-//RequestContext contains request, opts, response parameters for handler execution
+// Stage is a stage of the invocation performed by the handlers
+type Stage string
+
+const (
+	// StageSelection is the selection of the peers to send the proposal to
+	StageSelection Stage = "selection"
+	// StageEndorsement is the endorsement of the proposal by the targets
+	StageEndorsement Stage = "endorsement"
+	// StageValidation is the validation of the proposal responses
+	StageValidation Stage = "validation"
+	// StageBroadcast is the broadcast of the transaction to the orderer
+	StageBroadcast Stage = "broadcast"
+	// StageCommit is the wait for the commit event of the transaction
+	StageCommit Stage = "commit"
+)
+
+// StageInfo is the stage the handlers are at along with the targets (by URL) the stage is waiting for
+type StageInfo struct {
+	Stage   Stage
+	Targets []string
+}
+
+// RequestLabels identify the request whose latency is observed
+type RequestLabels struct {
+	ChannelID   string
+	ChaincodeID string
+}
+
+// MetricsObserver observes the latency of the requests and of their stages, e.g. to export it as metrics.
+// The observer is invoked synchronously by the handlers so it must return promptly.
+type MetricsObserver interface {
+	// ObserveStage is invoked once a stage of the request completes, with the targets (by URL) of the stage,
+	// i.e. the peers of the endorsement or the orderer of the broadcast
+	ObserveStage(stage Stage, duration time.Duration, targets []string, labels RequestLabels)
+	// ObserveRequest is invoked once the request completes, with the status code of its error (status.OK on success)
+	ObserveRequest(duration time.Duration, code int32, labels RequestLabels)
+}
+
+//stageState is the stage the handlers are at along with the time at which the stage started and its initial targets
+type stageState struct {
+	info    StageInfo
+	started time.Time
+	targets []string
+}
+
+// ProgressStage is a stage of the transaction lifecycle reported to the progress notifier of the request
+type ProgressStage string
+
+const (
+	// ProgressSelected is reported once the targets of the proposal are selected
+	ProgressSelected ProgressStage = "selected"
+	// ProgressEndorsed is reported once the proposal is endorsed
+	ProgressEndorsed ProgressStage = "endorsed"
+	// ProgressBroadcast is reported once the transaction is sent to the orderer
+	ProgressBroadcast ProgressStage = "broadcast"
+	// ProgressCommitted is reported once the transaction is committed as valid
+	ProgressCommitted ProgressStage = "committed"
+)
+
+// CommitWaitMode determines what Execute does when the event service which would deliver the commit
+// event of the transaction isn't connected before the transaction is broadcast
+type CommitWaitMode int
+
+const (
+	// WaitForCommit broadcasts the transaction and waits for its commit event until the request times out, in case
+	// the event service reconnects in the meantime. It's the default.
+	WaitForCommit CommitWaitMode = iota
+	// FailFast fails the request with the EventServiceUnavailable status, without broadcasting the transaction
+	FailFast
+	// ProceedWithoutCommitWait broadcasts the transaction and returns without waiting for its commit, as if the
+	// commit wait was skipped with NoCommitWait. A request which captures a chaincode event fails fast instead,
+	// since the event can only be captured from the committing block.
+	ProceedWithoutCommitWait
+)
+
+// TxProgress is the progress of a request through the transaction lifecycle
+type TxProgress struct {
+	Stage ProgressStage
+	Time  time.Time
+	// Targets are the URLs of the selected targets, or of the endorsers once endorsed
+	Targets []string
+	// TxID is the ID of the transaction, which is known once the proposal is created
+	TxID fab.TransactionID
+	// BlockNumber is the number of the block which committed the transaction
+	BlockNumber uint64
+}
+
+// RequestContext contains request, opts, response parameters for handler execution
 type RequestContext struct {
 	Request         Request
 	Opts            Opts
@@ -69,4 +196,95 @@ type RequestContext struct {
 	RetryHandler    retry.Handler
 	Ctx             reqContext.Context
 	SelectionFilter selectopts.PeerFilter
+	ChannelID       string
+	MetricsObserver MetricsObserver
+	stage           atomic.Value
+}
+
+// SetStage records the stage the handlers are at and the targets it's waiting for, which are reported
+// if the request times out. It may be called while the request is in flight.
+// The completion of the previous stage is reported to the metrics observer of the request, if any.
+func (rc *RequestContext) SetStage(stage Stage, targets ...string) {
+	now := time.Now()
+	current, _ := rc.stage.Load().(stageState)
+	if current.info.Stage == stage {
+		rc.stage.Store(stageState{info: StageInfo{Stage: stage, Targets: targets}, started: current.started, targets: current.targets})
+		return
+	}
+	rc.observeStage(current, now)
+	rc.stage.Store(stageState{info: StageInfo{Stage: stage, Targets: targets}, started: now, targets: targets})
+}
+
+// ObserveCompletion reports the completion of the current stage and of the request which started at the given
+// time to the metrics observer of the request, if any
+func (rc *RequestContext) ObserveCompletion(started time.Time, err error) {
+	if rc.MetricsObserver == nil {
+		return
+	}
+
+	current, _ := rc.stage.Load().(stageState)
+	rc.observeStage(current, time.Now())
+	rc.ObserveRequest(started, err)
+}
+
+// ObserveRequest reports the completion of the request which started at the given time to the metrics observer of
+// the request, if any, e.g. when the request timed out while its handlers are still in flight
+func (rc *RequestContext) ObserveRequest(started time.Time, err error) {
+	if rc.MetricsObserver == nil {
+		return
+	}
+
+	code := status.OK.ToInt32()
+	if err != nil {
+		code = status.Unknown.ToInt32()
+		if s, ok := status.FromError(err); ok {
+			code = s.Code
+		}
+	}
+	rc.MetricsObserver.ObserveRequest(time.Since(started), code, rc.labels())
+}
+
+//observeStage reports the completion of the given stage to the metrics observer, if any
+func (rc *RequestContext) observeStage(state stageState, completed time.Time) {
+	if rc.MetricsObserver == nil || state.info.Stage == "" {
+		return
+	}
+
+	targets := state.targets
+	if state.info.Stage == StageBroadcast && rc.Response.Orderer != "" {
+		targets = []string{rc.Response.Orderer}
+	}
+	rc.MetricsObserver.ObserveStage(state.info.Stage, completed.Sub(state.started), targets, rc.labels())
+}
+
+func (rc *RequestContext) labels() RequestLabels {
+	return RequestLabels{ChannelID: rc.ChannelID, ChaincodeID: rc.Request.ChaincodeID}
+}
+
+// NotifyProgress reports the stage of the transaction lifecycle to the progress notifier of the request, if any,
+// along with the transaction ID and block number of the response. The notification is dropped rather than
+// block if the notifier isn't ready to receive it.
+func (rc *RequestContext) NotifyProgress(stage ProgressStage, targets ...string) {
+	if rc.Opts.ProgressNotifier == nil {
+		return
+	}
+
+	progress := TxProgress{
+		Stage:       stage,
+		Time:        time.Now(),
+		Targets:     targets,
+		TxID:        rc.Response.TransactionID,
+		BlockNumber: rc.Response.BlockNumber,
+	}
+	select {
+	case rc.Opts.ProgressNotifier <- progress:
+	default:
+		// A slow consumer mustn't stall the transaction
+	}
+}
+
+// Stage returns the stage the handlers are at, which is empty if no handler recorded it
+func (rc *RequestContext) Stage() StageInfo {
+	stage, _ := rc.stage.Load().(stageState)
+	return stage.info
 }
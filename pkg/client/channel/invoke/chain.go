@@ -0,0 +1,172 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Names of the steps of the built-in handlers of a Chain
+const (
+	SelectStep               = "select"
+	CheckOrgAffinityStep     = "check-org-affinity"
+	EndorseStep              = "endorse"
+	ValidateEndorsementsStep = "validate-endorsements"
+	CheckSignatureStep       = "check-signature"
+	CommitStep               = "commit"
+)
+
+// Chain builds a chain of handlers, in which each handler invokes the handler of the next step unless it
+// failed. The built-in steps are appended in the order of the invocation, e.g.
+//  invoke.NewChain().Select().Endorse().ValidateEndorsements().CheckSignature().Commit().Build()
+// and user-defined handlers may be inserted between the named steps, e.g. InsertAfter(invoke.EndorseStep, h).
+type Chain struct {
+	steps []chainStep
+	err   error
+}
+
+type chainStep struct {
+	name       string
+	newHandler func(next Handler) Handler
+}
+
+// NewChain returns an empty chain of handlers
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// NewQueryChain returns the chain of the query handler (see NewQueryHandler), to which handlers may be appended
+// or inserted. Unlike NewQueryHandler, which only invokes the first of the next handlers, all the handlers
+// appended with Then are invoked in turn.
+func NewQueryChain() *Chain {
+	return NewChain().Select().Endorse().ValidateEndorsements().CheckSignature()
+}
+
+// NewExecuteChain returns the chain of the execute handler (see NewExecuteHandler), to which handlers may be
+// appended or inserted. Unlike NewExecuteHandler, which only invokes the first of the next handlers, all the
+// handlers appended with Then are invoked in turn.
+func NewExecuteChain() *Chain {
+	return NewQueryChain().Commit()
+}
+
+// Select appends the step selecting the endorsers of the chaincode unless the targets are given
+func (c *Chain) Select() *Chain {
+	return c.Step(SelectStep, func(next Handler) Handler { return NewProposalProcessorHandler(next) })
+}
+
+// CheckOrgAffinity appends the step checking that a request pinned to an organization may be endorsed by the
+// organization alone (see OrgAffinityHandler)
+func (c *Chain) CheckOrgAffinity() *Chain {
+	return c.Step(CheckOrgAffinityStep, func(next Handler) Handler { return NewOrgAffinityHandler(next) })
+}
+
+// Endorse appends the step sending the proposal to the targets
+func (c *Chain) Endorse() *Chain {
+	return c.Step(EndorseStep, func(next Handler) Handler { return NewEndorsementHandler(next) })
+}
+
+// ValidateEndorsements appends the step checking that the endorsements succeeded and match
+func (c *Chain) ValidateEndorsements() *Chain {
+	return c.Step(ValidateEndorsementsStep, func(next Handler) Handler { return NewEndorsementValidationHandler(next) })
+}
+
+// CheckSignature appends the step verifying the signatures of the endorsements
+func (c *Chain) CheckSignature() *Chain {
+	return c.Step(CheckSignatureStep, func(next Handler) Handler { return NewSignatureValidationHandler(next) })
+}
+
+// Commit appends the step sending the transaction to the orderer and waiting for its commit
+func (c *Chain) Commit() *Chain {
+	return c.Step(CommitStep, func(next Handler) Handler { return NewCommitHandler(next) })
+}
+
+// Step appends a named step whose handler is created with the handler of the next step, which is nil for the
+// last step. It's meant for handlers which need to run code around the following steps.
+func (c *Chain) Step(name string, newHandler func(next Handler) Handler) *Chain {
+	c.steps = append(c.steps, chainStep{name: name, newHandler: newHandler})
+	return c
+}
+
+// Then appends the given handlers, which are invoked in turn unless one of them fails
+func (c *Chain) Then(handlers ...Handler) *Chain {
+	c.steps = append(c.steps, handlerSteps(handlers)...)
+	return c
+}
+
+// InsertAfter inserts the given handlers after the named step
+func (c *Chain) InsertAfter(name string, handlers ...Handler) *Chain {
+	return c.insert(name, 1, handlers)
+}
+
+// InsertBefore inserts the given handlers before the named step
+func (c *Chain) InsertBefore(name string, handlers ...Handler) *Chain {
+	return c.insert(name, 0, handlers)
+}
+
+func (c *Chain) insert(name string, offset int, handlers []Handler) *Chain {
+	for i, step := range c.steps {
+		if name != "" && step.name == name {
+			steps := append([]chainStep{}, c.steps[:i+offset]...)
+			steps = append(steps, handlerSteps(handlers)...)
+			c.steps = append(steps, c.steps[i+offset:]...)
+			return c
+		}
+	}
+	if c.err == nil {
+		c.err = errors.Errorf("chain has no step named [%s]", name)
+	}
+	return c
+}
+
+// Build returns the first handler of the chain
+func (c *Chain) Build() (Handler, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if len(c.steps) == 0 {
+		return nil, errors.New("chain has no steps")
+	}
+	return c.handler(), nil
+}
+
+//handler links the handlers of the steps, from the last one
+func (c *Chain) handler() Handler {
+	var next Handler
+	for i := len(c.steps) - 1; i >= 0; i-- {
+		next = c.steps[i].newHandler(next)
+	}
+	return next
+}
+
+//handlerSteps returns unnamed steps invoking the given handlers
+func handlerSteps(handlers []Handler) []chainStep {
+	var steps []chainStep
+	for _, handler := range handlers {
+		if handler == nil {
+			continue
+		}
+		h := handler
+		steps = append(steps, chainStep{newHandler: func(next Handler) Handler {
+			return &sequenceHandler{handler: h, next: next}
+		}})
+	}
+	return steps
+}
+
+//sequenceHandler invokes a handler and then, unless it failed, the next handler
+type sequenceHandler struct {
+	handler Handler
+	next    Handler
+}
+
+//Handle invokes the handler and the next handler
+func (h *sequenceHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	h.handler.Handle(requestContext, clientContext)
+	if requestContext.Error == nil && h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+type recordingHandler struct {
+	name     string
+	invoked  *[]string
+	response Response
+	err      error
+}
+
+func (h *recordingHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	*h.invoked = append(*h.invoked, h.name)
+	h.response = requestContext.Response
+	if h.err != nil {
+		requestContext.Error = h.err
+	}
+}
+
+func TestChain(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}}
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.Payload = []byte("value")
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{peer1}, t)
+
+	var invoked []string
+	afterEndorse := &recordingHandler{name: "afterEndorse", invoked: &invoked}
+	beforeEndorse := &recordingHandler{name: "beforeEndorse", invoked: &invoked}
+	last := &recordingHandler{name: "last", invoked: &invoked}
+
+	handler, err := NewChain().Select().Endorse().ValidateEndorsements().CheckSignature().
+		Then(last).
+		InsertAfter(EndorseStep, afterEndorse).
+		InsertBefore(EndorseStep, beforeEndorse).
+		Build()
+	assert.Nil(t, err)
+
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []string{"beforeEndorse", "afterEndorse", "last"}, invoked)
+
+	// The handlers see the response populated by the preceding steps
+	assert.Nil(t, beforeEndorse.response.Responses, "Expected no endorsements before the endorse step")
+	assert.Equal(t, []string{"peer1:7051"}, beforeEndorse.response.SelectedTargets)
+	assert.Len(t, afterEndorse.response.Responses, 1)
+	assert.Equal(t, []byte("value"), afterEndorse.response.Payload)
+	assert.NotEmpty(t, afterEndorse.response.TransactionID)
+
+	// A failed handler stops the chain
+	invoked = nil
+	afterEndorse.err = errors.New("custom validation failed")
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	handler.Handle(requestContext, clientContext)
+	assert.Equal(t, afterEndorse.err, requestContext.Error)
+	assert.Equal(t, []string{"beforeEndorse", "afterEndorse"}, invoked)
+}
+
+func TestQueryChain(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{fcmocks.NewMockPeer("p1", "peer1:7051")}, t)
+
+	var invoked []string
+	first := &recordingHandler{name: "first", invoked: &invoked}
+	second := &recordingHandler{name: "second", invoked: &invoked}
+
+	// The query handler only invokes the first of the next handlers
+	NewQueryHandler(first, second).Handle(prepareRequestContext(request, Opts{}, t), clientContext)
+	assert.Equal(t, []string{"first"}, invoked)
+
+	invoked = nil
+	handler, err := NewQueryChain().Then(first, second).Build()
+	assert.Nil(t, err)
+	handler.Handle(prepareRequestContext(request, Opts{}, t), clientContext)
+	assert.Equal(t, []string{"first", "second"}, invoked)
+}
+
+func TestChainStep(t *testing.T) {
+	var invoked []string
+	handler, err := NewChain().
+		Step("around", func(next Handler) Handler {
+			return &aroundHandler{invoked: &invoked, next: next}
+		}).
+		Then(&recordingHandler{name: "inner", invoked: &invoked}).
+		Build()
+	assert.Nil(t, err)
+
+	handler.Handle(prepareRequestContext(Request{}, Opts{}, t), setupChannelClientContext(nil, nil, nil, t))
+	assert.Equal(t, []string{"before", "inner", "after"}, invoked)
+}
+
+func TestChainErrors(t *testing.T) {
+	_, err := NewChain().Build()
+	assert.NotNil(t, err, "Expected error for empty chain")
+
+	_, err = NewChain().Select().Endorse().InsertAfter(CommitStep, NewSignatureValidationHandler()).Build()
+	assert.NotNil(t, err, "Expected error for unknown step")
+}
+
+type aroundHandler struct {
+	invoked *[]string
+	next    Handler
+}
+
+func (h *aroundHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	*h.invoked = append(*h.invoked, "before")
+	h.next.Handle(requestContext, clientContext)
+	*h.invoked = append(*h.invoked, "after")
+}
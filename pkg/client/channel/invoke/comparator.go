@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	rwsetutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// EndorsementComparator returns true if the two proposal responses are consistent, i.e. the endorsements of the
+// peers may be accepted together
+type EndorsementComparator func(r1, r2 *pb.ProposalResponse) (bool, error)
+
+// ByteExact compares the proposal response payloads byte for byte, along with the payloads of the chaincode
+// responses. It's the default comparator.
+func ByteExact(r1, r2 *pb.ProposalResponse) (bool, error) {
+	return bytes.Equal(r1.Payload, r2.Payload) && bytes.Equal(r1.GetResponse().GetPayload(), r2.GetResponse().GetPayload()), nil
+}
+
+// PayloadOnly compares the payloads of the chaincode responses and the write sets of the proposal responses,
+// ignoring the chaincode events and the read sets (including the versions of the keys read). It tolerates
+// chaincodes whose events differ between endorsers, e.g. because they contain a timestamp.
+// Note that a transaction is assembled with the proposal response payload of the first endorser: the endorsements
+// of the peers whose payload differs don't count towards the endorsement policy when the transaction is validated.
+func PayloadOnly(r1, r2 *pb.ProposalResponse) (bool, error) {
+	if !bytes.Equal(r1.GetResponse().GetPayload(), r2.GetResponse().GetPayload()) {
+		return false, nil
+	}
+
+	w1, err := writeSet(r1)
+	if err != nil {
+		return false, err
+	}
+	w2, err := writeSet(r2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(w1, w2), nil
+}
+
+//writeSet returns the read-write set of the proposal response stripped of its reads
+func writeSet(r *pb.ProposalResponse) ([]byte, error) {
+	prp := &pb.ProposalResponsePayload{}
+	if err := proto.Unmarshal(r.Payload, prp); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of proposal response payload failed")
+	}
+	action := &pb.ChaincodeAction{}
+	if err := proto.Unmarshal(prp.Extension, action); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of chaincode action failed")
+	}
+	txRwSet := &rwsetutil.TxRwSet{}
+	if err := txRwSet.FromProtoBytes(action.Results); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of read-write set failed")
+	}
+
+	writes := &rwsetutil.TxRwSet{}
+	for _, ns := range txRwSet.NsRwSets {
+		nsWrites := &rwsetutil.NsRwSet{
+			NameSpace: ns.NameSpace,
+			KvRwSet:   &kvrwset.KVRWSet{Writes: ns.KvRwSet.GetWrites()},
+		}
+		for _, coll := range ns.CollHashedRwSets {
+			nsWrites.CollHashedRwSets = append(nsWrites.CollHashedRwSets, &rwsetutil.CollHashedRwSet{
+				CollectionName: coll.CollectionName,
+				HashedRwSet:    &kvrwset.HashedRWSet{HashedWrites: coll.HashedRwSet.GetHashedWrites()},
+			})
+		}
+		writes.NsRwSets = append(writes.NsRwSets, nsWrites)
+	}
+	return writes.ToProtoBytes()
+}
@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	rwsetutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestPayloadOnlyComparator(t *testing.T) {
+	r1 := proposalResponse(t, "value", 1, "event at 10:00:00", "value2")
+	// Same writes, different read versions and events
+	r2 := proposalResponse(t, "value", 2, "event at 10:00:01", "value2")
+
+	match, err := ByteExact(r1.ProposalResponse, r2.ProposalResponse)
+	assert.Nil(t, err)
+	assert.False(t, match, "Expected byte-exact comparison to detect differing events")
+
+	match, err = PayloadOnly(r1.ProposalResponse, r2.ProposalResponse)
+	assert.Nil(t, err)
+	assert.True(t, match, "Expected events and reads to be ignored")
+
+	match, err = PayloadOnly(r1.ProposalResponse, proposalResponse(t, "value", 1, "event at 10:00:00", "other value2").ProposalResponse)
+	assert.Nil(t, err)
+	assert.False(t, match, "Expected differing writes to be detected")
+
+	match, err = PayloadOnly(r1.ProposalResponse, proposalResponse(t, "other value", 1, "event at 10:00:00", "value2").ProposalResponse)
+	assert.Nil(t, err)
+	assert.False(t, match, "Expected differing response payloads to be detected")
+
+	_, err = PayloadOnly(r1.ProposalResponse, &pb.ProposalResponse{Response: &pb.Response{Payload: []byte("value")}, Payload: []byte("invalid")})
+	assert.NotNil(t, err, "Expected error for invalid proposal response payload")
+
+	// The responses are compared byte for byte unless a comparator is given
+	err = matchAll([]*fab.TransactionProposalResponse{r1, r2}, nil)
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, int32(status.EndorsementMismatch), s.Code, "expected endorsement mismatch")
+	assert.Nil(t, matchAll([]*fab.TransactionProposalResponse{r1, r2}, PayloadOnly))
+}
+
+func proposalResponse(t *testing.T, payload string, readVersion uint64, event string, write string) *fab.TransactionProposalResponse {
+	txRwSet := &rwsetutil.TxRwSet{NsRwSets: []*rwsetutil.NsRwSet{{
+		NameSpace: "ns1",
+		KvRwSet: &kvrwset.KVRWSet{
+			Reads:  []*kvrwset.KVRead{{Key: "key1", Version: &kvrwset.Version{BlockNum: readVersion}}},
+			Writes: []*kvrwset.KVWrite{{Key: "key2", Value: []byte(write)}},
+		},
+	}}}
+	results, err := txRwSet.ToProtoBytes()
+	assert.Nil(t, err)
+
+	events, err := proto.Marshal(&pb.ChaincodeEvent{ChaincodeId: "ns1", EventName: "event", Payload: []byte(event)})
+	assert.Nil(t, err)
+	action, err := proto.Marshal(&pb.ChaincodeAction{Results: results, Events: events, Response: &pb.Response{Status: http.StatusOK, Payload: []byte(payload)}})
+	assert.Nil(t, err)
+	prp, err := proto.Marshal(&pb.ProposalResponsePayload{ProposalHash: []byte("hash"), Extension: action})
+	assert.Nil(t, err)
+
+	return &fab.TransactionProposalResponse{
+		Endorser: "peer",
+		Status:   http.StatusOK,
+		ProposalResponse: &pb.ProposalResponse{
+			Response: &pb.Response{Status: http.StatusOK, Payload: []byte(payload)},
+			Payload:  prp,
+		},
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/pkg/errors"
+)
+
+//ordererSender is implemented by transactors which can send the transaction to the orderers of the caller's choice
+type ordererSender interface {
+	Orderers() []fab.Orderer
+	SendTransactionToOrderers(tx *fab.Transaction, orderers []fab.Orderer) (*fab.TransactionResponse, error)
+}
+
+//broadcastTransaction creates the transaction from the endorsements and sends it to the orderers selected
+//by the request options, one after the other until an orderer accepts it. The orderer which accepted it and
+//the orderers which failed are recorded in the response.
+func broadcastTransaction(requestContext *RequestContext, clientContext *ClientContext) error {
+	sender, ok := clientContext.Transactor.(ordererSender)
+	if !ok {
+		opts := requestContext.Opts
+		if opts.Orderer != "" || opts.OrdererFilter != nil || opts.ShuffleOrderers || opts.OrdererComparator != nil {
+			return errors.New("transactor doesn't support orderer selection")
+		}
+		resp, err := createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+		if err != nil {
+			return err
+		}
+		requestContext.Response.Orderer = resp.Orderer
+		return nil
+	}
+
+	orderers, err := orderersToAttempt(sender.Orderers(), requestContext.Opts)
+	if err != nil {
+		return err
+	}
+
+	tx, err := clientContext.Transactor.CreateTransaction(fab.TransactionRequest{
+		Proposal:          requestContext.Response.Proposal,
+		ProposalResponses: requestContext.Response.Responses,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "CreateTransaction failed")
+	}
+
+	requestContext.Response.FailedOrderers = make(map[string]error)
+	var errs error
+	for _, orderer := range orderers {
+		resp, err := sender.SendTransactionToOrderers(tx, []fab.Orderer{orderer})
+		if err == nil {
+			requestContext.Response.Orderer = resp.Orderer
+			return nil
+		}
+		requestContext.Response.FailedOrderers[orderer.URL()] = err
+		errs = multi.Append(errs, err)
+		if rejected(err) {
+			// The other orderers would reject the transaction as well
+			break
+		}
+	}
+	return errors.WithMessage(errs, "SendTransaction failed")
+}
+
+//orderersToAttempt returns the orderers to send the transaction to, in the order in which they're attempted: the
+//orderer given in the options first, followed by the orderers accepted by the filter in the order of the channel
+//config, unless they are shuffled or sorted with the comparator
+func orderersToAttempt(orderers []fab.Orderer, opts Opts) ([]fab.Orderer, error) {
+	preferred, others, err := selectOrderers(orderers, opts.Orderer, opts.OrdererFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ShuffleOrderers {
+		shuffled := make([]fab.Orderer, len(others))
+		for i, j := range rand.Perm(len(others)) {
+			shuffled[i] = others[j]
+		}
+		others = shuffled
+	}
+	if opts.OrdererComparator != nil {
+		sort.SliceStable(others, func(i, j int) bool { return opts.OrdererComparator(others[i], others[j]) })
+	}
+
+	if preferred != nil {
+		return append([]fab.Orderer{preferred}, others...), nil
+	}
+	return others, nil
+}
+
+//rejected returns true if the orderer received the transaction but rejected it, e.g. because it's malformed or
+//not authorized, as opposed to connection failures and unavailable orderers
+func rejected(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.OrdererServerStatus {
+		return false
+	}
+	return s.Code != int32(common.Status_SERVICE_UNAVAILABLE) && s.Code != int32(common.Status_INTERNAL_SERVER_ERROR)
+}
+
+//selectOrderers returns the orderer with the given URL, if any, and the other orderers accepted by the filter
+func selectOrderers(orderers []fab.Orderer, url string, filter func(fab.Orderer) bool) (fab.Orderer, []fab.Orderer, error) {
+	var preferred fab.Orderer
+	var others []fab.Orderer
+	for _, o := range orderers {
+		if url != "" && endpoint.ToAddress(o.URL()) == endpoint.ToAddress(url) {
+			preferred = o
+			continue
+		}
+		if filter == nil || filter(o) {
+			others = append(others, o)
+		}
+	}
+
+	if url != "" && preferred == nil {
+		return nil, nil, errors.Errorf("orderer [%s] is not an orderer of the channel", url)
+	}
+	if preferred == nil && len(others) == 0 {
+		return nil, nil, errors.New("no orderers of the channel match the orderer filter")
+	}
+	return preferred, others, nil
+}
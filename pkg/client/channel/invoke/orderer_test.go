@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+func TestBroadcastTransactionOrdererSelection(t *testing.T) {
+	orderer1 := fcmocks.NewMockOrderer("orderer1.example.com:7050", nil)
+	orderer2 := fcmocks.NewMockOrderer("orderer2.example.com:7050", nil)
+	orderer3 := fcmocks.NewMockOrderer("orderer3.example.com:7050", nil)
+	notOrderer3 := func(o fab.Orderer) bool { return o.URL() != orderer3.URL() }
+	onlyOrderer3 := func(o fab.Orderer) bool { return o.URL() == orderer3.URL() }
+
+	// The preferred orderer accepts the transaction
+	requestContext, clientContext := endorsedRequest(t, Opts{Orderer: "grpcs://orderer2.example.com:7050"}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer2.URL(), requestContext.Response.Orderer)
+
+	// The preferred orderer is unreachable: the transaction is sent to the other orderers accepted by the filter
+	orderer1.EnqueueSendBroadcastError(errors.New("unreachable"))
+	requestContext, clientContext = endorsedRequest(t, Opts{Orderer: orderer1.URL(), OrdererFilter: notOrderer3}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer2.URL(), requestContext.Response.Orderer)
+
+	requestContext, clientContext = endorsedRequest(t, Opts{OrdererFilter: onlyOrderer3}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer3.URL(), requestContext.Response.Orderer)
+
+	// The orderer which accepted the transaction is also set without orderer selection
+	requestContext, clientContext = endorsedRequest(t, Opts{}, orderer1)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer1.URL(), requestContext.Response.Orderer)
+
+	requestContext, clientContext = endorsedRequest(t, Opts{Orderer: "orderer4.example.com:7050"}, orderer1, orderer2)
+	assert.NotNil(t, broadcastTransaction(requestContext, clientContext), "expected error for orderer which isn't an orderer of the channel")
+
+	requestContext, clientContext = endorsedRequest(t, Opts{OrdererFilter: onlyOrderer3}, orderer1, orderer2)
+	assert.NotNil(t, broadcastTransaction(requestContext, clientContext), "expected error if no orderer matches the filter")
+
+	orderer1.EnqueueSendBroadcastError(errors.New("unreachable"))
+	requestContext, clientContext = endorsedRequest(t, Opts{Orderer: orderer1.URL()}, orderer1)
+	assert.NotNil(t, broadcastTransaction(requestContext, clientContext), "expected error if no orderer accepts the transaction")
+}
+
+func TestBroadcastTransactionFailover(t *testing.T) {
+	orderer1 := fcmocks.NewMockOrderer("orderer1.example.com:7050", nil)
+	orderer2 := fcmocks.NewMockOrderer("orderer2.example.com:7050", nil)
+	orderer3 := fcmocks.NewMockOrderer("orderer3.example.com:7050", nil)
+	connectionFailed := func(o fab.Orderer) error {
+		return status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), "connection to "+o.URL()+" failed", nil)
+	}
+
+	// The orderers are attempted in the order of the channel config until one of them accepts the transaction
+	orderer1.EnqueueSendBroadcastError(connectionFailed(orderer1))
+	orderer2.EnqueueSendBroadcastError(connectionFailed(orderer2))
+	requestContext, clientContext := endorsedRequest(t, Opts{}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer3.URL(), requestContext.Response.Orderer)
+	assert.Len(t, requestContext.Response.FailedOrderers, 2)
+	assert.Contains(t, requestContext.Response.FailedOrderers[orderer1.URL()].Error(), "connection to orderer1.example.com:7050 failed")
+	assert.Contains(t, requestContext.Response.FailedOrderers[orderer2.URL()].Error(), "connection to orderer2.example.com:7050 failed")
+
+	// The comparator changes the order of the attempts, after the preferred orderer
+	reverse := func(o1, o2 fab.Orderer) bool { return o1.URL() > o2.URL() }
+	requestContext, clientContext = endorsedRequest(t, Opts{OrdererComparator: reverse}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer3.URL(), requestContext.Response.Orderer)
+	assert.Empty(t, requestContext.Response.FailedOrderers)
+
+	orderer1.EnqueueSendBroadcastError(connectionFailed(orderer1))
+	requestContext, clientContext = endorsedRequest(t, Opts{Orderer: orderer1.URL(), OrdererComparator: reverse}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.Equal(t, orderer3.URL(), requestContext.Response.Orderer)
+	assert.Len(t, requestContext.Response.FailedOrderers, 1)
+
+	requestContext, clientContext = endorsedRequest(t, Opts{ShuffleOrderers: true}, orderer1, orderer2, orderer3)
+	assert.Nil(t, broadcastTransaction(requestContext, clientContext))
+	assert.NotEmpty(t, requestContext.Response.Orderer)
+
+	// The error reports the failure of each orderer
+	orderer1.EnqueueSendBroadcastError(connectionFailed(orderer1))
+	orderer2.EnqueueSendBroadcastError(connectionFailed(orderer2))
+	requestContext, clientContext = endorsedRequest(t, Opts{}, orderer1, orderer2)
+	err := broadcastTransaction(requestContext, clientContext)
+	assert.NotNil(t, err, "expected error if no orderer accepts the transaction")
+	assert.Contains(t, err.Error(), "connection to orderer1.example.com:7050 failed")
+	assert.Contains(t, err.Error(), "connection to orderer2.example.com:7050 failed")
+	assert.Len(t, requestContext.Response.FailedOrderers, 2)
+	assert.Empty(t, requestContext.Response.Orderer)
+
+	// A transaction rejected by an orderer isn't sent to the other orderers
+	orderer1.EnqueueSendBroadcastError(status.New(status.OrdererServerStatus, int32(common.Status_BAD_REQUEST), "bad request", nil))
+	requestContext, clientContext = endorsedRequest(t, Opts{}, orderer1, orderer2)
+	err = broadcastTransaction(requestContext, clientContext)
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, int32(common.Status_BAD_REQUEST), s.Code)
+	assert.Len(t, requestContext.Response.FailedOrderers, 1)
+}
+
+//endorsedRequest returns the context of an endorsed request on a channel with the given orderers
+func endorsedRequest(t *testing.T, opts Opts, orderers ...fab.Orderer) (*RequestContext, *ClientContext) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, opts, t)
+
+	mockPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	mockPeer.Payload = []byte("value")
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer}, t)
+	transactor := clientContext.Transactor.(*txnmocks.MockTransactor)
+	transactor.Orderers = orderers
+	clientContext.Transactor = &ordererSelectionTransactor{MockTransactor: transactor}
+
+	NewProposalProcessorHandler(NewEndorsementHandler()).Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Endorsement failed: %s", requestContext.Error)
+	}
+	return requestContext, clientContext
+}
+
+//ordererSelectionTransactor sends transactions to the orderers of choice
+type ordererSelectionTransactor struct {
+	*txnmocks.MockTransactor
+}
+
+func (t *ordererSelectionTransactor) Orderers() []fab.Orderer {
+	return t.MockTransactor.Orderers
+}
+
+func (t *ordererSelectionTransactor) SendTransactionToOrderers(tx *fab.Transaction, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+	transactor := *t.MockTransactor
+	transactor.Orderers = orderers
+	return transactor.SendTransaction(tx)
+}
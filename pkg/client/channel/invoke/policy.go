@@ -0,0 +1,226 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/pkg/errors"
+)
+
+const (
+	lscc          = "lscc"
+	lsccGetCCData = "getccdata"
+)
+
+//NewPolicyCheckHandler returns a handler that checks that the endorsements satisfy the chaincode's endorsement policy
+func NewPolicyCheckHandler(next ...Handler) *PolicyCheckHandler {
+	return &PolicyCheckHandler{next: getNext(next)}
+}
+
+//PolicyCheckHandler checks that the endorsements satisfy the endorsement policy of the chaincode before the
+//transaction is sent to the orderer, so that a transaction which would be invalidated with an
+//ENDORSEMENT_POLICY_FAILURE fails with an EndorsementPolicyNotSatisfied status instead. The policy is queried
+//from lscc on the endorsing peers. Principals are matched by MSP ID (and organizational unit or identity);
+//MSP roles are not verified.
+type PolicyCheckHandler struct {
+	next Handler
+}
+
+//Handle checks the endorsements against the endorsement policy
+func (p *PolicyCheckHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	requestContext.SetStage(StageValidation)
+
+	policy, err := queryEndorsementPolicy(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "failed to get endorsement policy")
+		return
+	}
+
+	endorsers := endorsingIdentities(requestContext.Response.Responses)
+	if !satisfiesPolicy(policy.Rule, policy.Identities, endorsers, make([]bool, len(endorsers))) {
+		requestContext.Error = status.New(status.EndorserClientStatus, status.EndorsementPolicyNotSatisfied.ToInt32(),
+			"endorsements do not satisfy the endorsement policy of the chaincode", []interface{}{endorserMSPIDs(endorsers)})
+		return
+	}
+
+	//Delegate to next step if any
+	if p.next != nil {
+		p.next.Handle(requestContext, clientContext)
+	}
+}
+
+//queryEndorsementPolicy queries the endorsement policy of the chaincode from lscc on the request's targets
+func queryEndorsementPolicy(requestContext *RequestContext, clientContext *ClientContext) (*common.SignaturePolicyEnvelope, error) {
+	txh, err := clientContext.Transactor.CreateTransactionHeader()
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating transaction header failed")
+	}
+
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID: lscc,
+		Fcn:         lsccGetCCData,
+		Args:        [][]byte{[]byte(txh.ChannelID()), []byte(requestContext.Request.ChaincodeID)},
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating lscc proposal failed")
+	}
+
+	errs := multi.Errors{}
+	for _, target := range requestContext.Opts.Targets {
+		responses, err := clientContext.Transactor.SendTransactionProposal(proposal, []fab.ProposalProcessor{target})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if responses[0].ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+			errs = append(errs, status.NewFromProposalResponse(responses[0].ProposalResponse, responses[0].Endorser))
+			continue
+		}
+
+		ccData := &ccprovider.ChaincodeData{}
+		if err := proto.Unmarshal(responses[0].ProposalResponse.GetResponse().Payload, ccData); err != nil {
+			return nil, errors.Wrap(err, "unmarshal of chaincode data failed")
+		}
+		policy := &common.SignaturePolicyEnvelope{}
+		if err := proto.Unmarshal(ccData.Policy, policy); err != nil {
+			return nil, errors.Wrap(err, "unmarshal of endorsement policy failed")
+		}
+		return policy, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no targets to query the endorsement policy from")
+	}
+	return nil, errs
+}
+
+//endorsingIdentities returns the distinct identities which endorsed the responses
+func endorsingIdentities(responses []*fab.TransactionProposalResponse) []*mb.SerializedIdentity {
+	var identities []*mb.SerializedIdentity
+	var seen [][]byte
+	for _, r := range responses {
+		endorser := r.ProposalResponse.GetEndorsement().GetEndorser()
+		if endorser == nil || containsBytes(seen, endorser) {
+			continue
+		}
+		seen = append(seen, endorser)
+
+		identity := &mb.SerializedIdentity{}
+		if err := proto.Unmarshal(endorser, identity); err != nil {
+			// Endorsements with invalid identities don't count towards the policy
+			continue
+		}
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+//satisfiesPolicy evaluates the signature policy in the same way as the committing peers, i.e. each endorser
+//may only satisfy one principal of the policy. The endorsers which satisfy principals are marked as used.
+func satisfiesPolicy(policy *common.SignaturePolicy, principals []*mb.MSPPrincipal, endorsers []*mb.SerializedIdentity, used []bool) bool {
+	switch t := policy.GetType().(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(principals) {
+			return false
+		}
+		for i, endorser := range endorsers {
+			if !used[i] && satisfiesPrincipal(endorser, principals[t.SignedBy]) {
+				used[i] = true
+				return true
+			}
+		}
+		return false
+
+	case *common.SignaturePolicy_NOutOf_:
+		satisfied := int32(0)
+		ruleUsed := make([]bool, len(used))
+		for _, rule := range t.NOutOf.Rules {
+			copy(ruleUsed, used)
+			if satisfiesPolicy(rule, principals, endorsers, ruleUsed) {
+				satisfied++
+				copy(used, ruleUsed)
+			}
+		}
+		return satisfied >= t.NOutOf.N
+
+	default:
+		return false
+	}
+}
+
+func satisfiesPrincipal(endorser *mb.SerializedIdentity, principal *mb.MSPPrincipal) bool {
+	switch principal.PrincipalClassification {
+	case mb.MSPPrincipal_ROLE:
+		role := &mb.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return false
+		}
+		return role.MspIdentifier == endorser.Mspid
+
+	case mb.MSPPrincipal_ORGANIZATION_UNIT:
+		unit := &mb.OrganizationUnit{}
+		if err := proto.Unmarshal(principal.Principal, unit); err != nil {
+			return false
+		}
+		return unit.MspIdentifier == endorser.Mspid && hasOrganizationalUnit(endorser.IdBytes, unit.OrganizationalUnitIdentifier)
+
+	case mb.MSPPrincipal_IDENTITY:
+		identity := &mb.SerializedIdentity{}
+		if err := proto.Unmarshal(principal.Principal, identity); err != nil {
+			return false
+		}
+		return identity.Mspid == endorser.Mspid && bytes.Equal(identity.IdBytes, endorser.IdBytes)
+
+	default:
+		return false
+	}
+}
+
+func hasOrganizationalUnit(certPEM []byte, ou string) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		if unit == ou {
+			return true
+		}
+	}
+	return false
+}
+
+func endorserMSPIDs(endorsers []*mb.SerializedIdentity) []string {
+	mspIDs := make([]string, len(endorsers))
+	for i, endorser := range endorsers {
+		mspIDs[i] = endorser.Mspid
+	}
+	return mspIDs
+}
+
+func containsBytes(values [][]byte, value []byte) bool {
+	for _, v := range values {
+		if bytes.Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheckHandler(t *testing.T) {
+	// Endorsements are required from both Org1MSP and Org2MSP
+	lsccPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	lsccPeer.Payload = newChaincodeData(t, newAndPolicy(t, "Org1MSP", "Org2MSP"))
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("b")}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer}}, t)
+	requestContext.Response.Responses = []*fab.TransactionProposalResponse{
+		newEndorsement(t, "http://peer1.com", "Org1MSP", "peer1"),
+		newEndorsement(t, "http://peer2.com", "Org1MSP", "peer2"),
+	}
+	NewPolicyCheckHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.EndorsementPolicyNotSatisfied.ToInt32(), s.Code, "expected endorsement policy not satisfied")
+
+	// The same endorser only counts once
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer}}, t)
+	requestContext.Response.Responses = []*fab.TransactionProposalResponse{
+		newEndorsement(t, "http://peer1.com", "Org1MSP", "peer1"),
+		newEndorsement(t, "http://peer1.com", "Org1MSP", "peer1"),
+	}
+	NewPolicyCheckHandler().Handle(requestContext, clientContext)
+	assert.Error(t, requestContext.Error)
+
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{lsccPeer}}, t)
+	requestContext.Response.Responses = []*fab.TransactionProposalResponse{
+		newEndorsement(t, "http://peer1.com", "Org1MSP", "peer1"),
+		newEndorsement(t, "http://peer3.com", "Org2MSP", "peer3"),
+	}
+	NewPolicyCheckHandler().Handle(requestContext, clientContext)
+	assert.NoError(t, requestContext.Error)
+
+	// The policy can't be retrieved
+	badPeer := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	badPeer.Status = 500
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{badPeer}}, t)
+	NewPolicyCheckHandler().Handle(requestContext, clientContext)
+	assert.Error(t, requestContext.Error)
+}
+
+func TestSatisfiesPolicy(t *testing.T) {
+	policy := newAndPolicy(t, "Org1MSP", "Org2MSP")
+	// 1 out of Org1MSP and Org2MSP
+	policy.Rule.GetNOutOf().N = 1
+
+	org1 := &mb.SerializedIdentity{Mspid: "Org1MSP", IdBytes: []byte("peer1")}
+	org3 := &mb.SerializedIdentity{Mspid: "Org3MSP", IdBytes: []byte("peer3")}
+
+	assert.True(t, satisfiesPolicy(policy.Rule, policy.Identities, []*mb.SerializedIdentity{org1}, make([]bool, 1)))
+	assert.False(t, satisfiesPolicy(policy.Rule, policy.Identities, []*mb.SerializedIdentity{org3}, make([]bool, 1)))
+	assert.False(t, satisfiesPolicy(policy.Rule, policy.Identities, nil, nil))
+}
+
+func newAndPolicy(t *testing.T, mspIDs ...string) *common.SignaturePolicyEnvelope {
+	var rules []*common.SignaturePolicy
+	var principals []*mb.MSPPrincipal
+	for i, mspID := range mspIDs {
+		role, err := proto.Marshal(&mb.MSPRole{MspIdentifier: mspID, Role: mb.MSPRole_MEMBER})
+		if err != nil {
+			t.Fatalf("Failed to marshal role: %s", err)
+		}
+		principals = append(principals, &mb.MSPPrincipal{PrincipalClassification: mb.MSPPrincipal_ROLE, Principal: role})
+		rules = append(rules, &common.SignaturePolicy{Type: &common.SignaturePolicy_SignedBy{SignedBy: int32(i)}})
+	}
+	return &common.SignaturePolicyEnvelope{
+		Rule: &common.SignaturePolicy{Type: &common.SignaturePolicy_NOutOf_{
+			NOutOf: &common.SignaturePolicy_NOutOf{N: int32(len(rules)), Rules: rules},
+		}},
+		Identities: principals,
+	}
+}
+
+func newChaincodeData(t *testing.T, policy *common.SignaturePolicyEnvelope) []byte {
+	policyBytes, err := proto.Marshal(policy)
+	if err != nil {
+		t.Fatalf("Failed to marshal policy: %s", err)
+	}
+	ccData, err := proto.Marshal(&ccprovider.ChaincodeData{Name: "testCC", Version: "v1", Policy: policyBytes})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode data: %s", err)
+	}
+	return ccData
+}
+
+func newEndorsement(t *testing.T, url, mspID, id string) *fab.TransactionProposalResponse {
+	endorser, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: []byte(id)})
+	if err != nil {
+		t.Fatalf("Failed to marshal identity: %s", err)
+	}
+	return &fab.TransactionProposalResponse{
+		Endorser: url,
+		Status:   200,
+		ProposalResponse: &pb.ProposalResponse{
+			Response:    &pb.Response{Status: 200, Payload: []byte("value")},
+			Endorsement: &pb.Endorsement{Endorser: endorser, Signature: []byte("signature")},
+		},
+	}
+}
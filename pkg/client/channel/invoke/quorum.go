@@ -0,0 +1,160 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+//QuorumQueryHandler queries peers until a quorum of them returned identical response payloads
+type QuorumQueryHandler struct {
+	next Handler
+}
+
+//NewQuorumQueryHandler returns a handler that queries a quorum of peers (see Opts.QueryQuorum)
+func NewQuorumQueryHandler(next ...Handler) *QuorumQueryHandler {
+	return &QuorumQueryHandler{next: getNext(next)}
+}
+
+//Handle queries the candidate peers, expanding the queried peers as long as the quorum can't be reached
+//with the responses in flight
+func (h *QuorumQueryHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	quorum := requestContext.Opts.QueryQuorum
+
+	candidates, err := quorumCandidates(requestContext, clientContext, quorum)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+	if len(candidates) < quorum {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(),
+			fmt.Sprintf("%d peers available but query quorum is %d", len(candidates), quorum), nil)
+		return
+	}
+
+	proposal, err := createTransactionProposal(clientContext.Transactor, &requestContext.Request)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	results := make(chan endorsementResult, len(candidates))
+	var queried []fab.Peer
+	pending := make(map[string]bool)
+	query := func() {
+		target := candidates[len(queried)]
+		queried = append(queried, target)
+		pending[target.URL()] = true
+		go func() {
+			results <- sendTransactionProposal(clientContext.Transactor, proposal, target)
+		}()
+	}
+	for len(queried) < quorum {
+		query()
+	}
+	requestContext.Response.SelectedTargets = peerURLs(queried)
+	requestContext.NotifyProgress(ProgressSelected, requestContext.Response.SelectedTargets...)
+	requestContext.SetStage(StageEndorsement, pendingURLs(queried, pending)...)
+
+	requestContext.Response.FailedEndorsers = make(map[string]error)
+	hashes := make(map[string]string)
+	groups := make(map[string][]*fab.TransactionProposalResponse)
+	agreed := 0
+	for len(pending) > 0 {
+		result := <-results
+		delete(pending, result.target)
+
+		if result.err != nil {
+			requestContext.Response.FailedEndorsers[result.target] = result.err
+		} else {
+			hash := payloadHash(result.response)
+			hashes[result.target] = hash
+			groups[hash] = append(groups[hash], result.response)
+			if len(groups[hash]) >= quorum {
+				h.complete(requestContext, clientContext, groups[hash])
+				return
+			}
+			if len(groups[hash]) > agreed {
+				agreed = len(groups[hash])
+			}
+		}
+
+		// The quorum may only be reached by querying more peers
+		for agreed+len(pending) < quorum && len(queried) < len(candidates) {
+			query()
+		}
+		requestContext.Response.SelectedTargets = peerURLs(queried)
+		requestContext.SetStage(StageEndorsement, pendingURLs(queried, pending)...)
+	}
+
+	requestContext.Error = status.New(status.ClientStatus, status.QuorumNotReached.ToInt32(),
+		fmt.Sprintf("%d of %d queried peers returned identical payloads but query quorum is %d (%d peers failed)",
+			agreed, len(queried), quorum, len(requestContext.Response.FailedEndorsers)),
+		[]interface{}{hashes})
+}
+
+//complete records the responses of the quorum and invokes the next handler
+func (h *QuorumQueryHandler) complete(requestContext *RequestContext, clientContext *ClientContext, responses []*fab.TransactionProposalResponse) {
+	requestContext.Response.Responses = responses
+	requestContext.Response.Payload = responses[0].ProposalResponse.GetResponse().Payload
+	requestContext.Response.ChaincodeStatus = responses[0].ChaincodeStatus
+	requestContext.NotifyProgress(ProgressEndorsed, endorserURLs(responses)...)
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+//quorumCandidates returns the targets of the request, selected unless given, followed by the other peers of the
+//channel accepted by the selection filter if there are fewer targets than the quorum
+func quorumCandidates(requestContext *RequestContext, clientContext *ClientContext, quorum int) ([]fab.Peer, error) {
+	candidates := requestContext.Opts.Targets
+	if len(candidates) == 0 {
+		endorsers, err := selectEndorsers(requestContext, clientContext)
+		if err != nil {
+			return nil, err
+		}
+		candidates = endorsers
+	}
+	if len(candidates) >= quorum || clientContext.Discovery == nil {
+		return candidates, nil
+	}
+
+	peers, err := clientContext.Discovery.GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get peers")
+	}
+
+	seen := make(map[string]bool)
+	for _, peer := range candidates {
+		seen[peer.URL()] = true
+	}
+	expanded := append([]fab.Peer{}, candidates...)
+	for _, peer := range peers {
+		if seen[peer.URL()] || (requestContext.SelectionFilter != nil && !requestContext.SelectionFilter(peer)) {
+			continue
+		}
+		seen[peer.URL()] = true
+		expanded = append(expanded, peer)
+	}
+	return expanded, nil
+}
+
+//payloadHash returns the hex encoded SHA-256 hash of the response payload
+func payloadHash(response *fab.TransactionProposalResponse) string {
+	hash := sha256.Sum256(response.ProposalResponse.GetResponse().Payload)
+	return hex.EncodeToString(hash[:])
+}
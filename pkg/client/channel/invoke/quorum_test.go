@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestQuorumQueryHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.Payload = []byte("value")
+	peer3 := fcmocks.NewMockPeer("p3", "peer3:7051")
+	peer3.Payload = []byte("value")
+	peer4 := fcmocks.NewMockPeer("p4", "peer4:7051")
+	peer4.Payload = []byte("value")
+
+	// The failed peer is replaced by the next target
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2, peer3, peer4}, QueryQuorum: 2}, t)
+	NewQuorumQueryHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Len(t, requestContext.Response.Responses, 2)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	assert.Contains(t, requestContext.Response.FailedEndorsers, "peer1:7051")
+	assert.Equal(t, 0, peer4.ProcessProposalCalls, "expected the quorum to be reached without the last target")
+
+	// Divergent payloads
+	peer5 := fcmocks.NewMockPeer("p5", "peer5:7051")
+	peer5.Payload = []byte("other value")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer2, peer5}, QueryQuorum: 2}, t)
+	NewQuorumQueryHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.QuorumNotReached.ToInt32(), s.Code, "expected quorum not reached")
+	if assert.Len(t, s.Details, 1) {
+		hashes, ok := s.Details[0].(map[string]string)
+		assert.True(t, ok, "expected payload hashes per peer")
+		assert.Len(t, hashes, 2)
+		assert.NotEqual(t, hashes["peer2:7051"], hashes["peer5:7051"])
+	}
+}
+
+func TestQuorumQueryHandlerExpandsTargets(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}}
+
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.Payload = []byte("value")
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.Payload = []byte("value")
+
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	discoveryService, err := setupTestDiscovery(nil, []fab.Peer{peer1, peer2})
+	assert.Nil(t, err)
+	clientContext.Discovery = discoveryService
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1}, QueryQuorum: 2}, t)
+	NewQuorumQueryHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []string{"peer1:7051", "peer2:7051"}, requestContext.Response.SelectedTargets)
+
+	// Not enough peers in the channel
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1}, QueryQuorum: 3}, t)
+	NewQuorumQueryHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected no peers found")
+}
@@ -7,8 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package invoke
 
 import (
-	"bytes"
+	"fmt"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/pkg/errors"
@@ -34,11 +35,34 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 		return
 	}
 
+	threshold := requestContext.Opts.EndorsementThreshold
+	if threshold > len(requestContext.Opts.Targets) {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(),
+			fmt.Sprintf("%d targets available but endorsement threshold is %d", len(requestContext.Opts.Targets), threshold), nil)
+		return
+	}
+
+	requestContext.SetStage(StageEndorsement, peerURLs(requestContext.Opts.Targets)...)
+
 	// Endorse Tx
-	transactionProposalResponses, proposal, err := createAndSendTransactionProposal(clientContext.Transactor, &requestContext.Request, peer.PeersToTxnProcessors(requestContext.Opts.Targets))
+	var transactionProposalResponses []*fab.TransactionProposalResponse
+	var proposal *fab.TransactionProposal
+	var err error
+	if threshold > 0 {
+		transactionProposalResponses, proposal, err = e.endorseWithThreshold(requestContext, clientContext, threshold)
+	} else {
+		transactionProposalResponses, proposal, err = createAndSendTransactionProposal(clientContext.Transactor, &requestContext.Request, peer.PeersToTxnProcessors(requestContext.Opts.Targets))
+		if err != nil && requestContext.Opts.EndorsementValidator != nil && len(transactionProposalResponses) > 0 {
+			// The validator decides whether the endorsements received suffice
+			requestContext.Response.FailedEndorsers = failedEndorsers(requestContext.Opts.Targets, transactionProposalResponses, err)
+			err = nil
+		}
+	}
 
-	requestContext.Response.Proposal = proposal
-	requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	if proposal != nil {
+		requestContext.Response.Proposal = proposal
+		requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	}
 
 	if err != nil {
 		requestContext.Error = err
@@ -50,6 +74,7 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 		requestContext.Response.Payload = transactionProposalResponses[0].ProposalResponse.GetResponse().Payload
 		requestContext.Response.ChaincodeStatus = transactionProposalResponses[0].ChaincodeStatus
 	}
+	requestContext.NotifyProgress(ProgressEndorsed, endorserURLs(transactionProposalResponses)...)
 
 	//Delegate to next step if any
 	if e.next != nil {
@@ -57,6 +82,138 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 	}
 }
 
+type endorsementResult struct {
+	target   string
+	response *fab.TransactionProposalResponse
+	err      error
+}
+
+//endorseWithThreshold sends the proposal to each target and returns as soon as threshold matching successful
+//responses have been received. Targets which fail to endorse are recorded in the response.
+func (e *EndorsementHandler) endorseWithThreshold(requestContext *RequestContext, clientContext *ClientContext, threshold int) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, error) {
+	proposal, err := createTransactionProposal(clientContext.Transactor, &requestContext.Request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	targets := requestContext.Opts.Targets
+	results := make(chan endorsementResult, len(targets))
+	for _, target := range targets {
+		go func(target fab.Peer) {
+			results <- sendTransactionProposal(clientContext.Transactor, proposal, target)
+		}(target)
+	}
+
+	pending := make(map[string]bool)
+	for _, target := range targets {
+		pending[target.URL()] = true
+	}
+
+	requestContext.Response.FailedEndorsers = make(map[string]error)
+	var endorsements [][]*fab.TransactionProposalResponse
+	for range targets {
+		result := <-results
+		delete(pending, result.target)
+		requestContext.SetStage(StageEndorsement, pendingURLs(targets, pending)...)
+
+		if result.err != nil {
+			requestContext.Response.FailedEndorsers[result.target] = result.err
+			continue
+		}
+
+		i, err := matchingEndorsements(endorsements, result.response, requestContext.Opts.EndorsementComparator)
+		if err != nil {
+			requestContext.Response.FailedEndorsers[result.target] = err
+			continue
+		}
+		if i < 0 {
+			endorsements = append(endorsements, nil)
+			i = len(endorsements) - 1
+		}
+		endorsements[i] = append(endorsements[i], result.response)
+		if len(endorsements[i]) >= threshold {
+			return endorsements[i], proposal, nil
+		}
+	}
+
+	if len(endorsements) == 0 {
+		errs := multi.Errors{}
+		for _, err := range requestContext.Response.FailedEndorsers {
+			errs = append(errs, err)
+		}
+		return nil, proposal, errs.ToError()
+	}
+
+	return nil, proposal, status.New(status.EndorserClientStatus, status.MissingEndorsement.ToInt32(),
+		fmt.Sprintf("received fewer than %d matching endorsements", threshold), nil)
+}
+
+//matchingEndorsements returns the index of the endorsements matching the given response or -1 if there are none
+func matchingEndorsements(endorsements [][]*fab.TransactionProposalResponse, response *fab.TransactionProposalResponse, compare EndorsementComparator) (int, error) {
+	if compare == nil {
+		compare = ByteExact
+	}
+	for i, group := range endorsements {
+		match, err := compare(group[0].ProposalResponse, response.ProposalResponse)
+		if err != nil {
+			return -1, err
+		}
+		if match {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func sendTransactionProposal(transactor fab.ProposalSender, proposal *fab.TransactionProposal, target fab.Peer) endorsementResult {
+	result := endorsementResult{target: target.URL()}
+
+	responses, err := transactor.SendTransactionProposal(proposal, []fab.ProposalProcessor{target})
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if len(responses) == 0 {
+		result.err = errors.New("no proposal response received")
+		return result
+	}
+
+	result.response = responses[0]
+	if result.response.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+		result.err = status.NewFromProposalResponse(result.response.ProposalResponse, result.response.Endorser)
+	}
+	return result
+}
+
+//peerURLs returns the URLs of the peers
+func peerURLs(peers []fab.Peer) []string {
+	urls := make([]string, len(peers))
+	for i, p := range peers {
+		urls[i] = p.URL()
+	}
+	return urls
+}
+
+//endorserURLs returns the URLs of the endorsers of the responses
+func endorserURLs(responses []*fab.TransactionProposalResponse) []string {
+	urls := make([]string, len(responses))
+	for i, r := range responses {
+		urls[i] = r.Endorser
+	}
+	return urls
+}
+
+//pendingURLs returns the URLs of the targets which are pending, in the order of the targets
+func pendingURLs(targets []fab.Peer, pending map[string]bool) []string {
+	var urls []string
+	for _, target := range targets {
+		if pending[target.URL()] {
+			urls = append(urls, target.URL())
+		}
+	}
+	return urls
+}
+
 //ProposalProcessorHandler for selecting proposal processors
 type ProposalProcessorHandler struct {
 	next Handler
@@ -66,17 +223,15 @@ type ProposalProcessorHandler struct {
 func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
 	//Get proposal processor, if not supplied then use selection service to get available peers as endorser
 	if len(requestContext.Opts.Targets) == 0 {
-		var selectionOpts []options.Opt
-		if requestContext.SelectionFilter != nil {
-			selectionOpts = append(selectionOpts, selectopts.WithPeerFilter(requestContext.SelectionFilter))
-		}
-		endorsers, err := clientContext.Selection.GetEndorsersForChaincode([]string{requestContext.Request.ChaincodeID}, selectionOpts...)
+		endorsers, err := selectEndorsers(requestContext, clientContext)
 		if err != nil {
-			requestContext.Error = errors.WithMessage(err, "Failed to get endorsing peers")
+			requestContext.Error = err
 			return
 		}
 		requestContext.Opts.Targets = endorsers
 	}
+	requestContext.Response.SelectedTargets = peerURLs(requestContext.Opts.Targets)
+	requestContext.NotifyProgress(ProgressSelected, requestContext.Response.SelectedTargets...)
 
 	//Delegate to next step if any
 	if h.next != nil {
@@ -84,6 +239,46 @@ func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, client
 	}
 }
 
+//selectEndorsers selects the endorsers of the chaincode with the selection service
+func selectEndorsers(requestContext *RequestContext, clientContext *ClientContext) ([]fab.Peer, error) {
+	requestContext.SetStage(StageSelection)
+	var selectionOpts []options.Opt
+	if requestContext.SelectionFilter != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithPeerFilter(requestContext.SelectionFilter))
+	}
+	if requestContext.Opts.TargetSorter != nil {
+		selectionOpts = append(selectionOpts, selectopts.WithPeerSorter(requestContext.Opts.TargetSorter.Sort))
+	}
+	if len(requestContext.Opts.ChaincodeInterests) > 0 {
+		selectionOpts = append(selectionOpts, selectopts.WithChaincodeInterests(requestContext.Opts.ChaincodeInterests))
+	}
+	ccIDs := ChaincodeIDs([]string{requestContext.Request.ChaincodeID}, requestContext.Opts.ChaincodeInterests)
+	endorsers, err := clientContext.Selection.GetEndorsersForChaincode(ccIDs, selectionOpts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get endorsing peers")
+	}
+	return endorsers, nil
+}
+
+// ChaincodeIDs returns the given chaincode IDs followed by the IDs of the chaincode interests, without duplicates
+func ChaincodeIDs(ccIDs []string, interests []fab.CCInterest) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ccIDs {
+		add(id)
+	}
+	for _, interest := range interests {
+		add(interest.ID)
+	}
+	return ids
+}
+
 //EndorsementValidationHandler for transaction proposal response filtering
 type EndorsementValidationHandler struct {
 	next Handler
@@ -91,81 +286,207 @@ type EndorsementValidationHandler struct {
 
 //Handle for Filtering proposal response
 func (f *EndorsementValidationHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	requestContext.SetStage(StageValidation)
 
 	//Filter tx proposal responses
-	err := f.validate(requestContext.Response.Responses)
+	validate := requestContext.Opts.EndorsementValidator
+	if validate == nil {
+		validate = StrictMatch(requestContext.Opts.EndorsementComparator)
+	}
+	responses, err := validate(requestContext.Response.Responses)
+	if err == nil && len(responses) == 0 {
+		err = errors.New("no endorsement accepted")
+	}
 	if err != nil {
 		requestContext.Error = errors.WithMessage(err, "endorsement validation failed")
 		return
 	}
 
+	// The response reflects the accepted endorsements
+	requestContext.Response.Responses = responses
+	requestContext.Response.Payload = responses[0].ProposalResponse.GetResponse().Payload
+	requestContext.Response.ChaincodeStatus = responses[0].ChaincodeStatus
+
 	//Delegate to next step if any
 	if f.next != nil {
 		f.next.Handle(requestContext, clientContext)
 	}
 }
 
-func (f *EndorsementValidationHandler) validate(txProposalResponse []*fab.TransactionProposalResponse) error {
-	var a1 *pb.ProposalResponse
-	for n, r := range txProposalResponse {
-		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
-			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
+//CommitTxHandler for committing transactions
+type CommitTxHandler struct {
+	next Handler
+}
+
+//Handle handles commit tx
+func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	noCommitWait := requestContext.Opts.NoCommitWait
+	if !noCommitWait && requestContext.Opts.EventServiceUnavailable != WaitForCommit && !eventServiceConnected(clientContext.EventService) {
+		// The commit of the transaction couldn't be observed
+		if requestContext.Opts.EventServiceUnavailable == FailFast || requestContext.Opts.CCEventCapture != "" {
+			requestContext.Error = status.New(status.ClientStatus, status.EventServiceUnavailable.ToInt32(), "event service is unavailable: transaction not broadcast", nil)
+			return
 		}
-		if n == 0 {
-			a1 = r.ProposalResponse
-			continue
+		noCommitWait = true
+	}
+
+	if noCommitWait {
+		if err := c.broadcast(requestContext, clientContext); err != nil {
+			requestContext.Error = err
+			return
 		}
 
-		if !bytes.Equal(a1.Payload, r.ProposalResponse.Payload) ||
-			!bytes.Equal(a1.GetResponse().Payload, r.ProposalResponse.GetResponse().Payload) {
-			return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
-				"ProposalResponsePayloads do not match", nil)
+		//Delegate to next step if any
+		if c.next != nil {
+			c.next.Handle(requestContext, clientContext)
 		}
+		return
+	}
+
+	var txStatus *fab.TxStatusEvent
+	var err error
+	if requestContext.Opts.BlockCommitWait || requestContext.Opts.CCEventCapture != "" {
+		txStatus, err = c.waitForBlockCommit(requestContext, clientContext)
+	} else {
+		txStatus, err = c.waitForTxStatus(requestContext, clientContext)
+	}
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+	requestContext.Response.BlockNumber = txStatus.BlockNumber
+	requestContext.Response.CommitObserved = true
+
+	if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
+		requestContext.Error = status.New(status.TxValidationStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil)
+		return
+	}
+	requestContext.NotifyProgress(ProgressCommitted)
+
+	//Delegate to next step if any
+	if c.next != nil {
+		c.next.Handle(requestContext, clientContext)
 	}
+}
 
+//broadcast sends the transaction without registering for its commit, which is recorded as skipped in the response.
+//The commit can't be skipped on request if the committing block is awaited or its chaincode event captured.
+func (c *CommitTxHandler) broadcast(requestContext *RequestContext, clientContext *ClientContext) error {
+	if requestContext.Opts.NoCommitWait && (requestContext.Opts.BlockCommitWait || requestContext.Opts.CCEventCapture != "") {
+		return errors.New("waiting for the committing block or capturing its chaincode event requires waiting for the commit")
+	}
+
+	requestContext.SetStage(StageBroadcast)
+	if err := broadcastTransaction(requestContext, clientContext); err != nil {
+		return errors.Wrap(err, "CreateAndSendTransaction failed")
+	}
+	requestContext.NotifyProgress(ProgressBroadcast)
+	requestContext.Response.NoCommitWait = true
 	return nil
 }
 
-//CommitTxHandler for committing transactions
-type CommitTxHandler struct {
-	next Handler
+//eventServiceConnected returns false if the event service reports that it isn't connected to the event server.
+//An event service which doesn't report its connection is assumed to be connected.
+func eventServiceConnected(eventService fab.EventService) bool {
+	if connectedService, ok := eventService.(fab.ConnectedEventService); ok {
+		return connectedService.Connected()
+	}
+	return true
 }
 
-//Handle handles commit tx
-func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+//waitForTxStatus sends the transaction and waits for its TxStatus event
+func (c *CommitTxHandler) waitForTxStatus(requestContext *RequestContext, clientContext *ClientContext) (*fab.TxStatusEvent, error) {
 	txnID := requestContext.Response.TransactionID
 
 	//Register Tx event
 	reg, statusNotifier, err := clientContext.EventService.RegisterTxStatusEvent(string(txnID)) // TODO: Change func to use TransactionID instead of string
 	if err != nil {
-		requestContext.Error = errors.Wrap(err, "error registering for TxStatus event")
-		return
+		return nil, errors.Wrap(err, "error registering for TxStatus event")
 	}
 	defer clientContext.EventService.Unregister(reg)
 
-	_, err = createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+	requestContext.SetStage(StageBroadcast)
+	err = broadcastTransaction(requestContext, clientContext)
 	if err != nil {
-		requestContext.Error = errors.Wrap(err, "CreateAndSendTransaction failed")
-		return
+		return nil, errors.Wrap(err, "CreateAndSendTransaction failed")
 	}
+	requestContext.NotifyProgress(ProgressBroadcast)
+	requestContext.SetStage(StageCommit)
 
 	select {
 	case txStatus := <-statusNotifier:
-		requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+		return txStatus, nil
+	case <-requestContext.Ctx.Done():
+		return nil, errors.New("Execute didn't receive block event")
+	}
+}
 
-		if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
-			requestContext.Error = status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil)
-			return
+//waitForBlockCommit sends the transaction and waits for the filtered block which contains it. The block
+//event registration is made before the transaction is sent so that the committing block can't be missed.
+//The chaincode event to capture, if any, is set in the response.
+func (c *CommitTxHandler) waitForBlockCommit(requestContext *RequestContext, clientContext *ClientContext) (*fab.TxStatusEvent, error) {
+	txnID := string(requestContext.Response.TransactionID)
+
+	reg, blockNotifier, err := clientContext.EventService.RegisterFilteredBlockEvent()
+	if err != nil {
+		return nil, errors.Wrap(err, "error registering for filtered block event")
+	}
+	defer clientContext.EventService.Unregister(reg)
+
+	requestContext.SetStage(StageBroadcast)
+	err = broadcastTransaction(requestContext, clientContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateAndSendTransaction failed")
+	}
+	requestContext.NotifyProgress(ProgressBroadcast)
+	requestContext.SetStage(StageCommit)
+
+	for {
+		select {
+		case event, ok := <-blockNotifier:
+			if !ok {
+				return nil, errors.New("filtered block event registration was closed")
+			}
+			if event.FilteredBlock == nil {
+				continue
+			}
+			for _, tx := range event.FilteredBlock.FilteredTransactions {
+				if tx.Txid == txnID {
+					if eventName := requestContext.Opts.CCEventCapture; eventName != "" {
+						requestContext.Response.CCEvent = chaincodeEvent(tx, eventName, event.FilteredBlock.Number, event.SourceURL)
+					}
+					return &fab.TxStatusEvent{
+						TxID:             txnID,
+						TxValidationCode: tx.TxValidationCode,
+						BlockNumber:      event.FilteredBlock.Number,
+						SourceURL:        event.SourceURL,
+					}, nil
+				}
+			}
+		case <-requestContext.Ctx.Done():
+			return nil, errors.New("Execute didn't receive block event")
 		}
-	case <-requestContext.Ctx.Done():
-		requestContext.Error = errors.New("Execute didn't receive block event")
-		return
 	}
+}
 
-	//Delegate to next step if any
-	if c.next != nil {
-		c.next.Handle(requestContext, clientContext)
+//chaincodeEvent returns the chaincode event with the given name set by the transaction, or nil if there is none
+func chaincodeEvent(tx *pb.FilteredTransaction, eventName string, blockNumber uint64, sourceURL string) *fab.CCEvent {
+	for _, action := range tx.GetTransactionActions().GetChaincodeActions() {
+		ccEvent := action.GetChaincodeEvent()
+		if ccEvent != nil && ccEvent.EventName == eventName {
+			return &fab.CCEvent{
+				TxID:        tx.Txid,
+				ChaincodeID: ccEvent.ChaincodeId,
+				EventName:   ccEvent.EventName,
+				Payload:     ccEvent.Payload,
+				BlockNumber: blockNumber,
+				SourceURL:   sourceURL,
+			}
+		}
 	}
+	return nil
 }
 
 //NewQueryHandler returns query handler with EndorseTxHandler & EndorsementValidationHandler Chained
@@ -239,6 +560,17 @@ func createAndSendTransaction(sender fab.Sender, proposal *fab.TransactionPropos
 }
 
 func createAndSendTransactionProposal(transactor fab.ProposalSender, chrequest *Request, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, error) {
+	proposal, err := createTransactionProposal(transactor, chrequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactionProposalResponses, err := transactor.SendTransactionProposal(proposal, targets)
+
+	return transactionProposalResponses, proposal, err
+}
+
+func createTransactionProposal(transactor fab.ProposalSender, chrequest *Request) (*fab.TransactionProposal, error) {
 	request := fab.ChaincodeInvokeRequest{
 		ChaincodeID:  chrequest.ChaincodeID,
 		Fcn:          chrequest.Fcn,
@@ -248,15 +580,13 @@ func createAndSendTransactionProposal(transactor fab.ProposalSender, chrequest *
 
 	txh, err := transactor.CreateTransactionHeader()
 	if err != nil {
-		return nil, nil, errors.WithMessage(err, "creating transaction header failed")
+		return nil, errors.WithMessage(err, "creating transaction header failed")
 	}
 
 	proposal, err := txn.CreateChaincodeInvokeProposal(txh, request)
 	if err != nil {
-		return nil, nil, errors.WithMessage(err, "creating transaction proposal failed")
+		return nil, errors.WithMessage(err, "creating transaction proposal failed")
 	}
 
-	transactionProposalResponses, err := transactor.SendTransactionProposal(proposal, targets)
-
-	return transactionProposalResponses, proposal, err
+	return proposal, nil
 }
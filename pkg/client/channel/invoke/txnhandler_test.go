@@ -17,7 +17,9 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
@@ -86,6 +88,87 @@ func TestExecuteTxHandlerSuccess(t *testing.T) {
 	assert.Nil(t, requestContext.Error)
 }
 
+func TestExecuteTxHandlerBlockCommitWait(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, Opts{BlockCommitWait: true}, t)
+
+	mockPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	mockPeer1.Payload = []byte("value")
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	mockEventService := fcmocks.NewMockEventService()
+	clientContext.EventService = mockEventService
+
+	go func() {
+		select {
+		case blockReg := <-mockEventService.FilteredBlockRegCh:
+			blockReg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+				Number:               4,
+				FilteredTransactions: []*pb.FilteredTransaction{{Txid: "othertx", TxValidationCode: pb.TxValidationCode_VALID}},
+			}}
+			blockReg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+				Number:               5,
+				FilteredTransactions: []*pb.FilteredTransaction{{Txid: string(requestContext.Response.TransactionID), TxValidationCode: pb.TxValidationCode_MVCC_READ_CONFLICT}},
+			}}
+		case <-time.After(requestContext.Opts.Timeouts[fab.Execute]):
+			panic("Execute handler : time out not expected")
+		}
+	}()
+
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.Equal(t, status.TxValidationStatus, s.Group)
+	assert.EqualValues(t, pb.TxValidationCode_MVCC_READ_CONFLICT, s.Code)
+	assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, requestContext.Response.TxValidationCode)
+	assert.EqualValues(t, 5, requestContext.Response.BlockNumber)
+	assert.Empty(t, mockEventService.TxStatusRegCh, "Expected no TxStatus registration")
+}
+
+func TestExecuteTxHandlerCCEventCapture(t *testing.T) {
+	for _, eventName := range []string{"transfer", "other"} {
+		request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+		requestContext := prepareRequestContext(request, Opts{CCEventCapture: eventName}, t)
+
+		mockPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+		clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+		mockEventService := fcmocks.NewMockEventService()
+		clientContext.EventService = mockEventService
+
+		go func() {
+			select {
+			case blockReg := <-mockEventService.FilteredBlockRegCh:
+				blockReg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+					Number: 3,
+					FilteredTransactions: []*pb.FilteredTransaction{{
+						Txid:             string(requestContext.Response.TransactionID),
+						TxValidationCode: pb.TxValidationCode_VALID,
+						Data: &pb.FilteredTransaction_TransactionActions{TransactionActions: &pb.FilteredTransactionActions{
+							ChaincodeActions: []*pb.FilteredChaincodeAction{{ChaincodeEvent: &pb.ChaincodeEvent{ChaincodeId: "test", EventName: "transfer"}}},
+						}},
+					}},
+				}}
+			case <-time.After(requestContext.Opts.Timeouts[fab.Execute]):
+				panic("Execute handler : time out not expected")
+			}
+		}()
+
+		NewExecuteHandler().Handle(requestContext, clientContext)
+		assert.Nil(t, requestContext.Error, "Expected execute to succeed")
+		if eventName != "transfer" {
+			assert.Nil(t, requestContext.Response.CCEvent, "Expected no chaincode event to be captured")
+			continue
+		}
+		if assert.NotNil(t, requestContext.Response.CCEvent, "Expected chaincode event to be captured") {
+			assert.Equal(t, "test", requestContext.Response.CCEvent.ChaincodeID)
+			assert.Equal(t, "transfer", requestContext.Response.CCEvent.EventName)
+			assert.EqualValues(t, requestContext.Response.TransactionID, requestContext.Response.CCEvent.TxID)
+			assert.EqualValues(t, 3, requestContext.Response.CCEvent.BlockNumber)
+		}
+	}
+}
+
 func TestQueryHandlerErrors(t *testing.T) {
 
 	//Error Scenario 1
@@ -157,6 +240,46 @@ func TestEndorsementHandler(t *testing.T) {
 }
 
 // Target filter
+func TestEndorsementHandlerWithThreshold(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	peer1 := fcmocks.NewMockPeer("p1", "peer1:7051")
+	peer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	peer2 := fcmocks.NewMockPeer("p2", "peer2:7051")
+	peer2.Payload = []byte("value")
+	peer3 := fcmocks.NewMockPeer("p3", "peer3:7051")
+	peer3.Payload = []byte("value")
+	peer4 := fcmocks.NewMockPeer("p4", "peer4:7051")
+	peer4.Payload = []byte("value")
+
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2, peer3, peer4}, EndorsementThreshold: 3}, t)
+	NewEndorsementHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Len(t, requestContext.Response.Responses, 3)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	if assert.Len(t, requestContext.Response.FailedEndorsers, 1) {
+		assert.Contains(t, requestContext.Response.FailedEndorsers, "peer1:7051")
+	}
+
+	// Not enough matching endorsements
+	peer4.Payload = []byte("other value")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2, peer3, peer4}, EndorsementThreshold: 3}, t)
+	NewEndorsementHandler().Handle(requestContext, clientContext)
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.MissingEndorsement.ToInt32(), s.Code, "expected missing endorsement")
+
+	// Fewer targets than the threshold
+	peer5 := fcmocks.NewMockPeer("p5", "peer5:7051")
+	requestContext = prepareRequestContext(request, Opts{Targets: []fab.Peer{peer5}, EndorsementThreshold: 2}, t)
+	NewEndorsementHandler().Handle(requestContext, clientContext)
+	s, ok = status.FromError(requestContext.Error)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected no peers found")
+	assert.Equal(t, 0, peer5.ProcessProposalCalls, "expected no proposal to be sent")
+}
+
 type filter struct {
 	peer fab.Peer
 }
@@ -180,8 +303,7 @@ func TestResponseValidation(t *testing.T) {
 			Message: "test", Status: http.StatusOK, Payload: []byte("ResponsePayload")},
 			Payload: []byte("ProposalPayload2"),
 		}}
-	h := EndorsementValidationHandler{}
-	err := h.validate([]*fab.TransactionProposalResponse{p1, p2})
+	err := matchAll([]*fab.TransactionProposalResponse{p1, p2}, nil)
 	assert.NotNil(t, err, "expected error with different response payloads")
 	s, ok := status.FromError(err)
 	assert.True(t, ok, "expected status error")
@@ -261,6 +383,35 @@ func TestProposalProcessorHandler(t *testing.T) {
 	}
 }
 
+func TestSelectEndorsersWithChaincodeInterests(t *testing.T) {
+	selection := &recordingSelectionService{}
+	clientContext := &ClientContext{Selection: selection}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke"}
+	_, err := selectEndorsers(prepareRequestContext(request, Opts{}, t), clientContext)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"testCC"}, selection.chaincodeIDs)
+	assert.Empty(t, selection.params.ChaincodeInterests)
+
+	interests := []fab.CCInterest{{ID: "otherCC", Collections: []string{"coll1"}}, {ID: "testCC"}}
+	_, err = selectEndorsers(prepareRequestContext(request, Opts{ChaincodeInterests: interests}, t), clientContext)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"testCC", "otherCC"}, selection.chaincodeIDs, "Expected the endorsers of all chaincodes to be selected")
+	assert.Equal(t, interests, selection.params.ChaincodeInterests, "Expected the interests to be passed to the selection service")
+}
+
+//recordingSelectionService records the chaincodes and options of the latest selection
+type recordingSelectionService struct {
+	chaincodeIDs []string
+	params       *selectopts.Params
+}
+
+func (s *recordingSelectionService) GetEndorsersForChaincode(chaincodeIDs []string, opts ...options.Opt) ([]fab.Peer, error) {
+	s.chaincodeIDs = chaincodeIDs
+	s.params = selectopts.NewParams(opts)
+	return []fab.Peer{fcmocks.NewMockPeer("p1", "peer1:7051")}, nil
+}
+
 //prepareHandlerContexts prepares context objects for handlers
 func prepareRequestContext(request Request, opts Opts, t *testing.T) *RequestContext {
 	requestContext := &RequestContext{Request: request,
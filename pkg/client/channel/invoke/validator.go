@@ -0,0 +1,135 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// EndorsementValidator decides which of the proposal responses collected from the endorsers are submitted in the
+// transaction. It returns the accepted responses, which must be consistent since the transaction is assembled with
+// the proposal response payload of the first one, or an error which fails the request.
+// Note that the endorsement policy of the chaincode is still enforced when the transaction is validated.
+type EndorsementValidator func(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error)
+
+// StrictMatch returns the validator which accepts the responses only if all of them are successful and consistent
+// according to compare (ByteExact if nil). It's the default validator.
+func StrictMatch(compare EndorsementComparator) EndorsementValidator {
+	return func(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error) {
+		if err := matchAll(responses, compare); err != nil {
+			return nil, err
+		}
+		return responses, nil
+	}
+}
+
+// MajorityMatch returns a validator which groups the successful responses that are consistent according to compare
+// (ByteExact if nil) and accepts the group endorsed by the most organizations, provided they're at least minOrgs.
+// The other responses, e.g. the write set of a disagreeing endorser, are discarded.
+// Returns a MissingEndorsement status if no group is endorsed by minOrgs organizations, or an EndorsementMismatch
+// status if several groups are endorsed by as many organizations.
+func MajorityMatch(minOrgs int, compare EndorsementComparator) EndorsementValidator {
+	return func(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error) {
+		var groups [][]*fab.TransactionProposalResponse
+		for _, r := range responses {
+			if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+				continue
+			}
+			i, err := matchingEndorsements(groups, r, compare)
+			if err != nil {
+				return nil, errors.WithMessage(err, "comparing proposal responses failed")
+			}
+			if i < 0 {
+				groups = append(groups, nil)
+				i = len(groups) - 1
+			}
+			groups[i] = append(groups[i], r)
+		}
+
+		var majority []*fab.TransactionProposalResponse
+		majorityOrgs := 0
+		tie := false
+		for _, group := range groups {
+			orgs := numOrgs(group)
+			if orgs > majorityOrgs {
+				majority, majorityOrgs, tie = group, orgs, false
+			} else if orgs == majorityOrgs {
+				tie = true
+			}
+		}
+
+		if majorityOrgs < minOrgs {
+			return nil, status.New(status.EndorserClientStatus, status.MissingEndorsement.ToInt32(),
+				fmt.Sprintf("matching endorsements of %d organizations received but %d are required", majorityOrgs, minOrgs), nil)
+		}
+		if tie {
+			return nil, status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
+				fmt.Sprintf("several sets of matching endorsements of %d organizations received", majorityOrgs), nil)
+		}
+		return majority, nil
+	}
+}
+
+//matchAll returns an error unless all responses are successful and consistent according to compare
+func matchAll(responses []*fab.TransactionProposalResponse, compare EndorsementComparator) error {
+	if compare == nil {
+		compare = ByteExact
+	}
+
+	var a1 *pb.ProposalResponse
+	for n, r := range responses {
+		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
+			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
+		}
+		if n == 0 {
+			a1 = r.ProposalResponse
+			continue
+		}
+
+		match, err := compare(a1, r.ProposalResponse)
+		if err != nil {
+			return errors.WithMessage(err, "comparing proposal responses failed")
+		}
+		if !match {
+			return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
+				"ProposalResponsePayloads do not match", nil)
+		}
+	}
+
+	return nil
+}
+
+//numOrgs returns the number of organizations (MSPs) which endorsed the responses
+func numOrgs(responses []*fab.TransactionProposalResponse) int {
+	orgs := make(map[string]bool)
+	for _, r := range responses {
+		orgs[r.MSPID] = true
+	}
+	return len(orgs)
+}
+
+//failedEndorsers returns the error of the request for each target which didn't return a response
+func failedEndorsers(targets []fab.Peer, responses []*fab.TransactionProposalResponse, err error) map[string]error {
+	responded := make(map[string]bool)
+	for _, r := range responses {
+		responded[r.Endorser] = true
+	}
+	failed := make(map[string]error)
+	for _, target := range targets {
+		if !responded[target.URL()] {
+			failed[target.URL()] = err
+		}
+	}
+	return failed
+}
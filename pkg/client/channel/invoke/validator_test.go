@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestMajorityMatch(t *testing.T) {
+	r1 := orgResponse(t, "peer1", "Org1MSP", "value")
+	r2 := orgResponse(t, "peer2", "Org2MSP", "value")
+	r3 := orgResponse(t, "peer3", "Org3MSP", "other value")
+	r4 := orgResponse(t, "peer4", "Org1MSP", "value")
+	failed := orgResponse(t, "peer5", "Org4MSP", "value")
+	failed.ProposalResponse.Response.Status = 500
+
+	// The write set endorsed by most organizations is accepted
+	responses, err := MajorityMatch(2, nil)([]*fab.TransactionProposalResponse{r1, r3, r2, failed})
+	assert.Nil(t, err)
+	assert.Equal(t, []*fab.TransactionProposalResponse{r1, r2}, responses)
+
+	// Endorsements of peers of the same organization count once
+	_, err = MajorityMatch(2, nil)([]*fab.TransactionProposalResponse{r1, r3, r4})
+	assertStatusCode(t, status.MissingEndorsement, err)
+
+	_, err = MajorityMatch(2, nil)([]*fab.TransactionProposalResponse{r1, r2, r3, orgResponse(t, "peer6", "Org4MSP", "other value")})
+	assertStatusCode(t, status.EndorsementMismatch, err)
+
+	_, err = MajorityMatch(1, nil)(nil)
+	assertStatusCode(t, status.MissingEndorsement, err)
+
+	// The strict validator requires all responses to match
+	_, err = StrictMatch(nil)([]*fab.TransactionProposalResponse{r1, r2, r3})
+	assertStatusCode(t, status.EndorsementMismatch, err)
+	responses, err = StrictMatch(nil)([]*fab.TransactionProposalResponse{r1, r2})
+	assert.Nil(t, err)
+	assert.Len(t, responses, 2)
+}
+
+func TestEndorsementValidator(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	peer1 := &fcmocks.MockPeer{MockName: "p1", MockURL: "peer1:7051", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peer2 := &fcmocks.MockPeer{MockName: "p2", MockURL: "peer2:7051", MockMSP: "Org2MSP", Status: 200, Payload: []byte("value")}
+	peer3 := &fcmocks.MockPeer{MockName: "p3", MockURL: "peer3:7051", MockMSP: "Org3MSP", Status: 200, Payload: []byte("other value")}
+	peer4 := &fcmocks.MockPeer{MockName: "p4", MockURL: "peer4:7051", MockMSP: "Org4MSP", Status: 200,
+		Error: status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)}
+	targets := []fab.Peer{peer1, peer2, peer3, peer4}
+
+	// By default, the disagreeing and failed endorsers fail the request
+	requestContext := prepareRequestContext(request, Opts{Targets: targets}, t)
+	NewEndorsementHandler(NewEndorsementValidationHandler()).Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error)
+
+	requestContext = prepareRequestContext(request, Opts{Targets: targets, EndorsementValidator: MajorityMatch(2, nil)}, t)
+	NewEndorsementHandler(NewEndorsementValidationHandler()).Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.ElementsMatch(t, []string{"peer1:7051", "peer2:7051"}, endorserURLs(requestContext.Response.Responses))
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+	if assert.Len(t, requestContext.Response.FailedEndorsers, 1) {
+		assert.Contains(t, requestContext.Response.FailedEndorsers, "peer4:7051")
+	}
+
+	// The validator may reject all endorsements
+	requestContext = prepareRequestContext(request, Opts{Targets: targets, EndorsementValidator: MajorityMatch(3, nil)}, t)
+	NewEndorsementHandler(NewEndorsementValidationHandler()).Handle(requestContext, clientContext)
+	assertStatusCode(t, status.MissingEndorsement, requestContext.Error)
+}
+
+func orgResponse(t *testing.T, endorser, mspID, payload string) *fab.TransactionProposalResponse {
+	r := proposalResponse(t, payload, 1, "event", payload)
+	r.Endorser = endorser
+	r.MSPID = mspID
+	return r
+}
+
+func assertStatusCode(t *testing.T, code status.Code, err error) {
+	s, ok := status.FromError(err)
+	if assert.True(t, ok, "expected status error, got: %v", err) {
+		assert.EqualValues(t, code.ToInt32(), s.Code)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/pkg/errors"
+)
+
+// Metrics is a sink for the durations of the phases of the transactions executed by the client.
+// The sink is invoked synchronously by the handlers so it must return promptly.
+type Metrics interface {
+	// EndorsementDuration is invoked with the duration of the endorsement of the proposal by the targets
+	EndorsementDuration(duration time.Duration, labels invoke.RequestLabels)
+	// OrderingDuration is invoked with the duration of the broadcast of the transaction to the orderer
+	OrderingDuration(duration time.Duration, labels invoke.RequestLabels)
+	// CommitDuration is invoked with the duration of the wait for the commit event of the transaction
+	CommitDuration(duration time.Duration, labels invoke.RequestLabels)
+}
+
+// WithMetricsObserver sets an observer which is notified of the latency of each request and of its stages
+// (selection, endorsement, validation, broadcast and commit), e.g. to export latency percentiles per stage.
+func WithMetricsObserver(observer invoke.MetricsObserver) ClientOption {
+	return func(client *Client) error {
+		if observer == nil {
+			return errors.New("metrics observer is required")
+		}
+		client.metricsObserver = observer
+		return nil
+	}
+}
+
+// WithMetrics sets a sink which is notified of the duration of the endorsement, ordering and commit of each
+// attempt of the requests. It may be used along with a metrics observer.
+func WithMetrics(sink Metrics) ClientOption {
+	return func(client *Client) error {
+		if sink == nil {
+			return errors.New("metrics sink is required")
+		}
+		client.metricsSink = sink
+		return nil
+	}
+}
+
+//sinkObserver reports the durations of the stages to a metrics sink, and to the next observer, if any
+type sinkObserver struct {
+	sink Metrics
+	next invoke.MetricsObserver
+}
+
+func newSinkObserver(sink Metrics, next invoke.MetricsObserver) *sinkObserver {
+	return &sinkObserver{sink: sink, next: next}
+}
+
+func (o *sinkObserver) ObserveStage(stage invoke.Stage, duration time.Duration, targets []string, labels invoke.RequestLabels) {
+	switch stage {
+	case invoke.StageEndorsement:
+		o.sink.EndorsementDuration(duration, labels)
+	case invoke.StageBroadcast:
+		o.sink.OrderingDuration(duration, labels)
+	case invoke.StageCommit:
+		o.sink.CommitDuration(duration, labels)
+	}
+	if o.next != nil {
+		o.next.ObserveStage(stage, duration, targets, labels)
+	}
+}
+
+func (o *sinkObserver) ObserveRequest(duration time.Duration, code int32, labels invoke.RequestLabels) {
+	if o.next != nil {
+		o.next.ObserveRequest(duration, code, labels)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestMetricsObserver(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	orderer := fcmocks.NewMockOrderer("orderer.example.com", nil)
+	chClient := setupChannelClientWithNodes([]fab.Peer{testPeer}, []fab.Orderer{orderer}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	err := WithMetricsObserver(nil)(chClient)
+	assert.NotNil(t, err, "Expected error for nil metrics observer")
+
+	observer := newHistogramObserver(time.Millisecond, 10*time.Millisecond, 100*time.Millisecond, time.Second)
+	assert.Nil(t, WithMetricsObserver(observer)(chClient))
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	go commitTx(mockEventService, 7)
+	_, err = chClient.Execute(request)
+	assert.Nil(t, err, "Failed to execute transaction")
+
+	assert.Equal(t, []invoke.Stage{invoke.StageSelection, invoke.StageEndorsement, invoke.StageValidation, invoke.StageBroadcast, invoke.StageCommit}, observer.stages)
+	labels := fmt.Sprintf("channel=%q,chaincode=%q", channelID, "testCC")
+	assert.Equal(t, uint64(1), observer.count(fmt.Sprintf("stage=%q,%s,target=%q", invoke.StageEndorsement, labels, "http://peer1.com")))
+	assert.Equal(t, uint64(1), observer.count(fmt.Sprintf("stage=%q,%s,target=%q", invoke.StageBroadcast, labels, "orderer.example.com")))
+	assert.Equal(t, uint64(1), observer.count(fmt.Sprintf("%s,code=%q", labels, status.OK)))
+
+	// The status code of a failed request is observed
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("other value")
+	_, err = chClient.Query(request, WithTargets(testPeer, testPeer2))
+	assert.NotNil(t, err, "Expected endorsement mismatch")
+	assert.Equal(t, uint64(1), observer.count(fmt.Sprintf("%s,code=%q", labels, status.EndorsementMismatch)))
+	t.Logf("Observed latencies:\n%s", observer)
+}
+
+func TestMetricsSink(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	orderer := fcmocks.NewMockOrderer("orderer.example.com", nil)
+	discoveryService, err := setupTestDiscovery(nil, nil)
+	assert.Nil(t, err, "Failed to setup discovery service")
+	selectionService, err := setupTestSelection(nil, []fab.Peer{testPeer})
+	assert.Nil(t, err, "Failed to setup selection service")
+	ctx := createChannelContext(setupCustomTestContext(t, selectionService, discoveryService, []fab.Orderer{orderer}), channelID)
+
+	_, err = New(ctx, WithMetrics(nil))
+	assert.NotNil(t, err, "Expected error for nil metrics sink")
+
+	// The sink is used along with the observer, regardless of the order of the options
+	sink := &durationSink{}
+	observer := newHistogramObserver(time.Second)
+	chClient, err := New(ctx, WithMetrics(sink), WithMetricsObserver(observer))
+	if err != nil {
+		t.Fatalf("Failed to create new channel client: %s", err)
+	}
+	mockEventService := fcmocks.NewMockEventService()
+	chClient.eventService = mockEventService
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	go commitTx(mockEventService, 7)
+	_, err = chClient.Execute(request)
+	assert.Nil(t, err, "Failed to execute transaction")
+
+	labels := invoke.RequestLabels{ChannelID: channelID, ChaincodeID: "testCC"}
+	assert.Equal(t, []string{"endorsement", "ordering", "commit"}, sink.phases)
+	assert.Equal(t, []invoke.RequestLabels{labels, labels, labels}, sink.labels)
+	assert.Equal(t, []invoke.Stage{invoke.StageSelection, invoke.StageEndorsement, invoke.StageValidation, invoke.StageBroadcast, invoke.StageCommit}, observer.stages)
+	assert.Equal(t, uint64(1), observer.count(fmt.Sprintf("channel=%q,chaincode=%q,code=%q", channelID, "testCC", status.OK)))
+
+	// Queries are only endorsed
+	sink.reset()
+	_, err = chClient.Query(request)
+	assert.Nil(t, err, "Failed to query")
+	assert.Equal(t, []string{"endorsement"}, sink.phases)
+}
+
+//durationSink records the phases whose durations are reported to the sink
+type durationSink struct {
+	mutex  sync.Mutex
+	phases []string
+	labels []invoke.RequestLabels
+}
+
+func (s *durationSink) EndorsementDuration(duration time.Duration, labels invoke.RequestLabels) {
+	s.record("endorsement", labels)
+}
+
+func (s *durationSink) OrderingDuration(duration time.Duration, labels invoke.RequestLabels) {
+	s.record("ordering", labels)
+}
+
+func (s *durationSink) CommitDuration(duration time.Duration, labels invoke.RequestLabels) {
+	s.record("commit", labels)
+}
+
+func (s *durationSink) record(phase string, labels invoke.RequestLabels) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.phases = append(s.phases, phase)
+	s.labels = append(s.labels, labels)
+}
+
+func (s *durationSink) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.phases = nil
+	s.labels = nil
+}
+
+//histogramObserver is an example of a metrics observer which keeps Prometheus-style latency histograms of the
+//stages by target, and of the requests by status code
+type histogramObserver struct {
+	mutex   sync.Mutex
+	buckets []time.Duration
+	series  map[string][]uint64 // cumulative bucket counts by labels, the last bucket being +Inf
+	stages  []invoke.Stage
+}
+
+func newHistogramObserver(buckets ...time.Duration) *histogramObserver {
+	return &histogramObserver{buckets: buckets, series: make(map[string][]uint64)}
+}
+
+func (o *histogramObserver) ObserveStage(stage invoke.Stage, duration time.Duration, targets []string, labels invoke.RequestLabels) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.stages = append(o.stages, stage)
+	for _, target := range targets {
+		o.observe(fmt.Sprintf("stage=%q,channel=%q,chaincode=%q,target=%q", stage, labels.ChannelID, labels.ChaincodeID, target), duration)
+	}
+}
+
+func (o *histogramObserver) ObserveRequest(duration time.Duration, code int32, labels invoke.RequestLabels) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.observe(fmt.Sprintf("channel=%q,chaincode=%q,code=%q", labels.ChannelID, labels.ChaincodeID, status.Code(code)), duration)
+}
+
+func (o *histogramObserver) observe(labels string, duration time.Duration) {
+	counts, ok := o.series[labels]
+	if !ok {
+		counts = make([]uint64, len(o.buckets)+1)
+		o.series[labels] = counts
+	}
+	for i, bucket := range o.buckets {
+		if duration <= bucket {
+			counts[i]++
+		}
+	}
+	counts[len(o.buckets)]++
+}
+
+//count returns the number of observations of the series with the given labels
+func (o *histogramObserver) count(labels string) uint64 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	counts, ok := o.series[labels]
+	if !ok {
+		return 0
+	}
+	return counts[len(counts)-1]
+}
+
+//String formats the histograms in the Prometheus text format
+func (o *histogramObserver) String() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var b bytes.Buffer
+	for labels, counts := range o.series {
+		for i, bucket := range o.buckets {
+			fmt.Fprintf(&b, "fabsdk_latency_bucket{%s,le=\"%g\"} %d\n", labels, bucket.Seconds(), counts[i])
+		}
+		fmt.Fprintf(&b, "fabsdk_latency_bucket{%s,le=\"+Inf\"} %d\n", labels, counts[len(o.buckets)])
+	}
+	return b.String()
+}
@@ -0,0 +1,230 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"container/list"
+	reqContext "context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxPendingTransactions = 10000
+	defaultPendingTransactionTTL  = 10 * time.Minute
+)
+
+// PendingTransaction is a transaction submitted by the client whose commit has not been observed yet
+type PendingTransaction struct {
+	TxID      fab.TransactionID
+	Submitted time.Time
+	Targets   []string // URLs of the endorsing peers
+}
+
+// WithPendingTransactionLimits bounds the registry of pending transactions to maxEntries transactions,
+// each of which is tracked for at most ttl. Transactions evicted while still pending are logged.
+func WithPendingTransactionLimits(maxEntries int, ttl time.Duration) ClientOption {
+	return func(client *Client) error {
+		if maxEntries < 1 || ttl <= 0 {
+			return errors.Errorf("invalid pending transaction limits [%d, %s]", maxEntries, ttl)
+		}
+		client.pending = newPendingTxRegistry(maxEntries, ttl)
+		return nil
+	}
+}
+
+// pendingTxRegistry is a size and TTL bounded registry of the transactions submitted by the client. The
+// transactions are kept in the order of their submission, so that the oldest are expired or evicted first.
+type pendingTxRegistry struct {
+	maxEntries int
+	ttl        time.Duration
+	lock       sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	drained    chan struct{}
+}
+
+func newPendingTxRegistry(maxEntries int, ttl time.Duration) *pendingTxRegistry {
+	drained := make(chan struct{})
+	close(drained)
+
+	return &pendingTxRegistry{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		drained:    drained,
+	}
+}
+
+// add registers the transaction as pending, evicting the oldest transaction if the registry is full
+func (r *pendingTxRegistry) add(txID fab.TransactionID, targets []fab.Peer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.purgeExpired()
+	if e, ok := r.entries[string(txID)]; ok {
+		// The transaction is submitted again
+		r.order.Remove(e)
+		delete(r.entries, string(txID))
+	} else if len(r.entries) >= r.maxEntries {
+		r.evictOldest()
+	}
+
+	var urls []string
+	for _, target := range targets {
+		urls = append(urls, target.URL())
+	}
+
+	if len(r.entries) == 0 {
+		r.drained = make(chan struct{})
+	}
+	r.entries[string(txID)] = r.order.PushBack(&PendingTransaction{TxID: txID, Submitted: time.Now(), Targets: urls})
+}
+
+// remove removes the transaction once its commit has been observed or it was never submitted
+func (r *pendingTxRegistry) remove(txID fab.TransactionID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.delete(string(txID))
+}
+
+// contains returns true if the transaction is pending
+func (r *pendingTxRegistry) contains(txID fab.TransactionID) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.purgeExpired()
+	_, ok := r.entries[string(txID)]
+	return ok
+}
+
+// list returns the pending transactions
+func (r *pendingTxRegistry) list() []PendingTransaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.purgeExpired()
+
+	var pending []PendingTransaction
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		pending = append(pending, *e.Value.(*PendingTransaction))
+	}
+	return pending
+}
+
+// wait blocks until there are no pending transactions or the context is done
+func (r *pendingTxRegistry) wait(ctx reqContext.Context) error {
+	for {
+		r.lock.Lock()
+		r.purgeExpired()
+		drained := r.drained
+		nextExpiry := r.nextExpiry()
+		r.lock.Unlock()
+
+		select {
+		case <-drained:
+			return nil
+		case <-time.After(time.Until(nextExpiry)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *pendingTxRegistry) delete(txID string) {
+	e, ok := r.entries[txID]
+	if !ok {
+		return
+	}
+	r.order.Remove(e)
+	delete(r.entries, txID)
+	if len(r.entries) == 0 {
+		close(r.drained)
+	}
+}
+
+func (r *pendingTxRegistry) purgeExpired() {
+	now := time.Now()
+	for e := r.order.Front(); e != nil; e = r.order.Front() {
+		entry := e.Value.(*PendingTransaction)
+		if now.Sub(entry.Submitted) <= r.ttl {
+			return
+		}
+		logger.Warnf("Pending transaction [%s] expired before its commit was observed", entry.TxID)
+		r.delete(string(entry.TxID))
+	}
+}
+
+func (r *pendingTxRegistry) evictOldest() {
+	if e := r.order.Front(); e != nil {
+		oldest := e.Value.(*PendingTransaction)
+		logger.Warnf("Pending transaction [%s] evicted before its commit was observed", oldest.TxID)
+		r.delete(string(oldest.TxID))
+	}
+}
+
+func (r *pendingTxRegistry) nextExpiry() time.Time {
+	if e := r.order.Front(); e != nil {
+		return e.Value.(*PendingTransaction).Submitted.Add(r.ttl)
+	}
+	return time.Now().Add(r.ttl)
+}
+
+//trackStep is the name of the step of the transaction chains tracking the transactions
+const trackStep = "track"
+
+//txTrackingHandler tracks the endorsed transaction as pending until its commit is observed, and records its ID
+//for the idempotency key of the request if any
+type txTrackingHandler struct {
+	client *Client
+	next   invoke.Handler
+}
+
+//Handle tracks the transaction around its commit
+func (h *txTrackingHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	txID := requestContext.Response.TransactionID
+
+	if key := requestContext.Opts.IdempotencyKey; key != "" {
+		if err := h.client.recordTxID(key, txID); err != nil {
+			requestContext.Error = errors.WithMessage(err, "recording transaction of idempotency key failed")
+			return
+		}
+	}
+
+	if notifier := h.client.commitNotifier; notifier != nil {
+		err := notifier.track(clientContext.EventService, txID, requestContext.Opts.CorrelationMetadata)
+		if err != nil {
+			requestContext.Error = errors.WithMessage(err, "tracking transaction for post-commit hook failed")
+			return
+		}
+	}
+
+	h.client.pending.add(txID, requestContext.Opts.Targets)
+
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+
+	if requestContext.Error != nil && requestContext.Ctx.Err() != nil {
+		// The commit wasn't observed before the request ended, so the transaction stays pending
+		return
+	}
+
+	if requestContext.Error == nil && requestContext.Response.NoCommitWait && h.client.commitNotifier != nil {
+		// The commit wasn't awaited, so the transaction stays pending until the post-commit hook observes it
+		return
+	}
+
+	// Either the commit was observed or the transaction failed before it could be committed
+	h.client.pending.remove(txID)
+}
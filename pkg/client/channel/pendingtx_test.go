@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingTxRegistry(t *testing.T) {
+	registry := newPendingTxRegistry(2, time.Minute)
+	peer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+
+	registry.add("txid1", []fab.Peer{peer})
+	registry.add("txid2", nil)
+	assert.Len(t, registry.list(), 2)
+
+	// Registry is full so the oldest transaction is evicted
+	registry.add("txid3", nil)
+	pending := registry.list()
+	assert.Len(t, pending, 2)
+	for _, tx := range pending {
+		assert.NotEqual(t, fab.TransactionID("txid1"), tx.TxID, "Expected oldest transaction to be evicted")
+	}
+
+	// A transaction submitted again becomes the newest
+	registry.add("txid2", nil)
+	registry.add("txid4", nil)
+	pending = registry.list()
+	if assert.Len(t, pending, 2) {
+		assert.Equal(t, fab.TransactionID("txid2"), pending[0].TxID, "Expected transactions in submission order")
+		assert.Equal(t, fab.TransactionID("txid4"), pending[1].TxID, "Expected transactions in submission order")
+	}
+
+	registry.remove("txid2")
+	registry.remove("txid4")
+	assert.Empty(t, registry.list())
+	assert.Nil(t, registry.wait(reqContext.Background()), "Expected wait to return for drained registry")
+}
+
+func TestPendingTxRegistryTTL(t *testing.T) {
+	registry := newPendingTxRegistry(10, 20*time.Millisecond)
+	registry.add("txid1", nil)
+	assert.Len(t, registry.list(), 1)
+
+	// Wait returns once the pending transaction expires
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, registry.wait(ctx), "Expected wait to return when pending transaction expires")
+	assert.Empty(t, registry.list())
+}
+
+func TestWaitForPending(t *testing.T) {
+	registry := newPendingTxRegistry(10, time.Minute)
+	registry.add("txid1", nil)
+
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, reqContext.DeadlineExceeded, registry.wait(ctx), "Expected wait to time out")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		registry.remove("txid1")
+	}()
+	assert.Nil(t, registry.wait(reqContext.Background()), "Expected wait to return once registry drains")
+}
@@ -0,0 +1,286 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+const defaultQueryCacheSize = 1000
+
+// CachedResponse is a query response held by a QueryCache
+type CachedResponse struct {
+	ChaincodeID string
+	Response    Response
+	Expiry      time.Time
+}
+
+// QueryCache stores the responses of queries. Implementations must be safe for concurrent use;
+// they may keep expired responses, which are then only served as stale responses (see WithStaleQueryResponses).
+type QueryCache interface {
+	// Get returns the response cached under the key
+	Get(key string) (*CachedResponse, bool)
+	// Put caches the response under the key
+	Put(key string, response *CachedResponse)
+	// Invalidate removes the responses of the chaincode
+	Invalidate(chaincodeID string)
+	// InvalidateAll removes all responses
+	InvalidateAll()
+}
+
+// CacheInvalidation defines the events upon which cached query responses are invalidated.
+// Strategies may be combined, e.g. InvalidateOnExecute | InvalidateOnChaincodeEvent.
+type CacheInvalidation int
+
+const (
+	// InvalidateOnExecute invalidates the responses of a chaincode when the client executes a transaction against it
+	InvalidateOnExecute CacheInvalidation = 1 << iota
+	// InvalidateOnChaincodeEvent invalidates the responses of a chaincode when a committed transaction emitted one of its events
+	InvalidateOnChaincodeEvent
+	// InvalidateOnBlock invalidates all responses whenever a block is committed
+	InvalidateOnBlock
+)
+
+// QueryCacheStats contains the metrics of the query cache
+type QueryCacheStats struct {
+	Hits        uint64 // queries served from the cache
+	Misses      uint64 // queries sent to the peers
+	StaleServes uint64 // expired responses served because the peers failed
+}
+
+// WithQueryCache caches the responses of Query for the given TTL. Queries with a transient map are not cached.
+// Use NewLRUQueryCache for an in-memory cache.
+func WithQueryCache(cache QueryCache, ttl time.Duration) ClientOption {
+	return func(client *Client) error {
+		if cache == nil {
+			return errors.New("query cache is required")
+		}
+		if ttl <= 0 {
+			return errors.Errorf("invalid query cache TTL [%s]", ttl)
+		}
+		client.queryCache = &queryCache{cache: cache, ttl: ttl, invalidation: InvalidateOnExecute}
+		return nil
+	}
+}
+
+// WithQueryCacheInvalidation sets the invalidation strategy of the query cache (InvalidateOnExecute by default).
+// It must follow WithQueryCache.
+func WithQueryCacheInvalidation(invalidation CacheInvalidation) ClientOption {
+	return func(client *Client) error {
+		if client.queryCache == nil {
+			return errors.New("query cache is not configured")
+		}
+		client.queryCache.invalidation = invalidation
+		return nil
+	}
+}
+
+// WithStaleQueryResponses serves a cached response which expired less than maxStaleness ago if the peers fail
+// to respond to the query. Invalidated responses are never served. It must follow WithQueryCache.
+func WithStaleQueryResponses(maxStaleness time.Duration) ClientOption {
+	return func(client *Client) error {
+		if client.queryCache == nil {
+			return errors.New("query cache is not configured")
+		}
+		if maxStaleness <= 0 {
+			return errors.Errorf("invalid query cache staleness [%s]", maxStaleness)
+		}
+		client.queryCache.maxStaleness = maxStaleness
+		return nil
+	}
+}
+
+// queryCache serves queries from the configured cache and invalidates it
+type queryCache struct {
+	// 64-bit counters first for atomic access on 32-bit platforms
+	generation   uint64
+	hits         uint64
+	misses       uint64
+	staleServes  uint64
+	cache        QueryCache
+	ttl          time.Duration
+	maxStaleness time.Duration
+	invalidation CacheInvalidation
+}
+
+// query returns the cached response of the request or invokes the query and caches its response
+func (c *queryCache) query(channelID string, request Request, bypass bool, invoke func() (Response, error)) (Response, error) {
+	if len(request.TransientMap) > 0 {
+		return invoke()
+	}
+
+	key := queryCacheKey(channelID, request)
+	var cached *CachedResponse
+	var ok bool
+	if !bypass {
+		cached, ok = c.cache.Get(key)
+	}
+	if ok && time.Now().Before(cached.Expiry) {
+		atomic.AddUint64(&c.hits, 1)
+		return cached.Response, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	// Responses of queries that were in flight while the cache was invalidated may be outdated
+	generation := atomic.LoadUint64(&c.generation)
+	response, err := invoke()
+	if err != nil {
+		if ok && c.maxStaleness > 0 && time.Since(cached.Expiry) < c.maxStaleness {
+			logger.Debugf("Serving stale response of query [%s:%s]: %s", request.ChaincodeID, request.Fcn, err)
+			atomic.AddUint64(&c.staleServes, 1)
+			return cached.Response, nil
+		}
+		return response, err
+	}
+
+	if atomic.LoadUint64(&c.generation) == generation {
+		c.cache.Put(key, &CachedResponse{ChaincodeID: request.ChaincodeID, Response: response, Expiry: time.Now().Add(c.ttl)})
+	}
+	return response, nil
+}
+
+func (c *queryCache) invalidate(chaincodeID string) {
+	atomic.AddUint64(&c.generation, 1)
+	c.cache.Invalidate(chaincodeID)
+}
+
+func (c *queryCache) invalidateAll() {
+	atomic.AddUint64(&c.generation, 1)
+	c.cache.InvalidateAll()
+}
+
+func (c *queryCache) stats() QueryCacheStats {
+	return QueryCacheStats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		StaleServes: atomic.LoadUint64(&c.staleServes),
+	}
+}
+
+// listen invalidates the cache upon the committed blocks, as per the invalidation strategy
+func (c *queryCache) listen(eventch <-chan *fab.FilteredBlockEvent) {
+	for event := range eventch {
+		if event.FilteredBlock == nil {
+			continue
+		}
+		if c.invalidation&InvalidateOnBlock != 0 {
+			c.invalidateAll()
+			continue
+		}
+		for _, tx := range event.FilteredBlock.FilteredTransactions {
+			for _, action := range tx.GetTransactionActions().GetChaincodeActions() {
+				if ccEvent := action.GetChaincodeEvent(); ccEvent != nil {
+					c.invalidate(ccEvent.ChaincodeId)
+				}
+			}
+		}
+	}
+}
+
+// queryCacheKey returns the key of the query: a hash of the channel, chaincode, function and arguments
+func queryCacheKey(channelID string, request Request) string {
+	h := sha256.New()
+	write := func(b []byte) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+		h.Write(length[:])
+		h.Write(b)
+	}
+
+	write([]byte(channelID))
+	write([]byte(request.ChaincodeID))
+	write([]byte(request.Fcn))
+	for _, arg := range request.Args {
+		write(arg)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lruQueryCache is an in-memory QueryCache which evicts the least recently used responses
+type lruQueryCache struct {
+	maxEntries int
+	lock       sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type lruEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewLRUQueryCache returns an in-memory query cache holding up to maxEntries responses
+// (1000 if maxEntries is not positive)
+func NewLRUQueryCache(maxEntries int) QueryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultQueryCacheSize
+	}
+	return &lruQueryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *lruQueryCache) Get(key string) (*CachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).response, true
+}
+
+func (c *lruQueryCache) Put(key string, response *CachedResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).response = response
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, response: response})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruQueryCache) Invalidate(chaincodeID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, e := range c.entries {
+		if e.Value.(*lruEntry).response.ChaincodeID == chaincodeID {
+			c.order.Remove(e)
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *lruQueryCache) InvalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCache(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	if err := WithQueryCache(NewLRUQueryCache(0), time.Minute)(chClient); err != nil {
+		t.Fatalf("Failed to set query cache: %s", err)
+	}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	for i := 0; i < 3; i++ {
+		response, err := chClient.Query(request)
+		if err != nil {
+			t.Fatalf("Failed to query: %s", err)
+		}
+		assert.Equal(t, []byte("value"), response.Payload)
+	}
+	assert.Equal(t, 1, testPeer.ProcessProposalCalls, "Expected repeated queries to be served from the cache")
+	assert.Equal(t, QueryCacheStats{Hits: 2, Misses: 1}, chClient.QueryCacheStats())
+
+	// Different arguments aren't served from the cache
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("a")}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, testPeer.ProcessProposalCalls)
+
+	_, err = chClient.Query(request, WithoutCache())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, testPeer.ProcessProposalCalls, "Expected WithoutCache to bypass the cache")
+
+	// Executing a transaction against the chaincode invalidates its responses, even if Execute fails
+	_, err = chClient.Execute(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move")}}, WithTimeout(fab.Execute, 50*time.Millisecond))
+	assert.Error(t, err)
+	calls := testPeer.ProcessProposalCalls
+
+	_, err = chClient.Query(request)
+	assert.NoError(t, err)
+	assert.Equal(t, calls+1, testPeer.ProcessProposalCalls, "Expected query to be sent to the peers after Execute")
+}
+
+func TestQueryCacheWithQuorum(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = []byte("other value")
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	if err := WithQueryCache(NewLRUQueryCache(0), time.Minute)(chClient); err != nil {
+		t.Fatalf("Failed to set query cache: %s", err)
+	}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	response, err := chClient.Query(request, WithTargets(testPeer1))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), response.Payload)
+
+	// The response of a single peer doesn't satisfy the quorum
+	_, err = chClient.Query(request, WithQueryQuorum(2))
+	s, ok := status.FromError(err)
+	if assert.True(t, ok, "Expected status error got %+v", err) {
+		assert.EqualValues(t, status.QuorumNotReached.ToInt32(), s.Code, "Expected quorum query to bypass the cache")
+	}
+
+	testPeer2.Payload = []byte("value")
+	calls := testPeer1.ProcessProposalCalls
+	response, err = chClient.Query(request, WithQueryQuorum(2))
+	assert.NoError(t, err)
+	assert.Len(t, response.Responses, 2, "Expected the responses of the quorum")
+	assert.Equal(t, calls+1, testPeer1.ProcessProposalCalls)
+
+	calls = testPeer1.ProcessProposalCalls
+	_, err = chClient.Query(request, WithTargets(testPeer1), WithMaxBlockHeightSelection())
+	assert.NoError(t, err)
+	assert.True(t, testPeer1.ProcessProposalCalls > calls, "Expected block height selection to bypass the cache")
+	assert.Zero(t, chClient.QueryCacheStats().Hits, "Expected no query to be served from the cache")
+}
+
+func TestQueryCacheStaleResponses(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	assert.Error(t, WithStaleQueryResponses(time.Minute)(chClient), "Expected error without query cache")
+	if err := WithQueryCache(NewLRUQueryCache(0), 10*time.Millisecond)(chClient); err != nil {
+		t.Fatalf("Failed to set query cache: %s", err)
+	}
+	if err := WithStaleQueryResponses(time.Minute)(chClient); err != nil {
+		t.Fatalf("Failed to set stale query responses: %s", err)
+	}
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request)
+	assert.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	testPeer.Error = errors.New("peer unavailable")
+
+	response, err := chClient.Query(request)
+	assert.NoError(t, err, "Expected stale response to be served")
+	assert.Equal(t, []byte("value"), response.Payload)
+	assert.Equal(t, QueryCacheStats{Misses: 2, StaleServes: 1}, chClient.QueryCacheStats())
+
+	// Invalidated responses are never served
+	chClient.queryCache.invalidate("testCC")
+	_, err = chClient.Query(request)
+	assert.Error(t, err)
+}
+
+func TestQueryCacheChaincodeEventInvalidation(t *testing.T) {
+	qc := &queryCache{cache: NewLRUQueryCache(0), ttl: time.Minute, invalidation: InvalidateOnChaincodeEvent}
+	qc.cache.Put("k1", &CachedResponse{ChaincodeID: "cc1", Expiry: time.Now().Add(time.Minute)})
+	qc.cache.Put("k2", &CachedResponse{ChaincodeID: "cc2", Expiry: time.Now().Add(time.Minute)})
+
+	eventch := make(chan *fab.FilteredBlockEvent, 1)
+	eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{
+		FilteredTransactions: []*pb.FilteredTransaction{{
+			Txid: "txid",
+			Data: &pb.FilteredTransaction_TransactionActions{TransactionActions: &pb.FilteredTransactionActions{
+				ChaincodeActions: []*pb.FilteredChaincodeAction{{ChaincodeEvent: &pb.ChaincodeEvent{ChaincodeId: "cc1"}}},
+			}},
+		}},
+	}}
+	close(eventch)
+	qc.listen(eventch)
+
+	_, ok := qc.cache.Get("k1")
+	assert.False(t, ok, "Expected responses of cc1 to be invalidated")
+	_, ok = qc.cache.Get("k2")
+	assert.True(t, ok, "Expected responses of cc2 to be cached")
+
+	qc.invalidation = InvalidateOnBlock
+	eventch = make(chan *fab.FilteredBlockEvent, 1)
+	eventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{}}
+	close(eventch)
+	qc.listen(eventch)
+
+	_, ok = qc.cache.Get("k2")
+	assert.False(t, ok, "Expected all responses to be invalidated")
+}
+
+func TestQueryCacheInvalidationRegistration(t *testing.T) {
+	discoveryService, err := setupTestDiscovery(nil, nil)
+	assert.Nil(t, err, "Failed to setup discovery service")
+	selectionService, err := setupTestSelection(nil, nil)
+	assert.Nil(t, err, "Failed to setup selection service")
+	ctx := createChannelContext(setupCustomTestContext(t, selectionService, discoveryService, nil), channelID)
+
+	chClient, err := New(ctx, WithQueryCache(NewLRUQueryCache(0), time.Minute), WithQueryCacheInvalidation(InvalidateOnBlock))
+	if err != nil {
+		t.Fatalf("Failed to create new channel client: %s", err)
+	}
+	assert.Len(t, chClient.registrations.unregister, 1, "Expected the invalidation registration to be tracked")
+
+	chClient.Close()
+	assert.Empty(t, chClient.registrations.unregister, "Expected the invalidation registration to be removed")
+}
+
+func TestLRUQueryCache(t *testing.T) {
+	cache := NewLRUQueryCache(2)
+	cache.Put("k1", &CachedResponse{ChaincodeID: "cc1"})
+	cache.Put("k2", &CachedResponse{ChaincodeID: "cc2"})
+
+	// k1 becomes the most recently used, so k2 is evicted
+	_, ok := cache.Get("k1")
+	assert.True(t, ok)
+	cache.Put("k3", &CachedResponse{ChaincodeID: "cc1"})
+
+	_, ok = cache.Get("k2")
+	assert.False(t, ok, "Expected least recently used response to be evicted")
+
+	cache.Invalidate("cc1")
+	_, ok = cache.Get("k1")
+	assert.False(t, ok)
+	_, ok = cache.Get("k3")
+	assert.False(t, ok)
+}
+
+func TestQueryCacheKey(t *testing.T) {
+	key := queryCacheKey("ch", Request{ChaincodeID: "cc", Fcn: "f", Args: [][]byte{[]byte("ab"), []byte("c")}})
+	assert.Equal(t, key, queryCacheKey("ch", Request{ChaincodeID: "cc", Fcn: "f", Args: [][]byte{[]byte("ab"), []byte("c")}}))
+	assert.NotEqual(t, key, queryCacheKey("ch", Request{ChaincodeID: "cc", Fcn: "f", Args: [][]byte{[]byte("a"), []byte("bc")}}))
+	assert.NotEqual(t, key, queryCacheKey("ch2", Request{ChaincodeID: "cc", Fcn: "f", Args: [][]byte{[]byte("ab"), []byte("c")}}))
+}
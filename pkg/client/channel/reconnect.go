@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/pkg/errors"
+)
+
+// EventReconnectOpts configures how the event service of the client reconnects to the event server
+// after the connection was lost, e.g. when the peer delivering the events restarts
+type EventReconnectOpts struct {
+	// InitialDelay is the delay before the first reconnection attempt and the initial time between attempts.
+	// Attempts are at least a second apart. If 0 then the default time between attempts is used.
+	InitialDelay time.Duration
+	// MaxDelay limits the time between attempts. If 0 then the time between attempts isn't limited.
+	MaxDelay time.Duration
+	// Multiplier is the factor by which the time between attempts grows after each failed attempt.
+	// If 0 then the time between attempts doesn't grow.
+	Multiplier float64
+	// MaxAttempts is the maximum number of reconnection attempts after which the event service is closed.
+	// If 0 then the event service attempts to reconnect until it's closed.
+	MaxAttempts uint
+}
+
+// WithEventReconnect configures how the event service of the client reconnects to the event server.
+// Event services are shared by the clients of a channel with the same identity and reconnection options.
+func WithEventReconnect(opts EventReconnectOpts) ClientOption {
+	return func(cc *Client) error {
+		if opts.Multiplier != 0 && opts.Multiplier < 1 {
+			return errors.Errorf("invalid reconnect multiplier [%f]", opts.Multiplier)
+		}
+		if opts.MaxDelay > 0 && opts.MaxDelay < opts.InitialDelay {
+			return errors.Errorf("reconnect max delay [%s] is less than the initial delay [%s]", opts.MaxDelay, opts.InitialDelay)
+		}
+
+		multiplier := opts.Multiplier
+		if multiplier == 0 {
+			multiplier = 1
+		}
+
+		eventOpts := []options.Opt{
+			client.WithReconnectInitialDelay(opts.InitialDelay),
+			client.WithReconnectBackoff(multiplier, opts.MaxDelay),
+			client.WithMaxReconnectAttempts(opts.MaxAttempts),
+		}
+		if opts.InitialDelay > 0 {
+			eventOpts = append(eventOpts, client.WithTimeBetweenConnectAttempts(opts.InitialDelay))
+		}
+		cc.eventOpts = eventOpts
+		return nil
+	}
+}
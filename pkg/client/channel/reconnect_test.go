@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEventReconnect(t *testing.T) {
+	ctx := setupTestContext().(*fcmocks.MockContext)
+	testChannelSvc, err := setupTestChannelService(ctx, nil)
+	assert.Nil(t, err, "Got error %s", err)
+	channelSvc := &eventOptsChannelService{ChannelService: testChannelSvc}
+	ctx.MockProviderContext.ChannelProvider().(*fcmocks.MockChannelProvider).SetCustomChannelService(channelSvc)
+	channelCtx := createChannelContext(createClientContext(ctx), channelID)
+
+	reconnectOpts := EventReconnectOpts{
+		InitialDelay: 2 * time.Second,
+		MaxDelay:     time.Minute,
+		Multiplier:   1.5,
+		MaxAttempts:  10,
+	}
+	_, err = New(channelCtx, WithEventReconnect(reconnectOpts))
+	assert.Nil(t, err, "Got error %s", err)
+
+	params := &reconnectParams{}
+	options.Apply(params, channelSvc.opts[len(channelSvc.opts)-1])
+	assert.Equal(t, reconnectParams{
+		initialDelay:            2 * time.Second,
+		timeBetweenConnAttempts: 2 * time.Second,
+		maxDelay:                time.Minute,
+		backoffFactor:           1.5,
+		maxAttempts:             10,
+	}, *params, "expected reconnect options to be passed to the event service")
+
+	// No backoff by default
+	_, err = New(channelCtx, WithEventReconnect(EventReconnectOpts{MaxAttempts: 3}))
+	assert.Nil(t, err, "Got error %s", err)
+	params = &reconnectParams{}
+	options.Apply(params, channelSvc.opts[len(channelSvc.opts)-1])
+	assert.Equal(t, reconnectParams{backoffFactor: 1, maxAttempts: 3}, *params)
+
+	_, err = New(channelCtx, WithEventReconnect(EventReconnectOpts{Multiplier: 0.5}))
+	assert.NotNil(t, err, "expected error for multiplier less than 1")
+
+	_, err = New(channelCtx, WithEventReconnect(EventReconnectOpts{InitialDelay: time.Minute, MaxDelay: time.Second}))
+	assert.NotNil(t, err, "expected error for max delay less than the initial delay")
+}
+
+//eventOptsChannelService records the options with which event services are created
+type eventOptsChannelService struct {
+	fab.ChannelService
+	opts [][]options.Opt
+}
+
+func (cs *eventOptsChannelService) EventService(opts ...options.Opt) (fab.EventService, error) {
+	cs.opts = append(cs.opts, opts)
+	return cs.ChannelService.EventService(opts...)
+}
+
+type reconnectParams struct {
+	initialDelay            time.Duration
+	timeBetweenConnAttempts time.Duration
+	maxDelay                time.Duration
+	backoffFactor           float64
+	maxAttempts             uint
+}
+
+func (p *reconnectParams) SetReconnectInitialDelay(value time.Duration) {
+	p.initialDelay = value
+}
+
+func (p *reconnectParams) SetTimeBetweenConnectAttempts(value time.Duration) {
+	p.timeBetweenConnAttempts = value
+}
+
+func (p *reconnectParams) SetReconnectBackoff(factor float64, maxDelay time.Duration) {
+	p.backoffFactor = factor
+	p.maxDelay = maxDelay
+}
+
+func (p *reconnectParams) SetMaxReconnectAttempts(value uint) {
+	p.maxAttempts = value
+}
@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// eventRegistrations tracks the event registrations handed out by the client along with the functions
+// which remove them, so that they can all be removed by UnregisterAll
+type eventRegistrations struct {
+	lock       sync.Mutex
+	unregister map[fab.Registration]func()
+}
+
+// add tracks the registration
+func (r *eventRegistrations) add(reg fab.Registration, unregister func()) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.unregister == nil {
+		r.unregister = make(map[fab.Registration]func())
+	}
+	r.unregister[reg] = unregister
+}
+
+// remove removes the registration if it's tracked, or otherwise with the given function
+func (r *eventRegistrations) remove(reg fab.Registration, untracked func(fab.Registration)) {
+	r.lock.Lock()
+	unregister, ok := r.unregister[reg]
+	delete(r.unregister, reg)
+	r.lock.Unlock()
+
+	if ok {
+		unregister()
+		return
+	}
+	untracked(reg)
+}
+
+// removeAll removes all of the tracked registrations
+func (r *eventRegistrations) removeAll() {
+	r.lock.Lock()
+	all := r.unregister
+	r.unregister = nil
+	r.lock.Unlock()
+
+	for _, unregister := range all {
+		unregister()
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	selectopts "github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/pkg/errors"
+)
+
+// sessionHeightPollInterval is how often the block heights of the peers are queried while
+// waiting for a peer to reach the block height required by a session query
+const sessionHeightPollInterval = 250 * time.Millisecond
+
+// Session provides read-your-writes consistency over a channel client: queries made through the session
+// observe the transactions executed through the session. The session records the block which committed the
+// last transaction, and queries are only sent to peers which have committed that block, preferring the peers
+// which endorsed the transaction. If none of the peers has committed the block yet, the query waits for one
+// of them to do so, within the query timeout.
+//
+// With sticky targets (see WithStickyTargets), the queries and transactions of the session are sent to the peers
+// selected for the first request, so that a read-modify-write sequence reads and endorses on the same peers.
+//
+// The session only holds client-side state. It's safe for concurrent use by multiple goroutines.
+type Session struct {
+	client         *Client
+	stickyDuration time.Duration
+	lock           sync.RWMutex
+	// Note: the following variables are protected by lock
+	minHeight    uint64
+	endorsers    map[string]bool
+	sticky       []fab.Peer
+	stickyExpiry time.Time
+}
+
+// SessionOption describes a functional parameter for NewSession
+type SessionOption func(*Session)
+
+// WithStickyTargets makes the session send its requests to the peers selected for the first request, for the
+// given duration. If those peers fail before the transaction is sent to the orderer, or are refused by the
+// selection filter (e.g. because they are greylisted), the targets are selected anew. The session also refuses
+// peers below the block height the sticky targets had when they were selected.
+// Requests with explicit targets (WithTargets) are not affected.
+func WithStickyTargets(duration time.Duration) SessionOption {
+	return func(s *Session) {
+		s.stickyDuration = duration
+	}
+}
+
+// NewSession returns a new read-your-writes session over the given channel client
+func NewSession(client *Client, opts ...SessionOption) *Session {
+	s := &Session{client: client}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewSession returns a new read-your-writes session over the channel client
+func (cc *Client) NewSession(opts ...SessionOption) *Session {
+	return NewSession(cc, opts...)
+}
+
+// Execute executes the transaction with the channel client and records the block which committed it
+func (s *Session) Execute(request Request, options ...RequestOption) (Response, error) {
+	response, err := s.execute(request, options...)
+	if err != nil {
+		return response, err
+	}
+
+	s.record(response)
+	return response, nil
+}
+
+func (s *Session) execute(request Request, options ...RequestOption) (Response, error) {
+	if s.stickyDuration == 0 {
+		return s.client.Execute(request, options...)
+	}
+
+	txnOpts, err := s.client.prepareOptsFromOptions(s.client.context, options...)
+	if err != nil {
+		return Response{}, errors.WithMessage(err, "option failed")
+	}
+
+	return s.client.execute(&stickyTargetsHandler{session: s, next: s.client.commitChain}, request, txnOpts)
+}
+
+// Query queries the chaincode on a peer which has committed the transactions executed through the session.
+// Once a transaction has been executed, the query cache of the client is bypassed since it may hold responses
+// from before the transaction. The cache is also bypassed by sessions with sticky targets.
+func (s *Session) Query(request Request, options ...RequestOption) (Response, error) {
+	minHeight, preferred := s.state()
+	if minHeight == 0 && s.stickyDuration == 0 {
+		return s.client.Query(request, options...)
+	}
+
+	txnOpts, err := s.client.prepareOptsFromOptions(s.client.context, options...)
+	if err != nil {
+		return Response{}, errors.WithMessage(err, "option failed")
+	}
+	s.client.addDefaultTimeout(fab.Query, &txnOpts)
+
+	next := s.client.endorsementChain
+	if minHeight == 0 {
+		return s.client.invokeHandler(&stickyTargetsHandler{session: s, next: next}, request, txnOpts)
+	}
+
+	handler := &minBlockHeightHandler{
+		heights:   s.client.blockHeights,
+		minHeight: minHeight,
+		endorsers: preferred,
+		next:      next,
+	}
+	return s.client.invokeHandler(handler, request, txnOpts)
+}
+
+// BlockHeight returns the block height a peer needs to have for session queries, i.e. the number
+// of the block which committed the last transaction plus one, or 0 if none has been executed.
+func (s *Session) BlockHeight() uint64 {
+	minHeight, _ := s.state()
+	return minHeight
+}
+
+func (s *Session) record(response Response) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	height := response.BlockNumber + 1
+	if height < s.minHeight {
+		return
+	}
+
+	// The endorsers are copied since they may be read by queries in progress
+	endorsers := make(map[string]bool)
+	if height == s.minHeight {
+		for endorser := range s.endorsers {
+			endorsers[endorser] = true
+		}
+	}
+	for _, r := range response.Responses {
+		endorsers[endpoint.ToAddress(r.Endorser)] = true
+	}
+	s.minHeight = height
+	s.endorsers = endorsers
+}
+
+//state returns the minimum block height of the session's queries and the peers they prefer, i.e. the
+//endorsers of the last transaction and the sticky targets
+func (s *Session) state() (uint64, map[string]bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.sticky) == 0 || time.Now().After(s.stickyExpiry) {
+		return s.minHeight, s.endorsers
+	}
+
+	preferred := make(map[string]bool)
+	for endorser := range s.endorsers {
+		preferred[endorser] = true
+	}
+	for _, peer := range s.sticky {
+		preferred[endpoint.ToAddress(peer.URL())] = true
+	}
+	return s.minHeight, preferred
+}
+
+//stickyTargets returns the sticky targets accepted by the filter, or nil if they expired
+func (s *Session) stickyTargets(filter selectopts.PeerFilter) []fab.Peer {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if time.Now().After(s.stickyExpiry) {
+		return nil
+	}
+
+	var targets []fab.Peer
+	for _, peer := range s.sticky {
+		if filter != nil && !filter(peer) {
+			logger.Debugf("Sticky target [%s] is refused by the selection filter", peer.URL())
+			return nil
+		}
+		targets = append(targets, peer)
+	}
+	return targets
+}
+
+//stick makes the targets the sticky targets of the session, and raises the block height of the session to
+//the lowest block height of the targets
+func (s *Session) stick(requestContext *invoke.RequestContext, targets []fab.Peer) {
+	heights := s.client.blockHeights.get(requestContext.Ctx, withoutPeerState(targets))
+	var floor uint64
+	for i, peer := range targets {
+		if height := peerHeight(peer, heights); i == 0 || height < floor {
+			floor = height
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sticky = append([]fab.Peer(nil), targets...)
+	s.stickyExpiry = time.Now().Add(s.stickyDuration)
+	if floor > s.minHeight {
+		s.minHeight = floor
+	}
+}
+
+//unstick clears the sticky targets of the session
+func (s *Session) unstick() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sticky = nil
+}
+
+//stickyTargetsHandler sends the request to the sticky targets of the session. If there are none, or if they
+//fail before the transaction is sent to the orderer, the targets are selected anew among the peers at the
+//block height of the session and become the sticky targets.
+type stickyTargetsHandler struct {
+	session *Session
+	next    invoke.Handler
+}
+
+//Handle invokes the next handler on the sticky targets, or on the selected targets
+func (h *stickyTargetsHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	if len(requestContext.Opts.Targets) > 0 {
+		invoke.NewProposalProcessorHandler(h.next).Handle(requestContext, clientContext)
+		return
+	}
+
+	if targets := h.session.stickyTargets(requestContext.SelectionFilter); len(targets) > 0 {
+		requestContext.Opts.Targets = targets
+		invoke.NewProposalProcessorHandler(h.next).Handle(requestContext, clientContext)
+		if requestContext.Error == nil || !beforeBroadcast(requestContext.Stage().Stage) {
+			return
+		}
+
+		logger.Debugf("Request on sticky targets failed, selecting new targets: %s", requestContext.Error)
+		h.session.unstick()
+		requestContext.Opts.Targets = nil
+		requestContext.Response = invoke.Response{}
+		requestContext.Error = nil
+	}
+
+	selectionFilter := requestContext.SelectionFilter
+	requestContext.SelectionFilter = h.heightFilter(requestContext)
+	invoke.NewProposalProcessorHandler(h.next).Handle(requestContext, clientContext)
+	requestContext.SelectionFilter = selectionFilter
+
+	if requestContext.Error == nil {
+		h.session.stick(requestContext, requestContext.Opts.Targets)
+	}
+}
+
+//heightFilter returns the selection filter of the request, refusing the peers below the block height of the session
+func (h *stickyTargetsHandler) heightFilter(requestContext *invoke.RequestContext) selectopts.PeerFilter {
+	filter := requestContext.SelectionFilter
+	minHeight := h.session.BlockHeight()
+	if minHeight == 0 {
+		return filter
+	}
+
+	return func(peer fab.Peer) bool {
+		if filter != nil && !filter(peer) {
+			return false
+		}
+		heights := h.session.client.blockHeights.get(requestContext.Ctx, withoutPeerState([]fab.Peer{peer}))
+		return peerHeight(peer, heights) >= minHeight
+	}
+}
+
+//beforeBroadcast returns true if the stage precedes the broadcast of the transaction to the orderer,
+//i.e. the request may be sent to other targets
+func beforeBroadcast(stage invoke.Stage) bool {
+	return stage != invoke.StageBroadcast && stage != invoke.StageCommit
+}
+
+//minBlockHeightHandler sends the query to the peers which are at or beyond the minimum block height,
+//preferring the given endorsers, and waits for one of the peers to reach the height if none has
+type minBlockHeightHandler struct {
+	heights   *blockHeightCache
+	minHeight uint64
+	endorsers map[string]bool
+	next      invoke.Handler
+}
+
+//Handle invokes the next handler on the peers at the minimum block height until one succeeds
+func (h *minBlockHeightHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	candidates, err := selectionCandidates(requestContext, clientContext)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	urls := make([]string, len(candidates))
+	for i, peer := range candidates {
+		urls[i] = peer.URL()
+	}
+	requestContext.SetStage(invoke.StageSelection, urls...)
+
+	peers, err := h.waitForHeight(requestContext, candidates)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	for _, peer := range peers {
+		requestContext.Opts.Targets = []fab.Peer{peer}
+		requestContext.Response = invoke.Response{SelectedTargets: []string{peer.URL()}}
+		requestContext.NotifyProgress(invoke.ProgressSelected, peer.URL())
+		requestContext.Error = nil
+
+		h.next.Handle(requestContext, clientContext)
+		if requestContext.Error == nil {
+			return
+		}
+		logger.Debugf("Session query on peer [%s] failed: %s", peer.URL(), requestContext.Error)
+	}
+}
+
+//waitForHeight returns the candidates which are at or beyond the minimum block height, querying
+//the block heights of the candidates until at least one of them is or the request is done
+func (h *minBlockHeightHandler) waitForHeight(requestContext *invoke.RequestContext, candidates []fab.Peer) ([]fab.Peer, error) {
+	heights := h.heights.get(requestContext.Ctx, withoutPeerState(candidates))
+	for {
+		if peers := h.atMinHeight(candidates, heights); len(peers) > 0 {
+			return peers, nil
+		}
+
+		logger.Debugf("No peer at block height %d yet, waiting", h.minHeight)
+		select {
+		case <-requestContext.Ctx.Done():
+			return nil, errors.Wrapf(requestContext.Ctx.Err(), "no peer reached block height %d", h.minHeight)
+		case <-time.After(sessionHeightPollInterval):
+		}
+		heights = h.heights.refresh(requestContext.Ctx, withoutPeerState(candidates))
+	}
+}
+
+//atMinHeight returns the candidates which are at or beyond the minimum block height, endorsers first
+func (h *minBlockHeightHandler) atMinHeight(candidates []fab.Peer, heights map[string]uint64) []fab.Peer {
+	var peers []fab.Peer
+	for _, peer := range candidates {
+		if peerHeight(peer, heights) >= h.minHeight {
+			peers = append(peers, peer)
+		}
+	}
+
+	sort.SliceStable(peers, func(i, j int) bool {
+		return h.endorsers[endpoint.ToAddress(peers[i].URL())] && !h.endorsers[endpoint.ToAddress(peers[j].URL())]
+	})
+	return peers
+}
+
+//peerHeight returns the block height reported by the peer, or else its queried block height
+func peerHeight(peer fab.Peer, heights map[string]uint64) uint64 {
+	if state, ok := peer.(fab.PeerState); ok {
+		return state.BlockHeight()
+	}
+	return heights[peer.URL()]
+}
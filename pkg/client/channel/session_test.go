@@ -0,0 +1,134 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 5)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 10)
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	chClient.eventService = mockEventService
+	session := NewSession(chClient)
+	assert.EqualValues(t, 0, session.BlockHeight())
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// The transaction is committed in block 9, i.e. the peers need a block height of 10
+	go commitTx(mockEventService, 9)
+	_, err := session.Execute(request, WithTargets(testPeer2))
+	assert.Nil(t, err, "Failed to execute transaction")
+	assert.EqualValues(t, 10, session.BlockHeight())
+
+	resp, err := session.Query(request, WithTargets(testPeer1, testPeer2))
+	assert.Nil(t, err, "Failed to query")
+	assert.Equal(t, testPeer2.Payload, resp.Payload, "Expected query on the peer which committed the transaction")
+
+	// None of the peers committed the next transaction: the query waits for one of them
+	go commitTx(mockEventService, 11)
+	_, err = session.Execute(request, WithTargets(testPeer2))
+	assert.Nil(t, err, "Failed to execute transaction")
+	assert.EqualValues(t, 12, session.BlockHeight())
+
+	_, err = session.Query(request, WithTargets(testPeer1, testPeer2), WithTimeout(fab.Query, 300*time.Millisecond))
+	assert.NotNil(t, err, "Expected query to time out since no peer reached the block height")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		testPeer1.RWLock.Lock()
+		testPeer1.Payload = blockchainInfoPayload(t, 12)
+		testPeer1.RWLock.Unlock()
+	}()
+	resp, err = session.Query(request, WithTargets(testPeer1, testPeer2), WithTimeout(fab.Query, 5*time.Second))
+	assert.Nil(t, err, "Expected query once a peer reached the block height")
+	assert.Equal(t, blockchainInfoPayload(t, 12), resp.Payload, "Expected query on the peer which reached the block height")
+}
+
+func TestSessionPrefersEndorsers(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 10)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 10)
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1, testPeer2}, t)
+	session := NewSession(chClient)
+	session.record(Response{
+		BlockNumber: 9,
+		Responses:   []*fab.TransactionProposalResponse{{Endorser: "http://peer2.com"}},
+	})
+	// An earlier block doesn't lower the block height
+	session.record(Response{BlockNumber: 5})
+	assert.EqualValues(t, 10, session.BlockHeight())
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := session.Query(request, WithTargets(testPeer1, testPeer2))
+	assert.Nil(t, err, "Failed to query")
+	assert.Equal(t, 2, testPeer2.ProcessProposalCalls, "Expected query on the endorser")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "Expected only the block height to be queried on the other peer")
+}
+
+func TestSessionStickyTargets(t *testing.T) {
+	mockEventService := fcmocks.NewMockEventService()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Payload = blockchainInfoPayload(t, 5)
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	testPeer2.Payload = blockchainInfoPayload(t, 5)
+
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.eventService = mockEventService
+	selection := chClient.context.SelectionService().(*txnmocks.MockSelectionService)
+	session := chClient.NewSession(WithStickyTargets(time.Minute))
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+
+	// The targets selected for the first request stick, and the block height they had is remembered
+	resp, err := session.Query(request)
+	assert.Nil(t, err, "Failed to query")
+	assert.Equal(t, []string{testPeer1.URL()}, resp.SelectedTargets)
+	assert.EqualValues(t, 5, session.BlockHeight())
+
+	selection.Peers = []fab.Peer{testPeer2}
+	go commitTx(mockEventService, 4)
+	resp, err = session.Execute(request)
+	assert.Nil(t, err, "Failed to execute transaction")
+	assert.Equal(t, []string{testPeer1.URL()}, resp.SelectedTargets, "Expected transaction on the sticky target")
+
+	// The targets are selected anew if the sticky targets fail
+	testPeer1.Error = errors.New("peer1 failed")
+	go commitTx(mockEventService, 4)
+	resp, err = session.Execute(request)
+	assert.Nil(t, err, "Expected transaction on newly selected target")
+	assert.Equal(t, []string{testPeer2.URL()}, resp.SelectedTargets)
+
+	// Peers below the block height of the session are refused
+	testPeer2.Error = errors.New("peer2 failed")
+	testPeer3 := fcmocks.NewMockPeer("Peer3", "http://peer3.com")
+	testPeer3.Payload = blockchainInfoPayload(t, 3)
+	selection.Peers = []fab.Peer{testPeer3}
+	_, err = session.Execute(request)
+	assert.NotNil(t, err, "Expected error since the selected peer is below the block height of the session")
+	assert.Equal(t, 1, testPeer3.ProcessProposalCalls, "Expected only the block height to be queried")
+}
+
+func commitTx(eventService *fcmocks.MockEventService, blockNumber uint64) {
+	txStatusReg := <-eventService.TxStatusRegCh
+	txStatusReg.Eventch <- &fab.TxStatusEvent{TxID: txStatusReg.TxID, TxValidationCode: pb.TxValidationCode_VALID, BlockNumber: blockNumber}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// SignedProposalOption describes a functional parameter for SendSignedProposal
+type SignedProposalOption func(opts *signedProposalOptions) error
+
+type signedProposalOptions struct {
+	anyChannel bool
+}
+
+// WithAnyChannel allows SendSignedProposal to send proposals for channels other than the channel of the client
+func WithAnyChannel() SignedProposalOption {
+	return func(opts *signedProposalOptions) error {
+		opts.anyChannel = true
+		return nil
+	}
+}
+
+// SendSignedProposal sends a signed proposal which was built beforehand, e.g. a proposal stored by an application,
+// to the given targets concurrently, using the connections and TLS configuration of the SDK. The targets are peer
+// URLs, which are resolved from the peers of the channel and otherwise from the config. The proposal must be for the
+// channel of the client unless WithAnyChannel is specified.
+// The responses of the targets which endorsed the proposal are returned along with the errors of the others,
+// whose statuses are extracted in the same way as for Query and Execute. The peer response timeout applies
+// unless the given context has an earlier deadline.
+func (cc *Client) SendSignedProposal(ctx reqContext.Context, signedProposal *pb.SignedProposal, targets []string, options ...SignedProposalOption) ([]*fab.TransactionProposalResponse, error) {
+	opts := signedProposalOptions{}
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return nil, errors.WithMessage(err, "option failed")
+		}
+	}
+
+	if signedProposal == nil {
+		return nil, errors.New("signed proposal is required")
+	}
+
+	if !opts.anyChannel {
+		channelID, err := proposalChannelID(signedProposal)
+		if err != nil {
+			return nil, err
+		}
+		if channelID != cc.context.ChannelID() {
+			return nil, errors.Errorf("proposal is for channel [%s] but the client is for channel [%s]", channelID, cc.context.ChannelID())
+		}
+	}
+
+	processors, err := cc.resolveTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := contextImpl.NewRequest(cc.context, contextImpl.WithTimeoutType(fab.PeerResponse), contextImpl.WithParent(ctx))
+	defer cancel()
+
+	return txn.SendSignedProposal(reqCtx, signedProposal, processors)
+}
+
+//resolveTargets resolves the target URLs to the peers of the channel or, failing that, to the peers in the config
+func (cc *Client) resolveTargets(targets []string) ([]fab.ProposalProcessor, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("targets are required")
+	}
+
+	channelPeers, err := cc.context.DiscoveryService().GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get peers of the channel")
+	}
+
+	var processors []fab.ProposalProcessor
+	for _, target := range targets {
+		peer, err := cc.resolveTarget(target, channelPeers)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to resolve target "+target)
+		}
+		processors = append(processors, peer)
+	}
+	return processors, nil
+}
+
+func (cc *Client) resolveTarget(target string, channelPeers []fab.Peer) (fab.Peer, error) {
+	for _, peer := range channelPeers {
+		if endpoint.ToAddress(peer.URL()) == endpoint.ToAddress(target) {
+			return peer, nil
+		}
+	}
+
+	peerCfg, err := comm.NetworkPeerConfigFromURL(cc.context.EndpointConfig(), target)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := cc.context.InfraProvider().CreatePeerFromConfig(peerCfg)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating peer from config failed")
+	}
+	return peer, nil
+}
+
+//proposalChannelID returns the ID of the channel in the header of the signed proposal
+func proposalChannelID(signedProposal *pb.SignedProposal) (string, error) {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return "", errors.Wrap(err, "unmarshal of proposal failed")
+	}
+	header, err := utils.GetHeader(proposal.Header)
+	if err != nil {
+		return "", errors.WithMessage(err, "invalid proposal header")
+	}
+	channelHeader, err := utils.UnmarshalChannelHeader(header.ChannelHeader)
+	if err != nil {
+		return "", errors.WithMessage(err, "invalid proposal channel header")
+	}
+	return channelHeader.ChannelId, nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	reqContext "context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendSignedProposal(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "grpcs://peer1.com:7051")
+	testPeer1.Payload = []byte("value")
+	testPeer2 := fcmocks.NewMockPeer("Peer2", "grpcs://peer2.com:7051")
+	chClient := setupChannelClientWithDiscovery([]fab.Peer{testPeer1, testPeer2}, t)
+
+	signedProposal := newSignedProposal(t, channelID)
+	responses, err := chClient.SendSignedProposal(reqContext.Background(), signedProposal, []string{"peer1.com:7051"})
+	assert.Nil(t, err, "Failed to send signed proposal")
+	assert.Len(t, responses, 1)
+	assert.Equal(t, "grpcs://peer1.com:7051", responses[0].Endorser)
+	assert.Equal(t, []byte("value"), responses[0].ProposalResponse.Response.Payload)
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls)
+	assert.Equal(t, 0, testPeer2.ProcessProposalCalls)
+
+	_, err = chClient.SendSignedProposal(reqContext.Background(), newSignedProposal(t, "otherchannel"), []string{"peer1.com:7051"})
+	assert.NotNil(t, err, "Expected error sending proposal for another channel")
+	assert.Equal(t, 1, testPeer1.ProcessProposalCalls, "Expected proposal for another channel not to be sent")
+
+	responses, err = chClient.SendSignedProposal(reqContext.Background(), newSignedProposal(t, "otherchannel"), []string{"peer1.com:7051"}, WithAnyChannel())
+	assert.Nil(t, err, "Failed to send signed proposal for another channel")
+	assert.Len(t, responses, 1)
+
+	_, err = chClient.SendSignedProposal(reqContext.Background(), signedProposal, []string{"invalid"})
+	assert.NotNil(t, err, "Expected error resolving unknown target")
+
+	_, err = chClient.SendSignedProposal(reqContext.Background(), &pb.SignedProposal{ProposalBytes: []byte("invalid")}, []string{"peer1.com:7051"})
+	assert.NotNil(t, err, "Expected error for invalid proposal")
+}
+
+func setupChannelClientWithDiscovery(peers []fab.Peer, t *testing.T) *Client {
+	discoveryService, err := setupTestDiscovery(nil, peers)
+	assert.Nil(t, err, "Failed to setup discovery service")
+
+	selectionService, err := setupTestSelection(nil, peers)
+	assert.Nil(t, err, "Failed to setup selection service")
+
+	fabCtx := setupCustomTestContext(t, selectionService, discoveryService, nil)
+	ch, err := New(createChannelContext(fabCtx, channelID))
+	assert.Nil(t, err, "Failed to create new channel client")
+	return ch
+}
+
+func newSignedProposal(t *testing.T, channelID string) *pb.SignedProposal {
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{ChannelId: channelID, TxId: "txid"})
+	assert.Nil(t, err, "Failed to marshal channel header")
+	header, err := proto.Marshal(&common.Header{ChannelHeader: channelHeader})
+	assert.Nil(t, err, "Failed to marshal header")
+	proposal, err := proto.Marshal(&pb.Proposal{Header: header})
+	assert.Nil(t, err, "Failed to marshal proposal")
+	return &pb.SignedProposal{ProposalBytes: proposal, Signature: []byte("signature")}
+}
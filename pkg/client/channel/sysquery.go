@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	ledgerutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	qscc              = "qscc"
+	qsccChannelInfo   = "GetChainInfo"
+	qsccBlockByNumber = "GetBlockByNumber"
+	qsccBlockByTxID   = "GetBlockByTxID"
+	lscc              = "lscc"
+	lsccChaincodes    = "getchaincodes"
+)
+
+// QueryChannelInfo queries the blockchain info of the channel (height and current and previous block hashes) from qscc.
+// The query is sent in the same way as Query, i.e. the usual request options such as targets, retries and timeouts apply.
+func (cc *Client) QueryChannelInfo(options ...RequestOption) (*common.BlockchainInfo, error) {
+	channelID := cc.context.ChannelID()
+	request := Request{ChaincodeID: qscc, Fcn: qsccChannelInfo, Args: [][]byte{[]byte(channelID)}}
+
+	info := &common.BlockchainInfo{}
+	if err := cc.querySystemChaincode(request, info, options...); err != nil {
+		return nil, errors.WithMessage(err, "query channel info failed")
+	}
+	return info, nil
+}
+
+// QueryBlockByNumberRaw queries the block with the given number from qscc. The block is returned as is,
+// i.e. its transactions aren't decoded.
+func (cc *Client) QueryBlockByNumberRaw(blockNumber uint64, options ...RequestOption) (*common.Block, error) {
+	channelID := cc.context.ChannelID()
+	request := Request{ChaincodeID: qscc, Fcn: qsccBlockByNumber, Args: [][]byte{[]byte(channelID), []byte(strconv.FormatUint(blockNumber, 10))}}
+
+	block := &common.Block{}
+	if err := cc.querySystemChaincode(request, block, options...); err != nil {
+		return nil, errors.WithMessage(err, "query block by number failed")
+	}
+	return block, nil
+}
+
+// QueryInstantiatedChaincodes queries the chaincodes which are instantiated on the channel from lscc
+func (cc *Client) QueryInstantiatedChaincodes(options ...RequestOption) (*pb.ChaincodeQueryResponse, error) {
+	request := Request{ChaincodeID: lscc, Fcn: lsccChaincodes}
+
+	chaincodes := &pb.ChaincodeQueryResponse{}
+	if err := cc.querySystemChaincode(request, chaincodes, options...); err != nil {
+		return nil, errors.WithMessage(err, "query instantiated chaincodes failed")
+	}
+	return chaincodes, nil
+}
+
+// queryCommittedTx looks up the transaction in the ledger and returns the outcome of its commit, or an error
+// if it wasn't found
+func (cc *Client) queryCommittedTx(txID fab.TransactionID, options ...RequestOption) (*IdempotencyRecord, error) {
+	channelID := cc.context.ChannelID()
+	request := Request{ChaincodeID: qscc, Fcn: qsccBlockByTxID, Args: [][]byte{[]byte(channelID), []byte(txID)}}
+
+	block := &common.Block{}
+	if err := cc.querySystemChaincode(request, block, options...); err != nil {
+		return nil, errors.WithMessage(err, "query block by transaction ID failed")
+	}
+
+	if block.Data != nil && block.Metadata != nil && len(block.Metadata.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		txFilter := ledgerutil.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for i, data := range block.Data.Data {
+			env, err := utils.GetEnvelopeFromBlock(data)
+			if err != nil {
+				return nil, errors.WithMessage(err, "invalid transaction in block")
+			}
+			payload, err := utils.GetPayload(env)
+			if err != nil || payload.Header == nil {
+				return nil, errors.New("invalid transaction payload in block")
+			}
+			channelHeader, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+			if err != nil {
+				return nil, errors.WithMessage(err, "invalid transaction header in block")
+			}
+			if channelHeader.TxId != string(txID) || i >= len(txFilter) {
+				continue
+			}
+
+			record := &IdempotencyRecord{TxID: txID, Committed: true, TxValidationCode: txFilter.Flag(i)}
+			if block.Header != nil {
+				record.BlockNumber = block.Header.Number
+			}
+			if response := chaincodeResponse(payload.Data); response != nil {
+				record.ChaincodeStatus = response.Status
+				record.Payload = response.Payload
+			}
+			return record, nil
+		}
+	}
+	return nil, errors.Errorf("transaction [%s] not found in block", txID)
+}
+
+// chaincodeResponse returns the chaincode response of the endorser transaction, or nil if it can't be decoded
+func chaincodeResponse(txBytes []byte) *pb.Response {
+	tx, err := utils.GetTransaction(txBytes)
+	if err != nil || len(tx.Actions) == 0 {
+		return nil
+	}
+	actionPayload, err := utils.GetChaincodeActionPayload(tx.Actions[0].Payload)
+	if err != nil || actionPayload.Action == nil {
+		return nil
+	}
+	responsePayload, err := utils.GetProposalResponsePayload(actionPayload.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil
+	}
+	action, err := utils.GetChaincodeAction(responsePayload.Extension)
+	if err != nil {
+		return nil
+	}
+	return action.Response
+}
+
+// querySystemChaincode queries the system chaincode and unmarshals the payload of the response into the given message
+func (cc *Client) querySystemChaincode(request Request, msg proto.Message, options ...RequestOption) error {
+	response, err := cc.Query(request, options...)
+	if err != nil {
+		return err
+	}
+
+	if err := proto.Unmarshal(response.Payload, msg); err != nil {
+		return errors.Wrapf(err, "unmarshal of %s response failed", request.ChaincodeID)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerySystemChaincodes(t *testing.T) {
+	testPeer := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+
+	testPeer.Payload = blockchainInfoPayload(t, 10)
+	info, err := chClient.QueryChannelInfo(WithTargets(testPeer))
+	assert.Nil(t, err, "Failed to query channel info")
+	assert.EqualValues(t, 10, info.Height)
+
+	block := &common.Block{Header: &common.BlockHeader{Number: 5}}
+	testPeer.Payload = marshal(t, block)
+	rawBlock, err := chClient.QueryBlockByNumberRaw(5, WithTargets(testPeer))
+	assert.Nil(t, err, "Failed to query block")
+	assert.True(t, proto.Equal(block, rawBlock), "Unexpected block")
+
+	chaincodes := &pb.ChaincodeQueryResponse{Chaincodes: []*pb.ChaincodeInfo{{Name: "testCC", Version: "v1"}}}
+	testPeer.Payload = marshal(t, chaincodes)
+	instantiated, err := chClient.QueryInstantiatedChaincodes(WithTargets(testPeer))
+	assert.Nil(t, err, "Failed to query instantiated chaincodes")
+	assert.True(t, proto.Equal(chaincodes, instantiated), "Unexpected chaincodes")
+
+	testPeer.Payload = []byte("invalid")
+	_, err = chClient.QueryChannelInfo(WithTargets(testPeer))
+	assert.NotNil(t, err, "Expected error unmarshalling invalid payload")
+}
+
+func marshal(t *testing.T, msg proto.Message) []byte {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %s", msg, err)
+	}
+	return payload
+}
@@ -0,0 +1,285 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package dynamicdiscovery provides a discovery service whose peers are refreshed periodically.
+//
+// The membership of a channel is determined by querying each of the network peers for the channels
+// it has joined, so peers joined to a channel at runtime are discovered without restarting the SDK.
+package dynamicdiscovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.NewLogger("fabsdk/client")
+
+const defaultRefreshInterval = 30 * time.Second
+
+type peerCreator interface {
+	CreatePeerFromConfig(peerCfg *fab.NetworkPeer) (fab.Peer, error)
+}
+
+// DiscoveryProvider implements a discovery provider whose discovery services refresh their peers periodically
+type DiscoveryProvider struct {
+	config          fab.EndpointConfig
+	fabPvdr         peerCreator
+	refreshInterval time.Duration
+	orgName         string
+	userName        string
+	identity        msp.SigningIdentity
+	ctx             context.Client
+	lock            sync.Mutex
+	services        []*discoveryService
+}
+
+// Opt is a discovery provider option
+type Opt func(*DiscoveryProvider)
+
+// WithRefreshInterval sets the interval at which the peers of a channel are refreshed
+func WithRefreshInterval(interval time.Duration) Opt {
+	return func(dp *DiscoveryProvider) {
+		dp.refreshInterval = interval
+	}
+}
+
+// WithUser sets the user (of the given organization) used to query the peers for their channels
+func WithUser(orgName, userName string) Opt {
+	return func(dp *DiscoveryProvider) {
+		dp.orgName = orgName
+		dp.userName = userName
+	}
+}
+
+// WithSigningIdentity sets the identity used to query the peers for their channels
+func WithSigningIdentity(identity msp.SigningIdentity) Opt {
+	return func(dp *DiscoveryProvider) {
+		dp.identity = identity
+	}
+}
+
+// New returns a dynamic discovery provider
+func New(config fab.EndpointConfig, fabPvdr peerCreator, opts ...Opt) (*DiscoveryProvider, error) {
+	dp := &DiscoveryProvider{
+		config:          config,
+		fabPvdr:         fabPvdr,
+		refreshInterval: defaultRefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(dp)
+	}
+
+	if dp.refreshInterval <= 0 {
+		return nil, errors.Errorf("invalid refresh interval [%s]", dp.refreshInterval)
+	}
+	if dp.identity == nil && dp.userName == "" {
+		return nil, errors.New("a user or signing identity is required to query peers")
+	}
+	return dp, nil
+}
+
+// Initialize resolves the identity used to query the peers
+func (dp *DiscoveryProvider) Initialize(providers context.Providers) error {
+	identity := dp.identity
+	if identity == nil {
+		mgr, ok := providers.IdentityManager(dp.orgName)
+		if !ok {
+			return errors.Errorf("identity manager not found for organization [%s]", dp.orgName)
+		}
+
+		var err error
+		identity, err = mgr.GetSigningIdentity(dp.userName)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get signing identity")
+		}
+	}
+
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+	dp.ctx = &contextImpl.Client{Providers: providers, SigningIdentity: identity}
+	return nil
+}
+
+// CreateDiscoveryService returns a discovery service for the channel. If the channel is empty,
+// the service returns all configured network peers.
+func (dp *DiscoveryProvider) CreateDiscoveryService(channelID string) (fab.DiscoveryService, error) {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+
+	if dp.ctx == nil {
+		return nil, errors.New("discovery provider is not initialized")
+	}
+
+	netPeers, err := dp.config.NetworkPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to read configuration for network peers")
+	}
+
+	var peers []fab.Peer
+	for _, p := range netPeers {
+		p := p
+		newPeer, err := dp.fabPvdr.CreatePeerFromConfig(&p)
+		if err != nil || newPeer == nil {
+			return nil, errors.WithMessage(err, "NewPeerFromConfig failed")
+		}
+		peers = append(peers, newPeer)
+	}
+
+	if channelID == "" {
+		return &discoveryService{peers: peers}, nil
+	}
+
+	ds := &discoveryService{
+		ctx:        dp.ctx,
+		channelID:  channelID,
+		candidates: peers,
+		joined:     make(map[string]bool),
+		done:       make(chan struct{}),
+	}
+	ds.onClose = func() { dp.remove(ds) }
+	ds.refresh()
+	go ds.refreshPeriodically(dp.refreshInterval)
+
+	dp.services = append(dp.services, ds)
+	return ds, nil
+}
+
+// Close stops refreshing the peers of the discovery services
+func (dp *DiscoveryProvider) Close() {
+	dp.lock.Lock()
+	services := dp.services
+	dp.services = nil
+	dp.lock.Unlock()
+
+	for _, ds := range services {
+		ds.Close()
+	}
+}
+
+// remove removes a closed discovery service, e.g. a shared service released by the last of its channel contexts
+func (dp *DiscoveryProvider) remove(ds *discoveryService) {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+
+	for i, s := range dp.services {
+		if s == ds {
+			dp.services = append(dp.services[:i], dp.services[i+1:]...)
+			return
+		}
+	}
+}
+
+// discoveryService implements a discovery service whose peers are refreshed periodically
+type discoveryService struct {
+	ctx        context.Client
+	channelID  string
+	candidates []fab.Peer
+	joined     map[string]bool
+	done       chan struct{}
+	onClose    func()
+	closeOnce  sync.Once
+	lock       sync.RWMutex
+	peers      []fab.Peer
+}
+
+// Close stops refreshing the peers of the discovery service
+func (ds *discoveryService) Close() {
+	ds.closeOnce.Do(func() {
+		if ds.done != nil {
+			close(ds.done)
+		}
+		if ds.onClose != nil {
+			ds.onClose()
+		}
+	})
+}
+
+// GetPeers returns the peers that joined the channel as of the latest refresh
+func (ds *discoveryService) GetPeers() ([]fab.Peer, error) {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	peers := make([]fab.Peer, len(ds.peers))
+	copy(peers, ds.peers)
+	return peers, nil
+}
+
+func (ds *discoveryService) refreshPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ds.refresh()
+		case <-ds.done:
+			return
+		}
+	}
+}
+
+// refresh queries the candidate peers for the channels they joined. A peer that can't be
+// queried keeps the membership it had as of the previous refresh.
+func (ds *discoveryService) refresh() {
+	type result struct {
+		peer   fab.Peer
+		joined bool
+		err    error
+	}
+
+	results := make(chan result, len(ds.candidates))
+	for _, p := range ds.candidates {
+		go func(p fab.Peer) {
+			joined, err := ds.hasJoined(p)
+			results <- result{peer: p, joined: joined, err: err}
+		}(p)
+	}
+
+	for range ds.candidates {
+		r := <-results
+		if r.err != nil {
+			logger.Debugf("Failed to query channels of peer [%s]: %s", r.peer.URL(), r.err)
+			continue
+		}
+		ds.joined[r.peer.URL()] = r.joined
+	}
+
+	var peers []fab.Peer
+	for _, p := range ds.candidates {
+		if ds.joined[p.URL()] {
+			peers = append(peers, p)
+		}
+	}
+
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.peers = peers
+}
+
+func (ds *discoveryService) hasJoined(peer fab.Peer) (bool, error) {
+	reqCtx, cancel := contextImpl.NewRequest(ds.ctx, contextImpl.WithTimeoutType(fab.PeerResponse))
+	defer cancel()
+
+	response, err := resource.QueryChannels(reqCtx, peer)
+	if err != nil {
+		return false, err
+	}
+
+	for _, channel := range response.Channels {
+		if channel.ChannelId == ds.channelID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
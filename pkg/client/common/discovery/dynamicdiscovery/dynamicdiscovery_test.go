@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dynamicdiscovery
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/test/mockfab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	channelID = "mychannel"
+	peer1URL  = "peer1.example.com:7051"
+	peer2URL  = "peer2.example.com:7051"
+)
+
+func TestDynamicDiscovery(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().NetworkPeers().Return([]fab.NetworkPeer{
+		{PeerConfig: fab.PeerConfig{URL: peer1URL}},
+		{PeerConfig: fab.PeerConfig{URL: peer2URL}},
+	}, nil).AnyTimes()
+
+	peer1 := fcmocks.NewMockPeer("peer1", peer1URL)
+	peer1.Payload = channelsPayload(t, channelID)
+	peer2 := fcmocks.NewMockPeer("peer2", peer2URL)
+	peer2.Payload = channelsPayload(t)
+
+	_, err := New(config, &mockPeerCreator{})
+	assert.NotNil(t, err, "Expected error without user or signing identity")
+
+	discoveryProvider, err := New(config, &mockPeerCreator{peers: []*fcmocks.MockPeer{peer1, peer2}},
+		WithRefreshInterval(10*time.Millisecond), WithSigningIdentity(mspmocks.NewMockSigningIdentity("test", "test")))
+	if err != nil {
+		t.Fatalf("Failed to setup discovery provider: %s", err)
+	}
+	defer discoveryProvider.Close()
+
+	_, err = discoveryProvider.CreateDiscoveryService(channelID)
+	assert.NotNil(t, err, "Expected error for uninitialized provider")
+
+	err = discoveryProvider.Initialize(fcmocks.NewMockProviderContext())
+	if err != nil {
+		t.Fatalf("Failed to initialize discovery provider: %s", err)
+	}
+
+	discoveryService, err := discoveryProvider.CreateDiscoveryService(channelID)
+	if err != nil {
+		t.Fatalf("Failed to setup discovery service: %s", err)
+	}
+	assertPeers(t, discoveryService, peer1URL)
+
+	// Join peer2 to the channel
+	peer2.RWLock.Lock()
+	peer2.Payload = channelsPayload(t, channelID)
+	peer2.RWLock.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := discoveryService.GetPeers()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		peers, err := discoveryService.GetPeers()
+		assert.Nil(t, err)
+		if len(peers) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected refresh to discover peer joined to the channel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A closed discovery service, e.g. a shared service released by its channel contexts, is removed from the provider
+	assert.Len(t, discoveryProvider.services, 1)
+	discoveryService.(interface{ Close() }).Close()
+	assert.Empty(t, discoveryProvider.services, "Expected closed discovery service to be removed")
+
+	// All network peers are returned if the channel is empty
+	discoveryService, err = discoveryProvider.CreateDiscoveryService("")
+	if err != nil {
+		t.Fatalf("Failed to setup discovery service: %s", err)
+	}
+	assertPeers(t, discoveryService, peer1URL, peer2URL)
+}
+
+func assertPeers(t *testing.T, discoveryService fab.DiscoveryService, urls ...string) {
+	peers, err := discoveryService.GetPeers()
+	if err != nil {
+		t.Fatalf("Failed to get peers from discovery service: %s", err)
+	}
+
+	var peerURLs []string
+	for _, p := range peers {
+		peerURLs = append(peerURLs, p.URL())
+	}
+	assert.Equal(t, urls, peerURLs)
+}
+
+func channelsPayload(t *testing.T, channelIDs ...string) []byte {
+	response := &pb.ChannelQueryResponse{}
+	for _, id := range channelIDs {
+		response.Channels = append(response.Channels, &pb.ChannelInfo{ChannelId: id})
+	}
+
+	payload, err := proto.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal channel query response: %s", err)
+	}
+	return payload
+}
+
+type mockPeerCreator struct {
+	peers []*fcmocks.MockPeer
+}
+
+func (pc *mockPeerCreator) CreatePeerFromConfig(peerCfg *fab.NetworkPeer) (fab.Peer, error) {
+	for _, p := range pc.peers {
+		if p.URL() == peerCfg.URL {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
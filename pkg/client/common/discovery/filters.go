@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package discovery
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// andFilter accepts a peer if all of its filters accept it
+type andFilter struct {
+	filters []fab.TargetFilter
+}
+
+// And returns a target filter which accepts a peer only if all of the given filters accept it.
+// The filters are evaluated in order and evaluation stops at the first filter rejecting the peer.
+// Nil filters are ignored; if there are no filters, all peers are accepted.
+func And(filters ...fab.TargetFilter) fab.TargetFilter {
+	return &andFilter{filters: nonNilFilters(filters)}
+}
+
+// Accept returns true if all filters accept the peer
+func (f *andFilter) Accept(peer fab.Peer) bool {
+	for _, filter := range f.filters {
+		if !filter.Accept(peer) {
+			return false
+		}
+	}
+	return true
+}
+
+// orFilter accepts a peer if any of its filters accepts it
+type orFilter struct {
+	filters []fab.TargetFilter
+}
+
+// Or returns a target filter which accepts a peer if any of the given filters accepts it.
+// The filters are evaluated in order and evaluation stops at the first filter accepting the peer.
+// Nil filters are ignored; if there are no filters, no peers are accepted.
+func Or(filters ...fab.TargetFilter) fab.TargetFilter {
+	return &orFilter{filters: nonNilFilters(filters)}
+}
+
+// Accept returns true if any filter accepts the peer
+func (f *orFilter) Accept(peer fab.Peer) bool {
+	for _, filter := range f.filters {
+		if filter.Accept(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNilFilters(filters []fab.TargetFilter) []fab.TargetFilter {
+	var result []fab.TargetFilter
+	for _, filter := range filters {
+		if filter != nil {
+			result = append(result, filter)
+		}
+	}
+	return result
+}
+
+// mspFilter accepts peers by MSP ID
+type mspFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// NewMSPFilter returns a target filter which accepts the peers of the included MSPs, except those of the
+// excluded MSPs. If no MSPs are included, the peers of all MSPs that aren't excluded are accepted.
+func NewMSPFilter(includeMSPIDs, excludeMSPIDs []string) fab.TargetFilter {
+	return &mspFilter{include: toSet(includeMSPIDs), exclude: toSet(excludeMSPIDs)}
+}
+
+// Accept returns true if the MSP of the peer is included and not excluded
+func (f *mspFilter) Accept(peer fab.Peer) bool {
+	mspID := peer.MSPID()
+	if f.exclude[mspID] {
+		return false
+	}
+	return len(f.include) == 0 || f.include[mspID]
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
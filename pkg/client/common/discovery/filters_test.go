@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingFilter struct {
+	accept bool
+	calls  int
+}
+
+func (f *countingFilter) Accept(peer fab.Peer) bool {
+	f.calls++
+	return f.accept
+}
+
+func TestAndFilter(t *testing.T) {
+	peer := mocks.NewMockPeer("p1", "grpcs://p1:7051")
+
+	f1 := &countingFilter{accept: true}
+	f2 := &countingFilter{accept: false}
+	f3 := &countingFilter{accept: true}
+
+	assert.False(t, And(f1, nil, f2, f3).Accept(peer))
+	assert.Equal(t, 1, f1.calls)
+	assert.Equal(t, 1, f2.calls)
+	assert.Equal(t, 0, f3.calls, "Expected evaluation to stop at the first rejecting filter")
+
+	assert.True(t, And(f1, f3).Accept(peer))
+	assert.True(t, And().Accept(peer), "Expected empty And to accept all peers")
+}
+
+func TestOrFilter(t *testing.T) {
+	peer := mocks.NewMockPeer("p1", "grpcs://p1:7051")
+
+	f1 := &countingFilter{accept: false}
+	f2 := &countingFilter{accept: true}
+	f3 := &countingFilter{accept: true}
+
+	assert.True(t, Or(f1, nil, f2, f3).Accept(peer))
+	assert.Equal(t, 1, f1.calls)
+	assert.Equal(t, 1, f2.calls)
+	assert.Equal(t, 0, f3.calls, "Expected evaluation to stop at the first accepting filter")
+
+	assert.False(t, Or(f1).Accept(peer))
+	assert.False(t, Or().Accept(peer), "Expected empty Or to accept no peers")
+
+	// Combinators nest
+	assert.True(t, And(Or(f1, f2), f3).Accept(peer))
+	assert.False(t, Or(And(f2, f1), f1).Accept(peer))
+}
+
+func TestMSPFilter(t *testing.T) {
+	peer1 := mocks.NewMockPeer("p1", "grpcs://p1:7051")
+	peer1.SetMSPID("Org1MSP")
+	peer2 := mocks.NewMockPeer("p2", "grpcs://p2:7051")
+	peer2.SetMSPID("Org2MSP")
+	peer3 := mocks.NewMockPeer("p3", "grpcs://p3:7051")
+	peer3.SetMSPID("Org3MSP")
+
+	filter := NewMSPFilter(nil, []string{"Org2MSP"})
+	assert.True(t, filter.Accept(peer1))
+	assert.False(t, filter.Accept(peer2))
+	assert.True(t, filter.Accept(peer3))
+
+	filter = NewMSPFilter([]string{"Org1MSP", "Org2MSP"}, nil)
+	assert.True(t, filter.Accept(peer1))
+	assert.True(t, filter.Accept(peer2))
+	assert.False(t, filter.Accept(peer3))
+
+	// Exclusion takes precedence over inclusion
+	filter = NewMSPFilter([]string{"Org1MSP", "Org2MSP"}, []string{"Org2MSP"})
+	assert.True(t, filter.Accept(peer1))
+	assert.False(t, filter.Accept(peer2))
+
+	assert.True(t, NewMSPFilter(nil, nil).Accept(peer3))
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package greylist
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -48,6 +49,50 @@ func (b *Filter) Accept(peer fab.Peer) bool {
 	return true
 }
 
+// Entry is a greylisted peer
+type Entry struct {
+	// URL is the address of the peer
+	URL string
+	// Expiry is the time at which the peer is accepted again
+	Expiry time.Time
+}
+
+// Entries returns the peers which are currently greylisted, sorted by URL
+func (b *Filter) Entries() []Entry {
+	var entries []Entry
+	now := time.Now()
+	b.greylistURLs.Range(func(key, value interface{}) bool {
+		url, ok := key.(string)
+		timeAdded, ok2 := value.(time.Time)
+		if ok && ok2 && timeAdded.Add(b.expiryInterval).After(now) {
+			entries = append(entries, Entry{URL: url, Expiry: timeAdded.Add(b.expiryInterval)})
+		}
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+	return entries
+}
+
+// Remove removes the given peer URLs from the greylist, e.g. once the peers were fixed, or all peers if
+// no URL is given
+func (b *Filter) Remove(urls ...string) {
+	if len(urls) == 0 {
+		b.greylistURLs.Range(func(key, value interface{}) bool {
+			b.greylistURLs.Delete(key)
+			return true
+		})
+		return
+	}
+
+	for _, url := range urls {
+		peerAddress := endpoint.ToAddress(url)
+		if _, ok := b.greylistURLs.Load(peerAddress); ok {
+			logger.Infof("Removing peer %s from greylist", url)
+			b.greylistURLs.Delete(peerAddress)
+		}
+	}
+}
+
 // Greylist the given peer URL
 func (b *Filter) Greylist(err error) {
 	s, ok := status.FromError(err)
@@ -63,12 +108,22 @@ func (b *Filter) Greylist(err error) {
 // required decides whether the given status error warrants a greylist
 // on the peer causing the error
 func required(s *status.Status) (bool, string) {
-	if s.Group == status.EndorserClientStatus && s.Code == status.ConnectionFailed.ToInt32() {
+	if s.Group == status.EndorserClientStatus && isConnectionFailure(status.ToSDKStatusCode(s.Code)) {
 		return true, peerURLFromConnectionFailedStatus(s.Details)
 	}
 	return false, ""
 }
 
+// isConnectionFailure returns true if the code is that of a failed connection, including the codes
+// which classify the phase of the dial which failed
+func isConnectionFailure(code status.Code) bool {
+	switch code {
+	case status.ConnectionFailed, status.DNSFailure, status.ConnectTimeout, status.TLSHandshakeFailure:
+		return true
+	}
+	return false
+}
+
 // peerURLFromConnectionFailedStatus extracts the peer url from the status error
 // details
 func peerURLFromConnectionFailedStatus(details []interface{}) string {
@@ -37,6 +37,34 @@ func TestGreylistFilter(t *testing.T) {
 	}
 }
 
+func TestGreylistEntries(t *testing.T) {
+	expiryPeriod := time.Minute
+	peers := createMockPeers(0, 3)
+
+	f := New(expiryPeriod)
+	assert.Empty(t, f.Entries())
+	for _, peer := range peers {
+		f.Greylist(connectionFailedStatus(peer.URL()))
+	}
+
+	entries := f.Entries()
+	assert.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, "myPeer.org:"+strconv.Itoa(i), entry.URL)
+		assert.True(t, entry.Expiry.After(time.Now()), "Expected expiry in the future")
+		assert.True(t, entry.Expiry.Before(time.Now().Add(expiryPeriod+time.Second)), "Expected expiry within expiry period")
+	}
+
+	f.Remove(peers[1].URL())
+	assert.True(t, f.Accept(peers[1]), "Expected removed peer to be accepted")
+	assert.False(t, f.Accept(peers[0]), "Expected peer to remain greylisted")
+	assert.Len(t, f.Entries(), 2)
+
+	f.Remove()
+	assert.Empty(t, f.Entries())
+	assert.True(t, f.Accept(peers[0]), "Expected peer to be accepted after clearing the greylist")
+}
+
 func TestGreylistInvalidErr(t *testing.T) {
 	f := New(time.Microsecond * 1)
 	f.Greylist(fmt.Errorf("test"))
@@ -68,6 +68,10 @@ func (ds *MockSelectionService) GetEndorsersForChaincode(chaincodeIDs []string,
 		peers = ds.Peers
 	}
 
+	if params.PeerSorter != nil {
+		peers = params.PeerSorter(peers)
+	}
+
 	return peers, nil
 
 }
@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package selection provides helpers for ordering the peers chosen by the selection services.
+package selection
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+var (
+	random     = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomLock sync.Mutex
+)
+
+// NewBlockHeightSorter returns a peer sorter which orders the peers by descending ledger height,
+// so that requests are sent to the most up-to-date peer first. The height is obtained from the
+// peer's BlockHeight (see fab.PeerState); peers which don't report it are ordered last.
+// Peers at the same height are shuffled to spread the load.
+func NewBlockHeightSorter() options.PeerSorter {
+	return func(peers []fab.Peer) []fab.Peer {
+		sorted := make([]fab.Peer, len(peers))
+		copy(sorted, peers)
+
+		randomLock.Lock()
+		for i := len(sorted) - 1; i > 0; i-- {
+			j := random.Intn(i + 1)
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+		randomLock.Unlock()
+
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return blockHeight(sorted[i]) > blockHeight(sorted[j])
+		})
+		return sorted
+	}
+}
+
+func blockHeight(peer fab.Peer) uint64 {
+	if state, ok := peer.(fab.PeerState); ok {
+		return state.BlockHeight()
+	}
+	return 0
+}
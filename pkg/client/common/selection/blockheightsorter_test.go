@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package selection
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPeerWithHeight struct {
+	*mocks.MockPeer
+	height uint64
+}
+
+func (p *mockPeerWithHeight) BlockHeight() uint64 {
+	return p.height
+}
+
+func newPeer(name string, height uint64) fab.Peer {
+	return &mockPeerWithHeight{MockPeer: mocks.NewMockPeer(name, name+".example.com:7051"), height: height}
+}
+
+func TestBlockHeightSorter(t *testing.T) {
+	peer1 := newPeer("peer1", 10)
+	peer2 := newPeer("peer2", 12)
+	peer3 := mocks.NewMockPeer("peer3", "peer3.example.com:7051")
+	peers := []fab.Peer{peer3, peer1, peer2}
+
+	sorted := NewBlockHeightSorter()(peers)
+	assert.Equal(t, []fab.Peer{peer2, peer1, peer3}, sorted, "Expected peers in order of descending block height")
+	assert.Equal(t, []fab.Peer{peer3, peer1, peer2}, peers, "Expected the given peers to be left as is")
+}
+
+func TestBlockHeightSorterTies(t *testing.T) {
+	peer1 := newPeer("peer1", 10)
+	peer2 := newPeer("peer2", 10)
+	peer3 := newPeer("peer3", 5)
+
+	sorter := NewBlockHeightSorter()
+	first := make(map[fab.Peer]int)
+	for i := 0; i < 100; i++ {
+		sorted := sorter([]fab.Peer{peer1, peer2, peer3})
+		assert.Equal(t, peer3, sorted[2])
+		first[sorted[0]]++
+	}
+	assert.Len(t, first, 2, "Expected ties to be broken randomly")
+}
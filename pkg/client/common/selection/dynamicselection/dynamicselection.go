@@ -196,6 +196,10 @@ func (s *selectionService) GetEndorsersForChaincode(chaincodeIDs []string, opts
 	if err != nil {
 		return nil, err
 	}
+
+	if params.PeerSorter != nil {
+		return params.PeerSorter(peerGroup.Peers()), nil
+	}
 	return peerGroup.Peers(), nil
 }
 
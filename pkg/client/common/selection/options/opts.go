@@ -17,9 +17,19 @@ var logger = logging.NewLogger("fabsdk/client")
 // PeerFilter filters out unwanted peers
 type PeerFilter func(peer fab.Peer) bool
 
+// PeerSorter sorts the peers in order of preference
+type PeerSorter func(peers []fab.Peer) []fab.Peer
+
+// Sort sorts the peers, so that a PeerSorter may be used as a fab.TargetSorter
+func (s PeerSorter) Sort(peers []fab.Peer) []fab.Peer {
+	return s(peers)
+}
+
 // Params defines the parameters of a selection service request
 type Params struct {
-	PeerFilter PeerFilter
+	PeerFilter         PeerFilter
+	PeerSorter         PeerSorter
+	ChaincodeInterests []fab.CCInterest
 }
 
 // NewParams creates new parameters based on the provided options
@@ -38,12 +48,52 @@ func WithPeerFilter(value PeerFilter) copts.Opt {
 	}
 }
 
+// WithPeerSorter sets a peer sorter which orders the selected peers by preference
+func WithPeerSorter(value PeerSorter) copts.Opt {
+	return func(p copts.Params) {
+		if setter, ok := p.(peerSorterSetter); ok {
+			setter.SetPeerSorter(value)
+		}
+	}
+}
+
+// WithChaincodeInterests sets the chaincodes invoked by the transaction along with the private data collections
+// they access, for selection services which take the collections into account. Note that the chaincodes must
+// also be passed to GetEndorsersForChaincode.
+func WithChaincodeInterests(value []fab.CCInterest) copts.Opt {
+	return func(p copts.Params) {
+		if setter, ok := p.(chaincodeInterestsSetter); ok {
+			setter.SetChaincodeInterests(value)
+		}
+	}
+}
+
 type peerFilterSetter interface {
 	SetPeerFilter(value PeerFilter)
 }
 
+type peerSorterSetter interface {
+	SetPeerSorter(value PeerSorter)
+}
+
+type chaincodeInterestsSetter interface {
+	SetChaincodeInterests(value []fab.CCInterest)
+}
+
 // SetPeerFilter sets the peer filter
 func (p *Params) SetPeerFilter(value PeerFilter) {
 	logger.Debugf("PeerFilter: %#v", value)
 	p.PeerFilter = value
 }
+
+// SetPeerSorter sets the peer sorter
+func (p *Params) SetPeerSorter(value PeerSorter) {
+	logger.Debugf("PeerSorter: %#v", value)
+	p.PeerSorter = value
+}
+
+// SetChaincodeInterests sets the chaincode interests
+func (p *Params) SetChaincodeInterests(value []fab.CCInterest) {
+	logger.Debugf("ChaincodeInterests: %v", value)
+	p.ChaincodeInterests = value
+}
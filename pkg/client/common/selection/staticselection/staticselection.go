@@ -63,6 +63,10 @@ func (s *selectionService) GetEndorsersForChaincode(chaincodeIDs []string, opts
 		channelPeers = peers
 	}
 
+	if params.PeerSorter != nil {
+		channelPeers = params.PeerSorter(channelPeers)
+	}
+
 	if logging.IsEnabledFor(loggerModule, logging.DEBUG) {
 		str := ""
 		for i, peer := range channelPeers {
@@ -0,0 +1,386 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBatchSize  = 100
+	defaultBatchDelay = time.Second
+)
+
+// RegistrationOption describes a functional parameter of the registration functions which accept options
+type RegistrationOption func(*registrationOpts) error
+
+type registrationOpts struct {
+	maxBatchSize  int
+	maxBatchDelay time.Duration
+	namespaces    []string
+	trimBlocks    bool
+	buffer        *fab.BufferOpts
+}
+
+// Overflow policies of the event channel of a registration (see WithOverflowPolicy)
+const (
+	// OverflowBlock waits until the event is received, so that no event is dropped. A consumer which doesn't
+	// keep up holds up the events of all the registrations of the event service.
+	OverflowBlock = fab.OverflowBlock
+	// OverflowDropOldest drops the oldest event of the channel to make room for the new event
+	OverflowDropOldest = fab.OverflowDropOldest
+	// OverflowDropNewest drops the new event
+	OverflowDropNewest = fab.OverflowDropNewest
+)
+
+// WithBatching groups consecutive events of the registration into batches of at most maxSize events.
+// A batch is delivered as soon as it's full, or when maxDelay elapsed since its first event was received.
+// The default is batches of 100 events delivered at most a second after their first event.
+func WithBatching(maxSize int, maxDelay time.Duration) RegistrationOption {
+	return func(o *registrationOpts) error {
+		if maxSize <= 0 {
+			return errors.New("batch size must be greater than zero")
+		}
+		if maxDelay <= 0 {
+			return errors.New("batch delay must be greater than zero")
+		}
+		o.maxBatchSize = maxSize
+		o.maxBatchDelay = maxDelay
+		return nil
+	}
+}
+
+// WithChaincodeNamespaces only delivers the blocks which contain at least one transaction touching one of the
+// chaincode namespaces, i.e. invoking the chaincode or reading or writing its state. The blocks are filtered by
+// the event service before they're dispatched, which parses each block once for all its registrations.
+// Applies to block event registrations only.
+func WithChaincodeNamespaces(namespaces ...string) RegistrationOption {
+	return func(o *registrationOpts) error {
+		if len(namespaces) == 0 {
+			return errors.New("at least one chaincode namespace must be specified")
+		}
+		for _, namespace := range namespaces {
+			if namespace == "" {
+				return errors.New("chaincode namespace must not be empty")
+			}
+		}
+		o.namespaces = namespaces
+		return nil
+	}
+}
+
+// WithTrimmedBlocks trims the blocks delivered to a registration filtered by chaincode namespace (see
+// WithChaincodeNamespaces) to the transactions touching the namespaces. The header of a trimmed block is
+// the header of the original block, so the hash of its data doesn't match the header.
+func WithTrimmedBlocks() RegistrationOption {
+	return func(o *registrationOpts) error {
+		o.trimBlocks = true
+		return nil
+	}
+}
+
+// WithBufferSize sets the capacity of the event channel of the registration.
+// The default is the buffer size of the event service.
+func WithBufferSize(size int) RegistrationOption {
+	return func(o *registrationOpts) error {
+		if size <= 0 {
+			return errors.New("buffer size must be greater than zero")
+		}
+		o.bufferOpts().Size = size
+		return nil
+	}
+}
+
+// WithOverflowPolicy sets what happens to the events of the registration when its event channel is full:
+// OverflowBlock, OverflowDropOldest or OverflowDropNewest. With the drop policies, a consumer which doesn't
+// keep up never holds up the event service. The default is the event consumer timeout of the event service,
+// after which the event is dropped.
+func WithOverflowPolicy(policy fab.OverflowPolicy) RegistrationOption {
+	return func(o *registrationOpts) error {
+		switch policy {
+		case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+		default:
+			return errors.Errorf("invalid overflow policy [%d]", policy)
+		}
+		o.bufferOpts().OverflowPolicy = policy
+		return nil
+	}
+}
+
+// WithOverflowHandler sets a handler which is called with each event of the registration which is dropped,
+// so that the application can detect the loss of events. The handler is called by the event dispatcher,
+// so it mustn't block.
+func WithOverflowHandler(handler func(event interface{})) RegistrationOption {
+	return func(o *registrationOpts) error {
+		if handler == nil {
+			return errors.New("overflow handler is required")
+		}
+		o.bufferOpts().OnOverflow = handler
+		return nil
+	}
+}
+
+func (o *registrationOpts) bufferOpts() *fab.BufferOpts {
+	if o.buffer == nil {
+		o.buffer = &fab.BufferOpts{}
+	}
+	return o.buffer
+}
+
+func newRegistrationOpts(opts []RegistrationOption) (registrationOpts, error) {
+	o := registrationOpts{maxBatchSize: defaultBatchSize, maxBatchDelay: defaultBatchDelay}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, errors.WithMessage(err, "failed to apply registration option")
+		}
+	}
+	if o.trimBlocks && len(o.namespaces) == 0 {
+		return o, errors.New("trimmed blocks require chaincode namespaces")
+	}
+	return o, nil
+}
+
+// RegisterChaincodeEventBatch registers for chaincode events which are received in batches (see WithBatching).
+// The events are received in the order in which they were committed, within and across batches. A batch is
+// only assembled once the previous batch was received, so an application which records its progress after
+// processing a batch never skips events that it didn't receive.
+// Unregister must be called when the registration is no longer needed. The pending events are delivered in
+// a last batch before the channel is closed, so the channel must be read until it's closed.
+//  Parameters:
+//  ccID is the chaincode ID for which events are to be received
+//  eventFilter is the chaincode event filter (regular expression) for which events are to be received
+//  opts are the registration options
+//
+//  Returns:
+//  the registration and a channel that is used to receive batches of events. The channel is closed when Unregister is called.
+func (c *Client) RegisterChaincodeEventBatch(ccID, eventFilter string, opts ...RegistrationOption) (fab.Registration, <-chan []*fab.CCEvent, error) {
+	o, err := newRegistrationOpts(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reg, eventch, err := c.registerChaincodeEvent(ccID, eventFilter, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan interface{})
+	go func() {
+		defer close(events)
+		for event := range eventch {
+			events <- event
+		}
+	}()
+
+	batchch := make(chan []*fab.CCEvent)
+	go func() {
+		defer close(batchch)
+		batchEvents(events, o, func(batch []interface{}) {
+			ccEvents := make([]*fab.CCEvent, len(batch))
+			for i, event := range batch {
+				ccEvents[i] = event.(*fab.CCEvent)
+			}
+			batchch <- ccEvents
+		})
+	}()
+
+	return reg, batchch, nil
+}
+
+// RegisterBlockEventBatch registers for block events which are received in batches (see WithBatching).
+// The blocks are received in order, within and across batches. A batch is only assembled once the previous
+// batch was received, so an application which records its progress after processing a batch never skips
+// blocks that it didn't receive.
+// Unregister must be called when the registration is no longer needed. The pending blocks are delivered in
+// a last batch before the channel is closed, so the channel must be read until it's closed.
+// Note that the caller must have sufficient privileges (see WithBlockEvents).
+//  Parameters:
+//  filter is an optional filter that filters out unwanted events (nil for all blocks)
+//  opts are the registration options
+//
+//  Returns:
+//  the registration and a channel that is used to receive batches of events. The channel is closed when Unregister is called.
+func (c *Client) RegisterBlockEventBatch(filter fab.BlockFilter, opts ...RegistrationOption) (fab.Registration, <-chan []*fab.BlockEvent, error) {
+	o, err := newRegistrationOpts(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reg, eventch, err := c.registerBlockEvent(filter, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan interface{})
+	go func() {
+		defer close(events)
+		for event := range eventch {
+			events <- event
+		}
+	}()
+
+	batchch := make(chan []*fab.BlockEvent)
+	go func() {
+		defer close(batchch)
+		batchEvents(events, o, func(batch []interface{}) {
+			blockEvents := make([]*fab.BlockEvent, len(batch))
+			for i, event := range batch {
+				blockEvents[i] = event.(*fab.BlockEvent)
+			}
+			batchch <- blockEvents
+		})
+	}()
+
+	return reg, batchch, nil
+}
+
+// RegisterBlockEventWithOptions registers for block events. The blocks may be filtered by chaincode namespace
+// (see WithChaincodeNamespaces) and trimmed to the matching transactions (see WithTrimmedBlocks), and the
+// registration may have its own buffer options (see WithBufferSize, WithOverflowPolicy and WithOverflowHandler).
+// Unlike RegisterBlockEvent, the registration doesn't resume from the checkpoint of the client.
+// Unregister must be called when the registration is no longer needed.
+// Note that the caller must have sufficient privileges (see WithBlockEvents).
+//  Parameters:
+//  filter is an optional filter that filters out unwanted events (nil for all blocks)
+//  opts are the registration options
+//
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterBlockEventWithOptions(filter fab.BlockFilter, opts ...RegistrationOption) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	o, err := newRegistrationOpts(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.registerBlockEvent(filter, o)
+}
+
+// RegisterFilteredBlockEventWithOptions registers for filtered block events with the buffer options of the
+// registration (see WithBufferSize, WithOverflowPolicy and WithOverflowHandler). Unlike RegisterFilteredBlockEvent,
+// the registration doesn't resume from the checkpoint of the client.
+// Unregister must be called when the registration is no longer needed.
+//  Parameters:
+//  opts are the registration options
+//
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterFilteredBlockEventWithOptions(opts ...RegistrationOption) (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	o, err := newRegistrationOpts(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	eventService, err := c.eventServiceFor(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eventService.RegisterFilteredBlockEvent()
+}
+
+// RegisterChaincodeEventWithOptions registers for chaincode events with the buffer options of the registration
+// (see WithBufferSize, WithOverflowPolicy and WithOverflowHandler). Unlike RegisterChaincodeEvent, the
+// registration doesn't resume from the checkpoint of the client.
+// Unregister must be called when the registration is no longer needed.
+//  Parameters:
+//  ccID is the chaincode ID for which events are to be received
+//  eventFilter is the chaincode event filter (regular expression) for which events are to be received
+//  opts are the registration options
+//
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterChaincodeEventWithOptions(ccID, eventFilter string, opts ...RegistrationOption) (fab.Registration, <-chan *fab.CCEvent, error) {
+	o, err := newRegistrationOpts(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.registerChaincodeEvent(ccID, eventFilter, o)
+}
+
+func (c *Client) registerChaincodeEvent(ccID, eventFilter string, o registrationOpts) (fab.Registration, <-chan *fab.CCEvent, error) {
+	eventService, err := c.eventServiceFor(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eventService.RegisterChaincodeEvent(ccID, eventFilter)
+}
+
+//registerBlockEvent registers for the block events of the event service, filtered by chaincode namespace if
+//the options specify namespaces
+func (c *Client) registerBlockEvent(filter fab.BlockFilter, o registrationOpts) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	eventService, err := c.eventServiceFor(o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var filters []fab.BlockFilter
+	if filter != nil {
+		filters = append(filters, filter)
+	}
+	if len(o.namespaces) == 0 {
+		return eventService.RegisterBlockEvent(filters...)
+	}
+
+	nsService, ok := eventService.(fab.NamespaceEventService)
+	if !ok {
+		return nil, nil, errors.New("event service doesn't filter block events by chaincode namespace")
+	}
+	return nsService.RegisterNamespaceBlockEvent(o.namespaces, o.trimBlocks, filters...)
+}
+
+//eventServiceFor returns the event service to register with: a buffered view of the event service if the
+//options specify buffer options
+func (c *Client) eventServiceFor(o registrationOpts) (fab.EventService, error) {
+	if o.buffer == nil {
+		return c.eventService, nil
+	}
+	bufferedService, ok := c.eventService.(fab.BufferedEventService)
+	if !ok {
+		return nil, errors.New("event service doesn't support buffer options")
+	}
+	return bufferedService.Buffered(*o.buffer), nil
+}
+
+//batchEvents groups the events received on eventch into batches which are passed to deliver when they're
+//full or their delay elapsed, until eventch is closed. The pending events are delivered before returning.
+func batchEvents(eventch <-chan interface{}, o registrationOpts, deliver func(batch []interface{})) {
+	var batch []interface{}
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(batch) > 0 {
+			deliver(batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) == 1 {
+				timer = time.NewTimer(o.maxBatchDelay)
+				timeout = timer.C
+			}
+			if len(batch) >= o.maxBatchSize {
+				flush()
+			}
+		case <-timeout:
+			timer, timeout = nil, nil
+			flush()
+		}
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestWithBatching(t *testing.T) {
+	o, err := newRegistrationOpts(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultBatchSize, o.maxBatchSize)
+	assert.Equal(t, defaultBatchDelay, o.maxBatchDelay)
+
+	o, err = newRegistrationOpts([]RegistrationOption{WithBatching(10, time.Millisecond)})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, o.maxBatchSize)
+	assert.Equal(t, time.Millisecond, o.maxBatchDelay)
+
+	_, err = newRegistrationOpts([]RegistrationOption{WithBatching(0, time.Millisecond)})
+	assert.NotNil(t, err, "expected error for invalid batch size")
+	_, err = newRegistrationOpts([]RegistrationOption{WithBatching(10, 0)})
+	assert.NotNil(t, err, "expected error for invalid batch delay")
+}
+
+func TestBatchEvents(t *testing.T) {
+	eventch := make(chan interface{})
+	batchch := make(chan []interface{})
+	go func() {
+		defer close(batchch)
+		batchEvents(eventch, registrationOpts{maxBatchSize: 2, maxBatchDelay: 100 * time.Millisecond}, func(batch []interface{}) {
+			batchch <- batch
+		})
+	}()
+
+	// A full batch is delivered immediately
+	eventch <- 1
+	eventch <- 2
+	assert.Equal(t, []interface{}{1, 2}, <-batchch)
+
+	// A partial batch is delivered once its delay elapsed
+	start := time.Now()
+	eventch <- 3
+	assert.Equal(t, []interface{}{3}, <-batchch)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "expected partial batch to be delivered after its delay")
+
+	// The pending events are delivered when the event channel is closed
+	eventch <- 4
+	close(eventch)
+	assert.Equal(t, []interface{}{4}, <-batchch)
+	_, ok := <-batchch
+	assert.False(t, ok, "expected batch channel to be closed")
+}
+
+func TestCCEventBatches(t *testing.T) {
+	chanID := "mychannel"
+	ccID := "mycc"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID))
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+	client.eventService = eventService
+
+	if _, _, err1 := client.RegisterChaincodeEventBatch(ccID, ".*", WithBatching(0, time.Second)); err1 == nil {
+		t.Fatalf("expecting error registering with invalid batch size but got none")
+	}
+
+	reg, batchch, err := client.RegisterChaincodeEventBatch(ccID, ".*", WithBatching(2, time.Minute))
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	eventProducer.Ledger().NewFilteredBlock(
+		chanID,
+		servicemocks.NewFilteredTxWithCCEvent("txid1", ccID, "event1"),
+		servicemocks.NewFilteredTxWithCCEvent("txid2", ccID, "event2"),
+		servicemocks.NewFilteredTxWithCCEvent("txid3", ccID, "event3"),
+	)
+
+	select {
+	case batch := <-batchch:
+		assert.Equal(t, []string{"event1", "event2"}, eventNames(batch))
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for CC event batch")
+	}
+
+	// Wait for the last event to be batched before unregistering, which delivers the partial batch
+	time.Sleep(100 * time.Millisecond)
+	client.Unregister(reg)
+
+	select {
+	case batch := <-batchch:
+		assert.Equal(t, []string{"event3"}, eventNames(batch))
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for partial CC event batch")
+	}
+	_, ok := <-batchch
+	assert.False(t, ok, "expected batch channel to be closed")
+}
+
+func TestWithChaincodeNamespaces(t *testing.T) {
+	o, err := newRegistrationOpts([]RegistrationOption{WithChaincodeNamespaces("cc1", "cc2"), WithTrimmedBlocks()})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cc1", "cc2"}, o.namespaces)
+	assert.True(t, o.trimBlocks)
+
+	_, err = newRegistrationOpts([]RegistrationOption{WithChaincodeNamespaces()})
+	assert.NotNil(t, err, "expected error for no namespaces")
+	_, err = newRegistrationOpts([]RegistrationOption{WithChaincodeNamespaces("cc1", "")})
+	assert.NotNil(t, err, "expected error for empty namespace")
+	_, err = newRegistrationOpts([]RegistrationOption{WithTrimmedBlocks()})
+	assert.NotNil(t, err, "expected error for trimmed blocks without namespaces")
+}
+
+func TestWithBufferOptions(t *testing.T) {
+	o, err := newRegistrationOpts(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, o.buffer, "expected no buffer options by default")
+
+	var dropped int
+	o, err = newRegistrationOpts([]RegistrationOption{
+		WithBufferSize(5),
+		WithOverflowPolicy(OverflowDropOldest),
+		WithOverflowHandler(func(event interface{}) { dropped++ }),
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, o.buffer) {
+		assert.Equal(t, 5, o.buffer.Size)
+		assert.Equal(t, OverflowDropOldest, o.buffer.OverflowPolicy)
+		o.buffer.OnOverflow(nil)
+		assert.Equal(t, 1, dropped)
+	}
+
+	_, err = newRegistrationOpts([]RegistrationOption{WithBufferSize(0)})
+	assert.NotNil(t, err, "expected error for invalid buffer size")
+	_, err = newRegistrationOpts([]RegistrationOption{WithOverflowPolicy(fab.OverflowDefault)})
+	assert.NotNil(t, err, "expected error for invalid overflow policy")
+	_, err = newRegistrationOpts([]RegistrationOption{WithOverflowHandler(nil)})
+	assert.NotNil(t, err, "expected error for nil overflow handler")
+}
+
+func TestBufferedEvents(t *testing.T) {
+	chanID := "mychannel"
+	ccID := "mycc"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID))
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+	client.eventService = eventService
+
+	dropped := make(chan interface{}, 10)
+	reg, eventch, err := client.RegisterChaincodeEventWithOptions(ccID, ".*",
+		WithBufferSize(1),
+		WithOverflowPolicy(OverflowDropNewest),
+		WithOverflowHandler(func(event interface{}) { dropped <- event }),
+	)
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	defer client.Unregister(reg)
+
+	freg, feventch, err := client.RegisterFilteredBlockEventWithOptions(WithBufferSize(2))
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+	defer client.Unregister(freg)
+	assert.Equal(t, 2, cap(feventch))
+
+	eventProducer.Ledger().NewFilteredBlock(
+		chanID,
+		servicemocks.NewFilteredTxWithCCEvent("txid1", ccID, "event1"),
+		servicemocks.NewFilteredTxWithCCEvent("txid2", ccID, "event2"),
+	)
+
+	select {
+	case event := <-dropped:
+		assert.Equal(t, "event2", event.(*fab.CCEvent).EventName)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for dropped CC event")
+	}
+	assert.Equal(t, "event1", (<-eventch).EventName)
+
+	// An event service which doesn't support buffer options
+	client.eventService = &unfilteredEventService{EventService: eventService}
+	_, _, err = client.RegisterChaincodeEventWithOptions(ccID, ".*", WithBufferSize(1))
+	assert.NotNil(t, err, "expected error for event service which doesn't support buffer options")
+}
+
+func TestNamespaceBlockEvents(t *testing.T) {
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), channelID))
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+	client.eventService = eventService
+
+	reg, eventch, err := client.RegisterBlockEventWithOptions(nil, WithChaincodeNamespaces("cc1"), WithTrimmedBlocks())
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	defer client.Unregister(reg)
+
+	breg, batchch, err := client.RegisterBlockEventBatch(nil, WithChaincodeNamespaces("cc2"), WithBatching(1, time.Minute))
+	if err != nil {
+		t.Fatalf("error registering for block event batches: %s", err)
+	}
+	defer client.Unregister(breg)
+
+	eventProducer.Ledger().NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, "cc2", "event1", nil),
+	)
+	eventProducer.Ledger().NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, "cc1", "event2", nil),
+		servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, "cc2", "event3", nil),
+	)
+
+	select {
+	case event := <-eventch:
+		assert.Equal(t, uint64(1), event.Block.Header.Number)
+		assert.Len(t, event.Block.Data.Data, 1, "expected the block to be trimmed to the transaction of cc1")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block event")
+	}
+
+	for _, expected := range []uint64{0, 1} {
+		select {
+		case batch := <-batchch:
+			if assert.Len(t, batch, 1) {
+				assert.Equal(t, expected, batch[0].Block.Header.Number)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block event batch")
+		}
+	}
+
+	// An event service which doesn't filter by namespace
+	client.eventService = &unfilteredEventService{EventService: eventService}
+	_, _, err = client.RegisterBlockEventWithOptions(nil, WithChaincodeNamespaces("cc1"))
+	assert.NotNil(t, err, "expected error for event service which doesn't filter by namespace")
+}
+
+func eventNames(batch []*fab.CCEvent) []string {
+	var names []string
+	for _, event := range batch {
+		names = append(names, event.EventName)
+	}
+	return names
+}
+
+type unfilteredEventService struct {
+	fab.EventService
+}
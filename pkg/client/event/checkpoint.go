@@ -0,0 +1,215 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is the position of the last event received by the application
+type Checkpoint struct {
+	// BlockNumber is the number of the block of the event
+	BlockNumber uint64 `json:"blockNumber"`
+	// TxIndex is the index of the transaction of the event within the block. The checkpoint of a block event
+	// is the index of the last transaction of the block, so it covers the whole block.
+	TxIndex int `json:"txIndex"`
+}
+
+// Checkpointer persists the position of the last event received by the application (see WithCheckpointer)
+type Checkpointer interface {
+	// Save records that the events up to and including those of the transaction at index txIdx of the given
+	// block were received
+	Save(block uint64, txIdx int) error
+	// Load returns the last checkpoint saved, or nil if none was saved
+	Load() (*Checkpoint, error)
+}
+
+// WithCheckpointer persists the position of the events received through the block, filtered block and chaincode
+// event registrations of the client, so that they resume where they left off when the application restarts.
+// The registrations receive the events from a dedicated connection to the Deliver service which seeks from the
+// checkpointed block, skipping the events of the block that were already received; they receive the live events
+// if there's no checkpoint yet. A reconnection resumes from the last block received by the connection, whose events
+// were all passed on to the application.
+// The checkpoint is advanced only once the application received an event from the registration channel, so an
+// application which crashes after receiving an event but before the checkpoint is saved receives the event again
+// after the restart: events may be delivered twice, but never skipped.
+// A checkpointer holds the position of a single registration, so a client with a checkpointer is meant for one
+// registration at a time.
+func WithCheckpointer(cp Checkpointer) ClientOption {
+	return func(c *Client) error {
+		if cp == nil {
+			return errors.New("checkpointer is nil")
+		}
+		c.checkpointer = cp
+		return nil
+	}
+}
+
+//registerCheckpointed registers with a dedicated event client which receives the events from the checkpoint on,
+//and forwards the events which come after the checkpoint to deliver, advancing the checkpoint once each event
+//was delivered. position returns the block number and the transaction index of an event. The forwarding stops
+//when the registration is unregistered, in which case deliver returns false, and done is called once it stopped.
+func (c *Client) registerCheckpointed(register func(eventClient fab.EventClient, stop <-chan struct{}) (fab.Registration, <-chan interface{}, error), position func(event interface{}) (uint64, int), deliver func(event interface{}, stop <-chan struct{}) bool, done func()) (fab.Registration, error) {
+	checkpoint, err := c.checkpointer.Load()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load checkpoint")
+	}
+
+	var fromBlock uint64
+	var opts []options.Opt
+	if checkpoint != nil {
+		logger.Debugf("Resuming events from checkpoint [%d, %d]", checkpoint.BlockNumber, checkpoint.TxIndex)
+		fromBlock = checkpoint.BlockNumber
+	} else {
+		opts = append(opts, deliverclient.WithSeekType(seek.Newest))
+	}
+
+	stop := make(chan struct{})
+	var eventch <-chan interface{}
+	reg, err := c.registerFrom(fromBlock, func(eventClient fab.EventClient) (fab.Registration, error) {
+		reg, ch, err := register(eventClient, stop)
+		eventch = ch
+		return reg, err
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reg.stop = stop
+	reg.stopped = make(chan struct{})
+
+	go func() {
+		defer close(reg.stopped)
+		defer done()
+		for {
+			var event interface{}
+			var ok bool
+			select {
+			case event, ok = <-eventch:
+				if !ok {
+					return
+				}
+			case <-stop:
+				return
+			}
+
+			block, txIndex := position(event)
+			if checkpoint != nil && (block < checkpoint.BlockNumber || block == checkpoint.BlockNumber && txIndex <= checkpoint.TxIndex) {
+				logger.Debugf("Skipping event [%d, %d] received before the checkpoint", block, txIndex)
+				continue
+			}
+			if !deliver(event, stop) {
+				return
+			}
+			if err := c.checkpointer.Save(block, txIndex); err != nil {
+				logger.Warnf("Failed to save checkpoint [%d, %d]: %s", block, txIndex, err)
+			}
+		}
+	}()
+
+	return reg, nil
+}
+
+//toEvents passes the typed events returned by next on to an untyped channel which is closed along with the typed
+//channel. Once stopped, the typed events are drained until the typed channel is closed, so that the dispatcher of
+//the event client isn't blocked.
+func toEvents(stop <-chan struct{}, next func() (interface{}, bool)) <-chan interface{} {
+	events := make(chan interface{})
+	go func() {
+		defer close(events)
+		for {
+			event, ok := next()
+			if !ok {
+				return
+			}
+			select {
+			case events <- event:
+			case <-stop:
+				for _, ok := next(); ok; _, ok = next() {
+				}
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// FileCheckpointer is a checkpointer which persists the checkpoint to a file
+type FileCheckpointer struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewFileCheckpointer returns a checkpointer which persists the checkpoint to the file at the given path. The
+// file is replaced atomically, so a checkpoint is never left partially written.
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{path: path}
+}
+
+// Save persists the checkpoint
+func (cp *FileCheckpointer) Save(block uint64, txIdx int) error {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	data, err := json.Marshal(&Checkpoint{BlockNumber: block, TxIndex: txIdx})
+	if err != nil {
+		return errors.Wrap(err, "marshal of checkpoint failed")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(cp.path), filepath.Base(cp.path))
+	if err != nil {
+		return errors.Wrap(err, "creating checkpoint file failed")
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "writing checkpoint file failed")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "writing checkpoint file failed")
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "writing checkpoint file failed")
+	}
+	if err := os.Rename(tmp.Name(), cp.path); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "replacing checkpoint file failed")
+	}
+	return nil
+}
+
+// Load reads the checkpoint, or returns nil if the file doesn't exist
+func (cp *FileCheckpointer) Load() (*Checkpoint, error) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	data, err := ioutil.ReadFile(cp.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading checkpoint file failed")
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of checkpoint failed")
+	}
+	return checkpoint, nil
+}
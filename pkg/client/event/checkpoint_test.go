@@ -0,0 +1,247 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestFileCheckpointer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cp := NewFileCheckpointer(filepath.Join(dir, "checkpoint.json"))
+	checkpoint, err := cp.Load()
+	assert.Nil(t, err)
+	assert.Nil(t, checkpoint, "Expected no checkpoint")
+
+	assert.Nil(t, cp.Save(5, 2))
+	assert.Nil(t, cp.Save(6, 0))
+	checkpoint, err = NewFileCheckpointer(filepath.Join(dir, "checkpoint.json")).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, &Checkpoint{BlockNumber: 6, TxIndex: 0}, checkpoint)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1, "Expected temporary files to be renamed")
+
+	_, err = New(createChannelContext(setupCustomTestContext(t, nil), "mychannel"), WithCheckpointer(nil))
+	assert.Error(t, err, "Expected error for nil checkpointer")
+}
+
+func TestChaincodeEventCheckpoint(t *testing.T) {
+	ccID := "mycc"
+	chanID := "mychannel"
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory, sourceURL)
+	ledger.NewBlock(chanID, servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, ccID, "event1", []byte("p1")))
+	ledger.NewBlock(chanID,
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, ccID, "event2", []byte("p2")),
+		servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, "othercc", "event3", nil),
+		servicemocks.NewTransactionWithCCEvent("txid4", pb.TxValidationCode_VALID, ccID, "event4", []byte("p4")),
+	)
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	// The events of block 0 were processed
+	assert.Nil(t, NewFileCheckpointer(path).Save(0, 0))
+
+	client, fromBlock := newCheckpointedClient(t, ledger, NewFileCheckpointer(path))
+	reg, eventch, err := client.RegisterChaincodeEvent(ccID, "event.*")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	assert.Equal(t, []string{"txid2", "txid4"}, receiveCCEvents(t, eventch, 2))
+	assert.EqualValues(t, 0, *fromBlock)
+	client.Unregister(reg)
+	assertClosed(t, eventch)
+
+	checkpoint, err := NewFileCheckpointer(path).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, &Checkpoint{BlockNumber: 1, TxIndex: 2}, checkpoint)
+
+	// After a restart, the events received before the checkpoint aren't received again
+	ledger.NewBlock(chanID, servicemocks.NewTransactionWithCCEvent("txid5", pb.TxValidationCode_VALID, ccID, "event5", []byte("p5")))
+	client, fromBlock = newCheckpointedClient(t, ledger, &crashingCheckpointer{Checkpointer: NewFileCheckpointer(path)})
+	reg, eventch, err = client.RegisterChaincodeEvent(ccID, "event.*")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	assert.Equal(t, []string{"txid5"}, receiveCCEvents(t, eventch, 1))
+	assert.EqualValues(t, 1, *fromBlock)
+	client.Unregister(reg)
+	assertClosed(t, eventch)
+
+	// The application crashed before the checkpoint of the event it received was saved, so it's received again
+	client, _ = newCheckpointedClient(t, ledger, NewFileCheckpointer(path))
+	reg, eventch, err = client.RegisterChaincodeEvent(ccID, "event.*")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	assert.Equal(t, []string{"txid5"}, receiveCCEvents(t, eventch, 1))
+	client.Unregister(reg)
+	assertClosed(t, eventch)
+}
+
+func TestBlockEventCheckpoint(t *testing.T) {
+	chanID := "mychannel"
+	ledger := newReplayLedger(chanID, 3)
+	cp := &memoryCheckpointer{}
+
+	// Without a checkpoint, the live blocks are received
+	client, _ := newCheckpointedClient(t, ledger, cp)
+	var replayOpts []options.Opt
+	replayProvider := client.replayProvider
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		replayOpts = opts
+		return replayProvider(fromBlock, permitBlockEvents, opts...)
+	}
+	reg, eventch, err := client.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	assert.Len(t, replayOpts, 1, "Expected newest block to be sought")
+	receiveBlocks(t, eventch, 3)
+	client.Unregister(reg)
+	assertClosed(t, eventch)
+	assert.Equal(t, &Checkpoint{BlockNumber: 2, TxIndex: 0}, cp.checkpoint)
+
+	// The checkpointed block isn't received again
+	client, _ = newCheckpointedClient(t, ledger, cp)
+	reg, eventch, err = client.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	ledger.NewBlock(chanID, servicemocks.NewTransaction("txid3", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	assert.Equal(t, []uint64{3}, receiveBlocks(t, eventch, 1))
+	client.Unregister(reg)
+	assertClosed(t, eventch)
+}
+
+func TestCheckpointedUnregisterWithoutReading(t *testing.T) {
+	ledger := newReplayLedger("mychannel", 3)
+	client, _ := newCheckpointedClient(t, ledger, &memoryCheckpointer{})
+	reg, eventch, err := client.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+
+	// The application doesn't read the events, yet the channel is closed upon Unregister
+	unregistered := make(chan struct{})
+	go func() {
+		client.Unregister(reg)
+		close(unregistered)
+	}()
+	select {
+	case <-unregistered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out unregistering")
+	}
+	_, ok := <-eventch
+	assert.False(t, ok, "Expected channel to be closed")
+}
+
+//newCheckpointedClient returns a client with the given checkpointer whose replay clients receive the blocks of the
+//ledger, along with the block from which the last replay client was created
+func newCheckpointedClient(t *testing.T, ledger *servicemocks.MockLedger, cp Checkpointer) (*Client, *uint64) {
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), "mychannel"), WithBlockEvents(), WithCheckpointer(cp))
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	var replayFrom uint64
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		replayFrom = fromBlock
+		replayClient := newMockReplayClient(ledger, fromBlock)
+		return replayClient, replayClient.Start()
+	}
+	return client, &replayFrom
+}
+
+//receiveCCEvents receives the given number of chaincode events and returns their transaction IDs
+func receiveCCEvents(t *testing.T, eventch <-chan *fab.CCEvent, n int) []string {
+	var txIDs []string
+	for i := 0; i < n; i++ {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			txIDs = append(txIDs, event.TxID)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for CC event")
+		}
+	}
+	return txIDs
+}
+
+//assertClosed drains the channel until it's closed
+func assertClosed(t *testing.T, eventch interface{}) {
+	timeout := time.After(5 * time.Second)
+	for {
+		var ok bool
+		switch ch := eventch.(type) {
+		case <-chan *fab.CCEvent:
+			select {
+			case _, ok = <-ch:
+			case <-timeout:
+				t.Fatalf("timed out waiting for channel to be closed")
+			}
+		case <-chan *fab.BlockEvent:
+			select {
+			case _, ok = <-ch:
+			case <-timeout:
+				t.Fatalf("timed out waiting for channel to be closed")
+			}
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+//crashingCheckpointer loads the checkpoint but never saves it, as if the application crashed before saving
+type crashingCheckpointer struct {
+	Checkpointer
+}
+
+func (cp *crashingCheckpointer) Save(block uint64, txIdx int) error {
+	return nil
+}
+
+//memoryCheckpointer holds the checkpoint in memory
+type memoryCheckpointer struct {
+	checkpoint *Checkpoint
+}
+
+func (cp *memoryCheckpointer) Save(block uint64, txIdx int) error {
+	cp.checkpoint = &Checkpoint{BlockNumber: block, TxIndex: txIdx}
+	return nil
+}
+
+func (cp *memoryCheckpointer) Load() (*Checkpoint, error) {
+	return cp.checkpoint, nil
+}
@@ -0,0 +1,335 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package cloudevents converts the events of the event client (chaincode, block and transaction status events) to
+// CloudEvents 1.0 events, and back. It doesn't depend on a CloudEvents SDK: the events are marshalled to the
+// structured JSON format of the specification, which any CloudEvents consumer accepts.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTypePrefix is the default prefix of the types of the events
+	DefaultTypePrefix = "org.hyperledger.fabric."
+
+	// ExtBlockNumber is the extension attribute holding the number of the block of the event
+	ExtBlockNumber = "fabricblocknumber"
+	// ExtTxIndex is the extension attribute holding the index of the transaction of the event within its block
+	ExtTxIndex = "fabrictxindex"
+	// ExtSourceURL is the extension attribute holding the URL of the peer which produced the event
+	ExtSourceURL = "fabricpeer"
+
+	ccEventType  = "chaincode."
+	blockType    = "block"
+	txStatusType = "transaction.status"
+
+	protobufContentType = "application/protobuf"
+	jsonContentType     = "application/json"
+)
+
+// Converter converts the events of a channel to CloudEvents and back
+type Converter struct {
+	channelID  string
+	typePrefix string
+}
+
+// Option describes a functional parameter for the New constructor
+type Option func(*Converter) error
+
+// WithTypePrefix sets the prefix of the types of the events (DefaultTypePrefix by default). The type of a
+// chaincode event is the prefix followed by "chaincode." and the name of the event, e.g.
+// org.hyperledger.fabric.chaincode.transfer.
+func WithTypePrefix(prefix string) Option {
+	return func(c *Converter) error {
+		if prefix == "" {
+			return errors.New("type prefix is empty")
+		}
+		c.typePrefix = prefix
+		return nil
+	}
+}
+
+// New returns a converter for the events of the given channel
+func New(channelID string, opts ...Option) (*Converter, error) {
+	if channelID == "" {
+		return nil, errors.New("channel ID is required")
+	}
+
+	c := &Converter{channelID: channelID, typePrefix: DefaultTypePrefix}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, errors.WithMessage(err, "option failed")
+		}
+	}
+	return c, nil
+}
+
+// FromCCEvent converts a chaincode event. The type of the event is derived from the name of the event, its source
+// from the channel and the chaincode (/channels/<channel>/chaincodes/<chaincode>) and its ID from the transaction
+// ID and the index of the transaction within the block. The subject is the transaction ID.
+// Chaincode events don't carry the time of their block, so the time of the event is blockTime, which BlockTime
+// extracts from the block of the event; the time is omitted if blockTime is zero.
+// The content type of the payload is detected: JSON payloads are embedded as JSON, text payloads as a string and
+// binary payloads are base64 encoded.
+func (c *Converter) FromCCEvent(event *fab.CCEvent, blockTime time.Time) (*Event, error) {
+	if event.EventName == "" {
+		return nil, errors.New("chaincode event name is required")
+	}
+
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              fmt.Sprintf("%s-%d", event.TxID, event.TxIndex),
+		Source:          c.ccSource(event.ChaincodeID),
+		Type:            c.typePrefix + ccEventType + event.EventName,
+		Subject:         event.TxID,
+		Time:            blockTime,
+		DataContentType: detectContentType(event.Payload),
+		Data:            event.Payload,
+		Extensions: map[string]string{
+			ExtBlockNumber: strconv.FormatUint(event.BlockNumber, 10),
+			ExtTxIndex:     strconv.Itoa(event.TxIndex),
+			ExtSourceURL:   event.SourceURL,
+		},
+	}, nil
+}
+
+// ToCCEvent converts an event created by FromCCEvent back to a chaincode event
+func (c *Converter) ToCCEvent(e *Event) (*fab.CCEvent, error) {
+	prefix := c.typePrefix + ccEventType
+	if !strings.HasPrefix(e.Type, prefix) {
+		return nil, errors.Errorf("event type [%s] isn't a chaincode event type", e.Type)
+	}
+
+	ccID, err := c.parseCCSource(e.Source)
+	if err != nil {
+		return nil, err
+	}
+	blockNum, err := parseUintExtension(e, ExtBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	txIndex, err := parseUintExtension(e, ExtTxIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fab.CCEvent{
+		TxID:        e.Subject,
+		ChaincodeID: ccID,
+		EventName:   strings.TrimPrefix(e.Type, prefix),
+		Payload:     e.Data,
+		BlockNumber: blockNum,
+		TxIndex:     int(txIndex),
+		SourceURL:   e.Extensions[ExtSourceURL],
+	}, nil
+}
+
+// FromBlockEvent converts a block event. The source of the event is the channel (/channels/<channel>) and its ID
+// the block number. The time of the event is the time of the block (see BlockTime) and its data the block,
+// marshalled to protobuf.
+func (c *Converter) FromBlockEvent(event *fab.BlockEvent) (*Event, error) {
+	if event.Block == nil || event.Block.Header == nil {
+		return nil, errors.New("block is required")
+	}
+
+	blockTime, err := BlockTime(event.Block)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(event.Block)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of block failed")
+	}
+
+	blockNum := strconv.FormatUint(event.Block.Header.Number, 10)
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              blockNum,
+		Source:          c.channelSource(),
+		Type:            c.typePrefix + blockType,
+		Time:            blockTime,
+		DataContentType: protobufContentType,
+		Data:            data,
+		Extensions: map[string]string{
+			ExtBlockNumber: blockNum,
+			ExtSourceURL:   event.SourceURL,
+		},
+	}, nil
+}
+
+// ToBlockEvent converts an event created by FromBlockEvent back to a block event
+func (c *Converter) ToBlockEvent(e *Event) (*fab.BlockEvent, error) {
+	if e.Type != c.typePrefix+blockType {
+		return nil, errors.Errorf("event type [%s] isn't a block event type", e.Type)
+	}
+	if e.Source != c.channelSource() {
+		return nil, errors.Errorf("event source [%s] isn't the source of channel [%s]", e.Source, c.channelID)
+	}
+
+	block := &cb.Block{}
+	if err := proto.Unmarshal(e.Data, block); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of block failed")
+	}
+	return &fab.BlockEvent{Block: block, SourceURL: e.Extensions[ExtSourceURL]}, nil
+}
+
+// txStatus is the data of a transaction status event
+type txStatus struct {
+	ValidationCode string `json:"validationCode"`
+}
+
+// FromTxStatusEvent converts a transaction status event. The source of the event is the channel
+// (/channels/<channel>), its ID and subject the transaction ID, and its data the validation code of the
+// transaction, e.g. {"validationCode":"VALID"}. The time of the event is blockTime, omitted if zero.
+func (c *Converter) FromTxStatusEvent(event *fab.TxStatusEvent, blockTime time.Time) (*Event, error) {
+	if event.TxID == "" {
+		return nil, errors.New("transaction ID is required")
+	}
+
+	data, err := json.Marshal(&txStatus{ValidationCode: event.TxValidationCode.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of transaction status failed")
+	}
+
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              event.TxID,
+		Source:          c.channelSource(),
+		Type:            c.typePrefix + txStatusType,
+		Subject:         event.TxID,
+		Time:            blockTime,
+		DataContentType: jsonContentType,
+		Data:            data,
+		Extensions: map[string]string{
+			ExtBlockNumber: strconv.FormatUint(event.BlockNumber, 10),
+			ExtSourceURL:   event.SourceURL,
+		},
+	}, nil
+}
+
+// ToTxStatusEvent converts an event created by FromTxStatusEvent back to a transaction status event
+func (c *Converter) ToTxStatusEvent(e *Event) (*fab.TxStatusEvent, error) {
+	if e.Type != c.typePrefix+txStatusType {
+		return nil, errors.Errorf("event type [%s] isn't a transaction status event type", e.Type)
+	}
+	if e.Source != c.channelSource() {
+		return nil, errors.Errorf("event source [%s] isn't the source of channel [%s]", e.Source, c.channelID)
+	}
+
+	status := txStatus{}
+	if err := json.Unmarshal(e.Data, &status); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of transaction status failed")
+	}
+	code, ok := pb.TxValidationCode_value[status.ValidationCode]
+	if !ok {
+		return nil, errors.Errorf("invalid validation code [%s]", status.ValidationCode)
+	}
+	blockNum, err := parseUintExtension(e, ExtBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fab.TxStatusEvent{
+		TxID:             e.Subject,
+		TxValidationCode: pb.TxValidationCode(code),
+		BlockNumber:      blockNum,
+		SourceURL:        e.Extensions[ExtSourceURL],
+	}, nil
+}
+
+// BlockTime returns the time of the block, i.e. the timestamp of the channel header of its first transaction,
+// or the zero time if the block has no transactions or the transaction has no timestamp
+func BlockTime(block *cb.Block) (time.Time, error) {
+	if block.Data == nil || len(block.Data.Data) == 0 {
+		return time.Time{}, nil
+	}
+
+	env, err := utils.GetEnvelopeFromBlock(block.Data.Data[0])
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error extracting Envelope from block")
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error extracting Payload from envelope")
+	}
+	if payload.Header == nil {
+		return time.Time{}, errors.New("payload header is missing")
+	}
+	channelHeader, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error extracting ChannelHeader from payload")
+	}
+	if channelHeader.Timestamp == nil {
+		return time.Time{}, nil
+	}
+
+	t, err := ptypes.Timestamp(channelHeader.Timestamp)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid timestamp in channel header")
+	}
+	return t, nil
+}
+
+func (c *Converter) channelSource() string {
+	return "/channels/" + url.PathEscape(c.channelID)
+}
+
+func (c *Converter) ccSource(ccID string) string {
+	return c.channelSource() + "/chaincodes/" + url.PathEscape(ccID)
+}
+
+//parseCCSource returns the chaincode ID of the source of a chaincode event of the channel
+func (c *Converter) parseCCSource(source string) (string, error) {
+	prefix := c.channelSource() + "/chaincodes/"
+	if !strings.HasPrefix(source, prefix) {
+		return "", errors.Errorf("event source [%s] isn't a chaincode source of channel [%s]", source, c.channelID)
+	}
+	ccID, err := url.PathUnescape(strings.TrimPrefix(source, prefix))
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid chaincode in event source [%s]", source)
+	}
+	return ccID, nil
+}
+
+func parseUintExtension(e *Event, name string) (uint64, error) {
+	value, ok := e.Extensions[name]
+	if !ok {
+		return 0, errors.Errorf("extension attribute [%s] is missing", name)
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid extension attribute [%s]", name)
+	}
+	return n, nil
+}
+
+//detectContentType returns the content type of the payload: JSON, or the type sniffed from the content, e.g.
+//text/plain; charset=utf-8 or application/octet-stream
+func detectContentType(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	if json.Valid(payload) {
+		return jsonContentType
+	}
+	return http.DetectContentType(payload)
+}
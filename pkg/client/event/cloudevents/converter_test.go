@@ -0,0 +1,225 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cloudevents
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	channelID = "mychannel"
+	peerURL   = "grpcs://peer0.org1.example.com:7051"
+)
+
+var blockTime = time.Date(2018, time.June, 12, 10, 30, 15, 500000000, time.UTC)
+
+func TestCCEvent(t *testing.T) {
+	c := newConverter(t)
+
+	tests := []struct {
+		golden      string
+		payload     []byte
+		contentType string
+	}{
+		{golden: "ccevent_json.json", payload: []byte(`{"from":"a","to":"b","amount":10}`), contentType: "application/json"},
+		{golden: "ccevent_text.json", payload: []byte("transferred 10 from a to b"), contentType: "text/plain; charset=utf-8"},
+		{golden: "ccevent_binary.json", payload: []byte{0x00, 0x01, 0xfe, 0xff}, contentType: "application/octet-stream"},
+	}
+	for _, test := range tests {
+		ccEvent := &fab.CCEvent{
+			TxID:        "txid1",
+			ChaincodeID: "example cc",
+			EventName:   "transfer",
+			Payload:     test.payload,
+			BlockNumber: 5,
+			TxIndex:     2,
+			SourceURL:   peerURL,
+		}
+
+		event, err := c.FromCCEvent(ccEvent, blockTime)
+		if err != nil {
+			t.Fatalf("Failed to convert chaincode event: %s", err)
+		}
+		assert.Equal(t, test.contentType, event.DataContentType)
+		assertGolden(t, test.golden, event)
+
+		converted, err := c.ToCCEvent(unmarshalGolden(t, test.golden))
+		if err != nil {
+			t.Fatalf("Failed to convert event to chaincode event: %s", err)
+		}
+		assert.Equal(t, ccEvent, converted)
+	}
+
+	// The payload of filtered chaincode events is nil
+	event, err := c.FromCCEvent(&fab.CCEvent{TxID: "txid1", ChaincodeID: "mycc", EventName: "transfer"}, time.Time{})
+	assert.Nil(t, err)
+	data, err := json.Marshal(event)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), `"data`)
+	assert.NotContains(t, string(data), `"time"`)
+
+	_, err = c.FromCCEvent(&fab.CCEvent{TxID: "txid1", ChaincodeID: "mycc"}, blockTime)
+	assert.Error(t, err, "Expected error for event without name")
+
+	other, err := New("otherchannel")
+	assert.Nil(t, err)
+	_, err = other.ToCCEvent(unmarshalGolden(t, "ccevent_json.json"))
+	assert.Error(t, err, "Expected error for event of another channel")
+	_, err = c.ToBlockEvent(unmarshalGolden(t, "ccevent_json.json"))
+	assert.Error(t, err, "Expected error for event of another type")
+}
+
+func TestBlockEvent(t *testing.T) {
+	c := newConverter(t)
+
+	block := newBlock(t, 5, "txid1", "txid2")
+	event, err := c.FromBlockEvent(&fab.BlockEvent{Block: block, SourceURL: peerURL})
+	if err != nil {
+		t.Fatalf("Failed to convert block event: %s", err)
+	}
+	assert.Equal(t, blockTime, event.Time)
+	assertGolden(t, "block.json", event)
+
+	blockEvent, err := c.ToBlockEvent(unmarshalGolden(t, "block.json"))
+	if err != nil {
+		t.Fatalf("Failed to convert event to block event: %s", err)
+	}
+	assert.True(t, proto.Equal(block, blockEvent.Block), "Expected the same block")
+	assert.Equal(t, peerURL, blockEvent.SourceURL)
+
+	_, err = c.FromBlockEvent(&fab.BlockEvent{})
+	assert.Error(t, err, "Expected error for event without block")
+}
+
+func TestTxStatusEvent(t *testing.T) {
+	c := newConverter(t, WithTypePrefix("com.example.ledger."))
+
+	txStatusEvent := &fab.TxStatusEvent{
+		TxID:             "txid1",
+		TxValidationCode: pb.TxValidationCode_MVCC_READ_CONFLICT,
+		BlockNumber:      5,
+		SourceURL:        peerURL,
+	}
+	event, err := c.FromTxStatusEvent(txStatusEvent, blockTime)
+	if err != nil {
+		t.Fatalf("Failed to convert transaction status event: %s", err)
+	}
+	assertGolden(t, "txstatus.json", event)
+
+	converted, err := c.ToTxStatusEvent(unmarshalGolden(t, "txstatus.json"))
+	if err != nil {
+		t.Fatalf("Failed to convert event to transaction status event: %s", err)
+	}
+	assert.Equal(t, txStatusEvent, converted)
+
+	_, err = newConverter(t).ToTxStatusEvent(unmarshalGolden(t, "txstatus.json"))
+	assert.Error(t, err, "Expected error for event type with another prefix")
+}
+
+func TestEventJSON(t *testing.T) {
+	event := &Event{}
+	err := json.Unmarshal([]byte(`{"specversion":"0.3","id":"1","source":"/test","type":"test"}`), event)
+	assert.Error(t, err, "Expected error for unsupported spec version")
+
+	err = json.Unmarshal([]byte(`{"specversion":"1.0","id":"1","source":"/test","type":"test","count":3,"data":"text"}`), event)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"count": "3"}, event.Extensions)
+	assert.Equal(t, []byte("text"), event.Data)
+
+	_, err = json.Marshal(&Event{ID: "1", Source: "/test"})
+	assert.Error(t, err, "Expected error for event without type")
+
+	_, err = json.Marshal(&Event{ID: "1", Source: "/test", Type: "test", Extensions: map[string]string{"id": "2"}})
+	assert.Error(t, err, "Expected error for extension conflicting with context attribute")
+
+	_, err = json.Marshal(&Event{ID: "1", Source: "/test", Type: "test", DataContentType: "application/json", Data: []byte("{")})
+	assert.Error(t, err, "Expected error for invalid JSON data")
+
+	_, err = New("")
+	assert.Error(t, err, "Expected error for empty channel ID")
+	_, err = New(channelID, WithTypePrefix(""))
+	assert.Error(t, err, "Expected error for empty type prefix")
+}
+
+func newConverter(t *testing.T, opts ...Option) *Converter {
+	c, err := New(channelID, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create converter: %s", err)
+	}
+	return c
+}
+
+//assertGolden asserts that the event marshals to the JSON of the golden file in testdata
+func assertGolden(t *testing.T, golden string, event *Event) {
+	expected, err := ioutil.ReadFile(filepath.Join("testdata", golden))
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %s", err)
+	}
+	actual, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %s", err)
+	}
+	assert.JSONEq(t, string(expected), string(actual), "Event doesn't match golden file [%s]", golden)
+}
+
+func unmarshalGolden(t *testing.T, golden string) *Event {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", golden))
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %s", err)
+	}
+	event := &Event{}
+	if err := json.Unmarshal(data, event); err != nil {
+		t.Fatalf("Failed to unmarshal golden file [%s]: %s", golden, err)
+	}
+	return event
+}
+
+//newBlock returns a block of endorser transactions timestamped with blockTime
+func newBlock(t *testing.T, number uint64, txIDs ...string) *cb.Block {
+	timestamp, err := ptypes.TimestampProto(blockTime)
+	if err != nil {
+		t.Fatalf("Failed to create timestamp: %s", err)
+	}
+
+	data := &cb.BlockData{}
+	for _, txID := range txIDs {
+		channelHeader := &cb.ChannelHeader{
+			Type:      int32(cb.HeaderType_ENDORSER_TRANSACTION),
+			ChannelId: channelID,
+			TxId:      txID,
+			Timestamp: timestamp,
+		}
+		payload := &cb.Payload{Header: &cb.Header{ChannelHeader: marshalOrFail(t, channelHeader)}}
+		data.Data = append(data.Data, marshalOrFail(t, &cb.Envelope{Payload: marshalOrFail(t, payload)}))
+	}
+
+	return &cb.Block{
+		Header:   &cb.BlockHeader{Number: number},
+		Data:     data,
+		Metadata: &cb.BlockMetadata{Metadata: [][]byte{{}, {}, {0, 0}, {}}},
+	}
+}
+
+func marshalOrFail(t *testing.T, msg proto.Message) []byte {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %s", err)
+	}
+	return data
+}
@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// SpecVersion is the version of the CloudEvents specification of the events
+const SpecVersion = "1.0"
+
+// Event is a CloudEvents event. It's marshalled to (and unmarshalled from) the structured JSON format of the
+// CloudEvents specification: the data is embedded as JSON if its content type is JSON, as a string if it's text,
+// and base64 encoded in the data_base64 attribute otherwise. JSON data is unmarshalled in its compact form.
+type Event struct {
+	// SpecVersion is the version of the CloudEvents specification
+	SpecVersion string
+	// ID identifies the event among the events of its source
+	ID string
+	// Source identifies the context in which the event happened
+	Source string
+	// Type is the type of the event
+	Type string
+	// Subject is the subject of the event within the context of its source
+	Subject string
+	// Time is the time at which the event happened, omitted if zero
+	Time time.Time
+	// DataContentType is the media type of the data
+	DataContentType string
+	// Data is the payload of the event
+	Data []byte
+	// Extensions holds the extension attributes of the event
+	Extensions map[string]string
+}
+
+const (
+	attrSpecVersion     = "specversion"
+	attrID              = "id"
+	attrSource          = "source"
+	attrType            = "type"
+	attrSubject         = "subject"
+	attrTime            = "time"
+	attrDataContentType = "datacontenttype"
+	attrData            = "data"
+	attrDataBase64      = "data_base64"
+)
+
+var contextAttributes = map[string]bool{
+	attrSpecVersion: true, attrID: true, attrSource: true, attrType: true, attrSubject: true,
+	attrTime: true, attrDataContentType: true, attrData: true, attrDataBase64: true,
+}
+
+// MarshalJSON marshals the event to the structured JSON format
+func (e *Event) MarshalJSON() ([]byte, error) {
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return nil, errors.New("id, source and type of the event are required")
+	}
+
+	attrs := make(map[string]interface{})
+	for name, value := range e.Extensions {
+		if contextAttributes[name] {
+			return nil, errors.Errorf("extension attribute [%s] conflicts with a context attribute", name)
+		}
+		attrs[name] = value
+	}
+
+	attrs[attrSpecVersion] = e.SpecVersion
+	if e.SpecVersion == "" {
+		attrs[attrSpecVersion] = SpecVersion
+	}
+	attrs[attrID] = e.ID
+	attrs[attrSource] = e.Source
+	attrs[attrType] = e.Type
+	if e.Subject != "" {
+		attrs[attrSubject] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		attrs[attrTime] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if e.DataContentType != "" {
+		attrs[attrDataContentType] = e.DataContentType
+	}
+
+	if len(e.Data) > 0 {
+		switch {
+		case isJSON(e.DataContentType):
+			if !json.Valid(e.Data) {
+				return nil, errors.Errorf("data of content type [%s] isn't valid JSON", e.DataContentType)
+			}
+			attrs[attrData] = json.RawMessage(e.Data)
+		case isText(e.DataContentType) && utf8.Valid(e.Data):
+			attrs[attrData] = string(e.Data)
+		default:
+			attrs[attrDataBase64] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(attrs)
+}
+
+// UnmarshalJSON unmarshals the event from the structured JSON format
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return errors.Wrap(err, "unmarshal of event failed")
+	}
+
+	event := Event{}
+	strAttrs := map[string]*string{
+		attrSpecVersion:     &event.SpecVersion,
+		attrID:              &event.ID,
+		attrSource:          &event.Source,
+		attrType:            &event.Type,
+		attrSubject:         &event.Subject,
+		attrDataContentType: &event.DataContentType,
+	}
+	for name, value := range strAttrs {
+		raw, ok := attrs[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, value); err != nil {
+			return errors.Wrapf(err, "unmarshal of attribute [%s] failed", name)
+		}
+	}
+	if event.SpecVersion != SpecVersion {
+		return errors.Errorf("unsupported spec version [%s]", event.SpecVersion)
+	}
+
+	if raw, ok := attrs[attrTime]; ok {
+		var t string
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return errors.Wrap(err, "unmarshal of attribute [time] failed")
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return errors.Wrap(err, "parsing time of the event failed")
+		}
+		event.Time = parsed
+	}
+
+	if raw, ok := attrs[attrDataBase64]; ok {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return errors.Wrap(err, "unmarshal of attribute [data_base64] failed")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return errors.Wrap(err, "decoding data of the event failed")
+		}
+		event.Data = decoded
+	} else if raw, ok := attrs[attrData]; ok {
+		var text string
+		if !isJSON(event.DataContentType) && json.Unmarshal(raw, &text) == nil {
+			event.Data = []byte(text)
+		} else {
+			compact := &bytes.Buffer{}
+			if err := json.Compact(compact, raw); err != nil {
+				return errors.Wrap(err, "unmarshal of data of the event failed")
+			}
+			event.Data = compact.Bytes()
+		}
+	}
+
+	for name, raw := range attrs {
+		if contextAttributes[name] {
+			continue
+		}
+		if event.Extensions == nil {
+			event.Extensions = make(map[string]string)
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// Extensions of other types are kept in their JSON form
+			value = string(raw)
+		}
+		event.Extensions[name] = value
+	}
+
+	*e = event
+	return nil
+}
+
+//isJSON returns true if the content type is JSON, e.g. application/json or application/cloudevents+json
+func isJSON(contentType string) bool {
+	mediaType := parseMediaType(contentType)
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+//isText returns true if the content type is text
+func isText(contentType string) bool {
+	return strings.HasPrefix(parseMediaType(contentType), "text/")
+}
+
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
@@ -0,0 +1,118 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.NewLogger("fabsdk/client")
+
+// ConfigChangeEvent is received when a config block updates the channel configuration
+type ConfigChangeEvent struct {
+	BlockNumber uint64
+	SourceURL   string
+	OldSequence uint64
+	NewSequence uint64
+	// Changed lists the config sections which were added, removed or modified by the update (see chconfig.Diff)
+	Changed []string
+	// Config is the updated channel configuration
+	Config fab.ChannelCfg
+}
+
+// configQuerier queries the current configuration of the channel
+type configQuerier func() (fab.ChannelCfg, error)
+
+// newConfigQuerier returns a config querier which queries the configuration from the channel's peers
+func newConfigQuerier(channelContext context.Channel) configQuerier {
+	return func() (fab.ChannelCfg, error) {
+		chConfig, err := channelContext.ChannelService().Config()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get channel config")
+		}
+
+		reqCtx, cancel := contextImpl.NewRequest(channelContext, contextImpl.WithTimeoutType(fab.PeerResponse))
+		defer cancel()
+
+		return chConfig.Query(reqCtx)
+	}
+}
+
+// RegisterConfigChangeEvent registers for changes of the channel configuration. When a config block is committed,
+// the configuration is queried from the channel's peers and an event is received if its sequence changed, so that
+// applications know that a pending config update computed from the previous configuration may now conflict.
+// Unregister must be called when the registration is no longer needed.
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterConfigChangeEvent() (fab.Registration, <-chan *ConfigChangeEvent, error) {
+	current, err := c.queryConfig()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to query channel config")
+	}
+
+	reg, blockch, err := c.eventService.RegisterFilteredBlockEvent()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventch := make(chan *ConfigChangeEvent)
+	go c.notifyConfigChanges(current, blockch, eventch)
+
+	return reg, eventch, nil
+}
+
+// notifyConfigChanges sends an event for each config block which changes the config sequence,
+// until the block event channel is closed
+func (c *Client) notifyConfigChanges(current fab.ChannelCfg, blockch <-chan *fab.FilteredBlockEvent, eventch chan<- *ConfigChangeEvent) {
+	defer close(eventch)
+
+	for event := range blockch {
+		if !hasConfigTx(event.FilteredBlock) {
+			continue
+		}
+
+		cfg, err := c.queryConfig()
+		if err != nil {
+			logger.Warnf("Failed to query channel config after config block %d: %s", event.FilteredBlock.Number, err)
+			continue
+		}
+		if cfg.Sequence() == current.Sequence() {
+			logger.Debugf("Config sequence %d unchanged by config block %d", cfg.Sequence(), event.FilteredBlock.Number)
+			continue
+		}
+
+		eventch <- &ConfigChangeEvent{
+			BlockNumber: event.FilteredBlock.Number,
+			SourceURL:   event.SourceURL,
+			OldSequence: current.Sequence(),
+			NewSequence: cfg.Sequence(),
+			Changed:     chconfig.Diff(current.Versions(), cfg.Versions()),
+			Config:      cfg,
+		}
+		current = cfg
+	}
+}
+
+// hasConfigTx returns true if the block contains a valid config transaction
+func hasConfigTx(block *pb.FilteredBlock) bool {
+	if block == nil {
+		return false
+	}
+	for _, tx := range block.FilteredTransactions {
+		if tx.Type == common.HeaderType_CONFIG && tx.TxValidationCode == pb.TxValidationCode_VALID {
+			return true
+		}
+	}
+	return false
+}
@@ -15,16 +15,49 @@ SPDX-License-Identifier: Apache-2.0
 package event
 
 import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	"github.com/pkg/errors"
 )
 
 // Client enables access to a channel events on a Fabric network.
+// An event client is safe for concurrent use by multiple goroutines.
 type Client struct {
 	eventService      fab.EventService
 	permitBlockEvents bool
+	replayProvider    replayProvider
+	queryConfig       configQuerier
+	checkpointer      Checkpointer
+}
+
+// replayProvider returns a dedicated, not yet connected, event client which receives the blocks of the channel
+// starting from the given block number. The options are passed on to the deliver client.
+type replayProvider func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error)
+
+// replayRegistration is the registration of events replayed by a dedicated event client. If the events are
+// forwarded to the application, stop is closed upon Unregister and stopped once the forwarding stopped.
+type replayRegistration struct {
+	fab.Registration
+	eventClient fab.EventClient
+	stop        chan struct{}
+	stopped     chan struct{}
+	once        sync.Once
+	releaseOnce sync.Once
+}
+
+// release unregisters from the dedicated event client and closes it
+func (r *replayRegistration) release() {
+	r.releaseOnce.Do(func() {
+		r.eventClient.Unregister(r.Registration)
+		r.eventClient.Close()
+	})
 }
 
 // New returns a Client instance. Client receives events such as block, filtered block,
@@ -41,7 +74,7 @@ func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client
 	for _, param := range opts {
 		err1 := param(&eventClient)
 		if err1 != nil {
-			return nil, errors.WithMessage(err, "option failed")
+			return nil, errors.WithMessage(err1, "option failed")
 		}
 	}
 
@@ -61,29 +94,126 @@ func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client
 	}
 
 	eventClient.eventService = es
+	eventClient.replayProvider = newDeliverReplayProvider(channelContext)
+	eventClient.queryConfig = newConfigQuerier(channelContext)
 
 	return &eventClient, nil
 }
 
+// newDeliverReplayProvider returns a replay provider which seeks the Deliver service of the channel's peers from the given block
+func newDeliverReplayProvider(channelContext context.Channel) replayProvider {
+	return func(fromBlock uint64, permitBlockEvents bool, replayOpts ...options.Opt) (fab.EventClient, error) {
+		if channelContext.EndpointConfig().EventServiceType() != fab.DeliverEventServiceType {
+			return nil, errors.New("replaying events is only supported by the deliver event service")
+		}
+
+		chConfig, err := channelContext.ChannelService().ChannelConfig()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get channel config")
+		}
+
+		opts := []options.Opt{
+			deliverclient.WithSeekType(seek.FromBlock),
+			deliverclient.WithBlockNum(fromBlock),
+			// Block rather than drop events when the consumer is slow, so that no replayed event is lost
+			dispatcher.WithEventConsumerTimeout(0),
+		}
+		if permitBlockEvents {
+			opts = append(opts, client.WithBlockEvents())
+		}
+		opts = append(opts, replayOpts...)
+		return deliverclient.New(channelContext, chConfig, opts...)
+	}
+}
+
 // RegisterBlockEvent registers for block events. If the caller does not have permission
 // to register for block events then an error is returned. Unregister must be called when the registration is no longer needed.
+// The registration resumes from the checkpoint if the client has a checkpointer (see WithCheckpointer).
 //  Parameters:
 //  filter is an optional filter that filters out unwanted events. (Note: Only one filter may be specified.)
 //
 //  Returns:
 //  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
 func (c *Client) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
-	return c.eventService.RegisterBlockEvent(filter...)
+	if c.checkpointer == nil {
+		return c.eventService.RegisterBlockEvent(filter...)
+	}
+
+	blockch := make(chan *fab.BlockEvent)
+	reg, err := c.registerCheckpointed(
+		func(eventClient fab.EventClient, stop <-chan struct{}) (fab.Registration, <-chan interface{}, error) {
+			reg, eventch, err := eventClient.RegisterBlockEvent(filter...)
+			if err != nil {
+				return nil, nil, err
+			}
+			return reg, toEvents(stop, func() (interface{}, bool) {
+				event, ok := <-eventch
+				return event, ok
+			}), nil
+		},
+		func(event interface{}) (uint64, int) {
+			block := event.(*fab.BlockEvent).Block
+			return block.Header.Number, len(block.Data.Data) - 1
+		},
+		func(event interface{}, stop <-chan struct{}) bool {
+			select {
+			case blockch <- event.(*fab.BlockEvent):
+				return true
+			case <-stop:
+				return false
+			}
+		},
+		func() { close(blockch) },
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, blockch, nil
 }
 
 // RegisterFilteredBlockEvent registers for filtered block events. Unregister must be called when the registration is no longer needed.
+// The registration resumes from the checkpoint if the client has a checkpointer (see WithCheckpointer).
 //  Returns:
 //  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
 func (c *Client) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
-	return c.eventService.RegisterFilteredBlockEvent()
+	if c.checkpointer == nil {
+		return c.eventService.RegisterFilteredBlockEvent()
+	}
+
+	fblockch := make(chan *fab.FilteredBlockEvent)
+	reg, err := c.registerCheckpointed(
+		func(eventClient fab.EventClient, stop <-chan struct{}) (fab.Registration, <-chan interface{}, error) {
+			reg, eventch, err := eventClient.RegisterFilteredBlockEvent()
+			if err != nil {
+				return nil, nil, err
+			}
+			return reg, toEvents(stop, func() (interface{}, bool) {
+				event, ok := <-eventch
+				return event, ok
+			}), nil
+		},
+		func(event interface{}) (uint64, int) {
+			fblock := event.(*fab.FilteredBlockEvent).FilteredBlock
+			return fblock.Number, len(fblock.FilteredTransactions) - 1
+		},
+		func(event interface{}, stop <-chan struct{}) bool {
+			select {
+			case fblockch <- event.(*fab.FilteredBlockEvent):
+				return true
+			case <-stop:
+				return false
+			}
+		},
+		func() { close(fblockch) },
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, fblockch, nil
 }
 
 // RegisterChaincodeEvent registers for chaincode events. Unregister must be called when the registration is no longer needed.
+// The registration resumes from the checkpoint if the client has a checkpointer (see WithCheckpointer).
 //  Parameters:
 //  ccID is the chaincode ID for which events are to be received
 //  eventFilter is the chaincode event filter (regular expression) for which events are to be received
@@ -91,7 +221,113 @@ func (c *Client) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.Fil
 //  Returns:
 //  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
 func (c *Client) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
-	return c.eventService.RegisterChaincodeEvent(ccID, eventFilter)
+	if c.checkpointer == nil {
+		return c.eventService.RegisterChaincodeEvent(ccID, eventFilter)
+	}
+
+	ccch := make(chan *fab.CCEvent)
+	reg, err := c.registerCheckpointed(
+		func(eventClient fab.EventClient, stop <-chan struct{}) (fab.Registration, <-chan interface{}, error) {
+			reg, eventch, err := eventClient.RegisterChaincodeEvent(ccID, eventFilter)
+			if err != nil {
+				return nil, nil, err
+			}
+			return reg, toEvents(stop, func() (interface{}, bool) {
+				event, ok := <-eventch
+				return event, ok
+			}), nil
+		},
+		func(event interface{}) (uint64, int) {
+			ccEvent := event.(*fab.CCEvent)
+			return ccEvent.BlockNumber, ccEvent.TxIndex
+		},
+		func(event interface{}, stop <-chan struct{}) bool {
+			select {
+			case ccch <- event.(*fab.CCEvent):
+				return true
+			case <-stop:
+				return false
+			}
+		},
+		func() { close(ccch) },
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, ccch, nil
+}
+
+// RegisterChaincodeEventFrom registers for chaincode events, starting with the events committed in the given block.
+// The events of the blocks which were already committed are replayed before the live events, and all events are
+// received in the order in which they were committed, i.e. by block number and transaction index.
+// The events are received from a dedicated connection to the Deliver service, which is closed upon Unregister.
+// Note that the payloads of the events are only received if the client was created WithBlockEvents, since
+// filtered blocks don't contain them.
+//  Parameters:
+//  ccID is the chaincode ID for which events are to be received
+//  eventFilter is the chaincode event filter (regular expression) for which events are to be received
+//  fromBlock is the number of the block from which events are to be received
+//
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterChaincodeEventFrom(ccID, eventFilter string, fromBlock uint64) (fab.Registration, <-chan *fab.CCEvent, error) {
+	var eventch <-chan *fab.CCEvent
+	reg, err := c.registerFrom(fromBlock, func(eventClient fab.EventClient) (fab.Registration, error) {
+		reg, ch, err := eventClient.RegisterChaincodeEvent(ccID, eventFilter)
+		eventch = ch
+		return reg, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, eventch, nil
+}
+
+// RegisterBlockEventFrom registers for block events, starting with the given block. The blocks which were already
+// committed are replayed before the live blocks, and all blocks are received in order, e.g. so that an indexer
+// backfills the blocks it missed and then keeps up with the channel without gaps or duplicates.
+// The blocks are received from a dedicated connection to the Deliver service, which is closed upon Unregister.
+// Note that the caller must have sufficient privileges (see WithBlockEvents).
+//  Parameters:
+//  fromBlock is the number of the first block to be received
+//  filter is an optional filter that filters out unwanted events. (Note: Only one filter may be specified.)
+//
+//  Returns:
+//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
+func (c *Client) RegisterBlockEventFrom(fromBlock uint64, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	var eventch <-chan *fab.BlockEvent
+	reg, err := c.registerFrom(fromBlock, func(eventClient fab.EventClient) (fab.Registration, error) {
+		reg, ch, err := eventClient.RegisterBlockEvent(filter...)
+		eventch = ch
+		return reg, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg, eventch, nil
+}
+
+// registerFrom registers with a dedicated event client which receives the blocks starting from the given block
+func (c *Client) registerFrom(fromBlock uint64, register func(eventClient fab.EventClient) (fab.Registration, error), opts ...options.Opt) (*replayRegistration, error) {
+	eventClient, err := c.replayProvider(fromBlock, c.permitBlockEvents, opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create event client for replay")
+	}
+
+	// Register before connecting so that none of the replayed events is missed
+	reg, err := register(eventClient)
+	if err != nil {
+		eventClient.Close()
+		return nil, err
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		eventClient.Unregister(reg)
+		eventClient.Close()
+		return nil, errors.WithMessage(err, "failed to connect event client for replay")
+	}
+
+	return &replayRegistration{Registration: reg, eventClient: eventClient}, nil
 }
 
 // RegisterTxStatusEvent registers for transaction status events. Unregister must be called when the registration is no longer needed.
@@ -108,5 +344,16 @@ func (c *Client) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *f
 //  Parameters:
 //  reg is the registration handle that was returned from one of the Register functions
 func (c *Client) Unregister(reg fab.Registration) {
+	if r, ok := reg.(*replayRegistration); ok {
+		r.once.Do(func() {
+			if r.stop != nil {
+				// The forwarding is stopped first, so that the channel is closed even if the application stopped reading
+				close(r.stop)
+				<-r.stopped
+			}
+			r.release()
+		})
+		return
+	}
 	c.eventService.Unregister(reg)
 }
@@ -0,0 +1,108 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// TestConcurrentRegistrations registers and unregisters for events from many goroutines while blocks
+// are being received. It's meant to be run with the race detector.
+func TestConcurrentRegistrations(t *testing.T) {
+	chanID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID))
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+	client.eventService = eventService
+
+	done := make(chan struct{})
+	producerDone := make(chan struct{})
+	go func() {
+		defer close(producerDone)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				eventProducer.Ledger().NewFilteredBlock(
+					chanID,
+					servicemocks.NewFilteredTx(fmt.Sprintf("txid%d", i), pb.TxValidationCode_VALID),
+					servicemocks.NewFilteredTxWithCCEvent(fmt.Sprintf("txid%d", i), fmt.Sprintf("cc%d", i%20), "event"),
+				)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				ccReg, ccEventch, err := client.RegisterChaincodeEvent(fmt.Sprintf("cc%d", i), ".*")
+				if err != nil {
+					t.Errorf("error registering for chaincode events: %s", err)
+					return
+				}
+				txReg, txEventch, err := client.RegisterTxStatusEvent(fmt.Sprintf("txid%d", i*10+j))
+				if err != nil {
+					t.Errorf("error registering for TxStatus events: %s", err)
+					return
+				}
+				blockReg, blockEventch, err := client.RegisterFilteredBlockEvent()
+				if err != nil {
+					t.Errorf("error registering for filtered block events: %s", err)
+					return
+				}
+
+				drained := make(chan struct{})
+				go func() {
+					defer close(drained)
+					for ccEventch != nil || txEventch != nil || blockEventch != nil {
+						select {
+						case _, ok := <-ccEventch:
+							if !ok {
+								ccEventch = nil
+							}
+						case _, ok := <-txEventch:
+							if !ok {
+								txEventch = nil
+							}
+						case _, ok := <-blockEventch:
+							if !ok {
+								blockEventch = nil
+							}
+						}
+					}
+				}()
+
+				time.Sleep(time.Millisecond)
+				client.Unregister(ccReg)
+				client.Unregister(txReg)
+				client.Unregister(blockReg)
+				<-drained
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	<-producerDone
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -142,6 +143,76 @@ func TestFilteredBlockEvents(t *testing.T) {
 	}
 }
 
+func TestConfigChangeEvents(t *testing.T) {
+
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	fabCtx := setupCustomTestContext(t, nil)
+	ctx := createChannelContext(fabCtx, channelID)
+
+	client, err := New(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	client.eventService = eventService
+
+	configs := make(chan fab.ChannelCfg, 3)
+	configs <- mockChannelCfg(1, 0)
+	configs <- mockChannelCfg(1, 0)
+	configs <- mockChannelCfg(2, 1)
+	client.queryConfig = func() (fab.ChannelCfg, error) {
+		return <-configs, nil
+	}
+
+	registration, eventch, err := client.RegisterConfigChangeEvent()
+	if err != nil {
+		t.Fatalf("error registering for config change events: %s", err)
+	}
+	defer client.Unregister(registration)
+
+	configTx := &pb.FilteredTransaction{Txid: "1234", Type: common.HeaderType_CONFIG, TxValidationCode: pb.TxValidationCode_VALID}
+
+	// Neither a block without config transaction nor a config block which doesn't change the sequence is notified
+	eventProducer.Ledger().NewFilteredBlock(channelID, servicemocks.NewFilteredTx("5678", pb.TxValidationCode_VALID))
+	eventProducer.Ledger().NewFilteredBlock(channelID, configTx)
+	eventProducer.Ledger().NewFilteredBlock(channelID, configTx)
+
+	select {
+	case event, ok := <-eventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		assert.EqualValues(t, 2, event.BlockNumber)
+		assert.EqualValues(t, 1, event.OldSequence)
+		assert.EqualValues(t, 2, event.NewSequence)
+		assert.Equal(t, []string{"Channel/Application/Org1MSP/Values/AnchorPeers"}, event.Changed)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for config change event")
+	}
+	assert.Empty(t, configs, "Expected config to be queried upon config blocks only")
+}
+
+func mockChannelCfg(sequence, anchorPeersVersion uint64) fab.ChannelCfg {
+	cfg := fcmocks.NewMockChannelCfg(channelID)
+	cfg.MockSequence = sequence
+	cfg.MockVersions = &fab.Versions{Channel: &common.ConfigGroup{
+		Groups: map[string]*common.ConfigGroup{
+			"Application": {
+				Groups: map[string]*common.ConfigGroup{
+					"Org1MSP": {Values: map[string]*common.ConfigValue{"AnchorPeers": {Version: anchorPeersVersion}}},
+				},
+			},
+		},
+	}}
+	return cfg
+}
+
 func TestTxStatusEvents(t *testing.T) {
 	chanID := "mychannel"
 	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger(sourceURL))
@@ -304,6 +375,123 @@ func validateCCEvents(t *testing.T, eventProducer *servicemocks.MockProducer, ev
 	}
 }
 
+func TestCCEventsFromBlock(t *testing.T) {
+	chanID := "mychannel"
+	ccID := "mycc"
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory, sourceURL)
+	ledger.NewBlock(chanID, servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, ccID, "event1", []byte("p1")))
+	ledger.NewBlock(chanID,
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, ccID, "event2", []byte("p2")),
+		servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, "othercc", "event3", nil),
+		servicemocks.NewTransactionWithCCEvent("txid4", pb.TxValidationCode_VALID, ccID, "event4", []byte("p4")),
+	)
+
+	fabCtx := setupCustomTestContext(t, nil)
+	ctx := createChannelContext(fabCtx, chanID)
+
+	client, err := New(ctx, WithBlockEvents())
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	var replayClient *mockReplayClient
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		assert.True(t, permitBlockEvents, "Expected full blocks to be requested")
+		replayClient = newMockReplayClient(ledger, fromBlock)
+		return replayClient, replayClient.Start()
+	}
+
+	reg, eventch, err := client.RegisterChaincodeEventFrom(ccID, "event.*", 1)
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	// Live events follow the replayed events
+	ledger.NewBlock(chanID, servicemocks.NewTransactionWithCCEvent("txid5", pb.TxValidationCode_VALID, ccID, "event5", []byte("p5")))
+
+	expected := []struct {
+		txID     string
+		blockNum uint64
+		payload  string
+	}{
+		{"txid2", 1, "p2"},
+		{"txid4", 1, "p4"},
+		{"txid5", 2, "p5"},
+	}
+	for _, e := range expected {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			assert.Equal(t, e.txID, event.TxID)
+			assert.Equal(t, e.blockNum, event.BlockNumber)
+			assert.Equal(t, e.payload, string(event.Payload))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for CC event of %s", e.txID)
+		}
+	}
+
+	client.Unregister(reg)
+	_, ok := <-eventch
+	assert.False(t, ok, "Expected event channel to be closed upon Unregister")
+	assert.True(t, replayClient.closed, "Expected replay client to be closed upon Unregister")
+
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		return nil, errors.New("unsupported")
+	}
+	_, _, err = client.RegisterChaincodeEventFrom(ccID, "event.*", 1)
+	assert.Error(t, err)
+}
+
+// mockReplayClient sends the blocks of the ledger starting from the given block upon Connect
+type mockReplayClient struct {
+	*service.Service
+	ledger    *servicemocks.MockLedger
+	fromBlock uint64
+	producer  *servicemocks.MockProducer
+	closed    bool
+}
+
+func newMockReplayClient(ledger *servicemocks.MockLedger, fromBlock uint64) *mockReplayClient {
+	return &mockReplayClient{
+		Service:   service.New(dispatcher.New()),
+		ledger:    ledger,
+		fromBlock: fromBlock,
+	}
+}
+
+func (c *mockReplayClient) Connect() error {
+	eventch, err := c.Dispatcher().EventCh()
+	if err != nil {
+		return err
+	}
+
+	c.producer = servicemocks.NewMockProducer(c.ledger)
+	producerch := c.producer.Register()
+	go func() {
+		for event := range producerch {
+			eventch <- event
+		}
+	}()
+
+	c.ledger.SendFrom(c.fromBlock)
+	return nil
+}
+
+func (c *mockReplayClient) Close() {
+	c.closed = true
+	if c.producer != nil {
+		c.producer.Close()
+	}
+	c.Stop()
+}
+
+func (c *mockReplayClient) CloseIfIdle() bool {
+	c.Close()
+	return true
+}
+
 func checkCCEvent(t *testing.T, event *fab.CCEvent, expectedCCID string, expectedEventNames ...string) {
 	if event.ChaincodeID != expectedCCID {
 		t.Fatalf("expecting event for CC [%s] but received event for CC [%s]", expectedCCID, event.ChaincodeID)
@@ -0,0 +1,131 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	"github.com/pkg/errors"
+)
+
+// ReplayOption describes a functional parameter of ReplayBlocks
+type ReplayOption func(*replayOpts) error
+
+type replayOpts struct {
+	failIfNotReady bool
+}
+
+// WithFailIfNotReady fails the replay when it reaches a block which isn't committed yet, rather than wait for
+// the block to be committed
+func WithFailIfNotReady() ReplayOption {
+	return func(o *replayOpts) error {
+		o.failIfNotReady = true
+		return nil
+	}
+}
+
+// ReplayBlocks receives the blocks of the given range, in order, and closes the channel once the last block of the
+// range was received, so that an indexer may backfill the blocks it missed before registering for the live blocks
+// (see RegisterBlockEventFrom). The Deliver service is asked for the blocks of the range only, so the replayed
+// blocks are never mixed with live blocks. If the range extends beyond the blocks committed so far, the replay
+// waits for the missing blocks to be committed, unless WithFailIfNotReady is given.
+// The blocks are received from a dedicated connection to the Deliver service, which is closed when the replay ends.
+// The replay may be stopped before the end of the range with Unregister, e.g. if the application stops reading.
+// Note that the caller must have sufficient privileges (see WithBlockEvents).
+//  Parameters:
+//  fromBlock is the number of the first block of the range
+//  toBlock is the number of the last block of the range
+//  opts are the replay options
+//
+//  Returns:
+//  the registration, a channel that is used to receive the blocks, and a channel that receives the error which
+//  ended the replay before the last block of the range, e.g. a block which isn't committed yet with
+//  WithFailIfNotReady. Both channels are closed when the replay ends or when Unregister is called.
+func (c *Client) ReplayBlocks(fromBlock, toBlock uint64, opts ...ReplayOption) (fab.Registration, <-chan *fab.BlockEvent, <-chan error, error) {
+	if toBlock < fromBlock {
+		return nil, nil, nil, errors.Errorf("invalid block range [%d, %d]", fromBlock, toBlock)
+	}
+
+	o := replayOpts{}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "failed to apply replay option")
+		}
+	}
+
+	seekOpts := []options.Opt{deliverclient.WithStopBlockNum(toBlock)}
+	if o.failIfNotReady {
+		// The Deliver service fails the request for a block which isn't ready, so retrying it is pointless
+		seekOpts = append(seekOpts, deliverclient.WithFailIfNotReady(), client.WithReconnect(false))
+	}
+
+	var eventch <-chan *fab.BlockEvent
+	reg, err := c.registerFrom(fromBlock, func(eventClient fab.EventClient) (fab.Registration, error) {
+		reg, ch, err := eventClient.RegisterBlockEvent()
+		eventch = ch
+		return reg, err
+	}, seekOpts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	reg.stop = make(chan struct{})
+	reg.stopped = make(chan struct{})
+
+	blockch := make(chan *fab.BlockEvent)
+	errch := make(chan error, 1)
+	go func() {
+		defer close(reg.stopped)
+		defer close(errch)
+		defer close(blockch)
+		// The connection is released once the replay ended, before the channels are closed. The remaining blocks
+		// are drained until the registration is removed, so that the event client isn't blocked.
+		defer func() {
+			go func() {
+				for range eventch {
+				}
+			}()
+			reg.release()
+		}()
+
+		next := fromBlock
+		for {
+			var event *fab.BlockEvent
+			var ok bool
+			select {
+			case event, ok = <-eventch:
+			case <-reg.stop:
+				return
+			}
+			if !ok {
+				break
+			}
+
+			number := event.Block.Header.Number
+			if number < next {
+				// Already received, e.g. before a reconnection
+				continue
+			}
+			if number > toBlock {
+				break
+			}
+			select {
+			case blockch <- event:
+			case <-reg.stop:
+				return
+			}
+			if number == toBlock {
+				return
+			}
+			next = number + 1
+		}
+		errch <- errors.Errorf("replay of blocks [%d, %d] ended before block [%d]", fromBlock, toBlock, next)
+	}()
+
+	return reg, blockch, errch, nil
+}
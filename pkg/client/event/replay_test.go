@@ -0,0 +1,164 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestBlockEventsFromBlock(t *testing.T) {
+	chanID := "mychannel"
+	ledger := newReplayLedger(chanID, 3)
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID), WithBlockEvents())
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	var replayClient *mockReplayClient
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		assert.True(t, permitBlockEvents, "Expected full blocks to be requested")
+		assert.Empty(t, opts, "Expected unbounded replay")
+		replayClient = newMockReplayClient(ledger, fromBlock)
+		return replayClient, replayClient.Start()
+	}
+
+	reg, eventch, err := client.RegisterBlockEventFrom(1)
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+
+	// Live blocks follow the replayed blocks
+	ledger.NewBlock(chanID, servicemocks.NewTransaction("txid3", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	assert.Equal(t, []uint64{1, 2, 3}, receiveBlocks(t, eventch, 3))
+
+	client.Unregister(reg)
+	_, ok := <-eventch
+	assert.False(t, ok, "Expected event channel to be closed upon Unregister")
+	assert.True(t, replayClient.closed, "Expected replay client to be closed upon Unregister")
+}
+
+func TestReplayBlocks(t *testing.T) {
+	chanID := "mychannel"
+	ledger := newReplayLedger(chanID, 3)
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID), WithBlockEvents())
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	var replayClient *mockReplayClient
+	var replayOpts []options.Opt
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		replayOpts = opts
+		replayClient = newMockReplayClient(ledger, fromBlock)
+		return replayClient, replayClient.Start()
+	}
+
+	_, _, _, err = client.ReplayBlocks(3, 2)
+	assert.Error(t, err, "Expected error for invalid block range")
+
+	// The replay waits for the blocks of the range which aren't committed yet, and ends with the range
+	_, blockch, errch, err := client.ReplayBlocks(1, 3)
+	if err != nil {
+		t.Fatalf("error replaying blocks: %s", err)
+	}
+	assert.Len(t, replayOpts, 1, "Expected stop block to be requested")
+	assert.Equal(t, []uint64{1, 2}, receiveBlocks(t, blockch, 2))
+
+	ledger.NewBlock(chanID, servicemocks.NewTransaction("txid3", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	ledger.NewBlock(chanID, servicemocks.NewTransaction("txid4", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	assert.Equal(t, []uint64{3}, receiveBlocks(t, blockch, 1))
+
+	_, ok := <-blockch
+	assert.False(t, ok, "Expected block channel to be closed at the end of the range")
+	err, ok = <-errch
+	assert.False(t, ok, "Expected replay to end without error")
+	assert.NoError(t, err)
+	assert.True(t, replayClient.closed, "Expected replay client to be closed at the end of the range")
+
+	// The replay fails if it ends before the end of the range
+	_, blockch, errch, err = client.ReplayBlocks(3, 10, WithFailIfNotReady())
+	if err != nil {
+		t.Fatalf("error replaying blocks: %s", err)
+	}
+	assert.Len(t, replayOpts, 3, "Expected stop block, fail if not ready and no reconnection to be requested")
+	assert.Equal(t, []uint64{3, 4}, receiveBlocks(t, blockch, 2))
+
+	replayClient.Close()
+	_, ok = <-blockch
+	assert.False(t, ok, "Expected block channel to be closed when the replay failed")
+	assert.Error(t, <-errch, "Expected replay error")
+}
+
+func TestReplayBlocksUnregister(t *testing.T) {
+	chanID := "mychannel"
+	ledger := newReplayLedger(chanID, 3)
+
+	client, err := New(createChannelContext(setupCustomTestContext(t, nil), chanID), WithBlockEvents())
+	if err != nil {
+		t.Fatalf("Failed to create new event client: %s", err)
+	}
+
+	var replayClient *mockReplayClient
+	client.replayProvider = func(fromBlock uint64, permitBlockEvents bool, opts ...options.Opt) (fab.EventClient, error) {
+		replayClient = newMockReplayClient(ledger, fromBlock)
+		return replayClient, replayClient.Start()
+	}
+
+	reg, blockch, errch, err := client.ReplayBlocks(1, 10)
+	if err != nil {
+		t.Fatalf("error replaying blocks: %s", err)
+	}
+	assert.Equal(t, []uint64{1}, receiveBlocks(t, blockch, 1))
+
+	// The replay is stopped even though the remaining blocks aren't read
+	client.Unregister(reg)
+	_, ok := <-blockch
+	assert.False(t, ok, "Expected block channel to be closed upon Unregister")
+	err, ok = <-errch
+	assert.False(t, ok, "Expected error channel to be closed upon Unregister")
+	assert.NoError(t, err)
+	assert.True(t, replayClient.closed, "Expected replay client to be closed upon Unregister")
+
+	client.Unregister(reg)
+}
+
+//newReplayLedger returns a ledger with the given number of blocks
+func newReplayLedger(chanID string, numBlocks int) *servicemocks.MockLedger {
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory, sourceURL)
+	for i := 0; i < numBlocks; i++ {
+		ledger.NewBlock(chanID, servicemocks.NewTransaction("txid", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	}
+	return ledger
+}
+
+//receiveBlocks receives the given number of blocks and returns their numbers
+func receiveBlocks(t *testing.T, eventch <-chan *fab.BlockEvent, n int) []uint64 {
+	var numbers []uint64
+	for i := 0; i < n; i++ {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			numbers = append(numbers, event.Block.Header.Number)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block event")
+		}
+	}
+	return numbers
+}
@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+)
+
+// BlockHashSize is the size of a block hash on channels using SHA-256 (or SHA3-256) as hashing algorithm
+const BlockHashSize = 32
+
+// entryNotFound is the error message of the peer for a block which isn't in the ledger
+const entryNotFound = "Entry not found"
+
+// BlockHashFromHex decodes a hex-encoded block hash, as displayed by most block explorers
+func BlockHashFromHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	hash, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "block hash [%s] is not hex-encoded", s)
+	}
+	if err := validateBlockHash(hash); err != nil {
+		return nil, errors.WithMessage(err, "invalid hex-encoded block hash")
+	}
+	return hash, nil
+}
+
+// BlockHashFromBase64 decodes a base64-encoded block hash, as found in JSON representations of blocks
+func BlockHashFromBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	hash, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		var urlErr error
+		hash, urlErr = base64.URLEncoding.DecodeString(s)
+		if urlErr != nil {
+			return nil, errors.Wrapf(err, "block hash [%s] is not base64-encoded", s)
+		}
+	}
+	if err := validateBlockHash(hash); err != nil {
+		return nil, errors.WithMessage(err, "invalid base64-encoded block hash")
+	}
+	return hash, nil
+}
+
+//validateBlockHash checks the size of the block hash, which is typically wrong if the hash was decoded with the
+//wrong encoding (or not decoded at all)
+func validateBlockHash(hash []byte) error {
+	if len(hash) != BlockHashSize {
+		return errors.Errorf("block hash has %d bytes, expected %d bytes", len(hash), BlockHashSize)
+	}
+	return nil
+}
+
+//blockNotFoundError returns a NotFound status if the peers reported that there's no block with the given hash,
+//echoing the hash in both encodings so that it can be compared with the hash the caller started from
+func blockNotFoundError(err error, hash []byte) error {
+	if !strings.Contains(err.Error(), entryNotFound) {
+		return err
+	}
+	return status.New(status.ClientStatus, status.NotFound.ToInt32(), "block not found: "+err.Error(),
+		[]interface{}{map[string]string{"hex": hex.EncodeToString(hash), "base64": base64.StdEncoding.EncodeToString(hash)}})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestBlockHashEncodings(t *testing.T) {
+	hash := sha256.Sum256([]byte("block"))
+	hexHash := hex.EncodeToString(hash[:])
+	base64Hash := base64.StdEncoding.EncodeToString(hash[:])
+
+	decoded, err := BlockHashFromHex(hexHash)
+	assert.Nil(t, err)
+	assert.Equal(t, hash[:], decoded)
+
+	decoded, err = BlockHashFromHex(" 0x" + hexHash + "\n")
+	assert.Nil(t, err)
+	assert.Equal(t, hash[:], decoded)
+
+	decoded, err = BlockHashFromBase64(base64Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, hash[:], decoded)
+
+	decoded, err = BlockHashFromBase64(base64.URLEncoding.EncodeToString(hash[:]))
+	assert.Nil(t, err)
+	assert.Equal(t, hash[:], decoded)
+
+	// Hashes decoded with the wrong encoding
+	_, err = BlockHashFromHex(base64Hash)
+	assert.NotNil(t, err, "Expected error for base64-encoded hash")
+	_, err = BlockHashFromBase64(hexHash)
+	assert.NotNil(t, err, "Expected error for hex-encoded hash")
+	_, err = BlockHashFromHex(hexHash[:32])
+	assert.NotNil(t, err, "Expected error for truncated hash")
+}
+
+func TestQueryBlockByHashNotFound(t *testing.T) {
+	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, MockMSP: "test"}
+	lc := setupLedgerClient([]fab.Peer{&peer}, t)
+
+	_, err := lc.QueryBlockByHash([]byte("hash"))
+	if err == nil || err.Error() != "QueryBlockByHash failed: block hash has 4 bytes, expected 32 bytes" {
+		t.Fatalf("Expected invalid block hash error, got: %v", err)
+	}
+
+	hash := sha256.Sum256([]byte("block"))
+	peer.Error = status.New(status.EndorserServerStatus, 500, "Failed to get block for hash, error Entry not found in index", nil)
+	_, err = lc.QueryBlockByHash(hash[:])
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.NotFound.ToInt32(), s.Code)
+	assert.Equal(t, []interface{}{map[string]string{
+		"hex":    hex.EncodeToString(hash[:]),
+		"base64": base64.StdEncoding.EncodeToString(hash[:]),
+	}}, s.Details)
+
+	peer.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "connection failed", nil)
+	_, err = lc.QueryBlockByHash(hash[:])
+	s, ok = status.FromError(err)
+	assert.False(t, ok && s.Code == status.NotFound.ToInt32(), "Expected other errors not to be mapped to NotFound")
+}
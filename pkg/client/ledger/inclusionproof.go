@@ -0,0 +1,385 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"math"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	ordererGroupKey = "Orderer"
+	mspValueKey     = "MSP"
+)
+
+// InclusionProof proves that a transaction is included in a block of a channel.
+// It can be handed over to a third party and verified with VerifyInclusionProof.
+type InclusionProof struct {
+	ChannelID string
+	TxID      fab.TransactionID
+	// Block is the raw block containing the transaction
+	Block *common.Block
+	// TxIndex is the index of the transaction within the block data
+	TxIndex int
+	// Transaction is the raw transaction envelope as stored in the block
+	Transaction []byte
+	// Signatures are the orderer signatures over the block metadata
+	Signatures []*BlockSignature
+}
+
+// BlockSignature is an orderer signature over the block metadata
+type BlockSignature struct {
+	// MSPID of the signer
+	MSPID string
+	// Creator is the serialized identity of the signer
+	Creator []byte
+	// SignatureHeader is the raw signature header included in the signed bytes
+	SignatureHeader []byte
+	// Signature over the block metadata, signature header and block header
+	Signature []byte
+}
+
+// GetInclusionProof retrieves the block containing the given transaction and returns
+// a proof of the transaction's inclusion in that block.
+func (c *Client) GetInclusionProof(txID fab.TransactionID, options ...RequestOption) (*InclusionProof, error) {
+	block, err := c.QueryBlockByTxID(txID, options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "GetInclusionProof failed")
+	}
+
+	return NewInclusionProof(c.ctx.ChannelID(), txID, block)
+}
+
+// NewInclusionProof creates the inclusion proof of the given transaction from the block containing it
+func NewInclusionProof(channelID string, txID fab.TransactionID, block *common.Block) (*InclusionProof, error) {
+	if block == nil || block.Header == nil || block.Data == nil {
+		return nil, errors.New("block is missing header or data")
+	}
+
+	txIndex, err := findTransaction(block, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures, err := blockSignatures(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InclusionProof{
+		ChannelID:   channelID,
+		TxID:        txID,
+		Block:       block,
+		TxIndex:     txIndex,
+		Transaction: block.Data.Data[txIndex],
+		Signatures:  signatures,
+	}, nil
+}
+
+// VerifyInclusionProof verifies the given proof against the orderer MSPs of the given channel config block.
+// It recomputes the block data hash, checks that the transaction is part of the block and verifies
+// that the block metadata was signed by at least one orderer. No network access is required.
+func VerifyInclusionProof(proof *InclusionProof, configBlock *common.Block) error {
+	if proof == nil || proof.Block == nil || proof.Block.Header == nil || proof.Block.Data == nil {
+		return errors.New("proof is missing block")
+	}
+
+	block := proof.Block
+	if !bytes.Equal(blockDataHash(block.Data), block.Header.DataHash) {
+		return errors.New("block data hash does not match block header")
+	}
+
+	if proof.TxIndex < 0 || proof.TxIndex >= len(block.Data.Data) || !bytes.Equal(block.Data.Data[proof.TxIndex], proof.Transaction) {
+		return errors.Errorf("transaction is not found at index %d of block", proof.TxIndex)
+	}
+
+	txIndex, err := findTransaction(block, proof.TxID)
+	if err != nil {
+		return err
+	}
+	if txIndex != proof.TxIndex {
+		return errors.Errorf("transaction [%s] is found at index %d instead of %d", proof.TxID, txIndex, proof.TxIndex)
+	}
+
+	ordererMSPs, err := ordererMSPsFromConfigBlock(configBlock)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load orderer MSPs from config block")
+	}
+
+	return verifyBlockSignatures(block, ordererMSPs)
+}
+
+func findTransaction(block *common.Block, txID fab.TransactionID) (int, error) {
+	for i, data := range block.Data.Data {
+		envelope, err := protos_utils.GetEnvelopeFromBlock(data)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed to extract envelope from block")
+		}
+		payload, err := protos_utils.GetPayload(envelope)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed to extract payload from envelope")
+		}
+		if payload.Header == nil {
+			continue
+		}
+		channelHeader, err := protos_utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed to extract channel header from payload")
+		}
+		if channelHeader.TxId == string(txID) {
+			return i, nil
+		}
+	}
+	return 0, errors.Errorf("transaction [%s] not found in block %d", txID, block.Header.Number)
+}
+
+func blockMetadataSignatures(block *common.Block) (*common.Metadata, error) {
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_SIGNATURES) {
+		return nil, errors.New("block metadata is missing signatures")
+	}
+
+	metadata := &common.Metadata{}
+	if err := proto.Unmarshal(block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], metadata); err != nil {
+		return nil, errors.Wrap(err, "unmarshal block signatures metadata failed")
+	}
+	return metadata, nil
+}
+
+func blockSignatures(block *common.Block) ([]*BlockSignature, error) {
+	metadata, err := blockMetadataSignatures(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures []*BlockSignature
+	for _, ms := range metadata.Signatures {
+		signature, err := newBlockSignature(ms)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+func newBlockSignature(ms *common.MetadataSignature) (*BlockSignature, error) {
+	sigHeader, err := protos_utils.GetSignatureHeader(ms.SignatureHeader)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to extract signature header")
+	}
+
+	creator := &mb.SerializedIdentity{}
+	if err := proto.Unmarshal(sigHeader.Creator, creator); err != nil {
+		return nil, errors.Wrap(err, "unmarshal signature creator failed")
+	}
+
+	return &BlockSignature{
+		MSPID:           creator.Mspid,
+		Creator:         sigHeader.Creator,
+		SignatureHeader: ms.SignatureHeader,
+		Signature:       ms.Signature,
+	}, nil
+}
+
+// verifyBlockSignatures verifies the block metadata signatures against the given orderer MSPs.
+// The signatures are read from the block itself so that they cannot be substituted.
+func verifyBlockSignatures(block *common.Block, ordererMSPs map[string]*mspCerts) error {
+	metadata, err := blockMetadataSignatures(block)
+	if err != nil {
+		return err
+	}
+	if len(metadata.Signatures) == 0 {
+		return errors.New("block has no orderer signatures")
+	}
+
+	headerBytes, err := blockHeaderBytes(block.Header)
+	if err != nil {
+		return err
+	}
+
+	for _, ms := range metadata.Signatures {
+		signature, err := newBlockSignature(ms)
+		if err != nil {
+			return err
+		}
+
+		certs, ok := ordererMSPs[signature.MSPID]
+		if !ok {
+			return errors.Errorf("block signer [%s] is not an orderer MSP of the channel", signature.MSPID)
+		}
+
+		signedBytes := bytes.Join([][]byte{metadata.Value, signature.SignatureHeader, headerBytes}, nil)
+		if err := certs.verify(signature.Creator, signedBytes, signature.Signature); err != nil {
+			return errors.WithMessage(err, "block signature verification failed")
+		}
+	}
+
+	return nil
+}
+
+// blockDataHash computes the hash of the block data as done by the orderer
+func blockDataHash(data *common.BlockData) []byte {
+	hash := sha256.Sum256(bytes.Join(data.Data, nil))
+	return hash[:]
+}
+
+type asn1Header struct {
+	Number       int64
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+// blockHeaderBytes returns the ASN.1 encoding of the block header used in block signatures
+func blockHeaderBytes(header *common.BlockHeader) ([]byte, error) {
+	if header.Number > uint64(math.MaxInt64) {
+		return nil, errors.Errorf("block number %d is too large to encode", header.Number)
+	}
+
+	result, err := asn1.Marshal(asn1Header{
+		Number:       int64(header.Number),
+		PreviousHash: header.PreviousHash,
+		DataHash:     header.DataHash,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal block header failed")
+	}
+	return result, nil
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// mspCerts holds the certificates of an MSP required to validate its members
+type mspCerts struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+}
+
+func (m *mspCerts) verify(serializedID []byte, msg []byte, sig []byte) error {
+	sID := &mb.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedID, sID); err != nil {
+		return errors.Wrap(err, "could not deserialize a SerializedIdentity")
+	}
+
+	cert, err := parseCert(sID.IdBytes)
+	if err != nil {
+		return err
+	}
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         m.roots,
+		Intermediates: m.intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "certificate of [%s] is not issued by its MSP", sID.Mspid)
+	}
+
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.Errorf("unsupported public key type for [%s]", sID.Mspid)
+	}
+
+	ecdsaSig := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(sig, ecdsaSig); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal signature from [%s]", sID.Mspid)
+	}
+
+	digest := sha256.Sum256(msg)
+	if !ecdsa.Verify(publicKey, digest[:], ecdsaSig.R, ecdsaSig.S) {
+		return errors.Errorf("invalid signature from [%s]", sID.Mspid)
+	}
+	return nil
+}
+
+// ordererMSPsFromConfigBlock extracts the orderer organizations' MSP certs from the given config block
+func ordererMSPsFromConfigBlock(configBlock *common.Block) (map[string]*mspCerts, error) {
+	if configBlock == nil || configBlock.Data == nil || len(configBlock.Data.Data) == 0 {
+		return nil, errors.New("config block is missing data")
+	}
+
+	configEnvelope, err := resource.CreateConfigEnvelope(configBlock.Data.Data[0])
+	if err != nil {
+		return nil, err
+	}
+	if configEnvelope.Config == nil || configEnvelope.Config.ChannelGroup == nil {
+		return nil, errors.New("config block is missing channel group")
+	}
+
+	ordererGroup, ok := configEnvelope.Config.ChannelGroup.Groups[ordererGroupKey]
+	if !ok {
+		return nil, errors.New("config block is missing orderer group")
+	}
+
+	msps := make(map[string]*mspCerts)
+	for org, group := range ordererGroup.Groups {
+		value, ok := group.Values[mspValueKey]
+		if !ok {
+			continue
+		}
+
+		mspConfig := &mb.MSPConfig{}
+		if err := proto.Unmarshal(value.Value, mspConfig); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal MSP config of orderer org [%s] failed", org)
+		}
+
+		fabricConfig := &mb.FabricMSPConfig{}
+		if err := proto.Unmarshal(mspConfig.Config, fabricConfig); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal FabricMSPConfig of orderer org [%s] failed", org)
+		}
+
+		certs := &mspCerts{roots: x509.NewCertPool(), intermediates: x509.NewCertPool()}
+		for _, pemBytes := range fabricConfig.RootCerts {
+			cert, err := parseCert(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			certs.roots.AddCert(cert)
+		}
+		for _, pemBytes := range fabricConfig.IntermediateCerts {
+			cert, err := parseCert(pemBytes)
+			if err != nil {
+				return nil, err
+			}
+			certs.intermediates.AddCert(cert)
+		}
+		msps[fabricConfig.Name] = certs
+	}
+
+	if len(msps) == 0 {
+		return nil, errors.New("no orderer MSPs found in config block")
+	}
+	return msps, nil
+}
+
+func parseCert(pemBytes []byte) (*x509.Certificate, error) {
+	bl, _ := pem.Decode(pemBytes)
+	if bl == nil {
+		return nil, errors.New("could not decode the PEM structure")
+	}
+	cert, err := x509.ParseCertificate(bl.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate failed")
+	}
+	return cert, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ordererMSPID = "OrdererMSP"
+	proofTxID    = "txid2"
+)
+
+func TestInclusionProof(t *testing.T) {
+	key, certPEM := newOrdererIdentity(t)
+	configBlock := newConfigBlock(t, certPEM)
+	block := newSignedBlock(t, key, certPEM, []string{"txid1", proofTxID, "txid3"})
+
+	proof, err := NewInclusionProof(channelID, proofTxID, block)
+	if err != nil {
+		t.Fatalf("Failed to create inclusion proof: %s", err)
+	}
+	assert.Equal(t, 1, proof.TxIndex)
+	assert.Equal(t, block.Data.Data[1], proof.Transaction)
+	if assert.Len(t, proof.Signatures, 1) {
+		assert.Equal(t, ordererMSPID, proof.Signatures[0].MSPID)
+	}
+
+	if err := VerifyInclusionProof(proof, configBlock); err != nil {
+		t.Fatalf("Expected inclusion proof to be valid: %s", err)
+	}
+
+	_, err = NewInclusionProof(channelID, "unknown", block)
+	if err == nil || !strings.Contains(err.Error(), "not found in block") {
+		t.Fatalf("Expected transaction not found error, got: %v", err)
+	}
+}
+
+func TestInclusionProofTampered(t *testing.T) {
+	key, certPEM := newOrdererIdentity(t)
+	configBlock := newConfigBlock(t, certPEM)
+
+	block := newSignedBlock(t, key, certPEM, []string{"txid1", proofTxID})
+	proof, err := NewInclusionProof(channelID, proofTxID, block)
+	if err != nil {
+		t.Fatalf("Failed to create inclusion proof: %s", err)
+	}
+	proof.TxIndex = 0
+	err = VerifyInclusionProof(proof, configBlock)
+	if err == nil || !strings.Contains(err.Error(), "transaction is not found") {
+		t.Fatalf("Expected transaction index mismatch, got: %v", err)
+	}
+
+	block = newSignedBlock(t, key, certPEM, []string{"txid1", proofTxID})
+	proof, err = NewInclusionProof(channelID, proofTxID, block)
+	if err != nil {
+		t.Fatalf("Failed to create inclusion proof: %s", err)
+	}
+	block.Data.Data[0] = newTxEnvelope(t, "txid4")
+	err = VerifyInclusionProof(proof, configBlock)
+	if err == nil || !strings.Contains(err.Error(), "data hash") {
+		t.Fatalf("Expected data hash mismatch, got: %v", err)
+	}
+
+	block = newSignedBlock(t, key, certPEM, []string{"txid1", proofTxID})
+	proof, err = NewInclusionProof(channelID, proofTxID, block)
+	if err != nil {
+		t.Fatalf("Failed to create inclusion proof: %s", err)
+	}
+	block.Header.Number++
+	err = VerifyInclusionProof(proof, configBlock)
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("Expected invalid signature, got: %v", err)
+	}
+
+	otherKey, otherCertPEM := newOrdererIdentity(t)
+	block = newSignedBlock(t, otherKey, otherCertPEM, []string{proofTxID})
+	proof, err = NewInclusionProof(channelID, proofTxID, block)
+	if err != nil {
+		t.Fatalf("Failed to create inclusion proof: %s", err)
+	}
+	err = VerifyInclusionProof(proof, configBlock)
+	if err == nil || !strings.Contains(err.Error(), "not issued by its MSP") {
+		t.Fatalf("Expected untrusted signer, got: %v", err)
+	}
+}
+
+func newOrdererIdentity(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "orderer.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newConfigBlock(t *testing.T, rootCertPEM []byte) *common.Block {
+	fabricMSPConfig := marshalOrFail(t, &mb.FabricMSPConfig{Name: ordererMSPID, RootCerts: [][]byte{rootCertPEM}})
+	mspConfig := marshalOrFail(t, &mb.MSPConfig{Config: fabricMSPConfig})
+
+	config := &common.Config{
+		ChannelGroup: &common.ConfigGroup{
+			Groups: map[string]*common.ConfigGroup{
+				ordererGroupKey: {
+					Groups: map[string]*common.ConfigGroup{
+						"OrdererOrg": {
+							Values: map[string]*common.ConfigValue{mspValueKey: {Value: mspConfig}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	channelHeader := marshalOrFail(t, &common.ChannelHeader{Type: int32(common.HeaderType_CONFIG), ChannelId: channelID})
+	payload := marshalOrFail(t, &common.Payload{
+		Header: &common.Header{ChannelHeader: channelHeader},
+		Data:   marshalOrFail(t, &common.ConfigEnvelope{Config: config}),
+	})
+
+	return &common.Block{
+		Header: &common.BlockHeader{},
+		Data:   &common.BlockData{Data: [][]byte{marshalOrFail(t, &common.Envelope{Payload: payload})}},
+	}
+}
+
+func newTxEnvelope(t *testing.T, txID string) []byte {
+	channelHeader := marshalOrFail(t, &common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), ChannelId: channelID, TxId: txID})
+	payload := marshalOrFail(t, &common.Payload{Header: &common.Header{ChannelHeader: channelHeader}})
+	return marshalOrFail(t, &common.Envelope{Payload: payload})
+}
+
+func newSignedBlock(t *testing.T, key *ecdsa.PrivateKey, certPEM []byte, txIDs []string) *common.Block {
+	data := &common.BlockData{}
+	for _, txID := range txIDs {
+		data.Data = append(data.Data, newTxEnvelope(t, txID))
+	}
+	dataHash := sha256.Sum256(bytes.Join(data.Data, nil))
+
+	block := &common.Block{
+		Header: &common.BlockHeader{Number: 5, PreviousHash: []byte("previous"), DataHash: dataHash[:]},
+		Data:   data,
+	}
+
+	creator := marshalOrFail(t, &mb.SerializedIdentity{Mspid: ordererMSPID, IdBytes: certPEM})
+	sigHeader := marshalOrFail(t, &common.SignatureHeader{Creator: creator, Nonce: []byte("nonce")})
+	headerBytes, err := blockHeaderBytes(block.Header)
+	if err != nil {
+		t.Fatalf("Failed to marshal block header: %s", err)
+	}
+
+	value := []byte("metadata")
+	digest := sha256.Sum256(bytes.Join([][]byte{value, sigHeader, headerBytes}, nil))
+	signature, err := key.Sign(rand.Reader, digest[:], nil)
+	if err != nil {
+		t.Fatalf("Failed to sign block: %s", err)
+	}
+
+	metadata := marshalOrFail(t, &common.Metadata{
+		Value:      value,
+		Signatures: []*common.MetadataSignature{{SignatureHeader: sigHeader, Signature: signature}},
+	})
+	block.Metadata = &common.BlockMetadata{Metadata: [][]byte{metadata, {}, {}, {}}}
+
+	return block
+}
+
+func marshalOrFail(t *testing.T, msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %s", msg, err)
+	}
+	return b
+}
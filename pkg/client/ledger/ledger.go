@@ -9,6 +9,7 @@ package ledger
 
 import (
 	reqContext "context"
+	"math"
 	"math/rand"
 	"time"
 
@@ -109,6 +110,15 @@ func (c *Client) QueryInfo(options ...RequestOption) (*fab.BlockchainInfoRespons
 	reqCtx, cancel := c.createRequestContext(opts)
 	defer cancel()
 
+	return c.queryInfo(reqCtx, targets, opts)
+}
+
+//queryInfo queries the targets for the blockchain info and returns the info with the highest block height
+func (c *Client) queryInfo(reqCtx reqContext.Context, targets []fab.Peer, opts *requestOptions) (*fab.BlockchainInfoResponse, error) {
+	if opts.isQuorumQuery() {
+		return c.queryInfoQuorum(targets, opts)
+	}
+
 	responses, err := c.ledger.QueryInfo(reqCtx, peersToTxnProcessors(targets), c.verifier)
 	if err != nil && len(responses) == 0 {
 		return nil, errors.WithMessage(err, "QueryInfo failed")
@@ -138,24 +148,69 @@ func (c *Client) QueryInfo(options ...RequestOption) (*fab.BlockchainInfoRespons
 
 // QueryBlockByHash queries the ledger for Block by block hash.
 // This query will be made to specified targets.
-// Returns the block.
+// The hash has to be the raw bytes of the hash: use BlockHashFromHex or BlockHashFromBase64 to decode an encoded hash.
+// Returns the block, or a NotFound status error if there's no block with the given hash.
 func (c *Client) QueryBlockByHash(blockHash []byte, options ...RequestOption) (*common.Block, error) {
 
+	if err := validateBlockHash(blockHash); err != nil {
+		return nil, errors.WithMessage(err, "QueryBlockByHash failed")
+	}
+
 	targets, opts, err := c.prepareRequestParams(options...)
 	if err != nil {
 		return nil, errors.WithMessage(err, "QueryBlockByHash failed to prepare request parameters")
 	}
+
+	if opts.isQuorumQuery() {
+		return c.queryBlockQuorum(targets, opts, func(reqCtx reqContext.Context, targets []fab.ProposalProcessor) ([]*common.Block, error) {
+			return c.ledger.QueryBlockByHash(reqCtx, blockHash, targets, c.verifier)
+		})
+	}
+
 	reqCtx, cancel := c.createRequestContext(opts)
 	defer cancel()
 
 	responses, err := c.ledger.QueryBlockByHash(reqCtx, blockHash, peersToTxnProcessors(targets), c.verifier)
 	if err != nil && len(responses) == 0 {
-		return nil, errors.WithMessage(err, "QueryBlockByHash failed")
+		return nil, blockNotFoundError(errors.WithMessage(err, "QueryBlockByHash failed"), blockHash)
 	}
 
 	return matchBlockData(responses, opts.MinTargets)
 }
 
+// QueryLatestBlock queries the ledger for the latest block, i.e. the block whose hash is reported by QueryInfo as
+// the current block hash. The block is queried from the target which reported the highest block height, since
+// the other targets may not have committed it yet.
+// Returns the block.
+func (c *Client) QueryLatestBlock(options ...RequestOption) (*common.Block, error) {
+
+	targets, opts, err := c.prepareRequestParams(options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "QueryLatestBlock failed to prepare request parameters")
+	}
+	reqCtx, cancel := c.createRequestContext(opts)
+	defer cancel()
+
+	info, err := c.queryInfo(reqCtx, targets, opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "QueryLatestBlock failed")
+	}
+
+	var blockTargets []fab.Peer
+	for _, target := range targets {
+		if target.URL() == info.Endorser {
+			blockTargets = append(blockTargets, target)
+		}
+	}
+
+	responses, err := c.ledger.QueryBlockByHash(reqCtx, info.BCI.CurrentBlockHash, peersToTxnProcessors(blockTargets), c.verifier)
+	if err != nil && len(responses) == 0 {
+		return nil, blockNotFoundError(errors.WithMessage(err, "QueryLatestBlock failed"), info.BCI.CurrentBlockHash)
+	}
+
+	return matchBlockData(responses, 1)
+}
+
 // QueryBlockByTxID returns a block which contains a transaction
 // This query will be made to specified targets.
 // Returns the block.
@@ -165,6 +220,13 @@ func (c *Client) QueryBlockByTxID(txID fab.TransactionID, options ...RequestOpti
 	if err != nil {
 		return nil, errors.WithMessage(err, "QueryBlockByTxID failed to prepare request parameters")
 	}
+
+	if opts.isQuorumQuery() {
+		return c.queryBlockQuorum(targets, opts, func(reqCtx reqContext.Context, targets []fab.ProposalProcessor) ([]*common.Block, error) {
+			return c.ledger.QueryBlockByTxID(reqCtx, txID, targets, c.verifier)
+		})
+	}
+
 	reqCtx, cancel := c.createRequestContext(opts)
 	defer cancel()
 
@@ -186,6 +248,13 @@ func (c *Client) QueryBlock(blockNumber uint64, options ...RequestOption) (*comm
 	if err != nil {
 		return nil, errors.WithMessage(err, "QueryBlock failed to prepare request parameters")
 	}
+
+	if opts.isQuorumQuery() {
+		return c.queryBlockQuorum(targets, opts, func(reqCtx reqContext.Context, targets []fab.ProposalProcessor) ([]*common.Block, error) {
+			return c.ledger.QueryBlock(reqCtx, blockNumber, targets, c.verifier)
+		})
+	}
+
 	reqCtx, cancel := c.createRequestContext(opts)
 	defer cancel()
 
@@ -241,6 +310,11 @@ func (c *Client) QueryTransaction(transactionID fab.TransactionID, options ...Re
 	if err != nil {
 		return nil, errors.WithMessage(err, "QueryTransaction failed to prepare request parameters")
 	}
+
+	if opts.isQuorumQuery() {
+		return c.queryTransactionQuorum(transactionID, targets, opts)
+	}
+
 	reqCtx, cancel := c.createRequestContext(opts)
 	defer cancel()
 
@@ -296,11 +370,17 @@ func (c *Client) prepareRequestOpts(options ...RequestOption) (requestOptions, e
 		}
 	}
 
-	// Set defaults for max targets
-	if opts.MaxTargets == 0 {
+	// Set defaults for max targets: quorum queries may query all targets
+	if opts.MaxTargets == 0 && opts.isQuorumQuery() {
+		opts.MaxTargets = math.MaxInt32
+	} else if opts.MaxTargets == 0 {
 		opts.MaxTargets = maxTargets
 	}
 
+	if opts.Quorum > opts.MaxTargets {
+		opts.MaxTargets = opts.Quorum
+	}
+
 	// Set defaults for min targets/matches
 	if opts.MinTargets == 0 {
 		opts.MinTargets = minTargets
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package ledger
 
 import (
+	reqContext "context"
 	"strings"
 	"testing"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -116,18 +118,19 @@ func TestQueryBlockByHash(t *testing.T) {
 
 	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, Status: 200, MockMSP: "test"}
 	lc := setupLedgerClient([]fab.Peer{&peer}, t)
+	blockHash := []byte("01234567890123456789012345678901")
 
-	_, err := lc.QueryBlockByHash([]byte("hash"))
+	_, err := lc.QueryBlockByHash(blockHash)
 	if err != nil {
 		t.Fatalf("Test ledger query block failed: %s", err)
 	}
 
-	_, err = lc.QueryBlockByHash([]byte("hash"), WithTargets(&peer))
+	_, err = lc.QueryBlockByHash(blockHash, WithTargets(&peer))
 	if err != nil {
 		t.Fatalf("Test ledger query block failed: %s", err)
 	}
 
-	_, err = lc.QueryBlockByHash([]byte("hash"), WithTargets(&peer), WithTargetFilter(&mspFilter{mspID: "test"}))
+	_, err = lc.QueryBlockByHash(blockHash, WithTargets(&peer), WithTargetFilter(&mspFilter{mspID: "test"}))
 	expected := "If targets are provided, filter cannot be provided"
 	if err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Test ledger query block should have failed with '%s'", expected)
@@ -135,12 +138,31 @@ func TestQueryBlockByHash(t *testing.T) {
 
 	expected = "QueryBlockByHash failed"
 	lc = setupLedgerClientWithError(nil, errors.New(expected), []fab.Peer{&peer}, t)
-	_, err = lc.QueryBlockByHash([]byte("hash"))
+	_, err = lc.QueryBlockByHash(blockHash)
 	if err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Test ledger query block should have failed with '%s'", expected)
 	}
 }
 
+func TestQueryLatestBlock(t *testing.T) {
+	peer1 := &sequencePeer{MockPeer: &mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, MockMSP: "test"}}
+	peer1.payloads = [][]byte{marshalOrFail(t, &common.BlockchainInfo{Height: 5, CurrentBlockHash: []byte("hash4")})}
+	peer2 := &sequencePeer{MockPeer: &mocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", Status: 200, MockMSP: "test"}}
+	peer2.payloads = [][]byte{
+		marshalOrFail(t, &common.BlockchainInfo{Height: 6, CurrentBlockHash: []byte("hash5")}),
+		marshalOrFail(t, &common.Block{Header: &common.BlockHeader{Number: 5}}),
+	}
+	lc := setupLedgerClient([]fab.Peer{peer1, peer2}, t)
+
+	block, err := lc.QueryLatestBlock(WithMaxTargets(2))
+	if err != nil {
+		t.Fatalf("Test ledger query latest block failed: %s", err)
+	}
+	assert.EqualValues(t, 5, block.Header.Number)
+	assert.Equal(t, 1, peer1.ProcessProposalCalls, "Expected the block to be queried from the peer with the highest block")
+	assert.Equal(t, 2, peer2.ProcessProposalCalls)
+}
+
 func TestQueryBlockByTxID(t *testing.T) {
 
 	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, Status: 200, MockMSP: "test"}
@@ -359,6 +381,17 @@ func createClientContext(client context.Client) context.ClientProvider {
 	}
 }
 
+//sequencePeer returns the given payloads in turn
+type sequencePeer struct {
+	*mocks.MockPeer
+	payloads [][]byte
+}
+
+func (p *sequencePeer) ProcessTransactionProposal(ctx reqContext.Context, tp fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.Payload = p.payloads[p.ProcessProposalCalls]
+	return p.MockPeer.ProcessTransactionProposal(ctx, tp)
+}
+
 type TestVerifier struct {
 	verifyErr error
 	matchErr  error
@@ -43,6 +43,10 @@ type requestOptions struct {
 	MinTargets    int                               // min number of targets that have to respond with no error (or agree on result)
 	Timeouts      map[fab.TimeoutType]time.Duration //timeout options for ledger query operations
 	ParentContext reqContext.Context                //parent grpc context for ledger operations
+	Quorum        int                               // number of targets that have to agree on the result
+	AllTargets    bool                              // query all targets at once
+	MaxLag        uint64                            // blocks a target may lag behind and still agree on blockchain info
+	Report        *QuorumReport                     // receives the report of a quorum query
 }
 
 //WithTargets encapsulates fab.Peer targets to ledger RequestOption
@@ -123,3 +127,46 @@ func WithParentContext(parentContext reqContext.Context) RequestOption {
 		return nil
 	}
 }
+
+// WithQuorum fans the query out to the targets (all targets available, unless limited by WithMaxTargets) and
+// returns the result that at least n of them agree on. Blocks agree if their hashes are equal, transactions if
+// they're structurally equal and blockchain info if it's equal or lags behind within the height tolerance (see
+// WithHeightTolerance). The quorum is queried first and more targets are queried as long as failed or dissenting
+// targets keep the quorum out of reach. A QuorumNotReached status is returned if the targets are exhausted.
+// Applies to QueryInfo, QueryLatestBlock (for the blockchain info), QueryBlock, QueryBlockByHash, QueryBlockByTxID
+// and QueryTransaction.
+func WithQuorum(n int) RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		if n < 1 {
+			return errors.Errorf("invalid quorum [%d]", n)
+		}
+		opts.Quorum = n
+		return nil
+	}
+}
+
+// WithAllTargets queries all targets at once rather than the quorum only, so that the report (see
+// WithQuorumReport) covers all targets. Unless given with WithQuorum, the quorum is the majority of the targets.
+func WithAllTargets() RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		opts.AllTargets = true
+		return nil
+	}
+}
+
+// WithHeightTolerance lets the targets which lag at most the given number of blocks behind agree on the
+// blockchain info of a quorum query, e.g. since they haven't committed the latest block yet
+func WithHeightTolerance(blocks uint64) RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		opts.MaxLag = blocks
+		return nil
+	}
+}
+
+// WithQuorumReport fills the given report with the agreeing, dissenting and failed targets of a quorum query
+func WithQuorumReport(report *QuorumReport) RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		opts.Report = report
+		return nil
+	}
+}
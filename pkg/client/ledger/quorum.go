@@ -0,0 +1,280 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"bytes"
+	reqContext "context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// QuorumReport reports how the targets of a quorum query (see WithQuorum) responded
+type QuorumReport struct {
+	// Agreeing are the URLs of the targets which returned the agreed result
+	Agreeing []string
+	// Dissenting are the URLs of the targets which returned a result other than the agreed result
+	Dissenting []string
+	// Failed are the errors of the targets which failed to respond, by URL
+	Failed map[string]error
+}
+
+//quorumResult is the response of a target to a quorum query
+type quorumResult struct {
+	target   string
+	response interface{}
+	err      error
+}
+
+//quorumQuery queries a single target
+type quorumQuery func(reqCtx reqContext.Context, target fab.Peer) (interface{}, error)
+
+//quorumMatch returns true if the response supports the candidate result
+type quorumMatch func(candidate, response interface{}) bool
+
+//isQuorumQuery returns true if the request is to be fanned out to several targets and reconciled
+func (opts *requestOptions) isQuorumQuery() bool {
+	return opts.Quorum > 0 || opts.AllTargets
+}
+
+//queryQuorum queries the targets concurrently, each with its own timeout, until a quorum of them returned results
+//which support the same candidate result. Unless all targets are to be queried at once, the quorum is queried
+//first and more targets are queried as long as failed or dissenting responses keep the quorum out of reach.
+//Returns a QuorumNotReached status if the targets are exhausted.
+func (c *Client) queryQuorum(targets []fab.Peer, opts *requestOptions, query quorumQuery, match quorumMatch) (interface{}, error) {
+	quorum := opts.Quorum
+	if quorum == 0 {
+		// Majority of all targets
+		quorum = len(targets)/2 + 1
+	}
+	if len(targets) < quorum {
+		return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(),
+			fmt.Sprintf("%d targets available but quorum is %d", len(targets), quorum), nil)
+	}
+
+	report := &QuorumReport{Failed: make(map[string]error)}
+	results := make(chan quorumResult, len(targets))
+	queried := 0
+	pending := 0
+	queryNext := func() {
+		target := targets[queried]
+		queried++
+		pending++
+		// Each target has its own timeout, so that the targets queried last get as much time as the first
+		reqCtx, cancel := c.createRequestContext(opts)
+		go func() {
+			defer cancel()
+			response, err := query(reqCtx, target)
+			results <- quorumResult{target: target.URL(), response: response, err: err}
+		}()
+	}
+
+	initial := quorum
+	if opts.AllTargets {
+		initial = len(targets)
+	}
+	for queried < initial {
+		queryNext()
+	}
+
+	var responses []quorumResult
+	agreed := 0
+	for pending > 0 {
+		result := <-results
+		pending--
+
+		if result.err != nil {
+			report.Failed[result.target] = result.err
+		} else {
+			responses = append(responses, result)
+			candidate, supporters := bestCandidate(responses, match)
+			if supporters >= quorum && !opts.AllTargets {
+				c.completeQuorumReport(report, responses, candidate, match, opts)
+				return candidate.response, nil
+			}
+			agreed = supporters
+		}
+
+		// The quorum may only be reached by querying more targets
+		for agreed+pending < quorum && queried < len(targets) {
+			queryNext()
+		}
+	}
+
+	if agreed >= quorum {
+		// All targets were queried at once
+		candidate, _ := bestCandidate(responses, match)
+		c.completeQuorumReport(report, responses, candidate, match, opts)
+		return candidate.response, nil
+	}
+
+	for _, r := range responses {
+		report.Dissenting = append(report.Dissenting, r.target)
+	}
+	if opts.Report != nil {
+		*opts.Report = *report
+	}
+	return nil, status.New(status.ClientStatus, status.QuorumNotReached.ToInt32(),
+		fmt.Sprintf("%d of %d queried targets agreed on the result but quorum is %d (%d targets failed)",
+			agreed, queried, quorum, len(report.Failed)),
+		[]interface{}{report})
+}
+
+//bestCandidate returns the response supported by the most responses. Of equally supported responses, the one
+//supported by the others is preferred, e.g. the blockchain info of the highest block.
+func bestCandidate(responses []quorumResult, match quorumMatch) (quorumResult, int) {
+	var best quorumResult
+	bestSupporters := 0
+	for _, candidate := range responses {
+		supporters := 0
+		for _, r := range responses {
+			if match(candidate.response, r.response) {
+				supporters++
+			}
+		}
+		if supporters > bestSupporters || supporters == bestSupporters && match(candidate.response, best.response) {
+			best, bestSupporters = candidate, supporters
+		}
+	}
+	return best, bestSupporters
+}
+
+//completeQuorumReport records the agreeing and dissenting targets and passes the report to the caller if requested
+func (c *Client) completeQuorumReport(report *QuorumReport, responses []quorumResult, candidate quorumResult, match quorumMatch, opts *requestOptions) {
+	for _, r := range responses {
+		if match(candidate.response, r.response) {
+			report.Agreeing = append(report.Agreeing, r.target)
+		} else {
+			report.Dissenting = append(report.Dissenting, r.target)
+		}
+	}
+	if opts.Report != nil {
+		*opts.Report = *report
+	}
+}
+
+//queryBlockQuorum queries a block from a quorum of the targets, which agree if the hashes of the blocks are equal
+func (c *Client) queryBlockQuorum(targets []fab.Peer, opts *requestOptions, queryBlock func(reqCtx reqContext.Context, targets []fab.ProposalProcessor) ([]*common.Block, error)) (*common.Block, error) {
+	query := func(reqCtx reqContext.Context, target fab.Peer) (interface{}, error) {
+		blocks, err := queryBlock(reqCtx, peersToTxnProcessors([]fab.Peer{target}))
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			return nil, errors.New("no block returned")
+		}
+		return quorumBlock(blocks[0])
+	}
+
+	response, err := c.queryQuorum(targets, opts, query, func(candidate, response interface{}) bool {
+		return bytes.Equal(candidate.(*hashedBlock).hash, response.(*hashedBlock).hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*hashedBlock).block, nil
+}
+
+//hashedBlock is a block along with its hash
+type hashedBlock struct {
+	block *common.Block
+	hash  []byte
+}
+
+//quorumBlock returns the block with its hash, after checking that the hash of its data matches its header
+func quorumBlock(block *common.Block) (*hashedBlock, error) {
+	if block.Header == nil || block.Data == nil {
+		return nil, errors.New("block has no header or data")
+	}
+	if !bytes.Equal(block.Header.DataHash, blockDataHash(block.Data)) {
+		return nil, errors.Errorf("data hash of block %d doesn't match its header", block.Header.Number)
+	}
+	hash, err := blockHeaderHash(block.Header)
+	if err != nil {
+		return nil, err
+	}
+	return &hashedBlock{block: block, hash: hash}, nil
+}
+
+//blockHeaderHash returns the hash of the block header, i.e. the hash of the block
+func blockHeaderHash(header *common.BlockHeader) ([]byte, error) {
+	headerBytes, err := blockHeaderBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(headerBytes)
+	return hash[:], nil
+}
+
+//queryInfoQuorum queries the blockchain info from a quorum of the targets and returns the info with the highest
+//block height which is supported by the quorum (see WithHeightTolerance)
+func (c *Client) queryInfoQuorum(targets []fab.Peer, opts *requestOptions) (*fab.BlockchainInfoResponse, error) {
+	query := func(reqCtx reqContext.Context, target fab.Peer) (interface{}, error) {
+		responses, err := c.ledger.QueryInfo(reqCtx, peersToTxnProcessors([]fab.Peer{target}), c.verifier)
+		if err != nil {
+			return nil, err
+		}
+		if len(responses) == 0 || responses[0].BCI == nil {
+			return nil, errors.New("no blockchain info returned")
+		}
+		return responses[0], nil
+	}
+
+	response, err := c.queryQuorum(targets, opts, query, infoMatch(opts.MaxLag))
+	if err != nil {
+		return nil, err
+	}
+	return response.(*fab.BlockchainInfoResponse), nil
+}
+
+//infoMatch returns true if the blockchain info supports the candidate info, i.e. it's equal or, within the height
+//tolerance, it lags behind the candidate. A target which lags one block behind must report the previous block hash
+//of the candidate as its current block hash.
+func infoMatch(tolerance uint64) quorumMatch {
+	return func(candidate, response interface{}) bool {
+		c := candidate.(*fab.BlockchainInfoResponse).BCI
+		r := response.(*fab.BlockchainInfoResponse).BCI
+		if c.Height == r.Height {
+			return bytes.Equal(c.CurrentBlockHash, r.CurrentBlockHash) && bytes.Equal(c.PreviousBlockHash, r.PreviousBlockHash)
+		}
+		if r.Height > c.Height || c.Height-r.Height > tolerance {
+			return false
+		}
+		return c.Height-r.Height > 1 || bytes.Equal(c.PreviousBlockHash, r.CurrentBlockHash)
+	}
+}
+
+//queryTransactionQuorum queries the processed transaction from a quorum of the targets, which agree if the
+//transactions are structurally equal
+func (c *Client) queryTransactionQuorum(transactionID fab.TransactionID, targets []fab.Peer, opts *requestOptions) (*pb.ProcessedTransaction, error) {
+	query := func(reqCtx reqContext.Context, target fab.Peer) (interface{}, error) {
+		responses, err := c.ledger.QueryTransaction(reqCtx, transactionID, peersToTxnProcessors([]fab.Peer{target}), c.verifier)
+		if err != nil {
+			return nil, err
+		}
+		if len(responses) == 0 {
+			return nil, errors.New("no transaction returned")
+		}
+		return responses[0], nil
+	}
+
+	response, err := c.queryQuorum(targets, opts, query, func(candidate, response interface{}) bool {
+		return proto.Equal(candidate.(*pb.ProcessedTransaction), response.(*pb.ProcessedTransaction))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*pb.ProcessedTransaction), nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestQueryBlockQuorum(t *testing.T) {
+	block := marshalOrFail(t, newQuorumBlock(5, "tx1"))
+	forged := marshalOrFail(t, newQuorumBlock(5, "tx2"))
+
+	peer1 := newQuorumPeer("Peer1", "http://peer1.com", block)
+	peer2 := newQuorumPeer("Peer2", "http://peer2.com", block)
+	peer3 := newQuorumPeer("Peer3", "http://peer3.com", forged)
+	lc := setupLedgerClient([]fab.Peer{peer1, peer2, peer3}, t)
+
+	_, err := lc.QueryBlock(5, WithQuorum(0))
+	assert.Error(t, err, "Expected error for invalid quorum")
+
+	// The agreed block is returned along with the dissenting peer
+	var report QuorumReport
+	b, err := lc.QueryBlock(5, WithQuorum(2), WithAllTargets(), WithQuorumReport(&report))
+	if err != nil {
+		t.Fatalf("Test ledger query block with quorum failed: %s", err)
+	}
+	assert.Equal(t, []byte("tx1"), b.Data.Data[0])
+	assert.ElementsMatch(t, []string{"http://peer1.com", "http://peer2.com"}, report.Agreeing)
+	assert.Equal(t, []string{"http://peer3.com"}, report.Dissenting)
+	assert.Empty(t, report.Failed)
+
+	// The majority of all targets is the default quorum
+	b, err = lc.QueryBlockByHash([]byte("0123456789abcdef0123456789abcdef"), WithAllTargets())
+	if err != nil {
+		t.Fatalf("Test ledger query block by hash with quorum failed: %s", err)
+	}
+	assert.Equal(t, []byte("tx1"), b.Data.Data[0])
+
+	// More targets are queried as long as the quorum is out of reach
+	for i := 0; i < 10; i++ {
+		b, err = lc.QueryBlockByTxID("txID", WithQuorum(2))
+		if err != nil {
+			t.Fatalf("Test ledger query block by txID with quorum failed: %s", err)
+		}
+		assert.Equal(t, []byte("tx1"), b.Data.Data[0])
+	}
+
+	report = QuorumReport{}
+	_, err = lc.QueryBlock(5, WithQuorum(3), WithQuorumReport(&report))
+	assertQuorumNotReached(t, err)
+	assert.Len(t, report.Dissenting, 3)
+
+	_, err = lc.QueryBlock(5, WithQuorum(4))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code)
+
+	// Failed targets are tolerated
+	peer3.Status = 500
+	report = QuorumReport{}
+	_, err = lc.QueryBlock(5, WithQuorum(2), WithAllTargets(), WithQuorumReport(&report))
+	assert.NoError(t, err)
+	assert.Contains(t, report.Failed, "http://peer3.com")
+	assert.Empty(t, report.Dissenting)
+
+	peer2.Status = 500
+	_, err = lc.QueryBlock(5, WithQuorum(2))
+	assertQuorumNotReached(t, err)
+}
+
+func TestQueryInfoQuorum(t *testing.T) {
+	peer1 := newQuorumPeer("Peer1", "http://peer1.com",
+		marshalOrFail(t, &common.BlockchainInfo{Height: 6, CurrentBlockHash: []byte("hash5"), PreviousBlockHash: []byte("hash4")}))
+	peer2 := newQuorumPeer("Peer2", "http://peer2.com",
+		marshalOrFail(t, &common.BlockchainInfo{Height: 5, CurrentBlockHash: []byte("hash4"), PreviousBlockHash: []byte("hash3")}))
+	peer3 := newQuorumPeer("Peer3", "http://peer3.com",
+		marshalOrFail(t, &common.BlockchainInfo{Height: 3, CurrentBlockHash: []byte("hash2"), PreviousBlockHash: []byte("hash1")}))
+	lc := setupLedgerClient([]fab.Peer{peer1, peer2, peer3}, t)
+
+	_, err := lc.QueryInfo(WithQuorum(2))
+	assertQuorumNotReached(t, err)
+
+	// A peer lagging behind within the height tolerance agrees with the highest block
+	var report QuorumReport
+	info, err := lc.QueryInfo(WithQuorum(2), WithAllTargets(), WithHeightTolerance(2), WithQuorumReport(&report))
+	if err != nil {
+		t.Fatalf("Test ledger query info with quorum failed: %s", err)
+	}
+	assert.EqualValues(t, 6, info.BCI.Height)
+	assert.Equal(t, "http://peer1.com", info.Endorser)
+	assert.ElementsMatch(t, []string{"http://peer1.com", "http://peer2.com"}, report.Agreeing)
+	assert.Equal(t, []string{"http://peer3.com"}, report.Dissenting)
+
+	// The latest block of a peer which lags one block behind has to be the previous block of the highest block
+	peer2.Payload = marshalOrFail(t, &common.BlockchainInfo{Height: 5, CurrentBlockHash: []byte("fork4")})
+	_, err = lc.QueryInfo(WithQuorum(2), WithHeightTolerance(1))
+	assertQuorumNotReached(t, err)
+
+	_, err = lc.QueryInfo(WithQuorum(2), WithHeightTolerance(3))
+	assert.NoError(t, err, "Expected peer lagging three blocks behind to agree")
+}
+
+func TestQueryTransactionQuorum(t *testing.T) {
+	tx := marshalOrFail(t, &pb.ProcessedTransaction{ValidationCode: int32(pb.TxValidationCode_VALID)})
+	invalid := marshalOrFail(t, &pb.ProcessedTransaction{ValidationCode: int32(pb.TxValidationCode_MVCC_READ_CONFLICT)})
+
+	peer1 := newQuorumPeer("Peer1", "http://peer1.com", tx)
+	peer2 := newQuorumPeer("Peer2", "http://peer2.com", invalid)
+	lc := setupLedgerClient([]fab.Peer{peer1, peer2}, t)
+
+	_, err := lc.QueryTransaction("1234", WithQuorum(2))
+	assertQuorumNotReached(t, err)
+
+	peer2.Payload = tx
+	processed, err := lc.QueryTransaction("1234", WithQuorum(2))
+	if err != nil {
+		t.Fatalf("Test ledger query transaction with quorum failed: %s", err)
+	}
+	assert.EqualValues(t, pb.TxValidationCode_VALID, processed.ValidationCode)
+}
+
+func assertQuorumNotReached(t *testing.T, err error) {
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected status error, got: %v", err)
+	}
+	assert.EqualValues(t, status.QuorumNotReached.ToInt32(), s.Code)
+	assert.Len(t, s.Details, 1, "Expected report in details")
+}
+
+func newQuorumPeer(name, url string, payload []byte) *mocks.MockPeer {
+	return &mocks.MockPeer{MockName: name, MockURL: url, Status: 200, MockMSP: "test", Payload: payload, RWLock: &sync.RWMutex{}}
+}
+
+func newQuorumBlock(number uint64, data ...string) *common.Block {
+	blockData := &common.BlockData{}
+	for _, d := range data {
+		blockData.Data = append(blockData.Data, []byte(d))
+	}
+	return &common.Block{
+		Header: &common.BlockHeader{Number: number, DataHash: blockDataHash(blockData)},
+		Data:   blockData,
+	}
+}
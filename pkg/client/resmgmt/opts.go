@@ -52,6 +52,17 @@ func WithTargetURLs(urls ...string) RequestOption {
 	}
 }
 
+// WithConfigSequence makes SaveChannel check that the channel config is at the given sequence, i.e. the sequence of
+// the config the update was computed from, before the update is sent. If the config was updated in the meantime
+// SaveChannel fails with a ConfigSequenceMismatch status rather than have the orderer reject the update.
+// The config is queried from the targets if any are provided, otherwise from the orderer of the request.
+func WithConfigSequence(sequence uint64) RequestOption {
+	return func(ctx context.Client, opts *requestOptions) error {
+		opts.ConfigSequence = &sequence
+		return nil
+	}
+}
+
 // WithTargetFilter enables a target filter for the request.
 func WithTargetFilter(targetFilter fab.TargetFilter) RequestOption {
 	return func(ctx context.Client, opts *requestOptions) error {
@@ -7,7 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package resmgmt
 
 import (
+	"bytes"
 	reqContext "context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/verifier"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
@@ -28,6 +31,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
@@ -89,6 +93,8 @@ type requestOptions struct {
 	Timeouts      map[fab.TimeoutType]time.Duration //timeout options for resmgmt operations
 	ParentContext reqContext.Context                //parent grpc context for resmgmt operations
 	Retry         retry.Opts
+	// ConfigSequence is the expected sequence of the channel config, which is checked before a channel update is sent
+	ConfigSequence *uint64
 }
 
 //SaveChannelRequest used to save channel request
@@ -111,6 +117,7 @@ type RequestOption func(ctx context.Client, opts *requestOptions) error
 var logger = logging.NewLogger("fabsdk/client")
 
 // Client enables managing resources in Fabric network.
+// A resource management client is safe for concurrent use by multiple goroutines.
 type Client struct {
 	ctx       context.Client
 	discovery fab.DiscoveryService // global discovery service (detects all peers on the network)
@@ -306,6 +313,9 @@ func (rc *Client) isChaincodeInstalled(reqCtx reqContext.Context, req InstallCCR
 
 	for _, chaincode := range chaincodeQueryResponse.Chaincodes {
 		if chaincode.Name == req.Name && chaincode.Version == req.Version && chaincode.Path == req.Path {
+			if err := verifyInstalledPackage(req, chaincode); err != nil {
+				return false, err
+			}
 			return true, nil
 		}
 	}
@@ -313,6 +323,34 @@ func (rc *Client) isChaincodeInstalled(reqCtx reqContext.Context, req InstallCCR
 	return false, nil
 }
 
+// verifyInstalledPackage verifies that the installed chaincode has the code package of the request,
+// by comparing the ID computed by the peer with the hash of the deployment spec of the request
+func verifyInstalledPackage(req InstallCCRequest, chaincode *pb.ChaincodeInfo) error {
+	if len(chaincode.Id) == 0 || req.Package == nil {
+		return nil
+	}
+
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        req.Package.Type,
+			ChaincodeId: &pb.ChaincodeID{Name: req.Name, Path: req.Path, Version: req.Version},
+		},
+		CodePackage: req.Package.Code,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal of chaincode deployment spec failed")
+	}
+
+	hash, err := ccpackager.ComputeCDSHash(cds)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, chaincode.Id) {
+		return errors.Errorf("chaincode %s:%s is installed with a different code package", req.Name, req.Version)
+	}
+	return nil
+}
+
 // InstallCC installs chaincode with optional custom options (specific peers, filtered peers)
 func (rc *Client) InstallCC(req InstallCCRequest, options ...RequestOption) ([]InstallCCResponse, error) {
 	// For each peer query if chaincode installed. If cc is installed treat as success with message 'already installed'.
@@ -688,7 +726,7 @@ func (rc *Client) sendTransactionAndCheckEvent(eventService fab.EventService, tp
 		if txStatus.TxValidationCode == pb.TxValidationCode_VALID {
 			return fab.TransactionID(txStatus.TxID), nil
 		}
-		return fab.TransactionID(txStatus.TxID), status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "instantiateOrUpgradeCC failed", nil)
+		return fab.TransactionID(txStatus.TxID), status.New(status.TxValidationStatus, int32(txStatus.TxValidationCode), "instantiateOrUpgradeCC failed", nil)
 	case <-reqCtx.Done():
 		return tp.TxnID, errors.New("instantiateOrUpgradeCC timed out or cancelled")
 	}
@@ -771,6 +809,12 @@ func (rc *Client) SaveChannel(req SaveChannelRequest, options ...RequestOption)
 		return SaveChannelResponse{}, errors.WithMessage(err, "failed to find orderer for request")
 	}
 
+	if opts.ConfigSequence != nil {
+		if err = rc.checkConfigSequence(req.ChannelID, *opts.ConfigSequence, orderer, opts); err != nil {
+			return SaveChannelResponse{}, err
+		}
+	}
+
 	configSignatures, err := rc.getConfigSignatures(req, chConfig)
 	if err != nil {
 		return SaveChannelResponse{}, err
@@ -873,6 +917,65 @@ func (rc *Client) QueryConfigFromOrderer(channelID string, options ...RequestOpt
 
 }
 
+// QueryConfigSequence returns the sequence of the channel config, which is incremented by each config update.
+// The config is queried from the targets if any are provided (WithTargets), otherwise from the orderer
+// (see QueryConfigFromOrderer).
+func (rc *Client) QueryConfigSequence(channelID string, options ...RequestOption) (uint64, error) {
+
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(opts.Targets) == 0 {
+		orderer, err := rc.requestOrderer(&opts, channelID)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed to find orderer for request")
+		}
+		opts.Orderer = orderer
+	}
+
+	return rc.queryConfigSequence(channelID, opts)
+}
+
+//queryConfigSequence queries the channel config from the targets of the request, or from its orderer if there are none
+func (rc *Client) queryConfigSequence(channelID string, opts requestOptions) (uint64, error) {
+	chConfigOpt := chconfig.WithOrderer(opts.Orderer)
+	timeoutType := fab.OrdererResponse
+	if len(opts.Targets) > 0 {
+		chConfigOpt = chconfig.WithPeers(opts.Targets)
+		timeoutType = fab.PeerResponse
+	}
+
+	channelConfig, err := chconfig.New(channelID, chConfigOpt)
+	if err != nil {
+		return 0, errors.WithMessage(err, "QueryConfig failed")
+	}
+
+	reqCtx, cancel := rc.createRequestContext(opts, timeoutType)
+	defer cancel()
+
+	cfg, err := channelConfig.Query(reqCtx)
+	if err != nil {
+		return 0, errors.WithMessage(err, "QueryConfig failed")
+	}
+	return cfg.Sequence(), nil
+}
+
+//checkConfigSequence fails with a ConfigSequenceMismatch status if the sequence of the channel config isn't the expected one
+func (rc *Client) checkConfigSequence(channelID string, expected uint64, orderer fab.Orderer, opts requestOptions) error {
+	opts.Orderer = orderer
+	sequence, err := rc.queryConfigSequence(channelID, opts)
+	if err != nil {
+		return errors.WithMessage(err, "failed to check config sequence")
+	}
+	if sequence != expected {
+		return status.New(status.ClientStatus, status.ConfigSequenceMismatch.ToInt32(),
+			fmt.Sprintf("config sequence of channel [%s] is %d, expected %d", channelID, sequence, expected), nil)
+	}
+	return nil
+}
+
 func (rc *Client) requestOrderer(opts *requestOptions, channelID string) (fab.Orderer, error) {
 	if opts.Orderer != nil {
 		return opts.Orderer, nil
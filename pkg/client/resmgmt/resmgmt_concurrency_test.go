@@ -0,0 +1,64 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConcurrentOperations shares one client and one set of request options between goroutines.
+// It's meant to be run with the race detector.
+func TestConcurrentOperations(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	channels, err := proto.Marshal(&pb.ChannelQueryResponse{Channels: []*pb.ChannelInfo{{ChannelId: "test"}}})
+	if err != nil {
+		t.Fatal("failed to marshal sample response")
+	}
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer1.Payload = channels
+	peer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	peer2.Payload = channels
+
+	opts := []RequestOption{WithTargets(peer1, peer2), WithTimeout(fab.ResMgmt, 5*time.Second)}
+	req := InstallCCRequest{Name: "ID", Version: "v0", Path: "path", Package: &api.CCPackage{Type: 1, Code: []byte("code")}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20*10*3)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := rc.QueryChannels(WithTargets(peer1)); err != nil {
+					errs <- err
+				}
+				if _, err := rc.QueryInstalledChaincodes(WithTargets(peer2)); err != nil {
+					errs <- err
+				}
+				if _, err := rc.InstallCC(req, opts...); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
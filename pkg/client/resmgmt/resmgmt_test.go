@@ -29,6 +29,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
 	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
@@ -419,6 +420,44 @@ func TestIsChaincodeInstalled(t *testing.T) {
 
 }
 
+func TestIsChaincodeInstalledVerifiesPackage(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	ccPackage := &api.CCPackage{Type: pb.ChaincodeSpec_GOLANG, Code: []byte("code")}
+	req := InstallCCRequest{Name: "test-name", Path: "test-path", Version: "test-version", Package: ccPackage}
+
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        pb.ChaincodeSpec_GOLANG,
+			ChaincodeId: &pb.ChaincodeID{Name: req.Name, Path: req.Path, Version: req.Version},
+		},
+		CodePackage: ccPackage.Code,
+	})
+	assert.Nil(t, err)
+	id, err := ccpackager.ComputeCDSHash(cds)
+	assert.Nil(t, err)
+
+	reqCtx, cancel := contextImpl.NewRequest(rc.ctx, contextImpl.WithTimeout(10*time.Second))
+	defer cancel()
+
+	peer1 := installedChaincodePeer(t, &pb.ChaincodeInfo{Name: req.Name, Path: req.Path, Version: req.Version, Id: id})
+	installed, err := rc.isChaincodeInstalled(reqCtx, req, peer1, retry.Opts{})
+	assert.Nil(t, err)
+	assert.True(t, installed, "Expected chaincode with the same package to be installed")
+
+	peer2 := installedChaincodePeer(t, &pb.ChaincodeInfo{Name: req.Name, Path: req.Path, Version: req.Version, Id: []byte("other")})
+	_, err = rc.isChaincodeInstalled(reqCtx, req, peer2, retry.Opts{})
+	assert.NotNil(t, err, "Expected error for chaincode installed with a different package")
+}
+
+func installedChaincodePeer(t *testing.T, chaincode *pb.ChaincodeInfo) *fcmocks.MockPeer {
+	responseBytes, err := proto.Marshal(&pb.ChaincodeQueryResponse{Chaincodes: []*pb.ChaincodeInfo{chaincode}})
+	if err != nil {
+		t.Fatal("failed to marshal sample response")
+	}
+	return &fcmocks.MockPeer{MockName: "Peer1", MockURL: "grpc://peer1.com", MockRoles: []string{}, MockMSP: "Org1MSP", Status: http.StatusOK, Payload: responseBytes}
+}
+
 func TestQueryInstalledChaincodes(t *testing.T) {
 
 	rc := setupDefaultResMgmtClient(t)
@@ -1445,6 +1484,61 @@ func TestSaveChannelSuccess(t *testing.T) {
 	assert.NotEmpty(t, resp.TransactionID, "transaction ID should be populated")
 }
 
+func TestSaveChannelWithConfigSequence(t *testing.T) {
+
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	_, addr := fcmocks.StartMockBroadcastServer("127.0.0.1:0", grpcServer)
+
+	ctx := setupTestContext("test", "Org1MSP")
+
+	mockConfig := &fcmocks.MockConfig{}
+	grpcOpts := make(map[string]interface{})
+	grpcOpts["allow-insecure"] = true
+
+	oConfig := &fab.OrdererConfig{
+		URL:         addr,
+		GRPCOptions: grpcOpts,
+	}
+	mockConfig.SetCustomOrdererCfg(oConfig)
+	ctx.SetEndpointConfig(mockConfig)
+
+	cc := setupResMgmtClient(ctx, nil, t)
+
+	peer := configBlockPeer(t, 4)
+	sequence, err := cc.QueryConfigSequence("mychannel", WithTargets(peer))
+	assert.Nil(t, err, "error should be nil")
+	assert.EqualValues(t, 4, sequence)
+
+	// Config was updated since the update was computed
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig}, WithTargets(peer), WithConfigSequence(3))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.ConfigSequenceMismatch.ToInt32(), s.Code, "expected config sequence mismatch")
+
+	resp, err := cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig}, WithTargets(peer), WithConfigSequence(4))
+	assert.Nil(t, err, "error should be nil")
+	assert.NotEmpty(t, resp.TransactionID, "transaction ID should be populated")
+}
+
+//configBlockPeer returns a peer which responds with a config block at the given sequence
+func configBlockPeer(t *testing.T, sequence uint64) fab.Peer {
+	builder := &fcmocks.MockConfigBlockBuilder{
+		MockConfigGroupBuilder: fcmocks.MockConfigGroupBuilder{
+			ModPolicy:      "Admins",
+			MSPNames:       []string{"Org1MSP"},
+			OrdererAddress: "localhost:7054",
+		},
+		Sequence: sequence,
+	}
+
+	payload, err := proto.Marshal(builder.Build())
+	if err != nil {
+		t.Fatalf("Failed to marshal mock block: %s", err)
+	}
+	return &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Payload: payload, Status: 200}
+}
+
 func TestSaveChannelFailure(t *testing.T) {
 
 	// Set up context with error in create channel
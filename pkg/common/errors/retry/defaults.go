@@ -80,12 +80,20 @@ var DefaultRetryableCodes = map[status.Group][]status.Code{
 		status.Code(common.Status_SERVICE_UNAVAILABLE),
 		status.Code(common.Status_INTERNAL_SERVER_ERROR),
 	},
+	// Deprecated: the validation codes are reported in the TxValidationStatus group. The
+	// EventServerStatus group is kept for errors which still report it.
 	status.EventServerStatus: []status.Code{
 		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
 		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
 		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
 		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
 	},
+	status.TxValidationStatus: []status.Code{
+		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
+		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
+		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
+		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
+	},
 	// TODO: gRPC introduced retries in v1.8.0. This can be replaced with the
 	// gRPC fail fast option, once available
 	status.GRPCTransportStatus: []status.Code{
@@ -110,12 +118,20 @@ var ResMgmtDefaultRetryableCodes = map[status.Group][]status.Code{
 		status.Code(common.Status_BAD_REQUEST),
 		status.Code(common.Status_NOT_FOUND),
 	},
+	// Deprecated: the validation codes are reported in the TxValidationStatus group. The
+	// EventServerStatus group is kept for errors which still report it.
 	status.EventServerStatus: []status.Code{
 		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
 		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
 		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
 		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
 	},
+	status.TxValidationStatus: []status.Code{
+		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
+		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
+		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
+		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
+	},
 	// TODO: gRPC introduced retries in v1.8.0. This can be replaced with the
 	// gRPC fail fast option, once available
 	status.GRPCTransportStatus: []status.Code{
@@ -128,25 +144,36 @@ var ResMgmtDefaultRetryableCodes = map[status.Group][]status.Code{
 var ChannelClientRetryableCodes = map[status.Group][]status.Code{
 	status.EndorserClientStatus: []status.Code{
 		status.ConnectionFailed, status.EndorsementMismatch,
-		status.PrematureChaincodeExecution,
+		status.PrematureChaincodeExecution, status.DNSFailure,
+		status.ConnectTimeout, status.TLSHandshakeFailure,
 	},
 	status.EndorserServerStatus: []status.Code{
 		status.Code(common.Status_SERVICE_UNAVAILABLE),
 		status.Code(common.Status_INTERNAL_SERVER_ERROR),
 	},
 	status.OrdererClientStatus: []status.Code{
-		status.ConnectionFailed,
+		status.ConnectionFailed, status.DNSFailure,
+		status.ConnectTimeout, status.TLSHandshakeFailure,
 	},
 	status.OrdererServerStatus: []status.Code{
 		status.Code(common.Status_SERVICE_UNAVAILABLE),
 		status.Code(common.Status_INTERNAL_SERVER_ERROR),
 	},
+	// Deprecated: the validation codes are reported in the TxValidationStatus group. The
+	// EventServerStatus group is kept for errors which still report it.
 	status.EventServerStatus: []status.Code{
 		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
 		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
 		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
 		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
 	},
+	// Invalidated transactions, e.g. by a conflicting transaction, are endorsed and submitted again
+	status.TxValidationStatus: []status.Code{
+		status.Code(pb.TxValidationCode_DUPLICATE_TXID),
+		status.Code(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE),
+		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
+		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
+	},
 	// TODO: gRPC introduced retries in v1.8.0. This can be replaced with the
 	// gRPC fail fast option, once available
 	status.GRPCTransportStatus: []status.Code{
@@ -9,6 +9,7 @@ package retry
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/pkg/errors"
 )
 
 var logger = logging.NewLogger("fabsdk/common")
@@ -83,7 +84,7 @@ func (ri *RetryableInvoker) Invoke(invocation Invocation) (interface{}, error) {
 }
 
 func (ri *RetryableInvoker) resolveRetry(err error) bool {
-	errs, ok := err.(multi.Errors)
+	errs, ok := errors.Cause(err).(multi.Errors)
 	if !ok {
 		errs = append(errs, err)
 	}
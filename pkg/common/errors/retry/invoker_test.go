@@ -10,7 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,3 +105,35 @@ func TestInvokeWithBeforeRetry(t *testing.T) {
 	assert.Equal(t, 2, attempt)
 	assert.Equal(t, 1, beforeRetryHandlerCalled)
 }
+
+func TestInvokeWrappedMultiError(t *testing.T) {
+	r := New(Opts{
+		Attempts:       3,
+		BackoffFactor:  2,
+		InitialBackoff: 1 * time.Millisecond,
+		MaxBackoff:     1 * time.Second,
+		RetryableCodes: ChannelClientRetryableCodes,
+	})
+
+	attempt := 0
+	expectedResp := "invoked"
+	invoker := NewInvoker(r)
+	resp, err := invoker.Invoke(
+		func() (interface{}, error) {
+			attempt++
+			if attempt == 1 {
+				// One of the errors is retryable
+				errs := multi.New(
+					status.New(status.OrdererServerStatus, int32(common.Status_BAD_REQUEST), "", nil),
+					status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), "", nil),
+				)
+				return nil, errors.WithMessage(errs, "SendTransaction failed")
+			}
+			return expectedResp, nil
+		},
+	)
+
+	assert.NoError(t, err, "Not expecting error")
+	assert.Equal(t, expectedResp, resp)
+	assert.Equal(t, 2, attempt)
+}
@@ -8,6 +8,7 @@ SPDX-License-Identifier: Apache-2.0
 package retry
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
@@ -26,6 +27,13 @@ type Opts struct {
 	// For example, a backoff factor of 2.5 will result in a backoff of
 	// InitialBackoff * 2.5 * 2.5 on the second attempt.
 	BackoffFactor float64
+	// Jitter randomizes each backoff interval between zero and the computed interval ("full jitter"),
+	// so that the retries of many clients failing at the same time are spread out.
+	Jitter bool
+	// MaxElapsedTime bounds the time spent on an operation and its retries: no retry is attempted once the
+	// time elapsed since the handler was created, including the backoff, would exceed it. Zero means no bound.
+	// Whichever of Attempts and MaxElapsedTime is reached first stops the retries.
+	MaxElapsedTime time.Duration
 	// RetryableCodes defines the status codes, mapped by group, returned by fabric-sdk-go
 	// that warrant a retry. This will default to retry.DefaultRetryableCodes.
 	RetryableCodes map[status.Group][]status.Code
@@ -41,6 +49,7 @@ type Handler interface {
 type impl struct {
 	opts    Opts
 	retries int
+	start   time.Time
 }
 
 // New retry Handler with the given opts
@@ -48,19 +57,37 @@ func New(opts Opts) Handler {
 	if len(opts.RetryableCodes) == 0 {
 		opts.RetryableCodes = DefaultRetryableCodes
 	}
-	return &impl{opts: opts}
+	return &impl{opts: opts, start: time.Now()}
 }
 
 // WithDefaults new retry Handler with default opts
 func WithDefaults() Handler {
-	return &impl{opts: DefaultOpts}
+	return &impl{opts: DefaultOpts, start: time.Now()}
 }
 
 // WithAttempts new retry Handler with given attempts. Other opts are set to default.
 func WithAttempts(attempts int) Handler {
 	opts := DefaultOpts
 	opts.Attempts = attempts
-	return &impl{opts: opts}
+	return &impl{opts: opts, start: time.Now()}
+}
+
+// WithExponentialBackoff returns the given opts with the given exponential backoff and full jitter, i.e. the
+// backoff interval of retry n is a random duration up to min(maxBackoff, initialBackoff * factor^n). The other
+// opts, e.g. the retryable codes, are kept, so the opts of the client should be given (e.g. DefaultChClientOpts).
+func WithExponentialBackoff(opts Opts, initialBackoff, maxBackoff time.Duration, factor float64) Opts {
+	opts.InitialBackoff = initialBackoff
+	opts.MaxBackoff = maxBackoff
+	opts.BackoffFactor = factor
+	opts.Jitter = true
+	return opts
+}
+
+// WithMaxElapsedTime returns the given opts with the given maximum elapsed time, i.e. no retry is attempted
+// once the time spent on the operation and its retries would exceed maxElapsedTime. The other opts are kept.
+func WithMaxElapsedTime(opts Opts, maxElapsedTime time.Duration) Opts {
+	opts.MaxElapsedTime = maxElapsedTime
+	return opts
 }
 
 // Required determines if retry is required for the given error
@@ -72,7 +99,11 @@ func (i *impl) Required(err error) bool {
 
 	s, ok := status.FromError(err)
 	if ok && i.isRetryable(s.Group, s.Code) {
-		time.Sleep(i.backoffPeriod())
+		backoff := i.backoffPeriod()
+		if i.opts.MaxElapsedTime > 0 && time.Since(i.start)+backoff >= i.opts.MaxElapsedTime {
+			return false
+		}
+		time.Sleep(backoff)
 		i.retries++
 		return true
 	}
@@ -89,6 +120,9 @@ func (i *impl) backoffPeriod() time.Duration {
 	if backoff > max {
 		backoff = max
 	}
+	if i.opts.Jitter && backoff >= 1 {
+		backoff = float64(rand.Int63n(int64(backoff) + 1))
+	}
 
 	return time.Duration(backoff)
 }
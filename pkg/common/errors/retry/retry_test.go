@@ -13,7 +13,10 @@ import (
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/stretchr/testify/assert"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
 func TestRetryRequired(t *testing.T) {
@@ -40,6 +43,49 @@ func TestRetryRequired(t *testing.T) {
 	assert.False(t, r.Required(unknownErr), "Expected retry to not be required on unknown error")
 }
 
+func TestRetryPrematureExecution(t *testing.T) {
+	message := "transaction returned with failure: premature execution - chaincode (somecc:v1) is being launched"
+	fromResponse := status.NewFromProposalResponse(&pb.ProposalResponse{
+		Response: &pb.Response{Status: int32(common.Status_INTERNAL_SERVER_ERROR), Message: message},
+	}, "peer0")
+	fromGRPC, ok := status.NewFromPrematureExecution(grpcstatus.New(grpcCodes.Unknown, message).Message(), nil)
+	assert.True(t, ok, "Expected premature execution error")
+
+	for name, opts := range map[string]Opts{
+		"DefaultOpts":         DefaultOpts,
+		"DefaultChClientOpts": DefaultChClientOpts,
+		"DefaultResMgmtOpts":  DefaultResMgmtOpts,
+	} {
+		for _, err := range []*status.Status{fromResponse, fromGRPC} {
+			i := New(opts).(*impl)
+			assert.True(t, i.isRetryable(err.Group, err.Code), "Expected premature execution to be retryable with %s", name)
+			first := i.backoffPeriod()
+			i.retries = 1
+			assert.True(t, i.backoffPeriod() > first, "Expected the backoff to grow with %s", name)
+		}
+	}
+}
+
+func TestRetryTxValidationCodes(t *testing.T) {
+	for name, opts := range map[string]Opts{
+		"DefaultOpts":         DefaultOpts,
+		"DefaultChClientOpts": DefaultChClientOpts,
+		"DefaultResMgmtOpts":  DefaultResMgmtOpts,
+	} {
+		i := New(opts).(*impl)
+		for _, code := range []pb.TxValidationCode{
+			pb.TxValidationCode_DUPLICATE_TXID,
+			pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE,
+			pb.TxValidationCode_MVCC_READ_CONFLICT,
+			pb.TxValidationCode_PHANTOM_READ_CONFLICT,
+		} {
+			assert.True(t, i.isRetryable(status.TxValidationStatus, int32(code)), "Expected %s to be retryable with %s", code, name)
+			assert.True(t, i.isRetryable(status.EventServerStatus, int32(code)), "Expected %s to remain retryable in the deprecated group with %s", code, name)
+		}
+		assert.False(t, i.isRetryable(status.TxValidationStatus, int32(pb.TxValidationCode_BAD_PAYLOAD)), "Expected BAD_PAYLOAD not to be retryable with %s", name)
+	}
+}
+
 func TestBackoffPeriod(t *testing.T) {
 	testAttempts := 10
 	testBackoffFactor := 3.34
@@ -63,3 +109,51 @@ func TestBackoffPeriod(t *testing.T) {
 	i.retries = 3
 	assert.Equal(t, testMaxBackoff, i.backoffPeriod(), "Expected max backoff")
 }
+
+func TestBackoffPeriodWithJitter(t *testing.T) {
+	opts := WithExponentialBackoff(DefaultChClientOpts, 100*time.Millisecond, time.Second, 2)
+	assert.True(t, opts.Jitter, "Expected jitter")
+	assert.Equal(t, DefaultChClientOpts.Attempts, opts.Attempts, "Expected attempts of the given opts")
+	assert.Equal(t, ChannelClientRetryableCodes, opts.RetryableCodes, "Expected retryable codes of the given opts")
+
+	i := New(opts).(*impl)
+	distinct := make(map[time.Duration]bool)
+	for j := 0; j < 20; j++ {
+		backoff := i.backoffPeriod()
+		assert.True(t, backoff >= 0 && backoff <= 100*time.Millisecond, "Expected backoff up to the initial backoff on first attempt")
+		distinct[backoff] = true
+	}
+	assert.True(t, len(distinct) > 1, "Expected randomized backoff")
+
+	i.retries = 5
+	for j := 0; j < 20; j++ {
+		backoff := i.backoffPeriod()
+		assert.True(t, backoff >= 0 && backoff <= time.Second, "Expected backoff up to the max backoff")
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	transientErr := status.New(status.EndorserClientStatus,
+		status.EndorsementMismatch.ToInt32(), "", nil)
+
+	opts := WithMaxElapsedTime(DefaultChClientOpts, 100*time.Millisecond)
+	assert.Equal(t, ChannelClientRetryableCodes, opts.RetryableCodes, "Expected retryable codes of the given opts")
+	opts.Attempts = 100
+	opts.InitialBackoff = 30 * time.Millisecond
+	opts.MaxBackoff = 30 * time.Millisecond
+	r := New(opts)
+
+	start := time.Now()
+	retries := 0
+	for r.Required(transientErr) {
+		retries++
+	}
+	assert.True(t, retries > 0 && retries <= 3, "Expected retries to stop before the max elapsed time")
+	assert.True(t, time.Since(start) < 100*time.Millisecond, "Expected max elapsed time not to be exceeded")
+
+	// The attempts still apply
+	opts.Attempts = 1
+	r = New(opts)
+	assert.True(t, r.Required(transientErr))
+	assert.False(t, r.Required(transientErr), "Expected retry to not be required after exhausting attempts")
+}
@@ -61,8 +61,41 @@ const (
 	NoMatchingOrdererEntity Code = 23
 
 	// PrematureChaincodeExecution indicates that an attempt was made to invoke a chaincode that's
-	// in the process of being launched.
+	// in the process of being launched, e.g. because the peer hasn't committed the chaincode definition yet.
+	// It's returned in the EndorserClientStatus group whether the peer reported the condition as a gRPC error
+	// or in its proposal response. The condition is transient, so the default retry options retry it with backoff.
 	PrematureChaincodeExecution Code = 24
+
+	// ConfigSequenceMismatch is returned when the sequence of the channel configuration differs from the expected sequence,
+	// e.g. because the configuration was updated after a configuration update was computed
+	ConfigSequenceMismatch Code = 25
+
+	// EndorsementPolicyNotSatisfied is returned when the endorsements collected by the SDK don't satisfy the
+	// endorsement policy of the chaincode, e.g. because several endorsements are from the same organization
+	EndorsementPolicyNotSatisfied Code = 26
+
+	// AlreadySubmitted is returned when a transaction with the same idempotency key was already submitted
+	// and its outcome isn't known yet
+	AlreadySubmitted Code = 27
+
+	// QuorumNotReached is returned when fewer peers than the query quorum returned identical response payloads
+	QuorumNotReached Code = 28
+
+	// NotFound is returned when the requested ledger entry, e.g. a block, doesn't exist
+	NotFound Code = 29
+
+	// DNSFailure is returned when the host name of a target couldn't be resolved while dialing a connection
+	DNSFailure Code = 30
+
+	// ConnectTimeout is returned when the TCP connect to a target timed out while dialing a connection
+	ConnectTimeout Code = 31
+
+	// TLSHandshakeFailure is returned when the TLS handshake with a target failed or timed out while dialing a connection
+	TLSHandshakeFailure Code = 32
+
+	// EventServiceUnavailable is returned when a transaction isn't broadcast because the event service which would
+	// deliver its commit event isn't connected
+	EventServiceUnavailable Code = 33
 )
 
 // CodeName maps the codes in this packages to human-readable strings
@@ -82,6 +115,15 @@ var CodeName = map[int32]string{
 	22: "NO_MATCHING_PEER_ENTITY",
 	23: "NO_MATCHING_ORDERER_ENTITY",
 	24: "PREMATURE_CHAINCODE_EXECUTION",
+	25: "CONFIG_SEQUENCE_MISMATCH",
+	26: "ENDORSEMENT_POLICY_NOT_SATISFIED",
+	27: "ALREADY_SUBMITTED",
+	28: "QUORUM_NOT_REACHED",
+	29: "NOT_FOUND",
+	30: "DNS_FAILURE",
+	31: "CONNECT_TIMEOUT",
+	32: "TLS_HANDSHAKE_FAILURE",
+	33: "EVENT_SERVICE_UNAVAILABLE",
 }
 
 // ToInt32 cast to int32
@@ -11,10 +11,12 @@ package status
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	grpcstatus "google.golang.org/grpc/status"
 )
@@ -55,7 +57,11 @@ const (
 
 	// EndorserServerStatus status returned by the endorser server
 	EndorserServerStatus
-	// EventServerStatus status returned by the eventhub
+	// EventServerStatus status returned by the eventhub.
+	//
+	// Deprecated: the validation codes of invalidated transactions are reported in the
+	// TxValidationStatus group. The default retryable codes list them in both groups until
+	// this group is removed.
 	EventServerStatus
 	// OrdererServerStatus status returned by the ordering service
 	OrdererServerStatus
@@ -72,20 +78,26 @@ const (
 	OrdererClientStatus
 	// ClientStatus is a generic client status
 	ClientStatus
+
+	// TxValidationStatus is the validation code of a transaction which was invalidated
+	// by the committing peers, as reported by the commit event of the transaction.
+	// It replaces the deprecated EventServerStatus group for the validation codes.
+	TxValidationStatus
 )
 
 // GroupName maps the groups in this packages to human-readable strings
 var GroupName = map[int32]string{
-	0: "Unknown",
-	1: "gRPC Transport Status",
-	2: "HTTP Transport Status",
-	3: "Endorser Server Status",
-	4: "Event Server Status",
-	5: "Orderer Server Status",
-	6: "Fabric CA Server Status",
-	7: "Endorser Client Status",
-	8: "Orderer Client Status",
-	9: "Client Status",
+	0:  "Unknown",
+	1:  "gRPC Transport Status",
+	2:  "HTTP Transport Status",
+	3:  "Endorser Server Status",
+	4:  "Event Server Status",
+	5:  "Orderer Server Status",
+	6:  "Fabric CA Server Status",
+	7:  "Endorser Client Status",
+	8:  "Orderer Client Status",
+	9:  "Client Status",
+	10: "Transaction Validation Status",
 }
 
 func (g Group) String() string {
@@ -125,7 +137,7 @@ func (s *Status) codeString() string {
 		return ToGRPCStatusCode(s.Code).String()
 	case EndorserServerStatus, OrdererServerStatus:
 		return ToFabricCommonStatusCode(s.Code).String()
-	case EventServerStatus:
+	case EventServerStatus, TxValidationStatus:
 		return ToTransactionValidationCode(s.Code).String()
 	case EndorserClientStatus, OrdererClientStatus, ClientStatus:
 		return ToSDKStatusCode(s.Code).String()
@@ -139,16 +151,43 @@ func New(group Group, code int32, msg string, details []interface{}) *Status {
 	return &Status{Group: group, Code: code, Message: msg, Details: details}
 }
 
-// NewFromProposalResponse creates a status created from the given ProposalResponse
+// NewFromProposalResponse creates a status created from the given ProposalResponse. A response with which the
+// peer rejects the proposal because the chaincode isn't ready yet has the PrematureChaincodeExecution status.
 func NewFromProposalResponse(res *pb.ProposalResponse, endorser string) *Status {
 	if res == nil {
 		return nil
 	}
 	details := []interface{}{endorser, res.Response.Payload}
 
+	if res.Response.Status == int32(common.Status_INTERNAL_SERVER_ERROR) {
+		if s, ok := NewFromPrematureExecution(res.Response.Message, details); ok {
+			return s
+		}
+	}
 	return New(EndorserServerStatus, res.Response.Status, res.Response.Message, details)
 }
 
+// prematureExecution is the part of the message with which a peer rejects a proposal for a chaincode which is
+// still being launched, e.g. because the peer hasn't committed the chaincode definition yet
+const prematureExecution = "premature execution"
+
+// NewFromPrematureExecution returns a PrematureChaincodeExecution status if the message of a peer error reports
+// the premature execution of a chaincode, otherwise it returns nil, false
+func NewFromPrematureExecution(message string, details []interface{}) (*Status, bool) {
+	index := strings.Index(message, prematureExecution)
+	if index == -1 {
+		return nil, false
+	}
+	return New(EndorserClientStatus, PrematureChaincodeExecution.ToInt32(), message[index:], details), true
+}
+
+// IsPrematureExecution returns true if err was caused by the premature execution of a chaincode, which is
+// transient: the proposal succeeds once the chaincode is launched
+func IsPrematureExecution(err error) bool {
+	s, ok := FromError(err)
+	return ok && s.Group == EndorserClientStatus && s.Code == PrematureChaincodeExecution.ToInt32()
+}
+
 // NewFromGRPCStatus new Status from gRPC status response
 func NewFromGRPCStatus(s *grpcstatus.Status) *Status {
 	if s == nil {
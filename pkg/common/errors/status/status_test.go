@@ -48,6 +48,35 @@ func TestStatusConstructors(t *testing.T) {
 	assert.Equal(t, "localhost", s.Details[0].(string))
 }
 
+func TestPrematureExecutionStatus(t *testing.T) {
+	message := "premature execution - chaincode (somecc:v1) is being launched"
+	s := NewFromProposalResponse(&pb.ProposalResponse{
+		Response: &pb.Response{
+			Status:  int32(common.Status_INTERNAL_SERVER_ERROR),
+			Message: "transaction returned with failure: " + message,
+		}}, "localhost")
+	assert.Equal(t, EndorserClientStatus, s.Group)
+	assert.EqualValues(t, PrematureChaincodeExecution, ToSDKStatusCode(s.Code))
+	assert.Equal(t, message, s.Message, "Expected the message to start with the premature execution")
+	assert.Equal(t, "localhost", s.Details[0].(string))
+	assert.True(t, IsPrematureExecution(errors.Wrap(s, "endorsement failed")))
+
+	s = NewFromProposalResponse(&pb.ProposalResponse{
+		Response: &pb.Response{
+			Status:  int32(common.Status_INTERNAL_SERVER_ERROR),
+			Message: "chaincode error",
+		}}, "localhost")
+	assert.Equal(t, EndorserServerStatus, s.Group)
+	assert.EqualValues(t, common.Status_INTERNAL_SERVER_ERROR, ToPeerStatusCode(s.Code))
+	assert.False(t, IsPrematureExecution(s))
+
+	_, ok := NewFromPrematureExecution("chaincode error", nil)
+	assert.False(t, ok)
+	assert.False(t, IsPrematureExecution(New(EndorserServerStatus, PrematureChaincodeExecution.ToInt32(), message, nil)),
+		"Expected the code to be an SDK code")
+	assert.False(t, IsPrematureExecution(fmt.Errorf(message)))
+}
+
 func TestFromError(t *testing.T) {
 	s := New(EndorserClientStatus, ConnectionFailed.ToInt32(), "test", nil)
 	derivedStatus, ok := FromError(s)
@@ -108,6 +137,9 @@ func TestStatusCodeString(t *testing.T) {
 	s = Status{Group: EventServerStatus, Code: int32(pb.TxValidationCode_BAD_CHANNEL_HEADER)}
 	assert.Equal(t, pb.TxValidationCode_BAD_CHANNEL_HEADER.String(), s.codeString())
 
+	s = Status{Group: TxValidationStatus, Code: int32(pb.TxValidationCode_MVCC_READ_CONFLICT)}
+	assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT.String(), s.codeString())
+
 	unknownCode45779 := 45779
 	s = Status{Code: int32(unknownCode45779)}
 	assert.Equal(t, Unknown.String(), s.codeString())
@@ -31,12 +31,35 @@ type ChannelConfig interface {
 type ChannelCfg interface {
 	ID() string
 	BlockNumber() uint64
+	Sequence() uint64
 	MSPs() []*mspCfg.MSPConfig
 	AnchorPeers() []*OrgAnchorPeer
 	Orderers() []string
 	Versions() *Versions
+	HasCapability(group ConfigGroupKey, capability string) bool
 }
 
+// ConfigGroupKey is the name of a group of the channel config which holds capabilities
+type ConfigGroupKey string
+
+const (
+	// ChannelGroupKey is the name of the channel group
+	ChannelGroupKey ConfigGroupKey = "Channel"
+	// OrdererGroupKey is the name of the orderer group
+	OrdererGroupKey ConfigGroupKey = "Orderer"
+	// ApplicationGroupKey is the name of the application group
+	ApplicationGroupKey ConfigGroupKey = "Application"
+)
+
+const (
+	// V1_1Capability indicates that Fabric 1.1 features are enabled
+	V1_1Capability = "V1_1"
+	// V1_2Capability indicates that Fabric 1.2 features are enabled
+	V1_2Capability = "V1_2"
+	// V1_3Capability indicates that Fabric 1.3 features are enabled
+	V1_3Capability = "V1_3"
+)
+
 // ChannelMembership helps identify a channel's members
 type ChannelMembership interface {
 	// Validate if the given ID was issued by the channel's members
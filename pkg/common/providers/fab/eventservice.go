@@ -40,6 +40,12 @@ type TxStatusEvent struct {
 	SourceURL string
 }
 
+// CCUpgradeEventName is the name of the informational chaincode event which is received by the chaincode event
+// registrations of a chaincode whose event filter matches it (e.g. ".*"), when an upgrade of the chaincode is
+// committed, so that consumers may resync their state. The event is only sent if enabled on the event service
+// (see dispatcher.WithChaincodeUpgradeEvents) and for full blocks. Its payload is that of the lscc upgrade event.
+const CCUpgradeEventName = "$upgrade"
+
 // CCEvent contains the data for a chaincode event
 type CCEvent struct {
 	// TxID is the ID of the transaction in which the event was set
@@ -54,6 +60,10 @@ type CCEvent struct {
 	// BlockNumber contains the block number in which the
 	// chaincode event was committed
 	BlockNumber uint64
+	// TxIndex is the index of the transaction which set the event
+	// within the block, which is also its index within the filtered
+	// block. Events are ordered by (BlockNumber, TxIndex).
+	TxIndex int
 	// SourceURL specifies the URL of the peer that produced the event
 	SourceURL string
 }
@@ -103,6 +113,60 @@ type EventService interface {
 	Unregister(reg Registration)
 }
 
+// NamespaceEventService is optionally implemented by an EventService which filters the block events of a
+// registration by chaincode namespace before they're dispatched, parsing each block once for all its registrations
+type NamespaceEventService interface {
+	// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+	// touching one of the chaincode namespaces, i.e. invoking the chaincode or reading or writing its state.
+	// If trim is true, the blocks of the events only contain the transactions touching the namespaces.
+	// - filter is an optional filter that filters out unwanted events. (Note: Only one filter may be specified.)
+	// - Returns the registration and a channel that is used to receive events. The channel
+	//   is closed when Unregister is called.
+	RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...BlockFilter) (Registration, <-chan *BlockEvent, error)
+}
+
+// OverflowPolicy determines what happens to the events of a registration when its event channel is full,
+// i.e. when the consumer doesn't keep up with the events
+type OverflowPolicy int
+
+const (
+	// OverflowDefault applies the event consumer timeout of the event service: the event is dropped if the
+	// channel is still full when the timeout expires
+	OverflowDefault OverflowPolicy = iota
+	// OverflowBlock waits until the consumer receives the event, so that no event is dropped. Note that a slow
+	// consumer then holds up the events of all the registrations of the event service.
+	OverflowBlock
+	// OverflowDropOldest drops the oldest event of the channel to make room for the event
+	OverflowDropOldest
+	// OverflowDropNewest drops the event
+	OverflowDropNewest
+)
+
+// BufferOpts are the options of the event channel of a registration
+type BufferOpts struct {
+	// Size is the capacity of the event channel, or zero for the default capacity of the event service
+	Size int
+	// OverflowPolicy determines what happens to the events when the channel is full
+	OverflowPolicy OverflowPolicy
+	// OnOverflow, if not nil, is called with each event of the registration which is dropped. It's called by
+	// the dispatcher of the event service, so it mustn't block.
+	OnOverflow func(event interface{})
+}
+
+// BufferedEventService is optionally implemented by an EventService whose registrations may have their own
+// event buffer and overflow policy
+type BufferedEventService interface {
+	// Buffered returns a view of the event service whose registrations have the given buffer options
+	Buffered(opts BufferOpts) EventService
+}
+
+// ConnectedEventService is optionally implemented by an EventService which reports whether its connection to the
+// event server is up, e.g. so that a transaction isn't broadcast while its commit event can't be received
+type ConnectedEventService interface {
+	// Connected returns false if the event service isn't connected to the event server, e.g. while it reconnects
+	Connected() bool
+}
+
 // ConnectionEvent is sent when the client disconnects from or
 // reconnects to the event server. Connected == true means that the
 // client has connected, whereas Connected == false means that the
@@ -18,3 +18,9 @@ type Peer interface {
 
 	// TODO: Roles, Name, EnrollmentCertificate (if needed)
 }
+
+// PeerState is implemented by peers which know the state of their ledger
+type PeerState interface {
+	// BlockHeight returns the height of the peer's ledger on the channel
+	BlockHeight() uint64
+}
@@ -62,6 +62,8 @@ type ProcessProposalRequest struct {
 // TransactionProposalResponse respresents the result of transaction proposal processing.
 type TransactionProposalResponse struct {
 	Endorser string
+	// MSPID is the MSP ID of the endorser
+	MSPID string
 	// Status is the EndorserStatus
 	Status int32
 	// ChaincodeStatus is the status returned by Chaincode
@@ -57,6 +57,13 @@ type SelectionService interface {
 	GetEndorsersForChaincode(chaincodeIDs []string, opts ...options.Opt) ([]Peer, error)
 }
 
+// CCInterest is a chaincode invoked by a transaction, e.g. through a chaincode-to-chaincode call, along with the
+// private data collections of the chaincode accessed by the transaction
+type CCInterest struct {
+	ID          string
+	Collections []string
+}
+
 // DiscoveryProvider is used to discover peers on the network
 type DiscoveryProvider interface {
 	CreateDiscoveryService(channelID string) (DiscoveryService, error)
@@ -73,12 +80,42 @@ type TargetFilter interface {
 	Accept(peer Peer) bool
 }
 
+// TargetSorter allows for ordering target peers by preference
+type TargetSorter interface {
+	// Sort returns the peers in order of preference, the most preferred peer first
+	Sort(peers []Peer) []Peer
+}
+
 // CommManager enables network communication.
 type CommManager interface {
 	DialContext(ctx reqContext.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
 	ReleaseConn(conn *grpc.ClientConn)
 }
 
+// CommStatsProvider is optionally implemented by a CommManager which pools its connections, to report the
+// statistics of the pool, e.g. to diagnose connections which are leaked (i.e. never released)
+type CommStatsProvider interface {
+	// Stats returns the statistics of the connections to each target
+	Stats() []CommStats
+}
+
+// CommStats are the statistics of the connections of a CommManager to a target. The counts are cumulative.
+type CommStats struct {
+	Target string
+	// Active is the number of usages of the connections to the target which weren't released
+	Active int
+	// Hits is the number of usages served by a pooled connection
+	Hits uint64
+	// Misses is the number of usages for which a new connection was created
+	Misses uint64
+	// Dials is the number of dials of the connections to the target, including the reconnections
+	Dials uint64
+	// DialFailures is the number of dials which failed
+	DialFailures uint64
+	// Releases is the number of usages which were released
+	Releases uint64
+}
+
 //EndpointConfig contains endpoint network configurations
 type EndpointConfig interface {
 	TimeoutOrDefault(TimeoutType) time.Duration
@@ -103,6 +140,12 @@ type EndpointConfig interface {
 	CryptoConfigPath() string
 }
 
+// TLSClientCertProvider supplies the client certificates used for mutual TLS. The provider is
+// consulted on every TLS handshake, allowing certificates to be rotated without recreating the SDK.
+type TLSClientCertProvider interface {
+	TLSClientCerts() ([]tls.Certificate, error)
+}
+
 // TimeoutType enumerates the different types of outgoing connections
 type TimeoutType int
 
@@ -137,6 +180,8 @@ const (
 	ChannelConfigRefresh
 	// ChannelMembershipRefresh channel membership refresh interval
 	ChannelMembershipRefresh
+	// PeerHandshake peer TLS handshake timeout
+	PeerHandshake
 )
 
 // EventServiceType specifies the type of event service to use
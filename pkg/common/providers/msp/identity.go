@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package msp
 
 import (
+	"crypto/tls"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/pkg/errors"
 )
@@ -53,6 +55,15 @@ type SigningIdentity interface {
 	PrivateKey() core.Key
 }
 
+// TLSIdentity is implemented by identities which have their own TLS client certificate, separate from their
+// enrollment certificate. It's presented to peers and orderers on requests made on behalf of the identity.
+// Event service connections are shared, they always present the client certificate from the config.
+type TLSIdentity interface {
+
+	// TLSClientCertificate returns the TLS client certificate of the identity, or nil if it has none
+	TLSClientCertificate() (*tls.Certificate, error)
+}
+
 // IdentityIdentifier is a holder for the identifier of a specific
 // identity, naturally namespaced, by its provider identifier.
 type IdentityIdentifier struct {
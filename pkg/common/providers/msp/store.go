@@ -8,10 +8,14 @@ package msp
 
 // UserData is the representation of User in UserStore
 // PrivateKey is stored separately, in the crypto store
+// TLSCertificate is optional: it's the TLS client certificate of the user (in PEM format) that is presented
+// instead of the configured client certificate when connecting on behalf of the user. Its private key is also
+// stored in the crypto store.
 type UserData struct {
 	ID                    string
 	MSPID                 string
 	EnrollmentCertificate []byte
+	TLSCertificate        []byte
 }
 
 // UserStore is responsible for UserData persistence
@@ -39,6 +39,7 @@ func DefaultMockConfig(mockCtrl *gomock.Controller) *MockEndpointConfig {
 	config.EXPECT().TLSCACertPool(BadCert).Return(CertPool, errors.New(ErrorMessage)).AnyTimes()
 	config.EXPECT().TLSCACertPool().Return(CertPool, nil).AnyTimes()
 	config.EXPECT().TimeoutOrDefault(fab.EndorserConnection).Return(time.Second * 5).AnyTimes()
+	config.EXPECT().TimeoutOrDefault(fab.PeerHandshake).Return(time.Second * 5).AnyTimes()
 	config.EXPECT().TLSClientCerts().Return([]tls.Certificate{TLSCert}, nil).AnyTimes()
 
 	return config
@@ -52,6 +53,7 @@ func BadTLSClientMockConfig(mockCtrl *gomock.Controller) *MockEndpointConfig {
 	config.EXPECT().TLSCACertPool(BadCert).Return(CertPool, errors.New(ErrorMessage)).AnyTimes()
 	config.EXPECT().TLSCACertPool().Return(CertPool, nil).AnyTimes()
 	config.EXPECT().TimeoutOrDefault(fab.EndorserConnection).Return(time.Second * 5).AnyTimes()
+	config.EXPECT().TimeoutOrDefault(fab.PeerHandshake).Return(time.Second * 5).AnyTimes()
 	config.EXPECT().TLSClientCerts().Return(nil, errors.Errorf(ErrorMessage)).AnyTimes()
 
 	return config
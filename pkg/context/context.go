@@ -8,6 +8,7 @@ package context
 
 import (
 	reqContext "context"
+	"crypto/tls"
 
 	"github.com/pkg/errors"
 
@@ -25,6 +26,12 @@ type Client struct {
 	msp.SigningIdentity
 }
 
+// TLSClientCertificate returns the TLS client certificate of the signing identity, or nil if the identity
+// doesn't have its own TLS client certificate
+func (c *Client) TLSClientCertificate() (*tls.Certificate, error) {
+	return tlsClientCertificate(c.SigningIdentity)
+}
+
 //Channel supplies the configuration for channel context client
 type Channel struct {
 	context.Client
@@ -32,6 +39,7 @@ type Channel struct {
 	selection      fab.SelectionService
 	channelService fab.ChannelService
 	channelID      string
+	release        func()
 }
 
 //Providers returns core providers
@@ -59,6 +67,18 @@ func (c *Channel) ChannelID() string {
 	return c.channelID
 }
 
+//TLSClientCertificate returns the TLS client certificate of the identity of the channel context, if any
+func (c *Channel) TLSClientCertificate() (*tls.Certificate, error) {
+	return tlsClientCertificate(c.Client)
+}
+
+//Close releases the discovery and selection services of the channel context if they're shared with other contexts
+func (c *Channel) Close() {
+	if c.release != nil {
+		c.release()
+	}
+}
+
 //Provider implementation of Providers interface
 type Provider struct {
 	cryptoSuiteConfig core.CryptoSuiteConfig
@@ -225,6 +245,12 @@ type serviceInit interface {
 	Initialize(context context.Channel) error
 }
 
+// sharedServiceProvider is implemented by channel services which share the discovery
+// and selection services of the channel among channel contexts
+type sharedServiceProvider interface {
+	SharedServices(create func() (fab.DiscoveryService, fab.SelectionService, error)) (fab.DiscoveryService, fab.SelectionService, func(), error)
+}
+
 //NewChannel creates new channel context client
 // Not be used by end developers, fabsdk package use only
 func NewChannel(clientProvider context.ClientProvider, channelID string) (*Channel, error) {
@@ -239,34 +265,49 @@ func NewChannel(clientProvider context.ClientProvider, channelID string) (*Chann
 		return nil, errors.WithMessage(err, "failed to get channel service to create channel client")
 	}
 
-	discoveryService, err := client.DiscoveryProvider().CreateDiscoveryService(channelID)
-	if err != nil {
-		return nil, errors.WithMessage(err, "failed to get discovery service to create channel client")
-	}
-
-	selectionService, err := client.SelectionProvider().CreateSelectionService(channelID)
-	if err != nil {
-		return nil, errors.WithMessage(err, "failed to get selection service to create channel client")
-	}
-
 	channel := &Channel{
 		Client:         client,
-		selection:      selectionService,
-		discovery:      discoveryService,
 		channelService: channelService,
 		channelID:      channelID,
 	}
 
-	//initialize
-	if pi, ok := channelService.(serviceInit); ok {
-		pi.Initialize(channel)
+	//The discovery and selection services are initialized with the context they're created for
+	createServices := func() (fab.DiscoveryService, fab.SelectionService, error) {
+		discoveryService, err := client.DiscoveryProvider().CreateDiscoveryService(channelID)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to get discovery service to create channel client")
+		}
+
+		selectionService, err := client.SelectionProvider().CreateSelectionService(channelID)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to get selection service to create channel client")
+		}
+
+		channel.discovery = discoveryService
+		channel.selection = selectionService
+
+		if pi, ok := discoveryService.(serviceInit); ok {
+			pi.Initialize(channel)
+		}
+
+		if pi, ok := selectionService.(serviceInit); ok {
+			pi.Initialize(channel)
+		}
+
+		return discoveryService, selectionService, nil
 	}
 
-	if pi, ok := discoveryService.(serviceInit); ok {
-		pi.Initialize(channel)
+	if shared, ok := channelService.(sharedServiceProvider); ok {
+		channel.discovery, channel.selection, channel.release, err = shared.SharedServices(createServices)
+	} else {
+		_, _, err = createServices()
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	if pi, ok := selectionService.(serviceInit); ok {
+	//initialize
+	if pi, ok := channelService.(serviceInit); ok {
 		pi.Initialize(channel)
 	}
 
@@ -277,6 +318,10 @@ type reqContextKey string
 
 //ReqContextTimeoutOverrides key for grpc context value of timeout overrides
 var ReqContextTimeoutOverrides = reqContextKey("timeout-overrides")
+//ReqContextTLSPins key for grpc context value of the TLS certificate fingerprints pinned per target address
+var ReqContextTLSPins = reqContextKey("tls-pins")
+//ReqContextCorrelationID key for grpc context value of the correlation ID of the request
+var ReqContextCorrelationID = reqContextKey("correlation-id")
 var reqContextCommManager = reqContextKey("commManager")
 var reqContextClient = reqContextKey("clientContext")
 
@@ -353,6 +398,52 @@ func RequestClientContext(ctx reqContext.Context) (context.Client, bool) {
 	return clientContext, ok
 }
 
+// RequestTLSPin extracts the SHA-256 fingerprint of the TLS certificate pinned for the given
+// target address (host:port) from the request-scoped context.
+func RequestTLSPin(ctx reqContext.Context, target string) ([]byte, bool) {
+	pins, ok := ctx.Value(ReqContextTLSPins).(map[string][]byte)
+	if !ok {
+		return nil, false
+	}
+	pin, ok := pins[target]
+	return pin, ok
+}
+
+// WithCorrelationID returns a copy of the given context which carries the correlation ID, e.g. the ID of a trace
+// spanning several components. The ID is available to the request contexts derived from the returned context.
+func WithCorrelationID(ctx reqContext.Context, correlationID string) reqContext.Context {
+	return reqContext.WithValue(ctx, ReqContextCorrelationID, correlationID)
+}
+
+// RequestCorrelationID extracts the correlation ID from the request-scoped context.
+func RequestCorrelationID(ctx reqContext.Context) (string, bool) {
+	correlationID, ok := ctx.Value(ReqContextCorrelationID).(string)
+	return correlationID, ok && correlationID != ""
+}
+
+// RequestTLSClientCert extracts the TLS client certificate of the identity of the client context from the
+// request-scoped context. It returns nil if the identity doesn't have its own TLS client certificate, in which
+// case the client certificate from the config is used.
+func RequestTLSClientCert(ctx reqContext.Context) (*tls.Certificate, error) {
+	client, ok := RequestClientContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	return tlsClientCertificate(client)
+}
+
+func tlsClientCertificate(identity interface{}) (*tls.Certificate, error) {
+	tlsIdentity, ok := identity.(msp.TLSIdentity)
+	if !ok {
+		return nil, nil
+	}
+	cert, err := tlsIdentity.TLSClientCertificate()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get TLS client certificate of identity")
+	}
+	return cert, nil
+}
+
 // requestTimeoutOverrides extracts the timeout from timeout override map from the request-scoped context.
 func requestTimeoutOverride(ctx reqContext.Context, timeoutType fab.TimeoutType) time.Duration {
 	timeoutOverrides, ok := ctx.Value(ReqContextTimeoutOverrides).(map[fab.TimeoutType]time.Duration)
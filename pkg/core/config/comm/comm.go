@@ -24,15 +24,14 @@ func TLSConfig(cert *x509.Certificate, serverName string, config fab.EndpointCon
 		return nil, err
 	}
 
-	if cert == nil && (certPool == nil || len(certPool.Subjects()) == 0) {
-		//Return empty tls config if there is no cert provided or if certpool unavailable
-		return &tls.Config{}, nil
-	}
-
-	tlsCaCertPool, err := config.TLSCACertPool(cert)
-
-	if err != nil {
-		return nil, err
+	var tlsCaCertPool *x509.CertPool
+	//The system cert pool is used if there is no cert provided and the cert pool is unavailable,
+	//but the client certs are still presented so that mutual TLS works for peers and orderers alike
+	if cert != nil || (certPool != nil && len(certPool.Subjects()) > 0) {
+		tlsCaCertPool, err = config.TLSCACertPool(cert)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	clientCerts, err := config.TLSClientCerts()
@@ -40,7 +39,26 @@ func TLSConfig(cert *x509.Certificate, serverName string, config fab.EndpointCon
 		return nil, errors.Errorf("Error loading cert/key pair for TLS client credentials: %v", err)
 	}
 
-	return &tls.Config{RootCAs: tlsCaCertPool, Certificates: clientCerts, ServerName: serverName}, nil
+	return &tls.Config{RootCAs: tlsCaCertPool, Certificates: clientCerts, ServerName: serverName,
+		GetClientCertificate: clientCertificate(config)}, nil
+}
+
+// clientCertificate returns a callback that loads the client cert from the config on every
+// TLS handshake so that rotated certs are picked up without recreating the TLS config
+func clientCertificate(config fab.EndpointConfig) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		certs, err := config.TLSClientCerts()
+		if err != nil {
+			return nil, errors.Errorf("Error loading cert/key pair for TLS client credentials: %v", err)
+		}
+
+		if len(certs) > 0 {
+			return &certs[0], nil
+		}
+
+		// no client cert is sent if the certificate is empty
+		return &tls.Certificate{}, nil
+	}
 }
 
 // TLSCertHash is a utility method to calculate the SHA256 hash of the configured certificate (for usage in channel headers)
@@ -50,11 +68,26 @@ func TLSCertHash(config fab.EndpointConfig) []byte {
 		return nil
 	}
 
-	cert := certs[0]
-	if len(cert.Certificate) == 0 {
+	return ClientCertHash(&certs[0])
+}
+
+// ClientCertHash calculates the SHA256 hash of the given client certificate (for usage in channel headers)
+func ClientCertHash(cert *tls.Certificate) []byte {
+	if cert == nil || len(cert.Certificate) == 0 {
 		return nil
 	}
 
 	h := cutil.ComputeSHA256(cert.Certificate[0])
 	return h
 }
+
+// ClientCertTLSConfig returns a copy of the TLS config which presents the given client certificate instead of the
+// client certificate from the config, e.g. the TLS client certificate of an identity
+func ClientCertTLSConfig(tlsConfig *tls.Config, cert *tls.Certificate) *tls.Config {
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.Certificates = []tls.Certificate{*cert}
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return cert, nil
+	}
+	return tlsConfig
+}
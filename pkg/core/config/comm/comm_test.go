@@ -84,6 +84,65 @@ func TestTLSConfigHappyPath(t *testing.T) {
 	}
 }
 
+func TestTLSConfigClientCertificateCallback(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().TLSCACertPool(mockfab.GoodCert).Return(mockfab.CertPool, nil).AnyTimes()
+	config.EXPECT().TLSCACertPool().Return(mockfab.CertPool, nil).AnyTimes()
+
+	rotatedCert := tls.Certificate{Certificate: [][]byte{{5}, {6}}}
+	gomock.InOrder(
+		config.EXPECT().TLSClientCerts().Return([]tls.Certificate{mockfab.TLSCert}, nil),
+		config.EXPECT().TLSClientCerts().Return([]tls.Certificate{rotatedCert}, nil),
+	)
+
+	tlsConfig, err := TLSConfig(mockfab.GoodCert, "", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if tlsConfig.GetClientCertificate == nil {
+		t.Fatal("Expected client certificate callback to be set")
+	}
+
+	cert, err := tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(*cert, rotatedCert) {
+		t.Fatal("Expected rotated cert to be returned on handshake")
+	}
+}
+
+func TestTLSConfigWithoutCACerts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().TLSCACertPool().Return(nil, nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return([]tls.Certificate{mockfab.TLSCert}, nil).AnyTimes()
+
+	tlsConfig, err := TLSConfig(nil, "orderer.example.com", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if tlsConfig.RootCAs != nil {
+		t.Fatal("Expected system cert pool to be used")
+	}
+
+	if tlsConfig.ServerName != "orderer.example.com" {
+		t.Fatal("Incorrect server name!")
+	}
+
+	if len(tlsConfig.Certificates) != 1 || tlsConfig.GetClientCertificate == nil {
+		t.Fatal("Expected client certs to be presented without CA certs")
+	}
+}
+
 func TestNoTlsCertHash(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -0,0 +1,284 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var logger = logging.NewLogger("fabsdk/core")
+
+// ServiceConfigOption is the key of the grpcOptions entry which holds the gRPC service config of an endpoint
+const ServiceConfigOption = "service-config"
+
+// DefaultServiceConfig is a service config suitable for Fabric peers and orderers. It transparently retries
+// calls which fail with UNAVAILABLE, e.g. when the server sends a GOAWAY upon reaching its maxConnectionAge.
+const DefaultServiceConfig = `{
+  "methodConfig": [{
+    "name": [{"service": "protos.Endorser"}, {"service": "protos.Deliver"}, {"service": "orderer.AtomicBroadcast"}],
+    "retryPolicy": {
+      "maxAttempts": 3,
+      "initialBackoff": "0.1s",
+      "maxBackoff": "1s",
+      "backoffMultiplier": 2,
+      "retryableStatusCodes": ["UNAVAILABLE"]
+    }
+  }]
+}`
+
+// maxRetryAttempts is the upper limit on attempts imposed by gRPC on retry policies
+const maxRetryAttempts = 5
+
+var statusCodes = map[string]codes.Code{
+	"OK":                  codes.OK,
+	"CANCELLED":           codes.Canceled,
+	"UNKNOWN":             codes.Unknown,
+	"INVALID_ARGUMENT":    codes.InvalidArgument,
+	"DEADLINE_EXCEEDED":   codes.DeadlineExceeded,
+	"NOT_FOUND":           codes.NotFound,
+	"ALREADY_EXISTS":      codes.AlreadyExists,
+	"PERMISSION_DENIED":   codes.PermissionDenied,
+	"RESOURCE_EXHAUSTED":  codes.ResourceExhausted,
+	"FAILED_PRECONDITION": codes.FailedPrecondition,
+	"ABORTED":             codes.Aborted,
+	"OUT_OF_RANGE":        codes.OutOfRange,
+	"UNIMPLEMENTED":       codes.Unimplemented,
+	"INTERNAL":            codes.Internal,
+	"UNAVAILABLE":         codes.Unavailable,
+	"DATA_LOSS":           codes.DataLoss,
+	"UNAUTHENTICATED":     codes.Unauthenticated,
+}
+
+// ServiceConfig is the subset of the gRPC service config (https://github.com/grpc/grpc/blob/master/doc/service_config.md)
+// which is supported by the SDK, i.e. the retry policies of methods
+type ServiceConfig struct {
+	MethodConfig []MethodConfig
+}
+
+// MethodConfig is the configuration of the methods matched by Name
+type MethodConfig struct {
+	Name        []MethodName
+	RetryPolicy *RetryPolicy
+}
+
+// MethodName matches all methods of Service if Method is empty, otherwise the given method
+type MethodName struct {
+	Service string
+	Method  string
+}
+
+// RetryPolicy defines how calls failing with one of the RetryableStatusCodes are retried at the transport level
+type RetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	BackoffMultiplier    float64
+	RetryableStatusCodes []codes.Code
+}
+
+type jsonServiceConfig struct {
+	MethodConfig []struct {
+		Name        []MethodName
+		RetryPolicy *struct {
+			MaxAttempts          int
+			InitialBackoff       string
+			MaxBackoff           string
+			BackoffMultiplier    float64
+			RetryableStatusCodes []string
+		}
+	}
+}
+
+// ServiceConfigFromGRPCOptions parses the service config of the endpoint's grpcOptions.
+// It returns nil if no service config is configured.
+func ServiceConfigFromGRPCOptions(grpcOptions map[string]interface{}) (*ServiceConfig, error) {
+	value, ok := grpcOptions[ServiceConfigOption]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, errors.Errorf("%s must be a JSON string", ServiceConfigOption)
+	}
+	return ParseServiceConfig(str)
+}
+
+// ParseServiceConfig parses a gRPC service config in its JSON representation
+func ParseServiceConfig(js string) (*ServiceConfig, error) {
+	var jsc jsonServiceConfig
+	if err := json.Unmarshal([]byte(js), &jsc); err != nil {
+		return nil, errors.Wrap(err, "invalid service config")
+	}
+
+	sc := &ServiceConfig{}
+	for _, jmc := range jsc.MethodConfig {
+		mc := MethodConfig{Name: jmc.Name}
+		if jmc.RetryPolicy != nil {
+			rp := &RetryPolicy{
+				MaxAttempts:       jmc.RetryPolicy.MaxAttempts,
+				BackoffMultiplier: jmc.RetryPolicy.BackoffMultiplier,
+			}
+
+			var err error
+			if rp.InitialBackoff, err = time.ParseDuration(jmc.RetryPolicy.InitialBackoff); err != nil {
+				return nil, errors.Wrap(err, "invalid initialBackoff")
+			}
+			if rp.MaxBackoff, err = time.ParseDuration(jmc.RetryPolicy.MaxBackoff); err != nil {
+				return nil, errors.Wrap(err, "invalid maxBackoff")
+			}
+			for _, name := range jmc.RetryPolicy.RetryableStatusCodes {
+				code, ok := statusCodes[strings.ToUpper(name)]
+				if !ok {
+					return nil, errors.Errorf("invalid retryable status code [%s]", name)
+				}
+				rp.RetryableStatusCodes = append(rp.RetryableStatusCodes, code)
+			}
+
+			if err := rp.validate(); err != nil {
+				return nil, err
+			}
+			mc.RetryPolicy = rp
+		}
+		sc.MethodConfig = append(sc.MethodConfig, mc)
+	}
+
+	return sc, nil
+}
+
+func (rp *RetryPolicy) validate() error {
+	if rp.MaxAttempts < 2 {
+		return errors.New("retry policy maxAttempts must be greater than 1")
+	}
+	if rp.MaxAttempts > maxRetryAttempts {
+		rp.MaxAttempts = maxRetryAttempts
+	}
+	if rp.InitialBackoff <= 0 || rp.MaxBackoff <= 0 {
+		return errors.New("retry policy backoffs must be greater than zero")
+	}
+	if rp.BackoffMultiplier <= 0 {
+		return errors.New("retry policy backoffMultiplier must be greater than zero")
+	}
+	if len(rp.RetryableStatusCodes) == 0 {
+		return errors.New("retry policy retryableStatusCodes are required")
+	}
+	return nil
+}
+
+// DialOptions returns the dial options which apply the service config to a connection
+func (sc *ServiceConfig) DialOptions() []grpc.DialOption {
+	if sc == nil {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(sc.unaryInterceptor),
+		grpc.WithStreamInterceptor(sc.streamInterceptor),
+	}
+}
+
+// retryPolicy returns the retry policy of the full method name (/service/method), if any
+func (sc *ServiceConfig) retryPolicy(fullMethod string) *RetryPolicy {
+	parts := strings.Split(strings.TrimPrefix(fullMethod, "/"), "/")
+	if len(parts) != 2 {
+		return nil
+	}
+
+	var serviceMatch *RetryPolicy
+	for _, mc := range sc.MethodConfig {
+		for _, name := range mc.Name {
+			if name.Service != parts[0] {
+				continue
+			}
+			if name.Method == parts[1] {
+				return mc.RetryPolicy
+			}
+			if name.Method == "" && serviceMatch == nil {
+				serviceMatch = mc.RetryPolicy
+			}
+		}
+	}
+	return serviceMatch
+}
+
+func (sc *ServiceConfig) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	rp := sc.retryPolicy(method)
+	if rp == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !rp.retry(ctx, method, attempt, err) {
+			return err
+		}
+	}
+}
+
+// streamInterceptor retries establishing the stream. Streams which fail after being established aren't
+// retried since messages may already have been exchanged.
+func (sc *ServiceConfig) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	rp := sc.retryPolicy(method)
+	if rp == nil {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if !rp.retry(ctx, method, attempt, err) {
+			return stream, err
+		}
+	}
+}
+
+// retry waits for the backoff of the attempt and returns true if the failed attempt is to be retried
+func (rp *RetryPolicy) retry(ctx context.Context, method string, attempt int, err error) bool {
+	if err == nil || attempt >= rp.MaxAttempts || !rp.isRetryable(err) {
+		return false
+	}
+
+	backoff := float64(rp.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= rp.BackoffMultiplier
+	}
+	if backoff > float64(rp.MaxBackoff) {
+		backoff = float64(rp.MaxBackoff)
+	}
+	wait := time.Duration(rand.Int63n(int64(backoff) + 1))
+
+	logger.Debugf("Retrying %s after %s (attempt %d): %s", method, wait, attempt, err)
+
+	select {
+	case <-time.After(wait):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (rp *RetryPolicy) isRetryable(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	code := s.Code()
+	for _, c := range rp.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestParseServiceConfig(t *testing.T) {
+	sc, err := ParseServiceConfig(DefaultServiceConfig)
+	if err != nil {
+		t.Fatalf("Failed to parse default service config: %s", err)
+	}
+
+	rp := sc.retryPolicy("/protos.Endorser/ProcessProposal")
+	if assert.NotNil(t, rp, "Expected retry policy for endorser") {
+		assert.Equal(t, 3, rp.MaxAttempts)
+		assert.Equal(t, 100*time.Millisecond, rp.InitialBackoff)
+		assert.Equal(t, time.Second, rp.MaxBackoff)
+		assert.Equal(t, float64(2), rp.BackoffMultiplier)
+		assert.Equal(t, []codes.Code{codes.Unavailable}, rp.RetryableStatusCodes)
+	}
+	assert.NotNil(t, sc.retryPolicy("/orderer.AtomicBroadcast/Broadcast"))
+	assert.Nil(t, sc.retryPolicy("/protos.Events/Chat"))
+	assert.Nil(t, sc.retryPolicy("invalid"))
+}
+
+func TestParseServiceConfigMethodMatch(t *testing.T) {
+	sc, err := ParseServiceConfig(`{"methodConfig": [
+		{"name": [{"service": "protos.Deliver"}], "retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}},
+		{"name": [{"service": "protos.Deliver", "method": "DeliverFiltered"}], "retryPolicy": {"maxAttempts": 10, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["unavailable", "RESOURCE_EXHAUSTED"]}}
+	]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse service config: %s", err)
+	}
+
+	assert.Equal(t, 2, sc.retryPolicy("/protos.Deliver/Deliver").MaxAttempts)
+	assert.Equal(t, maxRetryAttempts, sc.retryPolicy("/protos.Deliver/DeliverFiltered").MaxAttempts, "Expected attempts to be capped")
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.ResourceExhausted}, sc.retryPolicy("/protos.Deliver/DeliverFiltered").RetryableStatusCodes)
+}
+
+func TestParseServiceConfigInvalid(t *testing.T) {
+	invalid := []string{
+		`{`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 1, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "x", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 0, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["BOGUS"]}}]}`,
+		`{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1}}]}`,
+	}
+	for _, js := range invalid {
+		_, err := ParseServiceConfig(js)
+		assert.Error(t, err, "Expected error parsing %s", js)
+	}
+}
+
+func TestServiceConfigFromGRPCOptions(t *testing.T) {
+	sc, err := ServiceConfigFromGRPCOptions(map[string]interface{}{"fail-fast": true})
+	assert.NoError(t, err)
+	assert.Nil(t, sc)
+	assert.Nil(t, sc.DialOptions(), "Expected no dial options without service config")
+
+	sc, err = ServiceConfigFromGRPCOptions(map[string]interface{}{ServiceConfigOption: DefaultServiceConfig})
+	assert.NoError(t, err)
+	assert.NotNil(t, sc)
+	assert.Len(t, sc.DialOptions(), 2)
+
+	_, err = ServiceConfigFromGRPCOptions(map[string]interface{}{ServiceConfigOption: 5})
+	assert.Error(t, err, "Expected error for non-string service config")
+}
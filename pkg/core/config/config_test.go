@@ -441,6 +441,15 @@ func TestTimeouts(t *testing.T) {
 	if t1 != time.Second*118 {
 		t.Fatalf("Timeout not read correctly. Got: %s", t1)
 	}
+	t1 = endpointConfig.TimeoutOrDefault(fab.PeerHandshake)
+	if t1 != time.Second*2 {
+		t.Fatalf("Handshake timeout should default to the connection timeout. Got: %s", t1)
+	}
+	endpointConfig.backend.coreBackend.(*defConfigBackend).configViper.Set("client.peer.timeout.handshake", "9s")
+	t1 = endpointConfig.TimeoutOrDefault(fab.PeerHandshake)
+	if t1 != time.Second*9 {
+		t.Fatalf("Timeout not read correctly. Got: %s", t1)
+	}
 
 	// Test default
 	endpointConfig.backend.coreBackend.(*defConfigBackend).configViper.Set("client.orderer.timeout.connection", "")
@@ -588,6 +597,29 @@ func TestOrdererWithSubstitutedConfig_WithSubstituteUrlExpression(t *testing.T)
 	}
 }
 
+func TestGetPortIfPresent(t *testing.T) {
+	c := &EndpointConfig{}
+	tests := []struct {
+		url  string
+		port int
+		ok   bool
+	}{
+		{"orderer.example.com:7050", 7050, true},
+		{"grpcs://orderer.example.com:7050", 7050, true},
+		{"grpcs://orderer.example.com", 0, false},
+		{"127.0.0.1:7050", 7050, true},
+		{"[2001:db8::1]:7050", 7050, true},
+		{"grpcs://[2001:db8::1]", 0, false},
+		{"2001:db8::1", 0, false},
+	}
+	for _, test := range tests {
+		port, ok := c.getPortIfPresent(test.url)
+		if port != test.port || ok != test.ok {
+			t.Fatalf("expected port %d (%t) for %s, got %d (%t)", test.port, test.ok, test.url, port, ok)
+		}
+	}
+}
+
 func TestPeersConfig(t *testing.T) {
 	pc, err := endpointConfig.PeersConfig(org0)
 	if err != nil {
@@ -1495,6 +1527,36 @@ func TestTLSClientCertsNoCerts(t *testing.T) {
 	}
 }
 
+type mockCertProvider struct {
+	certs []tls.Certificate
+}
+
+func (p *mockCertProvider) TLSClientCerts() ([]tls.Certificate, error) {
+	return p.certs, nil
+}
+
+func TestTLSClientCertProvider(t *testing.T) {
+	provider := &mockCertProvider{certs: []tls.Certificate{{Certificate: [][]byte{{1}}}}}
+
+	endpointConfig.SetTLSClientCertProvider(provider)
+	defer endpointConfig.SetTLSClientCertProvider(nil)
+
+	certs, err := endpointConfig.TLSClientCerts()
+	if err != nil {
+		t.Fatalf("Expected no errors but got error instead: %s", err)
+	}
+	assert.Equal(t, provider.certs, certs, "Expected certs from provider")
+
+	rotated := []tls.Certificate{{Certificate: [][]byte{{2}}}}
+	provider.certs = rotated
+
+	certs, err = endpointConfig.TLSClientCerts()
+	if err != nil {
+		t.Fatalf("Expected no errors but got error instead: %s", err)
+	}
+	assert.Equal(t, rotated, certs, "Expected rotated certs from provider")
+}
+
 func TestNewGoodOpt(t *testing.T) {
 	_, err := FromFile("../../../test/fixtures/config/config_test.yaml", goodOpt())()
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"io/ioutil"
+	"net"
 	"strings"
 
 	"regexp"
@@ -31,23 +32,61 @@ func IsTLSEnabled(url string) bool {
 	return false
 }
 
-// ToAddress is a utility function to trim the GRPC protocol prefix as it is not needed by GO
-// if the GRPC protocol is not found, the url is returned unchanged
+// IsUnixSocket returns true if the URL refers to a Unix domain socket (unix:///path/to/socket)
+func IsUnixSocket(url string) bool {
+	return strings.HasPrefix(strings.ToLower(url), "unix://")
+}
+
+// ToAddress is a utility function to trim the GRPC protocol prefix (in any case) as it is not needed by GO
+// if the GRPC protocol is not found, the url is returned unchanged. For Unix domain sockets
+// the path of the socket is returned. The brackets of an IPv6 host are kept, e.g. the address of
+// grpcs://[2001:db8::1]:7051 is [2001:db8::1]:7051.
 func ToAddress(url string) string {
-	if strings.HasPrefix(url, "grpc://") {
-		return strings.TrimPrefix(url, "grpc://")
+	if IsUnixSocket(url) {
+		return url[len("unix://"):]
 	}
-	if strings.HasPrefix(url, "grpcs://") {
-		return strings.TrimPrefix(url, "grpcs://")
+	for _, prefix := range []string{"grpc://", "grpcs://"} {
+		if strings.HasPrefix(strings.ToLower(url), prefix) {
+			return url[len(prefix):]
+		}
 	}
 	return url
 }
 
+// SplitHostPort splits the URL (with or without a protocol prefix) into the host and the port of its address.
+// The brackets of an IPv6 host are removed, e.g. grpcs://[2001:db8::1]:7051 is split into 2001:db8::1 and 7051.
+// The port is empty if the address has no port; an IPv6 host without brackets (e.g. 2001:db8::1) has no port,
+// since a port can only follow a bracketed IPv6 host. For Unix domain sockets the host is the path of the socket.
+func SplitHostPort(url string) (host, port string) {
+	if IsUnixSocket(url) {
+		return ToAddress(url), ""
+	}
+	address := url
+	if i := strings.Index(address, "://"); i >= 0 {
+		address = address[i+len("://"):]
+	}
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[:i]
+	}
+
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return host, port
+	}
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		return address[1 : len(address)-1], ""
+	}
+	return address, ""
+}
+
 //AttemptSecured is a utility function which verifies URL and returns if secured connections needs to established
 // for protocol 'grpcs' in URL returns true
 // for protocol 'grpc' in URL returns false
+// for protocol 'unix' in URL returns false
 // for no protocol mentioned, returns !allowInSecure
 func AttemptSecured(url string, allowInSecure bool) bool {
+	if IsUnixSocket(url) {
+		return false
+	}
 	ok, err := regexp.MatchString(".*(?i)s://", url)
 	if ok && err == nil {
 		return true
@@ -47,6 +47,74 @@ func TestToAddress(t *testing.T) {
 	if !strings.HasPrefix(u, "http://") {
 		t.Fatalf("expected url to have kept http:// protocol as prefix")
 	}
+	u = ToAddress("unix:///var/run/peer.sock")
+	if u != "/var/run/peer.sock" {
+		t.Fatalf("expected socket path for unix:// url, got %s", u)
+	}
+}
+
+func TestToAddressHosts(t *testing.T) {
+	tests := []struct {
+		url     string
+		address string
+	}{
+		{"127.0.0.1:7051", "127.0.0.1:7051"},
+		{"grpc://127.0.0.1:7051", "127.0.0.1:7051"},
+		{"grpcs://127.0.0.1:7051", "127.0.0.1:7051"},
+		{"peer0.org1.example.com:7051", "peer0.org1.example.com:7051"},
+		{"grpcs://peer0.org1.example.com:7051", "peer0.org1.example.com:7051"},
+		{"GRPCS://peer0.org1.example.com:7051", "peer0.org1.example.com:7051"},
+		{"[2001:db8::1]:7051", "[2001:db8::1]:7051"},
+		{"grpc://[2001:db8::1]:7051", "[2001:db8::1]:7051"},
+		{"grpcs://[2001:db8::1]:7051", "[2001:db8::1]:7051"},
+		{"Grpcs://[::1]:7051", "[::1]:7051"},
+		{"grpcs://[2001:db8::1]", "[2001:db8::1]"},
+	}
+	for _, test := range tests {
+		if address := ToAddress(test.url); address != test.address {
+			t.Fatalf("expected address %s for %s, got %s", test.address, test.url, address)
+		}
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		url  string
+		host string
+		port string
+	}{
+		{"127.0.0.1:7051", "127.0.0.1", "7051"},
+		{"grpcs://127.0.0.1:7051", "127.0.0.1", "7051"},
+		{"127.0.0.1", "127.0.0.1", ""},
+		{"peer0.org1.example.com:7051", "peer0.org1.example.com", "7051"},
+		{"grpc://peer0.org1.example.com:7051", "peer0.org1.example.com", "7051"},
+		{"grpcs://peer0.org1.example.com", "peer0.org1.example.com", ""},
+		{"https://ca.org1.example.com:7054/path", "ca.org1.example.com", "7054"},
+		{"[2001:db8::1]:7051", "2001:db8::1", "7051"},
+		{"grpcs://[2001:db8::1]:7051", "2001:db8::1", "7051"},
+		{"GRPC://[::1]:7051", "::1", "7051"},
+		{"grpcs://[2001:db8::1]", "2001:db8::1", ""},
+		{"2001:db8::1", "2001:db8::1", ""},
+		{"unix:///var/run/peer.sock", "/var/run/peer.sock", ""},
+	}
+	for _, test := range tests {
+		host, port := SplitHostPort(test.url)
+		if host != test.host || port != test.port {
+			t.Fatalf("expected host %s and port %s for %s, got %s and %s", test.host, test.port, test.url, host, port)
+		}
+	}
+}
+
+func TestIsUnixSocket(t *testing.T) {
+	if !IsUnixSocket("unix:///var/run/peer.sock") {
+		t.Fatalf("IsUnixSocket returned false for unix://")
+	}
+	if IsUnixSocket("grpc://some.url") {
+		t.Fatalf("IsUnixSocket returned true for grpc://")
+	}
+	if AttemptSecured("unix:///var/run/secure.sock", false) {
+		t.Fatalf("trying to attempt secured with unix:// but got true")
+	}
 }
 
 func TestAttemptSecured(t *testing.T) {
@@ -52,6 +52,8 @@ type EndpointConfig struct {
 	ordererMatchers     map[int]*regexp.Regexp
 	caMatchers          map[int]*regexp.Regexp
 	certPoolLock        sync.Mutex
+	certProvider        fab.TLSClientCertProvider
+	certProviderLock    sync.RWMutex
 }
 
 // TimeoutOrDefault reads timeouts for the given timeout type, if not found, defaultTimeout is returned
@@ -440,9 +442,26 @@ func (c *EndpointConfig) EventServiceType() fab.EventServiceType {
 	}
 }
 
+// SetTLSClientCertProvider registers a provider for the client's mutual TLS certs. When set, the
+// provider takes precedence over the certs in the config and is consulted on every TLS handshake.
+func (c *EndpointConfig) SetTLSClientCertProvider(provider fab.TLSClientCertProvider) {
+	c.certProviderLock.Lock()
+	defer c.certProviderLock.Unlock()
+
+	c.certProvider = provider
+}
+
 // TLSClientCerts loads the client's certs for mutual TLS
 // It checks the config for embedded pem files before looking for cert files
 func (c *EndpointConfig) TLSClientCerts() ([]tls.Certificate, error) {
+	c.certProviderLock.RLock()
+	certProvider := c.certProvider
+	c.certProviderLock.RUnlock()
+
+	if certProvider != nil {
+		return certProvider.TLSClientCerts()
+	}
+
 	clientConfig, err := c.client()
 	if err != nil {
 		return nil, err
@@ -506,6 +525,11 @@ func (c *EndpointConfig) getTimeout(tType fab.TimeoutType) time.Duration {
 	switch tType {
 	case fab.EndorserConnection:
 		timeout = c.backend.getDuration("client.peer.timeout.connection")
+	case fab.PeerHandshake:
+		timeout = c.backend.getDuration("client.peer.timeout.handshake")
+		if timeout == 0 {
+			timeout = c.getTimeout(fab.EndorserConnection)
+		}
 	case fab.Query:
 		timeout = c.backend.getDuration("client.global.timeout.query")
 	case fab.Execute:
@@ -618,11 +642,9 @@ func randomOrdererConfig(orderers []fab.OrdererConfig) (*fab.OrdererConfig, erro
 }
 
 func (c *EndpointConfig) getPortIfPresent(url string) (int, bool) {
-	s := strings.Split(url, ":")
-	if len(s) > 1 {
-		if port, err := strconv.Atoi(s[len(s)-1]); err == nil {
-			return port, true
-		}
+	_, p := endpoint.SplitHostPort(url)
+	if port, err := strconv.Atoi(p); err == nil {
+		return port, true
 	}
 	return 0, false
 }
@@ -700,17 +722,9 @@ func (c *EndpointConfig) tryMatchingPeerConfig(peerName string) (*fab.PeerConfig
 
 			//if sslTargetOverrideUrlSubstitutionExp is empty, use the same network peer host
 			if peerMatchConfig.SSLTargetOverrideURLSubstitutionExp == "" {
-				if strings.Index(peerName, ":") < 0 {
-					peerConfig.GRPCOptions["ssl-target-name-override"] = peerName
-				} else {
-					//Remove port and protocol of the peerName
-					s := strings.Split(peerName, ":")
-					if isPortPresentInPeerName {
-						peerConfig.GRPCOptions["ssl-target-name-override"] = s[len(s)-2]
-					} else {
-						peerConfig.GRPCOptions["ssl-target-name-override"] = s[len(s)-1]
-					}
-				}
+				//Remove port and protocol of the peerName, as well as the brackets of an IPv6 host
+				host, _ := endpoint.SplitHostPort(peerName)
+				peerConfig.GRPCOptions["ssl-target-name-override"] = host
 
 			} else {
 				//else, replace url with sslTargetOverrideUrlSubstitutionExp if it doesnt have any variable declarations like $
@@ -783,17 +797,9 @@ func (c *EndpointConfig) tryMatchingOrdererConfig(ordererName string) (*fab.Orde
 
 			//if sslTargetOverrideUrlSubstitutionExp is empty, use the same network peer host
 			if ordererMatchConfig.SSLTargetOverrideURLSubstitutionExp == "" {
-				if strings.Index(ordererName, ":") < 0 {
-					ordererConfig.GRPCOptions["ssl-target-name-override"] = ordererName
-				} else {
-					//Remove port and protocol of the ordererName
-					s := strings.Split(ordererName, ":")
-					if isPortPresentInOrdererName {
-						ordererConfig.GRPCOptions["ssl-target-name-override"] = s[len(s)-2]
-					} else {
-						ordererConfig.GRPCOptions["ssl-target-name-override"] = s[len(s)-1]
-					}
-				}
+				//Remove port and protocol of the ordererName, as well as the brackets of an IPv6 host
+				host, _ := endpoint.SplitHostPort(ordererName)
+				ordererConfig.GRPCOptions["ssl-target-name-override"] = host
 
 			} else {
 				//else, replace url with sslTargetOverrideUrlSubstitutionExp if it doesnt have any variable declarations like $
@@ -31,7 +31,7 @@ func GetSuiteByConfig(config core.CryptoSuiteConfig) (core.CryptoSuite, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &wrapper.CryptoSuite{BCCSP: bccsp}, nil
+	return &wrapper.CryptoSuite{BCCSP: bccsp, SecLevel: opts.SecLevel, HashFam: opts.HashFamily}, nil
 }
 
 func getBCCSPFromOpts(config *pkcs11.PKCS11Opts) (bccsp.BCCSP, error) {
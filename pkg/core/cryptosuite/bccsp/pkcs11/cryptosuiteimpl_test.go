@@ -10,9 +10,11 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
@@ -20,6 +22,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/pkcs11"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/test/mockcore"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 )
 
 var securityLevel = 256
@@ -108,6 +111,73 @@ func TestPKCS11CSPConfigWithValidOptions(t *testing.T) {
 
 }
 
+func TestPKCS11CSPSharedContext(t *testing.T) {
+	opts := configurePKCS11Options("SHA2", securityLevel)
+	f := &pkcsFactory.PKCS11Factory{}
+
+	csp1, err := f.Get(opts)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	csp2, err := f.Get(opts)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	suite1 := wrapper.NewCryptoSuite(csp1).(*wrapper.CryptoSuite)
+	suite2 := wrapper.NewCryptoSuite(csp2).(*wrapper.CryptoSuite)
+
+	// The suites share the sessions of the token, concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(suite core.CryptoSuite) {
+			defer wg.Done()
+			errs <- signAndVerify(suite)
+		}([]core.CryptoSuite{suite1, suite2}[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// Closing a suite doesn't close the context of the other suite
+	suite1.Close()
+	suite1.Close()
+	assert.NoError(t, signAndVerify(suite2))
+	suite2.Close()
+
+	// The context is loaded again once it's closed by the last suite
+	csp3, err := f.Get(opts)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	suite3 := wrapper.NewCryptoSuite(csp3).(*wrapper.CryptoSuite)
+	defer suite3.Close()
+	assert.NoError(t, signAndVerify(suite3))
+}
+
+func signAndVerify(suite core.CryptoSuite) error {
+	key, err := suite.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := suite.Sign(key, digest[:], nil)
+	if err != nil {
+		return err
+	}
+	valid, err := suite.Verify(key, signature, digest[:], nil)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("signature not verified")
+	}
+	return nil
+}
+
 func TestPKCS11CSPConfigWithEmptyHashFamily(t *testing.T) {
 
 	opts := configurePKCS11Options("", securityLevel)
@@ -30,7 +30,7 @@ func GetSuiteByConfig(config core.CryptoSuiteConfig) (core.CryptoSuite, error) {
 	if err != nil {
 		return nil, err
 	}
-	return wrapper.NewCryptoSuite(bccsp), nil
+	return wrapper.NewCryptoSuiteWithSecurityConfig(bccsp, opts.SecLevel, opts.HashFamily), nil
 }
 
 //GetSuiteWithDefaultEphemeral returns cryptosuite adaptor for bccsp with default ephemeral options (intended to aid testing)
@@ -41,7 +41,7 @@ func GetSuiteWithDefaultEphemeral() (core.CryptoSuite, error) {
 	if err != nil {
 		return nil, err
 	}
-	return wrapper.NewCryptoSuite(bccsp), nil
+	return wrapper.NewCryptoSuiteWithSecurityConfig(bccsp, opts.SecLevel, opts.HashFamily), nil
 }
 
 func getBCCSPFromOpts(config *bccspSw.SwOpts) (bccsp.BCCSP, error) {
@@ -61,7 +61,7 @@ func GetSuite(securityLevel int, hashFamily string, keyStore bccsp.KeyStore) (co
 	if err != nil {
 		return nil, err
 	}
-	return wrapper.NewCryptoSuite(bccsp), nil
+	return wrapper.NewCryptoSuiteWithSecurityConfig(bccsp, securityLevel, hashFamily), nil
 }
 
 //GetOptsByConfig Returns Factory opts for given SDK config
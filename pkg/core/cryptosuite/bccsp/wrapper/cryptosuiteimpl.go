@@ -28,9 +28,31 @@ func GetKey(newkey bccsp.Key) core.Key {
 	return &key{newkey}
 }
 
+//NewCryptoSuiteWithSecurityConfig returns cryptosuite adaptor for given bccsp.BCCSP implementation,
+//which was configured with the given security level and hash family
+func NewCryptoSuiteWithSecurityConfig(bccsp bccsp.BCCSP, securityLevel int, hashFamily string) core.CryptoSuite {
+	return &CryptoSuite{
+		BCCSP:    bccsp,
+		SecLevel: securityLevel,
+		HashFam:  hashFamily,
+	}
+}
+
 // CryptoSuite provides a wrapper of BCCSP
 type CryptoSuite struct {
-	BCCSP bccsp.BCCSP
+	BCCSP    bccsp.BCCSP
+	SecLevel int    // security level the BCCSP was configured with, 0 if unknown
+	HashFam  string // hash family the BCCSP was configured with, empty if unknown
+}
+
+// SecurityLevel returns the security level the BCCSP was configured with
+func (c *CryptoSuite) SecurityLevel() int {
+	return c.SecLevel
+}
+
+// HashFamily returns the hash family the BCCSP was configured with
+func (c *CryptoSuite) HashFamily() string {
+	return c.HashFam
 }
 
 // KeyGen is a wrapper of BCCSP.KeyGen
@@ -71,6 +93,17 @@ func (c *CryptoSuite) Verify(k core.Key, signature, digest []byte, opts core.Sig
 	return c.BCCSP.Verify(k.(*key).key, signature, digest, opts)
 }
 
+// Close releases the resources held by the BCCSP, e.g. the reference of a PKCS11 BCCSP to the context and sessions
+// of its token, which are shared by the PKCS11 BCCSPs of the token. It has no effect if the BCCSP holds no
+// resources. The cryptosuite must not be used after it's closed.
+func (c *CryptoSuite) Close() {
+	if closer, ok := c.BCCSP.(interface {
+		Close()
+	}); ok {
+		closer.Close()
+	}
+}
+
 type key struct {
 	key bccsp.Key
 }
@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/pkg/errors"
+)
+
+// SecurityConfig is implemented by crypto suites which expose the security level and hash family they were configured with
+type SecurityConfig interface {
+	SecurityLevel() int
+	HashFamily() string
+}
+
+//GetHashOptsForKey returns the options for hashing the digests signed with the given key: as per the BCCSP
+//conventions the hash size matches the curve of ECDSA keys, i.e. SHA-256 for P-256 and SHA-384 for P-384,
+//in the given hash family (SHA2 or SHA3). The configured hash (see GetSHAOpts) is used for other keys.
+//Note that Fabric MSPs verify SHA2 signatures over SHA-256 digests whatever the curve.
+func GetHashOptsForKey(key core.Key, hashFamily string) (core.HashOpts, error) {
+	pubKey, err := ecdsaPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if pubKey == nil {
+		return GetSHAOpts(), nil
+	}
+
+	var opts core.HashOpts
+	switch level := pubKey.Curve.Params().BitSize; {
+	case level == 256 && hashFamily == bccsp.SHA3:
+		opts = &bccsp.SHA3_256Opts{}
+	case level == 256:
+		opts = &bccsp.SHA256Opts{}
+	case level == 384 && hashFamily == bccsp.SHA3:
+		opts = &bccsp.SHA3_384Opts{}
+	case level == 384:
+		opts = &bccsp.SHA384Opts{}
+	default:
+		return nil, errors.Errorf("unsupported ECDSA curve %s", pubKey.Curve.Params().Name)
+	}
+
+	if hashFamily != "" && hashFamily != bccsp.SHA2 && hashFamily != bccsp.SHA3 {
+		return nil, errors.Errorf("unsupported hash family [%s]", hashFamily)
+	}
+	return opts, nil
+}
+
+//ValidateKeySecurityLevel returns an error if the key doesn't provide the given security level,
+//i.e. if it is an ECDSA key on a curve which is either unsupported or smaller than the security level
+func ValidateKeySecurityLevel(key core.Key, securityLevel int) error {
+	pubKey, err := ecdsaPublicKey(key)
+	if err != nil {
+		return err
+	}
+	if pubKey == nil {
+		return nil
+	}
+
+	params := pubKey.Curve.Params()
+	if params.BitSize != 256 && params.BitSize != 384 {
+		return errors.Errorf("ECDSA curve %s is not supported, keys must use curve P-256 or P-384", params.Name)
+	}
+	if params.BitSize < securityLevel {
+		return errors.Errorf("ECDSA curve %s is incompatible with the configured security level %d, keys must use a curve of at least %d bits",
+			params.Name, securityLevel, securityLevel)
+	}
+	return nil
+}
+
+// ecdsaPublicKey returns the ECDSA public key of the key, or nil if it isn't an ECDSA key
+func ecdsaPublicKey(key core.Key) (*ecdsa.PublicKey, error) {
+	if key == nil {
+		return nil, errors.New("key is required")
+	}
+	if key.Symmetric() {
+		return nil, nil
+	}
+
+	if key.Private() {
+		var err error
+		key, err = key.PublicKey()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get public key")
+		}
+	}
+
+	raw, err := key.Bytes()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal public key")
+	}
+	pubKey, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse public key")
+	}
+
+	ecdsaKey, ok := pubKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil
+	}
+	return ecdsaKey, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateKey(t *testing.T, opts core.KeyGenOpts) core.Key {
+	suite, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		t.Fatalf("Failed to create cryptosuite: %s", err)
+	}
+	key, err := suite.KeyGen(opts)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	return key
+}
+
+func TestGetHashOptsForKey(t *testing.T) {
+	p256 := generateKey(t, &bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	p384 := generateKey(t, &bccsp.ECDSAP384KeyGenOpts{Temporary: true})
+
+	opts, err := GetHashOptsForKey(p256, bccsp.SHA2)
+	assert.NoError(t, err)
+	assert.Equal(t, bccsp.SHA256, opts.Algorithm())
+
+	opts, err = GetHashOptsForKey(p384, bccsp.SHA2)
+	assert.NoError(t, err)
+	assert.Equal(t, bccsp.SHA384, opts.Algorithm())
+
+	// Public keys select the same hash as their private keys
+	pub384, err := p384.PublicKey()
+	assert.NoError(t, err)
+	opts, err = GetHashOptsForKey(pub384, bccsp.SHA2)
+	assert.NoError(t, err)
+	assert.Equal(t, bccsp.SHA384, opts.Algorithm())
+
+	opts, err = GetHashOptsForKey(p384, bccsp.SHA3)
+	assert.NoError(t, err)
+	assert.Equal(t, bccsp.SHA3_384, opts.Algorithm())
+
+	_, err = GetHashOptsForKey(p256, "MD5")
+	assert.Error(t, err, "Expected unsupported hash family error")
+
+	_, err = GetHashOptsForKey(nil, bccsp.SHA2)
+	assert.Error(t, err)
+}
+
+func TestValidateKeySecurityLevel(t *testing.T) {
+	p256 := generateKey(t, &bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	p384 := generateKey(t, &bccsp.ECDSAP384KeyGenOpts{Temporary: true})
+
+	assert.NoError(t, ValidateKeySecurityLevel(p256, 256))
+	assert.NoError(t, ValidateKeySecurityLevel(p384, 256))
+	assert.NoError(t, ValidateKeySecurityLevel(p384, 384))
+
+	err := ValidateKeySecurityLevel(p256, 384)
+	if assert.Error(t, err, "Expected P-256 key to be incompatible with security level 384") {
+		assert.Contains(t, err.Error(), "P-256")
+		assert.Contains(t, err.Error(), "384")
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package ccpackager computes the identifiers which peers assign to chaincode packages, so that the
+// installed state of peers can be verified without network access.
+package ccpackager
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// ComputePackageID computes the ID of a lifecycle chaincode package, i.e. the label and the hex
+// encoded SHA-256 hash of the package bytes, separated by a colon.
+func ComputePackageID(label string, pkgBytes []byte) string {
+	return fmt.Sprintf("%s:%x", label, sha256.Sum256(pkgBytes))
+}
+
+// ComputeCDSHash computes the ID which peers assign to a chaincode installed as a
+// ChaincodeDeploymentSpec (CDS). As computed by the peer, it's the SHA-256 hash of the concatenation
+// of the code hash (the hash of the code package) and the metadata hash (the hash of the
+// concatenated chaincode name and version).
+func ComputeCDSHash(cds []byte) ([]byte, error) {
+	depSpec := &pb.ChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(cds, depSpec); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of chaincode deployment spec failed")
+	}
+
+	ccID := depSpec.GetChaincodeSpec().GetChaincodeId()
+	if ccID == nil {
+		return nil, errors.New("chaincode deployment spec has no chaincode ID")
+	}
+
+	return cdsHash(depSpec.CodePackage, ccID.Name, ccID.Version), nil
+}
+
+func cdsHash(codePackage []byte, name, version string) []byte {
+	hash := sha256.New()
+	hash.Write(codePackage)
+	codeHash := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write([]byte(name))
+	hash.Write([]byte(version))
+	metadataHash := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(codeHash)
+	hash.Write(metadataHash)
+	return hash.Sum(nil)
+}
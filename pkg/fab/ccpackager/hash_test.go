@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ccpackager
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestComputePackageID(t *testing.T) {
+	packageID := ComputePackageID("mycc_1", []byte("lifecycle package"))
+	assert.Equal(t, "mycc_1:ec18f6453c07c55dd59ab7754d0b1b5cd70826431a4d82435e47b17ae0e52b14", packageID)
+}
+
+func TestComputeCDSHash(t *testing.T) {
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        pb.ChaincodeSpec_GOLANG,
+			ChaincodeId: &pb.ChaincodeID{Name: "example_cc", Path: "github.com/example_cc", Version: "v1"},
+		},
+		CodePackage: []byte("chaincode code package"),
+	})
+	assert.Nil(t, err)
+
+	hash, err := ComputeCDSHash(cds)
+	assert.Nil(t, err)
+	assert.Equal(t, "4ff6551dc13a36af5f3d85660e5bc7b0a7f00d514bef889831eaaf4730972b95", hex.EncodeToString(hash))
+
+	// The path, type and effective date are not part of the hash
+	cds, err = proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        pb.ChaincodeSpec_NODE,
+			ChaincodeId: &pb.ChaincodeID{Name: "example_cc", Path: "other/path", Version: "v1"},
+		},
+		CodePackage: []byte("chaincode code package"),
+	})
+	assert.Nil(t, err)
+
+	otherHash, err := ComputeCDSHash(cds)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, otherHash)
+
+	_, err = ComputeCDSHash([]byte("invalid"))
+	assert.NotNil(t, err, "Expected error for invalid deployment spec")
+
+	cds, err = proto.Marshal(&pb.ChaincodeDeploymentSpec{CodePackage: []byte("chaincode code package")})
+	assert.Nil(t, err)
+	_, err = ComputeCDSHash(cds)
+	assert.NotNil(t, err, "Expected error for deployment spec without chaincode ID")
+}
@@ -46,7 +46,11 @@ func NewCacheKey(context Context, chConfigRef *lazyref.Reference, channelID stri
 }
 
 // NewRefCache a cache of membership references that refreshed with the
-// given interval
+// given interval. A reference may be removed from the cache with Invalidate,
+// e.g. when a config update of the channel is detected, so that the next Get
+// creates a new reference which loads the membership from the channel config
+// reference of the key. Note that a removed reference is closed, so it keeps
+// serving the membership it last loaded.
 func NewRefCache(refresh time.Duration) *lazycache.Cache {
 	initializer := func(key lazycache.Key) (interface{}, error) {
 		ck, ok := key.(CacheKey)
@@ -59,6 +63,24 @@ func NewRefCache(refresh time.Duration) *lazycache.Cache {
 	return lazycache.New("Membership_Cache", initializer)
 }
 
+// NewRefCacheWithRefresh a cache of membership references which are refreshed in the background,
+// refreshAhead before their time-to-live expires (see NewRefWithRefresh)
+func NewRefCacheWithRefresh(ttl, refreshAhead time.Duration) (*lazycache.Cache, error) {
+	if ttl <= 0 || refreshAhead <= 0 || refreshAhead >= ttl {
+		return nil, errors.Errorf("invalid membership refresh: refresh ahead [%s] must be positive and less than the time-to-live [%s]", refreshAhead, ttl)
+	}
+
+	initializer := func(key lazycache.Key) (interface{}, error) {
+		ck, ok := key.(CacheKey)
+		if !ok {
+			return nil, errors.New("unexpected cache key")
+		}
+		return NewRefWithRefresh(ttl, refreshAhead, ck.Context(), ck.ChConfigRef())
+	}
+
+	return lazycache.New("Membership_Cache", initializer), nil
+}
+
 // String returns the key as a string
 func (k *cacheKey) String() string {
 	return k.key
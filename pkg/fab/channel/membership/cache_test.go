@@ -8,6 +8,7 @@ package membership
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -64,6 +65,47 @@ func TestMembershipCache(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestMembershipCacheInvalidate(t *testing.T) {
+	testChannelID := "test"
+
+	cfg := mocks.NewMockChannelCfg(testChannelID)
+	cfg.MockMSPs = []*mb.MSPConfig{buildMSPConfig("OtherMSP", []byte(validRootCA))}
+	chConfigRef := lazyref.New(func() (interface{}, error) {
+		return cfg, nil
+	})
+	defer chConfigRef.Close()
+
+	cache := NewRefCache(time.Hour)
+	defer cache.Close()
+
+	key, err := NewCacheKey(Context{Providers: mocks.NewMockProviderContext(), EndpointConfig: mocks.NewMockEndpointConfig()}, chConfigRef, testChannelID)
+	assert.Nil(t, err)
+
+	sID := &mb.SerializedIdentity{Mspid: "GoodMSP", IdBytes: []byte(certPem)}
+	goodEndorser, err := proto.Marshal(sID)
+	assert.Nil(t, err)
+
+	r, err := cache.Get(key)
+	assert.Nil(t, err)
+	assert.NotNil(t, r.(fab.ChannelMembership).Validate(goodEndorser), "Expected identity of unknown MSP to be invalid")
+
+	// Update the config served by the channel config reference. The membership
+	// refresh interval is far longer than the test, so only Invalidate reloads it.
+	cfg.MockBlockNumber = 1
+	cfg.MockMSPs = []*mb.MSPConfig{buildMSPConfig("GoodMSP", []byte(validRootCA))}
+
+	// The membership isn't refreshed before its refresh interval
+	r, err = cache.Get(key)
+	assert.Nil(t, err)
+	assert.NotNil(t, r.(fab.ChannelMembership).Validate(goodEndorser), "Expected cached membership to be used")
+
+	// The membership is reloaded from the channel config after the reference is invalidated
+	cache.Invalidate(key)
+	r, err = cache.Get(key)
+	assert.Nil(t, err)
+	assert.Nil(t, r.(fab.ChannelMembership).Validate(goodEndorser), "Expected membership to be reloaded")
+}
+
 func TestMembershipCacheBad(t *testing.T) {
 	testChannelID := "test"
 	testErr := fmt.Errorf("bad initializer")
@@ -97,3 +139,55 @@ func TestMembershipCacheBad(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), testErr.Error())
 }
+
+func TestMembershipCacheWithRefresh(t *testing.T) {
+	_, err := NewRefCacheWithRefresh(time.Second, time.Second)
+	assert.NotNil(t, err, "Expected error for refresh ahead not less than the time-to-live")
+
+	cache, err := NewRefCacheWithRefresh(time.Minute, time.Second)
+	assert.Nil(t, err)
+	assert.NotNil(t, cache)
+
+	var lock sync.Mutex
+	cfg := mocks.NewMockChannelCfg("test")
+	cfg.MockMSPs = []*mb.MSPConfig{buildMSPConfig("OtherMSP", []byte(validRootCA))}
+	chConfigRef := lazyref.New(func() (interface{}, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return cfg, nil
+	}, lazyref.WithRefreshInterval(lazyref.InitImmediately, time.Millisecond))
+
+	ctx := mocks.NewMockProviderContext()
+	ref, err := NewRefWithRefresh(100*time.Millisecond, 80*time.Millisecond, Context{Providers: ctx, EndpointConfig: mocks.NewMockEndpointConfig()}, chConfigRef)
+	if err != nil {
+		t.Fatalf("Failed to create membership reference: %s", err)
+	}
+	defer ref.Close()
+
+	sID := &mb.SerializedIdentity{Mspid: "GoodMSP", IdBytes: []byte(certPem)}
+	goodEndorser, err := proto.Marshal(sID)
+	assert.Nil(t, err)
+	assert.NotNil(t, ref.Validate(goodEndorser), "Expected identity of unknown MSP to be invalid")
+
+	// The membership is refreshed in the background once the channel config is updated
+	newCfg := mocks.NewMockChannelCfg("test")
+	newCfg.MockBlockNumber = 1
+	newCfg.MockMSPs = []*mb.MSPConfig{buildMSPConfig("GoodMSP", []byte(validRootCA))}
+	lock.Lock()
+	cfg = newCfg
+	lock.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, ref.Validate(goodEndorser), "Expected membership to be refreshed")
+
+	// The membership isn't served after its time-to-live if it can't be refreshed
+	badCfg := mocks.NewMockChannelCfg("test")
+	badCfg.MockBlockNumber = 2
+	badCfg.MockMSPs = []*mb.MSPConfig{buildMSPConfig("GoodMSP", []byte("invalid root CA"))}
+	lock.Lock()
+	cfg = badCfg
+	lock.Unlock()
+
+	time.Sleep(150 * time.Millisecond)
+	assert.NotNil(t, ref.Validate(goodEndorser), "Expected expired membership not to be served")
+}
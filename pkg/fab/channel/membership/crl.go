@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package membership
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/pkg/errors"
+)
+
+// revocationList holds the CA certificates and the CRLs of an MSP
+type revocationList struct {
+	caCerts []*x509.Certificate
+	crls    []*pkix.CertificateList
+}
+
+// loadRevocationLists loads the CA certificates and CRLs from the MSP configs, by MSP ID
+func loadRevocationLists(mspConfigs []*mb.MSPConfig) (map[string]*revocationList, error) {
+	lists := make(map[string]*revocationList)
+	for _, config := range mspConfigs {
+		fabricConfig := &mb.FabricMSPConfig{}
+		if err := proto.Unmarshal(config.Config, fabricConfig); err != nil {
+			return nil, errors.Wrap(err, "unmarshal FabricMSPConfig from config failed")
+		}
+
+		list := &revocationList{}
+		for _, pemCerts := range append(fabricConfig.RootCerts, fabricConfig.IntermediateCerts...) {
+			list.caCerts = append(list.caCerts, parseCerts(pemCerts)...)
+		}
+		for _, crlBytes := range fabricConfig.RevocationList {
+			crl, err := x509.ParseCRL(crlBytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not parse RevocationList of MSP [%s]", fabricConfig.Name)
+			}
+			list.crls = append(list.crls, crl)
+		}
+		lists[fabricConfig.Name] = list
+	}
+	return lists, nil
+}
+
+// checkCurrentCRL returns an error if the issuer of the certificate has no current CRL. Revocation
+// itself is checked by the MSP against all of its CRLs.
+func (l *revocationList) checkCurrentCRL(cert *x509.Certificate) error {
+	issuer := l.issuer(cert)
+	if issuer == nil {
+		// Left to the MSP, which rejects certificates from unknown authorities
+		return nil
+	}
+
+	for _, crl := range l.crls {
+		if issuer.CheckCRLSignature(crl) == nil && !crl.HasExpired(time.Now()) {
+			return nil
+		}
+	}
+	return errors.Errorf("no current CRL available for issuer [%s] of the certificate", issuer.Subject.CommonName)
+}
+
+// issuer returns the CA certificate which signed the given certificate, or nil if none did
+func (l *revocationList) issuer(cert *x509.Certificate) *x509.Certificate {
+	for _, caCert := range l.caCerts {
+		if cert.CheckSignatureFrom(caCert) == nil {
+			return caCert
+		}
+	}
+	return nil
+}
+
+func parseCerts(pemCerts []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for len(pemCerts) > 0 {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
@@ -0,0 +1,160 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package membership
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWithCRL(t *testing.T) {
+	mspID := "Org1MSP"
+	ca := newTestCA(t)
+	cert := ca.issue(t, 2)
+	revokedCert := ca.issue(t, 3)
+
+	sID, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: cert})
+	assert.Nil(t, err)
+	revokedSID, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: revokedCert})
+	assert.Nil(t, err)
+
+	currentCRL := ca.crl(t, time.Now().Add(time.Hour), 3)
+	expiredCRL := ca.crl(t, time.Now().Add(-time.Hour), 3)
+
+	newMembership := func(strict bool, crls ...[]byte) *identityImpl {
+		cfg := mocks.NewMockChannelCfg("")
+		cfg.MockMSPs = []*mb.MSPConfig{{Config: marshalOrPanic(&mb.FabricMSPConfig{
+			Name:           mspID,
+			RootCerts:      [][]byte{ca.certPEM},
+			RevocationList: crls,
+		})}}
+		m, err := New(Context{Providers: mocks.NewMockProviderContext(), StrictCRL: strict}, cfg)
+		if err != nil {
+			t.Fatalf("Failed to create membership: %s", err)
+		}
+		return m.(*identityImpl)
+	}
+
+	// Without CRL
+	assert.NoError(t, newMembership(false).Validate(sID))
+	err = newMembership(true).Validate(sID)
+	if err == nil || !strings.Contains(err.Error(), "no current CRL available") {
+		t.Fatalf("Expected error for missing CRL in strict mode, got %v", err)
+	}
+
+	// With current CRL
+	for _, strict := range []bool{false, true} {
+		m := newMembership(strict, currentCRL)
+		assert.NoError(t, m.Validate(sID))
+		err = m.Validate(revokedSID)
+		if err == nil || !strings.Contains(err.Error(), "The certificate has been revoked") {
+			t.Fatalf("Expected error for revoked certificate, got %v", err)
+		}
+	}
+
+	// With expired CRL
+	m := newMembership(false, expiredCRL)
+	assert.NoError(t, m.Validate(sID))
+	assert.Error(t, m.Validate(revokedSID), "Expected revoked certificate to be rejected with expired CRL")
+	err = newMembership(true, expiredCRL).Validate(sID)
+	if err == nil || !strings.Contains(err.Error(), "no current CRL available") {
+		t.Fatalf("Expected error for expired CRL in strict mode, got %v", err)
+	}
+}
+
+type testCA struct {
+	key     *ecdsa.PrivateKey
+	cert    *x509.Certificate
+	certPEM []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	return newTestCAWithParent(t, nil, "ca.org1.example.com", 1, -1)
+}
+
+// intermediate returns an intermediate CA signed by the CA. A negative maxPathLen leaves the path length unconstrained.
+func (ca *testCA) intermediate(t *testing.T, commonName string, serialNumber int64, maxPathLen int) *testCA {
+	return newTestCAWithParent(t, ca, commonName, serialNumber, maxPathLen)
+}
+
+func newTestCAWithParent(t *testing.T, parent *testCA, commonName string, serialNumber int64, maxPathLen int) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serialNumber),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		SubjectKeyId:          []byte(commonName),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
+	}
+	issuerCert, issuerKey := template, key
+	if parent != nil {
+		issuerCert, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %s", err)
+	}
+	return &testCA{key: key, cert: cert, certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate with the given serial number signed by the CA
+func (ca *testCA) issue(t *testing.T, serialNumber int64) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serialNumber),
+		Subject:      pkix.Name{CommonName: "peer0.org1.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// crl returns a PEM CRL signed by the CA which revokes the given serial numbers
+func (ca *testCA) crl(t *testing.T, nextUpdate time.Time, serialNumbers ...int64) []byte {
+	var revoked []pkix.RevokedCertificate
+	for _, serialNumber := range serialNumbers {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: big.NewInt(serialNumber), RevocationTime: time.Now().Add(-time.Minute)})
+	}
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, revoked, time.Now().Add(-time.Minute), nextUpdate)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
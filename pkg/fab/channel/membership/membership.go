@@ -23,13 +23,18 @@ import (
 var logger = logging.NewLogger("fabsdk/fab")
 
 type identityImpl struct {
-	mspManager msp.MSPManager
+	mspManager      msp.MSPManager
+	revocationLists map[string]*revocationList
+	strictCRL       bool
 }
 
 // Context holds the providers
 type Context struct {
 	core.Providers
 	EndpointConfig fab.EndpointConfig
+	// StrictCRL rejects identities whose issuer has no current CRL in the MSP config. By default,
+	// identities are only checked against the CRLs which are available.
+	StrictCRL bool
 }
 
 // New member identity
@@ -38,13 +43,33 @@ func New(ctx Context, cfg fab.ChannelCfg) (fab.ChannelMembership, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &identityImpl{mspManager: m}, nil
+	impl := &identityImpl{mspManager: m, strictCRL: ctx.StrictCRL}
+	if ctx.StrictCRL {
+		impl.revocationLists, err = loadRevocationLists(cfg.MSPs())
+		if err != nil {
+			return nil, errors.WithMessage(err, "load CRLs from config failed")
+		}
+	}
+	return impl, nil
 }
 
 func (i *identityImpl) Validate(serializedID []byte) error {
-	err := areCertDatesValid(serializedID)
+	sID, cert, err := parseSerializedID(serializedID)
 	if err != nil {
-		logger.Errorf("Cert error %v", err)
+		return err
+	}
+
+	if revocationList, ok := i.revocationLists[sID.Mspid]; ok && i.strictCRL {
+		err = revocationList.checkCurrentCRL(cert)
+		if err != nil {
+			logger.Warnf("Certificate error '%v' for cert '%v'", err, cert.SerialNumber)
+			return err
+		}
+	}
+
+	err = verifier.ValidateCertificateDates(cert)
+	if err != nil {
+		logger.Errorf("Certificate error '%v' for cert '%v'", err, cert.SerialNumber)
 		return err
 	}
 
@@ -64,28 +89,23 @@ func (i *identityImpl) Verify(serializedID []byte, msg []byte, sig []byte) error
 	return id.Verify(msg, sig)
 }
 
-func areCertDatesValid(serializedID []byte) error {
+func parseSerializedID(serializedID []byte) (*mb.SerializedIdentity, *x509.Certificate, error) {
 
 	sID := &mb.SerializedIdentity{}
 	err := proto.Unmarshal(serializedID, sID)
 	if err != nil {
-		return errors.Wrap(err, "could not deserialize a SerializedIdentity")
+		return nil, nil, errors.Wrap(err, "could not deserialize a SerializedIdentity")
 	}
 
 	bl, _ := pem.Decode(sID.IdBytes)
 	if bl == nil {
-		return errors.New("could not decode the PEM structure")
+		return nil, nil, errors.New("could not decode the PEM structure")
 	}
 	cert, err := x509.ParseCertificate(bl.Bytes)
 	if err != nil {
-		return err
-	}
-	err = verifier.ValidateCertificateDates(cert)
-	if err != nil {
-		logger.Warnf("Certificate error '%v' for cert '%v'", err, cert.SerialNumber)
-		return err
+		return nil, nil, err
 	}
-	return nil
+	return sID, cert, nil
 }
 
 func createMSPManager(ctx Context, cfg fab.ChannelCfg) (msp.MSPManager, error) {
@@ -159,6 +179,15 @@ func loadMSPs(mspConfigs []*mb.MSPConfig, cs core.CryptoSuite) ([]msp.MSP, error
 			return nil, errors.Wrap(err, "instantiate MSP failed")
 		}
 
+		// Certificate chain files are split since the MSP only loads the first certificate of each entry
+		if splitCertChains(fabricConfig) {
+			configBytes, err := proto.Marshal(fabricConfig)
+			if err != nil {
+				return nil, errors.Wrap(err, "marshal FabricMSPConfig failed")
+			}
+			config = &mb.MSPConfig{Type: config.Type, Config: configBytes}
+		}
+
 		if err := newMSP.Setup(config); err != nil {
 			return nil, errors.Wrap(err, "configure MSP failed")
 		}
@@ -173,6 +202,41 @@ func loadMSPs(mspConfigs []*mb.MSPConfig, cs core.CryptoSuite) ([]msp.MSP, error
 	return msps, nil
 }
 
+//splitCertChains splits the root and intermediate cert entries of the config which hold several
+//PEM certificates into one entry per certificate. Returns true if an entry was split.
+func splitCertChains(config *mb.FabricMSPConfig) bool {
+	rootCerts, rootsSplit := splitPEMCerts(config.RootCerts)
+	intermediateCerts, intermediatesSplit := splitPEMCerts(config.IntermediateCerts)
+	config.RootCerts, config.IntermediateCerts = rootCerts, intermediateCerts
+	return rootsSplit || intermediatesSplit
+}
+
+func splitPEMCerts(entries [][]byte) ([][]byte, bool) {
+	var result [][]byte
+	split := false
+	for _, entry := range entries {
+		var certs [][]byte
+		rest := entry
+		for len(rest) > 0 {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type == "CERTIFICATE" {
+				certs = append(certs, pem.EncodeToMemory(block))
+			}
+		}
+		if len(certs) <= 1 {
+			result = append(result, entry)
+			continue
+		}
+		result = append(result, certs...)
+		split = true
+	}
+	return result, split
+}
+
 //addCertsToConfig adds cert bytes to config TLSCACertPool
 func addCertsToConfig(config fab.EndpointConfig, pemCerts []byte) {
 	for len(pemCerts) > 0 {
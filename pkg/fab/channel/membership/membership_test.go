@@ -24,6 +24,7 @@ import (
 	"encoding/pem"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
 	"github.com/stretchr/testify/assert"
@@ -375,3 +376,51 @@ func generateSelfSignedCert(t *testing.T, now time.Time) string {
 	return encodeCertToMemory(newCert)
 
 }
+
+func TestValidateWithIntermediateCAs(t *testing.T) {
+	mspID := "Org1MSP"
+	root := newTestCA(t)
+	intermediate1 := root.intermediate(t, "ica1.org1.example.com", 2, -1)
+	intermediate2 := intermediate1.intermediate(t, "ica2.org1.example.com", 3, 0)
+
+	newMembership := func(rootCA *testCA, intermediateCerts ...[]byte) fab.ChannelMembership {
+		cfg := mocks.NewMockChannelCfg("")
+		cfg.MockMSPs = []*mb.MSPConfig{{Config: marshalOrPanic(&mb.FabricMSPConfig{
+			Name:              mspID,
+			RootCerts:         [][]byte{rootCA.certPEM},
+			IntermediateCerts: intermediateCerts,
+		})}}
+		m, err := New(Context{Providers: mocks.NewMockProviderContext()}, cfg)
+		if err != nil {
+			t.Fatalf("Failed to create membership: %s", err)
+		}
+		return m
+	}
+	serializedID := func(certPEM []byte) []byte {
+		sID, err := proto.Marshal(&mb.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+		if err != nil {
+			t.Fatalf("Failed to marshal identity: %s", err)
+		}
+		return sID
+	}
+
+	// The intermediate certs may be configured separately or as a chain file
+	chainPEM := append(append([]byte{}, intermediate1.certPEM...), intermediate2.certPEM...)
+	for _, m := range []fab.ChannelMembership{newMembership(root, intermediate1.certPEM, intermediate2.certPEM), newMembership(root, chainPEM)} {
+		assert.NoError(t, m.Validate(serializedID(intermediate2.issue(t, 10))), "Expected identity issued by the deepest intermediate CA to be valid")
+
+		// As on the peers, only the leaf CAs of the certification tree may issue identities
+		assert.Error(t, m.Validate(serializedID(intermediate1.issue(t, 10))), "Expected identity issued by an intermediate CA with children to be invalid")
+	}
+
+	// Identities issued by an intermediate CA which isn't in the MSP config are rejected
+	m := newMembership(root, intermediate1.certPEM)
+	assert.Error(t, m.Validate(serializedID(intermediate2.issue(t, 10))), "Expected identity issued by unknown intermediate CA to be invalid")
+
+	// The path length constraint of the root CA is enforced
+	constrainedRoot := newTestCAWithParent(t, nil, "ca.org1.example.com", 1, 1)
+	constrained1 := constrainedRoot.intermediate(t, "ica1.org1.example.com", 2, -1)
+	constrained2 := constrained1.intermediate(t, "ica2.org1.example.com", 3, -1)
+	m = newMembership(constrainedRoot, constrained1.certPEM, constrained2.certPEM)
+	assert.Error(t, m.Validate(serializedID(constrained2.issue(t, 10))), "Expected identity exceeding the path length of the root CA to be invalid")
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package membership
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -19,9 +20,12 @@ type Ref struct {
 	*lazyref.Reference
 	chConfigRef *lazyref.Reference
 	context     Context
-	// Note: the following variables are only accessed from Ref.initializer which is synchronized
+	ttl         time.Duration
+	lock        sync.RWMutex
+	// Note: the following variables are protected by lock
 	configBlockNumber uint64
 	mem               fab.ChannelMembership
+	loaded            time.Time
 }
 
 // NewRef returns a new membership reference
@@ -39,6 +43,20 @@ func NewRef(refresh time.Duration, context Context, chConfigRef *lazyref.Referen
 	return ref
 }
 
+// NewRefWithRefresh returns a new membership reference which is refreshed in the background, refreshAhead before
+// its time-to-live expires. Callers are served the current membership while it's being refreshed, so they don't
+// wait for MSPs to be reloaded. The membership is reloaded upon access only if it couldn't be refreshed within
+// its time-to-live, e.g. because the channel config couldn't be retrieved.
+func NewRefWithRefresh(ttl, refreshAhead time.Duration, context Context, chConfigRef *lazyref.Reference) (*Ref, error) {
+	if ttl <= 0 || refreshAhead <= 0 || refreshAhead >= ttl {
+		return nil, errors.Errorf("invalid membership refresh: refresh ahead [%s] must be positive and less than the time-to-live [%s]", refreshAhead, ttl)
+	}
+
+	ref := NewRef(ttl-refreshAhead, context, chConfigRef)
+	ref.ttl = ttl
+	return ref, nil
+}
+
 // Validate calls validate on the underlying reference
 func (ref *Ref) Validate(serializedID []byte) error {
 	membership, err := ref.get()
@@ -62,33 +80,57 @@ func (ref *Ref) get() (fab.ChannelMembership, error) {
 	if err != nil {
 		return nil, err
 	}
-	return m.(fab.ChannelMembership), nil
+	if ref.ttl == 0 {
+		return m.(fab.ChannelMembership), nil
+	}
+
+	// The reference may hold a membership older than the one loaded by a previous reload
+	ref.lock.RLock()
+	mem, loaded := ref.mem, ref.loaded
+	ref.lock.RUnlock()
+
+	if time.Since(loaded) <= ref.ttl {
+		return mem, nil
+	}
+
+	logger.Warnf("Membership wasn't refreshed within %s, reloading it", ref.ttl)
+	return ref.load()
 }
 
 func (ref *Ref) initializer() lazyref.Initializer {
 	return func() (interface{}, error) {
 		logger.Debugf("Initializing membership reference...")
+		return ref.load()
+	}
+}
 
-		channelCfg, err := ref.chConfigRef.Get()
-		if err != nil {
-			return nil, errors.WithMessage(err, "could not get channel config from reference")
-		}
-		cfg, ok := channelCfg.(fab.ChannelCfg)
-		if !ok {
-			return nil, errors.New("chConfigRef.Get() returned unexpected value ")
-		}
+// load creates the membership from the channel config, unless the membership was created from the current config block
+func (ref *Ref) load() (fab.ChannelMembership, error) {
+	channelCfg, err := ref.chConfigRef.Get()
+	if err != nil {
+		return nil, errors.WithMessage(err, "could not get channel config from reference")
+	}
+	cfg, ok := channelCfg.(fab.ChannelCfg)
+	if !ok {
+		return nil, errors.New("chConfigRef.Get() returned unexpected value ")
+	}
 
-		logger.Debugf("Got config block with number %d have %d", cfg.BlockNumber(), ref.configBlockNumber)
+	ref.lock.Lock()
+	defer ref.lock.Unlock()
 
-		// Membership is refreshed only if we have a newer config block
-		if ref.mem == nil || cfg.BlockNumber() > ref.configBlockNumber {
-			logger.Debugf("Creating membership...")
-			ref.mem, err = New(ref.context, cfg)
-			if err != nil {
-				return nil, err
-			}
-		}
+	logger.Debugf("Got config block with number %d have %d", cfg.BlockNumber(), ref.configBlockNumber)
 
-		return ref.mem, nil
+	// Membership is refreshed only if we have a newer config block
+	if ref.mem == nil || cfg.BlockNumber() > ref.configBlockNumber {
+		logger.Debugf("Creating membership...")
+		mem, err := New(ref.context, cfg)
+		if err != nil {
+			return nil, err
+		}
+		ref.mem = mem
+		ref.configBlockNumber = cfg.BlockNumber()
 	}
+	ref.loaded = time.Now()
+
+	return ref.mem, nil
 }
@@ -156,3 +156,21 @@ func (t *Transactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionRespo
 
 	return txn.Send(reqCtx, tx, t.orderers)
 }
+
+// Orderers returns the orderers of the channel
+func (t *Transactor) Orderers() []fab.Orderer {
+	return t.orderers
+}
+
+// SendTransactionToOrderers sends a transaction to the given orderers (one or more orderer endpoints) rather than to the orderers of the channel.
+func (t *Transactor) SendTransactionToOrderers(tx *fab.Transaction, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+	ctx, ok := contextImpl.RequestClientContext(t.reqCtx)
+	if !ok {
+		return nil, errors.New("failed get client context from reqContext for SendTransactionToOrderers")
+	}
+
+	reqCtx, cancel := contextImpl.NewRequest(ctx, contextImpl.WithTimeoutType(fab.OrdererResponse), contextImpl.WithParent(t.reqCtx))
+	defer cancel()
+
+	return txn.Send(reqCtx, tx, orderers)
+}
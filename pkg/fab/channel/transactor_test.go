@@ -46,6 +46,21 @@ func TestTransaction(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestTransactionToOrderers(t *testing.T) {
+	transactor := createTransactor(t)
+	tp := createTransactionProposal(t, transactor)
+	tpr := createTransactionProposalResponse(t, transactor, tp)
+
+	tx, err := txn.New(fab.TransactionRequest{Proposal: tp, ProposalResponses: tpr})
+	assert.Nil(t, err)
+
+	orderer := mocks.NewMockOrderer("orderer2.example.com:7050", nil)
+	resp, err := transactor.SendTransactionToOrderers(tx, []fab.Orderer{orderer})
+	assert.Nil(t, err)
+	assert.Equal(t, orderer.URL(), resp.Orderer, "expected transaction to be sent to the given orderer")
+	assert.Len(t, transactor.Orderers(), 1, "expected orderers of the channel to be unchanged")
+}
+
 func TestTransactionBadStatus(t *testing.T) {
 	transactor := createTransactor(t)
 	tp := createTransactionProposal(t, transactor)
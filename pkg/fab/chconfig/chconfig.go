@@ -69,10 +69,13 @@ type ChannelConfig struct {
 type ChannelCfg struct {
 	id          string
 	blockNumber uint64
+	sequence    uint64
 	msps        []*mb.MSPConfig
 	anchorPeers []*fab.OrgAnchorPeer
 	orderers    []string
 	versions    *fab.Versions
+	// capabilities holds the capabilities enabled in the channel, orderer and application groups
+	capabilities map[fab.ConfigGroupKey]map[string]bool
 }
 
 // NewChannelCfg creates channel cfg
@@ -91,6 +94,11 @@ func (cfg *ChannelCfg) BlockNumber() uint64 {
 	return cfg.blockNumber
 }
 
+// Sequence returns the sequence number of the channel config, which is incremented by each config update
+func (cfg *ChannelCfg) Sequence() uint64 {
+	return cfg.sequence
+}
+
 // MSPs returns msps
 func (cfg *ChannelCfg) MSPs() []*mb.MSPConfig {
 	return cfg.msps
@@ -111,6 +119,12 @@ func (cfg *ChannelCfg) Versions() *fab.Versions {
 	return cfg.versions
 }
 
+// HasCapability returns true if the capability is enabled in the given config group
+// (e.g. fab.V1_2Capability in fab.ApplicationGroupKey)
+func (cfg *ChannelCfg) HasCapability(group fab.ConfigGroupKey, capability string) bool {
+	return cfg.capabilities[group][capability]
+}
+
 // New channel config implementation
 func New(channelID string, options ...Option) (*ChannelConfig, error) {
 	opts, err := prepareOpts(options...)
@@ -329,12 +343,14 @@ func extractConfig(channelID string, block *common.Block) (*ChannelCfg, error) {
 	}
 
 	config := &ChannelCfg{
-		id:          channelID,
-		blockNumber: block.Header.Number,
-		msps:        []*mb.MSPConfig{},
-		anchorPeers: []*fab.OrgAnchorPeer{},
-		orderers:    []string{},
-		versions:    versions,
+		id:           channelID,
+		blockNumber:  block.Header.Number,
+		sequence:     configEnvelope.Config.Sequence,
+		msps:         []*mb.MSPConfig{},
+		anchorPeers:  []*fab.OrgAnchorPeer{},
+		orderers:     []string{},
+		versions:     versions,
+		capabilities: make(map[fab.ConfigGroupKey]map[string]bool),
 	}
 
 	err = loadConfig(config, config.versions.Channel, group, "base", "", true)
@@ -355,6 +371,7 @@ func loadConfig(configItems *ChannelCfg, versionsGroup *common.ConfigGroup, grou
 	}
 
 	logger.Debugf("loadConfigGroup - %s   - version %v", name, group.Version)
+	versionsGroup.Version = group.Version
 	logger.Debugf("loadConfigGroup - %s   - mod policy %s", name, group.ModPolicy)
 	logger.Debugf("loadConfigGroup - %s - >> groups", name)
 
@@ -577,6 +594,26 @@ func loadConfigValue(configItems *ChannelCfg, key string, versionsValue *common.
 		}
 		break
 
+	case channelConfig.CapabilitiesKey:
+		capabilities := &common.Capabilities{}
+		err := proto.Unmarshal(configValue.Value, capabilities)
+		if err != nil {
+			return errors.Wrap(err, "unmarshal capabilities from config failed")
+		}
+		logger.Debugf("loadConfigValue - %s   - Capabilities value :: %v", groupName, capabilities.Capabilities)
+
+		// The capabilities of the channel group are found at the top level (no org)
+		group := fab.ChannelGroupKey
+		if org != "" {
+			group = fab.ConfigGroupKey(org)
+		}
+		enabled := make(map[string]bool)
+		for capability := range capabilities.Capabilities {
+			enabled[capability] = true
+		}
+		configItems.capabilities[group] = enabled
+		break
+
 	default:
 		logger.Debugf("loadConfigValue - %s   - value: %s", groupName, configValue.Value)
 	}
@@ -51,6 +51,13 @@ func TestChannelConfigWithPeer(t *testing.T) {
 	if cfg.ID() != channelID {
 		t.Fatalf("Channel name error. Expecting %s, got %s ", channelID, cfg.ID())
 	}
+	assert.EqualValues(t, 3, cfg.Sequence(), "Expected sequence of the config block")
+	assert.NotEmpty(t, Diff(nil, cfg.Versions()))
+
+	assert.True(t, cfg.HasCapability(fab.ChannelGroupKey, fab.V1_1Capability))
+	assert.True(t, cfg.HasCapability(fab.ApplicationGroupKey, fab.V1_2Capability))
+	assert.False(t, cfg.HasCapability(fab.ApplicationGroupKey, fab.V1_3Capability))
+	assert.False(t, cfg.HasCapability(fab.OrdererGroupKey, fab.V1_1Capability))
 }
 
 func TestChannelConfigWithPeerWithRetries(t *testing.T) {
@@ -260,9 +267,14 @@ func getPeerWithConfigBlockPayload(t *testing.T) fab.Peer {
 			},
 			OrdererAddress: "localhost:7054",
 			RootCA:         validRootCA,
+			Capabilities: map[string][]string{
+				"Channel":     {fab.V1_1Capability},
+				"Application": {fab.V1_1Capability, fab.V1_2Capability},
+			},
 		},
 		Index:           0,
 		LastConfigIndex: 0,
+		Sequence:        3,
 	}
 
 	payload, err := proto.Marshal(builder.Build())
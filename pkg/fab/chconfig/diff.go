@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chconfig
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+const channelGroupPath = "Channel"
+
+// Diff returns the sections of the channel config which differ between the two versions of the config, i.e. the
+// groups, values and policies which were added, removed or modified (per their versions). Sections are identified
+// by their path in the config tree, e.g. "Channel/Application/Org1MSP/Values/AnchorPeers".
+// A group is listed itself only if it was added or removed, or if it was modified without any of its members
+// being modified (e.g. its mod policy).
+func Diff(oldVersions, newVersions *fab.Versions) []string {
+	var changed []string
+	diffGroup(channelGroupPath, channelGroup(oldVersions), channelGroup(newVersions), &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+func channelGroup(versions *fab.Versions) *common.ConfigGroup {
+	if versions == nil {
+		return nil
+	}
+	return versions.Channel
+}
+
+func diffGroup(path string, oldGroup, newGroup *common.ConfigGroup, changed *[]string) {
+	if oldGroup == nil && newGroup == nil {
+		return
+	}
+	if oldGroup == nil || newGroup == nil {
+		*changed = append(*changed, path)
+		return
+	}
+
+	n := len(*changed)
+	for key := range unionKeys(valueVersions(oldGroup.Values), valueVersions(newGroup.Values)) {
+		if !sameVersion(valueVersions(oldGroup.Values), valueVersions(newGroup.Values), key) {
+			*changed = append(*changed, path+"/Values/"+key)
+		}
+	}
+	for key := range unionKeys(policyVersions(oldGroup.Policies), policyVersions(newGroup.Policies)) {
+		if !sameVersion(policyVersions(oldGroup.Policies), policyVersions(newGroup.Policies), key) {
+			*changed = append(*changed, path+"/Policies/"+key)
+		}
+	}
+	for key := range oldGroup.Groups {
+		diffGroup(path+"/"+key, oldGroup.Groups[key], newGroup.Groups[key], changed)
+	}
+	for key := range newGroup.Groups {
+		if _, ok := oldGroup.Groups[key]; !ok {
+			diffGroup(path+"/"+key, nil, newGroup.Groups[key], changed)
+		}
+	}
+
+	if len(*changed) == n && oldGroup.Version != newGroup.Version {
+		*changed = append(*changed, path)
+	}
+}
+
+func valueVersions(values map[string]*common.ConfigValue) map[string]uint64 {
+	versions := make(map[string]uint64, len(values))
+	for key, value := range values {
+		versions[key] = value.GetVersion()
+	}
+	return versions
+}
+
+func policyVersions(policies map[string]*common.ConfigPolicy) map[string]uint64 {
+	versions := make(map[string]uint64, len(policies))
+	for key, policy := range policies {
+		versions[key] = policy.GetVersion()
+	}
+	return versions
+}
+
+func unionKeys(m1, m2 map[string]uint64) map[string]struct{} {
+	keys := make(map[string]struct{}, len(m1)+len(m2))
+	for key := range m1 {
+		keys[key] = struct{}{}
+	}
+	for key := range m2 {
+		keys[key] = struct{}{}
+	}
+	return keys
+}
+
+func sameVersion(m1, m2 map[string]uint64, key string) bool {
+	v1, ok1 := m1[key]
+	v2, ok2 := m2[key]
+	return ok1 == ok2 && v1 == v2
+}
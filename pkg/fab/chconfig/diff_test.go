@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chconfig
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	oldVersions := &fab.Versions{Channel: &common.ConfigGroup{
+		Version: 1,
+		Groups: map[string]*common.ConfigGroup{
+			"Application": {
+				Version: 1,
+				Groups: map[string]*common.ConfigGroup{
+					"Org1MSP": {Values: map[string]*common.ConfigValue{"AnchorPeers": {Version: 0}, "MSP": {Version: 0}}},
+					"Org2MSP": {},
+				},
+			},
+			"Orderer": {Values: map[string]*common.ConfigValue{"BatchSize": {Version: 0}}},
+		},
+		Policies: map[string]*common.ConfigPolicy{"Admins": {Version: 0}},
+	}}
+	newVersions := &fab.Versions{Channel: &common.ConfigGroup{
+		Version: 1,
+		Groups: map[string]*common.ConfigGroup{
+			"Application": {
+				Version: 2,
+				Groups: map[string]*common.ConfigGroup{
+					"Org1MSP": {Values: map[string]*common.ConfigValue{"AnchorPeers": {Version: 1}, "MSP": {Version: 0}}},
+					"Org3MSP": {},
+				},
+			},
+			"Orderer": {Version: 1, Values: map[string]*common.ConfigValue{"BatchSize": {Version: 0}}},
+		},
+		Policies: map[string]*common.ConfigPolicy{"Admins": {Version: 0}, "Readers": {Version: 0}},
+	}}
+
+	assert.Equal(t, []string{
+		"Channel/Application/Org1MSP/Values/AnchorPeers",
+		"Channel/Application/Org2MSP",
+		"Channel/Application/Org3MSP",
+		"Channel/Orderer",
+		"Channel/Policies/Readers",
+	}, Diff(oldVersions, newVersions))
+
+	assert.Empty(t, Diff(oldVersions, oldVersions))
+	assert.Equal(t, []string{"Channel"}, Diff(nil, newVersions))
+}
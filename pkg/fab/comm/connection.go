@@ -161,6 +161,7 @@ func newDialOpts(config fab.EndpointConfig, url string, params *params) ([]grpc.
 
 	dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxCallRecvMsgSize),
 		grpc.MaxCallSendMsgSize(maxCallSendMsgSize)))
+	dialOpts = append(dialOpts, params.serviceConfig.DialOptions()...)
 
 	return dialOpts, nil
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -21,6 +22,7 @@ type params struct {
 	failFast        bool
 	insecure        bool
 	connectTimeout  time.Duration
+	serviceConfig   *comm.ServiceConfig
 }
 
 func defaultParams() *params {
@@ -75,6 +77,15 @@ func WithConnectTimeout(value time.Duration) options.Opt {
 	}
 }
 
+// WithServiceConfig sets the gRPC service config (i.e. retry policies) applied to the connection
+func WithServiceConfig(value *comm.ServiceConfig) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(serviceConfigSetter); ok {
+			setter.SetServiceConfig(value)
+		}
+	}
+}
+
 // WithInsecure indicates to fall back to an insecure connection if the
 // connection URL does not specify a protocol
 func WithInsecure() options.Opt {
@@ -119,6 +130,11 @@ func (p *params) SetInsecure(value bool) {
 	p.insecure = value
 }
 
+func (p *params) SetServiceConfig(value *comm.ServiceConfig) {
+	logger.Debugf("ServiceConfig: %#v", value)
+	p.serviceConfig = value
+}
+
 type hostOverrideSetter interface {
 	SetHostOverride(value string)
 }
@@ -142,3 +158,7 @@ type insecureSetter interface {
 type connectTimeoutSetter interface {
 	SetConnectTimeout(value time.Duration)
 }
+
+type serviceConfigSetter interface {
+	SetServiceConfig(value *comm.ServiceConfig)
+}
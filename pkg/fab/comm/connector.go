@@ -8,12 +8,17 @@ package comm
 
 import (
 	"context"
+	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 )
 
 const (
@@ -39,14 +44,35 @@ type CachingConnector struct {
 	janitorChan   chan *cachedConn
 	janitorDone   chan bool
 	janitorClosed chan bool
+	observer      atomic.Value
+	lookupIPAddr  lookupIPAddrFunc
+	netDial       netDialFunc
+	statsLock     sync.Mutex
+	stats         map[string]*fab.CommStats
 }
 
 type cachedConn struct {
 	target    string
 	conn      *grpc.ClientConn
+	trace     *dialTrace
 	open      int
+	created   time.Time
 	lastOpen  time.Time
 	lastClose time.Time
+	draining  bool
+}
+
+// ConnectionInfo is a snapshot of a cached connection
+type ConnectionInfo struct {
+	Target string
+	State  connectivity.State
+	Age    time.Duration
+	// RefCount is the number of usages of the connection which were not released
+	RefCount int
+	// Draining is true if the connection was closed while in use. It's closed once all usages are released.
+	Draining bool
+	// Dial describes how the connection was last established, it's empty until the connection is established
+	Dial DialInfo
 }
 
 // NewCachingConnector creates a GRPC connection cache. The cache is governed by
@@ -60,6 +86,9 @@ func NewCachingConnector(sweepTime time.Duration, idleTime time.Duration) *Cachi
 		janitorClosed: make(chan bool, 1),
 		sweepTime:     sweepTime,
 		idleTime:      idleTime,
+		lookupIPAddr:  net.DefaultResolver.LookupIPAddr,
+		netDial:       net.DialTimeout,
+		stats:         map[string]*fab.CommStats{},
 	}
 
 	// cc.janitorClosed determines if a goroutine needs to be spun up.
@@ -99,12 +128,36 @@ func (cc *CachingConnector) Close() {
 	cc.janitorDone = nil
 }
 
+// SetDialObserver sets the observer which is notified of the dials of the connections, including the
+// reconnections of the cached connections.
+func (cc *CachingConnector) SetDialObserver(observer DialObserver) {
+	cc.observer.Store(dialObserverRef{observer})
+}
+
+//dialObserverRef allows the observer to be stored in an atomic.Value, which requires a consistent concrete type
+type dialObserverRef struct {
+	DialObserver
+}
+
+func (cc *CachingConnector) dialObserver() DialObserver {
+	ref, ok := cc.observer.Load().(dialObserverRef)
+	if !ok {
+		return nil
+	}
+	return ref.DialObserver
+}
+
 // DialContext is a wrapper for grpc.DialContext where connections are cached.
+// The connections are dialed by the connector, which traces the phases of the dials (see DialInfo) and overrides
+// the dialer of the options. If the connection isn't ready before the context is done, the error is a DialError
+// which classifies the phase of the dial which failed or timed out.
 func (cc *CachingConnector) DialContext(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	logger.Debugf("DialContext: %s", target)
 
 	c, ok := cc.loadConn(target)
-	if !ok {
+	if ok {
+		cc.updateStats(target, func(stats *fab.CommStats) { stats.Hits++ })
+	} else {
 		createdConn, err := cc.createConn(ctx, target, opts...)
 		if err != nil {
 			return nil, errors.WithMessage(err, "connection creation failed")
@@ -113,7 +166,7 @@ func (cc *CachingConnector) DialContext(ctx context.Context, target string, opts
 	}
 
 	if err := cc.openConn(ctx, c); err != nil {
-		return nil, errors.Errorf("dialing connection timed out [%s]", target)
+		return nil, c.trace.dialError(err)
 	}
 	return c.conn, nil
 }
@@ -141,12 +194,150 @@ func (cc *CachingConnector) ReleaseConn(conn *grpc.ClientConn) {
 		return
 	}
 	logger.Debugf("ReleaseConn [%s]", cconn.target)
+	cc.updateStats(cconn.target, func(stats *fab.CommStats) { stats.Releases++ })
 
 	if cconn.open > 0 {
 		cconn.lastClose = time.Now()
 		cconn.open--
 	}
 
+	if cconn.draining {
+		if cconn.open == 0 {
+			logger.Debugf("closing drained connection [%s]", cconn.target)
+			cc.closeCachedConn(cconn)
+		}
+		return
+	}
+
+	cc.updateJanitor(cconn)
+}
+
+// Connections returns a snapshot of the cached connections, including the connections which are draining.
+func (cc *CachingConnector) Connections() []ConnectionInfo {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	now := time.Now()
+	var conns []ConnectionInfo
+	for conn, cconn := range cc.index {
+		conns = append(conns, ConnectionInfo{
+			Target:   cconn.target,
+			State:    conn.GetState(),
+			Age:      now.Sub(cconn.created),
+			RefCount: cconn.open,
+			Draining: cconn.draining,
+			Dial:     cconn.trace.lastInfo(),
+		})
+	}
+	return conns
+}
+
+// Stats returns the statistics of the connections to each target, ordered by target. The number of active usages
+// of a target is the number of usages of its connections which weren't released, including the connections
+// which are draining.
+func (cc *CachingConnector) Stats() []fab.CommStats {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	active := make(map[string]int)
+	for _, cconn := range cc.index {
+		active[cconn.target] += cconn.open
+	}
+
+	cc.statsLock.Lock()
+	defer cc.statsLock.Unlock()
+
+	stats := make([]fab.CommStats, 0, len(cc.stats))
+	for target, targetStats := range cc.stats {
+		s := *targetStats
+		s.Active = active[target]
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Target < stats[j].Target })
+	return stats
+}
+
+//updateStats updates the statistics of the target. It may be called while holding the lock of the connector.
+func (cc *CachingConnector) updateStats(target string, update func(stats *fab.CommStats)) {
+	cc.statsLock.Lock()
+	defer cc.statsLock.Unlock()
+
+	stats, ok := cc.stats[target]
+	if !ok {
+		stats = &fab.CommStats{Target: target}
+		cc.stats[target] = stats
+	}
+	update(stats)
+}
+
+//countDial counts a dial of a connection to the target
+func (cc *CachingConnector) countDial(target string, err error) {
+	cc.updateStats(target, func(stats *fab.CommStats) {
+		stats.Dials++
+		if err != nil {
+			stats.DialFailures++
+		}
+	})
+}
+
+// CloseConnection removes the connection to the target from the cache, so that the next usage dials a new
+// connection. A connection which is in use is closed once all its usages are released, unless force is
+// true in which case it's closed immediately and the calls in progress on it fail.
+func (cc *CachingConnector) CloseConnection(target string, force bool) error {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	if cc.janitorDone == nil {
+		return errors.New("caching connector is closed")
+	}
+
+	connRaw, ok := cc.conns.Load(target)
+	if !ok {
+		return errors.Errorf("connection not found [%s]", target)
+	}
+	cconn := connRaw.(*cachedConn)
+	cc.conns.Delete(target)
+
+	if cconn.open > 0 && !force {
+		logger.Debugf("draining connection [%s] with %d usages", target, cconn.open)
+		cconn.draining = true
+		cc.updateJanitor(cconn)
+		return nil
+	}
+
+	logger.Debugf("closing connection [%s] with %d usages", target, cconn.open)
+	cc.closeCachedConn(cconn)
+	return nil
+}
+
+// CloseAll removes all connections from the cache, as CloseConnection does.
+func (cc *CachingConnector) CloseAll(force bool) {
+	var targets []string
+	cc.conns.Range(func(key, value interface{}) bool {
+		targets = append(targets, key.(string))
+		return true
+	})
+
+	for _, target := range targets {
+		if err := cc.CloseConnection(target, force); err != nil {
+			logger.Debugf("unable to close connection [%s]", err)
+		}
+	}
+}
+
+// closeCachedConn closes a connection which was removed from the cache. The janitor stops monitoring the
+// connection unless it monitors a new connection to the target. The caller must hold the lock.
+func (cc *CachingConnector) closeCachedConn(cconn *cachedConn) {
+	delete(cc.index, cconn.conn)
+	if err := cconn.conn.Close(); err != nil {
+		logger.Debugf("unable to close connection [%s]", err)
+	}
+
+	if _, ok := cc.conns.Load(cconn.target); ok {
+		return
+	}
+	cconn.open = 0
+	cconn.lastClose = time.Time{}
 	cc.updateJanitor(cconn)
 }
 
@@ -175,10 +366,23 @@ func (cc *CachingConnector) createConn(ctx context.Context, target string, opts
 
 	cconn, ok := cc.loadConn(target)
 	if ok {
+		cc.updateStats(target, func(stats *fab.CommStats) { stats.Hits++ })
 		return cconn, nil
 	}
 
 	logger.Debugf("creating connection [%s]", target)
+	cc.updateStats(target, func(stats *fab.CommStats) { stats.Misses++ })
+	trace := &dialTrace{
+		target:       target,
+		lookupIPAddr: cc.lookupIPAddr,
+		netDial:      cc.netDial,
+		observer:     cc.dialObserver,
+		counter:      cc.countDial,
+	}
+	if connectTimeout, ok := ctx.Value(connectTimeoutKey{}).(time.Duration); ok {
+		trace.connectTimeout = connectTimeout
+	}
+	opts = append(append([]grpc.DialOption{}, opts...), grpc.WithDialer(trace.dial))
 	conn, err := grpc.DialContext(ctx, target, opts...)
 	if err != nil {
 		return nil, errors.WithMessage(err, "dialing peer failed")
@@ -186,8 +390,10 @@ func (cc *CachingConnector) createConn(ctx context.Context, target string, opts
 
 	logger.Debugf("storing connection [%s]", target)
 	cconn = &cachedConn{
-		target: target,
-		conn:   conn,
+		target:  target,
+		conn:    conn,
+		trace:   trace,
+		created: time.Now(),
 	}
 	cc.conns.Store(target, cconn)
 	cc.index[conn] = cconn
@@ -344,8 +550,11 @@ func cache(conns map[string]*cachedConn, updateConn *cachedConn) {
 	} else if c.conn != updateConn.conn {
 		logger.Debugf("connection change in connection janitor")
 
-		if err := c.conn.Close(); err != nil {
-			logger.Debugf("unable to close connection [%s]", err)
+		// Draining connections are closed by the connector once they are released
+		if !c.draining {
+			if err := c.conn.Close(); err != nil {
+				logger.Debugf("unable to close connection [%s]", err)
+			}
 		}
 
 	} else {
@@ -9,12 +9,15 @@ package comm
 import (
 	"context"
 	"math/rand"
+	"net"
 	"sync"
 	"testing"
 	"time"
 	"unsafe"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
@@ -91,6 +94,135 @@ func TestDialAfterClose(t *testing.T) {
 	assert.Error(t, err, "expecting error when dialing after connector is closed")
 }
 
+func TestConnectorCloseConnection(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	conn1, err := connector.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	cancel()
+	assert.Nil(t, err, "DialContext should have succeeded")
+
+	conns := connector.Connections()
+	assert.Len(t, conns, 1)
+	assert.Equal(t, endorserAddr[0], conns[0].Target)
+	assert.Equal(t, 1, conns[0].RefCount)
+	assert.False(t, conns[0].Draining)
+
+	// The connection is in use: it's drained and only closed once released
+	err = connector.CloseConnection(endorserAddr[0], false)
+	assert.Nil(t, err, "CloseConnection should have succeeded")
+	assert.NotEqual(t, connectivity.Shutdown, conn1.GetState(), "connection in use should not be shutdown")
+	conns = connector.Connections()
+	assert.Len(t, conns, 1)
+	assert.True(t, conns[0].Draining)
+
+	ctx, cancel = context.WithTimeout(context.Background(), normalTimeout)
+	conn2, err := connector.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	cancel()
+	assert.Nil(t, err, "DialContext should have succeeded")
+	assert.NotEqual(t, unsafe.Pointer(conn1), unsafe.Pointer(conn2), "connection should have been re-dialed")
+	assert.Len(t, connector.Connections(), 2)
+
+	connector.ReleaseConn(conn1)
+	assert.Equal(t, connectivity.Shutdown, conn1.GetState(), "drained connection should be shutdown")
+	assert.NotEqual(t, connectivity.Shutdown, conn2.GetState(), "new connection should not be shutdown")
+	assert.Len(t, connector.Connections(), 1)
+
+	// Force closes the connection although it's in use
+	err = connector.CloseConnection(endorserAddr[0], true)
+	assert.Nil(t, err, "CloseConnection should have succeeded")
+	assert.Equal(t, connectivity.Shutdown, conn2.GetState(), "connection should be shutdown")
+	assert.Empty(t, connector.Connections())
+
+	err = connector.CloseConnection(endorserAddr[0], false)
+	assert.NotNil(t, err, "CloseConnection should have failed for unknown target")
+}
+
+func TestConnectorStats(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+
+	var commManager fab.CommManager = connector
+	statsProvider, ok := commManager.(fab.CommStatsProvider)
+	if !ok {
+		t.Fatal("Expecting the connector to provide statistics")
+	}
+	assert.Empty(t, statsProvider.Stats())
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	conn1, err := connector.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	cancel()
+	assert.Nil(t, err, "DialContext should have succeeded")
+
+	ctx, cancel = context.WithTimeout(context.Background(), normalTimeout)
+	_, err = connector.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	cancel()
+	assert.Nil(t, err, "DialContext should have succeeded")
+
+	ctx, cancel = context.WithTimeout(context.Background(), normalTimeout)
+	conn3, err := connector.DialContext(ctx, endorserAddr[1], grpc.WithInsecure())
+	cancel()
+	assert.Nil(t, err, "DialContext should have succeeded")
+
+	connector.ReleaseConn(conn1)
+	connector.ReleaseConn(conn3)
+
+	// The first connection is still in use
+	assert.Len(t, connector.Stats(), 2)
+	assert.Equal(t, fab.CommStats{Target: endorserAddr[0], Active: 1, Hits: 1, Misses: 1, Dials: 1, Releases: 1}, targetStats(connector, endorserAddr[0]))
+	assert.Equal(t, fab.CommStats{Target: endorserAddr[1], Active: 0, Hits: 0, Misses: 1, Dials: 1, Releases: 1}, targetStats(connector, endorserAddr[1]))
+
+	// The counts are kept once the connection is closed
+	err = connector.CloseConnection(endorserAddr[1], false)
+	assert.Nil(t, err, "CloseConnection should have succeeded")
+	assert.Equal(t, uint64(1), targetStats(connector, endorserAddr[1]).Misses)
+
+	// Failed dials are counted
+	connector.netDial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err = dial(connector, "127.0.0.1:7051", grpc.WithInsecure())
+	assert.Error(t, err, "DialContext should have failed")
+	stats := targetStats(connector, "127.0.0.1:7051")
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.True(t, stats.Dials >= 1, "Expecting the failed dials to be counted")
+	assert.Equal(t, stats.Dials, stats.DialFailures)
+	assert.Zero(t, stats.Active)
+	assert.Len(t, connector.Stats(), 3)
+}
+
+func targetStats(connector *CachingConnector, target string) fab.CommStats {
+	for _, stats := range connector.Stats() {
+		if stats.Target == target {
+			return stats
+		}
+	}
+	return fab.CommStats{}
+}
+
+func TestConnectorCloseAll(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+
+	var conns []*grpc.ClientConn
+	for _, addr := range endorserAddr[:2] {
+		ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+		conn, err := connector.DialContext(ctx, addr, grpc.WithInsecure())
+		cancel()
+		assert.Nil(t, err, "DialContext should have succeeded")
+		connector.ReleaseConn(conn)
+		conns = append(conns, conn)
+	}
+	assert.Len(t, connector.Connections(), 2)
+
+	connector.CloseAll(false)
+	for _, conn := range conns {
+		assert.Equal(t, connectivity.Shutdown, conn.GetState(), "released connection should be shutdown")
+	}
+	assert.Empty(t, connector.Connections())
+}
+
 func TestConnectorHappyFlushNumber1(t *testing.T) {
 	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
 	defer connector.Close()
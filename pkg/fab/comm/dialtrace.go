@@ -0,0 +1,357 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+)
+
+// DialInfo describes how a connection was established: the address it was established with and the duration
+// of each phase of the dial
+type DialInfo struct {
+	// RemoteAddr is the address the connection was established with, i.e. the resolved address of the target
+	RemoteAddr string
+	// Resolution is the duration of the resolution of the host name of the target, zero for an IP address
+	Resolution time.Duration
+	// Connect is the duration of the TCP (or Unix socket) connect
+	Connect time.Duration
+	// Handshake is the duration of the TLS handshake, zero if the connection isn't secured
+	Handshake time.Duration
+}
+
+// DialObserver observes the connections dialed by the CachingConnector, e.g. to export the durations of the
+// phases of the dials as metrics
+type DialObserver interface {
+	// ObserveDial is notified of each attempt to establish a connection to the target, including the
+	// reconnections. err is nil if the connection was established, otherwise it's a DialError whose code
+	// classifies the phase which failed.
+	ObserveDial(target string, info DialInfo, err error)
+}
+
+// DialError is returned when a connection couldn't be established. Code classifies the phase of the dial which
+// failed: DNSFailure, ConnectTimeout or TLSHandshakeFailure, or ConnectionFailed if the phase isn't known or the
+// failure isn't specific to the phase, e.g. a refused connection.
+type DialError struct {
+	Target string
+	Code   status.Code
+	Err    error
+}
+
+func (e *DialError) Error() string {
+	return fmt.Sprintf("dialing connection failed [%s] (%s): %s", e.Target, e.Code, e.Err)
+}
+
+// DialErrorCode returns the code of the DialError which caused err, or ConnectionFailed if err wasn't caused by a
+// DialError
+func DialErrorCode(err error) status.Code {
+	if dialErr, ok := errors.Cause(err).(*DialError); ok {
+		return dialErr.Code
+	}
+	return status.ConnectionFailed
+}
+
+type connectTimeoutKey struct{}
+
+// ContextWithConnectTimeout returns a copy of the context which bounds the connects of the connections dialed with
+// it by the CachingConnector, so that the deadline of the context isn't consumed by the connect alone,
+// e.g. when the TLS handshake has its own deadline
+func ContextWithConnectTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, connectTimeoutKey{}, timeout)
+}
+
+type dialPhase int
+
+const (
+	phaseIdle dialPhase = iota
+	phaseResolving
+	phaseConnecting
+	phaseConnected
+	phaseHandshaking
+)
+
+// TLS record content types
+const (
+	tlsRecordHandshake       = 22
+	tlsRecordApplicationData = 23
+	tlsRecordHeaderLen       = 5
+)
+
+type lookupIPAddrFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+type netDialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+//dialTrace dials the connections of a target and keeps track of the phase of the dial in progress, of the last
+//connection established and of the error of the last failed dial
+type dialTrace struct {
+	target         string
+	connectTimeout time.Duration
+	lookupIPAddr   lookupIPAddrFunc
+	netDial        netDialFunc
+	observer       func() DialObserver
+	counter        func(target string, err error)
+
+	lock    sync.Mutex
+	phase   dialPhase
+	current DialInfo
+	started time.Time
+	info    DialInfo
+	err     *DialError
+}
+
+//dial is the dialer of the connections of the target. It resolves the host name of the target and connects to the
+//resolved addresses, in order, until a connect succeeds. The TLS handshake of the connection is traced by the
+//returned connection.
+func (t *dialTrace) dial(addr string, timeout time.Duration) (net.Conn, error) {
+	if t.connectTimeout > 0 && (timeout <= 0 || timeout > t.connectTimeout) {
+		timeout = t.connectTimeout
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	t.lock.Lock()
+	t.phase = phaseResolving
+	t.current = DialInfo{}
+	t.lock.Unlock()
+
+	network := "tcp"
+	addrs := []string{addr}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// Unix domain sockets are addressed by the path of the socket
+		network = "unix"
+	} else if net.ParseIP(host) == nil {
+		resolved, err := t.resolve(host, port, deadline)
+		if err != nil {
+			return nil, t.fail(status.DNSFailure, err)
+		}
+		addrs = resolved
+	}
+
+	t.setPhase(phaseConnecting)
+	started := time.Now()
+	var conn net.Conn
+	for _, a := range addrs {
+		conn, err = t.netDial(network, a, remaining(deadline))
+		if err == nil || (!deadline.IsZero() && !time.Now().Before(deadline)) {
+			break
+		}
+	}
+
+	t.lock.Lock()
+	t.current.Connect = time.Since(started)
+	t.lock.Unlock()
+	if err != nil {
+		code := status.ConnectionFailed
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			code = status.ConnectTimeout
+		}
+		return nil, t.fail(code, err)
+	}
+
+	remoteAddr := addr
+	if conn.RemoteAddr() != nil && conn.RemoteAddr().String() != "" {
+		remoteAddr = conn.RemoteAddr().String()
+	}
+	t.lock.Lock()
+	t.current.RemoteAddr = remoteAddr
+	t.phase = phaseConnected
+	t.started = time.Now()
+	t.lock.Unlock()
+
+	return &tracedConn{Conn: conn, trace: t}, nil
+}
+
+func (t *dialTrace) resolve(host, port string, deadline time.Time) ([]string, error) {
+	ctx := context.Background()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	started := time.Now()
+	ipAddrs, err := t.lookupIPAddr(ctx, host)
+	t.lock.Lock()
+	t.current.Resolution = time.Since(started)
+	t.lock.Unlock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving host [%s] failed", host)
+	}
+	if len(ipAddrs) == 0 {
+		return nil, errors.Errorf("no address found for host [%s]", host)
+	}
+
+	var addrs []string
+	for _, ipAddr := range ipAddrs {
+		addrs = append(addrs, net.JoinHostPort(ipAddr.String(), port))
+	}
+	return addrs, nil
+}
+
+//remaining returns the time left until the deadline, or zero (no timeout) if there's no deadline
+func remaining(deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return 0
+	}
+	if d := time.Until(deadline); d > 0 {
+		return d
+	}
+	// An expired deadline mustn't be mistaken for no timeout
+	return time.Nanosecond
+}
+
+func (t *dialTrace) setPhase(phase dialPhase) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.phase = phase
+}
+
+//fail records the failure of the dial in progress and notifies the observer
+func (t *dialTrace) fail(code status.Code, err error) error {
+	dialErr := &DialError{Target: t.target, Code: code, Err: err}
+
+	t.lock.Lock()
+	t.phase = phaseIdle
+	t.err = dialErr
+	info := t.current
+	t.lock.Unlock()
+
+	logger.Debugf("dialing connection failed [%s]: %s", t.target, dialErr)
+	t.notify(info, dialErr)
+	return dialErr
+}
+
+//established records the connection established by the dial in progress and notifies the observer
+func (t *dialTrace) established(handshake bool) {
+	t.lock.Lock()
+	if handshake {
+		t.current.Handshake = time.Since(t.started)
+	}
+	t.phase = phaseIdle
+	t.info = t.current
+	t.err = nil
+	info := t.current
+	t.lock.Unlock()
+
+	logger.Debugf("connection established [%s] with [%s]", t.target, info.RemoteAddr)
+	t.notify(info, nil)
+}
+
+func (t *dialTrace) notify(info DialInfo, err error) {
+	if t.counter != nil {
+		t.counter(t.target, err)
+	}
+	if observer := t.observer(); observer != nil {
+		observer.ObserveDial(t.target, info, err)
+	}
+}
+
+//lastInfo returns how the last connection was established
+func (t *dialTrace) lastInfo() DialInfo {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.info
+}
+
+//dialError returns the error of a connection which didn't become ready before the context of DialContext was done:
+//the error of the phase of the dial in progress, which timed out, or else the error of the last failed dial
+func (t *dialTrace) dialError(ctxErr error) *DialError {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch t.phase {
+	case phaseResolving:
+		return &DialError{Target: t.target, Code: status.DNSFailure, Err: errors.Wrap(ctxErr, "resolving host timed out")}
+	case phaseConnecting:
+		return &DialError{Target: t.target, Code: status.ConnectTimeout, Err: errors.Wrap(ctxErr, "connect timed out")}
+	case phaseHandshaking:
+		return &DialError{Target: t.target, Code: status.TLSHandshakeFailure, Err: errors.Wrap(ctxErr, "TLS handshake timed out")}
+	}
+	if t.err != nil {
+		return t.err
+	}
+	return &DialError{Target: t.target, Code: status.ConnectionFailed, Err: errors.Wrap(ctxErr, "dialing connection timed out")}
+}
+
+//tracedConn traces the TLS handshake of a connection. The handshake starts with the first TLS handshake record
+//written to the connection and ends with the first application data record; the connection isn't secured if the
+//first data written isn't a TLS handshake record (e.g. it's the HTTP/2 preface).
+type tracedConn struct {
+	net.Conn
+	trace *dialTrace
+	lock  sync.Mutex
+	done  bool
+}
+
+func (c *tracedConn) Write(b []byte) (int, error) {
+	c.traceWrite(b)
+	return c.Conn.Write(b)
+}
+
+func (c *tracedConn) traceWrite(b []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.done || len(b) == 0 {
+		return
+	}
+
+	c.trace.lock.Lock()
+	phase := c.trace.phase
+	if phase == phaseConnected && b[0] == tlsRecordHandshake {
+		c.trace.phase = phaseHandshaking
+		phase = phaseHandshaking
+	}
+	c.trace.lock.Unlock()
+
+	if phase != phaseHandshaking {
+		c.done = true
+		c.trace.established(false)
+		return
+	}
+	if containsApplicationData(b) {
+		c.done = true
+		c.trace.established(true)
+	}
+}
+
+func (c *tracedConn) Close() error {
+	c.lock.Lock()
+	if !c.done {
+		c.done = true
+		c.trace.lock.Lock()
+		phase := c.trace.phase
+		c.trace.lock.Unlock()
+		if phase == phaseHandshaking {
+			c.trace.fail(status.TLSHandshakeFailure, errors.New("connection closed during the TLS handshake"))
+		} else {
+			c.trace.fail(status.ConnectionFailed, errors.New("connection closed before it was used"))
+		}
+	}
+	c.lock.Unlock()
+	return c.Conn.Close()
+}
+
+//containsApplicationData returns true if the TLS records written contain an application data record
+func containsApplicationData(b []byte) bool {
+	for i := 0; i+tlsRecordHeaderLen <= len(b); {
+		if b[i] == tlsRecordApplicationData {
+			return true
+		}
+		length := int(b[i+3])<<8 | int(b[i+4])
+		i += tlsRecordHeaderLen + length
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+)
+
+const dialTimeout = 500 * time.Millisecond
+
+func TestDialInfo(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+	observer := &dialRecorder{}
+	connector.SetDialObserver(observer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	conn, err := connector.DialContext(ctx, endorserAddr[0], grpc.WithInsecure())
+	cancel()
+	if err != nil {
+		t.Fatalf("DialContext should have succeeded: %s", err)
+	}
+	defer connector.ReleaseConn(conn)
+
+	dials := observer.dials()
+	if assert.Len(t, dials, 1) {
+		assert.Nil(t, dials[0].err)
+		assert.Equal(t, endorserAddr[0], dials[0].info.RemoteAddr)
+		assert.Zero(t, dials[0].info.Resolution, "Expected no resolution of an IP address")
+		assert.Zero(t, dials[0].info.Handshake, "Expected no TLS handshake")
+	}
+
+	conns := connector.Connections()
+	if assert.Len(t, conns, 1) {
+		assert.Equal(t, endorserAddr[0], conns[0].Dial.RemoteAddr)
+	}
+}
+
+func TestDialInfoTLS(t *testing.T) {
+	serverCert, err := newTestServerCert()
+	if err != nil {
+		t.Fatalf("Failed to create server certificate: %s", err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	defer srv.Stop()
+	_, addr, ok := startEndorserServer(srv, endorserAddress)
+	if !ok {
+		t.Fatal("Failed to start TLS server")
+	}
+	_, port, _ := net.SplitHostPort(addr)
+
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+	observer := &dialRecorder{}
+	connector.SetDialObserver(observer)
+	connector.lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		time.Sleep(10 * time.Millisecond)
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), normalTimeout)
+	conn, err := connector.DialContext(ctx, net.JoinHostPort("peer0.example.com", port), tlsOption())
+	cancel()
+	if err != nil {
+		t.Fatalf("DialContext should have succeeded: %s", err)
+	}
+	defer connector.ReleaseConn(conn)
+
+	dials := observer.dials()
+	if assert.Len(t, dials, 1) {
+		assert.Nil(t, dials[0].err)
+		assert.Equal(t, "peer0.example.com:"+port, dials[0].target)
+		assert.Equal(t, "127.0.0.1:"+port, dials[0].info.RemoteAddr, "Expected the resolved address")
+		assert.True(t, dials[0].info.Resolution >= 10*time.Millisecond, "Expected the duration of the resolution")
+		assert.NotZero(t, dials[0].info.Handshake, "Expected the duration of the TLS handshake")
+	}
+}
+
+func TestDialDNSFailure(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+	observer := &dialRecorder{}
+	connector.SetDialObserver(observer)
+
+	connector.lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host}
+	}
+	_, err := dial(connector, "unknown.example.com:7051", grpc.WithInsecure())
+	assert.Equal(t, status.DNSFailure, DialErrorCode(err))
+	assert.Contains(t, err.Error(), "no such host")
+	if dials := observer.dials(); assert.NotEmpty(t, dials) {
+		assert.Equal(t, status.DNSFailure, DialErrorCode(dials[0].err))
+	}
+
+	// A resolution which doesn't complete before the deadline
+	connector.lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		time.Sleep(2 * dialTimeout)
+		return nil, ctx.Err()
+	}
+	_, err = dial(connector, "slow.example.com:7051", grpc.WithInsecure())
+	assert.Equal(t, status.DNSFailure, DialErrorCode(err))
+}
+
+func TestDialConnectTimeout(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+
+	// A connect which doesn't complete before the deadline of the context
+	connector.netDial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		time.Sleep(2 * dialTimeout)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+	_, err := dial(connector, "127.0.0.1:7051", grpc.WithInsecure())
+	assert.Equal(t, status.ConnectTimeout, DialErrorCode(err))
+
+	// A connect which times out
+	connector.netDial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+	_, err = dial(connector, "127.0.0.2:7051", grpc.WithInsecure())
+	assert.Equal(t, status.ConnectTimeout, DialErrorCode(err))
+
+	// The connect timeout of the context bounds the connect
+	var connectTimeout time.Duration
+	connector.netDial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		connectTimeout = timeout
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+	ctx, cancel := context.WithTimeout(ContextWithConnectTimeout(context.Background(), 100*time.Millisecond), dialTimeout)
+	_, err = connector.DialContext(ctx, "127.0.0.3:7051", grpc.WithInsecure())
+	cancel()
+	assert.Error(t, err)
+	assert.True(t, connectTimeout > 0 && connectTimeout <= 100*time.Millisecond, "Expected connect timeout to be bounded")
+}
+
+func TestDialConnectionRefused(t *testing.T) {
+	lis, err := net.Listen("tcp", endorserAddress)
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+
+	_, err = dial(connector, addr, grpc.WithInsecure())
+	assert.Equal(t, status.ConnectionFailed, DialErrorCode(err))
+}
+
+func TestDialTLSHandshakeFailure(t *testing.T) {
+	connector := NewCachingConnector(normalSweepTime, normalIdleTime)
+	defer connector.Close()
+	observer := &dialRecorder{}
+	connector.SetDialObserver(observer)
+
+	// A server which never responds to the handshake
+	addr, stop := startMisbehavingListener(t, func(conn net.Conn) {
+		time.Sleep(2 * dialTimeout)
+		conn.Close()
+	})
+	defer stop()
+	_, err := dial(connector, addr, tlsOption())
+	assert.Equal(t, status.TLSHandshakeFailure, DialErrorCode(err))
+
+	// A server which closes the connection during the handshake
+	addr, stop = startMisbehavingListener(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1024))
+		conn.Close()
+	})
+	defer stop()
+	_, err = dial(connector, addr, tlsOption())
+	assert.Equal(t, status.TLSHandshakeFailure, DialErrorCode(err))
+
+	var handshakeFailures int
+	for _, d := range observer.dials() {
+		if d.target == addr && DialErrorCode(d.err) == status.TLSHandshakeFailure {
+			handshakeFailures++
+			assert.NotEmpty(t, d.info.RemoteAddr, "Expected the address of the failed connection")
+		}
+	}
+	assert.NotZero(t, handshakeFailures, "Expected the handshake failures to be observed")
+}
+
+func TestDialErrorCode(t *testing.T) {
+	err := errors.WithMessage(&DialError{Target: "peer0:7051", Code: status.DNSFailure, Err: errors.New("test")}, "dialing failed")
+	assert.Equal(t, status.DNSFailure, DialErrorCode(err))
+	assert.Equal(t, status.ConnectionFailed, DialErrorCode(errors.New("test")))
+}
+
+func dial(connector *CachingConnector, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	return connector.DialContext(ctx, target, opts...)
+}
+
+func tlsOption() grpc.DialOption {
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+}
+
+//startMisbehavingListener starts a listener which handles its connections with the given handler
+func startMisbehavingListener(t *testing.T, handle func(conn net.Conn)) (string, func()) {
+	lis, err := net.Listen("tcp", endorserAddress)
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return lis.Addr().String(), func() { lis.Close() }
+}
+
+type dialRecord struct {
+	target string
+	info   DialInfo
+	err    error
+}
+
+type dialRecorder struct {
+	lock    sync.Mutex
+	records []dialRecord
+}
+
+func (r *dialRecorder) ObserveDial(target string, info DialInfo, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = append(r.records, dialRecord{target: target, info: info, err: err})
+}
+
+func (r *dialRecorder) dials() []dialRecord {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]dialRecord{}, r.records...)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func newTestServerCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
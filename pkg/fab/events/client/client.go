@@ -99,7 +99,7 @@ func (c *Client) Connect() error {
 	if c.maxConnAttempts == 1 {
 		return c.connect()
 	}
-	return c.connectWithRetry(c.maxConnAttempts, c.timeBetweenConnAttempts)
+	return c.connectWithRetry(c.maxConnAttempts, c.timeBetweenConnAttempts, 1, 0)
 }
 
 // CloseIfIdle closes the connection to the event server only if there are no outstanding
@@ -232,7 +232,7 @@ func (c *Client) connect() error {
 	return err
 }
 
-func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Duration) error {
+func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Duration, backoffFactor float64, maxDelay time.Duration) error {
 	if c.Stopped() {
 		return errors.New("event client is closed")
 	}
@@ -251,6 +251,7 @@ func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Dur
 				return errors.New("maximum connect attempts exceeded")
 			}
 			time.Sleep(timeBetweenAttempts)
+			timeBetweenAttempts = backoff(timeBetweenAttempts, backoffFactor, maxDelay)
 		} else {
 			logger.Debugf("... connect succeeded.")
 			return nil
@@ -258,15 +259,63 @@ func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Dur
 	}
 }
 
+// ErrBlockEventsNotPermitted is returned when registering for block events with a client which
+// wasn't created WithBlockEvents
+var ErrBlockEventsNotPermitted = errors.New("block events are not permitted")
+
 // RegisterBlockEvent registers for block events. If the client is not authorized to receive
 // block events then an error is returned.
 func (c *Client) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
 	if !c.permitBlockEvents {
-		return nil, nil, errors.New("block events are not permitted")
+		return nil, nil, ErrBlockEventsNotPermitted
 	}
 	return c.Service.RegisterBlockEvent(filter...)
 }
 
+// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+// touching one of the chaincode namespaces (see fab.NamespaceEventService). If the client is not authorized to
+// receive block events then an error is returned.
+func (c *Client) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	if !c.permitBlockEvents {
+		return nil, nil, ErrBlockEventsNotPermitted
+	}
+	return c.Service.RegisterNamespaceBlockEvent(namespaces, trim, filter...)
+}
+
+// Buffered returns a view of the client whose registrations have their own event buffer and overflow policy
+// (see fab.BufferedEventService)
+func (c *Client) Buffered(opts fab.BufferOpts) fab.EventService {
+	return &bufferedClient{
+		EventService:      c.Service.Buffered(opts),
+		permitBlockEvents: c.permitBlockEvents,
+	}
+}
+
+// bufferedClient is a buffered view of the client which checks that block events are permitted
+type bufferedClient struct {
+	fab.EventService
+	permitBlockEvents bool
+}
+
+// RegisterBlockEvent registers for block events. If the client is not authorized to receive
+// block events then an error is returned.
+func (c *bufferedClient) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	if !c.permitBlockEvents {
+		return nil, nil, ErrBlockEventsNotPermitted
+	}
+	return c.EventService.RegisterBlockEvent(filter...)
+}
+
+// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+// touching one of the chaincode namespaces. If the client is not authorized to receive block events then an
+// error is returned.
+func (c *bufferedClient) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	if !c.permitBlockEvents {
+		return nil, nil, ErrBlockEventsNotPermitted
+	}
+	return c.EventService.(fab.NamespaceEventService).RegisterNamespaceBlockEvent(namespaces, trim, filter...)
+}
+
 // registerConnectionEvent registers a connection event. The returned
 // ConnectionEvent channel will be called whenever the client clients or disconnects
 // from the event server
@@ -303,6 +352,12 @@ func (c *Client) ConnectionState() ConnectionState {
 	return ConnectionState(atomic.LoadInt32(&c.connectionState))
 }
 
+// Connected returns true if the client is connected to the event server, i.e. it isn't disconnected,
+// (re)connecting or stopped
+func (c *Client) Connected() bool {
+	return !c.Stopped() && c.ConnectionState() == Connected
+}
+
 // setConnectionState sets the connection state only if the given currentState
 // matches the actual state. True is returned if the connection state was successfully set.
 func (c *Client) setConnectionState(currentState, newState ConnectionState) bool {
@@ -362,12 +417,24 @@ func (c *Client) reconnect() {
 		}
 	}
 
-	if err := c.connectWithRetry(c.maxReconnAttempts, c.timeBetweenConnAttempts); err != nil {
+	if err := c.connectWithRetry(c.maxReconnAttempts, c.timeBetweenConnAttempts, c.reconnBackoffFactor, c.reconnMaxDelay); err != nil {
 		logger.Warnf("Could not reconnect event client: %s. Closing.", err)
 		c.Close()
 	}
 }
 
+// backoff returns the time until the next connection attempt, i.e. the given time multiplied by
+// the backoff factor and limited to the max delay (if any)
+func backoff(timeBetweenAttempts time.Duration, factor float64, maxDelay time.Duration) time.Duration {
+	if factor > 1 {
+		timeBetweenAttempts = time.Duration(float64(timeBetweenAttempts) * factor)
+	}
+	if maxDelay > 0 && timeBetweenAttempts > maxDelay {
+		return maxDelay
+	}
+	return timeBetweenAttempts
+}
+
 func (c *Client) closeConnectEventChan() {
 	c.Lock()
 	defer c.Unlock()
@@ -64,6 +64,9 @@ func TestConnect(t *testing.T) {
 	if eventClient.ConnectionState() != Disconnected {
 		t.Fatalf("expecting connection state %s but got %s", Disconnected, eventClient.ConnectionState())
 	}
+	if eventClient.Connected() {
+		t.Fatalf("expecting client not to report connected before it connects")
+	}
 	if err := eventClient.Connect(); err != nil {
 		t.Fatalf("error connecting: %s", err)
 	}
@@ -76,10 +79,16 @@ func TestConnect(t *testing.T) {
 	if eventClient.ConnectionState() != Connected {
 		t.Fatalf("expecting connection state %s but got %s", Connected, eventClient.ConnectionState())
 	}
+	if !eventClient.Connected() {
+		t.Fatalf("expecting client to report connected")
+	}
 	eventClient.Close()
 	if eventClient.ConnectionState() != Disconnected {
 		t.Fatalf("expecting connection state %s but got %s", Disconnected, eventClient.ConnectionState())
 	}
+	if eventClient.Connected() {
+		t.Fatalf("expecting client not to report connected once closed")
+	}
 	time.Sleep(2 * time.Second)
 }
 
@@ -708,6 +717,30 @@ func TestReconnectRegistration(t *testing.T) {
 	})
 }
 
+// TestReconnectBackoff tests that the time between reconnection attempts grows
+// by the backoff factor up to the max delay
+func TestReconnectBackoff(t *testing.T) {
+	params := defaultParams()
+	options.Apply(params, []options.Opt{WithReconnectBackoff(2, 5*time.Second)})
+
+	delay := time.Second
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delay = backoff(delay, params.reconnBackoffFactor, params.reconnMaxDelay)
+		delays = append(delays, delay)
+	}
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range delays {
+		if d != expected[i] {
+			t.Fatalf("expecting delay %s after attempt %d but got %s", expected[i], i+1, d)
+		}
+	}
+
+	if d := backoff(time.Second, defaultParams().reconnBackoffFactor, 0); d != time.Second {
+		t.Fatalf("expecting constant time between attempts by default but got %s", d)
+	}
+}
+
 // TestConcurrentEvents ensures that the channel event client is thread-safe
 func TestConcurrentEvents(t *testing.T) {
 	numEvents := 1000
@@ -111,6 +111,14 @@ func (ed *Dispatcher) HandleConnectEvent(e esdispatcher.Event) {
 		return
 	}
 
+	if ed.peerMSPID != "" {
+		peers = peersOfMSP(peers, ed.peerMSPID)
+		if len(peers) == 0 {
+			evt.ErrCh <- errors.Errorf("no peers of MSP [%s] to connect to", ed.peerMSPID)
+			return
+		}
+	}
+
 	if len(peers) == 0 {
 		evt.ErrCh <- errors.New("no peers to connect to")
 		return
@@ -136,6 +144,17 @@ func (ed *Dispatcher) HandleConnectEvent(e esdispatcher.Event) {
 	evt.ErrCh <- nil
 }
 
+//peersOfMSP returns the peers which belong to the given MSP
+func peersOfMSP(peers []fab.Peer, mspID string) []fab.Peer {
+	var filtered []fab.Peer
+	for _, peer := range peers {
+		if peer.MSPID() == mspID {
+			filtered = append(filtered, peer)
+		}
+	}
+	return filtered
+}
+
 // HandleDisconnectEvent disconnects from the event server
 func (ed *Dispatcher) HandleDisconnectEvent(e esdispatcher.Event) {
 	evt := e.(*DisconnectEvent)
@@ -150,6 +150,58 @@ func TestConnectNoPeers(t *testing.T) {
 	}
 }
 
+func TestConnectPeerMSPID(t *testing.T) {
+	org2Peer := fabmocks.NewMockPeer("peer3", "grpcs://peer3.example.com:7051")
+	org2Peer.SetMSPID("Org2MSP")
+
+	newDispatcher := func(peers ...fab.Peer) *Dispatcher {
+		dispatcher := New(
+			fabmocks.NewMockContextWithCustomDiscovery(
+				mspmocks.NewMockSigningIdentity("user1", "Org1MSP"),
+				clientmocks.NewDiscoveryProvider(peers...),
+			),
+			fabmocks.NewMockChannelCfg("testchannel"),
+			clientmocks.NewProviderFactory().Provider(
+				clientmocks.NewMockConnection(
+					clientmocks.WithLedger(
+						servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory, sourceURL),
+					),
+				),
+			),
+			WithPeerMSPID("Org2MSP"),
+		)
+		if err := dispatcher.Start(); err != nil {
+			t.Fatalf("Error starting dispatcher: %s", err)
+		}
+		return dispatcher
+	}
+
+	connect := func(dispatcher *Dispatcher) error {
+		dispatcherEventch, err := dispatcher.EventCh()
+		if err != nil {
+			t.Fatalf("Error getting event channel from dispatcher: %s", err)
+		}
+		errch := make(chan error)
+		dispatcherEventch <- NewConnectEvent(errch)
+		err = <-errch
+
+		stopResp := make(chan error)
+		dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+		if err := <-stopResp; err != nil {
+			t.Fatalf("Error stopping dispatcher: %s", err)
+		}
+		return err
+	}
+
+	if err := connect(newDispatcher(peer1, peer2)); err == nil {
+		t.Fatalf("Expecting error connecting with no peers of the MSP but got none")
+	}
+
+	if err := connect(newDispatcher(peer1, org2Peer)); err != nil {
+		t.Fatalf("Error connecting to the peer of the MSP: %s", err)
+	}
+}
+
 func TestConnectionEvent(t *testing.T) {
 	channelID := "testchannel"
 
@@ -13,6 +13,7 @@ import (
 
 type params struct {
 	loadBalancePolicy lbp.LoadBalancePolicy
+	peerMSPID         string
 }
 
 func defaultParams() *params {
@@ -39,3 +40,22 @@ func (p *params) SetLoadBalancePolicy(value lbp.LoadBalancePolicy) {
 	logger.Debugf("LoadBalancePolicy: %#v", value)
 	p.loadBalancePolicy = value
 }
+
+// WithPeerMSPID restricts the event endpoints to the peers of the given MSP,
+// e.g. so that the events of a channel are only received from the peers of one organization
+func WithPeerMSPID(mspID string) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(peerMSPIDSetter); ok {
+			setter.SetPeerMSPID(mspID)
+		}
+	}
+}
+
+type peerMSPIDSetter interface {
+	SetPeerMSPID(mspID string)
+}
+
+func (p *params) SetPeerMSPID(mspID string) {
+	logger.Debugf("PeerMSPID: %s", mspID)
+	p.peerMSPID = mspID
+}
@@ -20,6 +20,8 @@ type params struct {
 	maxReconnAttempts       uint
 	reconnInitialDelay      time.Duration
 	timeBetweenConnAttempts time.Duration
+	reconnBackoffFactor     float64
+	reconnMaxDelay          time.Duration
 	connEventCh             chan *dispatcher.ConnectionEvent
 	respTimeout             time.Duration
 	permitBlockEvents       bool
@@ -33,6 +35,7 @@ func defaultParams() *params {
 		maxReconnAttempts:       0, // Try forever
 		reconnInitialDelay:      0,
 		timeBetweenConnAttempts: 5 * time.Second,
+		reconnBackoffFactor:     1,
 		respTimeout:             5 * time.Second,
 	}
 }
@@ -106,6 +109,16 @@ func WithTimeBetweenConnectAttempts(value time.Duration) options.Opt {
 	}
 }
 
+// WithReconnectBackoff sets the factor by which the time between reconnection attempts is multiplied after
+// each failed attempt, up to the given maximum. If the maximum is 0 then the time between attempts isn't limited.
+func WithReconnectBackoff(factor float64, maxDelay time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(reconnectBackoffSetter); ok {
+			setter.SetReconnectBackoff(factor, maxDelay)
+		}
+	}
+}
+
 // WithResponseTimeout sets the timeout when waiting for a response from the event server
 func WithResponseTimeout(value time.Duration) options.Opt {
 	return func(p options.Params) {
@@ -144,6 +157,12 @@ func (p *params) SetTimeBetweenConnectAttempts(value time.Duration) {
 	p.timeBetweenConnAttempts = value
 }
 
+func (p *params) SetReconnectBackoff(factor float64, maxDelay time.Duration) {
+	logger.Debugf("ReconnectBackoff: factor %f, max delay %s", factor, maxDelay)
+	p.reconnBackoffFactor = factor
+	p.reconnMaxDelay = maxDelay
+}
+
 func (p *params) SetConnectEventCh(value chan *dispatcher.ConnectionEvent) {
 	logger.Debugf("ConnectEventCh: %#v", value)
 	p.connEventCh = value
@@ -183,6 +202,10 @@ type timeBetweenConnectAttemptsSetter interface {
 	SetTimeBetweenConnectAttempts(value time.Duration)
 }
 
+type reconnectBackoffSetter interface {
+	SetReconnectBackoff(factor float64, maxDelay time.Duration)
+}
+
 type responseTimeoutSetter interface {
 	SetResponseTimeout(value time.Duration)
 }
@@ -106,15 +106,16 @@ func (c *Client) setSeekFromLastBlockReceived() error {
 	c.Lock()
 	defer c.Unlock()
 
-	// Make sure that, when we reconnect, we receive all of the events that we've missed
+	// Make sure that, when we reconnect (possibly to another peer), we receive all of the events that we've missed.
+	// Blocks which are received again are ignored by the dispatcher.
 	lastBlockNum := c.Dispatcher().LastBlockNum()
 	if lastBlockNum < math.MaxUint64 {
 		c.seekType = seek.FromBlock
-		c.fromBlock = c.Dispatcher().LastBlockNum() + 1
-	} else {
-		// We haven't received any blocks yet. Just ask for the newest
-		c.seekType = seek.Newest
+		c.fromBlock = lastBlockNum + 1
+		logger.Debugf("Reconnecting from block %d", c.fromBlock)
 	}
+	// Otherwise we haven't received any blocks yet, so the original seek still applies. (Asking for the
+	// newest block would skip the blocks requested by a seek from the oldest or from a given block.)
 	return nil
 }
 
@@ -128,6 +129,9 @@ func (c *Client) seekInfo() (*ab.SeekInfo, error) {
 	case seek.Oldest:
 		return seek.InfoOldest(), nil
 	case seek.FromBlock:
+		if c.bounded {
+			return seek.InfoRange(c.fromBlock, c.toBlock, c.failNotReady), nil
+		}
 		return seek.InfoFrom(c.fromBlock), nil
 	default:
 		return nil, errors.Errorf("unsupported seek type:[%s]", c.seekType)
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
@@ -462,3 +463,87 @@ func newMockContext() *fabmocks.MockContext {
 	ctx.SetEndpointConfig(newMockConfig())
 	return ctx
 }
+
+func TestSeekInfoRange(t *testing.T) {
+	params := defaultParams()
+	options.Apply(params, []options.Opt{WithSeekType(seek.FromBlock), WithBlockNum(5), WithStopBlockNum(10)})
+	c := &Client{params: *params}
+
+	seekInfo, err := c.seekInfo()
+	if err != nil {
+		t.Fatalf("error getting seek info: %s", err)
+	}
+	if seekInfo.Start.GetSpecified().Number != 5 || seekInfo.Stop.GetSpecified().Number != 10 {
+		t.Fatalf("expecting seek from block 5 to block 10 but got %s", seekInfo)
+	}
+	if seekInfo.Behavior != ab.SeekInfo_BLOCK_UNTIL_READY {
+		t.Fatalf("expecting seek to wait for blocks which aren't ready but got %s", seekInfo.Behavior)
+	}
+
+	options.Apply(params, []options.Opt{WithFailIfNotReady()})
+	c = &Client{params: *params}
+	seekInfo, err = c.seekInfo()
+	if err != nil {
+		t.Fatalf("error getting seek info: %s", err)
+	}
+	if seekInfo.Behavior != ab.SeekInfo_FAIL_IF_NOT_READY {
+		t.Fatalf("expecting seek to fail for blocks which aren't ready but got %s", seekInfo.Behavior)
+	}
+}
+
+func TestSeekFromLastBlockReceived(t *testing.T) {
+	channelID := "mychannel"
+	eventClient, err := New(
+		newMockContext(),
+		fabmocks.NewMockChannelCfg(channelID),
+		withConnectionProvider(
+			clientmocks.NewProviderFactory().Provider(
+				delivermocks.NewConnection(
+					clientmocks.WithLedger(servicemocks.NewMockLedger(delivermocks.BlockEventFactory, sourceURL)),
+				),
+			),
+		),
+		WithSeekType(seek.FromBlock),
+		WithBlockNum(5),
+	)
+	if err != nil {
+		t.Fatalf("error creating deliver client: %s", err)
+	}
+	defer eventClient.Close()
+
+	// No blocks were received so the original seek still applies
+	if err := eventClient.setSeekFromLastBlockReceived(); err != nil {
+		t.Fatalf("error setting seek: %s", err)
+	}
+	seekInfo, err := eventClient.seekInfo()
+	if err != nil {
+		t.Fatalf("error getting seek info: %s", err)
+	}
+	if seekInfo.Start.GetSpecified().GetNumber() != 5 {
+		t.Fatalf("expecting seek from block 5 but got %s", seekInfo)
+	}
+
+	block := servicemocks.NewBlock(channelID)
+	block.Header.Number = 7
+	if err := eventClient.Submit(esdispatcher.NewBlockEvent(block, sourceURL)); err != nil {
+		t.Fatalf("error submitting block event: %s", err)
+	}
+	for i := 0; eventClient.Dispatcher().LastBlockNum() != 7; i++ {
+		if i == 100 {
+			t.Fatalf("timed out waiting for block to be received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The reconnection resumes from the block after the last block received
+	if err := eventClient.setSeekFromLastBlockReceived(); err != nil {
+		t.Fatalf("error setting seek: %s", err)
+	}
+	seekInfo, err = eventClient.seekInfo()
+	if err != nil {
+		t.Fatalf("error getting seek info: %s", err)
+	}
+	if seekInfo.Start.GetSpecified().GetNumber() != 8 {
+		t.Fatalf("expecting seek from block 8 but got %s", seekInfo)
+	}
+}
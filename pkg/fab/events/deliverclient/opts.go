@@ -18,6 +18,9 @@ type params struct {
 	connProvider api.ConnectionProvider
 	seekType     seek.Type
 	fromBlock    uint64
+	toBlock      uint64
+	bounded      bool
+	failNotReady bool
 	respTimeout  time.Duration
 }
 
@@ -48,6 +51,28 @@ func WithBlockNum(value uint64) options.Opt {
 	}
 }
 
+// WithStopBlockNum specifies the number of the last block to be received, so that the blocks of a range are
+// received rather than all the blocks as they're committed.
+// Note that this option is only valid if SeekType is set to SeekFrom.
+func WithStopBlockNum(value uint64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(stopBlockSetter); ok {
+			setter.SetStopBlock(value)
+		}
+	}
+}
+
+// WithFailIfNotReady indicates that the deliver server should fail the request when it reaches a block which
+// isn't committed yet, rather than wait for the block.
+// Note that this option is only valid if the stop block is set (see WithStopBlockNum).
+func WithFailIfNotReady() options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(failIfNotReadySetter); ok {
+			setter.SetFailIfNotReady()
+		}
+	}
+}
+
 // withConnectionProvider is used only for testing
 func withConnectionProvider(connProvider api.ConnectionProvider) options.Opt {
 	return func(p options.Params) {
@@ -70,6 +95,14 @@ type fromBlockSetter interface {
 	SetFromBlock(value uint64)
 }
 
+type stopBlockSetter interface {
+	SetStopBlock(value uint64)
+}
+
+type failIfNotReadySetter interface {
+	SetFailIfNotReady()
+}
+
 func (p *params) PermitBlockEvents() {
 	logger.Debugf("PermitBlockEvents")
 	p.connProvider = deliverProvider
@@ -86,6 +119,17 @@ func (p *params) SetFromBlock(value uint64) {
 	p.fromBlock = value
 }
 
+func (p *params) SetStopBlock(value uint64) {
+	logger.Debugf("StopBlock: %d", value)
+	p.toBlock = value
+	p.bounded = true
+}
+
+func (p *params) SetFailIfNotReady() {
+	logger.Debugf("FailIfNotReady")
+	p.failNotReady = true
+}
+
 func (p *params) SetSeekType(value seek.Type) {
 	logger.Debugf("SeekType: %s", value)
 	p.seekType = value
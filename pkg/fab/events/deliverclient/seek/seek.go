@@ -48,6 +48,17 @@ func InfoFrom(fromBlock uint64) *ab.SeekInfo {
 	return newSeekInfo(seekFromPos(fromBlock), maxPos)
 }
 
+// InfoRange returns a SeekInfo struct that indicates to the deliver server that we want the blocks from
+// the given block number up to and including the given stop block number. Unless failIfNotReady is set,
+// the server waits for the blocks which aren't committed yet; otherwise it fails when it reaches such a block.
+func InfoRange(fromBlock, toBlock uint64, failIfNotReady bool) *ab.SeekInfo {
+	seekInfo := newSeekInfo(seekFromPos(fromBlock), seekFromPos(toBlock))
+	if failIfNotReady {
+		seekInfo.Behavior = ab.SeekInfo_FAIL_IF_NOT_READY
+	}
+	return seekInfo
+}
+
 func seekFromPos(fromBlock uint64) *ab.SeekPosition {
 	return &ab.SeekPosition{
 		Type: &ab.SeekPosition_Specified{
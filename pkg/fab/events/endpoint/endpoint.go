@@ -13,8 +13,10 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	configcomm "github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/spf13/cast"
 	"google.golang.org/grpc/keepalive"
 )
@@ -31,6 +33,7 @@ type EventEndpoint struct {
 	FailFast        bool
 	ConnectTimeout  time.Duration
 	AllowInsecure   bool
+	ServiceConfig   *configcomm.ServiceConfig
 }
 
 // EventURL returns the event URL
@@ -50,6 +53,9 @@ func (e *EventEndpoint) Opts() []options.Opt {
 	if e.AllowInsecure {
 		opts = append(opts, comm.WithInsecure())
 	}
+	if e.ServiceConfig != nil {
+		opts = append(opts, comm.WithServiceConfig(e.ServiceConfig))
+	}
 	return opts
 }
 
@@ -64,6 +70,11 @@ func FromPeerConfig(config fab.EndpointConfig, peer fab.Peer, peerCfg *fab.PeerC
 		}
 	}
 
+	serviceConfig, err := configcomm.ServiceConfigFromGRPCOptions(peerCfg.GRPCOptions)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid service config for peer "+peerCfg.URL)
+	}
+
 	return &EventEndpoint{
 		Peer:            peer,
 		EvtURL:          peerCfg.EventURL,
@@ -73,6 +84,7 @@ func FromPeerConfig(config fab.EndpointConfig, peer fab.Peer, peerCfg *fab.PeerC
 		FailFast:        getFailFast(peerCfg),
 		ConnectTimeout:  config.TimeoutOrDefault(fab.EventHubConnection),
 		AllowInsecure:   isInsecureAllowed(peerCfg),
+		ServiceConfig:   serviceConfig,
 	}, nil
 }
 
@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// bufferedService is a view of the event service whose registrations have their own event buffer and overflow policy
+type bufferedService struct {
+	service *Service
+	opts    fab.BufferOpts
+}
+
+// RegisterBlockEvent registers for block events
+func (b *bufferedService) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	return b.service.registerBlockEvent(&b.opts, nil, false, filter)
+}
+
+// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+// touching one of the chaincode namespaces
+func (b *bufferedService) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	return b.service.registerNamespaceBlockEvent(&b.opts, namespaces, trim, filter)
+}
+
+// RegisterFilteredBlockEvent registers for filtered block events
+func (b *bufferedService) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	return b.service.registerFilteredBlockEvent(&b.opts)
+}
+
+// RegisterChaincodeEvent registers for chaincode events
+func (b *bufferedService) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	return b.service.registerChaincodeEvent(&b.opts, ccID, eventFilter)
+}
+
+// RegisterTxStatusEvent registers for transaction status events
+func (b *bufferedService) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	return b.service.registerTxStatusEvent(&b.opts, txID)
+}
+
+// Unregister unregisters the given registration
+func (b *bufferedService) Unregister(reg fab.Registration) {
+	b.service.Unregister(reg)
+}
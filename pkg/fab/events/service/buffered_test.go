@@ -0,0 +1,237 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
+)
+
+const (
+	numStressBlocks  = 1000
+	stressBufferSize = 10
+)
+
+// TestNonBlockingOverflowPolicies registers consumers which never receive their events with each of the non-blocking
+// overflow policies, alongside a consumer which keeps up, and checks that the events which don't fit are reported
+func TestNonBlockingOverflowPolicies(t *testing.T) {
+	channelID := "mychannel"
+	// The dispatcher waits for the consumers of the registrations without a policy
+	eventService, eventProducer, err := newServiceWithMockProducer([]options.Opt{dispatcher.WithEventConsumerTimeout(0)}, withBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	reg, eventch, err := eventService.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	defer eventService.Unregister(reg)
+
+	dropNewest := newStalledConsumer(t, eventService, fab.OverflowDropNewest)
+	defer eventService.Unregister(dropNewest.reg)
+	dropOldest := newStalledConsumer(t, eventService, fab.OverflowDropOldest)
+	defer eventService.Unregister(dropOldest.reg)
+
+	go func() {
+		for i := 0; i < numStressBlocks; i++ {
+			eventProducer.Ledger().NewBlock(channelID)
+		}
+	}()
+
+	// The stalled consumers don't hold up the consumer which keeps up
+	for i := 0; i < numStressBlocks; i++ {
+		select {
+		case event := <-eventch:
+			assert.Equal(t, uint64(i), event.Block.Header.Number)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block event #%d", i)
+		}
+	}
+
+	// Wait for the dispatcher to complete the last block event, which it handles before the registration
+	barrier, _, err := eventService.RegisterTxStatusEvent("txid")
+	if err != nil {
+		t.Fatalf("error registering for Tx Status events: %s", err)
+	}
+	eventService.Unregister(barrier)
+
+	received, dropped := dropNewest.blocks()
+	assert.Equal(t, blockNumbers(0, stressBufferSize), received, "expected the first events to be kept")
+	assert.Equal(t, blockNumbers(stressBufferSize, numStressBlocks), dropped, "expected the newest events to be dropped")
+
+	received, dropped = dropOldest.blocks()
+	assert.Equal(t, blockNumbers(numStressBlocks-stressBufferSize, numStressBlocks), received, "expected the last events to be kept")
+	assert.Equal(t, blockNumbers(0, numStressBlocks-stressBufferSize), dropped, "expected the oldest events to be dropped")
+}
+
+// TestDefaultOverflowPolicy checks that the events dropped once the event consumer timeout expires are reported
+func TestDefaultOverflowPolicy(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer([]options.Opt{dispatcher.WithEventConsumerTimeout(-1)}, withBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	consumer := newStalledConsumer(t, eventService, fab.OverflowDefault)
+	defer eventService.Unregister(consumer.reg)
+
+	for i := 0; i < 2*stressBufferSize; i++ {
+		eventProducer.Ledger().NewBlock(channelID)
+	}
+
+	assert.Nil(t, waitFor(func() bool {
+		_, dropped := consumer.dropped()
+		return dropped == stressBufferSize
+	}), "expected the events which don't fit to be reported")
+	received, dropped := consumer.blocks()
+	assert.Equal(t, blockNumbers(0, stressBufferSize), received)
+	assert.Equal(t, blockNumbers(stressBufferSize, 2*stressBufferSize), dropped)
+}
+
+// TestBlockOverflowPolicy checks that no event is dropped for a slow consumer with the blocking policy, even
+// though the dispatcher would drop events for the consumer without a policy
+func TestBlockOverflowPolicy(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer([]options.Opt{dispatcher.WithEventConsumerTimeout(-1)}, withBlockLedger(sourceURL))
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	numBlocks := 10 * stressBufferSize
+	var dropped int32
+	reg, eventch, err := eventService.Buffered(fab.BufferOpts{
+		Size:           stressBufferSize,
+		OverflowPolicy: fab.OverflowBlock,
+		OnOverflow:     func(event interface{}) { atomic.AddInt32(&dropped, 1) },
+	}).RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	defer eventService.Unregister(reg)
+
+	go func() {
+		for i := 0; i < numBlocks; i++ {
+			eventProducer.Ledger().NewBlock(channelID)
+		}
+	}()
+
+	for i := 0; i < numBlocks; i++ {
+		select {
+		case event := <-eventch:
+			assert.Equal(t, uint64(i), event.Block.Header.Number)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block event #%d", i)
+		}
+		// A slow consumer
+		time.Sleep(time.Millisecond)
+	}
+	assert.Zero(t, atomic.LoadInt32(&dropped), "expected no dropped event")
+}
+
+func TestBufferSize(t *testing.T) {
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	reg, eventch, err := eventService.Buffered(fab.BufferOpts{Size: 3}).RegisterChaincodeEvent("mycc", ".*")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	defer eventService.Unregister(reg)
+	assert.Equal(t, 3, cap(eventch))
+
+	reg, fbeventch, err := eventService.Buffered(fab.BufferOpts{}).RegisterFilteredBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+	defer eventService.Unregister(reg)
+	assert.Equal(t, int(eventService.eventConsumerBufferSize), cap(fbeventch), "expected the buffer size of the event service")
+
+	_, _, err = eventService.Buffered(fab.BufferOpts{}).(fab.NamespaceEventService).RegisterNamespaceBlockEvent(nil, false)
+	assert.Error(t, err, "expected error for no namespaces")
+}
+
+// stalledConsumer is the consumer of a buffered registration which doesn't receive its events
+type stalledConsumer struct {
+	reg     fab.Registration
+	eventch <-chan *fab.BlockEvent
+	lock    sync.Mutex
+	drops   []uint64
+}
+
+func newStalledConsumer(t *testing.T, eventService *Service, policy fab.OverflowPolicy) *stalledConsumer {
+	c := &stalledConsumer{}
+	reg, eventch, err := eventService.Buffered(fab.BufferOpts{
+		Size:           stressBufferSize,
+		OverflowPolicy: policy,
+		OnOverflow: func(event interface{}) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.drops = append(c.drops, event.(*fab.BlockEvent).Block.Header.Number)
+		},
+	}).RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	c.reg = reg
+	c.eventch = eventch
+	return c
+}
+
+func (c *stalledConsumer) dropped() ([]uint64, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]uint64{}, c.drops...), len(c.drops)
+}
+
+// blocks returns the numbers of the blocks in the event channel and of the blocks which were dropped
+func (c *stalledConsumer) blocks() ([]uint64, []uint64) {
+	var received []uint64
+	for len(c.eventch) > 0 {
+		received = append(received, (<-c.eventch).Block.Header.Number)
+	}
+	dropped, _ := c.dropped()
+	return received, dropped
+}
+
+func blockNumbers(from, to int) []uint64 {
+	var numbers []uint64
+	for i := from; i < to; i++ {
+		numbers = append(numbers, uint64(i))
+	}
+	return numbers
+}
+
+func waitFor(cond func() bool) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
@@ -10,17 +10,14 @@ import (
 	"math"
 	"reflect"
 	"regexp"
+	"strings"
 	"sync/atomic"
-	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	ledgerutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/util"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
-	utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 	"github.com/pkg/errors"
 )
 
@@ -136,17 +133,15 @@ func (ed *Dispatcher) LastBlockNum() uint64 {
 	return atomic.LoadUint64(&ed.lastBlockNum)
 }
 
-// updateLastBlockNum updates the value of lastBlockNum and
-// returns the updated value.
+// updateLastBlockNum updates the value of lastBlockNum. An error is returned if
+// the block isn't newer than the last block received.
 func (ed *Dispatcher) updateLastBlockNum(blockNum uint64) error {
-	// The Deliver Service shouldn't be sending blocks out of order.
-	// Log an error if we detect this happening.
 	lastBlockNum := atomic.LoadUint64(&ed.lastBlockNum)
 	if lastBlockNum == math.MaxUint64 || blockNum > lastBlockNum {
 		atomic.StoreUint64(&ed.lastBlockNum, blockNum)
 		return nil
 	}
-	return errors.Errorf("Expecting a block number greater than %d but received block number %d", lastBlockNum, lastBlockNum)
+	return errors.Errorf("Expecting a block number greater than %d but received block number %d", lastBlockNum, blockNum)
 }
 
 // clearBlockRegistrations removes all block registrations and closes the corresponding event channels.
@@ -302,12 +297,23 @@ func (ed *Dispatcher) HandleBlock(block *cb.Block, sourceURL string) {
 	logger.Debugf("Handling block event - Block #%d", block.Header.Number)
 
 	if err := ed.updateLastBlockNum(block.Header.Number); err != nil {
-		logger.Error(err.Error())
+		// After a reconnect, blocks which were already received may be sent again (e.g. blocks which were
+		// in flight on the previous connection). They're ignored so that their events aren't published twice.
+		logger.Debugf("Ignoring block: %s", err)
 		return
 	}
 
-	ed.publishBlockEvents(block, sourceURL)
-	ed.publishFilteredBlockEvents(toFilteredBlock(block), sourceURL)
+	parsed := parseBlock(block)
+	ed.publishBlockEvents(parsed, sourceURL)
+	ed.publishFilteredBlockEvents(parsed.filtered, sourceURL)
+
+	// The transactions are published with their index within the block, which differs from their index
+	// within the filtered block if a transaction of the block couldn't be parsed
+	for i, tx := range parsed.txs {
+		if tx != nil {
+			ed.publishTxEvents(tx.filtered, block.Header.Number, i, sourceURL)
+		}
+	}
 }
 
 // HandleFilteredBlock handles a filtered block event
@@ -315,12 +321,17 @@ func (ed *Dispatcher) HandleFilteredBlock(fblock *pb.FilteredBlock, sourceURL st
 	logger.Debugf("Handling filtered block event - Block #%d", fblock.Number)
 
 	if err := ed.updateLastBlockNum(fblock.Number); err != nil {
-		logger.Error(err.Error())
+		logger.Debugf("Ignoring filtered block: %s", err)
 		return
 	}
 
 	logger.Debugf("Publishing filtered block event...")
 	ed.publishFilteredBlockEvents(fblock, sourceURL)
+
+	// A filtered block contains all the transactions of the block
+	for i, tx := range fblock.FilteredTransactions {
+		ed.publishTxEvents(tx, fblock.Number, i, sourceURL)
+	}
 }
 
 func (ed *Dispatcher) unregisterBlockEvents(registration *BlockReg) error {
@@ -374,28 +385,22 @@ func (ed *Dispatcher) unregisterTXEvents(registration *TxStatusReg) error {
 	return nil
 }
 
-func (ed *Dispatcher) publishBlockEvents(block *cb.Block, sourceURL string) {
+func (ed *Dispatcher) publishBlockEvents(parsed *parsedBlock, sourceURL string) {
 	for _, reg := range ed.blockRegistrations {
+		block := parsed.block
+		if len(reg.Namespaces) > 0 {
+			if block = parsed.forNamespaces(reg); block == nil {
+				logger.Debugf("Not sending block event for block #%d since none of its transactions touches the namespaces of the registration.", parsed.block.Header.Number)
+				continue
+			}
+		}
+
 		if !reg.Filter(block) {
 			logger.Debugf("Not sending block event for block #%d since it was filtered out.", block.Header.Number)
 			continue
 		}
 
-		if ed.eventConsumerTimeout < 0 {
-			select {
-			case reg.Eventch <- NewBlockEvent(block, sourceURL):
-			default:
-				logger.Warnf("Unable to send to block event channel.")
-			}
-		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- NewBlockEvent(block, sourceURL)
-		} else {
-			select {
-			case reg.Eventch <- NewBlockEvent(block, sourceURL):
-			case <-time.After(ed.eventConsumerTimeout):
-				logger.Warnf("Timed out sending block event.")
-			}
-		}
+		ed.send(reg, reg.Overflow, NewBlockEvent(block, sourceURL))
 	}
 }
 
@@ -408,37 +413,26 @@ func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock, sourc
 	logger.Debugf("Publishing filtered block event: %#v", fblock)
 
 	for _, reg := range ed.filteredBlockRegistrations {
-		if ed.eventConsumerTimeout < 0 {
-			select {
-			case reg.Eventch <- NewFilteredBlockEvent(fblock, sourceURL):
-			default:
-				logger.Warnf("Unable to send to filtered block event channel.")
-			}
-		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- NewFilteredBlockEvent(fblock, sourceURL)
-		} else {
-			select {
-			case reg.Eventch <- NewFilteredBlockEvent(fblock, sourceURL):
-			case <-time.After(ed.eventConsumerTimeout):
-				logger.Warnf("Timed out sending filtered block event.")
-			}
-		}
+		ed.send(reg, reg.Overflow, NewFilteredBlockEvent(fblock, sourceURL))
 	}
+}
 
-	for _, tx := range fblock.FilteredTransactions {
-		ed.publishTxStatusEvents(tx, fblock.Number, sourceURL)
+// publishTxEvents publishes the transaction status and chaincode events of the transaction at index txIndex
+// of the block
+func (ed *Dispatcher) publishTxEvents(tx *pb.FilteredTransaction, blockNum uint64, txIndex int, sourceURL string) {
+	ed.publishTxStatusEvents(tx, blockNum, sourceURL)
 
-		// Only send a chaincode event if the transaction has committed
-		if tx.TxValidationCode == pb.TxValidationCode_VALID {
-			txActions := tx.GetTransactionActions()
-			if txActions == nil {
-				continue
-			}
-			for _, action := range txActions.ChaincodeActions {
-				if action.ChaincodeEvent != nil {
-					ed.publishCCEvents(action.ChaincodeEvent, fblock.Number, sourceURL)
-				}
-			}
+	// Only send a chaincode event if the transaction has committed
+	if tx.TxValidationCode != pb.TxValidationCode_VALID {
+		return
+	}
+	txActions := tx.GetTransactionActions()
+	if txActions == nil {
+		return
+	}
+	for _, action := range txActions.ChaincodeActions {
+		if action.ChaincodeEvent != nil {
+			ed.publishCCEvents(action.ChaincodeEvent, blockNum, txIndex, sourceURL)
 		}
 	}
 }
@@ -447,50 +441,64 @@ func (ed *Dispatcher) publishTxStatusEvents(tx *pb.FilteredTransaction, blockNum
 	logger.Debugf("Publishing Tx Status event for TxID [%s]...", tx.Txid)
 	if reg, ok := ed.txRegistrations[tx.Txid]; ok {
 		logger.Debugf("Sending Tx Status event for TxID [%s] to registrant...", tx.Txid)
-
-		if ed.eventConsumerTimeout < 0 {
-			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode, blockNum, sourceURL):
-			default:
-				logger.Warnf("Unable to send to Tx Status event channel.")
-			}
-		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode, blockNum, sourceURL)
-		} else {
-			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode, blockNum, sourceURL):
-			case <-time.After(ed.eventConsumerTimeout):
-				logger.Warnf("Timed out sending Tx Status event.")
-			}
-		}
+		ed.send(reg, reg.Overflow, NewTxStatusEvent(tx.Txid, tx.TxValidationCode, blockNum, sourceURL))
 	}
 }
 
-func (ed *Dispatcher) publishCCEvents(ccEvent *pb.ChaincodeEvent, blockNum uint64, sourceURL string) {
+func (ed *Dispatcher) publishCCEvents(ccEvent *pb.ChaincodeEvent, blockNum uint64, txIndex int, sourceURL string) {
+	if ed.chaincodeUpgradeEvents && isUpgradeEvent(ccEvent) {
+		ed.publishCCUpgradeEvents(ccEvent, blockNum, txIndex, sourceURL)
+	}
+
 	for _, reg := range ed.ccRegistrations {
 		logger.Debugf("Matching CCEvent[%s,%s] against Reg[%s,%s] ...", ccEvent.ChaincodeId, ccEvent.EventName, reg.ChaincodeID, reg.EventFilter)
-		if reg.ChaincodeID == ccEvent.ChaincodeId && reg.EventRegExp.MatchString(ccEvent.EventName) {
+		if ed.matchesChaincode(reg.ChaincodeID, ccEvent.ChaincodeId) && reg.EventRegExp.MatchString(ccEvent.EventName) {
 			logger.Debugf("... matched CCEvent[%s,%s] against Reg[%s,%s]", ccEvent.ChaincodeId, ccEvent.EventName, reg.ChaincodeID, reg.EventFilter)
+			event := NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId, ccEvent.Payload, blockNum, sourceURL)
+			event.TxIndex = txIndex
+			ed.sendCCEvent(reg, event)
+		}
+	}
+}
 
-			if ed.eventConsumerTimeout < 0 {
-				select {
-				case reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId, ccEvent.Payload, blockNum, sourceURL):
-				default:
-					logger.Warnf("Unable to send to CC event channel.")
-				}
-			} else if ed.eventConsumerTimeout == 0 {
-				reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId, ccEvent.Payload, blockNum, sourceURL)
-			} else {
-				select {
-				case reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId, ccEvent.Payload, blockNum, sourceURL):
-				case <-time.After(ed.eventConsumerTimeout):
-					logger.Warnf("Timed out sending CC event.")
-				}
-			}
+// publishCCUpgradeEvents sends an informational upgrade event (see fab.CCUpgradeEventName) to the registrations
+// of the upgraded chaincode whose event filter matches it. The name of the chaincode is only known if the payload
+// of the lscc event is available, which isn't the case for filtered blocks, in which case no event is sent.
+func (ed *Dispatcher) publishCCUpgradeEvents(ccEvent *pb.ChaincodeEvent, blockNum uint64, txIndex int, sourceURL string) {
+	ccName, err := upgradedChaincodeName(ccEvent.Payload)
+	if err != nil {
+		logger.Warnf("Unable to unmarshal lscc upgrade event of TxID [%s]: %s", ccEvent.TxId, err)
+		return
+	}
+	if ccName == "" {
+		logger.Debugf("Upgraded chaincode of TxID [%s] is unknown. Upgrade event will not be published", ccEvent.TxId)
+		return
+	}
+
+	for _, reg := range ed.ccRegistrations {
+		if chaincodeName(reg.ChaincodeID) != ccName || !reg.EventRegExp.MatchString(fab.CCUpgradeEventName) {
+			continue
 		}
+		logger.Debugf("Sending upgrade event for chaincode [%s] to Reg[%s,%s]", ccName, reg.ChaincodeID, reg.EventFilter)
+		event := NewChaincodeEvent(reg.ChaincodeID, fab.CCUpgradeEventName, ccEvent.TxId, ccEvent.Payload, blockNum, sourceURL)
+		event.TxIndex = txIndex
+		ed.sendCCEvent(reg, event)
 	}
 }
 
+func (ed *Dispatcher) sendCCEvent(reg *ChaincodeReg, event *fab.CCEvent) {
+	ed.send(reg, reg.Overflow, event)
+}
+
+// matchesChaincode returns true if the chaincode ID of the event matches the chaincode ID of the registration.
+// Unless disabled, the chaincode IDs are compared by chaincode name, ignoring any version suffix.
+func (ed *Dispatcher) matchesChaincode(regCCID, eventCCID string) bool {
+	if ed.matchChaincodeName {
+		return chaincodeName(regCCID) == chaincodeName(eventCCID)
+	}
+	return regCCID == eventCCID
+}
+
 // RegisterHandler registers an event handler
 func (ed *Dispatcher) RegisterHandler(t interface{}, h Handler) {
 	htype := reflect.TypeOf(t)
@@ -506,92 +514,12 @@ func getCCKey(ccID, eventFilter string) string {
 	return ccID + "/" + eventFilter
 }
 
-func toFilteredBlock(block *cb.Block) *pb.FilteredBlock {
-	var channelID string
-	var filteredTxs []*pb.FilteredTransaction
-	txFilter := ledgerutil.TxValidationFlags(block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
-
-	for i, data := range block.Data.Data {
-		filteredTx, chID, err := getFilteredTx(data, txFilter.Flag(i))
-		if err != nil {
-			logger.Warnf("error extracting Envelope from block: %v", err)
-			continue
-		}
-		channelID = chID
-		filteredTxs = append(filteredTxs, filteredTx)
-	}
-
-	return &pb.FilteredBlock{
-		ChannelId:            channelID,
-		Number:               block.Header.Number,
-		FilteredTransactions: filteredTxs,
-	}
-}
-
-func getFilteredTx(data []byte, txValidationCode pb.TxValidationCode) (*pb.FilteredTransaction, string, error) {
-	env, err := utils.GetEnvelopeFromBlock(data)
-	if err != nil {
-		return nil, "", errors.Wrap(err, "error extracting Envelope from block")
-	}
-	if env == nil {
-		return nil, "", errors.New("nil envelope")
-	}
-
-	payload, err := utils.GetPayload(env)
-	if err != nil {
-		return nil, "", errors.Wrap(err, "error extracting Payload from envelope")
-	}
-
-	channelHeaderBytes := payload.Header.ChannelHeader
-	channelHeader := &cb.ChannelHeader{}
-	if err := proto.Unmarshal(channelHeaderBytes, channelHeader); err != nil {
-		return nil, "", errors.Wrap(err, "error extracting ChannelHeader from payload")
-	}
-
-	filteredTx := &pb.FilteredTransaction{
-		Type:             cb.HeaderType(channelHeader.Type),
-		Txid:             channelHeader.TxId,
-		TxValidationCode: txValidationCode,
-	}
-
-	if cb.HeaderType(channelHeader.Type) == cb.HeaderType_ENDORSER_TRANSACTION {
-		actions, err := getFilteredTransactionActions(payload.Data)
-		if err != nil {
-			return nil, "", errors.Wrap(err, "error getting filtered transaction actions")
-		}
-		filteredTx.Data = actions
-	}
-	return filteredTx, channelHeader.ChannelId, nil
-}
-
-func getFilteredTransactionActions(data []byte) (*pb.FilteredTransaction_TransactionActions, error) {
-	actions := &pb.FilteredTransaction_TransactionActions{
-		TransactionActions: &pb.FilteredTransactionActions{},
-	}
-	tx, err := utils.GetTransaction(data)
-	if err != nil {
-		return nil, errors.Wrap(err, "error unmarshalling transaction payload")
-	}
-	chaincodeActionPayload, err := utils.GetChaincodeActionPayload(tx.Actions[0].Payload)
-	if err != nil {
-		return nil, errors.Wrap(err, "error unmarshalling chaincode action payload")
-	}
-	propRespPayload, err := utils.GetProposalResponsePayload(chaincodeActionPayload.Action.ProposalResponsePayload)
-	if err != nil {
-		return nil, errors.Wrap(err, "error unmarshalling response payload")
-	}
-	ccAction, err := utils.GetChaincodeAction(propRespPayload.Extension)
-	if err != nil {
-		return nil, errors.Wrap(err, "error unmarshalling chaincode action")
-	}
-	ccEvent, err := utils.GetChaincodeEvents(ccAction.Events)
-	if err != nil {
-		return nil, errors.Wrap(err, "error getting chaincode events")
-	}
-	if ccEvent != nil {
-		actions.TransactionActions.ChaincodeActions = append(actions.TransactionActions.ChaincodeActions, &pb.FilteredChaincodeAction{ChaincodeEvent: ccEvent})
+// chaincodeName returns the chaincode ID without the version suffix, e.g. "mycc" for "mycc:1.0"
+func chaincodeName(ccID string) string {
+	if i := strings.Index(ccID, ":"); i >= 0 {
+		return ccID[:i]
 	}
-	return actions, nil
+	return ccID
 }
 
 func (ed *Dispatcher) getState() int32 {
@@ -240,6 +240,77 @@ func TestFilteredBlockEvents(t *testing.T) {
 	}
 }
 
+// TestDuplicateBlockEvents tests that blocks which are received again (e.g. after failing over to another peer)
+// aren't published twice
+func TestDuplicateBlockEvents(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	fbeventch := make(chan *fab.FilteredBlockEvent, 10)
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(fbeventch, regch, errch)
+
+	var reg fab.Registration
+	select {
+	case reg = <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+	fblock0 := producer.NewFilteredBlock(channelID)
+	fblock1 := producer.NewFilteredBlock(channelID)
+	fblock2 := producer.NewFilteredBlock(channelID)
+
+	dispatcherEventch <- NewFilteredBlockEvent(fblock0, sourceURL)
+	dispatcherEventch <- NewFilteredBlockEvent(fblock1, sourceURL)
+	// The peer failed over to resends the last block
+	dispatcherEventch <- NewFilteredBlockEvent(fblock1, "localhost:10051")
+	dispatcherEventch <- NewFilteredBlockEvent(fblock0, "localhost:10051")
+	dispatcherEventch <- NewFilteredBlockEvent(fblock2, "localhost:10051")
+
+	for _, expected := range []uint64{0, 1, 2} {
+		select {
+		case fbevent, ok := <-fbeventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			if fbevent.FilteredBlock.Number != expected {
+				t.Fatalf("Expecting block number [%d] but got [%d]", expected, fbevent.FilteredBlock.Number)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for filtered block event for block [%d]", expected)
+		}
+	}
+
+	select {
+	case fbevent := <-fbeventch:
+		t.Fatalf("Expecting no more filtered block events but got block [%d]", fbevent.FilteredBlock.Number)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if dispatcher.LastBlockNum() != 2 {
+		t.Fatalf("Expecting last block number [2] but got [%d]", dispatcher.LastBlockNum())
+	}
+
+	dispatcherEventch <- NewUnregisterEvent(reg)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
 func TestBlockAndFilteredBlockEvents(t *testing.T) {
 	channelID := "testchannel"
 	dispatcher := New()
@@ -695,6 +766,77 @@ func TestCCEventsFiltered(t *testing.T) {
 	}
 }
 
+func TestCCEventPosition(t *testing.T) {
+	channelID := "testchannel"
+	ccID := "mycc"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	errch := make(chan error)
+	respch := make(chan fab.Registration)
+	eventch := make(chan *fab.CCEvent, 10)
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, ".*", eventch, respch, errch)
+
+	var reg fab.Registration
+	select {
+	case reg = <-respch:
+	case err := <-errch:
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	// The transaction at index 1 of the full block can't be parsed, so its chaincode event is lost, but
+	// it still counts in the index of the next transaction
+	block := servicemocks.NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, ccID, "event1", nil),
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, ccID, "event2", nil),
+		servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, ccID, "event3", nil),
+	)
+	block.Header.Number = 5
+	block.Data.Data[1] = []byte("invalid envelope")
+	dispatcherEventch <- NewBlockEvent(block, sourceURL)
+
+	// A filtered block contains all the transactions, including those which aren't valid
+	fblock := servicemocks.NewFilteredBlock(channelID,
+		servicemocks.NewFilteredTx("txid4", pb.TxValidationCode_MVCC_READ_CONFLICT),
+		servicemocks.NewFilteredTxWithCCEvent("txid5", ccID, "event5"),
+	)
+	fblock.Number = 6
+	dispatcherEventch <- NewFilteredBlockEvent(fblock, "localhost:9052")
+
+	expected := []*fab.CCEvent{
+		{TxID: "txid1", ChaincodeID: ccID, EventName: "event1", BlockNumber: 5, TxIndex: 0, SourceURL: sourceURL},
+		{TxID: "txid3", ChaincodeID: ccID, EventName: "event3", BlockNumber: 5, TxIndex: 2, SourceURL: sourceURL},
+		{TxID: "txid5", ChaincodeID: ccID, EventName: "event5", BlockNumber: 6, TxIndex: 1, SourceURL: "localhost:9052"},
+	}
+	for _, e := range expected {
+		select {
+		case event := <-eventch:
+			if event.TxID != e.TxID || event.EventName != e.EventName || event.BlockNumber != e.BlockNumber || event.TxIndex != e.TxIndex || event.SourceURL != e.SourceURL {
+				t.Fatalf("expecting CC event [%s, %s, %d, %d, %s] but got [%s, %s, %d, %d, %s]",
+					e.TxID, e.EventName, e.BlockNumber, e.TxIndex, e.SourceURL,
+					event.TxID, event.EventName, event.BlockNumber, event.TxIndex, event.SourceURL)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for CC event [%s]", e.EventName)
+		}
+	}
+
+	dispatcherEventch <- NewUnregisterEvent(reg)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
 func TestRegistrationInfo(t *testing.T) {
 	dispatcher := New()
 	if err := dispatcher.Start(); err != nil {
@@ -78,6 +78,19 @@ func NewRegisterBlockEvent(filter fab.BlockFilter, eventch chan<- *fab.BlockEven
 	}
 }
 
+// NewRegisterNamespaceBlockEvent creates a new RegisterBlockEvent for the blocks which contain at least one
+// transaction touching one of the chaincode namespaces. The blocks are trimmed to these transactions if trim is true.
+func NewRegisterNamespaceBlockEvent(namespaces []string, trim bool, filter fab.BlockFilter, eventch chan<- *fab.BlockEvent, respch chan<- fab.Registration, errCh chan<- error) *RegisterBlockEvent {
+	event := NewRegisterBlockEvent(filter, eventch, respch, errCh)
+	event.Reg.Namespaces = make(map[string]bool)
+	for _, namespace := range namespaces {
+		event.Reg.Namespaces[namespace] = true
+	}
+	event.Reg.TrimBlocks = trim
+	event.Reg.namespaceKey = namespaceKey(event.Reg.Namespaces)
+	return event
+}
+
 // NewRegisterFilteredBlockEvent creates a new RegisterFilterBlockEvent
 func NewRegisterFilteredBlockEvent(eventch chan<- *fab.FilteredBlockEvent, respch chan<- fab.Registration, errCh chan<- error) *RegisterFilteredBlockEvent {
 	return &RegisterFilteredBlockEvent{
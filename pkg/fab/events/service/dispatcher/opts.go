@@ -15,12 +15,16 @@ import (
 type params struct {
 	eventConsumerBufferSize uint
 	eventConsumerTimeout    time.Duration
+	matchChaincodeName      bool
+	chaincodeUpgradeEvents  bool
 }
 
 func defaultParams() *params {
 	return &params{
 		eventConsumerBufferSize: 100,
 		eventConsumerTimeout:    500 * time.Millisecond,
+		matchChaincodeName:      true,
+		chaincodeUpgradeEvents:  false,
 	}
 }
 
@@ -45,6 +49,30 @@ func WithEventConsumerTimeout(value time.Duration) options.Opt {
 	}
 }
 
+// WithMatchChaincodeName indicates whether chaincode events are matched against the chaincode event registrations
+// by chaincode name, ignoring any version suffix of the chaincode IDs (e.g. "mycc:1.0" matches "mycc"), so that
+// registrations keep matching after an upgrade of the chaincode. If false, the chaincode IDs must be equal.
+// Default: true
+func WithMatchChaincodeName(value bool) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(matchChaincodeNameSetter); ok {
+			setter.SetMatchChaincodeName(value)
+		}
+	}
+}
+
+// WithChaincodeUpgradeEvents indicates whether an informational chaincode event (see fab.CCUpgradeEventName)
+// is sent to the chaincode event registrations of a chaincode whose event filter matches it, when an upgrade of
+// the chaincode is committed. The event isn't sent for filtered blocks, which don't tell the upgraded chaincode.
+// Default: false
+func WithChaincodeUpgradeEvents(value bool) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(chaincodeUpgradeEventsSetter); ok {
+			setter.SetChaincodeUpgradeEvents(value)
+		}
+	}
+}
+
 type eventConsumerBufferSizeSetter interface {
 	SetEventConsumerBufferSize(value uint)
 }
@@ -53,6 +81,14 @@ type eventEventConsumerTimeoutSetter interface {
 	SetEventConsumerTimeout(value time.Duration)
 }
 
+type matchChaincodeNameSetter interface {
+	SetMatchChaincodeName(value bool)
+}
+
+type chaincodeUpgradeEventsSetter interface {
+	SetChaincodeUpgradeEvents(value bool)
+}
+
 func (p *params) SetEventConsumerBufferSize(value uint) {
 	logger.Debugf("EventConsumerBufferSize: %d", value)
 	p.eventConsumerBufferSize = value
@@ -62,3 +98,13 @@ func (p *params) SetEventConsumerTimeout(value time.Duration) {
 	logger.Debugf("EventConsumerTimeout: %s", value)
 	p.eventConsumerTimeout = value
 }
+
+func (p *params) SetMatchChaincodeName(value bool) {
+	logger.Debugf("MatchChaincodeName: %t", value)
+	p.matchChaincodeName = value
+}
+
+func (p *params) SetChaincodeUpgradeEvents(value bool) {
+	logger.Debugf("ChaincodeUpgradeEvents: %t", value)
+	p.chaincodeUpgradeEvents = value
+}
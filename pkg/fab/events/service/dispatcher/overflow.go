@@ -0,0 +1,96 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// Overflow determines how the events of a registration are handled when its event channel is full
+type Overflow struct {
+	// Policy is the overflow policy of the registration
+	Policy fab.OverflowPolicy
+	// Handler, if not nil, is called with each event of the registration which is dropped
+	Handler func(event interface{})
+
+	dropOldest func() (interface{}, bool)
+}
+
+// NewOverflow returns the overflow of a registration with the given policy and handler. dropOldest removes the
+// oldest event from the event channel of the registration, returning false if the channel is empty; it's required
+// by the OverflowDropOldest policy only.
+func NewOverflow(policy fab.OverflowPolicy, handler func(event interface{}), dropOldest func() (interface{}, bool)) *Overflow {
+	return &Overflow{
+		Policy:     policy,
+		Handler:    handler,
+		dropOldest: dropOldest,
+	}
+}
+
+// eventSender sends the events of a registration to its event channel
+type eventSender interface {
+	// trySend sends the event unless the channel is full
+	trySend(event interface{}) bool
+	// send sends the event unless the timeout expires first. A nil timeout never expires.
+	send(event interface{}, timeout <-chan time.Time) bool
+}
+
+// send sends the event to a registration. If the event channel of the registration is full, the event is handled
+// according to the overflow policy of the registration, or else the event consumer timeout of the dispatcher.
+func (ed *Dispatcher) send(reg eventSender, overflow *Overflow, event interface{}) {
+	policy := fab.OverflowDefault
+	if overflow != nil {
+		policy = overflow.Policy
+	}
+
+	switch policy {
+	case fab.OverflowBlock:
+		reg.send(event, nil)
+		return
+	case fab.OverflowDropNewest:
+		if reg.trySend(event) {
+			return
+		}
+	case fab.OverflowDropOldest:
+		for !reg.trySend(event) {
+			var oldest interface{}
+			ok := false
+			if overflow.dropOldest != nil {
+				oldest, ok = overflow.dropOldest()
+			}
+			if !ok {
+				// The channel is unbuffered and the consumer isn't waiting for the event
+				ed.dropped(overflow, event)
+				return
+			}
+			ed.dropped(overflow, oldest)
+		}
+		return
+	default:
+		if ed.eventConsumerTimeout < 0 {
+			if reg.trySend(event) {
+				return
+			}
+		} else if ed.eventConsumerTimeout == 0 {
+			reg.send(event, nil)
+			return
+		} else if reg.send(event, time.After(ed.eventConsumerTimeout)) {
+			return
+		}
+	}
+
+	ed.dropped(overflow, event)
+}
+
+func (ed *Dispatcher) dropped(overflow *Overflow, event interface{}) {
+	logger.Warnf("Dropped %T since the event channel of the registration is full", event)
+	if overflow != nil && overflow.Handler != nil {
+		overflow.Handler(event)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	ledgerutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/util"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// parsedBlock is a block whose transactions are parsed once for all the registrations of the dispatcher:
+// the filtered block is published to the filtered block, transaction status and chaincode registrations,
+// and the namespaces of the transactions filter the block registrations by chaincode namespace.
+type parsedBlock struct {
+	block    *cb.Block
+	filtered *pb.FilteredBlock
+	txs      []*parsedTx          // by index within the block, nil if the transaction couldn't be parsed
+	trimmed  map[string]*cb.Block // blocks trimmed to the transactions touching the namespaces of the key
+}
+
+// parsedTx is a transaction of a parsed block
+type parsedTx struct {
+	filtered    *pb.FilteredTransaction
+	chaincodeID string          // the chaincode invoked by an endorser transaction
+	results     []byte          // the read-write set of an endorser transaction
+	namespaces  map[string]bool // the namespaces of the read-write set, unmarshalled on demand
+}
+
+func parseBlock(block *cb.Block) *parsedBlock {
+	var channelID string
+	var filteredTxs []*pb.FilteredTransaction
+	txFilter := ledgerutil.TxValidationFlags(block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	txs := make([]*parsedTx, len(block.Data.Data))
+
+	for i, data := range block.Data.Data {
+		tx, chID, err := parseTx(data, txFilter.Flag(i))
+		if err != nil {
+			logger.Warnf("error extracting Envelope from block: %v", err)
+			continue
+		}
+		channelID = chID
+		filteredTxs = append(filteredTxs, tx.filtered)
+		txs[i] = tx
+	}
+
+	return &parsedBlock{
+		block: block,
+		filtered: &pb.FilteredBlock{
+			ChannelId:            channelID,
+			Number:               block.Header.Number,
+			FilteredTransactions: filteredTxs,
+		},
+		txs: txs,
+	}
+}
+
+// forNamespaces returns the block to deliver to a registration filtered by chaincode namespace: nil if none of
+// the transactions of the block touches the namespaces of the registration, otherwise the block, or a copy of
+// the block trimmed to the matching transactions if the registration trims the blocks. The trimmed blocks are
+// shared by the registrations of the same namespaces.
+func (p *parsedBlock) forNamespaces(reg *BlockReg) *cb.Block {
+	var matching []int
+	for i, tx := range p.txs {
+		if tx == nil || !tx.touches(reg.Namespaces) {
+			continue
+		}
+		if !reg.TrimBlocks {
+			return p.block
+		}
+		matching = append(matching, i)
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+
+	if block, ok := p.trimmed[reg.namespaceKey]; ok {
+		return block
+	}
+	block := trimBlock(p.block, matching)
+	if p.trimmed == nil {
+		p.trimmed = make(map[string]*cb.Block)
+	}
+	p.trimmed[reg.namespaceKey] = block
+	return block
+}
+
+// namespaceKey identifies a set of namespaces
+func namespaceKey(namespaces map[string]bool) string {
+	var names []string
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// touches returns true if the transaction touches one of the namespaces, i.e. it invokes the chaincode
+// or reads or writes its state (e.g. through a chaincode-to-chaincode invocation)
+func (tx *parsedTx) touches(namespaces map[string]bool) bool {
+	if tx.chaincodeID != "" && namespaces[tx.chaincodeID] {
+		return true
+	}
+
+	if tx.namespaces == nil {
+		tx.namespaces = make(map[string]bool)
+		txRWSet := &rwset.TxReadWriteSet{}
+		if err := proto.Unmarshal(tx.results, txRWSet); err != nil {
+			logger.Warnf("error unmarshalling read-write set of transaction [%s]: %s", tx.filtered.Txid, err)
+		}
+		for _, nsRWSet := range txRWSet.NsRwset {
+			tx.namespaces[nsRWSet.Namespace] = true
+		}
+	}
+
+	for namespace := range namespaces {
+		if tx.namespaces[namespace] {
+			return true
+		}
+	}
+	return false
+}
+
+// trimBlock returns a copy of the block which only contains the transactions at the given indexes, along with
+// their validation codes. The header of the block is kept, so the hash of the data of the trimmed block doesn't
+// match its header.
+func trimBlock(block *cb.Block, indexes []int) *cb.Block {
+	txFilter := ledgerutil.TxValidationFlags(block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	data := make([][]byte, len(indexes))
+	flags := make([]byte, len(indexes))
+	for i, index := range indexes {
+		data[i] = block.Data.Data[index]
+		flags[i] = uint8(txFilter.Flag(index))
+	}
+
+	metadata := make([][]byte, len(block.Metadata.Metadata))
+	copy(metadata, block.Metadata.Metadata)
+	metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = flags
+
+	return &cb.Block{
+		Header:   block.Header,
+		Data:     &cb.BlockData{Data: data},
+		Metadata: &cb.BlockMetadata{Metadata: metadata},
+	}
+}
+
+func parseTx(data []byte, txValidationCode pb.TxValidationCode) (*parsedTx, string, error) {
+	env, err := utils.GetEnvelopeFromBlock(data)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error extracting Envelope from block")
+	}
+	if env == nil {
+		return nil, "", errors.New("nil envelope")
+	}
+
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error extracting Payload from envelope")
+	}
+
+	channelHeaderBytes := payload.Header.ChannelHeader
+	channelHeader := &cb.ChannelHeader{}
+	if err := proto.Unmarshal(channelHeaderBytes, channelHeader); err != nil {
+		return nil, "", errors.Wrap(err, "error extracting ChannelHeader from payload")
+	}
+
+	tx := &parsedTx{
+		filtered: &pb.FilteredTransaction{
+			Type:             cb.HeaderType(channelHeader.Type),
+			Txid:             channelHeader.TxId,
+			TxValidationCode: txValidationCode,
+		},
+	}
+
+	if cb.HeaderType(channelHeader.Type) == cb.HeaderType_ENDORSER_TRANSACTION {
+		ccAction, err := getChaincodeAction(payload.Data)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "error getting filtered transaction actions")
+		}
+		actions, err := getFilteredTransactionActions(ccAction)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "error getting filtered transaction actions")
+		}
+		tx.filtered.Data = actions
+		if ccAction.ChaincodeId != nil {
+			tx.chaincodeID = ccAction.ChaincodeId.Name
+		}
+		tx.results = ccAction.Results
+	}
+	return tx, channelHeader.ChannelId, nil
+}
+
+func getChaincodeAction(data []byte) (*pb.ChaincodeAction, error) {
+	tx, err := utils.GetTransaction(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling transaction payload")
+	}
+	chaincodeActionPayload, err := utils.GetChaincodeActionPayload(tx.Actions[0].Payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode action payload")
+	}
+	propRespPayload, err := utils.GetProposalResponsePayload(chaincodeActionPayload.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling response payload")
+	}
+	ccAction, err := utils.GetChaincodeAction(propRespPayload.Extension)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling chaincode action")
+	}
+	return ccAction, nil
+}
+
+func getFilteredTransactionActions(ccAction *pb.ChaincodeAction) (*pb.FilteredTransaction_TransactionActions, error) {
+	actions := &pb.FilteredTransaction_TransactionActions{
+		TransactionActions: &pb.FilteredTransactionActions{},
+	}
+	ccEvent, err := utils.GetChaincodeEvents(ccAction.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting chaincode events")
+	}
+	if ccEvent != nil {
+		actions.TransactionActions.ChaincodeActions = append(actions.TransactionActions.ChaincodeActions, &pb.FilteredChaincodeAction{ChaincodeEvent: ccEvent})
+	}
+	return actions, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/blockfilter"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	ledgerutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/util"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestNamespaceBlockEvents(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	register := func(namespaces []string, trim bool) (fab.Registration, chan *fab.BlockEvent) {
+		eventch := make(chan *fab.BlockEvent, 10)
+		dispatcherEventch <- NewRegisterNamespaceBlockEvent(namespaces, trim, blockfilter.AcceptAny, eventch, regch, errch)
+		select {
+		case reg := <-regch:
+			return reg, eventch
+		case err := <-errch:
+			t.Fatalf("Error registering for block events: %s", err)
+		}
+		return nil, nil
+	}
+
+	cc1Reg, cc1Eventch := register([]string{"cc1"}, false)
+	cc1TrimReg, cc1TrimEventch := register([]string{"cc1"}, true)
+	cc2TrimReg, cc2TrimEventch := register([]string{"cc2", "cc3"}, true)
+
+	// cc3 is only touched through a chaincode-to-chaincode invocation of cc1
+	cc2cc := servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, "cc1", "event3", nil)
+	cc2cc.Namespaces = []string{"cc1", "cc3"}
+
+	eventProducer := servicemocks.NewBlockProducer()
+	dispatcherEventch <- NewBlockEvent(eventProducer.NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, "cc1", "event1", nil),
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_MVCC_READ_CONFLICT, "cc2", "event2", nil),
+		cc2cc,
+	), sourceURL)
+	dispatcherEventch <- NewBlockEvent(eventProducer.NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid4", pb.TxValidationCode_VALID, "cc4", "event4", nil),
+	), sourceURL)
+	dispatcherEventch <- NewBlockEvent(eventProducer.NewBlock(channelID,
+		servicemocks.NewTransactionWithCCEvent("txid5", pb.TxValidationCode_VALID, "cc2", "event5", nil),
+	), sourceURL)
+
+	event := receiveBlockEvent(t, cc1Eventch)
+	assert.Equal(t, uint64(0), event.Block.Header.Number)
+	assert.Equal(t, []string{"txid1", "txid2", "txid3"}, blockTxIDs(t, event.Block), "Expected the whole block")
+
+	trimmed := receiveBlockEvent(t, cc1TrimEventch)
+	assert.Equal(t, uint64(0), trimmed.Block.Header.Number)
+	assert.Equal(t, []string{"txid1", "txid3"}, blockTxIDs(t, trimmed.Block))
+
+	trimmed = receiveBlockEvent(t, cc2TrimEventch)
+	assert.Equal(t, uint64(0), trimmed.Block.Header.Number)
+	assert.Equal(t, []string{"txid2", "txid3"}, blockTxIDs(t, trimmed.Block))
+	txFilter := ledgerutil.TxValidationFlags(trimmed.Block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, txFilter.Flag(0), "Expected the validation code of the trimmed transaction")
+	assert.Equal(t, pb.TxValidationCode_VALID, txFilter.Flag(1), "Expected the validation code of the trimmed transaction")
+
+	// Block #1 doesn't touch any of the namespaces
+	trimmed = receiveBlockEvent(t, cc2TrimEventch)
+	assert.Equal(t, uint64(2), trimmed.Block.Header.Number)
+	assert.Equal(t, []string{"txid5"}, blockTxIDs(t, trimmed.Block))
+
+	select {
+	case event := <-cc1Eventch:
+		t.Fatalf("unexpected block event for block #%d", event.Block.Header.Number)
+	case event := <-cc1TrimEventch:
+		t.Fatalf("unexpected block event for block #%d", event.Block.Header.Number)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	dispatcherEventch <- NewUnregisterEvent(cc1Reg)
+	dispatcherEventch <- NewUnregisterEvent(cc1TrimReg)
+	dispatcherEventch <- NewUnregisterEvent(cc2TrimReg)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestParsedBlockSharesTrimmedBlocks(t *testing.T) {
+	block := servicemocks.NewBlock("testchannel",
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, "cc1", "event1", nil),
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, "cc2", "event2", nil),
+	)
+	parsed := parseBlock(block)
+	if assert.Len(t, parsed.filtered.FilteredTransactions, 2) {
+		assert.Equal(t, "txid1", parsed.filtered.FilteredTransactions[0].Txid)
+	}
+
+	reg1 := NewRegisterNamespaceBlockEvent([]string{"cc1", "cc3"}, true, blockfilter.AcceptAny, nil, nil, nil).Reg
+	reg2 := NewRegisterNamespaceBlockEvent([]string{"cc3", "cc1"}, true, blockfilter.AcceptAny, nil, nil, nil).Reg
+	trimmed := parsed.forNamespaces(reg1)
+	assert.True(t, trimmed == parsed.forNamespaces(reg2), "Expected the trimmed block to be shared by the registrations of the same namespaces")
+	assert.Equal(t, []string{"txid1"}, blockTxIDs(t, trimmed))
+
+	reg3 := NewRegisterNamespaceBlockEvent([]string{"cc3"}, true, blockfilter.AcceptAny, nil, nil, nil).Reg
+	assert.Nil(t, parsed.forNamespaces(reg3), "Expected no block for namespaces which aren't touched")
+}
+
+// BenchmarkBlockConsumer measures the work of a consumer which is only interested in the transactions of a
+// chaincode in a busy stream of blocks, when it receives every block and looks for the transactions itself
+func BenchmarkBlockConsumer(b *testing.B) {
+	blocks := newBusyBlocks(100)
+	namespaces := map[string]bool{"cc1": true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, block := range blocks {
+			consumeTxs(block, namespaces)
+		}
+	}
+}
+
+// BenchmarkNamespaceBlockConsumer measures the work of the same consumer when it only receives the blocks
+// trimmed to the transactions of the chaincode, which are parsed once by the dispatcher for all registrations
+func BenchmarkNamespaceBlockConsumer(b *testing.B) {
+	blocks := newBusyBlocks(100)
+	namespaces := map[string]bool{"cc1": true}
+	reg := NewRegisterNamespaceBlockEvent([]string{"cc1"}, true, blockfilter.AcceptAny, nil, nil, nil).Reg
+	var delivered []*cb.Block
+	for _, block := range blocks {
+		if trimmed := parseBlock(block).forNamespaces(reg); trimmed != nil {
+			delivered = append(delivered, trimmed)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, block := range delivered {
+			consumeTxs(block, namespaces)
+		}
+	}
+}
+
+//consumeTxs is the work of a consumer which parses the transactions of a block to find those touching the namespaces
+func consumeTxs(block *cb.Block, namespaces map[string]bool) int {
+	var count int
+	for _, tx := range parseBlock(block).txs {
+		if tx != nil && tx.touches(namespaces) {
+			count++
+		}
+	}
+	return count
+}
+
+//newBusyBlocks returns blocks of 50 transactions, of which one in ten blocks contains a transaction of cc1
+func newBusyBlocks(count int) []*cb.Block {
+	eventProducer := servicemocks.NewBlockProducer()
+	var blocks []*cb.Block
+	for i := 0; i < count; i++ {
+		var txs []*servicemocks.TxInfo
+		for j := 0; j < 50; j++ {
+			ccID := "cc2"
+			if i%10 == 0 && j == 0 {
+				ccID = "cc1"
+			}
+			txs = append(txs, servicemocks.NewTransactionWithCCEvent(fmt.Sprintf("txid%d-%d", i, j), pb.TxValidationCode_VALID, ccID, "event", nil))
+		}
+		blocks = append(blocks, eventProducer.NewBlock("testchannel", txs...))
+	}
+	return blocks
+}
+
+func receiveBlockEvent(t *testing.T, eventch <-chan *fab.BlockEvent) *fab.BlockEvent {
+	select {
+	case event, ok := <-eventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block event")
+	}
+	return nil
+}
+
+func blockTxIDs(t *testing.T, block *cb.Block) []string {
+	var txIDs []string
+	for _, tx := range parseBlock(block).filtered.FilteredTransactions {
+		txIDs = append(txIDs, tx.Txid)
+	}
+	return txIDs
+}
@@ -8,6 +8,7 @@ package dispatcher
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 )
@@ -16,11 +17,20 @@ import (
 type BlockReg struct {
 	Filter  fab.BlockFilter
 	Eventch chan<- *fab.BlockEvent
+	// Namespaces, if any, are the chaincode namespaces which at least one transaction of the blocks must touch
+	Namespaces map[string]bool
+	// TrimBlocks trims the blocks to the transactions which touch the namespaces
+	TrimBlocks bool
+	// Overflow, if not nil, determines how the events are handled when the event channel is full
+	Overflow *Overflow
+
+	namespaceKey string
 }
 
 // FilteredBlockReg contains the data for a filtered block registration
 type FilteredBlockReg struct {
-	Eventch chan<- *fab.FilteredBlockEvent
+	Eventch  chan<- *fab.FilteredBlockEvent
+	Overflow *Overflow
 }
 
 // ChaincodeReg contains the data for a chaincode registration
@@ -29,10 +39,84 @@ type ChaincodeReg struct {
 	EventFilter string
 	EventRegExp *regexp.Regexp
 	Eventch     chan<- *fab.CCEvent
+	Overflow    *Overflow
 }
 
 // TxStatusReg contains the data for a transaction status registration
 type TxStatusReg struct {
-	TxID    string
-	Eventch chan<- *fab.TxStatusEvent
+	TxID     string
+	Eventch  chan<- *fab.TxStatusEvent
+	Overflow *Overflow
+}
+
+func (r *BlockReg) trySend(event interface{}) bool {
+	select {
+	case r.Eventch <- event.(*fab.BlockEvent):
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *BlockReg) send(event interface{}, timeout <-chan time.Time) bool {
+	select {
+	case r.Eventch <- event.(*fab.BlockEvent):
+		return true
+	case <-timeout:
+		return false
+	}
+}
+
+func (r *FilteredBlockReg) trySend(event interface{}) bool {
+	select {
+	case r.Eventch <- event.(*fab.FilteredBlockEvent):
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *FilteredBlockReg) send(event interface{}, timeout <-chan time.Time) bool {
+	select {
+	case r.Eventch <- event.(*fab.FilteredBlockEvent):
+		return true
+	case <-timeout:
+		return false
+	}
+}
+
+func (r *ChaincodeReg) trySend(event interface{}) bool {
+	select {
+	case r.Eventch <- event.(*fab.CCEvent):
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *ChaincodeReg) send(event interface{}, timeout <-chan time.Time) bool {
+	select {
+	case r.Eventch <- event.(*fab.CCEvent):
+		return true
+	case <-timeout:
+		return false
+	}
+}
+
+func (r *TxStatusReg) trySend(event interface{}) bool {
+	select {
+	case r.Eventch <- event.(*fab.TxStatusEvent):
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *TxStatusReg) send(event interface{}, timeout <-chan time.Time) bool {
+	select {
+	case r.Eventch <- event.(*fab.TxStatusEvent):
+		return true
+	case <-timeout:
+		return false
+	}
 }
@@ -0,0 +1,47 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	lscc             = "lscc"
+	lsccUpgradeEvent = "upgrade"
+)
+
+// lifecycleEvent is the payload of the chaincode event which is set by lscc when a chaincode is upgraded
+// (LifecycleEvent in Fabric's peer protos)
+type lifecycleEvent struct {
+	ChaincodeName string `protobuf:"bytes,1,opt,name=chaincode_name,json=chaincodeName" json:"chaincode_name,omitempty"`
+}
+
+func (m *lifecycleEvent) Reset()         { *m = lifecycleEvent{} }
+func (m *lifecycleEvent) String() string { return proto.CompactTextString(m) }
+func (*lifecycleEvent) ProtoMessage()    {}
+
+// isUpgradeEvent returns true if the chaincode event was set by lscc for a chaincode upgrade
+func isUpgradeEvent(ccEvent *pb.ChaincodeEvent) bool {
+	return chaincodeName(ccEvent.ChaincodeId) == lscc && ccEvent.EventName == lsccUpgradeEvent
+}
+
+// upgradedChaincodeName returns the name of the upgraded chaincode from the payload of the lscc upgrade event,
+// or an empty name if the payload isn't available
+func upgradedChaincodeName(payload []byte) (string, error) {
+	if len(payload) == 0 {
+		return "", nil
+	}
+
+	event := &lifecycleEvent{}
+	if err := proto.Unmarshal(payload, event); err != nil {
+		return "", errors.Wrap(err, "unmarshal of lifecycle event failed")
+	}
+	return event.ChaincodeName, nil
+}
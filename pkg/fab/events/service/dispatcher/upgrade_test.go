@@ -0,0 +1,175 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestCCEventsAcrossUpgrade(t *testing.T) {
+	dispatcherEventch, stop := startDispatcher(t, WithChaincodeUpgradeEvents(true))
+	defer stop()
+
+	eventch1 := registerCCEvent(t, dispatcherEventch, "mycc", `event.*|\$upgrade`)
+	eventch2 := registerCCEvent(t, dispatcherEventch, "othercc", ".*")
+	eventch3 := registerCCEvent(t, dispatcherEventch, "mycc", "event2")
+
+	upgradePayload, err := proto.Marshal(&lifecycleEvent{ChaincodeName: "mycc"})
+	if err != nil {
+		t.Fatalf("Error marshalling lifecycle event: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+	dispatcherEventch <- NewBlockEvent(producer.NewBlock("testchannel",
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, "mycc:1.0", "event1", []byte("payload1")),
+	), sourceURL)
+	dispatcherEventch <- NewBlockEvent(producer.NewBlock("testchannel",
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, lscc, lsccUpgradeEvent, upgradePayload),
+	), sourceURL)
+	dispatcherEventch <- NewBlockEvent(producer.NewBlock("testchannel",
+		servicemocks.NewTransactionWithCCEvent("txid3", pb.TxValidationCode_VALID, "mycc:2.0", "event2", []byte("payload2")),
+	), sourceURL)
+
+	checkCCEvent(t, receiveCCEvent(t, eventch1), "mycc:1.0", []byte("payload1"), "event1")
+	checkCCEvent(t, receiveCCEvent(t, eventch1), "mycc", upgradePayload, fab.CCUpgradeEventName)
+	checkCCEvent(t, receiveCCEvent(t, eventch1), "mycc:2.0", []byte("payload2"), "event2")
+	expectNoCCEvent(t, eventch2)
+
+	// The upgrade event isn't sent to the registrations whose event filter doesn't match it
+	checkCCEvent(t, receiveCCEvent(t, eventch3), "mycc:2.0", []byte("payload2"), "event2")
+	expectNoCCEvent(t, eventch3)
+}
+
+func TestCCEventsUpgradeDisabled(t *testing.T) {
+	dispatcherEventch, stop := startDispatcher(t)
+	defer stop()
+
+	eventch := registerCCEvent(t, dispatcherEventch, "mycc", ".*")
+
+	upgradePayload, err := proto.Marshal(&lifecycleEvent{ChaincodeName: "mycc"})
+	if err != nil {
+		t.Fatalf("Error marshalling lifecycle event: %s", err)
+	}
+
+	// Upgrade events are only sent if enabled
+	dispatcherEventch <- NewBlockEvent(servicemocks.NewBlockProducer().NewBlock("testchannel",
+		servicemocks.NewTransactionWithCCEvent("txid1", pb.TxValidationCode_VALID, lscc, lsccUpgradeEvent, upgradePayload),
+		servicemocks.NewTransactionWithCCEvent("txid2", pb.TxValidationCode_VALID, "mycc", "event1", []byte("payload1")),
+	), sourceURL)
+
+	checkCCEvent(t, receiveCCEvent(t, eventch), "mycc", []byte("payload1"), "event1")
+	expectNoCCEvent(t, eventch)
+}
+
+func TestCCEventsFilteredUpgrade(t *testing.T) {
+	dispatcherEventch, stop := startDispatcher(t, WithChaincodeUpgradeEvents(true))
+	defer stop()
+
+	eventch1 := registerCCEvent(t, dispatcherEventch, "mycc", ".*")
+	eventch2 := registerCCEvent(t, dispatcherEventch, "othercc", ".*")
+
+	// The payload of the lscc event isn't available in filtered blocks, so the upgraded chaincode is unknown
+	// and no upgrade event is sent
+	dispatcherEventch <- NewFilteredBlockEvent(servicemocks.NewBlockProducer().NewFilteredBlock("testchannel",
+		servicemocks.NewFilteredTxWithCCEvent("txid1", lscc, lsccUpgradeEvent),
+		servicemocks.NewFilteredTxWithCCEvent("txid2", "mycc", "event1"),
+	), sourceURL)
+
+	checkCCEvent(t, receiveCCEvent(t, eventch1), "mycc", nil, "event1")
+	expectNoCCEvent(t, eventch1)
+	expectNoCCEvent(t, eventch2)
+}
+
+func TestCCEventsStrictChaincodeID(t *testing.T) {
+	dispatcherEventch, stop := startDispatcher(t, WithMatchChaincodeName(false), WithChaincodeUpgradeEvents(false))
+	defer stop()
+
+	eventch := registerCCEvent(t, dispatcherEventch, "mycc", "event.*")
+
+	dispatcherEventch <- NewFilteredBlockEvent(servicemocks.NewBlockProducer().NewFilteredBlock("testchannel",
+		servicemocks.NewFilteredTxWithCCEvent("txid1", "mycc:1.0", "event1"),
+		servicemocks.NewFilteredTxWithCCEvent("txid2", lscc, lsccUpgradeEvent),
+		servicemocks.NewFilteredTxWithCCEvent("txid3", "mycc", "event2"),
+	), sourceURL)
+
+	checkCCEvent(t, receiveCCEvent(t, eventch), "mycc", nil, "event2")
+	expectNoCCEvent(t, eventch)
+}
+
+func TestChaincodeName(t *testing.T) {
+	if name := chaincodeName("mycc:1.0"); name != "mycc" {
+		t.Fatalf("expecting chaincode name [mycc] but got [%s]", name)
+	}
+	if name := chaincodeName("mycc"); name != "mycc" {
+		t.Fatalf("expecting chaincode name [mycc] but got [%s]", name)
+	}
+	if _, err := upgradedChaincodeName([]byte("invalid")); err == nil {
+		t.Fatalf("expecting error unmarshalling invalid lifecycle event")
+	}
+}
+
+func startDispatcher(t *testing.T, opts ...options.Opt) (chan<- interface{}, func()) {
+	dispatcher := New(opts...)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	return dispatcherEventch, func() {
+		stopResp := make(chan error)
+		dispatcherEventch <- NewStopEvent(stopResp)
+		if err := <-stopResp; err != nil {
+			t.Fatalf("Error stopping dispatcher: %s", err)
+		}
+	}
+}
+
+func registerCCEvent(t *testing.T, dispatcherEventch chan<- interface{}, ccID, eventFilter string) <-chan *fab.CCEvent {
+	errch := make(chan error)
+	respch := make(chan fab.Registration)
+	eventch := make(chan *fab.CCEvent, 10)
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, eventFilter, eventch, respch, errch)
+
+	select {
+	case <-respch:
+	case err := <-errch:
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	return eventch
+}
+
+func receiveCCEvent(t *testing.T, eventch <-chan *fab.CCEvent) *fab.CCEvent {
+	select {
+	case event, ok := <-eventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for CC event")
+	}
+	return nil
+}
+
+func expectNoCCEvent(t *testing.T, eventch <-chan *fab.CCEvent) {
+	select {
+	case event := <-eventch:
+		t.Fatalf("unexpected CC event [%s] for CC [%s]", event.EventName, event.ChaincodeID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
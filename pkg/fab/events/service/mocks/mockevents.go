@@ -9,6 +9,7 @@ package mocks
 import (
 	"github.com/golang/protobuf/proto"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -44,6 +45,9 @@ type TxInfo struct {
 	ChaincodeID      string
 	EventName        string
 	Payload          []byte
+	// Namespaces are the namespaces of the read-write set of the transaction, e.g. the chaincodes invoked
+	// by the chaincode of the transaction
+	Namespaces []string
 }
 
 // NewTransaction creates a new transaction
@@ -107,7 +111,7 @@ func NewFilteredTxWithCCEvent(txID, ccID, event string) *pb.FilteredTransaction
 
 func newEnvelope(channelID string, txInfo *TxInfo) *cb.Envelope {
 	tx := &pb.Transaction{
-		Actions: []*pb.TransactionAction{newTxAction(txInfo.TxID, txInfo.ChaincodeID, txInfo.EventName, txInfo.Payload)},
+		Actions: []*pb.TransactionAction{newTxAction(txInfo.TxID, txInfo.ChaincodeID, txInfo.EventName, txInfo.Payload, txInfo.Namespaces)},
 	}
 	txBytes, err := proto.Marshal(tx)
 	if err != nil {
@@ -134,7 +138,7 @@ func newEnvelope(channelID string, txInfo *TxInfo) *cb.Envelope {
 	}
 }
 
-func newTxAction(txID string, ccID string, eventName string, payload []byte) *pb.TransactionAction {
+func newTxAction(txID string, ccID string, eventName string, payload []byte, namespaces []string) *pb.TransactionAction {
 	ccEvent := &pb.ChaincodeEvent{
 		TxId:        string(txID),
 		ChaincodeId: ccID,
@@ -146,11 +150,21 @@ func newTxAction(txID string, ccID string, eventName string, payload []byte) *pb
 		panic(err)
 	}
 
+	txRWSet := &rwset.TxReadWriteSet{}
+	for _, namespace := range namespaces {
+		txRWSet.NsRwset = append(txRWSet.NsRwset, &rwset.NsReadWriteSet{Namespace: namespace})
+	}
+	resultsBytes, err := proto.Marshal(txRWSet)
+	if err != nil {
+		panic(err)
+	}
+
 	chaincodeAction := &pb.ChaincodeAction{
 		ChaincodeId: &pb.ChaincodeID{
 			Name: ccID,
 		},
-		Events: eventBytes,
+		Results: resultsBytes,
+		Events:  eventBytes,
 	}
 	extBytes, err := proto.Marshal(chaincodeAction)
 	if err != nil {
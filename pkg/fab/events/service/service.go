@@ -119,7 +119,44 @@ func (s *Service) Dispatcher() Dispatcher {
 // RegisterBlockEvent registers for block events. If the client is not authorized to receive
 // block events then an error is returned.
 func (s *Service) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
-	eventch := make(chan *fab.BlockEvent, s.eventConsumerBufferSize)
+	return s.registerBlockEvent(nil, nil, false, filter)
+}
+
+// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+// touching one of the chaincode namespaces. The blocks are trimmed to these transactions if trim is true.
+func (s *Service) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	return s.registerNamespaceBlockEvent(nil, namespaces, trim, filter)
+}
+
+// RegisterFilteredBlockEvent registers for filtered block events. If the client is not authorized to receive
+// filtered block events then an error is returned.
+func (s *Service) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	return s.registerFilteredBlockEvent(nil)
+}
+
+// RegisterChaincodeEvent registers for chaincode events. If the client is not authorized to receive
+// chaincode events then an error is returned.
+// - ccID is the chaincode ID for which events are to be received
+// - eventFilter is the chaincode event name for which events are to be received
+func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	return s.registerChaincodeEvent(nil, ccID, eventFilter)
+}
+
+// RegisterTxStatusEvent registers for transaction status events. If the client is not authorized to receive
+// transaction status events then an error is returned.
+// - txID is the transaction ID for which events are to be received
+func (s *Service) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	return s.registerTxStatusEvent(nil, txID)
+}
+
+// Buffered returns a view of the event service whose registrations have their own event buffer and
+// overflow policy (see fab.BufferedEventService)
+func (s *Service) Buffered(opts fab.BufferOpts) fab.EventService {
+	return &bufferedService{service: s, opts: opts}
+}
+
+func (s *Service) registerBlockEvent(buffer *fab.BufferOpts, namespaces []string, trim bool, filter []fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	eventch := make(chan *fab.BlockEvent, s.bufferSize(buffer))
 	regch := make(chan fab.Registration)
 	errch := make(chan error)
 
@@ -132,7 +169,22 @@ func (s *Service) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registratio
 		blockFilter = filter[0]
 	}
 
-	if err := s.Submit(dispatcher.NewRegisterBlockEvent(blockFilter, eventch, regch, errch)); err != nil {
+	var event *dispatcher.RegisterBlockEvent
+	if len(namespaces) > 0 {
+		event = dispatcher.NewRegisterNamespaceBlockEvent(namespaces, trim, blockFilter, eventch, regch, errch)
+	} else {
+		event = dispatcher.NewRegisterBlockEvent(blockFilter, eventch, regch, errch)
+	}
+	event.Reg.Overflow = newOverflow(buffer, func() (interface{}, bool) {
+		select {
+		case e, ok := <-eventch:
+			return e, ok
+		default:
+			return nil, false
+		}
+	})
+
+	if err := s.Submit(event); err != nil {
 		return nil, nil, errors.WithMessage(err, "error registering for block events")
 	}
 
@@ -144,14 +196,29 @@ func (s *Service) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registratio
 	}
 }
 
-// RegisterFilteredBlockEvent registers for filtered block events. If the client is not authorized to receive
-// filtered block events then an error is returned.
-func (s *Service) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
-	eventch := make(chan *fab.FilteredBlockEvent, s.eventConsumerBufferSize)
+func (s *Service) registerNamespaceBlockEvent(buffer *fab.BufferOpts, namespaces []string, trim bool, filter []fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	if len(namespaces) == 0 {
+		return nil, nil, errors.New("at least one chaincode namespace must be specified")
+	}
+	return s.registerBlockEvent(buffer, namespaces, trim, filter)
+}
+
+func (s *Service) registerFilteredBlockEvent(buffer *fab.BufferOpts) (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	eventch := make(chan *fab.FilteredBlockEvent, s.bufferSize(buffer))
 	regch := make(chan fab.Registration)
 	errch := make(chan error)
 
-	if err := s.Submit(dispatcher.NewRegisterFilteredBlockEvent(eventch, regch, errch)); err != nil {
+	event := dispatcher.NewRegisterFilteredBlockEvent(eventch, regch, errch)
+	event.Reg.Overflow = newOverflow(buffer, func() (interface{}, bool) {
+		select {
+		case e, ok := <-eventch:
+			return e, ok
+		default:
+			return nil, false
+		}
+	})
+
+	if err := s.Submit(event); err != nil {
 		return nil, nil, errors.WithMessage(err, "error registering for filtered block events")
 	}
 
@@ -163,11 +230,7 @@ func (s *Service) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.Fi
 	}
 }
 
-// RegisterChaincodeEvent registers for chaincode events. If the client is not authorized to receive
-// chaincode events then an error is returned.
-// - ccID is the chaincode ID for which events are to be received
-// - eventFilter is the chaincode event name for which events are to be received
-func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+func (s *Service) registerChaincodeEvent(buffer *fab.BufferOpts, ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
 	if ccID == "" {
 		return nil, nil, errors.New("chaincode ID is required")
 	}
@@ -175,11 +238,21 @@ func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registra
 		return nil, nil, errors.New("event filter is required")
 	}
 
-	eventch := make(chan *fab.CCEvent, s.eventConsumerBufferSize)
+	eventch := make(chan *fab.CCEvent, s.bufferSize(buffer))
 	regch := make(chan fab.Registration)
 	errch := make(chan error)
 
-	if err := s.Submit(dispatcher.NewRegisterChaincodeEvent(ccID, eventFilter, eventch, regch, errch)); err != nil {
+	event := dispatcher.NewRegisterChaincodeEvent(ccID, eventFilter, eventch, regch, errch)
+	event.Reg.Overflow = newOverflow(buffer, func() (interface{}, bool) {
+		select {
+		case e, ok := <-eventch:
+			return e, ok
+		default:
+			return nil, false
+		}
+	})
+
+	if err := s.Submit(event); err != nil {
 		return nil, nil, errors.WithMessage(err, "error registering for chaincode events")
 	}
 
@@ -191,19 +264,26 @@ func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registra
 	}
 }
 
-// RegisterTxStatusEvent registers for transaction status events. If the client is not authorized to receive
-// transaction status events then an error is returned.
-// - txID is the transaction ID for which events are to be received
-func (s *Service) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+func (s *Service) registerTxStatusEvent(buffer *fab.BufferOpts, txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
 	if txID == "" {
 		return nil, nil, errors.New("txID must be provided")
 	}
 
-	eventch := make(chan *fab.TxStatusEvent, s.eventConsumerBufferSize)
+	eventch := make(chan *fab.TxStatusEvent, s.bufferSize(buffer))
 	regch := make(chan fab.Registration)
 	errch := make(chan error)
 
-	if err := s.Submit(dispatcher.NewRegisterTxStatusEvent(txID, eventch, regch, errch)); err != nil {
+	event := dispatcher.NewRegisterTxStatusEvent(txID, eventch, regch, errch)
+	event.Reg.Overflow = newOverflow(buffer, func() (interface{}, bool) {
+		select {
+		case e, ok := <-eventch:
+			return e, ok
+		default:
+			return nil, false
+		}
+	})
+
+	if err := s.Submit(event); err != nil {
 		return nil, nil, errors.WithMessage(err, "error registering for Tx Status events")
 	}
 
@@ -215,6 +295,22 @@ func (s *Service) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *
 	}
 }
 
+// bufferSize returns the capacity of the event channel of a registration
+func (s *Service) bufferSize(buffer *fab.BufferOpts) uint {
+	if buffer != nil && buffer.Size > 0 {
+		return uint(buffer.Size)
+	}
+	return s.eventConsumerBufferSize
+}
+
+// newOverflow returns the overflow of a registration with the buffer options, or nil if the registration has none
+func newOverflow(buffer *fab.BufferOpts, dropOldest func() (interface{}, bool)) *dispatcher.Overflow {
+	if buffer == nil {
+		return nil
+	}
+	return dispatcher.NewOverflow(buffer.OverflowPolicy, buffer.OnOverflow, dropOldest)
+}
+
 // Unregister unregisters the given registration.
 // - reg is the registration handle that was returned from one of the RegisterXXX functions
 func (s *Service) Unregister(reg fab.Registration) {
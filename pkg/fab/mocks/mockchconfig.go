@@ -16,13 +16,15 @@ import (
 
 // MockChannelCfg contains mock channel configuration
 type MockChannelCfg struct {
-	MockID          string
-	MockBlockNumber uint64
-	MockMSPs        []*msp.MSPConfig
-	MockAnchorPeers []*fab.OrgAnchorPeer
-	MockOrderers    []string
-	MockVersions    *fab.Versions
-	MockMembership  fab.ChannelMembership
+	MockID           string
+	MockBlockNumber  uint64
+	MockSequence     uint64
+	MockMSPs         []*msp.MSPConfig
+	MockAnchorPeers  []*fab.OrgAnchorPeer
+	MockOrderers     []string
+	MockVersions     *fab.Versions
+	MockMembership   fab.ChannelMembership
+	MockCapabilities map[fab.ConfigGroupKey]map[string]bool
 }
 
 // NewMockChannelCfg ...
@@ -40,6 +42,11 @@ func (cfg *MockChannelCfg) BlockNumber() uint64 {
 	return cfg.MockBlockNumber
 }
 
+// Sequence returns sequence
+func (cfg *MockChannelCfg) Sequence() uint64 {
+	return cfg.MockSequence
+}
+
 // MSPs returns msps
 func (cfg *MockChannelCfg) MSPs() []*msp.MSPConfig {
 	return cfg.MockMSPs
@@ -60,6 +67,11 @@ func (cfg *MockChannelCfg) Versions() *fab.Versions {
 	return cfg.MockVersions
 }
 
+// HasCapability returns true if the capability is enabled in the given group
+func (cfg *MockChannelCfg) HasCapability(group fab.ConfigGroupKey, capability string) bool {
+	return cfg.MockCapabilities[group][capability]
+}
+
 // MockChannelConfig mockcore query channel configuration
 type MockChannelConfig struct {
 	channelID string
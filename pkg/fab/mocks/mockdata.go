@@ -59,6 +59,8 @@ type MockConfigGroupBuilder struct {
 	MSPNames       []string
 	RootCA         string
 	Groups         map[string]*common.ConfigGroup
+	// Capabilities are added to the channel, orderer and application groups, by group name
+	Capabilities map[string][]string
 }
 
 // MockConfigBlockBuilder is used to build a mock Chain configuration block
@@ -66,6 +68,7 @@ type MockConfigBlockBuilder struct {
 	MockConfigGroupBuilder
 	Index           uint64
 	LastConfigIndex uint64
+	Sequence        uint64
 }
 
 // MockConfigUpdateEnvelopeBuilder builds a mock ConfigUpdateEnvelope
@@ -161,7 +164,7 @@ func (b *MockConfigBlockBuilder) buildConfigEnvelope() *common.ConfigEnvelope {
 
 func (b *MockConfigBlockBuilder) buildConfig() *common.Config {
 	return &common.Config{
-		Sequence:     0,
+		Sequence:     b.Sequence,
 		ChannelGroup: b.buildConfigGroup(),
 	}
 }
@@ -178,14 +181,31 @@ func (b *MockConfigGroupBuilder) buildConfigGroup() *common.ConfigGroup {
 			"Readers":         b.buildBasicConfigPolicy(),
 			"Admins":          b.buildBasicConfigPolicy(),
 		},
-		Values: map[string]*common.ConfigValue{
+		Values: b.withCapabilitiesConfigValue(channelConfig.ChannelGroupKey, map[string]*common.ConfigValue{
 			channelConfig.OrdererAddressesKey: b.buildOrdererAddressesConfigValue(),
-		},
+		}),
 		Version:   b.Version,
 		ModPolicy: b.ModPolicy,
 	}
 }
 
+func (b *MockConfigGroupBuilder) withCapabilitiesConfigValue(group string, values map[string]*common.ConfigValue) map[string]*common.ConfigValue {
+	capabilities, ok := b.Capabilities[group]
+	if !ok {
+		return values
+	}
+
+	value := &common.Capabilities{Capabilities: make(map[string]*common.Capability)}
+	for _, capability := range capabilities {
+		value.Capabilities[capability] = &common.Capability{}
+	}
+	values[channelConfig.CapabilitiesKey] = &common.ConfigValue{
+		Version:   b.Version,
+		ModPolicy: b.ModPolicy,
+		Value:     marshalOrPanic(value)}
+	return values
+}
+
 func (b *MockConfigGroupBuilder) buildOrdererAddressesConfigValue() *common.ConfigValue {
 	return &common.ConfigValue{
 		Version:   b.Version,
@@ -210,7 +230,7 @@ func (b *MockConfigGroupBuilder) buildOrdererGroup() *common.ConfigGroup {
 			"Readers":         b.buildBasicConfigPolicy(),
 			"Admins":          b.buildBasicConfigPolicy(),
 		},
-		Values: map[string]*common.ConfigValue{
+		Values: b.withCapabilitiesConfigValue(channelConfig.OrdererGroupKey, map[string]*common.ConfigValue{
 			channelConfig.BatchSizeKey:                 b.buildBatchSizeConfigValue(),
 			channelConfig.AnchorPeersKey:               b.buildAnchorPeerConfigValue(),
 			channelConfig.ConsensusTypeKey:             b.buildConsensusTypeConfigValue(),
@@ -218,7 +238,7 @@ func (b *MockConfigGroupBuilder) buildOrdererGroup() *common.ConfigGroup {
 			channelConfig.ChannelRestrictionsKey:       b.buildChannelRestrictionsConfigValue(),
 			channelConfig.HashingAlgorithmKey:          b.buildHashingAlgorithmConfigValue(),
 			channelConfig.BlockDataHashingStructureKey: b.buildBlockDataHashingStructureConfigValue(),
-		},
+		}),
 		Version:   b.Version,
 		ModPolicy: b.ModPolicy,
 	}
@@ -405,10 +425,10 @@ func (b *MockConfigGroupBuilder) buildApplicationGroup() *common.ConfigGroup {
 			"Writers": b.buildSignatureConfigPolicy(),
 			"Readers": b.buildSignatureConfigPolicy(),
 		},
-		Values: map[string]*common.ConfigValue{
+		Values: b.withCapabilitiesConfigValue("Application", map[string]*common.ConfigValue{
 			channelConfig.BatchSizeKey: b.buildBatchSizeConfigValue(),
 			// TODO: More
-		},
+		}),
 		Version:   b.Version,
 		ModPolicy: b.ModPolicy,
 	}
@@ -13,13 +13,15 @@ import (
 
 // MockEventService implements a mock event service
 type MockEventService struct {
-	TxStatusRegCh chan *dispatcher.TxStatusReg
+	TxStatusRegCh      chan *dispatcher.TxStatusReg
+	FilteredBlockRegCh chan *dispatcher.FilteredBlockReg
 }
 
 // NewMockEventService returns a new mock event service
 func NewMockEventService() *MockEventService {
 	return &MockEventService{
-		TxStatusRegCh: make(chan *dispatcher.TxStatusReg, 1),
+		TxStatusRegCh:      make(chan *dispatcher.TxStatusReg, 1),
+		FilteredBlockRegCh: make(chan *dispatcher.FilteredBlockReg, 1),
 	}
 }
 
@@ -38,6 +40,10 @@ func (m *MockEventService) RegisterFilteredBlockEvent() (fab.Registration, <-cha
 	reg := &dispatcher.FilteredBlockReg{
 		Eventch: eventCh,
 	}
+	select {
+	case m.FilteredBlockRegCh <- reg:
+	default:
+	}
 	return reg, eventCh, nil
 }
 
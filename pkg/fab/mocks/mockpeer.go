@@ -93,6 +93,7 @@ func (p *MockPeer) ProcessTransactionProposal(ctx reqContext.Context, tp fab.Pro
 
 	return &fab.TransactionProposalResponse{
 		Endorser: p.MockURL,
+		MSPID:    p.MockMSP,
 		Status:   p.Status,
 		ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{
 			Message: p.ResponseMessage, Status: p.Status, Payload: p.Payload},
@@ -8,6 +8,7 @@ package orderer
 
 import (
 	reqContext "context"
+	"crypto/tls"
 	"crypto/x509"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 )
 
@@ -47,7 +49,9 @@ type Orderer struct {
 	dialTimeout    time.Duration
 	failFast       bool
 	allowInsecure  bool
+	serviceConfig  *comm.ServiceConfig
 	commManager    fab.CommManager
+	tlsConfig      *tls.Config // nil if the connection isn't secured
 }
 
 // Option describes a functional parameter for the New constructor
@@ -79,12 +83,14 @@ func New(config fab.EndpointConfig, opts ...Option) (*Orderer, error) {
 			return nil, err
 		}
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		orderer.tlsConfig = tlsConfig
 	} else {
 		grpcOpts = append(grpcOpts, grpc.WithInsecure())
 	}
 
 	grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxCallRecvMsgSize),
 		grpc.MaxCallSendMsgSize(maxCallSendMsgSize)))
+	grpcOpts = append(grpcOpts, orderer.serviceConfig.DialOptions()...)
 
 	orderer.dialTimeout = config.TimeoutOrDefault(fab.OrdererConnection)
 	orderer.url = endpoint.ToAddress(orderer.url)
@@ -152,6 +158,11 @@ func FromOrdererConfig(ordererCfg *fab.OrdererConfig) Option {
 		o.failFast = getFailFast(ordererCfg)
 		o.allowInsecure = isInsecureConnectionAllowed(ordererCfg)
 
+		o.serviceConfig, err = comm.ServiceConfigFromGRPCOptions(ordererCfg.GRPCOptions)
+		if err != nil {
+			return errors.WithMessage(err, "invalid service config for orderer "+ordererCfg.URL)
+		}
+
 		return nil
 	}
 }
@@ -215,6 +226,17 @@ func (o *Orderer) conn(ctx reqContext.Context) (*grpc.ClientConn, error) {
 	ctx, cancel := reqContext.WithTimeout(ctx, o.dialTimeout)
 	defer cancel()
 
+	clientCert, err := o.requestClientCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if clientCert != nil {
+		// A dedicated connection is used since cached connections present the client certificate from the config
+		grpcOpts := append([]grpc.DialOption{}, o.grpcDialOption...)
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(comm.ClientCertTLSConfig(o.tlsConfig, clientCert))))
+		return (&defCommManager{}).DialContext(ctx, o.url, grpcOpts...)
+	}
+
 	commManager, ok := context.RequestCommManager(ctx)
 	if !ok {
 		commManager = o.commManager
@@ -224,6 +246,11 @@ func (o *Orderer) conn(ctx reqContext.Context) (*grpc.ClientConn, error) {
 }
 
 func (o *Orderer) releaseConn(ctx reqContext.Context, conn *grpc.ClientConn) {
+	if clientCert, err := o.requestClientCert(ctx); err == nil && clientCert != nil {
+		(&defCommManager{}).ReleaseConn(conn)
+		return
+	}
+
 	commManager, ok := context.RequestCommManager(ctx)
 	if !ok {
 		commManager = o.commManager
@@ -232,6 +259,15 @@ func (o *Orderer) releaseConn(ctx reqContext.Context, conn *grpc.ClientConn) {
 	commManager.ReleaseConn(conn)
 }
 
+//requestClientCert returns the TLS client certificate of the identity of the request, or nil if the identity
+//doesn't have its own TLS client certificate or the connection isn't secured
+func (o *Orderer) requestClientCert(ctx reqContext.Context) (*tls.Certificate, error) {
+	if o.tlsConfig == nil {
+		return nil, nil
+	}
+	return context.RequestTLSClientCert(ctx)
+}
+
 // URL Get the Orderer url. Required property for the instance objects.
 // Returns the address of the Orderer.
 func (o *Orderer) URL() string {
@@ -247,7 +283,7 @@ func (o *Orderer) SendBroadcast(ctx reqContext.Context, envelope *fab.SignedEnve
 			return nil, errors.WithMessage(status.NewFromGRPCStatus(rpcStatus), "connection failed")
 		}
 
-		return nil, status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), nil)
+		return nil, status.New(status.OrdererClientStatus, fabcomm.DialErrorCode(err).ToInt32(), err.Error(), nil)
 	}
 	defer o.releaseConn(ctx, conn)
 
@@ -320,7 +356,7 @@ func (o *Orderer) SendDeliver(ctx reqContext.Context, envelope *fab.SignedEnvelo
 			return responses, errs
 		}
 
-		errs <- status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), nil)
+		errs <- status.New(status.OrdererClientStatus, fabcomm.DialErrorCode(err).ToInt32(), err.Error(), nil)
 		return responses, errs
 	}
 
@@ -0,0 +1,251 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package orderer
+
+import (
+	"bytes"
+	reqContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/test/mockfab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
+	mocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+//tlsBindingBroadcastServer only delivers blocks if the TLS cert hash of the seek envelope
+//matches the client certificate of the TLS handshake
+type tlsBindingBroadcastServer struct {
+	mocks.MockBroadcastServer
+}
+
+func (s *tlsBindingBroadcastServer) Deliver(server ab.AtomicBroadcast_DeliverServer) error {
+	envelope, err := server.Recv()
+	if err != nil {
+		return err
+	}
+
+	p, ok := peer.FromContext(server.Context())
+	if !ok {
+		return errors.New("no peer in context")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return errors.New("no client certificate presented")
+	}
+	certHash := sha256.Sum256(tlsInfo.State.PeerCertificates[0].Raw)
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return err
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return err
+	}
+	if !bytes.Equal(channelHeader.TlsCertHash, certHash[:]) {
+		return server.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Status{Status: common.Status_FORBIDDEN}})
+	}
+
+	return server.Send(mocks.TestBlock)
+}
+
+func TestSendBroadcastAndDeliverWithMutualTLS(t *testing.T) {
+	serverCert, serverCA := newTestCert(t, x509.ExtKeyUsageServerAuth)
+	clientCert, clientCA := newTestCert(t, x509.ExtKeyUsageClientAuth)
+
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    certPool(clientCA),
+	})))
+	defer grpcServer.Stop()
+	ab.RegisterAtomicBroadcastServer(grpcServer, &tlsBindingBroadcastServer{})
+	lis, err := net.Listen("tcp", testOrdererURL)
+	if err != nil {
+		t.Fatalf("Error starting test server %s", err)
+	}
+	addr := lis.Addr().String()
+	go grpcServer.Serve(lis)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(5 * time.Second).AnyTimes()
+	config.EXPECT().TLSCACertPool(gomock.Any()).Return(certPool(serverCA), nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return([]tls.Certificate{clientCert}, nil).AnyTimes()
+
+	orderer, err := New(config, WithURL("grpcs://"+addr), WithServerName("localhost"))
+	assert.Nil(t, err)
+
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = orderer.SendBroadcast(ctx, &fab.SignedEnvelope{})
+	assert.Nil(t, err, "Expected broadcast with client certificate to succeed")
+
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{TlsCertHash: comm.TLSCertHash(config)})
+	assert.Nil(t, err)
+	payload, err := proto.Marshal(&common.Payload{Header: &common.Header{ChannelHeader: channelHeader}})
+	assert.Nil(t, err)
+
+	blocks, errs := orderer.SendDeliver(ctx, &fab.SignedEnvelope{Payload: payload})
+	select {
+	case block := <-blocks:
+		assert.NotNil(t, block, "Expected block to be delivered over the TLS bound stream")
+	case err := <-errs:
+		t.Fatalf("Unexpected error from SendDeliver(): %s", err)
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for block")
+	}
+}
+
+func TestSendBroadcastWithoutClientCert(t *testing.T) {
+	serverCert, serverCA := newTestCert(t, x509.ExtKeyUsageServerAuth)
+	_, clientCA := newTestCert(t, x509.ExtKeyUsageClientAuth)
+
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    certPool(clientCA),
+	})))
+	defer grpcServer.Stop()
+	addr := startCustomizedMockServer(t, testOrdererURL, grpcServer, &mocks.MockBroadcastServer{})
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(time.Second).AnyTimes()
+	config.EXPECT().TLSCACertPool(gomock.Any()).Return(certPool(serverCA), nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return(nil, nil).AnyTimes()
+
+	orderer, err := New(config, WithURL("grpcs://"+addr), WithServerName("localhost"))
+	assert.Nil(t, err)
+
+	_, err = orderer.SendBroadcast(reqContext.Background(), &fab.SignedEnvelope{})
+	assert.NotNil(t, err, "Expected broadcast to fail since the orderer requires a client certificate")
+}
+
+func TestSendBroadcastAndDeliverWithIdentityTLSCert(t *testing.T) {
+	serverCert, serverCA := newTestCert(t, x509.ExtKeyUsageServerAuth)
+	clientCert, clientCA := newTestCert(t, x509.ExtKeyUsageClientAuth)
+
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    certPool(clientCA),
+	})))
+	defer grpcServer.Stop()
+	ab.RegisterAtomicBroadcastServer(grpcServer, &tlsBindingBroadcastServer{})
+	lis, err := net.Listen("tcp", testOrdererURL)
+	if err != nil {
+		t.Fatalf("Error starting test server %s", err)
+	}
+	addr := lis.Addr().String()
+	go grpcServer.Serve(lis)
+
+	// No client certificate is configured, the identity presents its own
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockfab.NewMockEndpointConfig(mockCtrl)
+	config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(5 * time.Second).AnyTimes()
+	config.EXPECT().TLSCACertPool(gomock.Any()).Return(certPool(serverCA), nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return(nil, nil).AnyTimes()
+
+	orderer, err := New(config, WithURL("grpcs://"+addr), WithServerName("localhost"))
+	assert.Nil(t, err)
+
+	user := &tlsIdentity{SigningIdentity: mspmocks.NewMockSigningIdentity("user1", "Org1MSP"), cert: &clientCert}
+	ctx, cancel := contextImpl.NewRequest(&contextImpl.Client{Providers: mocks.NewMockProviderContext(), SigningIdentity: user}, contextImpl.WithTimeout(5*time.Second))
+	defer cancel()
+
+	_, err = orderer.SendBroadcast(ctx, &fab.SignedEnvelope{})
+	assert.Nil(t, err, "Expected broadcast with the client certificate of the identity to succeed")
+
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{TlsCertHash: comm.ClientCertHash(&clientCert)})
+	assert.Nil(t, err)
+	payload, err := proto.Marshal(&common.Payload{Header: &common.Header{ChannelHeader: channelHeader}})
+	assert.Nil(t, err)
+
+	blocks, errs := orderer.SendDeliver(ctx, &fab.SignedEnvelope{Payload: payload})
+	select {
+	case block := <-blocks:
+		assert.NotNil(t, block, "Expected block to be delivered over the TLS bound stream")
+	case err := <-errs:
+		t.Fatalf("Unexpected error from SendDeliver(): %s", err)
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for block")
+	}
+}
+
+// tlsIdentity is a signing identity with its own TLS client certificate
+type tlsIdentity struct {
+	msp.SigningIdentity
+	cert *tls.Certificate
+}
+
+func (i *tlsIdentity) TLSClientCertificate() (*tls.Certificate, error) {
+	return i.cert, nil
+}
+
+func newTestCert(t *testing.T, usage x509.ExtKeyUsage) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+func certPool(certs ...*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
+}
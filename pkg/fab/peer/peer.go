@@ -11,6 +11,7 @@ import (
 
 	"crypto/x509"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -18,6 +19,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 )
 
 var logger = logging.NewLogger("fabsdk/fab")
@@ -25,16 +27,17 @@ var logger = logging.NewLogger("fabsdk/fab")
 // Peer represents a node in the target blockchain network to which
 // HFC sends endorsement proposals, transaction ordering or query requests.
 type Peer struct {
-	config      fab.EndpointConfig
-	certificate *x509.Certificate
-	serverName  string
-	processor   fab.ProposalProcessor
-	mspID       string
-	url         string
-	kap         keepalive.ClientParameters
-	failFast    bool
-	inSecure    bool
-	commManager fab.CommManager
+	config        fab.EndpointConfig
+	certificate   *x509.Certificate
+	serverName    string
+	processor     fab.ProposalProcessor
+	mspID         string
+	url           string
+	kap           keepalive.ClientParameters
+	failFast      bool
+	inSecure      bool
+	serviceConfig *comm.ServiceConfig
+	commManager   fab.CommManager
 }
 
 // Option describes a functional parameter for the New constructor
@@ -65,6 +68,7 @@ func New(config fab.EndpointConfig, opts ...Option) (*Peer, error) {
 			kap:                peer.kap,
 			failFast:           peer.failFast,
 			allowInsecure:      peer.inSecure,
+			serviceConfig:      peer.serviceConfig,
 			commManager:        peer.commManager,
 		}
 		processor, err := newPeerEndorser(&endorseRequest)
@@ -147,6 +151,11 @@ func FromPeerConfig(peerCfg *fab.NetworkPeer) Option {
 		p.mspID = peerCfg.MSPID
 		p.kap = getKeepAliveOptions(peerCfg)
 		p.failFast = getFailFast(peerCfg)
+
+		p.serviceConfig, err = comm.ServiceConfigFromGRPCOptions(peerCfg.GRPCOptions)
+		if err != nil {
+			return errors.WithMessage(err, "invalid service config for peer "+peerCfg.URL)
+		}
 		return nil
 	}
 }
@@ -214,7 +223,11 @@ func (p *Peer) URL() string {
 
 // ProcessTransactionProposal sends the created proposal to peer for endorsement.
 func (p *Peer) ProcessTransactionProposal(ctx reqContext.Context, proposal fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
-	return p.processor.ProcessTransactionProposal(ctx, proposal)
+	resp, err := p.processor.ProcessTransactionProposal(ctx, proposal)
+	if resp != nil {
+		resp.MSPID = p.mspID
+	}
+	return resp, err
 }
 
 func (p *Peer) String() string {
@@ -7,8 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package peer
 
 import (
+	"bytes"
 	reqContext "context"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
+	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +30,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/endpoint"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
@@ -37,10 +43,12 @@ const (
 
 // peerEndorser enables access to a GRPC-based endorser for running transaction proposal simulations
 type peerEndorser struct {
-	grpcDialOption []grpc.DialOption
-	target         string
-	dialTimeout    time.Duration
-	commManager    fab.CommManager
+	grpcDialOption   []grpc.DialOption
+	target           string
+	dialTimeout      time.Duration
+	handshakeTimeout time.Duration
+	commManager      fab.CommManager
+	tlsConfig        *tls.Config // nil if the connection isn't secured
 }
 
 type peerEndorserRequest struct {
@@ -51,6 +59,7 @@ type peerEndorserRequest struct {
 	kap                keepalive.ClientParameters
 	failFast           bool
 	allowInsecure      bool
+	serviceConfig      *comm.ServiceConfig
 	commManager        fab.CommManager
 }
 
@@ -61,31 +70,54 @@ func newPeerEndorser(endorseReq *peerEndorserRequest) (*peerEndorser, error) {
 
 	// Construct dialer options for the connection
 	var grpcOpts []grpc.DialOption
+	var handshakeTimeout time.Duration
+	var tlsConfig *tls.Config
 	if endorseReq.kap.Time > 0 {
 		grpcOpts = append(grpcOpts, grpc.WithKeepaliveParams(endorseReq.kap))
 	}
 	grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.FailFast(endorseReq.failFast)))
 
 	if endpoint.AttemptSecured(endorseReq.target, endorseReq.allowInsecure) {
-		tlsConfig, err := comm.TLSConfig(endorseReq.certificate, endorseReq.serverHostOverride, endorseReq.config)
+		var err error
+		tlsConfig, err = comm.TLSConfig(endorseReq.certificate, endorseReq.serverHostOverride, endorseReq.config)
 		if err != nil {
 			return nil, err
 		}
-		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		handshakeTimeout = endorseReq.config.TimeoutOrDefault(fab.PeerHandshake)
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(&handshakeTimeoutCredentials{
+			TransportCredentials: credentials.NewTLS(tlsConfig),
+			timeout:              handshakeTimeout,
+		}))
 	} else {
 		grpcOpts = append(grpcOpts, grpc.WithInsecure())
 	}
 
 	grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxCallRecvMsgSize),
 		grpc.MaxCallSendMsgSize(maxCallSendMsgSize)))
+	grpcOpts = append(grpcOpts, endorseReq.serviceConfig.DialOptions()...)
 
 	timeout := endorseReq.config.TimeoutOrDefault(fab.EndorserConnection)
 
+	network := "tcp"
+	if endpoint.IsUnixSocket(endorseReq.target) {
+		network = "unix"
+	}
+
+	// The dial timeout only covers the connect, the TLS handshake has its own deadline
+	grpcOpts = append(grpcOpts, grpc.WithDialer(func(addr string, t time.Duration) (net.Conn, error) {
+		if t <= 0 || t > timeout {
+			t = timeout
+		}
+		return net.DialTimeout(network, addr, t)
+	}))
+
 	pc := &peerEndorser{
-		grpcDialOption: grpcOpts,
-		target:         endpoint.ToAddress(endorseReq.target),
-		dialTimeout:    timeout,
-		commManager:    endorseReq.commManager,
+		grpcDialOption:   grpcOpts,
+		target:           endpoint.ToAddress(endorseReq.target),
+		dialTimeout:      timeout,
+		handshakeTimeout: handshakeTimeout,
+		commManager:      endorseReq.commManager,
+		tlsConfig:        tlsConfig,
 	}
 
 	return pc, nil
@@ -93,7 +125,11 @@ func newPeerEndorser(endorseReq *peerEndorserRequest) (*peerEndorser, error) {
 
 // ProcessTransactionProposal sends the transaction proposal to a peer and returns the response.
 func (p *peerEndorser) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
-	logger.Debugf("Processing proposal using endorser: %s", p.target)
+	if correlationID, ok := context.RequestCorrelationID(ctx); ok {
+		logger.Debugf("Processing proposal using endorser: %s [correlation ID: %s]", p.target, correlationID)
+	} else {
+		logger.Debugf("Processing proposal using endorser: %s", p.target)
+	}
 
 	proposalResponse, err := p.sendProposal(ctx, request)
 	if err != nil {
@@ -116,10 +152,27 @@ func (p *peerEndorser) conn(ctx reqContext.Context) (*grpc.ClientConn, error) {
 		commManager = p.commManager
 	}
 
-	ctx, cancel := reqContext.WithTimeout(ctx, p.dialTimeout)
+	ctx, cancel := reqContext.WithTimeout(ctx, p.dialTimeout+p.handshakeTimeout)
+	defer cancel()
+
+	return commManager.DialContext(fabcomm.ContextWithConnectTimeout(ctx, p.dialTimeout), p.target, p.grpcDialOption...)
+}
+
+// handshakeTimeoutCredentials applies a deadline to the TLS handshake which is independent of the dial timeout
+type handshakeTimeoutCredentials struct {
+	credentials.TransportCredentials
+	timeout time.Duration
+}
+
+func (c *handshakeTimeoutCredentials) ClientHandshake(ctx reqContext.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	ctx, cancel := reqContext.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
-	return commManager.DialContext(ctx, p.target, p.grpcDialOption...)
+	return c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+}
+
+func (c *handshakeTimeoutCredentials) Clone() credentials.TransportCredentials {
+	return &handshakeTimeoutCredentials{TransportCredentials: c.TransportCredentials.Clone(), timeout: c.timeout}
 }
 
 func (p *peerEndorser) releaseConn(ctx reqContext.Context, conn *grpc.ClientConn) {
@@ -131,17 +184,112 @@ func (p *peerEndorser) releaseConn(ctx reqContext.Context, conn *grpc.ClientConn
 	commManager.ReleaseConn(conn)
 }
 
+// requestTLSConfig returns the TLS config of a dedicated connection for the request, or nil if the request may use
+// a cached connection. A dedicated connection is needed if the peer's TLS certificate is pinned, since the TLS
+// handshake of a cached connection may not have been verified against the fingerprint, or if the identity of the
+// request has its own TLS client certificate, since cached connections present the client certificate from the config.
+func (p *peerEndorser) requestTLSConfig(ctx reqContext.Context) (*tls.Config, error) {
+	fingerprint, pinned := context.RequestTLSPin(ctx, p.target)
+	if p.tlsConfig == nil {
+		if pinned {
+			return nil, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(),
+				"TLS certificate pinned for a target which isn't secured", []interface{}{p.target})
+		}
+		return nil, nil
+	}
+
+	clientCert, err := context.RequestTLSClientCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !pinned && clientCert == nil {
+		return nil, nil
+	}
+
+	var tlsConfig *tls.Config
+	if clientCert != nil {
+		tlsConfig = comm.ClientCertTLSConfig(p.tlsConfig, clientCert)
+	} else {
+		tlsConfig = p.tlsConfig.Clone()
+	}
+	if pinned {
+		tlsConfig.VerifyPeerCertificate = verifyFingerprint(fingerprint)
+	}
+	return tlsConfig, nil
+}
+
+// dedicatedConn establishes a new connection with the given TLS config, it's closed once the request is done
+func (p *peerEndorser) dedicatedConn(ctx reqContext.Context, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
+	grpcOpts := append([]grpc.DialOption{}, p.grpcDialOption...)
+	grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(&handshakeTimeoutCredentials{
+		TransportCredentials: credentials.NewTLS(tlsConfig),
+		timeout:              p.handshakeTimeout,
+	}), grpc.WithBlock(), grpc.FailOnNonTempDialError(true))
+
+	ctx, cancel := reqContext.WithTimeout(ctx, p.dialTimeout+p.handshakeTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, p.target, grpcOpts...)
+	if err != nil {
+		return nil, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), []interface{}{p.target})
+	}
+	return conn, nil
+}
+
+// tlsPinError is returned from the TLS handshake if the peer's certificate doesn't match the pinned fingerprint.
+// The error isn't temporary so that the dial fails without retrying the handshake.
+type tlsPinError string
+
+func (e tlsPinError) Error() string {
+	return string(e)
+}
+
+func (e tlsPinError) Temporary() bool {
+	return false
+}
+
+// verifyFingerprint returns a TLS verification callback which checks the SHA-256 fingerprint of the leaf certificate
+func verifyFingerprint(fingerprint []byte) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return tlsPinError("no TLS certificate presented by the peer")
+		}
+		actual := sha256.Sum256(rawCerts[0])
+		if !bytes.Equal(actual[:], fingerprint) {
+			return tlsPinError(fmt.Sprintf("TLS certificate fingerprint [%x] doesn't match the pinned fingerprint [%x]", actual, fingerprint))
+		}
+		return nil
+	}
+}
+
 func (p *peerEndorser) sendProposal(ctx reqContext.Context, proposal fab.ProcessProposalRequest) (*pb.ProposalResponse, error) {
+	tlsConfig, err := p.requestTLSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		conn, err := p.dedicatedConn(ctx, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return p.processProposal(ctx, conn, proposal)
+	}
+
 	conn, err := p.conn(ctx)
 	if err != nil {
 		rpcStatus, ok := grpcstatus.FromError(err)
 		if ok {
 			return nil, errors.WithMessage(status.NewFromGRPCStatus(rpcStatus), "connection failed")
 		}
-		return nil, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), []interface{}{p.target})
+		return nil, status.New(status.EndorserClientStatus, fabcomm.DialErrorCode(err).ToInt32(), err.Error(), []interface{}{p.target})
 	}
 	defer p.releaseConn(ctx, conn)
 
+	return p.processProposal(ctx, conn, proposal)
+}
+
+func (p *peerEndorser) processProposal(ctx reqContext.Context, conn *grpc.ClientConn, proposal fab.ProcessProposalRequest) (*pb.ProposalResponse, error) {
 	endorserClient := pb.NewEndorserClient(conn)
 	resp, err := endorserClient.ProcessProposal(ctx, proposal.SignedProposal)
 
@@ -166,7 +314,29 @@ func (p *peerEndorser) sendProposal(ctx reqContext.Context, proposal fab.Process
 	return resp, err
 }
 
+// extractChaincodeError extracts the chaincode response code and message from the GRPC status. Structured
+// status details are decoded first; the status message is only parsed for peers that don't provide them.
 func extractChaincodeError(status *grpcstatus.Status) (int, string, error) {
+	code, message, err := extractChaincodeErrorFromDetails(status)
+	if err == nil {
+		return code, message, nil
+	}
+	return extractChaincodeErrorFromMessage(status)
+}
+
+// extractChaincodeErrorFromDetails decodes the chaincode response carried in the GRPC status details
+func extractChaincodeErrorFromDetails(status *grpcstatus.Status) (int, string, error) {
+	for _, detail := range status.Details() {
+		resp, ok := detail.(*pb.Response)
+		if ok && resp.Status != 0 {
+			return int(resp.Status), resp.Message, nil
+		}
+	}
+	return 0, "", errors.New("GRPC status details do not contain a chaincode response")
+}
+
+// extractChaincodeErrorFromMessage parses the chaincode response out of the GRPC status message
+func extractChaincodeErrorFromMessage(status *grpcstatus.Status) (int, string, error) {
 	var code int
 	var message string
 	if status.Code().String() != "Unknown" || status.Message() == "" {
@@ -202,15 +372,17 @@ func extractChaincodeError(status *grpcstatus.Status) (int, string, error) {
 	return code, message, errors.Errorf("Unable to parse GRPC Status Message Code: %v Message: %v", code, message)
 }
 
+// extractPrematureExecutionError extracts the PrematureChaincodeExecution code and the message from the GRPC
+// status with which a peer rejects a proposal for a chaincode which isn't ready yet
 func extractPrematureExecutionError(grpcstat *grpcstatus.Status) (int32, string, error) {
 	if grpcstat.Code().String() != "Unknown" || grpcstat.Message() == "" {
 		return 0, "", errors.New("not a premature execution error")
 	}
-	index := strings.Index(grpcstat.Message(), "premature execution")
-	if index == -1 {
+	s, ok := status.NewFromPrematureExecution(grpcstat.Message(), nil)
+	if !ok {
 		return 0, "", errors.New("not a premature execution error")
 	}
-	return int32(status.PrematureChaincodeExecution), grpcstat.Message()[index:], nil
+	return s.Code, s.Message, nil
 }
 
 // getChaincodeResponseStatus gets the actual response status from response.Payload.extension.Response.status, as fabric always returns actual 200
@@ -8,9 +8,19 @@ package peer
 
 import (
 	reqContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -19,15 +29,20 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 	grpcstatus "google.golang.org/grpc/status"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/test/mockfab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
 )
 
 const (
@@ -202,6 +217,37 @@ func TestProcessProposalGoodDial(t *testing.T) {
 	}
 }
 
+// TestProcessProposalIPv6 validates that an endorser is dialed at a bracketed IPv6 address
+func TestProcessProposalIPv6(t *testing.T) {
+	lis, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %s", err)
+	}
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	pb.RegisterEndorserServer(grpcServer, &mocks.MockEndorserServer{})
+	go grpcServer.Serve(lis)
+
+	addr := lis.Addr().String()
+	for _, url := range []string{addr, "grpc://" + addr, "GRPC://" + addr} {
+		mockCtrl := gomock.NewController(t)
+		config := mockfab.DefaultMockConfig(mockCtrl)
+		config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(time.Second * 1).AnyTimes()
+
+		conn, err := newPeerEndorser(getPeerEndorserRequest(url, nil, "", config, kap, false, true))
+		if err != nil {
+			t.Fatalf("Peer conn construction error (%v)", err)
+		}
+		assert.Equal(t, addr, conn.target, "Expected the brackets of the IPv6 host to be kept")
+
+		ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+		_, err = conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+		cancel()
+		mockCtrl.Finish()
+		assert.Nil(t, err, "Process proposal failed for %s", url)
+	}
+}
+
 func testProcessProposal(t *testing.T, url string) (*fab.TransactionProposalResponse, error) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -258,6 +304,98 @@ func startEndorserServerWithError(t *testing.T, grpcServer *grpc.Server, testErr
 	return endorserServer, addr
 }
 
+func TestProcessProposalUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerendorser")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "peer.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %s", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	pb.RegisterEndorserServer(grpcServer, &mocks.MockEndorserServer{})
+	go grpcServer.Serve(lis)
+
+	// TLS is skipped for unix sockets even if insecure connections aren't allowed
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockfab.DefaultMockConfig(mockCtrl)
+
+	conn, err := newPeerEndorser(getPeerEndorserRequest("unix://"+socket, nil, "", config, kap, false, false))
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+	assert.Equal(t, socket, conn.target)
+
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+	defer cancel()
+	_, err = conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+	assert.Nil(t, err, "Process proposal over unix socket failed")
+}
+
+// goAwayEndorserServer fails the first calls as if the server had sent a GOAWAY while the call was in flight
+type goAwayEndorserServer struct {
+	mocks.MockEndorserServer
+	failures int
+	calls    int
+}
+
+func (s *goAwayEndorserServer) ProcessProposal(ctx reqContext.Context, proposal *pb.SignedProposal) (*pb.ProposalResponse, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, grpcstatus.Error(grpcCodes.Unavailable, "transport is closing")
+	}
+	return s.MockEndorserServer.ProcessProposal(ctx, proposal)
+}
+
+func TestProcessProposalServiceConfigRetry(t *testing.T) {
+	lis, err := net.Listen("tcp", testAddress)
+	if err != nil {
+		t.Fatalf("Error starting test server %s", err)
+	}
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	endorserServer := &goAwayEndorserServer{failures: 1}
+	pb.RegisterEndorserServer(grpcServer, endorserServer)
+	go grpcServer.Serve(lis)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mockfab.DefaultMockConfig(mockCtrl)
+
+	// Without a service config the GOAWAY surfaces to the caller
+	conn, err := newPeerEndorser(getPeerEndorserRequest("grpc://"+lis.Addr().String(), nil, "", config, kap, false, true))
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+	_, err = conn.ProcessTransactionProposal(reqContext.Background(), mockProcessProposalRequest())
+	assert.Error(t, err, "Expected unavailable error without service config")
+
+	serviceConfig, err := comm.ParseServiceConfig(comm.DefaultServiceConfig)
+	if err != nil {
+		t.Fatalf("Failed to parse service config: %s", err)
+	}
+	endorseReq := getPeerEndorserRequest("grpc://"+lis.Addr().String(), nil, "", config, kap, false, true)
+	endorseReq.serviceConfig = serviceConfig
+	conn, err = newPeerEndorser(endorseReq)
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+
+	endorserServer.calls = 0
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+	defer cancel()
+	_, err = conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+	assert.NoError(t, err, "Expected GOAWAY to be retried transparently")
+	assert.Equal(t, 2, endorserServer.calls)
+}
+
 func TestEndorserConnectionError(t *testing.T) {
 	_, err := testProcessProposal(t, "grpc://"+testAddress)
 	assert.NotNil(t, err, "Expected connection error without server running")
@@ -285,6 +423,26 @@ func TestEndorserRPCError(t *testing.T) {
 	assert.Equal(t, grpcCodes.Unknown, grpcCode)
 }
 
+func TestHandshakeTimeout(t *testing.T) {
+	creds := &handshakeTimeoutCredentials{
+		TransportCredentials: credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}),
+		timeout:              50 * time.Millisecond,
+	}
+
+	// The server end never responds so the handshake can only end by timing out
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	start := time.Now()
+	_, _, err := creds.ClientHandshake(reqContext.Background(), "peer", clientConn)
+	assert.NotNil(t, err, "Expected handshake to time out")
+	assert.True(t, time.Since(start) < 5*time.Second, "Expected handshake to honor the handshake timeout")
+
+	clone, ok := creds.Clone().(*handshakeTimeoutCredentials)
+	assert.True(t, ok, "Expected clone to keep the handshake timeout")
+	assert.Equal(t, creds.timeout, clone.timeout)
+}
+
 func TestExtractChainCodeError(t *testing.T) {
 	expectedMsg := "Chaincode error(status: 500, message: Invalid function (dummy) call)"
 	error := grpcstatus.New(grpcCodes.Unknown, expectedMsg)
@@ -297,6 +455,32 @@ func TestExtractChainCodeError(t *testing.T) {
 	}
 }
 
+func TestExtractChainCodeErrorFromDetails(t *testing.T) {
+	grpcStatus, err := grpcstatus.New(grpcCodes.Unknown, "unparseable error format").
+		WithDetails(&pb.Response{Status: 500, Message: "Invalid function (dummy) call"})
+	if err != nil {
+		t.Fatalf("Failed to add status details: %s", err)
+	}
+
+	code, message, err := extractChaincodeError(grpcStatus)
+	assert.Nil(t, err, "Expected chaincode error to be extracted from details")
+	assert.Equal(t, 500, code)
+	assert.Equal(t, "Invalid function (dummy) call", message)
+
+	grpcStatus, err = grpcstatus.New(grpcCodes.Unknown, "Chaincode error(status: 404, message: not found)").
+		WithDetails(&pb.Response{Status: 500, Message: "Invalid function (dummy) call"})
+	if err != nil {
+		t.Fatalf("Failed to add status details: %s", err)
+	}
+
+	code, _, err = extractChaincodeError(grpcStatus)
+	assert.Nil(t, err, "Expected chaincode error to be extracted from details")
+	assert.Equal(t, 500, code, "Expected details to take precedence over the status message")
+
+	_, _, err = extractChaincodeError(grpcstatus.New(grpcCodes.Unknown, "unparseable error format"))
+	assert.NotNil(t, err, "Expected error for status without details or parseable message")
+}
+
 func TestExtractPrematureExecError(t *testing.T) {
 	err := grpcstatus.New(grpcCodes.Unknown, "some error")
 	_, _, e := extractPrematureExecutionError(err)
@@ -312,3 +496,145 @@ func TestExtractPrematureExecError(t *testing.T) {
 	assert.EqualValues(t, int32(status.PrematureChaincodeExecution), code, "Expected premature execution error")
 	assert.EqualValues(t, "premature execution - chaincode (somecc:v1) launched and waiting for registration", message, "Invalid message")
 }
+
+func TestProcessProposalTLSPin(t *testing.T) {
+	serverCert, err := newTestServerCert()
+	if err != nil {
+		t.Fatalf("Failed to create server certificate: %s", err)
+	}
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	defer grpcServer.Stop()
+	_, addr := startEndorserServer(t, grpcServer)
+
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse server certificate: %s", err)
+	}
+	certPool := x509.NewCertPool()
+	certPool.AddCert(leaf)
+
+	conn := &peerEndorser{
+		target:           addr,
+		dialTimeout:      time.Second,
+		handshakeTimeout: time.Second,
+		commManager:      &defCommManager{},
+		tlsConfig:        &tls.Config{RootCAs: certPool, ServerName: "localhost"},
+	}
+
+	ctx, cancel := reqContext.WithTimeout(reqContext.Background(), normalTimeout)
+	defer cancel()
+
+	fingerprint := sha256.Sum256(serverCert.Certificate[0])
+	_, err = conn.ProcessTransactionProposal(tlsPinContext(ctx, addr, fingerprint[:]), mockProcessProposalRequest())
+	assert.Nil(t, err, "Expected proposal to succeed with the certificate's fingerprint")
+
+	otherFingerprint := sha256.Sum256([]byte("other certificate"))
+	_, err = conn.ProcessTransactionProposal(tlsPinContext(ctx, addr, otherFingerprint[:]), mockProcessProposalRequest())
+	assert.NotNil(t, err, "Expected proposal to fail with a different fingerprint")
+	statusError, ok := status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.ConnectionFailed, statusError.Code)
+
+	// Pinning requires a secured connection
+	conn.tlsConfig = nil
+	_, err = conn.ProcessTransactionProposal(tlsPinContext(ctx, addr, fingerprint[:]), mockProcessProposalRequest())
+	statusError, ok = status.FromError(err)
+	assert.True(t, ok, "Expected status error")
+	assert.EqualValues(t, status.ConnectionFailed, statusError.Code)
+}
+
+func tlsPinContext(ctx reqContext.Context, target string, fingerprint []byte) reqContext.Context {
+	return reqContext.WithValue(ctx, contextImpl.ReqContextTLSPins, map[string][]byte{target: fingerprint})
+}
+
+func TestProcessProposalIdentityTLSCert(t *testing.T) {
+	serverCert, err := newTestServerCert()
+	if err != nil {
+		t.Fatalf("Failed to create server certificate: %s", err)
+	}
+	clientCert, err := newTestCert(x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		t.Fatalf("Failed to create client certificate: %s", err)
+	}
+	clientLeaf, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse client certificate: %s", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientLeaf)
+
+	// The peer only accepts the TLS client certificate of the identity
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})))
+	defer grpcServer.Stop()
+	_, addr := startEndorserServer(t, grpcServer)
+
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse server certificate: %s", err)
+	}
+	certPool := x509.NewCertPool()
+	certPool.AddCert(leaf)
+
+	conn := &peerEndorser{
+		target:           addr,
+		dialTimeout:      time.Second,
+		handshakeTimeout: time.Second,
+		commManager:      &defCommManager{},
+		tlsConfig:        &tls.Config{RootCAs: certPool, ServerName: "localhost"},
+	}
+	conn.grpcDialOption = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(conn.tlsConfig))}
+
+	user := mspmocks.NewMockSigningIdentity("user1", "Org1MSP")
+	ctx, cancel := contextImpl.NewRequest(&contextImpl.Client{Providers: mocks.NewMockProviderContext(), SigningIdentity: user}, contextImpl.WithTimeout(normalTimeout))
+	defer cancel()
+	_, err = conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+	assert.NotNil(t, err, "Expected proposal to fail without the client certificate of the identity")
+
+	tlsUser := &tlsIdentity{SigningIdentity: user, cert: &clientCert}
+	ctx, cancel = contextImpl.NewRequest(&contextImpl.Client{Providers: mocks.NewMockProviderContext(), SigningIdentity: tlsUser}, contextImpl.WithTimeout(normalTimeout))
+	defer cancel()
+	_, err = conn.ProcessTransactionProposal(ctx, mockProcessProposalRequest())
+	assert.Nil(t, err, "Expected proposal to succeed with the client certificate of the identity")
+}
+
+// tlsIdentity is a signing identity with its own TLS client certificate
+type tlsIdentity struct {
+	msp.SigningIdentity
+	cert *tls.Certificate
+}
+
+func (i *tlsIdentity) TLSClientCertificate() (*tls.Certificate, error) {
+	return i.cert, nil
+}
+
+func newTestServerCert() (tls.Certificate, error) {
+	return newTestCert(x509.ExtKeyUsageServerAuth)
+}
+
+func newTestCert(usage x509.ExtKeyUsage) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
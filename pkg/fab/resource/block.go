@@ -32,9 +32,13 @@ func retrieveBlock(reqCtx reqContext.Context, orderers []fab.Orderer, channel st
 		return nil, errors.Wrap(err, "generating TX ID failed")
 	}
 
+	certHash, err := tlsCertHash(reqCtx, ctx.EndpointConfig())
+	if err != nil {
+		return nil, err
+	}
 	channelHeaderOpts := txn.ChannelHeaderOpts{
 		TxnHeader:   th,
-		TLSCertHash: ccomm.TLSCertHash(ctx.EndpointConfig()),
+		TLSCertHash: certHash,
 	}
 	seekInfoHeader, err := txn.CreateChannelHeader(common.HeaderType_DELIVER_SEEK_INFO, channelHeaderOpts)
 	if err != nil {
@@ -97,3 +101,16 @@ func newNewestSeekPosition() *ab.SeekPosition {
 func newSpecificSeekPosition(index uint64) *ab.SeekPosition {
 	return &ab.SeekPosition{Type: &ab.SeekPosition_Specified{Specified: &ab.SeekSpecified{Number: index}}}
 }
+
+// tlsCertHash returns the hash of the TLS client certificate which is presented to the orderer on behalf of the
+// identity of the request: the identity's own TLS client certificate if it has one, the configured one otherwise
+func tlsCertHash(reqCtx reqContext.Context, config fab.EndpointConfig) ([]byte, error) {
+	cert, err := contextImpl.RequestTLSClientCert(reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		return ccomm.ClientCertHash(cert), nil
+	}
+	return ccomm.TLSCertHash(config), nil
+}
@@ -7,7 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package signingmgr
 
 import (
+	"sync"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 	"github.com/pkg/errors"
@@ -18,14 +21,58 @@ type SigningManager struct {
 	cryptoProvider core.CryptoSuite
 	hashOpts       core.HashOpts
 	signerOpts     core.SignerOpts
+	hashOverrides  map[string]core.HashOpts
+	hashByCurve    bool
+	lock           sync.RWMutex
+	keyHashOpts    map[string]core.HashOpts
+}
+
+// Option describes a functional parameter for the New constructor
+type Option func(*SigningManager) error
+
+// WithHashOverride overrides the hash used for the signatures of the given identity, which is otherwise
+// the configured hash (or the hash selected by the curve of its key, see WithHashByKeyCurve)
+func WithHashOverride(identity msp.SigningIdentity, hashOpts core.HashOpts) Option {
+	return func(mgr *SigningManager) error {
+		if identity == nil || identity.PrivateKey() == nil {
+			return errors.New("signing identity with a private key is required")
+		}
+		if hashOpts == nil {
+			return errors.New("hash options are required")
+		}
+		mgr.hashOverrides[string(identity.PrivateKey().SKI())] = hashOpts
+		return nil
+	}
+}
+
+// WithHashByKeyCurve selects the hash of the signatures according to the curve of the signing key, i.e. SHA-256
+// for P-256 keys and SHA-384 for P-384 keys, in the configured hash family. Note that Fabric MSPs verify SHA2
+// signatures over SHA-256 digests regardless of the curve, so this is only intended for peers which expect
+// otherwise. By default the configured hash is used for all keys.
+func WithHashByKeyCurve() Option {
+	return func(mgr *SigningManager) error {
+		mgr.hashByCurve = true
+		return nil
+	}
 }
 
 // New Constructor for a signing manager.
 // @param {BCCSP} cryptoProvider - crypto provider
 // @param {Config} config - configuration provider
 // @returns {SigningManager} new signing manager
-func New(cryptoProvider core.CryptoSuite) (*SigningManager, error) {
-	return &SigningManager{cryptoProvider: cryptoProvider, hashOpts: cryptosuite.GetSHAOpts()}, nil
+func New(cryptoProvider core.CryptoSuite, opts ...Option) (*SigningManager, error) {
+	mgr := &SigningManager{
+		cryptoProvider: cryptoProvider,
+		hashOpts:       cryptosuite.GetSHAOpts(),
+		hashOverrides:  make(map[string]core.HashOpts),
+		keyHashOpts:    make(map[string]core.HashOpts),
+	}
+	for _, opt := range opts {
+		if err := opt(mgr); err != nil {
+			return nil, err
+		}
+	}
+	return mgr, nil
 }
 
 // Sign will sign the given object using provided key
@@ -39,7 +86,12 @@ func (mgr *SigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
 		return nil, errors.New("key (for signing) required")
 	}
 
-	digest, err := mgr.cryptoProvider.Hash(object, mgr.hashOpts)
+	hashOpts, err := mgr.hashOptsForKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := mgr.cryptoProvider.Hash(object, hashOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -49,3 +101,37 @@ func (mgr *SigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
 	}
 	return signature, nil
 }
+
+// hashOptsForKey returns the hash options for signing with the key. Unless the hash is selected by the curve of
+// the key and the crypto suite exposes its hash family, the configured hash is used regardless of the key.
+func (mgr *SigningManager) hashOptsForKey(key core.Key) (core.HashOpts, error) {
+	ski := string(key.SKI())
+	if hashOpts, ok := mgr.hashOverrides[ski]; ok {
+		return hashOpts, nil
+	}
+	if !mgr.hashByCurve {
+		return mgr.hashOpts, nil
+	}
+
+	secConfig, ok := mgr.cryptoProvider.(cryptosuite.SecurityConfig)
+	if !ok || secConfig.HashFamily() == "" {
+		return mgr.hashOpts, nil
+	}
+
+	mgr.lock.RLock()
+	hashOpts, ok := mgr.keyHashOpts[ski]
+	mgr.lock.RUnlock()
+	if ok {
+		return hashOpts, nil
+	}
+
+	hashOpts, err := cryptosuite.GetHashOptsForKey(key, secConfig.HashFamily())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to select hash for signing key")
+	}
+
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+	mgr.keyHashOpts[ski] = hashOpts
+	return hashOpts, nil
+}
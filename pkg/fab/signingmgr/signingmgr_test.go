@@ -8,11 +8,21 @@ package signingmgr
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
 	"testing"
 
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	bccspwrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSigningManager(t *testing.T) {
@@ -48,3 +58,84 @@ func TestSigningManager(t *testing.T) {
 	}
 
 }
+
+type keyIdentity struct {
+	msp.SigningIdentity
+	key core.Key
+}
+
+func (id *keyIdentity) PrivateKey() core.Key {
+	return id.key
+}
+
+func TestSigningManagerHashByKey(t *testing.T) {
+	suite, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		t.Fatalf("Failed to create cryptosuite: %s", err)
+	}
+	p256, err := suite.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	p384, err := suite.KeyGen(&bccsp.ECDSAP384KeyGenOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+
+	signingMgr, err := New(suite)
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+
+	// By default the configured hash is used regardless of the curve, as expected by Fabric MSPs
+	object := []byte("Hello")
+	verifySignature(t, signingMgr, object, p256, crypto.SHA256)
+	verifySignature(t, signingMgr, object, p384, crypto.SHA256)
+
+	signingMgr, err = New(suite, WithHashByKeyCurve())
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+
+	// The suite is configured for security level 256, but P-384 keys sign SHA-384 digests
+	verifySignature(t, signingMgr, object, p256, crypto.SHA256)
+	verifySignature(t, signingMgr, object, p384, crypto.SHA384)
+
+	signingMgr, err = New(suite, WithHashByKeyCurve(), WithHashOverride(&keyIdentity{key: p384}, &bccsp.SHA256Opts{}))
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+	verifySignature(t, signingMgr, object, p384, crypto.SHA256)
+
+	_, err = New(suite, WithHashOverride(&keyIdentity{}, &bccsp.SHA256Opts{}))
+	assert.Error(t, err, "Expected error for identity without private key")
+}
+
+func verifySignature(t *testing.T, signingMgr *SigningManager, object []byte, key core.Key, hash crypto.Hash) {
+	signature, err := signingMgr.Sign(object, key)
+	if err != nil {
+		t.Fatalf("Failed to sign object: %s", err)
+	}
+
+	pubKey, err := key.PublicKey()
+	if err != nil {
+		t.Fatalf("Failed to get public key: %s", err)
+	}
+	raw, err := pubKey.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %s", err)
+	}
+	ecdsaKey, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %s", err)
+	}
+
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		t.Fatalf("Failed to unmarshal signature: %s", err)
+	}
+
+	h := hash.New()
+	h.Write(object)
+	assert.True(t, ecdsa.Verify(ecdsaKey.(*ecdsa.PublicKey), h.Sum(nil), sig.R, sig.S), "Expected signature over %s digest", hash)
+}
@@ -97,6 +97,20 @@ func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal,
 		return nil, errors.WithMessage(err, "sign proposal failed")
 	}
 
+	return SendSignedProposal(reqCtx, signedProposal, targets)
+}
+
+// SendSignedProposal sends a SignedProposal to the ProposalProcessors concurrently. The responses of the
+// processors which succeeded are returned along with the errors of the others.
+func SendSignedProposal(reqCtx reqContext.Context, signedProposal *pb.SignedProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	if signedProposal == nil {
+		return nil, errors.New("signed proposal is required")
+	}
+
+	if len(targets) < 1 {
+		return nil, errors.New("targets is required")
+	}
+
 	request := fab.ProcessProposalRequest{SignedProposal: signedProposal}
 
 	var responseMtx sync.Mutex
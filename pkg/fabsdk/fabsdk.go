@@ -11,6 +11,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/logging/api"
@@ -21,6 +22,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	sdkApi "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/chpvdr"
 	"github.com/pkg/errors"
@@ -29,6 +31,8 @@ import (
 var logger = logging.NewLogger("fabsdk")
 
 // FabricSDK provides access (and context) to clients being managed by the SDK.
+// An SDK instance is safe for concurrent use and should be shared by the clients created from it
+// rather than created per request.
 type FabricSDK struct {
 	opts     options
 	provider *context.Provider
@@ -40,8 +44,11 @@ type options struct {
 	Service           sdkApi.ServiceProviderFactory
 	Logger            api.LoggerProvider
 	CryptoSuiteConfig core.CryptoSuiteConfig
+	cryptoSuite       core.CryptoSuite
 	endpointConfig    fab.EndpointConfig
 	IdentityConfig    msp.IdentityConfig
+	requestOptions    []channel.RequestOption
+	certProvider      fab.TLSClientCertProvider
 }
 
 // Option configures the SDK.
@@ -51,6 +58,10 @@ type closeable interface {
 	Close()
 }
 
+type tlsClientCertProviderSetter interface {
+	SetTLSClientCertProvider(provider fab.TLSClientCertProvider)
+}
+
 // New initializes the SDK based on the set of options provided.
 // ConfigOptions provides the application configuration.
 func New(configProvider core.ConfigProvider, opts ...Option) (*FabricSDK, error) {
@@ -106,6 +117,25 @@ func WithConfigCryptoSuite(cryptoConfig core.CryptoSuiteConfig) Option {
 	}
 }
 
+// WithCryptoSuite injects a cryptosuite into the SDK, instead of the cryptosuite created by the core pkg from the
+// CryptoSuiteConfig. A process which creates several SDK instances (e.g. one per connection profile) should create
+// its cryptosuite once and inject it into each instance, so that the instances share a single key store and, with
+// PKCS11, a single pool of HSM sessions:
+//
+//  cryptoSuite, err := pkcs11.GetSuiteByConfig(cryptoSuiteConfig)
+//  ...
+//  sdk1, err := fabsdk.New(config1, fabsdk.WithCryptoSuite(cryptoSuite))
+//  sdk2, err := fabsdk.New(config2, fabsdk.WithCryptoSuite(cryptoSuite))
+//
+// The cryptosuite is safe for concurrent use by the instances, and it isn't closed by the SDK: its owner closes it
+// (if it's closeable) once the SDK instances are closed.
+func WithCryptoSuite(cryptoSuite core.CryptoSuite) Option {
+	return func(opts *options) error {
+		opts.cryptoSuite = cryptoSuite
+		return nil
+	}
+}
+
 // WithConfigEndpoint injects a EndpointConfig interface to the SDK
 func WithConfigEndpoint(endpointConfig fab.EndpointConfig) Option {
 	return func(opts *options) error {
@@ -154,6 +184,35 @@ func WithLoggerPkg(logger api.LoggerProvider) Option {
 	}
 }
 
+// WithChannelRequestOptions sets default request options for all the channel clients created from the channel
+// contexts of the SDK. They're applied to every request before the default options of the client and the
+// options of the request, which override them (see channel.WithDefaultRequestOptions).
+func WithChannelRequestOptions(requestOptions ...channel.RequestOption) Option {
+	return func(opts *options) error {
+		for _, opt := range requestOptions {
+			if opt == nil {
+				return errors.New("channel request option is required")
+			}
+		}
+		opts.requestOptions = append(opts.requestOptions, requestOptions...)
+		return nil
+	}
+}
+
+// WithTLSClientCertProvider registers a provider for the client's mutual TLS certs with the endpoint config of the
+// SDK, so that the certs may be rotated without recreating the SDK. The provider takes precedence over the certs in
+// the config and is consulted on every TLS handshake. The endpoint config must support the provider, as the default
+// endpoint config does.
+func WithTLSClientCertProvider(provider fab.TLSClientCertProvider) Option {
+	return func(opts *options) error {
+		if provider == nil {
+			return errors.New("TLS client cert provider is required")
+		}
+		opts.certProvider = provider
+		return nil
+	}
+}
+
 // providerInit interface allows for initializing providers
 // TODO: minimize interface
 type providerInit interface {
@@ -180,10 +239,21 @@ func initSDK(sdk *FabricSDK, configProvider core.ConfigProvider, opts []Option)
 		return errors.WithMessage(err, "failed to initialize configuration")
 	}
 
-	// Initialize crypto provider
-	cryptoSuite, err := sdk.opts.Core.CreateCryptoSuiteProvider(sdk.opts.CryptoSuiteConfig)
-	if err != nil {
-		return errors.WithMessage(err, "failed to initialize crypto suite")
+	if sdk.opts.certProvider != nil {
+		setter, ok := sdk.opts.endpointConfig.(tlsClientCertProviderSetter)
+		if !ok {
+			return errors.New("endpoint config doesn't support a TLS client cert provider")
+		}
+		setter.SetTLSClientCertProvider(sdk.opts.certProvider)
+	}
+
+	// Initialize crypto provider, unless it's shared with other SDK instances
+	cryptoSuite := sdk.opts.cryptoSuite
+	if cryptoSuite == nil {
+		cryptoSuite, err = sdk.opts.Core.CreateCryptoSuiteProvider(sdk.opts.CryptoSuiteConfig)
+		if err != nil {
+			return errors.WithMessage(err, "failed to initialize crypto suite")
+		}
 	}
 
 	// Initialize rand (TODO: should probably be optional)
@@ -289,6 +359,17 @@ func (sdk *FabricSDK) Close() {
 	sdk.provider.InfraProvider().Close()
 }
 
+// ConnectionCache returns the cache of the gRPC connections held by the SDK, which lists the cached connections
+// and closes them (e.g. during incident response) without restarting the process. An error is returned if the
+// infra provider doesn't cache connections.
+func (sdk *FabricSDK) ConnectionCache() (*comm.CachingConnector, error) {
+	connector, ok := sdk.provider.InfraProvider().CommManager().(*comm.CachingConnector)
+	if !ok {
+		return nil, errors.New("infra provider doesn't cache connections")
+	}
+	return connector, nil
+}
+
 //Config returns config provider used by SDK
 func (sdk *FabricSDK) Config() config.Provider {
 	return func() (core.CryptoSuiteConfig, fab.EndpointConfig, msp.IdentityConfig, error) {
@@ -317,13 +398,31 @@ func (sdk *FabricSDK) ChannelContext(channelID string, options ...ContextOption)
 	channelProvider := func() (contextApi.Channel, error) {
 
 		clientCtxProvider := sdk.Context(options...)
-		return context.NewChannel(clientCtxProvider, channelID)
+		channelCtx, err := context.NewChannel(clientCtxProvider, channelID)
+		if err != nil {
+			return nil, err
+		}
+		if len(sdk.opts.requestOptions) == 0 {
+			return channelCtx, nil
+		}
+		return &channelContext{Channel: channelCtx, requestOptions: sdk.opts.requestOptions}, nil
 
 	}
 
 	return channelProvider
 }
 
+//channelContext is a channel context which provides the default request options of the SDK to channel clients
+type channelContext struct {
+	*context.Channel
+	requestOptions []channel.RequestOption
+}
+
+//DefaultRequestOptions returns the default request options of the SDK
+func (c *channelContext) DefaultRequestOptions() []channel.RequestOption {
+	return c.requestOptions
+}
+
 //loadConfig load config from config backend when configs are not provided through opts
 func (sdk *FabricSDK) loadConfig(configProvider core.ConfigProvider) error {
 	if sdk.opts.CryptoSuiteConfig == nil || sdk.opts.endpointConfig == nil || sdk.opts.IdentityConfig == nil {
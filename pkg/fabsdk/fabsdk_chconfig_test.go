@@ -10,8 +10,11 @@ package fabsdk
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
@@ -47,6 +50,38 @@ func verifySDK(t *testing.T, sdk *FabricSDK) {
 	}
 }
 
+func TestWithChannelRequestOptions(t *testing.T) {
+	_, err := New(config.FromFile(sdkConfigFile), WithChannelRequestOptions(nil))
+	if err == nil {
+		t.Fatal("Expected error for nil channel request option")
+	}
+
+	sdk, err := New(config.FromFile(sdkConfigFile), WithChannelRequestOptions(channel.WithTimeout(fab.Query, time.Second)))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	defer sdk.Close()
+
+	sdk.provider.InfraProvider().(*fabpvdr.InfraProvider).SetChannelConfig(mocks.NewMockChannelCfg("orgchannel"))
+	chCtx, err := sdk.ChannelContext("orgchannel", WithUser(sdkValidClientUser), WithOrg(sdkValidClientOrg2))()
+	if err != nil {
+		t.Fatalf("Failed to create channel context: %s", err)
+	}
+
+	provider, ok := chCtx.(channel.DefaultRequestOptionsProvider)
+	if !ok {
+		t.Fatal("Expected channel context to provide the default request options")
+	}
+	if len(provider.DefaultRequestOptions()) != 1 {
+		t.Fatalf("Expected 1 default request option but got %d", len(provider.DefaultRequestOptions()))
+	}
+
+	_, err = channel.New(func() (contextApi.Channel, error) { return chCtx, nil })
+	if err != nil {
+		t.Fatalf("Failed to create new channel client: %s", err)
+	}
+}
+
 func TestWithConfigOpt(t *testing.T) {
 	// Test New SDK with valid config file
 	c := config.FromFile(sdkConfigFile)
@@ -7,12 +7,21 @@ SPDX-License-Identifier: Apache-2.0
 package fabsdk
 
 import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	bccspSw "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	mockapisdk "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/test/mocksdkapi"
 	"github.com/pkg/errors"
 )
@@ -62,6 +71,22 @@ func TestDoubleClose(t *testing.T) {
 	sdk.Close()
 }
 
+func TestConnectionCache(t *testing.T) {
+	sdk, err := New(configImpl.FromFile(sdkConfigFile))
+	if err != nil {
+		t.Fatalf("Expected no error from New, but got %v", err)
+	}
+	defer sdk.Close()
+
+	connectionCache, err := sdk.ConnectionCache()
+	if err != nil {
+		t.Fatalf("Expected connection cache, but got %v", err)
+	}
+	if len(connectionCache.Connections()) != 0 {
+		t.Fatal("Expected no cached connections")
+	}
+}
+
 func TestWithCorePkg(t *testing.T) {
 	// Test New SDK with valid config file
 	c := configImpl.FromFile(sdkConfigFile)
@@ -85,6 +110,93 @@ func TestWithCorePkg(t *testing.T) {
 	}
 }
 
+func TestWithCryptoSuite(t *testing.T) {
+	keyStorePath, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("Error creating key store directory: %s", err)
+	}
+	defer os.RemoveAll(keyStorePath)
+	keyStore, err := bccspSw.NewFileBasedKeyStore(nil, keyStorePath, false)
+	if err != nil {
+		t.Fatalf("Error creating key store: %s", err)
+	}
+	cryptoSuite, err := sw.GetSuite(256, "SHA2", keyStore)
+	if err != nil {
+		t.Fatalf("Error creating cryptosuite: %s", err)
+	}
+
+	// Two SDK instances over the same cryptosuite, e.g. for two connection profiles
+	var sdks []*FabricSDK
+	for i := 0; i < 2; i++ {
+		sdk, err := New(configImpl.FromFile(sdkConfigFile), WithCryptoSuite(cryptoSuite))
+		if err != nil {
+			t.Fatalf("Error initializing SDK: %s", err)
+		}
+		defer sdk.Close()
+		if sdk.provider.CryptoSuite() != cryptoSuite {
+			t.Fatal("Expected the SDK to use the injected cryptosuite")
+		}
+		sdks = append(sdks, sdk)
+	}
+
+	// Keys stored through one instance are loaded from the key store by the other, concurrently
+	const numKeys = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, numKeys)
+	for i := 0; i < numKeys; i++ {
+		wg.Add(1)
+		go func(storing, loading core.CryptoSuite) {
+			defer wg.Done()
+			errs <- verifySharedKey(storing, loading)
+		}(sdks[i%2].provider.CryptoSuite(), sdks[(i+1)%2].provider.CryptoSuite())
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Error sharing key between SDK instances: %s", err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(keyStorePath)
+	if err != nil {
+		t.Fatalf("Error reading key store: %s", err)
+	}
+	if len(files) != numKeys {
+		t.Fatalf("Expected %d keys in the shared key store, but got %d", numKeys, len(files))
+	}
+}
+
+// verifySharedKey generates a key with the storing cryptosuite, and checks that the key loaded by the loading
+// cryptosuite verifies the signatures of the storing cryptosuite
+func verifySharedKey(storing, loading core.CryptoSuite) error {
+	key, err := storing.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: false})
+	if err != nil {
+		return errors.WithMessage(err, "key generation failed")
+	}
+	loaded, err := loading.GetKey(key.SKI())
+	if err != nil {
+		return errors.WithMessage(err, "loading key failed")
+	}
+	if !bytes.Equal(key.SKI(), loaded.SKI()) || !loaded.Private() {
+		return errors.New("unexpected key loaded")
+	}
+
+	digest, err := storing.Hash([]byte("message"), &bccsp.SHA256Opts{})
+	if err != nil {
+		return errors.WithMessage(err, "hashing failed")
+	}
+	signature, err := storing.Sign(key, digest, nil)
+	if err != nil {
+		return errors.WithMessage(err, "signing failed")
+	}
+	valid, err := loading.Verify(loaded, signature, digest, nil)
+	if err != nil || !valid {
+		return errors.Errorf("signature not verified with the loaded key: %v", err)
+	}
+	return nil
+}
+
 func TestWithMSPPkg(t *testing.T) {
 	// Test New SDK with valid config file
 	c := configImpl.FromFile(sdkConfigFile)
@@ -260,3 +372,44 @@ func TestWithConfigFailure(t *testing.T) {
 		t.Fatal("Expected failure due to invalid config")
 	}
 }
+
+type mockCertProvider struct {
+	certs []tls.Certificate
+}
+
+func (p *mockCertProvider) TLSClientCerts() ([]tls.Certificate, error) {
+	return p.certs, nil
+}
+
+func TestWithTLSClientCertProvider(t *testing.T) {
+	_, err := New(configImpl.FromFile(sdkConfigFile), WithTLSClientCertProvider(nil))
+	if err == nil {
+		t.Fatal("Expected error for nil TLS client cert provider")
+	}
+
+	provider := &mockCertProvider{certs: []tls.Certificate{{Certificate: [][]byte{{1}}}}}
+
+	_, err = New(configImpl.FromFile(sdkConfigFile), WithConfigEndpoint(mocks.NewMockEndpointConfig()), WithTLSClientCertProvider(provider))
+	if err == nil {
+		t.Fatal("Expected error for endpoint config which doesn't support a TLS client cert provider")
+	}
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithTLSClientCertProvider(provider))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	defer sdk.Close()
+
+	_, endpointConfig, _, err := sdk.Config()()
+	if err != nil {
+		t.Fatalf("Error getting config from sdk: %s", err)
+	}
+
+	certs, err := endpointConfig.TLSClientCerts()
+	if err != nil {
+		t.Fatalf("Error getting TLS client certs: %s", err)
+	}
+	if len(certs) != 1 || certs[0].Certificate[0][0] != 1 {
+		t.Fatalf("Expected certs from provider but got %v", certs)
+	}
+}
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package chpvdr
 
 import (
+	"sync"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -18,11 +20,16 @@ import (
 // underlying channel services need to recreate their channel clients.
 type ChannelProvider struct {
 	infraProvider fab.InfraProvider
+	lock          sync.Mutex
+	services      map[string]*sharedServices
 }
 
 // New creates a ChannelProvider based on a context
 func New(infraProvider fab.InfraProvider) (*ChannelProvider, error) {
-	cp := ChannelProvider{infraProvider: infraProvider}
+	cp := ChannelProvider{
+		infraProvider: infraProvider,
+		services:      make(map[string]*sharedServices),
+	}
 	return &cp, nil
 }
 
@@ -0,0 +1,92 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chpvdr
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+var logger = logging.NewLogger("fabsdk")
+
+// sharedServices holds the discovery and selection services of a channel which are shared
+// by the clients of an organization. The services are closed once no client uses them.
+type sharedServices struct {
+	lock      sync.Mutex
+	refs      int
+	discovery fab.DiscoveryService
+	selection fab.SelectionService
+}
+
+type closable interface {
+	Close()
+}
+
+// SharedServices returns the discovery and selection services of the channel, which are shared by the clients of
+// the organization of the channel service's identity, so that the peers aren't queried by each client separately.
+// The services are created with the given function if no client uses them. The returned function releases the
+// services, which are closed once released by all clients.
+func (cs *ChannelService) SharedServices(create func() (fab.DiscoveryService, fab.SelectionService, error)) (fab.DiscoveryService, fab.SelectionService, func(), error) {
+	return cs.provider.acquire(cs.channelID+"/"+cs.context.Identifier().MSPID, create)
+}
+
+func (cp *ChannelProvider) acquire(key string, create func() (fab.DiscoveryService, fab.SelectionService, error)) (fab.DiscoveryService, fab.SelectionService, func(), error) {
+	cp.lock.Lock()
+	services, ok := cp.services[key]
+	if !ok {
+		services = &sharedServices{}
+		cp.services[key] = services
+	}
+	services.refs++
+	cp.lock.Unlock()
+
+	// The services are created outside of the provider lock since creating them may query the peers
+	services.lock.Lock()
+	if services.discovery == nil {
+		discovery, selection, err := create()
+		if err != nil {
+			services.lock.Unlock()
+			cp.release(key, services)
+			return nil, nil, nil, err
+		}
+		logger.Debugf("Created shared discovery and selection services [%s]", key)
+		services.discovery = discovery
+		services.selection = selection
+	}
+	discovery, selection := services.discovery, services.selection
+	services.lock.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { cp.release(key, services) })
+	}
+	return discovery, selection, release, nil
+}
+
+func (cp *ChannelProvider) release(key string, services *sharedServices) {
+	cp.lock.Lock()
+	services.refs--
+	if services.refs > 0 {
+		cp.lock.Unlock()
+		return
+	}
+	delete(cp.services, key)
+	cp.lock.Unlock()
+
+	services.lock.Lock()
+	defer services.lock.Unlock()
+
+	logger.Debugf("Closing shared discovery and selection services [%s]", key)
+	if c, ok := services.discovery.(closable); ok {
+		c.Close()
+	}
+	if c, ok := services.selection.(closable); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chpvdr
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedServices(t *testing.T) {
+	cp, err := New(&mocks.MockInfraProvider{})
+	assert.Nil(t, err)
+	discoveryProvider := &refreshingDiscoveryProvider{}
+	selectionProvider := &mocks.MockSelectionProvider{}
+
+	org1Client := newServicesClientContext(cp, discoveryProvider, selectionProvider, "Org1MSP")
+	org2Client := newServicesClientContext(cp, discoveryProvider, selectionProvider, "Org2MSP")
+
+	channel1, err := contextImpl.NewChannel(org1Client, "mychannel")
+	assert.Nil(t, err)
+	channel2, err := contextImpl.NewChannel(org1Client, "mychannel")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, discoveryProvider.refreshing(), "expected a single refresh for two clients on one channel")
+	assert.True(t, channel1.DiscoveryService() == channel2.DiscoveryService(), "expected discovery service to be shared")
+	assert.True(t, channel1.SelectionService() == channel2.SelectionService(), "expected selection service to be shared")
+
+	// The services aren't shared with other organizations and channels
+	channel3, err := contextImpl.NewChannel(org2Client, "mychannel")
+	assert.Nil(t, err)
+	channel4, err := contextImpl.NewChannel(org1Client, "otherchannel")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, discoveryProvider.refreshing())
+
+	// The services are closed once released by all clients
+	channel1.Close()
+	channel1.Close()
+	assert.Equal(t, 3, discoveryProvider.refreshing(), "expected services to be used by the second client")
+	channel2.Close()
+	assert.Equal(t, 2, discoveryProvider.refreshing(), "expected services to be closed once released by all clients")
+
+	channel5, err := contextImpl.NewChannel(org1Client, "mychannel")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, discoveryProvider.refreshing(), "expected services to be created again")
+
+	channel3.Close()
+	channel4.Close()
+	channel5.Close()
+	assert.Equal(t, 0, discoveryProvider.refreshing())
+}
+
+//servicesClientContext is a client context with the given channel, discovery and selection providers
+type servicesClientContext struct {
+	context.Client
+	channelProvider   fab.ChannelProvider
+	discoveryProvider fab.DiscoveryProvider
+	selectionProvider fab.SelectionProvider
+}
+
+func newServicesClientContext(cp fab.ChannelProvider, dp fab.DiscoveryProvider, sp fab.SelectionProvider, mspID string) context.ClientProvider {
+	ctx := &servicesClientContext{
+		Client: &mockClientContext{
+			Providers:       mocks.NewMockProviderContext(),
+			SigningIdentity: mspmocks.NewMockSigningIdentity("user", mspID),
+		},
+		channelProvider:   cp,
+		discoveryProvider: dp,
+		selectionProvider: sp,
+	}
+	return func() (context.Client, error) {
+		return ctx, nil
+	}
+}
+
+func (c *servicesClientContext) ChannelProvider() fab.ChannelProvider {
+	return c.channelProvider
+}
+
+func (c *servicesClientContext) DiscoveryProvider() fab.DiscoveryProvider {
+	return c.discoveryProvider
+}
+
+func (c *servicesClientContext) SelectionProvider() fab.SelectionProvider {
+	return c.selectionProvider
+}
+
+//refreshingDiscoveryProvider counts the discovery services refreshing their peers, i.e. which aren't closed
+type refreshingDiscoveryProvider struct {
+	lock     sync.Mutex
+	services int
+}
+
+func (dp *refreshingDiscoveryProvider) CreateDiscoveryService(channelID string) (fab.DiscoveryService, error) {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+	dp.services++
+	return &refreshingDiscoveryService{provider: dp}, nil
+}
+
+func (dp *refreshingDiscoveryProvider) refreshing() int {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+	return dp.services
+}
+
+type refreshingDiscoveryService struct {
+	provider *refreshingDiscoveryProvider
+}
+
+func (ds *refreshingDiscoveryService) GetPeers() ([]fab.Peer, error) {
+	return nil, nil
+}
+
+func (ds *refreshingDiscoveryService) Close() {
+	ds.provider.lock.Lock()
+	defer ds.provider.lock.Unlock()
+	ds.provider.services--
+}
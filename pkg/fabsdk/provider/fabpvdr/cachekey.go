@@ -9,6 +9,7 @@ package fabpvdr
 import (
 	"crypto/sha256"
 	"strconv"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -23,7 +24,13 @@ type CacheKey struct {
 }
 
 type params struct {
-	permitBlockEvents bool
+	permitBlockEvents       bool
+	maxReconnAttempts       uint
+	reconnInitialDelay      time.Duration
+	timeBetweenConnAttempts time.Duration
+	reconnBackoffFactor     float64
+	reconnMaxDelay          time.Duration
+	peerMSPID               string
 }
 
 func defaultParams() *params {
@@ -34,6 +41,27 @@ func (p *params) PermitBlockEvents() {
 	p.permitBlockEvents = true
 }
 
+func (p *params) SetMaxReconnectAttempts(value uint) {
+	p.maxReconnAttempts = value
+}
+
+func (p *params) SetReconnectInitialDelay(value time.Duration) {
+	p.reconnInitialDelay = value
+}
+
+func (p *params) SetTimeBetweenConnectAttempts(value time.Duration) {
+	p.timeBetweenConnAttempts = value
+}
+
+func (p *params) SetReconnectBackoff(factor float64, maxDelay time.Duration) {
+	p.reconnBackoffFactor = factor
+	p.reconnMaxDelay = maxDelay
+}
+
+func (p *params) SetPeerMSPID(mspID string) {
+	p.peerMSPID = mspID
+}
+
 type permitBlockEventsSetter interface {
 	PermitBlockEvents()
 }
@@ -41,6 +69,13 @@ type permitBlockEventsSetter interface {
 func (p *params) getOptKey() string {
 	//	Construct opts portion
 	optKey := "blockEvents:" + strconv.FormatBool(p.permitBlockEvents)
+	//	Event services which reconnect differently aren't shared
+	optKey += ",maxReconnectAttempts:" + strconv.FormatUint(uint64(p.maxReconnAttempts), 10) +
+		",reconnectInitialDelay:" + p.reconnInitialDelay.String() +
+		",timeBetweenConnectAttempts:" + p.timeBetweenConnAttempts.String() +
+		",reconnectBackoff:" + strconv.FormatFloat(p.reconnBackoffFactor, 'f', -1, 64) + "/" + p.reconnMaxDelay.String()
+	//	Event services restricted to the peers of an MSP aren't shared with unrestricted ones
+	optKey += ",peerMSPID:" + p.peerMSPID
 	return optKey
 }
 
@@ -77,6 +77,20 @@ func (ref *EventClientRef) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Re
 	return service.RegisterBlockEvent(filter...)
 }
 
+// RegisterNamespaceBlockEvent registers for the block events of the blocks which contain at least one transaction
+// touching one of the chaincode namespaces. An error is returned if the event client doesn't filter by namespace.
+func (ref *EventClientRef) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	service, err := ref.get()
+	if err != nil {
+		return nil, nil, err
+	}
+	nsService, ok := service.(fab.NamespaceEventService)
+	if !ok {
+		return nil, nil, errors.New("event client doesn't filter block events by chaincode namespace")
+	}
+	return nsService.RegisterNamespaceBlockEvent(namespaces, trim, filter...)
+}
+
 // RegisterFilteredBlockEvent registers for filtered block events.
 func (ref *EventClientRef) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
 	service, err := ref.get()
@@ -104,6 +118,12 @@ func (ref *EventClientRef) RegisterTxStatusEvent(txID string) (fab.Registration,
 	return service.RegisterTxStatusEvent(txID)
 }
 
+// Buffered returns a view of the event client whose registrations have their own event buffer and overflow
+// policy. Registering with the view returns an error if the event client doesn't support it.
+func (ref *EventClientRef) Buffered(opts fab.BufferOpts) fab.EventService {
+	return &bufferedEventClientRef{ref: ref, opts: opts}
+}
+
 // Unregister removes the given registration and closes the event channel.
 func (ref *EventClientRef) Unregister(reg fab.Registration) {
 	if service, err := ref.get(); err != nil {
@@ -113,6 +133,33 @@ func (ref *EventClientRef) Unregister(reg fab.Registration) {
 	}
 }
 
+// Connected returns false if the event client is closed, can't be connected or reports that its connection to the
+// event server is down. The event client is connected if it was released after the idle timeout.
+func (ref *EventClientRef) Connected() bool {
+	service, err := ref.get()
+	if err != nil {
+		logger.Debugf("Event client is unavailable: %s", err)
+		return false
+	}
+	if connectedService, ok := service.(fab.ConnectedEventService); ok {
+		return connectedService.Connected()
+	}
+	return true
+}
+
+// bufferedEventClient returns the buffered view of the event client
+func (ref *EventClientRef) bufferedEventClient(opts fab.BufferOpts) (fab.EventService, error) {
+	service, err := ref.get()
+	if err != nil {
+		return nil, err
+	}
+	bufferedService, ok := service.(fab.BufferedEventService)
+	if !ok {
+		return nil, errors.New("event client doesn't support buffer options")
+	}
+	return bufferedService.Buffered(opts), nil
+}
+
 func (ref *EventClientRef) get() (fab.EventService, error) {
 	if ref.Closed() {
 		return nil, errors.New("event client is closed")
@@ -168,3 +215,61 @@ func (ref *EventClientRef) finalizer() lazyref.Finalizer {
 		}
 	}
 }
+
+// bufferedEventClientRef is a buffered view of the event client of the reference
+type bufferedEventClientRef struct {
+	ref  *EventClientRef
+	opts fab.BufferOpts
+}
+
+func (b *bufferedEventClientRef) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	service, err := b.ref.bufferedEventClient(b.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.RegisterBlockEvent(filter...)
+}
+
+func (b *bufferedEventClientRef) RegisterNamespaceBlockEvent(namespaces []string, trim bool, filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	service, err := b.ref.bufferedEventClient(b.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	nsService, ok := service.(fab.NamespaceEventService)
+	if !ok {
+		return nil, nil, errors.New("event client doesn't filter block events by chaincode namespace")
+	}
+	return nsService.RegisterNamespaceBlockEvent(namespaces, trim, filter...)
+}
+
+func (b *bufferedEventClientRef) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	service, err := b.ref.bufferedEventClient(b.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.RegisterFilteredBlockEvent()
+}
+
+func (b *bufferedEventClientRef) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	service, err := b.ref.bufferedEventClient(b.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.RegisterChaincodeEvent(ccID, eventFilter)
+}
+
+func (b *bufferedEventClientRef) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	service, err := b.ref.bufferedEventClient(b.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.RegisterTxStatusEvent(txID)
+}
+
+func (b *bufferedEventClientRef) Unregister(reg fab.Registration) {
+	b.ref.Unregister(reg)
+}
+
+func (b *bufferedEventClientRef) Connected() bool {
+	return b.ref.Connected()
+}
@@ -9,6 +9,7 @@ package fabpvdr
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -17,6 +18,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	coreMocks "github.com/hyperledger/fabric-sdk-go/pkg/core/mocks"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	peerImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
@@ -80,6 +82,21 @@ func TestCreateMembership(t *testing.T) {
 	assert.NotNil(t, m)
 }
 
+func TestCacheKeyReconnectOpts(t *testing.T) {
+	ctx := mocks.NewMockContext(mspmocks.NewMockSigningIdentity("user", "user"))
+	chConfig := mocks.NewMockChannelCfg("mychannel")
+
+	key1, err := NewCacheKey(ctx, chConfig)
+	assert.Nil(t, err)
+	key2, err := NewCacheKey(ctx, chConfig, client.WithReconnectBackoff(2, time.Minute))
+	assert.Nil(t, err)
+	key3, err := NewCacheKey(ctx, chConfig, client.WithReconnectBackoff(2, time.Minute))
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, key1.String(), key2.String(), "expected event services with different reconnect options not to be shared")
+	assert.Equal(t, key2.String(), key3.String(), "expected event services with the same reconnect options to be shared")
+}
+
 func newInfraProvider(t *testing.T) *InfraProvider {
 	configBackend, err := config.FromFile("../../../../test/fixtures/config/config_test.yaml")()
 	if err != nil {
@@ -17,6 +17,8 @@ import (
 // CertFileUserStore stores each user in a separate file.
 // Only user's enrollment cert is stored, in pem format.
 // File naming is <user>@<org>-cert.pem
+// The TLS client cert of the user, if any, is stored in a separate file
+// named <user>@<org>-tls.pem
 type CertFileUserStore struct {
 	store core.KVStore
 }
@@ -32,6 +34,10 @@ func storeKeyFromUserIdentifier(key msp.IdentityIdentifier) string {
 	return key.ID + "@" + key.MSPID + "-cert.pem"
 }
 
+func tlsStoreKeyFromUserIdentifier(key msp.IdentityIdentifier) string {
+	return key.ID + "@" + key.MSPID + "-tls.pem"
+}
+
 // NewCertFileUserStore1 creates a new instance of CertFileUserStore
 func NewCertFileUserStore1(store core.KVStore) (*CertFileUserStore, error) {
 	return &CertFileUserStore{
@@ -66,21 +72,55 @@ func (s *CertFileUserStore) Load(key msp.IdentityIdentifier) (*msp.UserData, err
 	if !ok {
 		return nil, errors.New("user is not of proper type")
 	}
+	tlsCertBytes, err := s.loadTLSCert(key)
+	if err != nil {
+		return nil, err
+	}
 	userData := &msp.UserData{
 		MSPID: key.MSPID,
 		ID:    key.ID,
 		EnrollmentCertificate: certBytes,
+		TLSCertificate:        tlsCertBytes,
 	}
 	return userData, nil
 }
 
-// Store stores a User into store
+//loadTLSCert loads the TLS client cert of the user, users stored without one have none
+func (s *CertFileUserStore) loadTLSCert(key msp.IdentityIdentifier) ([]byte, error) {
+	cert, err := s.store.Load(tlsStoreKeyFromUserIdentifier(key))
+	if err != nil {
+		if err == core.ErrKeyValueNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	certBytes, ok := cert.([]byte)
+	if !ok {
+		return nil, errors.New("user TLS cert is not of proper type")
+	}
+	return certBytes, nil
+}
+
+// Store stores a User into store. The TLS client cert stored for the user, if any, is kept
+// unless the user has a new one, e.g. when the user is re-enrolled.
 func (s *CertFileUserStore) Store(user *msp.UserData) error {
-	key := storeKeyFromUserIdentifier(msp.IdentityIdentifier{MSPID: user.MSPID, ID: user.ID})
-	return s.store.Store(key, user.EnrollmentCertificate)
+	id := msp.IdentityIdentifier{MSPID: user.MSPID, ID: user.ID}
+	if err := s.store.Store(storeKeyFromUserIdentifier(id), user.EnrollmentCertificate); err != nil {
+		return err
+	}
+	if len(user.TLSCertificate) == 0 {
+		return nil
+	}
+	return s.store.Store(tlsStoreKeyFromUserIdentifier(id), user.TLSCertificate)
 }
 
 // Delete deletes a User from store
 func (s *CertFileUserStore) Delete(key msp.IdentityIdentifier) error {
-	return s.store.Delete(storeKeyFromUserIdentifier(key))
+	if err := s.store.Delete(storeKeyFromUserIdentifier(key)); err != nil {
+		return err
+	}
+	if err := s.store.Delete(tlsStoreKeyFromUserIdentifier(key)); err != nil && err != core.ErrKeyValueNotFound {
+		return err
+	}
+	return nil
 }
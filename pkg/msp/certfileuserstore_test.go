@@ -116,6 +116,74 @@ func TestStore(t *testing.T) {
 	}
 }
 
+func TestStoreTLSCertificate(t *testing.T) {
+
+	cleanupTestPath(t, storePathRoot)
+	defer cleanupTestPath(t, storePathRoot)
+
+	store, err := NewCertFileUserStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileKeyValueStore failed [%s]", err)
+	}
+
+	// Users stored without a TLS cert have none
+	user := &msp.UserData{MSPID: "Org1", ID: "user1", EnrollmentCertificate: []byte(testCert1)}
+	if err := store.Store(user); err != nil {
+		t.Fatalf("Store %s failed [%s]", user.ID, err)
+	}
+	loaded, err := store.Load(userIdentifier(user))
+	if err != nil {
+		t.Fatalf("Load %s failed [%s]", user.ID, err)
+	}
+	if loaded.TLSCertificate != nil {
+		t.Fatal("Expected user without TLS cert")
+	}
+
+	user.TLSCertificate = []byte(testCert2)
+	if err := store.Store(user); err != nil {
+		t.Fatalf("Store %s failed [%s]", user.ID, err)
+	}
+	if err := checkStoreValue(store, user, user.EnrollmentCertificate); err != nil {
+		t.Fatalf("checkStoreValue %s failed [%s]", user.ID, err)
+	}
+	tlsFile := path.Join(storePath, tlsStoreKeyFromUserIdentifier(userIdentifier(user)))
+	tlsCertBytes, err := ioutil.ReadFile(tlsFile)
+	if err != nil {
+		t.Fatalf("Reading TLS cert failed [%s]", err)
+	}
+	if err := compare(tlsCertBytes, user.TLSCertificate); err != nil {
+		t.Fatalf("Stored TLS cert is wrong [%s]", err)
+	}
+
+	// The TLS cert is kept when the user is stored again without one, e.g. when re-enrolled
+	if err := store.Store(&msp.UserData{MSPID: user.MSPID, ID: user.ID, EnrollmentCertificate: []byte(testCert1)}); err != nil {
+		t.Fatalf("Store %s failed [%s]", user.ID, err)
+	}
+	loaded, err = store.Load(userIdentifier(user))
+	if err != nil {
+		t.Fatalf("Load %s failed [%s]", user.ID, err)
+	}
+	if err := compare(loaded.TLSCertificate, user.TLSCertificate); err != nil {
+		t.Fatalf("Loaded TLS cert is wrong [%s]", err)
+	}
+
+	// The user store lists users by their enrollment cert only
+	ids, err := listUserStore(storePath)
+	if err != nil {
+		t.Fatalf("listUserStore failed [%s]", err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("Expected one user in store, got %v", ids)
+	}
+
+	if err := store.Delete(userIdentifier(user)); err != nil {
+		t.Fatalf("Delete %s failed [%s]", user.ID, err)
+	}
+	if _, err := os.Stat(tlsFile); !os.IsNotExist(err) {
+		t.Fatalf("Expected TLS cert to be deleted [%v]", err)
+	}
+}
+
 func TestCreateNewStore(t *testing.T) {
 
 	_, err := NewCertFileUserStore("")
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package msp
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -17,6 +18,7 @@ import (
 	fabricCaUtil "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 	"github.com/hyperledger/fabric-sdk-go/pkg/util/pathvar"
 	"github.com/pkg/errors"
 )
@@ -26,6 +28,7 @@ func newUser(userData *msp.UserData, cryptoSuite core.CryptoSuite) (*User, error
 	if err != nil {
 		return nil, errors.WithMessage(err, "fetching public key from cert failed")
 	}
+	checkKeySecurityLevel(userData.ID, pubKey, cryptoSuite)
 	pk, err := cryptoSuite.GetKey(pubKey.SKI())
 	if err != nil {
 		return nil, errors.WithMessage(err, "cryptoSuite GetKey failed")
@@ -36,9 +39,46 @@ func newUser(userData *msp.UserData, cryptoSuite core.CryptoSuite) (*User, error
 		enrollmentCertificate: userData.EnrollmentCertificate,
 		privateKey:            pk,
 	}
+	if len(userData.TLSCertificate) > 0 {
+		tlsCert, err := tlsClientCert(userData.TLSCertificate, cryptoSuite)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("loading TLS certificate of user [%s] failed", userData.ID))
+		}
+		u.tlsCertificate = userData.TLSCertificate
+		u.tlsClientCert = tlsCert
+	}
 	return u, nil
 }
 
+//tlsClientCert pairs the TLS certificate with its private key from the crypto suite's key store
+func tlsClientCert(certPEM []byte, cryptoSuite core.CryptoSuite) (*tls.Certificate, error) {
+	pubKey, err := cryptoutil.GetPublicKeyFromCert(certPEM, cryptoSuite)
+	if err != nil {
+		return nil, errors.WithMessage(err, "fetching public key from TLS cert failed")
+	}
+	pk, err := cryptoSuite.GetKey(pubKey.SKI())
+	if err != nil {
+		return nil, errors.WithMessage(err, "cryptoSuite GetKey failed")
+	}
+	cert, err := cryptoutil.X509KeyPair(certPEM, pk, cryptoSuite)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// checkKeySecurityLevel warns if the key of the user doesn't provide the security level the crypto suite
+// was configured with, which may explain why its signatures are rejected
+func checkKeySecurityLevel(username string, key core.Key, cryptoSuite core.CryptoSuite) {
+	secConfig, ok := cryptoSuite.(cryptosuite.SecurityConfig)
+	if !ok || secConfig.SecurityLevel() == 0 {
+		return
+	}
+	if err := cryptosuite.ValidateKeySecurityLevel(key, secConfig.SecurityLevel()); err != nil {
+		logger.Warnf("Key of user [%s] is incompatible with the cryptosuite configuration: %s", username, err)
+	}
+}
+
 // NewUser creates a User instance
 func (mgr *IdentityManager) NewUser(userData *msp.UserData) (*User, error) {
 	return newUser(userData, mgr.cryptoSuite)
@@ -107,6 +147,7 @@ func (mgr *IdentityManager) GetUser(username string) (*User, error) {
 		if privateKey == nil {
 			return nil, fmt.Errorf("unable to find private key for user [%s]", username)
 		}
+		checkKeySecurityLevel(username, privateKey, mgr.cryptoSuite)
 		mspID, err := mgr.config.MSPID(mgr.orgName)
 		if err != nil {
 			return nil, errors.WithMessage(err, "MSP ID config read failed")
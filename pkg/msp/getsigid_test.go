@@ -7,7 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package msp
 
 import (
+	"io/ioutil"
 	"math/rand"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -15,10 +17,12 @@ import (
 	"fmt"
 
 	fabricCaUtil "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
+	bccspSw "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/pkg/errors"
 )
@@ -219,3 +223,29 @@ func TestGetSigningIdentityFromEmbeddedCryptoConfig(t *testing.T) {
 func createRandomName() string {
 	return "user" + strconv.Itoa(rand.Intn(500000))
 }
+
+func TestNewUserWithLowerSecurityLevel(t *testing.T) {
+	keyStorePath, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("Failed to create key store directory: %s", err)
+	}
+	defer os.RemoveAll(keyStorePath)
+	keyStore, err := bccspSw.NewFileBasedKeyStore(nil, keyStorePath, false)
+	if err != nil {
+		t.Fatalf("Failed to create key store: %s", err)
+	}
+	suite, err := sw.GetSuite(256, "SHA2", keyStore)
+	if err != nil {
+		t.Fatalf("Failed to create cryptosuite: %s", err)
+	}
+	if _, err := fabricCaUtil.ImportBCCSPKeyFromPEMBytes([]byte(testPrivKey), suite, false); err != nil {
+		t.Fatalf("ImportBCCSPKeyFromPEMBytes failed [%s]", err)
+	}
+	userData := &msp.UserData{ID: "User1", MSPID: "Org1MSP", EnrollmentCertificate: []byte(testCert)}
+
+	// The enrollment certificate has a P-256 key, which is only reported as incompatible
+	suite384 := wrapper.NewCryptoSuiteWithSecurityConfig(suite.(*wrapper.CryptoSuite).BCCSP, 384, "SHA2")
+	if _, err := newUser(userData, suite384); err != nil {
+		t.Fatalf("Expected user with a lower security level to load, got %s", err)
+	}
+}
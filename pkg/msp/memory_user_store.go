@@ -12,18 +12,24 @@ import (
 
 // MemoryUserStore is in-memory implementation of UserStore
 type MemoryUserStore struct {
-	store map[string][]byte
+	store    map[string][]byte
+	tlsStore map[string][]byte
 }
 
 // NewMemoryUserStore creates a new MemoryUserStore instance
 func NewMemoryUserStore() *MemoryUserStore {
 	store := make(map[string][]byte)
-	return &MemoryUserStore{store: store}
+	tlsStore := make(map[string][]byte)
+	return &MemoryUserStore{store: store, tlsStore: tlsStore}
 }
 
-// Store stores a user into store
+// Store stores a user into store. The TLS client cert stored for the user, if any, is kept
+// unless the user has a new one.
 func (s *MemoryUserStore) Store(user *msp.UserData) error {
 	s.store[user.ID+"@"+user.MSPID] = user.EnrollmentCertificate
+	if len(user.TLSCertificate) > 0 {
+		s.tlsStore[user.ID+"@"+user.MSPID] = user.TLSCertificate
+	}
 	return nil
 }
 
@@ -37,6 +43,7 @@ func (s *MemoryUserStore) Load(id msp.IdentityIdentifier) (*msp.UserData, error)
 		ID:    id.ID,
 		MSPID: id.MSPID,
 		EnrollmentCertificate: cert,
+		TLSCertificate:        s.tlsStore[id.ID+"@"+id.MSPID],
 	}
 	return &userData, nil
 }
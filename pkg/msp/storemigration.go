@@ -0,0 +1,340 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	bccspsw "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/pkg/errors"
+)
+
+const (
+	certFileSuffix = "-cert.pem"
+	keyFileSuffix  = "_sk"
+)
+
+// StoreLocation locates the stores of a client's identities: the user store holding their enrollment
+// certificates (see CertFileUserStore) and the SW keystore holding their private keys.
+// KeyStorePath is the keystore directory, as returned by CryptoSuiteConfig.KeyStorePath.
+type StoreLocation struct {
+	CredentialStorePath string
+	KeyStorePath        string
+}
+
+// MigrationReport lists the identities migrated by MigrateStores, or the identities which would be migrated in dry-run mode
+type MigrationReport struct {
+	DryRun     bool
+	Identities []MigratedIdentity
+}
+
+// MigratedIdentity is the outcome of the migration of an identity
+type MigratedIdentity struct {
+	msp.IdentityIdentifier
+	SKI []byte
+	// Err is the reason the identity couldn't be migrated, nil if it was migrated successfully
+	Err error
+}
+
+// Failed returns the identities which couldn't be migrated
+func (r *MigrationReport) Failed() []MigratedIdentity {
+	var failed []MigratedIdentity
+	for _, identity := range r.Identities {
+		if identity.Err != nil {
+			failed = append(failed, identity)
+		}
+	}
+	return failed
+}
+
+// MigrationOption describes a functional parameter for MigrateStores
+type MigrationOption func(*migrationOptions)
+
+type migrationOptions struct {
+	dryRun      bool
+	oldPassword []byte
+	newPassword []byte
+}
+
+// WithDryRun checks that every identity can be migrated, without writing to the new location
+func WithDryRun() MigrationOption {
+	return func(o *migrationOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithKeyStorePasswords re-encrypts the private keys during the migration: keys are decrypted with oldPassword
+// and encrypted with newPassword. A nil password denotes a keystore which isn't encrypted.
+func WithKeyStorePasswords(oldPassword, newPassword []byte) MigrationOption {
+	return func(o *migrationOptions) {
+		o.oldPassword = oldPassword
+		o.newPassword = newPassword
+	}
+}
+
+// MigrateStores copies the identities of the old location to the new location, i.e. the user entries and
+// the private keys (which are looked up by SKI), and verifies that each migrated identity can sign.
+// The old location is never modified. The migration is all or nothing: if an identity can't be migrated,
+// everything written to the new location is removed and an error is returned along with the report.
+func MigrateStores(oldLocation, newLocation StoreLocation, opts ...MigrationOption) (*MigrationReport, error) {
+	options := migrationOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := validateLocations(oldLocation, newLocation); err != nil {
+		return nil, err
+	}
+
+	m, err := newStoreMigration(oldLocation, newLocation, options)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := listUserStore(oldLocation.CredentialStorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{DryRun: options.dryRun}
+	for _, id := range ids {
+		ski, err := m.migrate(id)
+		if err != nil {
+			logger.Warnf("Failed to migrate identity [%s:%s]: %s", id.MSPID, id.ID, err)
+		}
+		report.Identities = append(report.Identities, MigratedIdentity{IdentityIdentifier: id, SKI: ski, Err: err})
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		m.rollback()
+		return report, errors.Errorf("migration of %d of %d identities failed", len(failed), len(report.Identities))
+	}
+	return report, nil
+}
+
+func validateLocations(oldLocation, newLocation StoreLocation) error {
+	for _, path := range []string{oldLocation.CredentialStorePath, oldLocation.KeyStorePath} {
+		if path == "" {
+			return errors.New("old store paths are required")
+		}
+		if _, err := os.Stat(path); err != nil {
+			return errors.Wrap(err, "invalid old store location")
+		}
+	}
+	if newLocation.CredentialStorePath == "" || newLocation.KeyStorePath == "" {
+		return errors.New("new store paths are required")
+	}
+	if filepath.Clean(oldLocation.CredentialStorePath) == filepath.Clean(newLocation.CredentialStorePath) ||
+		filepath.Clean(oldLocation.KeyStorePath) == filepath.Clean(newLocation.KeyStorePath) {
+		return errors.New("new store location must differ from the old location")
+	}
+	return nil
+}
+
+// listUserStore returns the identities of the users stored in the CertFileUserStore at the given path
+func listUserStore(path string) ([]msp.IdentityIdentifier, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read user store")
+	}
+
+	var ids []msp.IdentityIdentifier
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, certFileSuffix) {
+			continue
+		}
+		// File naming is <user>@<mspID>-cert.pem, where the user name may contain '@'
+		name = strings.TrimSuffix(name, certFileSuffix)
+		i := strings.LastIndex(name, "@")
+		if i <= 0 || i == len(name)-1 {
+			logger.Warnf("Skipping user store entry with unexpected name [%s]", file.Name())
+			continue
+		}
+		ids = append(ids, msp.IdentityIdentifier{ID: name[:i], MSPID: name[i+1:]})
+	}
+	return ids, nil
+}
+
+// storeMigration migrates identities between two locations, keeping track of the files
+// written to the new location so that they can be removed upon failure
+type storeMigration struct {
+	dryRun       bool
+	newLocation  StoreLocation
+	oldUserStore msp.UserStore
+	oldKeyStore  bccsp.KeyStore
+	oldSuite     core.CryptoSuite
+	newUserStore msp.UserStore
+	newKeyStore  bccsp.KeyStore
+	newSuite     core.CryptoSuite
+	written      []string
+}
+
+func newStoreMigration(oldLocation, newLocation StoreLocation, options migrationOptions) (*storeMigration, error) {
+	m := &storeMigration{dryRun: options.dryRun, newLocation: newLocation}
+
+	var err error
+	if m.oldUserStore, err = NewCertFileUserStore(oldLocation.CredentialStorePath); err != nil {
+		return nil, err
+	}
+	if m.oldKeyStore, err = bccspsw.NewFileBasedKeyStore(options.oldPassword, oldLocation.KeyStorePath, true); err != nil {
+		return nil, errors.Wrap(err, "failed to open old keystore")
+	}
+	if m.oldSuite, err = sw.GetSuite(256, bccsp.SHA2, m.oldKeyStore); err != nil {
+		return nil, errors.WithMessage(err, "failed to create cryptosuite for old keystore")
+	}
+
+	if m.dryRun {
+		return m, nil
+	}
+
+	if m.newUserStore, err = NewCertFileUserStore(newLocation.CredentialStorePath); err != nil {
+		return nil, err
+	}
+	if m.newKeyStore, err = bccspsw.NewFileBasedKeyStore(options.newPassword, newLocation.KeyStorePath, false); err != nil {
+		return nil, errors.Wrap(err, "failed to open new keystore")
+	}
+	if m.newSuite, err = sw.GetSuite(256, bccsp.SHA2, m.newKeyStore); err != nil {
+		return nil, errors.WithMessage(err, "failed to create cryptosuite for new keystore")
+	}
+	return m, nil
+}
+
+// migrate copies the user entry and private key of the identity and returns the SKI of its key
+func (m *storeMigration) migrate(id msp.IdentityIdentifier) ([]byte, error) {
+	userData, err := m.oldUserStore.Load(id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load user")
+	}
+	pubKey, err := cryptoutil.GetPublicKeyFromCert(userData.EnrollmentCertificate, m.oldSuite)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get public key from enrollment certificate")
+	}
+	ski := pubKey.SKI()
+
+	key, err := m.oldKeyStore.GetKey(ski)
+	if err != nil {
+		return ski, errors.Wrap(err, "failed to load private key")
+	}
+	if !key.Private() {
+		return ski, errors.New("private key not found")
+	}
+
+	if len(userData.TLSCertificate) > 0 {
+		if err := m.migrateTLSKey(userData.TLSCertificate); err != nil {
+			return ski, errors.WithMessage(err, "failed to migrate TLS key")
+		}
+	}
+
+	if m.dryRun {
+		return ski, verifySigning(m.oldSuite, ski, pubKey)
+	}
+
+	keyPath := filepath.Join(m.newLocation.KeyStorePath, hex.EncodeToString(ski)+keyFileSuffix)
+	if err := m.write(keyPath, func() error { return m.newKeyStore.StoreKey(key) }); err != nil {
+		return ski, errors.Wrap(err, "failed to store private key")
+	}
+	certPath := filepath.Join(m.newLocation.CredentialStorePath, storeKeyFromUserIdentifier(id))
+	if err := m.write(certPath, func() error {
+		if err := m.newUserStore.Store(userData); err != nil {
+			return err
+		}
+		if len(userData.TLSCertificate) > 0 {
+			m.written = append(m.written, filepath.Join(m.newLocation.CredentialStorePath, tlsStoreKeyFromUserIdentifier(id)))
+		}
+		return nil
+	}); err != nil {
+		return ski, errors.WithMessage(err, "failed to store user")
+	}
+
+	return ski, verifySigning(m.newSuite, ski, pubKey)
+}
+
+// migrateTLSKey copies the private key of the TLS client certificate of an identity
+func (m *storeMigration) migrateTLSKey(tlsCert []byte) error {
+	pubKey, err := cryptoutil.GetPublicKeyFromCert(tlsCert, m.oldSuite)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get public key from TLS certificate")
+	}
+	ski := pubKey.SKI()
+
+	key, err := m.oldKeyStore.GetKey(ski)
+	if err != nil {
+		return errors.Wrap(err, "failed to load private key")
+	}
+	if !key.Private() {
+		return errors.New("private key not found")
+	}
+	if m.dryRun {
+		return nil
+	}
+
+	keyPath := filepath.Join(m.newLocation.KeyStorePath, hex.EncodeToString(ski)+keyFileSuffix)
+	if err := m.write(keyPath, func() error { return m.newKeyStore.StoreKey(key) }); err != nil {
+		return errors.Wrap(err, "failed to store private key")
+	}
+	return nil
+}
+
+// write stores the file unless it already exists, in which case it's left as is
+func (m *storeMigration) write(path string, store func() error) error {
+	if _, err := os.Stat(path); err == nil {
+		logger.Debugf("Not overwriting existing file [%s]", path)
+		return nil
+	}
+	if err := store(); err != nil {
+		return err
+	}
+	m.written = append(m.written, path)
+	return nil
+}
+
+// rollback removes the files written to the new location
+func (m *storeMigration) rollback() {
+	for i := len(m.written) - 1; i >= 0; i-- {
+		if err := os.Remove(m.written[i]); err != nil {
+			logger.Warnf("Failed to remove [%s] after failed migration: %s", m.written[i], err)
+		}
+	}
+	m.written = nil
+}
+
+// verifySigning checks that the private key with the given SKI can be loaded from the cryptosuite and
+// produces signatures which are valid for the public key
+func verifySigning(cs core.CryptoSuite, ski []byte, pubKey core.Key) error {
+	privKey, err := cs.GetKey(ski)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load private key")
+	}
+
+	digest, err := cs.Hash([]byte("store migration"), cryptosuite.GetSHA256Opts())
+	if err != nil {
+		return errors.WithMessage(err, "failed to hash message")
+	}
+	signature, err := cs.Sign(privKey, digest, nil)
+	if err != nil {
+		return errors.WithMessage(err, "failed to sign")
+	}
+	valid, err := cs.Verify(pubKey, signature, digest, nil)
+	if err != nil {
+		return errors.WithMessage(err, "failed to verify signature")
+	}
+	if !valid {
+		return errors.New("signature of private key doesn't match the enrollment certificate")
+	}
+	return nil
+}
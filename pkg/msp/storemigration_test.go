@@ -0,0 +1,242 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	bccspsw "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateStores(t *testing.T) {
+	dir := newMigrationTestDir(t)
+	defer os.RemoveAll(dir)
+
+	oldLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "old", "msp"), KeyStorePath: filepath.Join(dir, "old", "keystore")}
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "User1@org1.example.com", MSPID: "Org1MSP"}, true)
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "admin", MSPID: "Org1MSP"}, true)
+	oldFiles := readDirFiles(t, filepath.Join(dir, "old"))
+
+	newLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "new", "msp"), KeyStorePath: filepath.Join(dir, "new", "keystore")}
+	report, err := MigrateStores(oldLocation, newLocation, WithKeyStorePasswords(nil, []byte("password")))
+	if err != nil {
+		t.Fatalf("Failed to migrate stores: %s", err)
+	}
+
+	assert.False(t, report.DryRun)
+	if assert.Len(t, report.Identities, 2) {
+		assert.Equal(t, msp.IdentityIdentifier{ID: "User1@org1.example.com", MSPID: "Org1MSP"}, report.Identities[0].IdentityIdentifier)
+		assert.Equal(t, msp.IdentityIdentifier{ID: "admin", MSPID: "Org1MSP"}, report.Identities[1].IdentityIdentifier)
+	}
+	assert.Empty(t, report.Failed())
+
+	for _, identity := range report.Identities {
+		assert.NotEmpty(t, identity.SKI)
+		_, err := os.Stat(filepath.Join(newLocation.CredentialStorePath, storeKeyFromUserIdentifier(identity.IdentityIdentifier)))
+		assert.NoError(t, err, "Expected user entry in new location")
+	}
+
+	keyFiles, err := ioutil.ReadDir(newLocation.KeyStorePath)
+	if err != nil {
+		t.Fatalf("Failed to read new keystore: %s", err)
+	}
+	if assert.Len(t, keyFiles, 2) {
+		raw, err := ioutil.ReadFile(filepath.Join(newLocation.KeyStorePath, keyFiles[0].Name()))
+		assert.NoError(t, err)
+		assert.Contains(t, string(raw), "ENCRYPTED", "Expected keys to be re-encrypted")
+	}
+
+	assert.Equal(t, oldFiles, readDirFiles(t, filepath.Join(dir, "old")), "Expected old location to be untouched")
+}
+
+func TestMigrateStoresWithTLSCertificate(t *testing.T) {
+	dir := newMigrationTestDir(t)
+	defer os.RemoveAll(dir)
+
+	oldLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "old", "msp"), KeyStorePath: filepath.Join(dir, "old", "keystore")}
+	id := msp.IdentityIdentifier{ID: "User1", MSPID: "Org1MSP"}
+	storeTestIdentity(t, oldLocation, id, true)
+	// The TLS cert and key are generated as a separate identity which is then attached to the user
+	tlsID := msp.IdentityIdentifier{ID: "User1-tls", MSPID: "Org1MSP"}
+	storeTestIdentity(t, oldLocation, tlsID, true)
+	userStore, err := NewCertFileUserStore(oldLocation.CredentialStorePath)
+	if err != nil {
+		t.Fatalf("Failed to create user store: %s", err)
+	}
+	tlsUser, err := userStore.Load(tlsID)
+	if err != nil {
+		t.Fatalf("Failed to load user: %s", err)
+	}
+	user, err := userStore.Load(id)
+	if err != nil {
+		t.Fatalf("Failed to load user: %s", err)
+	}
+	user.TLSCertificate = tlsUser.EnrollmentCertificate
+	if err := userStore.Store(user); err != nil {
+		t.Fatalf("Failed to store user: %s", err)
+	}
+	if err := userStore.Delete(tlsID); err != nil {
+		t.Fatalf("Failed to delete user: %s", err)
+	}
+
+	newLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "new", "msp"), KeyStorePath: filepath.Join(dir, "new", "keystore")}
+	report, err := MigrateStores(oldLocation, newLocation)
+	if err != nil {
+		t.Fatalf("Failed to migrate stores: %s", err)
+	}
+	assert.Len(t, report.Identities, 1)
+
+	newUserStore, err := NewCertFileUserStore(newLocation.CredentialStorePath)
+	if err != nil {
+		t.Fatalf("Failed to create user store: %s", err)
+	}
+	migrated, err := newUserStore.Load(id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.TLSCertificate, migrated.TLSCertificate)
+	}
+	keyFiles, err := ioutil.ReadDir(newLocation.KeyStorePath)
+	if err != nil {
+		t.Fatalf("Failed to read new keystore: %s", err)
+	}
+	assert.Len(t, keyFiles, 2, "Expected the key of the TLS cert to be migrated")
+}
+
+func TestMigrateStoresDryRun(t *testing.T) {
+	dir := newMigrationTestDir(t)
+	defer os.RemoveAll(dir)
+
+	oldLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "old", "msp"), KeyStorePath: filepath.Join(dir, "old", "keystore")}
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "User1", MSPID: "Org1MSP"}, true)
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "User2", MSPID: "Org1MSP"}, false)
+
+	newLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "new", "msp"), KeyStorePath: filepath.Join(dir, "new", "keystore")}
+	report, err := MigrateStores(oldLocation, newLocation, WithDryRun())
+	assert.Error(t, err, "Expected error for identity without private key")
+	if assert.NotNil(t, report) && assert.Len(t, report.Identities, 2) {
+		assert.True(t, report.DryRun)
+		assert.NoError(t, report.Identities[0].Err)
+		assert.Error(t, report.Identities[1].Err)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "new"))
+	assert.True(t, os.IsNotExist(err), "Expected nothing to be written in dry-run mode")
+}
+
+func TestMigrateStoresPartialFailure(t *testing.T) {
+	dir := newMigrationTestDir(t)
+	defer os.RemoveAll(dir)
+
+	oldLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "old", "msp"), KeyStorePath: filepath.Join(dir, "old", "keystore")}
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "User1", MSPID: "Org1MSP"}, true)
+	storeTestIdentity(t, oldLocation, msp.IdentityIdentifier{ID: "User2", MSPID: "Org1MSP"}, false)
+	oldFiles := readDirFiles(t, filepath.Join(dir, "old"))
+
+	newLocation := StoreLocation{CredentialStorePath: filepath.Join(dir, "new", "msp"), KeyStorePath: filepath.Join(dir, "new", "keystore")}
+	report, err := MigrateStores(oldLocation, newLocation)
+	assert.Error(t, err)
+	if assert.NotNil(t, report) {
+		assert.Len(t, report.Failed(), 1)
+	}
+
+	assert.Empty(t, readDirFiles(t, filepath.Join(dir, "new")), "Expected migrated files to be removed")
+	assert.Equal(t, oldFiles, readDirFiles(t, filepath.Join(dir, "old")), "Expected old location to be untouched")
+
+	_, err = MigrateStores(oldLocation, oldLocation)
+	assert.Error(t, err, "Expected error migrating to the same location")
+	_, err = MigrateStores(StoreLocation{CredentialStorePath: filepath.Join(dir, "missing"), KeyStorePath: oldLocation.KeyStorePath}, newLocation)
+	assert.Error(t, err, "Expected error for missing old location")
+}
+
+func newMigrationTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "storemigration")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	return dir
+}
+
+// storeTestIdentity stores a user with a self-signed certificate and, if withKey is set, its private key
+func storeTestIdentity(t *testing.T, location StoreLocation, id msp.IdentityIdentifier, withKey bool) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: id.ID},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privKey.PublicKey, privKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+
+	userStore, err := NewCertFileUserStore(location.CredentialStorePath)
+	if err != nil {
+		t.Fatalf("Failed to create user store: %s", err)
+	}
+	err = userStore.Store(&msp.UserData{ID: id.ID, MSPID: id.MSPID, EnrollmentCertificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})})
+	if err != nil {
+		t.Fatalf("Failed to store user: %s", err)
+	}
+
+	keyStore, err := bccspsw.NewFileBasedKeyStore(nil, location.KeyStorePath, false)
+	if err != nil {
+		t.Fatalf("Failed to create keystore: %s", err)
+	}
+	if !withKey {
+		return
+	}
+	suite, err := sw.GetSuite(256, bccsp.SHA2, keyStore)
+	if err != nil {
+		t.Fatalf("Failed to create cryptosuite: %s", err)
+	}
+	rawKey, err := x509.MarshalECPrivateKey(privKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %s", err)
+	}
+	if _, err := suite.KeyImport(rawKey, &bccsp.ECDSAPrivateKeyImportOpts{Temporary: false}); err != nil {
+		t.Fatalf("Failed to import key: %s", err)
+	}
+}
+
+// readDirFiles returns the contents of the files under dir, by relative path
+func readDirFiles(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimPrefix(path, dir)] = string(raw)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", dir, err)
+	}
+	return files
+}
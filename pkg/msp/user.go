@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package msp
 
 import (
+	"crypto/tls"
+
 	"github.com/golang/protobuf/proto"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
@@ -21,6 +23,8 @@ type User struct {
 	mspID                 string
 	enrollmentCertificate []byte
 	privateKey            core.Key
+	tlsCertificate        []byte
+	tlsClientCert         *tls.Certificate
 }
 
 func userIdentifier(userData *msp.UserData) msp.IdentityIdentifier {
@@ -60,6 +64,17 @@ func (u *User) PrivateKey() core.Key {
 	return u.privateKey
 }
 
+// TLSCertificate returns the TLS client certificate of the user in PEM format, or nil if the user has none
+func (u *User) TLSCertificate() []byte {
+	return u.tlsCertificate
+}
+
+// TLSClientCertificate returns the TLS client certificate of the user, or nil if the user has none,
+// in which case the client certificate from the config is used
+func (u *User) TLSClientCertificate() (*tls.Certificate, error) {
+	return u.tlsClientCert, nil
+}
+
 // PublicVersion returns the public parts of this identity
 func (u *User) PublicVersion() msp.Identity {
 	return u
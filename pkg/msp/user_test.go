@@ -8,6 +8,7 @@ package msp
 
 import (
 	"bytes"
+	"encoding/pem"
 	"testing"
 
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
@@ -83,6 +84,32 @@ func TestUserMethods(t *testing.T) {
 	// Check PrivateKey
 	verifyBytes(t, user.PrivateKey().SKI(), generatedKey.SKI())
 
+	// The user doesn't have its own TLS client cert
+	tlsCert, err := user.TLSClientCertificate()
+	if err != nil || tlsCert != nil {
+		t.Fatalf("Expected no TLS client cert, got %v, %v", tlsCert, err)
+	}
+
+	// TLS client cert of the user (sharing the key of the enrollment cert for the test)
+	userData.TLSCertificate = generatedCertBytes
+	user, err = newUser(userData, cryptoSuite)
+	if err != nil {
+		t.Fatalf("newUser with TLS cert failed: %v", err)
+	}
+	verifyBytes(t, user.TLSCertificate(), generatedCertBytes)
+	tlsCert, err = user.TLSClientCertificate()
+	if err != nil || tlsCert == nil {
+		t.Fatalf("Expected TLS client cert, got %v, %v", tlsCert, err)
+	}
+	block, _ := pem.Decode(generatedCertBytes)
+	verifyBytes(t, tlsCert.Certificate[0], block.Bytes)
+
+	// Private key of the TLS client cert is not in the crypto store
+	userData.TLSCertificate = []byte(testCert1)
+	_, err = newUser(userData, cryptoSuite)
+	if err == nil {
+		t.Fatalf("Expected newUser to fail when the private key of the TLS cert is missing")
+	}
 }
 
 func verifyBytes(t *testing.T, v interface{}, expected []byte) error {
@@ -113,6 +113,22 @@ func (c *Cache) MustGet(key Key) interface{} {
 	return value
 }
 
+// Invalidate removes the entry for the given key (if any) so that the
+// next call to Get invokes the initializer to create a new value.
+// The removed value is closed if it implements a Close() function.
+func (c *Cache) Invalidate(key Key) {
+	keyStr := key.String()
+
+	f, ok := c.m.Load(keyStr)
+	if !ok {
+		return
+	}
+
+	logger.Debugf("%s - Invalidating key [%s]", c.name, keyStr)
+	c.m.Delete(keyStr)
+	c.close(keyStr, f.(future))
+}
+
 // Close does the following:
 // - calls Close on all values that implement a Close() function
 // - deletes all entries from the cache
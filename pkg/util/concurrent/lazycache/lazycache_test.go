@@ -188,3 +188,35 @@ func TestClose(t *testing.T) {
 		t.Fatalf("Expecting error since cache is closed")
 	}
 }
+
+func TestInvalidate(t *testing.T) {
+	var initCount int32
+	cache := New("Example_Cache", func(key Key) (interface{}, error) {
+		count := atomic.AddInt32(&initCount, 1)
+		return &closableValue{
+			str: fmt.Sprintf("Value_for_key_%s_%d", key, count),
+		}, nil
+	})
+	defer cache.Close()
+
+	// Invalidating a key which doesn't exist should be fine
+	cache.Invalidate(NewStringKey("Key1"))
+
+	cval := cache.MustGet(NewStringKey("Key1")).(*closableValue)
+	if cval.str != "Value_for_key_Key1_1" {
+		t.Fatalf("Expecting value [%s] but got [%s]", "Value_for_key_Key1_1", cval.str)
+	}
+
+	cache.Invalidate(NewStringKey("Key1"))
+	if !cval.CloseCalled() {
+		t.Fatalf("Expecting close to be called on invalidated value but is wasn't")
+	}
+
+	newCval := cache.MustGet(NewStringKey("Key1")).(*closableValue)
+	if newCval.str != "Value_for_key_Key1_2" {
+		t.Fatalf("Expecting value [%s] but got [%s]", "Value_for_key_Key1_2", newCval.str)
+	}
+	if newCval.CloseCalled() {
+		t.Fatalf("Not expecting close to be called but is was")
+	}
+}